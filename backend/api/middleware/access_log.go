@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/gorilla/mux"
+)
+
+// RequestIDHeader carries the ID of a request, from the client or generated
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs echoed into logs
+const maxRequestIDLength = 128
+
+var requestDuration = metrics.NewHistogram(
+	"http_request_duration_seconds",
+	"Latency of HTTP requests by method and route template",
+	metrics.DefaultBuckets,
+	"method", "route",
+)
+
+var accessLogger = log.New(os.Stdout, "", 0)
+
+// AccessLogConfig controls the access log
+type AccessLogConfig struct {
+	// Enabled writes a JSON line per request; latencies are measured either way
+	Enabled bool
+	// SkipPaths are request paths left out of the log, such as probes
+	SkipPaths []string
+}
+
+// accessLogEntry is one line of the access log. Principal is filled in by
+// Authenticate further down the chain, so it is shared through the context.
+type accessLogEntry struct {
+	Time      string  `json:"time"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Route     string  `json:"route"`
+	Status    int     `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Bytes     int64   `json:"bytes"`
+	RequestID string  `json:"requestId"`
+	Principal string  `json:"principal,omitempty"`
+	Tenant    string  `json:"tenant,omitempty"`
+}
+
+type accessLogKey struct{}
+
+type requestIDKey struct{}
+
+// AccessLog wraps the router to assign each request an ID, record its latency
+// by route template and, when enabled, log it as a JSON line. It wraps the
+// router rather than being registered with Use so requests that match no
+// route are logged too.
+func AccessLog(router *mux.Router, config AccessLogConfig) http.Handler {
+	skip := make(map[string]bool, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		if path = strings.TrimSpace(path); path != "" {
+			skip[path] = true
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := requestIDOf(r)
+		w.Header().Set(RequestIDHeader, requestID)
+
+		entry := &accessLogEntry{
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Route:     routeOf(router, r),
+			RequestID: requestID,
+		}
+		ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+		ctx = context.WithValue(ctx, accessLogKey{}, entry)
+
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		router.ServeHTTP(rw, r.WithContext(ctx))
+
+		elapsed := time.Since(start)
+		requestDuration.Observe(elapsed.Seconds(), r.Method, entry.Route)
+		if !config.Enabled || skip[r.URL.Path] {
+			return
+		}
+
+		entry.Time = start.UTC().Format(time.RFC3339Nano)
+		entry.Status = rw.status
+		entry.LatencyMs = float64(elapsed.Microseconds()) / 1000
+		entry.Bytes = rw.bytes
+		line, err := json.Marshal(entry)
+		if err != nil {
+			log.Printf("Failed to encode access log entry: %v", err)
+			return
+		}
+		accessLogger.Println(string(line))
+	})
+}
+
+// RequestID returns the ID of the request being served, or "" outside AccessLog
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// withPrincipal stores the authenticated principal in the context and on the
+// access log entry of the request
+func withPrincipal(ctx context.Context, principal *auth.Principal) context.Context {
+	if entry, ok := ctx.Value(accessLogKey{}).(*accessLogEntry); ok && principal != nil {
+		entry.Principal = principal.Subject
+		entry.Tenant = principal.Tenant
+	}
+	return auth.WithPrincipal(ctx, principal)
+}
+
+// requestIDOf returns the client's request ID when it is safe to log, and a
+// random one otherwise
+func requestIDOf(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" && len(id) <= maxRequestIDLength && printable(id) {
+		return id
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return strings.ReplaceAll(time.Now().UTC().Format("20060102150405.000000000"), ".", "")
+	}
+	return hex.EncodeToString(buf)
+}
+
+func printable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// routeOf returns the template of the route matching the request, so latencies
+// are grouped by endpoint rather than by job ID
+func routeOf(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if !router.Match(r, &match) || match.Route == nil {
+		return "unmatched"
+	}
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return "unmatched"
+	}
+	return template
+}
+
+// statusRecorder records the status and body size of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(statusCode int) {
+	if !s.wroteHeader {
+		s.wroteHeader = true
+		s.status = statusCode
+	}
+	s.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (s *statusRecorder) Write(data []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(data)
+	s.bytes += int64(n)
+	return n, err
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// apiKeyHeader carries an API key for clients that cannot set Authorization
+const apiKeyHeader = "X-API-Key"
+
+// Authenticate resolves the credential of a request to its principal. API keys
+// are accepted in an Authorization: Bearer or X-API-Key header, and, when
+// tokens is set, OIDC bearer tokens of dashboard users in the Authorization
+// header. Requests are refused when the credential is invalid or revoked,
+// lacks the scope the route needs, or an API key is over its rate limit.
+// Requests without a credential are refused if required is set, or if they
+// reach the admin or API key endpoints, and pass through anonymously otherwise.
+// Every request is scoped to the jobs of the caller's tenant.
+func Authenticate(keys services.APIKeysService, tokens *auth.OIDCVerifier, required bool) mux.MiddlewareFunc {
+	limiter := newKeyRateLimiter()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			credential := requestCredential(r)
+			if credential == "" {
+				if required || !anonymousAllowed(r) {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+					shared.RespondErrorMessage(w, http.StatusUnauthorized, "an API key or bearer token is required")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(services.ScopeToCaller(r.Context())))
+				return
+			}
+
+			scope := requiredScope(r)
+
+			if tokens != nil && auth.LooksLikeToken(credential) {
+				principal, err := tokens.Verify(r.Context(), credential)
+				if errors.Is(err, auth.ErrInvalidToken) {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					shared.RespondErrorMessage(w, http.StatusUnauthorized, err.Error())
+					return
+				}
+				if err != nil {
+					log.Printf("Failed to verify bearer token: %v", err)
+					shared.RespondErrorMessage(w, http.StatusInternalServerError, "failed to verify bearer token")
+					return
+				}
+
+				principal.Scopes = roleScopes(principal)
+				if !grantsScope(principal.Scopes, scope) {
+					shared.RespondErrorMessage(w, http.StatusForbidden, "user lacks the "+scope+" scope")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(services.ScopeToCaller(withPrincipal(r.Context(), principal))))
+				return
+			}
+
+			key, err := keys.Authenticate(r.Context(), credential)
+			if errors.Is(err, services.ErrInvalidAPIKey) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				shared.RespondErrorMessage(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			if err != nil {
+				log.Printf("Failed to authenticate API key: %v", err)
+				shared.RespondErrorMessage(w, http.StatusInternalServerError, "failed to authenticate API key")
+				return
+			}
+
+			if !key.HasScope(scope) {
+				shared.RespondErrorMessage(w, http.StatusForbidden, "API key lacks the "+scope+" scope")
+				return
+			}
+
+			if retryAfter, ok := limiter.allow(key, time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+				shared.RespondErrorMessage(w, http.StatusTooManyRequests, "API key rate limit exceeded")
+				return
+			}
+
+			principal := &auth.Principal{
+				Subject: "apikey:" + key.Prefix,
+				Tenant:  key.Tenant,
+				Scopes:  key.Scopes,
+			}
+			if key.HasScope(models.ScopeAdmin) {
+				principal.Roles = []string{auth.RoleAdmin}
+			}
+			next.ServeHTTP(w, r.WithContext(services.ScopeToCaller(withPrincipal(r.Context(), principal))))
+		})
+	}
+}
+
+// roleScopes returns the scopes of a dashboard user: admins may do everything,
+// and other users may read and change their tenant's jobs
+func roleScopes(principal *auth.Principal) []string {
+	if principal.HasRole(auth.RoleAdmin) {
+		return []string{models.ScopeAdmin}
+	}
+	return []string{models.ScopeJobsRead, models.ScopeJobsWrite}
+}
+
+// grantsScope reports whether scopes include scope; the admin scope grants every scope
+func grantsScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope || granted == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// requestCredential returns the API key or bearer token a request was made with, or an empty string
+func requestCredential(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, token, ok := strings.Cut(header, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get(apiKeyHeader))
+}
+
+// requiredScope returns the scope a request needs: admin for the operational
+// endpoints, and jobs:read or jobs:write by method for the rest. Routes are
+// told apart by their template, so an ID such as "admin" in a job's path does
+// not make it an operational endpoint.
+func requiredScope(r *http.Request) string {
+	if hasSegment(routeTemplate(r), "admin") {
+		return models.ScopeAdmin
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return models.ScopeJobsRead
+	}
+	return models.ScopeJobsWrite
+}
+
+// anonymousAllowed reports whether a request may be made without a credential
+// while authentication is optional. The admin endpoints and API key management
+// always need one, so anonymous callers cannot take over the deployment or
+// mint keys.
+func anonymousAllowed(r *http.Request) bool {
+	if requiredScope(r) == models.ScopeAdmin {
+		return false
+	}
+	return !hasSegment(routeTemplate(r), "apikeys")
+}
+
+// hasSegment reports whether a route template has a literal path segment
+func hasSegment(template, segment string) bool {
+	for _, s := range strings.Split(template, "/") {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
+
+// keyRateLimiter counts the requests made with each API key in fixed one-minute
+// windows. Counts are kept per instance, so a key's effective limit scales with
+// the number of backend replicas.
+type keyRateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	swept   time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newKeyRateLimiter() *keyRateLimiter {
+	return &keyRateLimiter{windows: make(map[string]*rateWindow)}
+}
+
+// allow records a request made with key at now, and reports whether it is
+// within the key's limit, or how long until the next window if it is not
+func (l *keyRateLimiter) allow(key *models.APIKey, now time.Time) (time.Duration, bool) {
+	if key.RateLimitPerMinute <= 0 {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	start := now.Truncate(time.Minute)
+	window, ok := l.windows[key.Prefix]
+	if !ok || !window.start.Equal(start) {
+		l.sweep(start)
+		window = &rateWindow{start: start}
+		l.windows[key.Prefix] = window
+	}
+	if window.count >= key.RateLimitPerMinute {
+		return start.Add(time.Minute).Sub(now), false
+	}
+	window.count++
+	return 0, true
+}
+
+// sweep drops the windows that ended before start, once per window, so keys
+// that stop being used are forgotten
+func (l *keyRateLimiter) sweep(start time.Time) {
+	if !l.swept.Before(start) {
+		return
+	}
+	l.swept = start
+	for prefix, window := range l.windows {
+		if window.start.Before(start) {
+			delete(l.windows, prefix)
+		}
+	}
+}
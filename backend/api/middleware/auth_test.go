@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// fakeAPIKeys authenticates the keys it holds by their secret
+type fakeAPIKeys struct {
+	services.APIKeysService
+	keys map[string]*models.APIKey
+}
+
+func (f *fakeAPIKeys) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	if key, ok := f.keys[secret]; ok {
+		return key, nil
+	}
+	return nil, services.ErrInvalidAPIKey
+}
+
+// newAuthRouter routes the given templates behind Authenticate, as main does
+// under /api/v1, answering 200 from every route
+func newAuthRouter(keys services.APIKeysService, required bool, routes map[string]string) *mux.Router {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(Authenticate(keys, nil, required))
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	for template, method := range routes {
+		api.HandleFunc(template, ok).Methods(method)
+	}
+	return router
+}
+
+var authTestRoutes = map[string]string{
+	"/jobs":                 http.MethodPost,
+	"/jobs/{id}":            http.MethodGet,
+	"/jobs/{id}/cancel":     http.MethodPut,
+	"/admin/config":         http.MethodGet,
+	"/admin/alerts/{id}":    http.MethodDelete,
+	"/admin/jobs/requeue":   http.MethodPost,
+	"/apikeys":              http.MethodGet,
+	"/views/{name}":         http.MethodGet,
+	"/groups/{id}/jobs":     http.MethodPost,
+	"/notifications":        http.MethodGet,
+	"/jobs/{id}/comments":   http.MethodGet,
+	"/jobs/{id}/logs/admin": http.MethodGet,
+}
+
+func TestAuthenticateRequiredScope(t *testing.T) {
+	keys := &fakeAPIKeys{keys: map[string]*models.APIKey{
+		"reader": {Prefix: "jp_reader", Tenant: "acme", Scopes: []string{models.ScopeJobsRead}},
+		"writer": {Prefix: "jp_writer", Tenant: "acme", Scopes: []string{models.ScopeJobsRead, models.ScopeJobsWrite}},
+		"admin":  {Prefix: "jp_admin", Tenant: "acme", Scopes: []string{models.ScopeAdmin}},
+	}}
+	router := newAuthRouter(keys, true, authTestRoutes)
+
+	tests := []struct {
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{http.MethodGet, "/api/v1/jobs/abc", "reader", http.StatusOK},
+		{http.MethodPost, "/api/v1/jobs", "reader", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/jobs", "writer", http.StatusOK},
+		{http.MethodGet, "/api/v1/admin/config", "writer", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/admin/config", "admin", http.StatusOK},
+		{http.MethodDelete, "/api/v1/admin/alerts/abc", "writer", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/admin/jobs/requeue", "writer", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/admin/jobs/requeue", "admin", http.StatusOK},
+		// IDs named "admin" are not operational endpoints
+		{http.MethodGet, "/api/v1/jobs/admin", "reader", http.StatusOK},
+		{http.MethodPut, "/api/v1/jobs/admin/cancel", "writer", http.StatusOK},
+		{http.MethodGet, "/api/v1/views/admin", "reader", http.StatusOK},
+		{http.MethodPost, "/api/v1/groups/admin/jobs", "writer", http.StatusOK},
+		// A literal admin segment anywhere in the template is
+		{http.MethodGet, "/api/v1/jobs/abc/logs/admin", "reader", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/jobs/abc", "unknown", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path+" as "+tt.key, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.key)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateAnonymous(t *testing.T) {
+	router := newAuthRouter(&fakeAPIKeys{}, false, authTestRoutes)
+
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{http.MethodGet, "/api/v1/jobs/abc", http.StatusOK},
+		{http.MethodPost, "/api/v1/jobs", http.StatusOK},
+		{http.MethodGet, "/api/v1/jobs/admin", http.StatusOK},
+		{http.MethodGet, "/api/v1/views/apikeys", http.StatusOK},
+		{http.MethodGet, "/api/v1/admin/config", http.StatusUnauthorized},
+		{http.MethodPost, "/api/v1/admin/jobs/requeue", http.StatusUnauthorized},
+		{http.MethodGet, "/api/v1/apikeys", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRequired(t *testing.T) {
+	router := newAuthRouter(&fakeAPIKeys{}, true, authTestRoutes)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/abc", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthenticateRateLimit(t *testing.T) {
+	keys := &fakeAPIKeys{keys: map[string]*models.APIKey{
+		"limited": {Prefix: "jp_limited", Scopes: []string{models.ScopeJobsRead}, RateLimitPerMinute: 2},
+	}}
+	router := newAuthRouter(keys, true, authTestRoutes)
+
+	var codes []int
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/abc", nil)
+		req.Header.Set(apiKeyHeader, "limited")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		codes = append(codes, rec.Code)
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Error("rate limited response has no Retry-After header")
+		}
+	}
+	// The limit may straddle a minute boundary, which resets the window
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK {
+		t.Fatalf("statuses = %v, want the first two allowed", codes)
+	}
+}
+
+func TestKeyRateLimiterAllow(t *testing.T) {
+	limited := &models.APIKey{Prefix: "jp_limited", RateLimitPerMinute: 2}
+	other := &models.APIKey{Prefix: "jp_other", RateLimitPerMinute: 2}
+	unlimited := &models.APIKey{Prefix: "jp_unlimited"}
+
+	limiter := newKeyRateLimiter()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if _, ok := limiter.allow(limited, start.Add(time.Duration(i)*time.Second)); !ok {
+			t.Fatalf("request %d refused within the limit", i+1)
+		}
+	}
+	retryAfter, ok := limiter.allow(limited, start.Add(15*time.Second))
+	if ok {
+		t.Fatal("request over the limit allowed")
+	}
+	if retryAfter != 45*time.Second {
+		t.Errorf("retryAfter = %s, want the 45s left in the window", retryAfter)
+	}
+
+	// Limits are counted per key prefix
+	if _, ok := limiter.allow(other, start.Add(20*time.Second)); !ok {
+		t.Error("another key was limited by the first key's requests")
+	}
+	for i := 0; i < 100; i++ {
+		if _, ok := limiter.allow(unlimited, start); !ok {
+			t.Fatal("key without a limit was refused")
+		}
+	}
+
+	// The next window starts a new count, and the ended windows are forgotten
+	if _, ok := limiter.allow(limited, start.Add(time.Minute)); !ok {
+		t.Error("request in the next window refused")
+	}
+	if _, ok := limiter.windows["jp_other"]; ok {
+		t.Error("window of a key unused in the current window was not swept")
+	}
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/gorilla/mux"
+)
+
+// snakeCaseProfile is the Accept-Profile value requesting snake_case field names
+const snakeCaseProfile = "snake_case"
+
+// opaqueFields hold caller-defined keys, which are returned as stored
+var opaqueFields = map[string]bool{
+	"config": true,
+	"state":  true,
+	"result": true,
+}
+
+// FieldCase renders the field names of JSON responses and server-sent event
+// data in snake_case for clients that ask for it with ?case=snake or an
+// Accept-Profile: snake_case header. Responses use camelCase otherwise. The
+// strong ETag of a snake_case response is tagged with the snake variant.
+func FieldCase() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Profile")
+
+			snake, ok := wantsSnakeCase(r)
+			if !ok {
+				shared.RespondErrorMessage(w, http.StatusBadRequest, "case must be one of camel, snake")
+				return
+			}
+			if !snake {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &fieldCaseWriter{ResponseWriter: w}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// wantsSnakeCase reports whether the request asks for snake_case field names,
+// and false as its second result if the case parameter is not recognized
+func wantsSnakeCase(r *http.Request) (bool, bool) {
+	switch r.URL.Query().Get("case") {
+	case "snake":
+		return true, true
+	case "camel":
+		return false, true
+	case "":
+	default:
+		return false, false
+	}
+
+	for _, profile := range strings.Split(r.Header.Get("Accept-Profile"), ",") {
+		if strings.Trim(strings.TrimSpace(profile), `"`) == snakeCaseProfile {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// fieldCaseWriter buffers JSON responses to rename their fields once complete,
+// and renames the fields of each server-sent event as it is written
+type fieldCaseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buffered    bool
+	streaming   bool
+	body        bytes.Buffer
+}
+
+func (c *fieldCaseWriter) WriteHeader(statusCode int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+	c.status = statusCode
+	if etag := c.Header().Get("ETag"); etag != "" {
+		c.Header().Set("ETag", shared.ETagVariant(etag, "snake"))
+	}
+
+	contentType := c.Header().Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "application/json"):
+		// The renamed body has a different length; the header is sent with it
+		c.buffered = true
+		c.Header().Del("Content-Length")
+		return
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		c.streaming = true
+	}
+	c.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (c *fieldCaseWriter) Write(data []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	switch {
+	case c.buffered:
+		return c.body.Write(data)
+	case c.streaming:
+		// Handlers write whole events, so each data line holds a complete JSON value
+		if _, err := c.ResponseWriter.Write(snakeCaseEvents(data)); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	default:
+		return c.ResponseWriter.Write(data)
+	}
+}
+
+// Flush sends streamed events to the client; buffered JSON is sent on Close
+func (c *fieldCaseWriter) Flush() {
+	if c.buffered {
+		return
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close renames the fields of a buffered JSON response and sends it
+func (c *fieldCaseWriter) Close() {
+	if !c.buffered {
+		return
+	}
+	body := c.body.Bytes()
+	if renamed, err := snakeCaseJSON(body); err == nil {
+		body = renamed
+	}
+	c.ResponseWriter.WriteHeader(c.status)
+	c.ResponseWriter.Write(body)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (c *fieldCaseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// snakeCaseEvents renames the fields of the JSON data lines of server-sent events
+func snakeCaseEvents(data []byte) []byte {
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		value, ok := bytes.CutPrefix(line, []byte("data: "))
+		if !ok {
+			continue
+		}
+		if renamed, err := snakeCaseJSON(value); err == nil {
+			lines[i] = append([]byte("data: "), bytes.TrimSuffix(renamed, []byte("\n"))...)
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// snakeCaseJSON renames the object keys of a JSON document to snake_case
+func snakeCaseJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := json.NewEncoder(&out).Encode(snakeCaseKeys(value)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func snakeCaseKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if !opaqueFields[key] {
+				field = snakeCaseKeys(field)
+			}
+			renamed[snakeCase(key)] = field
+		}
+		return renamed
+	case []interface{}:
+		for i, item := range v {
+			v[i] = snakeCaseKeys(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// snakeCase converts a camelCase name to snake_case, keeping acronyms together,
+// e.g. sloMs to slo_ms and parentID to parent_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) && runes[i-1] != '_' {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
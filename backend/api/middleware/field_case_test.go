@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"id", "id"},
+		{"jobType", "job_type"},
+		{"sloMs", "slo_ms"},
+		{"jobID", "job_id"},
+		{"parentID", "parent_id"},
+		{"configURL", "config_url"},
+		{"URLPath", "url_path"},
+		{"HTTPStatusCode", "http_status_code"},
+		{"ID", "id"},
+		{"already_snake", "already_snake"},
+		{"retry_Count", "retry_count"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snakeCase(tt.name); got != tt.want {
+				t.Errorf("snakeCase(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnakeCaseJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "nested objects and arrays",
+			in:   `{"jobID":"abc","retryPolicy":{"maxRetries":3},"attempts":[{"workerID":"w1"}]}`,
+			want: `{"job_id":"abc","retry_policy":{"max_retries":3},"attempts":[{"worker_id":"w1"}]}`,
+		},
+		{
+			name: "opaque fields keep their keys",
+			in:   `{"jobType":"export","config":{"targetURL":"s3://x"},"state":{"lastPage":2},"result":{"rowCount":10}}`,
+			want: `{"job_type":"export","config":{"targetURL":"s3://x"},"state":{"lastPage":2},"result":{"rowCount":10}}`,
+		},
+		{
+			name: "large numbers are kept exact",
+			in:   `{"sizeBytes":9007199254740993}`,
+			want: `{"size_bytes":9007199254740993}`,
+		},
+		{name: "array of scalars", in: `["aB",1]`, want: `["aB",1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snakeCaseJSON([]byte(tt.in))
+			if err != nil {
+				t.Fatalf("snakeCaseJSON() error = %v", err)
+			}
+			assertJSONEqual(t, string(got), tt.want)
+		})
+	}
+}
+
+func assertJSONEqual(t *testing.T, got, want string) {
+	t.Helper()
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal([]byte(got), &gotValue); err != nil {
+		t.Fatalf("invalid JSON %q: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("invalid JSON %q: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
+
+func TestFieldCase(t *testing.T) {
+	handler := FieldCase()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: status\ndata: {\"jobID\":\"abc\",\"config\":{\"batchSize\":5}}\n\n")
+			fmt.Fprint(w, ": keepalive\n\n")
+			fmt.Fprint(w, "data: not json\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "42")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"jobID":"abc","createdAt":"2026-01-01T00:00:00Z"}`)
+	}))
+
+	serve := func(target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("JSON in snake_case", func(t *testing.T) {
+		rec := serve("/jobs?case=snake", nil)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+		}
+		if length := rec.Header().Get("Content-Length"); length != "" {
+			t.Errorf("Content-Length = %s of the camelCase body was kept", length)
+		}
+		assertJSONEqual(t, rec.Body.String(), `{"job_id":"abc","created_at":"2026-01-01T00:00:00Z"}`)
+	})
+
+	t.Run("JSON in snake_case by profile", func(t *testing.T) {
+		rec := serve("/jobs", http.Header{"Accept-Profile": {`"snake_case"`}})
+		assertJSONEqual(t, rec.Body.String(), `{"job_id":"abc","created_at":"2026-01-01T00:00:00Z"}`)
+	})
+
+	t.Run("JSON in camelCase", func(t *testing.T) {
+		rec := serve("/jobs?case=camel", http.Header{"Accept-Profile": {"snake_case"}})
+		if got := rec.Body.String(); got != `{"jobID":"abc","createdAt":"2026-01-01T00:00:00Z"}` {
+			t.Errorf("body = %s, want it unchanged", got)
+		}
+	})
+
+	t.Run("events in snake_case", func(t *testing.T) {
+		rec := serve("/events?case=snake", nil)
+		want := "event: status\ndata: {\"config\":{\"batchSize\":5},\"job_id\":\"abc\"}\n\n" +
+			": keepalive\n\n" +
+			"data: not json\n\n"
+		if got := rec.Body.String(); got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("unknown case", func(t *testing.T) {
+		if rec := serve("/jobs?case=kebab", nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+}
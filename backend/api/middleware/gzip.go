@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/gorilla/mux"
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// Gzip compresses response bodies for clients that accept gzip encoding.
+// Streaming responses (text/event-stream) and bodiless responses are passed through untouched.
+// Compressed responses, and the 304s standing in for them, have their strong
+// ETag tagged with the gzip variant.
+func Gzip() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			if !acceptsGzip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w}
+			defer gw.Close()
+
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.SplitN(encoding, ";", 2)[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipResponseWriter lazily decides whether to compress once the status and
+// headers are known, and only compresses once there is a body: a response
+// written without one is sent as is, since an empty body is not a gzip stream
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer      *gzip.Writer
+	status      int
+	wroteHeader bool
+	sentHeader  bool
+	passthrough bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(statusCode int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+	g.status = statusCode
+
+	header := g.Header()
+	if statusCode == http.StatusNotModified {
+		g.tagETag()
+	}
+	if statusCode == http.StatusNoContent ||
+		statusCode == http.StatusNotModified ||
+		statusCode < http.StatusOK ||
+		header.Get("Content-Encoding") != "" ||
+		strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+		g.passthrough = true
+		g.sendHeader()
+	}
+}
+
+func (g *gzipResponseWriter) Write(data []byte) (int, error) {
+	if !g.wroteHeader {
+		if g.Header().Get("Content-Type") == "" {
+			g.Header().Set("Content-Type", http.DetectContentType(data))
+		}
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.passthrough {
+		return g.ResponseWriter.Write(data)
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if g.writer == nil {
+		header := g.Header()
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		g.tagETag()
+		g.sendHeader()
+		g.writer = gzipWriterPool.Get().(*gzip.Writer)
+		g.writer.Reset(g.ResponseWriter)
+	}
+	return g.writer.Write(data)
+}
+
+// tagETag marks the response's ETag as that of the gzip-encoded representation
+func (g *gzipResponseWriter) tagETag() {
+	if etag := g.Header().Get("ETag"); etag != "" {
+		g.Header().Set("ETag", shared.ETagVariant(etag, "gzip"))
+	}
+}
+
+// sendHeader writes the status and headers to the client once
+func (g *gzipResponseWriter) sendHeader() {
+	if g.sentHeader {
+		return
+	}
+	g.sentHeader = true
+	g.ResponseWriter.WriteHeader(g.status)
+}
+
+// Flush flushes buffered compressed data to the client. Flushed before any
+// body was written, the response is sent uncompressed.
+func (g *gzipResponseWriter) Flush() {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.writer == nil {
+		g.passthrough = true
+		g.sendHeader()
+	}
+	if g.writer != nil {
+		g.writer.Flush()
+	}
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close finishes the gzip stream and returns the writer to the pool, or sends
+// the headers of a response written without a body
+func (g *gzipResponseWriter) Close() {
+	if g.writer == nil {
+		if g.wroteHeader {
+			g.sendHeader()
+		}
+		return
+	}
+	g.writer.Close()
+	gzipWriterPool.Put(g.writer)
+	g.writer = nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
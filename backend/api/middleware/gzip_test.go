@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGzip(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantGzip   bool
+		wantBody   string
+		wantETag   string
+	}{
+		{
+			name: "body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"id":"abc"}`))
+			},
+			wantStatus: http.StatusOK,
+			wantGzip:   true,
+			wantBody:   `{"id":"abc"}`,
+		},
+		{
+			name: "body with an ETag",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", `"abc-1"`)
+				w.Write([]byte(`{"id":"abc"}`))
+			},
+			wantStatus: http.StatusOK,
+			wantGzip:   true,
+			wantBody:   `{"id":"abc"}`,
+			wantETag:   `"abc-1+gzip"`,
+		},
+		{
+			name: "not modified",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"abc-1"`)
+				w.WriteHeader(http.StatusNotModified)
+			},
+			wantStatus: http.StatusNotModified,
+			wantETag:   `"abc-1+gzip"`,
+		},
+		{
+			name: "weak ETag",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", `W/"abc-1"`)
+				w.Write([]byte(`{"id":"abc"}`))
+			},
+			wantStatus: http.StatusOK,
+			wantGzip:   true,
+			wantBody:   `{"id":"abc"}`,
+			wantETag:   `W/"abc-1"`,
+		},
+		{
+			name:       "no content",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "status without a body",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusAccepted) },
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name: "empty write",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				w.Write(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "event stream",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte("data: {}\n\n"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "data: {}\n\n",
+		},
+		{
+			name: "flushed before the body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/x-ndjson")
+				w.(http.Flusher).Flush()
+				w.Write([]byte("{}\n"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "{}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil)
+			req.Header.Set("Accept-Encoding", "gzip, deflate")
+			rec := httptest.NewRecorder()
+			Gzip()(tt.handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			gzipped := rec.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("gzip encoded = %v, want %v", gzipped, tt.wantGzip)
+			}
+			body := rec.Body.String()
+			if gzipped {
+				reader, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("body is not gzip: %v", err)
+				}
+				decoded, err := io.ReadAll(reader)
+				if err != nil {
+					t.Fatalf("body is not gzip: %v", err)
+				}
+				body = string(decoded)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if etag := rec.Header().Get("ETag"); etag != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", etag, tt.wantETag)
+			}
+		})
+	}
+}
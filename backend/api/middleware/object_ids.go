@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ObjectIDParams rejects requests whose named path parameters are not ObjectIDs,
+// 24-character hex strings, with 400 Bad Request before they reach a handler.
+// Routes without a parameter are passed through, so it can be used on routers
+// where only some routes take an ID.
+func ObjectIDParams(names ...string) mux.MiddlewareFunc {
+	return idParams(names, primitive.IsValidObjectID, " must be a 24-character hex ObjectID")
+}
+
+// JobIDParams is ObjectIDParams for job IDs, which are ObjectIDs or UUIDv7s
+// depending on the ID strategy they were created under
+func JobIDParams(names ...string) mux.MiddlewareFunc {
+	return idParams(names, func(value string) bool {
+		_, err := models.ParseJobID(value)
+		return err == nil
+	}, " must be an ObjectID or a UUIDv7")
+}
+
+func idParams(names []string, valid func(string) bool, message string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			for _, name := range names {
+				value, ok := vars[name]
+				if ok && !valid(value) {
+					shared.RespondErrorMessage(w, http.StatusBadRequest, name+message)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/gorilla/mux"
+)
+
+// timeoutWriteMargin is added to a route's timeout for writing the response
+const timeoutWriteMargin = 5 * time.Second
+
+var requestTimeouts = metrics.NewCounter(
+	"http_request_timeouts_total",
+	"Requests answered with 504 because they ran out of time, by method and route template",
+	"method", "route",
+)
+
+// TimeoutConfig sets how long each route may take
+type TimeoutConfig struct {
+	// Default bounds reads, lists and single-resource writes
+	Default time.Duration
+	// Bulk bounds routes acting on many jobs at once and exports
+	Bulk time.Duration
+	// Routes overrides the timeout by "METHOD /route/template", e.g.
+	// "GET /api/v1/jobs/search"; zero leaves the route unbounded
+	Routes map[string]time.Duration
+}
+
+// bulkRoutes are the routes given the bulk timeout
+var bulkRoutes = map[string]bool{
+	"POST /api/v1/groups/{id}/jobs":              true,
+	"POST /api/v1/groups/{id}/jobs/bulk":         true,
+	"POST /api/v1/groups/{id}/cancel":            true,
+	"POST /api/v1/jobs/{id}/input":               true,
+	"POST /api/v1/admin/jobs/requeue":            true,
+	"POST /api/v1/admin/jobs/cancelling/resolve": true,
+	"GET /api/v1/admin/usage":                    true,
+	"GET /api/v1/admin/retention/report":         true,
+}
+
+// streamRoutes are never bounded: they stream until the client leaves, or
+// bound themselves, as the wait endpoint does with its timeout parameter
+var streamRoutes = map[string]bool{
+	"GET /api/v1/jobs/events":        true,
+	"GET /api/v1/jobs/{id}/wait":     true,
+	"GET /api/v1/jobs/{id}/download": true,
+}
+
+// Timeout bounds each request by the timeout of its route. The request context
+// carries the deadline, so repository calls stop waiting on a slow MongoDB,
+// and a handler still running when it expires is answered with a 504 while
+// its late response is discarded. Streams, including requests for server-sent
+// events, are left unbounded.
+func Timeout(config TimeoutConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeKey(r)
+			timeout := config.timeoutOf(r, route)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Routes bounded beyond the server's write timeout still get to answer
+			http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + timeoutWriteMargin))
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{header: make(http.Header), status: http.StatusOK}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.flushTo(w)
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+				// A client that went away is not answered
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					requestTimeouts.Inc(r.Method, strings.TrimPrefix(route, r.Method+" "))
+					shared.RespondErrorMessage(w, http.StatusGatewayTimeout, "request timed out")
+				}
+			}
+		})
+	}
+}
+
+// timeoutOf returns the timeout of the request to route, or zero if it is unbounded
+func (c TimeoutConfig) timeoutOf(r *http.Request, route string) time.Duration {
+	if timeout, ok := c.Routes[route]; ok {
+		return timeout
+	}
+	if streamRoutes[route] || isEventStream(r) {
+		return 0
+	}
+	if bulkRoutes[route] {
+		return c.Bulk
+	}
+	return c.Default
+}
+
+// isEventStream reports whether the client asked for server-sent events, as
+// followed logs and requeue progress are sent
+func isEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") || r.URL.Query().Get("follow") == "true"
+}
+
+// routeKey returns the method and path template of the matched route
+func routeKey(r *http.Request) string {
+	return r.Method + " " + routeTemplate(r)
+}
+
+// routeTemplate returns the path template of the matched route, or the path
+// when no route matched
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// timeoutWriter buffers a response until the handler returns, so nothing is
+// written once the request has been answered with a 504
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (t *timeoutWriter) Header() http.Header {
+	return t.header
+}
+
+func (t *timeoutWriter) WriteHeader(statusCode int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timedOut || t.wroteHeader {
+		return
+	}
+	t.wroteHeader = true
+	t.status = statusCode
+}
+
+func (t *timeoutWriter) Write(data []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	t.wroteHeader = true
+	return t.buf.Write(data)
+}
+
+// flushTo writes the buffered response to w
+func (t *timeoutWriter) flushTo(w http.ResponseWriter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	header := w.Header()
+	for key, values := range t.header {
+		header[key] = values
+	}
+	w.WriteHeader(t.status)
+	w.Write(t.buf.Bytes())
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// newTimeoutRouter routes handler under /api/v1 behind Timeout, as main does
+func newTimeoutRouter(config TimeoutConfig, handler http.HandlerFunc) *mux.Router {
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(Timeout(config))
+	api.HandleFunc("/jobs/events", handler).Methods(http.MethodGet)
+	api.HandleFunc("/jobs/{id}", handler).Methods(http.MethodGet)
+	api.HandleFunc("/jobs/{id}/logs", handler).Methods(http.MethodGet)
+	api.HandleFunc("/groups/{id}/jobs", handler).Methods(http.MethodPost)
+	return router
+}
+
+func TestTimeoutAnswersInTime(t *testing.T) {
+	router := newTimeoutRouter(TimeoutConfig{Default: time.Second}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id":"abc"}`)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/abc", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != `{"id":"abc"}` {
+		t.Errorf("response = %d %s, want the handler's", rec.Code, rec.Body)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want the handler's", contentType)
+	}
+}
+
+func TestTimeoutOutlivedDeadline(t *testing.T) {
+	lateWrite := make(chan error, 1)
+	router := newTimeoutRouter(TimeoutConfig{Default: 20 * time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Partial", "yes")
+		fmt.Fprint(w, "partial")
+		<-r.Context().Done()
+		// Give the middleware time to answer before writing again
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		_, err := fmt.Fprint(w, "late")
+		lateWrite <- err
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/abc", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+	if rec.Header().Get("X-Partial") != "" {
+		t.Error("headers of the timed out handler were sent")
+	}
+
+	select {
+	case err := <-lateWrite:
+		if !errors.Is(err, http.ErrHandlerTimeout) {
+			t.Errorf("late Write() error = %v, want http.ErrHandlerTimeout", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler did not return after its deadline")
+	}
+	if body := rec.Body.String(); body == "" || body == "partial" || body == "late" {
+		t.Errorf("body = %q, want only the timeout error", body)
+	}
+}
+
+func TestTimeoutReraisesPanic(t *testing.T) {
+	router := newTimeoutRouter(TimeoutConfig{Default: time.Second}, func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failed")
+	})
+
+	defer func() {
+		if p := recover(); p != "handler failed" {
+			t.Errorf("recovered %v, want the handler's panic", p)
+		}
+	}()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/jobs/abc", nil))
+	t.Error("the handler's panic was swallowed")
+}
+
+func TestTimeoutOf(t *testing.T) {
+	config := TimeoutConfig{
+		Default: time.Second,
+		Bulk:    time.Minute,
+		Routes:  map[string]time.Duration{"GET /api/v1/jobs/{id}/logs": 0},
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		accept    string
+		bounded   bool
+		wantLimit time.Duration
+	}{
+		{name: "default", method: http.MethodGet, target: "/api/v1/jobs/abc", bounded: true, wantLimit: time.Second},
+		{name: "bulk", method: http.MethodPost, target: "/api/v1/groups/abc/jobs", bounded: true, wantLimit: time.Minute},
+		{name: "stream route", method: http.MethodGet, target: "/api/v1/jobs/events"},
+		{name: "event stream accepted", method: http.MethodGet, target: "/api/v1/jobs/abc", accept: "text/event-stream"},
+		{name: "followed", method: http.MethodGet, target: "/api/v1/jobs/abc?follow=true"},
+		{name: "unbounded by config", method: http.MethodGet, target: "/api/v1/jobs/abc/logs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffered, hasDeadline bool
+			var limit time.Duration
+			router := newTimeoutRouter(config, func(w http.ResponseWriter, r *http.Request) {
+				_, buffered = w.(*timeoutWriter)
+				var deadline time.Time
+				deadline, hasDeadline = r.Context().Deadline()
+				limit = time.Until(deadline)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if buffered != tt.bounded || hasDeadline != tt.bounded {
+				t.Fatalf("buffered = %v, deadline = %v, want both %v", buffered, hasDeadline, tt.bounded)
+			}
+			if tt.bounded && (limit > tt.wantLimit || limit < tt.wantLimit/2) {
+				t.Errorf("deadline in %s, want %s", limit, tt.wantLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package shared
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// BatchResult is the multi-status payload of a bulk request. Each item reports
+// the outcome of one element of the request, by its index, with the status and
+// error code the element would have been answered with on its own, so one
+// invalid element does not fail the others.
+type BatchResult struct {
+	Succeeded int         `json:"succeeded"`
+	Failed    int         `json:"failed"`
+	Items     []BatchItem `json:"items"`
+}
+
+// BatchItem is the outcome of one element of a bulk request
+type BatchItem struct {
+	Index  int `json:"index"`
+	Status int `json:"status"`
+	// ID identifies the resource the element created or changed
+	ID string `json:"id,omitempty"`
+	// Code and Error describe why the element failed, and Field names the
+	// invalid field when it failed validation
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	Field string `json:"field,omitempty"`
+	// Data is the resource the element created or changed
+	Data interface{} `json:"data,omitempty"`
+}
+
+// NewBatchResult creates an empty result for a request of size elements
+func NewBatchResult(size int) *BatchResult {
+	return &BatchResult{Items: make([]BatchItem, 0, size)}
+}
+
+// Succeed records that the element at index succeeded with status
+func (b *BatchResult) Succeed(index, status int, id string, data interface{}) {
+	b.Succeeded++
+	b.Items = append(b.Items, BatchItem{Index: index, Status: status, ID: id, Data: data})
+}
+
+// Fail records that the element at index failed with err, with the status
+// MapError gives it
+func (b *BatchResult) Fail(index int, id string, err error, overrides ...ErrorMapping) {
+	status, message := MapError(err, overrides...)
+	b.fail(index, status, id, message, err)
+}
+
+// FailWithStatus records that the element at index failed with err and status
+func (b *BatchResult) FailWithStatus(index, status int, id string, err error) {
+	b.fail(index, status, id, err.Error(), err)
+}
+
+func (b *BatchResult) fail(index, status int, id, message string, err error) {
+	item := BatchItem{Index: index, Status: status, ID: id, Code: ErrorCode(status), Error: message}
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		item.Code = "invalid_field"
+		item.Field = validationErr.Field
+	}
+	b.Failed++
+	b.Items = append(b.Items, item)
+}
+
+// StatusCode returns the status of the whole response: status when every
+// element succeeded, and 207 Multi-Status when any failed
+func (b *BatchResult) StatusCode(status int) int {
+	if b.Failed > 0 {
+		return http.StatusMultiStatus
+	}
+	return status
+}
+
+// errorCodes are the machine-readable codes of failed batch items by status
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "invalid_request",
+	http.StatusNotFound:              "not_found",
+	http.StatusConflict:              "conflict",
+	http.StatusPreconditionFailed:    "precondition_failed",
+	http.StatusRequestEntityTooLarge: "too_large",
+	http.StatusUnprocessableEntity:   "rejected",
+	http.StatusTooManyRequests:       "rate_limited",
+	http.StatusServiceUnavailable:    "unavailable",
+	http.StatusGatewayTimeout:        "timeout",
+}
+
+// ErrorCode returns the machine-readable code of an error answered with status
+func ErrorCode(status int) string {
+	if code, ok := errorCodes[status]; ok {
+		return code
+	}
+	return "internal_error"
+}
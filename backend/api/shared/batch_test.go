@@ -0,0 +1,108 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/fullstack-assessment/backend/services"
+)
+
+func TestBatchResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		record     func(b *BatchResult)
+		want       []BatchItem
+		wantStatus int
+	}{
+		{
+			name: "all succeeded",
+			record: func(b *BatchResult) {
+				b.Succeed(0, http.StatusCreated, "job-1", nil)
+				b.Succeed(1, http.StatusCreated, "job-2", nil)
+			},
+			want: []BatchItem{
+				{Index: 0, Status: http.StatusCreated, ID: "job-1"},
+				{Index: 1, Status: http.StatusCreated, ID: "job-2"},
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "mapped failure",
+			record: func(b *BatchResult) {
+				b.Succeed(0, http.StatusOK, "job-1", nil)
+				b.Fail(1, "job-2", fmt.Errorf("cancel: %w", services.ErrInvalidJobState))
+			},
+			want: []BatchItem{
+				{Index: 0, Status: http.StatusOK, ID: "job-1"},
+				{Index: 1, Status: http.StatusConflict, ID: "job-2", Code: "conflict", Error: services.ErrInvalidJobState.Error()},
+			},
+			wantStatus: http.StatusMultiStatus,
+		},
+		{
+			name: "validation failure",
+			record: func(b *BatchResult) {
+				b.Fail(0, "", &services.ValidationError{Field: "job_type", Message: "job_type is required"})
+			},
+			want: []BatchItem{
+				{Index: 0, Status: http.StatusBadRequest, Code: "invalid_field", Error: "job_type: job_type is required", Field: "job_type"},
+			},
+			wantStatus: http.StatusMultiStatus,
+		},
+		{
+			name: "override",
+			record: func(b *BatchResult) {
+				b.Fail(0, "job-1", services.ErrInvalidJobState, ErrorMapping{Err: services.ErrInvalidJobState, Status: http.StatusConflict, Message: "only pending jobs can be prioritized"})
+			},
+			want: []BatchItem{
+				{Index: 0, Status: http.StatusConflict, ID: "job-1", Code: "conflict", Error: "only pending jobs can be prioritized"},
+			},
+			wantStatus: http.StatusMultiStatus,
+		},
+		{
+			name: "explicit status",
+			record: func(b *BatchResult) {
+				b.FailWithStatus(0, http.StatusTooManyRequests, "job-1", errors.New("quota exceeded"))
+			},
+			want: []BatchItem{
+				{Index: 0, Status: http.StatusTooManyRequests, ID: "job-1", Code: "rate_limited", Error: "quota exceeded"},
+			},
+			wantStatus: http.StatusMultiStatus,
+		},
+		{
+			name: "unexpected error",
+			record: func(b *BatchResult) {
+				b.Fail(0, "job-1", errors.New("connection reset"))
+			},
+			want: []BatchItem{
+				{Index: 0, Status: http.StatusInternalServerError, ID: "job-1", Code: "internal_error", Error: "connection reset"},
+			},
+			wantStatus: http.StatusMultiStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewBatchResult(len(tt.want))
+			tt.record(result)
+
+			if !reflect.DeepEqual(result.Items, tt.want) {
+				t.Errorf("items = %+v, want %+v", result.Items, tt.want)
+			}
+			failed := 0
+			for _, item := range tt.want {
+				if item.Code != "" {
+					failed++
+				}
+			}
+			if result.Failed != failed || result.Succeeded != len(tt.want)-failed {
+				t.Errorf("succeeded %d failed %d, want %d and %d", result.Succeeded, result.Failed, len(tt.want)-failed, failed)
+			}
+			if status := result.StatusCode(http.StatusCreated); status != tt.wantStatus {
+				t.Errorf("StatusCode() = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package shared
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fullstack-assessment/backend/repositories"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// ErrorMapping maps the errors matching Err, as reported by errors.Is, to an HTTP
+// status. The response carries Message, or Err's own text when Message is empty.
+type ErrorMapping struct {
+	Err     error
+	Status  int
+	Message string
+}
+
+// errorMappings are the service errors every handler maps the same way
+var errorMappings = []ErrorMapping{
+	{Err: services.ErrJobNotFound, Status: http.StatusNotFound},
+	{Err: services.ErrAttemptNotFound, Status: http.StatusNotFound},
+	{Err: services.ErrNoArtifact, Status: http.StatusNotFound},
+	{Err: services.ErrViewNotFound, Status: http.StatusNotFound},
+	{Err: services.ErrGroupNotFound, Status: http.StatusNotFound},
+	{Err: services.ErrAPIKeyNotFound, Status: http.StatusNotFound},
+	{Err: services.ErrAlertRuleNotFound, Status: http.StatusNotFound},
+	{Err: services.ErrWebhookDeliveryNotFound, Status: http.StatusNotFound},
+	{Err: services.ErrTenantNotificationsNotFound, Status: http.StatusNotFound},
+	{Err: services.ErrPreconditionFailed, Status: http.StatusPreconditionFailed, Message: "job was modified since it was read"},
+	{Err: services.ErrInvalidJobState, Status: http.StatusConflict},
+	{Err: services.ErrJobAlreadyHeld, Status: http.StatusConflict},
+	{Err: services.ErrJobNotHeld, Status: http.StatusConflict},
+	{Err: services.ErrViewExists, Status: http.StatusConflict},
+	{Err: services.ErrGroupCancelled, Status: http.StatusConflict},
+	{Err: services.ErrSubmissionInProgress, Status: http.StatusConflict},
+	{Err: services.ErrJobIDTaken, Status: http.StatusConflict},
+	{Err: services.ErrInputTooLarge, Status: http.StatusRequestEntityTooLarge},
+	{Err: repositories.ErrInvalidID, Status: http.StatusBadRequest},
+}
+
+// ErrorResponse is how a service error is answered
+type ErrorResponse struct {
+	Status  int
+	Message string
+	// RetryAfter is sent as the Retry-After header when set
+	RetryAfter time.Duration
+	// Data describes the error alongside the message, or is the response's
+	// data when the error is answered with a success status
+	Data interface{}
+}
+
+// errorTypeMappings answer the service errors whose type carries more than a
+// status, such as when to retry or the job a duplicate stands for
+var errorTypeMappings = []func(err error) (ErrorResponse, bool){
+	func(err error) (ErrorResponse, bool) {
+		var duplicate *services.DuplicateJobError
+		if !errors.As(err, &duplicate) {
+			return ErrorResponse{}, false
+		}
+		if duplicate.Rejected {
+			return ErrorResponse{Status: http.StatusConflict, Message: err.Error()}, true
+		}
+		// The original submission stands in for the duplicate
+		return ErrorResponse{Status: http.StatusOK, Data: duplicate.Job}, true
+	},
+	func(err error) (ErrorResponse, bool) {
+		var backpressure *services.BackpressureError
+		if !errors.As(err, &backpressure) {
+			return ErrorResponse{}, false
+		}
+		return ErrorResponse{Status: http.StatusTooManyRequests, Message: err.Error(), RetryAfter: backpressure.RetryAfter, Data: backpressure.Queue}, true
+	},
+	func(err error) (ErrorResponse, bool) {
+		var quota *services.QuotaExceededError
+		if !errors.As(err, &quota) {
+			return ErrorResponse{}, false
+		}
+		return ErrorResponse{Status: http.StatusTooManyRequests, Message: err.Error(), RetryAfter: quota.RetryAfter, Data: quota.Usage}, true
+	},
+	func(err error) (ErrorResponse, bool) {
+		var rejected *services.HookRejectedError
+		if !errors.As(err, &rejected) {
+			return ErrorResponse{}, false
+		}
+		return ErrorResponse{Status: http.StatusUnprocessableEntity, Message: err.Error()}, true
+	},
+	func(err error) (ErrorResponse, bool) {
+		var maintenance *services.MaintenanceError
+		if !errors.As(err, &maintenance) {
+			return ErrorResponse{}, false
+		}
+		return ErrorResponse{Status: http.StatusServiceUnavailable, Message: err.Error(), RetryAfter: maintenance.RetryAfter}, true
+	},
+}
+
+// MapError returns the status and message a service error is answered with,
+// as ResolveError gives them
+func MapError(err error, overrides ...ErrorMapping) (int, string) {
+	response := ResolveError(err, overrides...)
+	return response.Status, response.Message
+}
+
+// ResolveError returns how a service error is answered. overrides are checked
+// before the shared mappings, so a handler can say more than the generic
+// message, such as why a job cannot change state. Error types carrying details
+// for the client come next, then validation errors, which map to 400, request
+// errors, which map to their own status, and timeouts, from an expired context
+// deadline or a query stopped by MongoDB, which map to 504. Anything else is a
+// 500.
+func ResolveError(err error, overrides ...ErrorMapping) ErrorResponse {
+	for _, mappings := range [][]ErrorMapping{overrides, errorMappings} {
+		for _, mapping := range mappings {
+			if !errors.Is(err, mapping.Err) {
+				continue
+			}
+			if mapping.Message != "" {
+				return ErrorResponse{Status: mapping.Status, Message: mapping.Message}
+			}
+			return ErrorResponse{Status: mapping.Status, Message: mapping.Err.Error()}
+		}
+	}
+	for _, mapping := range errorTypeMappings {
+		if response, ok := mapping(err); ok {
+			return response
+		}
+	}
+
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		return ErrorResponse{Status: http.StatusBadRequest, Message: validationErr.Error()}
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return ErrorResponse{Status: reqErr.StatusCode, Message: reqErr.Message}
+	}
+	if repositories.IsTimeout(err) {
+		return ErrorResponse{Status: http.StatusGatewayTimeout, Message: "request timed out"}
+	}
+	return ErrorResponse{Status: http.StatusInternalServerError, Message: err.Error()}
+}
+
+// Write sends the response, as a success when its status is below 400
+func (e ErrorResponse) Write(w http.ResponseWriter) {
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(e.RetryAfter.Seconds())))
+	}
+	switch {
+	case e.Status < http.StatusBadRequest:
+		RespondJSON(w, e.Status, e.Data)
+	case e.Data != nil:
+		RespondErrorWithData(w, e.Status, errors.New(e.Message), e.Data)
+	default:
+		RespondErrorMessage(w, e.Status, e.Message)
+	}
+}
+
+// RespondServiceError sends the response ResolveError gives for err
+func RespondServiceError(w http.ResponseWriter, err error, overrides ...ErrorMapping) {
+	ResolveError(err, overrides...).Write(w)
+}
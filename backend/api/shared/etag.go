@@ -0,0 +1,96 @@
+package shared
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// etagVariantSeparator separates a version from the encodings its
+// representation was rendered in, e.g. "<version>+snake+gzip"
+const etagVariantSeparator = "+"
+
+// ETag builds a strong entity tag from a resource ID and its last modification
+// time, matching the version the resource is rendered with
+func ETag(id string, updatedAt time.Time) string {
+	return `"` + models.ResourceVersion(id, updatedAt) + `"`
+}
+
+// ETagVariant tags a strong entity tag with an encoding applied to the
+// representation, such as a content-coding, so each encoding of a version has
+// its own strong validator. Weak and empty tags are returned as they are.
+func ETagVariant(etag, variant string) string {
+	if len(etag) < 2 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + etagVariantSeparator + variant + `"`
+}
+
+// CheckNotModified sets the ETag header and responds with 304 Not Modified
+// when the request's If-None-Match header matches it. It returns true if a
+// response has been written.
+func CheckNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// IfMatchVersions reads the If-Match header of a request for resource id as the
+// modification times encoded in its entity tags. If-Match uses the strong
+// comparison, so weak tags never match. It returns nil when the header is
+// absent or "*", and false when no tag in it can belong to the resource, in
+// which case the precondition fails.
+func IfMatchVersions(r *http.Request, id string) ([]time.Time, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	versions := []time.Time{}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		nanos, ok := strings.CutPrefix(etagVersion(candidate), id+"-")
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseInt(nanos, 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, time.Unix(0, parsed))
+	}
+	return versions, len(versions) > 0
+}
+
+// etagMatches performs a weak comparison of an If-None-Match header against an
+// ETag. The encodings of a version match each other, as the Vary headers of
+// the response already keep caches from serving one in place of another.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || etagVersion(strings.TrimPrefix(candidate, "W/")) == etagVersion(strings.TrimPrefix(etag, "W/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// etagVersion returns the version an opaque entity tag was built from, without
+// its quotes and encoding variants
+func etagVersion(tag string) string {
+	version, _, _ := strings.Cut(strings.Trim(tag, `"`), etagVariantSeparator)
+	return version
+}
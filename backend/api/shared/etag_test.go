@@ -0,0 +1,114 @@
+package shared
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+)
+
+func TestETagMatchesJobVersion(t *testing.T) {
+	id, err := models.ParseJobID("65a1b2c3d4e5f60718293a4b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A job just updated in memory holds nanoseconds MongoDB does not store
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	raw, err := json.Marshal(models.Job{ID: id, UpdatedAt: updatedAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rendered struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(raw, &rendered); err != nil {
+		t.Fatal(err)
+	}
+
+	etag := ETag(id.String(), updatedAt)
+	if etag != `"`+rendered.Version+`"` {
+		t.Errorf("ETag() = %s, want the job's version %s", etag, rendered.Version)
+	}
+	if stored := ETag(id.String(), updatedAt.Truncate(time.Millisecond)); stored != etag {
+		t.Errorf("ETag of the stored job = %s, want %s", stored, etag)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/jobs/"+id.String()+"/cancel", nil)
+	req.Header.Set("If-Match", etag)
+	versions, ok := IfMatchVersions(req, id.String())
+	if !ok || len(versions) != 1 || !versions[0].Equal(updatedAt.Truncate(time.Millisecond)) {
+		t.Errorf("IfMatchVersions() = %v, %v, want the stored update time", versions, ok)
+	}
+}
+
+func TestIfMatchVersions(t *testing.T) {
+	id := "65a1b2c3d4e5f60718293a4b"
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	etag := ETag(id, updatedAt)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    int
+		wantOK  bool
+	}{
+		{name: "absent", wantOK: true},
+		{name: "any", ifMatch: "*", wantOK: true},
+		{name: "strong", ifMatch: etag, want: 1, wantOK: true},
+		{name: "gzip variant", ifMatch: ETagVariant(etag, "gzip"), want: 1, wantOK: true},
+		{name: "snake and gzip variant", ifMatch: ETagVariant(ETagVariant(etag, "snake"), "gzip"), want: 1, wantOK: true},
+		{name: "bare version", ifMatch: models.ResourceVersion(id, updatedAt), want: 1, wantOK: true},
+		{name: "weak", ifMatch: "W/" + etag},
+		{name: "weak and strong", ifMatch: "W/" + etag + ", " + etag, want: 1, wantOK: true},
+		{name: "another resource", ifMatch: ETag("65a1b2c3d4e5f60718293a4c", updatedAt)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs/"+id+"/cancel", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			versions, ok := IfMatchVersions(req, id)
+			if ok != tt.wantOK || len(versions) != tt.want {
+				t.Errorf("IfMatchVersions() = %v, %v, want %d versions, %v", versions, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCheckNotModified(t *testing.T) {
+	etag := ETag("65a1b2c3d4e5f60718293a4b", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{name: "absent"},
+		{name: "same", ifNoneMatch: etag, want: true},
+		{name: "weak", ifNoneMatch: "W/" + etag, want: true},
+		{name: "gzip variant", ifNoneMatch: ETagVariant(etag, "gzip"), want: true},
+		{name: "other version", ifNoneMatch: ETag("65a1b2c3d4e5f60718293a4b", time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC))},
+		{name: "any", ifNoneMatch: "*", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/65a1b2c3d4e5f60718293a4b", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			if got := CheckNotModified(rec, req, etag); got != tt.want {
+				t.Errorf("CheckNotModified() = %v, want %v", got, tt.want)
+			}
+			if rec.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %q, want %q", rec.Header().Get("ETag"), etag)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package shared
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ParseFields reads the comma-separated fields query parameter, rejecting names
+// not in allowed. It returns nil when the parameter is absent.
+func ParseFields(r *http.Request, allowed map[string]bool) ([]string, error) {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !allowed[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// SelectFields encodes v as JSON and keeps only the given top-level fields.
+// Fields v omits are left out of the result rather than rendered as null.
+func SelectFields(v interface{}, fields []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,106 @@
+package shared
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodyBytes is the request body limit used when none is configured
+const DefaultMaxBodyBytes int64 = 1 << 20 // 1 MiB
+
+// RequestError represents a request decoding error with the HTTP status it maps to
+type RequestError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *RequestError) Error() string {
+	return e.Message
+}
+
+// DecodeJSON decodes a size-limited JSON request body into dst.
+// Unknown fields, trailing data and bodies larger than maxBytes are rejected.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, maxBytes int64, dst interface{}) error {
+	return decodeJSON(w, r, maxBytes, dst, false)
+}
+
+// DecodeOptionalJSON behaves like DecodeJSON but accepts an empty body, leaving dst untouched
+func DecodeOptionalJSON(w http.ResponseWriter, r *http.Request, maxBytes int64, dst interface{}) error {
+	return decodeJSON(w, r, maxBytes, dst, true)
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, maxBytes int64, dst interface{}, allowEmpty bool) error {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		if allowEmpty && errors.Is(err, io.EOF) {
+			return nil
+		}
+		return decodeError(err, maxBytes)
+	}
+
+	// Ensure the body contains a single JSON value
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err, maxBytes)
+		}
+		return &RequestError{StatusCode: http.StatusBadRequest, Message: "request body must contain a single JSON object"}
+	}
+
+	return nil
+}
+
+// decodeError converts a JSON decoding error into a RequestError
+func decodeError(err error, maxBytes int64) *RequestError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return &RequestError{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Message:    fmt.Sprintf("request body must not exceed %d bytes", maxBytes),
+		}
+	case errors.As(err, &syntaxErr):
+		return &RequestError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("malformed JSON at position %d", syntaxErr.Offset),
+		}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &RequestError{StatusCode: http.StatusBadRequest, Message: "malformed JSON"}
+	case errors.As(err, &typeErr):
+		return &RequestError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("invalid value for field '%s'", typeErr.Field),
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return &RequestError{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("unknown field %s", field)}
+	case errors.Is(err, io.EOF):
+		return &RequestError{StatusCode: http.StatusBadRequest, Message: "request body must not be empty"}
+	default:
+		return &RequestError{StatusCode: http.StatusBadRequest, Message: err.Error()}
+	}
+}
+
+// RespondDecodeError sends the error response for a failed DecodeJSON call
+func RespondDecodeError(w http.ResponseWriter, err error) {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		RespondErrorMessage(w, reqErr.StatusCode, reqErr.Message)
+		return
+	}
+	RespondError(w, http.StatusBadRequest, err)
+}
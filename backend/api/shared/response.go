@@ -50,3 +50,17 @@ func RespondErrorMessage(w http.ResponseWriter, statusCode int, message string)
 
 	json.NewEncoder(w).Encode(response)
 }
+
+// RespondErrorWithData sends a JSON error response that also carries data describing the error
+func RespondErrorWithData(w http.ResponseWriter, statusCode int, err error, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	response := Response{
+		Status: "error",
+		Data:   data,
+		Error:  err.Error(),
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// listAlertRules handles GET /api/v1/admin/alerts
+func (h *Handler) listAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.alerts.ListRules(r.Context())
+	if err != nil {
+		shared.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, rules)
+}
+
+// createAlertRule handles POST /api/v1/admin/alerts
+func (h *Handler) createAlertRule(w http.ResponseWriter, r *http.Request) {
+	var req services.AlertRuleRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	rule, err := h.alerts.CreateRule(r.Context(), req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusCreated, rule)
+}
+
+// getAlertRule handles GET /api/v1/admin/alerts/{id}
+func (h *Handler) getAlertRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.alerts.GetRule(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, rule)
+}
+
+// updateAlertRule handles PUT /api/v1/admin/alerts/{id}
+func (h *Handler) updateAlertRule(w http.ResponseWriter, r *http.Request) {
+	var req services.AlertRuleRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	rule, err := h.alerts.UpdateRule(r.Context(), mux.Vars(r)["id"], req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, rule)
+}
+
+// deleteAlertRule handles DELETE /api/v1/admin/alerts/{id}
+func (h *Handler) deleteAlertRule(w http.ResponseWriter, r *http.Request) {
+	if err := h.alerts.DeleteRule(r.Context(), mux.Vars(r)["id"]); err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+)
+
+// listAuditEvents handles GET /api/v1/admin/audit
+func (h *Handler) listAuditEvents(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	page, err := h.comments.ListAuditEvents(r.Context(), query.Get("job_id"), query.Get("author"), cursor, limit)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// resolveStuckCancellations handles POST /api/v1/admin/jobs/cancelling/resolve.
+// It releases jobs left in cancelling because no worker acknowledged their
+// cancellation, cancelling them or reverting them to processing.
+func (h *Handler) resolveStuckCancellations(w http.ResponseWriter, r *http.Request) {
+	var req services.ResolveCancellationsRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+	req.Actor = auth.Actor(r.Context())
+
+	result, err := h.cancellations.ResolveStuckCancellations(r.Context(), req)
+	if err != nil {
+		if result != nil {
+			shared.RespondErrorWithData(w, http.StatusInternalServerError, err, result)
+			return
+		}
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, result)
+}
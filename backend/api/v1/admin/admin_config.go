@@ -0,0 +1,12 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+)
+
+// getConfig handles GET /api/v1/admin/config, returning the effective configuration with secrets redacted
+func (h *Handler) getConfig(w http.ResponseWriter, r *http.Request) {
+	shared.RespondJSON(w, http.StatusOK, h.config.Current().Redacted())
+}
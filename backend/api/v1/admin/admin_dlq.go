@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+)
+
+// listDeadLetters handles GET /api/v1/admin/dlq
+func (h *Handler) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.deadLetters.ListDeadLetters(r.Context(), r.URL.Query().Get("job_type"), cursor, limit)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, page)
+}
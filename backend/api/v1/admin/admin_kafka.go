@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// getKafkaFailover handles GET /api/v1/admin/kafka/failover
+func (h *Handler) getKafkaFailover(w http.ResponseWriter, r *http.Request) {
+	status, err := h.failover.GetFailover(r.Context())
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, status)
+}
+
+// setKafkaFailover handles POST /api/v1/admin/kafka/failover. It switches the
+// worker fleet between the primary region's topics and their mirrors in the
+// disaster recovery region.
+func (h *Handler) setKafkaFailover(w http.ResponseWriter, r *http.Request) {
+	var req services.KafkaFailoverRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+	req.Actor = auth.Actor(r.Context())
+
+	status, err := h.failover.SetFailover(r.Context(), req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// getMaintenance handles GET /api/v1/admin/maintenance
+func (h *Handler) getMaintenance(w http.ResponseWriter, r *http.Request) {
+	shared.RespondJSON(w, http.StatusOK, h.maintenance.GetStatus())
+}
+
+// setMaintenance handles POST /api/v1/admin/maintenance
+func (h *Handler) setMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req services.MaintenanceRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+	req.Actor = auth.Actor(r.Context())
+
+	status, err := h.maintenance.SetMaintenance(r.Context(), req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,26 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// pageParams reads the cursor and limit of a cursor-paginated listing, responding
+// with 400 Bad Request and returning false when the limit is not a number
+func pageParams(w http.ResponseWriter, r *http.Request) (cursor string, limit int, ok bool) {
+	query := r.URL.Query()
+
+	limit = services.DefaultCursorPageLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			shared.RespondErrorMessage(w, http.StatusBadRequest, "limit must be a number")
+			return "", 0, false
+		}
+		limit = parsed
+	}
+	return query.Get("cursor"), limit, true
+}
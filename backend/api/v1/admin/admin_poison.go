@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+)
+
+// listPoisonMessages handles GET /api/v1/admin/poison-messages
+func (h *Handler) listPoisonMessages(w http.ResponseWriter, r *http.Request) {
+	cursor, limit, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.poison.ListPoisonMessages(r.Context(), r.URL.Query().Get("topic"), cursor, limit)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, page)
+}
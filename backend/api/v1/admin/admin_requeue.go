@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// requeueJobs handles POST /api/v1/admin/jobs/requeue.
+// Clients accepting text/event-stream receive a progress event after each batch
+// and an end event with the result; others receive the result once done.
+func (h *Handler) requeueJobs(w http.ResponseWriter, r *http.Request) {
+	var req services.RequeueRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+	req.Actor = auth.Actor(r.Context())
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		result, err := h.jobs.RequeueJobs(r.Context(), req, nil)
+		if err != nil {
+			if services.IsValidationError(err) {
+				shared.RespondError(w, http.StatusBadRequest, err)
+				return
+			}
+			shared.RespondErrorWithData(w, http.StatusInternalServerError, err, result)
+			return
+		}
+		shared.RespondJSON(w, http.StatusOK, result)
+		return
+	}
+
+	// The stream outlives the server's write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	started := false
+	writeEvent := func(event string, payload interface{}) {
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		data, _ := json.Marshal(payload)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		controller.Flush()
+	}
+
+	result, err := h.jobs.RequeueJobs(r.Context(), req, func(progress services.RequeueProgress) {
+		writeEvent("progress", progress)
+	})
+	if err != nil {
+		// Validation fails before any batch, while a plain error response is still possible
+		if !started && services.IsValidationError(err) {
+			shared.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeEvent("error", shared.Response{Status: "error", Error: err.Error(), Data: result})
+		return
+	}
+	writeEvent("end", result)
+}
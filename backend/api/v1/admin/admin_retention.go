@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+)
+
+// getRetentionReport handles GET /api/v1/admin/retention/report. It counts the
+// jobs each retention rule would remove if it were applied now, removing none.
+func (h *Handler) getRetentionReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.retention.Report(r.Context())
+	if err != nil {
+		shared.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, report)
+}
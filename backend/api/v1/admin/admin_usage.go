@@ -0,0 +1,71 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// usageCSVHeader is the header row of the usage CSV export
+var usageCSVHeader = []string{"tenant", "day", "jobs_run", "compute_seconds", "bytes_exported"}
+
+// listUsage handles GET /api/v1/admin/usage. The usage is exported as CSV
+// with ?format=csv or an Accept header of text/csv.
+func (h *Handler) listUsage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" && strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		format = "csv"
+	}
+	if format != "" && format != "json" && format != "csv" {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+
+	usage, err := h.usage.ListUsage(r.Context(), repositories.UsageQuery{
+		Tenant: query.Get("tenant"),
+		From:   query.Get("from"),
+		To:     query.Get("to"),
+	})
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	if format == "csv" {
+		writeUsageCSV(w, usage)
+		return
+	}
+	shared.RespondJSON(w, http.StatusOK, usage)
+}
+
+// writeUsageCSV writes usage records as a CSV attachment
+func writeUsageCSV(w http.ResponseWriter, usage []models.TenantUsage) {
+	filename := "usage.csv"
+	if len(usage) > 0 {
+		filename = fmt.Sprintf("usage-%s-to-%s.csv", usage[0].Day, usage[len(usage)-1].Day)
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(usageCSVHeader)
+	for _, record := range usage {
+		writer.Write([]string{
+			record.Tenant,
+			record.Day,
+			strconv.FormatInt(record.JobsRun, 10),
+			strconv.FormatFloat(record.ComputeSeconds, 'f', 3, 64),
+			strconv.FormatInt(record.BytesExported, 10),
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,30 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+)
+
+// listWorkers handles GET /api/v1/admin/workers
+func (h *Handler) listWorkers(w http.ResponseWriter, r *http.Request) {
+	workers, err := h.workers.ListActiveWorkers(r.Context())
+	if err != nil {
+		shared.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, workers)
+}
+
+// getDrainStatus handles GET /api/v1/admin/drain-status. Deployment tooling
+// polls it before stopping workers until no jobs are in flight.
+func (h *Handler) getDrainStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.workers.DrainStatus(r.Context())
+	if err != nil {
+		shared.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, status)
+}
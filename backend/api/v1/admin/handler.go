@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"github.com/fullstack-assessment/backend/api/middleware"
+	"github.com/fullstack-assessment/backend/config"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for operational endpoints
+type Handler struct {
+	config        *config.Store
+	alerts        services.AlertsService
+	workers       services.WorkersService
+	maintenance   services.MaintenanceService
+	poison        services.PoisonMessagesService
+	deadLetters   services.DeadLettersService
+	comments      services.JobCommentsService
+	jobs          services.JobsService
+	usage         services.UsageService
+	retention     services.RetentionService
+	failover      services.KafkaFailoverService
+	cancellations services.CancellationsService
+	maxBodyBytes  int64
+}
+
+// NewHandler creates a new admin handler
+func NewHandler(config *config.Store, alerts services.AlertsService, workers services.WorkersService, maintenance services.MaintenanceService, poison services.PoisonMessagesService, deadLetters services.DeadLettersService, comments services.JobCommentsService, jobs services.JobsService, usage services.UsageService, retention services.RetentionService, failover services.KafkaFailoverService, cancellations services.CancellationsService, maxBodyBytes int64) *Handler {
+	return &Handler{
+		config:        config,
+		alerts:        alerts,
+		workers:       workers,
+		maintenance:   maintenance,
+		poison:        poison,
+		deadLetters:   deadLetters,
+		comments:      comments,
+		jobs:          jobs,
+		usage:         usage,
+		retention:     retention,
+		failover:      failover,
+		cancellations: cancellations,
+		maxBodyBytes:  maxBodyBytes,
+	}
+}
+
+// RegisterRoutes registers the admin routes
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	adminRouter := router.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(middleware.ObjectIDParams("id"))
+
+	adminRouter.HandleFunc("/config", h.getConfig).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/workers", h.listWorkers).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/drain-status", h.getDrainStatus).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/maintenance", h.getMaintenance).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/maintenance", h.setMaintenance).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/kafka/failover", h.getKafkaFailover).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/kafka/failover", h.setKafkaFailover).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/poison-messages", h.listPoisonMessages).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/dlq", h.listDeadLetters).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/audit", h.listAuditEvents).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/alerts", h.listAlertRules).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/alerts", h.createAlertRule).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/alerts/{id}", h.getAlertRule).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/alerts/{id}", h.updateAlertRule).Methods("PUT", "OPTIONS")
+	adminRouter.HandleFunc("/alerts/{id}", h.deleteAlertRule).Methods("DELETE", "OPTIONS")
+	adminRouter.HandleFunc("/jobs/requeue", h.requeueJobs).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/jobs/cancelling/resolve", h.resolveStuckCancellations).Methods("POST", "OPTIONS")
+	adminRouter.HandleFunc("/usage", h.listUsage).Methods("GET", "OPTIONS")
+	adminRouter.HandleFunc("/retention/report", h.getRetentionReport).Methods("GET", "OPTIONS")
+}
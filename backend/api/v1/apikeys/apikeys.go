@@ -0,0 +1,49 @@
+package apikeys
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// listAPIKeys handles GET /api/v1/apikeys
+func (h *Handler) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListAPIKeys(r.Context())
+	if err != nil {
+		shared.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, keys)
+}
+
+// createAPIKey handles POST /api/v1/apikeys. The key's secret is only part of this response.
+func (h *Handler) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateAPIKeyRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(r.Context(), req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	shared.RespondJSON(w, http.StatusCreated, key)
+}
+
+// revokeAPIKey handles DELETE /api/v1/apikeys/{id}
+func (h *Handler) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.service.RevokeAPIKey(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, key)
+}
@@ -0,0 +1,31 @@
+package apikeys
+
+import (
+	"github.com/fullstack-assessment/backend/api/middleware"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for API keys
+type Handler struct {
+	service      services.APIKeysService
+	maxBodyBytes int64
+}
+
+// NewHandler creates a new API keys handler
+func NewHandler(service services.APIKeysService, maxBodyBytes int64) *Handler {
+	return &Handler{
+		service:      service,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+// RegisterRoutes registers the API key routes
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	keysRouter := router.PathPrefix("/apikeys").Subrouter()
+	keysRouter.Use(middleware.ObjectIDParams("id"))
+
+	keysRouter.HandleFunc("", h.listAPIKeys).Methods("GET", "OPTIONS")
+	keysRouter.HandleFunc("", h.createAPIKey).Methods("POST", "OPTIONS")
+	keysRouter.HandleFunc("/{id}", h.revokeAPIKey).Methods("DELETE", "OPTIONS")
+}
@@ -0,0 +1,18 @@
+package dashboard
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+)
+
+// getDashboard handles GET /api/v1/dashboard
+func (h *Handler) getDashboard(w http.ResponseWriter, r *http.Request) {
+	dashboard, err := h.service.GetDashboard(r.Context())
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, dashboard)
+}
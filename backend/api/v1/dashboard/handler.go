@@ -0,0 +1,23 @@
+package dashboard
+
+import (
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for the dashboard summary
+type Handler struct {
+	service services.DashboardService
+}
+
+// NewHandler creates a new dashboard handler
+func NewHandler(service services.DashboardService) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the dashboard routes
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/dashboard", h.getDashboard).Methods("GET", "OPTIONS")
+}
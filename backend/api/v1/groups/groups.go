@@ -0,0 +1,132 @@
+package groups
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// createGroup handles POST /api/v1/groups
+func (h *Handler) createGroup(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateGroupRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	group, err := h.service.CreateGroup(r.Context(), req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusCreated, group)
+}
+
+// getGroup handles GET /api/v1/groups/{id}
+func (h *Handler) getGroup(w http.ResponseWriter, r *http.Request) {
+	group, err := h.service.GetGroup(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, group)
+}
+
+// attachJobs handles POST /api/v1/groups/{id}/jobs
+func (h *Handler) attachJobs(w http.ResponseWriter, r *http.Request) {
+	var req services.AttachJobsRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	result, err := h.service.AttachJobs(r.Context(), mux.Vars(r)["id"], req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, result)
+}
+
+// createGroupJobs handles POST /api/v1/groups/{id}/jobs/bulk
+func (h *Handler) createGroupJobs(w http.ResponseWriter, r *http.Request) {
+	var req services.CreateGroupJobsRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	result, err := h.service.CreateJobs(r.Context(), mux.Vars(r)["id"], req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	batch := shared.NewBatchResult(len(result.Outcomes))
+	for i, outcome := range result.Outcomes {
+		if outcome.Err != nil {
+			if status, _ := shared.MapError(outcome.Err); status < http.StatusBadRequest {
+				// A duplicate that the single create answers with the original job is
+				// still not created into the group
+				batch.FailWithStatus(i, http.StatusConflict, "", outcome.Err)
+			} else {
+				batch.Fail(i, "", outcome.Err)
+			}
+			continue
+		}
+		// Held jobs are accepted but not queued until maintenance ends
+		status := http.StatusCreated
+		if outcome.Job.Status == models.JobStatusHeld {
+			status = http.StatusAccepted
+		}
+		batch.Succeed(i, status, outcome.JobID.String(), h.jobs.RedactJob(r.Context(), outcome.Job))
+	}
+
+	shared.RespondJSON(w, batch.StatusCode(http.StatusCreated), groupBatchResponse{BatchResult: batch, Group: result.Group})
+}
+
+// groupBatchResponse is the multi-status payload of a bulk request on a group,
+// with the group's progress after it
+type groupBatchResponse struct {
+	*shared.BatchResult
+	Group *models.JobGroup `json:"group"`
+}
+
+// cancelGroup handles POST /api/v1/groups/{id}/cancel
+func (h *Handler) cancelGroup(w http.ResponseWriter, r *http.Request) {
+	// The body is optional and may carry a cancellation reason
+	var req services.CancelJobRequest
+	if err := shared.DecodeOptionalJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+	req.Actor = auth.Actor(r.Context())
+
+	result, err := h.service.CancelGroup(r.Context(), mux.Vars(r)["id"], req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	batch := shared.NewBatchResult(len(result.Outcomes))
+	for i, outcome := range result.Outcomes {
+		if outcome.Err != nil {
+			batch.Fail(i, outcome.JobID.String(), outcome.Err, shared.ErrorMapping{
+				Err:     services.ErrInvalidJobState,
+				Status:  http.StatusConflict,
+				Message: "job finished before it could be cancelled",
+			})
+			continue
+		}
+		batch.Succeed(i, http.StatusOK, outcome.JobID.String(), h.jobs.RedactJob(r.Context(), outcome.Job))
+	}
+
+	shared.RespondJSON(w, batch.StatusCode(http.StatusOK), groupBatchResponse{BatchResult: batch, Group: result.Group})
+}
@@ -0,0 +1,35 @@
+package groups
+
+import (
+	"github.com/fullstack-assessment/backend/api/middleware"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for job groups
+type Handler struct {
+	service      services.GroupsService
+	jobs         services.JobsService
+	maxBodyBytes int64
+}
+
+// NewHandler creates a new job groups handler
+func NewHandler(service services.GroupsService, jobs services.JobsService, maxBodyBytes int64) *Handler {
+	return &Handler{
+		service:      service,
+		jobs:         jobs,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+// RegisterRoutes registers the job group routes
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	groupsRouter := router.PathPrefix("/groups").Subrouter()
+	groupsRouter.Use(middleware.ObjectIDParams("id"))
+
+	groupsRouter.HandleFunc("", h.createGroup).Methods("POST", "OPTIONS")
+	groupsRouter.HandleFunc("/{id}", h.getGroup).Methods("GET", "OPTIONS")
+	groupsRouter.HandleFunc("/{id}/jobs", h.attachJobs).Methods("POST", "OPTIONS")
+	groupsRouter.HandleFunc("/{id}/jobs/bulk", h.createGroupJobs).Methods("POST", "OPTIONS")
+	groupsRouter.HandleFunc("/{id}/cancel", h.cancelGroup).Methods("POST", "OPTIONS")
+}
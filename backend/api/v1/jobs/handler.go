@@ -1,29 +1,56 @@
 package jobs
 
 import (
+	"github.com/fullstack-assessment/backend/api/middleware"
 	"github.com/fullstack-assessment/backend/services"
 	"github.com/gorilla/mux"
 )
 
 // Handler handles HTTP requests for jobs
 type Handler struct {
-	service services.JobsService
+	service      services.JobsService
+	logs         services.JobLogsService
+	comments     services.JobCommentsService
+	views        services.ViewsService
+	maxBodyBytes int64
 }
 
 // NewHandler creates a new jobs handler
-func NewHandler(service services.JobsService) *Handler {
+func NewHandler(service services.JobsService, logs services.JobLogsService, comments services.JobCommentsService, views services.ViewsService, maxBodyBytes int64) *Handler {
 	return &Handler{
-		service: service,
+		service:      service,
+		logs:         logs,
+		comments:     comments,
+		views:        views,
+		maxBodyBytes: maxBodyBytes,
 	}
 }
 
 // RegisterRoutes registers the job routes
 func (h *Handler) RegisterRoutes(router *mux.Router) {
 	jobsRouter := router.PathPrefix("/jobs").Subrouter()
+	jobsRouter.Use(middleware.JobIDParams("id"))
 
 	jobsRouter.HandleFunc("", h.listJobs).Methods("GET", "OPTIONS")
 	jobsRouter.HandleFunc("", h.createJob).Methods("POST", "OPTIONS")
+	jobsRouter.HandleFunc("/summaries", h.listJobSummaries).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/search", h.searchJobs).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/stats", h.getStats).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/stats/timeseries", h.getTimeseries).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/quota", h.getQuota).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/events", h.watchJobs).Methods("GET", "OPTIONS")
 	jobsRouter.HandleFunc("/{id}", h.getJob).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/wait", h.waitForJob).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/attempts", h.getJobAttempts).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/children", h.listJobChildren).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/logs", h.getJobLogs).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/comments", h.listJobComments).Methods("GET", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/comments", h.createJobComment).Methods("POST", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/input", h.uploadJobInput).Methods("POST", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/download", h.downloadArtifact).Methods("GET", "OPTIONS")
 	jobsRouter.HandleFunc("/{id}/cancel", h.cancelJob).Methods("POST", "OPTIONS")
 	jobsRouter.HandleFunc("/{id}/retry", h.retryJob).Methods("POST", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/prioritize", h.prioritizeJob).Methods("POST", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/hold", h.holdJob).Methods("POST", "OPTIONS")
+	jobsRouter.HandleFunc("/{id}/release", h.releaseJob).Methods("POST", "OPTIONS")
 }
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/gorilla/mux"
+)
+
+// getJobAttempts handles GET /api/v1/jobs/{id}/attempts
+func (h *Handler) getJobAttempts(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if id == "" {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	attempts, err := h.service.GetJobAttempts(r.Context(), id)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, attempts)
+}
@@ -4,11 +4,12 @@ import (
 	"net/http"
 
 	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/services"
 	"github.com/gorilla/mux"
 )
 
 // cancelJob handles POST /api/v1/jobs/{id}/cancel
-// NOTE: This is a skeleton - candidate should implement the service method
 func (h *Handler) cancelJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -18,21 +19,36 @@ func (h *Handler) cancelJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job, err := h.service.CancelJob(r.Context(), id)
+	// The body is optional and may carry a cancellation reason
+	var req services.CancelJobRequest
+	if err := shared.DecodeOptionalJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+	req.Actor = auth.Actor(r.Context())
+
+	versions, ok := shared.IfMatchVersions(r, id)
+	if !ok {
+		shared.RespondErrorMessage(w, http.StatusPreconditionFailed, "job was modified since it was read")
+		return
+	}
+	req.IfMatch = versions
+
+	job, err := h.service.CancelJob(r.Context(), id, req)
 	if err != nil {
-		// TODO: Candidate should add proper error handling here
-		// - 404 for job not found
-		// - 409 for job that cannot be cancelled (wrong state)
-		// - 500 for internal errors
-		shared.RespondError(w, http.StatusInternalServerError, err)
+		shared.RespondServiceError(w, err, shared.ErrorMapping{
+			Err:     services.ErrInvalidJobState,
+			Status:  http.StatusConflict,
+			Message: "job cannot be cancelled in its current state",
+		})
 		return
 	}
 
-	shared.RespondJSON(w, http.StatusOK, job)
+	w.Header().Set("ETag", shared.ETag(job.ID.String(), job.UpdatedAt))
+	shared.RespondJSON(w, http.StatusOK, h.service.RedactJob(r.Context(), job))
 }
 
 // retryJob handles POST /api/v1/jobs/{id}/retry
-// NOTE: This is a skeleton - candidate should implement the service method
 func (h *Handler) retryJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -42,15 +58,29 @@ func (h *Handler) retryJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job, err := h.service.RetryJob(r.Context(), id)
+	versions, ok := shared.IfMatchVersions(r, id)
+	if !ok {
+		shared.RespondErrorMessage(w, http.StatusPreconditionFailed, "job was modified since it was read")
+		return
+	}
+
+	job, err := h.service.RetryJob(r.Context(), id, versions)
 	if err != nil {
-		// TODO: Candidate should add proper error handling here
-		// - 404 for job not found
-		// - 409 for job that cannot be retried (wrong state or max retries reached)
-		// - 500 for internal errors
-		shared.RespondError(w, http.StatusInternalServerError, err)
+		shared.RespondServiceError(w, err,
+			shared.ErrorMapping{
+				Err:     services.ErrInvalidJobState,
+				Status:  http.StatusConflict,
+				Message: "only failed jobs can be retried",
+			},
+			shared.ErrorMapping{
+				Err:     services.ErrMaxRetriesReached,
+				Status:  http.StatusConflict,
+				Message: "job has reached its maximum number of retries",
+			},
+		)
 		return
 	}
 
-	shared.RespondJSON(w, http.StatusOK, job)
+	w.Header().Set("ETag", shared.ETag(job.ID.String(), job.UpdatedAt))
+	shared.RespondJSON(w, http.StatusOK, h.service.RedactJob(r.Context(), job))
 }
@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// retryingJobsService retries a single failed job, checking If-Match versions
+// against its last modification time as the service does
+type retryingJobsService struct {
+	services.JobsService
+	job      models.Job
+	called   bool
+	versions []time.Time
+}
+
+func (s *retryingJobsService) RetryJob(ctx context.Context, id string, ifMatch []time.Time) (*models.Job, error) {
+	s.called, s.versions = true, ifMatch
+	if ifMatch != nil {
+		matched := false
+		for _, version := range ifMatch {
+			matched = matched || version.Equal(s.job.UpdatedAt)
+		}
+		if !matched {
+			return nil, services.ErrPreconditionFailed
+		}
+	}
+	retried := s.job
+	retried.Status = models.JobStatusPending
+	retried.RetryCount++
+	retried.UpdatedAt = s.job.UpdatedAt.Add(time.Second)
+	return &retried, nil
+}
+
+func (s *retryingJobsService) RedactJob(ctx context.Context, job *models.Job) *models.Job {
+	return job
+}
+
+func TestRetryJobIfMatch(t *testing.T) {
+	id, err := models.ParseJobID("65a1b2c3d4e5f60718293a4b")
+	if err != nil {
+		t.Fatalf("ParseJobID() error = %v", err)
+	}
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	current := shared.ETag(id.String(), updatedAt)
+	stale := shared.ETag(id.String(), updatedAt.Add(-time.Minute))
+	other := shared.ETag("65a1b2c3d4e5f60718293a4c", updatedAt)
+
+	tests := []struct {
+		name       string
+		ifMatch    string
+		wantStatus int
+		wantCalled bool
+	}{
+		{name: "matching version", ifMatch: current, wantStatus: http.StatusOK, wantCalled: true},
+		{name: "one of several versions", ifMatch: stale + ", " + current, wantStatus: http.StatusOK, wantCalled: true},
+		{name: "stale version", ifMatch: stale, wantStatus: http.StatusPreconditionFailed, wantCalled: true},
+		{name: "version of another job", ifMatch: other, wantStatus: http.StatusPreconditionFailed},
+		{name: "missing header", wantStatus: http.StatusOK, wantCalled: true},
+		{name: "any version", ifMatch: "*", wantStatus: http.StatusOK, wantCalled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &retryingJobsService{job: models.Job{ID: id, Status: models.JobStatusFailed, UpdatedAt: updatedAt}}
+			router := mux.NewRouter()
+			NewHandler(service, nil, nil, nil, 0).RegisterRoutes(router)
+
+			req := httptest.NewRequest(http.MethodPost, "/jobs/"+id.String()+"/retry", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d %s, want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if service.called != tt.wantCalled {
+				t.Errorf("RetryJob() called = %v, want %v", service.called, tt.wantCalled)
+			}
+			if (tt.ifMatch == "" || tt.ifMatch == "*") && service.versions != nil {
+				t.Errorf("RetryJob() versions = %v without a precondition, want nil", service.versions)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			want := shared.ETag(id.String(), updatedAt.Add(time.Second))
+			if etag := rec.Header().Get("ETag"); etag != want {
+				t.Errorf("ETag = %q, want the retried job's %q", etag, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/gorilla/mux"
+)
+
+// JobChildrenResponse represents the response for listing a job's children
+type JobChildrenResponse struct {
+	ParentID string       `json:"parentId"`
+	Children []models.Job `json:"children"`
+}
+
+// listJobChildren handles GET /api/v1/jobs/{id}/children
+func (h *Handler) listJobChildren(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if id == "" {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	children, err := h.service.ListChildren(r.Context(), id, limit)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	for i := range children {
+		children[i] = *h.service.RedactJob(r.Context(), &children[i])
+	}
+
+	shared.RespondJSON(w, http.StatusOK, JobChildrenResponse{
+		ParentID: id,
+		Children: children,
+	})
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// listJobComments handles GET /api/v1/jobs/{id}/comments
+func (h *Handler) listJobComments(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	comments, err := h.comments.ListComments(r.Context(), id)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, comments)
+}
+
+// createJobComment handles POST /api/v1/jobs/{id}/comments
+func (h *Handler) createJobComment(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req services.CreateCommentRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	comment, err := h.comments.AddComment(r.Context(), id, req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusCreated, comment)
+}
@@ -1,10 +1,11 @@
 package jobs
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
 	"github.com/fullstack-assessment/backend/services"
 )
 
@@ -12,16 +13,31 @@ import (
 func (h *Handler) createJob(w http.ResponseWriter, r *http.Request) {
 	var req services.CreateJobRequest
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		shared.RespondError(w, http.StatusBadRequest, err)
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
 		return
 	}
 
+	if principal := auth.PrincipalFromContext(r.Context()); principal != nil {
+		req.Tenant = principal.Tenant
+	}
+
 	job, err := h.service.CreateJob(r.Context(), req)
 	if err != nil {
-		shared.RespondError(w, http.StatusInternalServerError, err)
+		response := shared.ResolveError(err)
+		if original, ok := response.Data.(*models.Job); ok {
+			// A duplicate is answered with the original job, redacted like any other
+			response.Data = h.service.RedactJob(r.Context(), original)
+		}
+		response.Write(w)
+		return
+	}
+
+	// Held jobs are accepted but not queued until maintenance ends
+	if job.Status == models.JobStatusHeld {
+		shared.RespondJSON(w, http.StatusAccepted, h.service.RedactJob(r.Context(), job))
 		return
 	}
 
-	shared.RespondJSON(w, http.StatusCreated, job)
+	shared.RespondJSON(w, http.StatusCreated, h.service.RedactJob(r.Context(), job))
 }
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/gorilla/mux"
+)
+
+// downloadArtifact handles GET /api/v1/jobs/{id}/download
+func (h *Handler) downloadArtifact(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if id == "" {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	download, err := h.service.GetArtifactDownload(r.Context(), id)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	if download.RedirectURL != "" {
+		http.Redirect(w, r, download.RedirectURL, http.StatusFound)
+		return
+	}
+	defer download.Content.Close()
+
+	artifact := download.Artifact
+	w.Header().Set("Content-Type", artifact.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(artifact.Size, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+path.Ext(artifact.Key)))
+	w.Header().Set("X-Artifact-Checksum", artifact.Checksum)
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, download.Content)
+}
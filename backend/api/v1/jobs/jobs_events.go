@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// watchKeepAliveInterval is how often an idle event stream sends a comment, so
+// proxies do not close it
+const watchKeepAliveInterval = 15 * time.Second
+
+// jobEventPayload is the data of a job event sent to watchers
+type jobEventPayload struct {
+	JobID          models.JobID     `json:"jobId"`
+	JobType        models.JobType   `json:"jobType"`
+	Status         models.JobStatus `json:"status"`
+	PreviousStatus models.JobStatus `json:"previousStatus,omitempty"`
+	At             time.Time        `json:"at"`
+}
+
+// watchJobs handles GET /api/v1/jobs/events?status=failed&job_type=export.
+// It streams the creations and status changes of the matching jobs as
+// server-sent events named after the event type, until the client disconnects.
+func (h *Handler) watchJobs(w http.ResponseWriter, r *http.Request) {
+	var filter services.WatchFilter
+	query := r.URL.Query()
+	if value := query.Get("status"); value != "" {
+		for _, status := range strings.Split(value, ",") {
+			filter.Status = append(filter.Status, models.JobStatus(strings.TrimSpace(status)))
+		}
+	}
+	if value := query.Get("job_type"); value != "" {
+		for _, jobType := range strings.Split(value, ",") {
+			filter.JobType = append(filter.JobType, models.JobType(strings.TrimSpace(jobType)))
+		}
+	}
+
+	events, stop, err := h.service.WatchJobs(r.Context(), filter)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+	defer stop()
+
+	// The stream outlives the server's write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	controller.Flush()
+
+	keepAlive := time.NewTicker(watchKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				data, _ := json.Marshal(shared.Response{Status: "error", Error: "watcher fell behind, reconnect to resume"})
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+				controller.Flush()
+				return
+			}
+			data, _ := json.Marshal(jobEventPayload{
+				JobID:          event.JobID,
+				JobType:        event.JobType,
+				Status:         event.Status,
+				PreviousStatus: event.PreviousStatus,
+				At:             event.At,
+			})
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		controller.Flush()
+	}
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// jobFields is the set of job fields that can be requested with the fields query parameter
+var jobFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(models.Job{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// parseJobFields reads the fields query parameter. The job ID is always included
+// so projected jobs can still be told apart.
+func parseJobFields(r *http.Request) ([]string, error) {
+	fields, err := shared.ParseFields(r, jobFields)
+	if err != nil || fields == nil {
+		return fields, err
+	}
+	for _, field := range fields {
+		if field == "id" {
+			return fields, nil
+		}
+	}
+	return append([]string{"id"}, fields...), nil
+}
+
+// projectJob returns the job with only the requested fields, or the whole job when none were requested
+func projectJob(job *models.Job, fields []string) (interface{}, error) {
+	if fields == nil {
+		return job, nil
+	}
+	return shared.SelectFields(job, fields)
+}
@@ -1,11 +1,9 @@
 package jobs
 
 import (
-	"errors"
 	"net/http"
 
 	"github.com/fullstack-assessment/backend/api/shared"
-	"github.com/fullstack-assessment/backend/services"
 	"github.com/gorilla/mux"
 )
 
@@ -19,15 +17,28 @@ func (h *Handler) getJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	job, err := h.service.GetJob(r.Context(), id)
+	fields, err := parseJobFields(r)
+	if err != nil {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job, err := h.service.GetJobWithQueueInfo(r.Context(), id)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	// Queue estimates change without the job being updated, so they are never served from cache
+	if job.QueuePosition == nil && shared.CheckNotModified(w, r, shared.ETag(job.ID.String(), job.UpdatedAt)) {
+		return
+	}
+
+	response, err := projectJob(h.service.RedactJob(r.Context(), job), fields)
 	if err != nil {
-		if errors.Is(err, services.ErrJobNotFound) {
-			shared.RespondErrorMessage(w, http.StatusNotFound, "job not found")
-			return
-		}
 		shared.RespondError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	shared.RespondJSON(w, http.StatusOK, job)
+	shared.RespondJSON(w, http.StatusOK, response)
 }
@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// holdJob handles POST /api/v1/jobs/{id}/hold
+func (h *Handler) holdJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	// The body is optional and may carry the reason the job is held
+	var req services.HoldJobRequest
+	if err := shared.DecodeOptionalJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+	req.Actor = auth.Actor(r.Context())
+
+	versions, ok := shared.IfMatchVersions(r, id)
+	if !ok {
+		shared.RespondErrorMessage(w, http.StatusPreconditionFailed, "job was modified since it was read")
+		return
+	}
+	req.IfMatch = versions
+
+	job, err := h.service.HoldJob(r.Context(), id, req)
+	if err != nil {
+		respondHoldError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", shared.ETag(job.ID.String(), job.UpdatedAt))
+	shared.RespondJSON(w, http.StatusOK, h.service.RedactJob(r.Context(), job))
+}
+
+// releaseJob handles POST /api/v1/jobs/{id}/release
+func (h *Handler) releaseJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	versions, ok := shared.IfMatchVersions(r, id)
+	if !ok {
+		shared.RespondErrorMessage(w, http.StatusPreconditionFailed, "job was modified since it was read")
+		return
+	}
+
+	job, err := h.service.ReleaseJob(r.Context(), id, versions)
+	if err != nil {
+		respondHoldError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", shared.ETag(job.ID.String(), job.UpdatedAt))
+	shared.RespondJSON(w, http.StatusOK, h.service.RedactJob(r.Context(), job))
+}
+
+// respondHoldError maps hold and release errors to HTTP responses
+func respondHoldError(w http.ResponseWriter, err error) {
+	shared.RespondServiceError(w, err, shared.ErrorMapping{
+		Err:     services.ErrInvalidJobState,
+		Status:  http.StatusConflict,
+		Message: "completed jobs cannot be held",
+	})
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// uploadJobInput handles POST /api/v1/jobs/{id}/input
+// The input is either the "file" part of a multipart/form-data body or the
+// raw request body, named by the filename query parameter.
+func (h *Handler) uploadJobInput(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if id == "" {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	upload := services.InputUpload{
+		Filename:    r.URL.Query().Get("filename"),
+		ContentType: r.Header.Get("Content-Type"),
+		Content:     r.Body,
+	}
+	if mediaType, _, _ := mime.ParseMediaType(upload.ContentType); mediaType == "multipart/form-data" {
+		part, err := inputFilePart(r)
+		if err != nil {
+			shared.RespondError(w, http.StatusBadRequest, err)
+			return
+		}
+		defer part.Close()
+		upload.Filename = part.FileName()
+		upload.ContentType = part.Header.Get("Content-Type")
+		upload.Content = part
+	}
+
+	job, err := h.service.AttachInput(r.Context(), id, upload)
+	if err != nil {
+		shared.RespondServiceError(w, err, shared.ErrorMapping{
+			Err:     services.ErrInvalidJobState,
+			Status:  http.StatusConflict,
+			Message: "job is not awaiting input",
+		})
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, h.service.RedactJob(r.Context(), job))
+}
+
+// inputFilePart returns the "file" part of a multipart upload without buffering the parts before it
+func inputFilePart(r *http.Request) (*multipart.Part, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, &services.ValidationError{Field: "file", Message: "file part is required"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+		part.Close()
+	}
+}
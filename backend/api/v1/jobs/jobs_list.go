@@ -3,49 +3,162 @@ package jobs
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/models"
 	"github.com/fullstack-assessment/backend/services"
 )
 
 // ListJobsResponse represents the response for listing jobs
 type ListJobsResponse struct {
-	Jobs  interface{} `json:"jobs"`
-	Total int64       `json:"total"`
-	Page  int         `json:"page"`
-	Limit int         `json:"limit"`
+	Jobs    interface{} `json:"jobs"`
+	Total   *int64      `json:"total,omitempty"`
+	HasMore bool        `json:"hasMore"`
+	Page    int         `json:"page"`
+	Limit   int         `json:"limit"`
+}
+
+// ListJobSummariesResponse represents the response for listing job summaries
+type ListJobSummariesResponse struct {
+	Jobs    []models.JobSummary `json:"jobs"`
+	Total   *int64              `json:"total,omitempty"`
+	HasMore bool                `json:"hasMore"`
+	Page    int                 `json:"page"`
+	Limit   int                 `json:"limit"`
 }
 
 // listJobs handles GET /api/v1/jobs
 func (h *Handler) listJobs(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	filter, err := h.parseListFilter(r)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
 	}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 10
+	fields, err := parseJobFields(r)
+	if err != nil {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	filter := services.JobFilter{
-		Page:  page,
-		Limit: limit,
+	// Large fields are only loaded when included, or requested through fields
+	if value := r.URL.Query().Get("include"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			filter.Include = append(filter.Include, strings.TrimSpace(field))
+		}
+	}
+	for _, field := range fields {
+		if field == "config" || field == "checkpoint" {
+			filter.Include = append(filter.Include, field)
+		}
 	}
 
-	jobs, total, err := h.service.ListJobs(r.Context(), filter)
+	result, err := h.service.ListJobs(r.Context(), filter)
 	if err != nil {
-		shared.RespondError(w, http.StatusInternalServerError, err)
+		shared.RespondServiceError(w, err)
 		return
 	}
 
+	jobs := make([]interface{}, len(result.Jobs))
+	for i := range result.Jobs {
+		jobs[i], err = projectJob(h.service.RedactJob(r.Context(), &result.Jobs[i]), fields)
+		if err != nil {
+			shared.RespondError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
 	response := ListJobsResponse{
-		Jobs:  jobs,
-		Total: total,
-		Page:  page,
-		Limit: limit,
+		Jobs:    jobs,
+		Total:   result.Total,
+		HasMore: result.HasMore,
+		Page:    result.Page,
+		Limit:   result.Limit,
 	}
 
 	shared.RespondJSON(w, http.StatusOK, response)
 }
+
+// listJobSummaries handles GET /api/v1/jobs/summaries. It accepts the filters
+// of GET /api/v1/jobs and returns the fields dashboard lists show.
+func (h *Handler) listJobSummaries(w http.ResponseWriter, r *http.Request) {
+	filter, err := h.parseListFilter(r)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	result, err := h.service.ListJobSummaries(r.Context(), filter)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, ListJobSummariesResponse{
+		Jobs:    result.Summaries,
+		Total:   result.Total,
+		HasMore: result.HasMore,
+		Page:    result.Page,
+		Limit:   result.Limit,
+	})
+}
+
+// parseListFilter reads the pagination and filter parameters of a list request
+func (h *Handler) parseListFilter(r *http.Request) (services.JobFilter, error) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	// The total count is included unless explicitly disabled
+	includeTotal := true
+	if value := query.Get("include_total"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return services.JobFilter{}, &shared.RequestError{StatusCode: http.StatusBadRequest, Message: "include_total must be a boolean"}
+		}
+		includeTotal = parsed
+	}
+
+	filter := services.JobFilter{
+		Page:         page,
+		Limit:        limit,
+		IncludeTotal: includeTotal,
+	}
+
+	// A saved view supplies the filter; parameters given alongside it override its values
+	if name := query.Get("view"); name != "" {
+		view, err := h.views.GetView(r.Context(), name)
+		if err != nil {
+			return services.JobFilter{}, err
+		}
+		filter.ViewFilter = view.Filter
+	}
+	if value := query.Get("status"); value != "" {
+		filter.Status = nil
+		for _, status := range strings.Split(value, ",") {
+			filter.Status = append(filter.Status, models.JobStatus(strings.TrimSpace(status)))
+		}
+	}
+	if value := query.Get("job_type"); value != "" {
+		filter.JobType = nil
+		for _, jobType := range strings.Split(value, ",") {
+			filter.JobType = append(filter.JobType, models.JobType(strings.TrimSpace(jobType)))
+		}
+	}
+	if value := query.Get("active_only"); value != "" {
+		activeOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			return services.JobFilter{}, &shared.RequestError{StatusCode: http.StatusBadRequest, Message: "active_only must be a boolean"}
+		}
+		filter.ActiveOnly = &activeOnly
+	}
+	if value := query.Get("created_within"); value != "" {
+		filter.CreatedWithin = value
+	}
+	if value := query.Get("sort"); value != "" {
+		filter.Sort = value
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,122 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// logFollowInterval is how often new log lines are polled while following
+const logFollowInterval = time.Second
+
+// getJobLogs handles GET /api/v1/jobs/{id}/logs.
+// With follow=true the lines are streamed as server-sent events until the attempt finishes.
+func (h *Handler) getJobLogs(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	query := r.URL.Query()
+	var logQuery services.LogQuery
+	for name, target := range map[string]*int{
+		"attempt": &logQuery.Attempt,
+		"tail":    &logQuery.Tail,
+		"after":   &logQuery.AfterSeq,
+		"limit":   &logQuery.Limit,
+	} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				shared.RespondErrorMessage(w, http.StatusBadRequest, name+" must be a number")
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	follow := false
+	if value := query.Get("follow"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			shared.RespondErrorMessage(w, http.StatusBadRequest, "follow must be a boolean")
+			return
+		}
+		follow = parsed
+	}
+
+	logs, err := h.logs.GetLogs(r.Context(), id, logQuery)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	if !follow {
+		shared.RespondJSON(w, http.StatusOK, logs)
+		return
+	}
+
+	h.followJobLogs(w, r, id, logQuery, logs)
+}
+
+// followJobLogs streams the lines of an attempt as server-sent events, starting with first
+func (h *Handler) followJobLogs(w http.ResponseWriter, r *http.Request, id string, query services.LogQuery, first *services.JobLogs) {
+	// The stream outlives the server's write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	query.Tail = 0
+
+	logs := first
+	ticker := time.NewTicker(logFollowInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, line := range logs.Lines {
+			data, _ := json.Marshal(line)
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", line.Seq, data)
+		}
+		query.AfterSeq = logs.NextSeq
+
+		// Stay on the attempt being followed, even if a newer one starts
+		if query.Attempt == 0 {
+			query.Attempt = logs.Attempt
+		}
+
+		if !logs.Running && !logs.HasMore {
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+			controller.Flush()
+			return
+		}
+		controller.Flush()
+
+		// A full page is followed by the next one right away
+		if !logs.HasMore {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+
+		var err error
+		logs, err = h.logs.GetLogs(r.Context(), id, query)
+		if err != nil {
+			data, _ := json.Marshal(shared.Response{Status: "error", Error: err.Error()})
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+			controller.Flush()
+			return
+		}
+	}
+}
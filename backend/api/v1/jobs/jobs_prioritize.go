@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// prioritizeJob handles POST /api/v1/jobs/{id}/prioritize
+func (h *Handler) prioritizeJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if id == "" {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	versions, ok := shared.IfMatchVersions(r, id)
+	if !ok {
+		shared.RespondErrorMessage(w, http.StatusPreconditionFailed, "job was modified since it was read")
+		return
+	}
+
+	job, err := h.service.PrioritizeJob(r.Context(), id, versions)
+	if err != nil {
+		shared.RespondServiceError(w, err, shared.ErrorMapping{
+			Err:     services.ErrInvalidJobState,
+			Status:  http.StatusConflict,
+			Message: "only pending jobs can be prioritized",
+		})
+		return
+	}
+
+	w.Header().Set("ETag", shared.ETag(job.ID.String(), job.UpdatedAt))
+	shared.RespondJSON(w, http.StatusOK, h.service.RedactJob(r.Context(), job))
+}
@@ -0,0 +1,18 @@
+package jobs
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+)
+
+// getQuota handles GET /api/v1/jobs/quota
+func (h *Handler) getQuota(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.GetQuotaStatus(r.Context())
+	if err != nil {
+		shared.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// SearchJobsResponse represents the response for searching jobs
+type SearchJobsResponse struct {
+	Results []services.SearchResult `json:"results"`
+	Query   string                  `json:"query"`
+}
+
+// searchJobs handles GET /api/v1/jobs/search
+func (h *Handler) searchJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	filter := services.SearchFilter{
+		Query: query.Get("q"),
+		Limit: limit,
+	}
+
+	results, err := h.service.SearchJobs(r.Context(), filter)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	for i := range results {
+		results[i].Job = *h.service.RedactJob(r.Context(), &results[i].Job)
+	}
+
+	shared.RespondJSON(w, http.StatusOK, SearchJobsResponse{
+		Results: results,
+		Query:   filter.Query,
+	})
+}
@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// getStats handles GET /api/v1/jobs/stats
+func (h *Handler) getStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.service.GetStats(r.Context())
+	if err != nil {
+		shared.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, stats)
+}
+
+// getTimeseries handles GET /api/v1/jobs/stats/timeseries
+func (h *Handler) getTimeseries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	interval := services.DefaultTimeseriesInterval
+	if value := query.Get("interval"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			shared.RespondErrorMessage(w, http.StatusBadRequest, "interval must be a duration such as 5m")
+			return
+		}
+		interval = parsed
+	}
+	window := services.DefaultTimeseriesWindow
+	if value := query.Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			shared.RespondErrorMessage(w, http.StatusBadRequest, "window must be a duration such as 24h")
+			return
+		}
+		window = parsed
+	}
+
+	series, err := h.service.GetTimeseries(r.Context(), interval, window)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, series)
+}
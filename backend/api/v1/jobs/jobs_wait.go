@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// waitWriteMargin is added to the wait timeout for writing the response
+const waitWriteMargin = 10 * time.Second
+
+// waitForJob handles GET /api/v1/jobs/{id}/wait?timeout=30s.
+// It responds 200 with the job once it is terminal, or 202 with its current
+// state if it is still running when the timeout elapses.
+func (h *Handler) waitForJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		shared.RespondErrorMessage(w, http.StatusBadRequest, "job ID is required")
+		return
+	}
+
+	timeout := services.DefaultWaitTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			shared.RespondErrorMessage(w, http.StatusBadRequest, "timeout must be a duration such as 30s")
+			return
+		}
+		timeout = parsed
+	}
+
+	// The wait outlives the server's write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Now().Add(timeout + waitWriteMargin))
+
+	job, finished, err := h.service.WaitForJob(r.Context(), id, timeout)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !finished {
+		status = http.StatusAccepted
+	}
+	shared.RespondJSON(w, status, h.service.RedactJob(r.Context(), job))
+}
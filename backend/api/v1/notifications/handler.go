@@ -0,0 +1,27 @@
+package notifications
+
+import (
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for tenant notification settings
+type Handler struct {
+	service      services.TenantNotificationsService
+	maxBodyBytes int64
+}
+
+// NewHandler creates a new tenant notifications handler
+func NewHandler(service services.TenantNotificationsService, maxBodyBytes int64) *Handler {
+	return &Handler{
+		service:      service,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+// RegisterRoutes registers the tenant notification routes
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/notifications", h.getNotifications).Methods("GET", "OPTIONS")
+	router.HandleFunc("/notifications", h.setNotifications).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/notifications", h.deleteNotifications).Methods("DELETE", "OPTIONS")
+}
@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// getNotifications handles GET /api/v1/notifications
+func (h *Handler) getNotifications(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.service.GetNotifications(r.Context())
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, settings)
+}
+
+// setNotifications handles PUT /api/v1/notifications
+func (h *Handler) setNotifications(w http.ResponseWriter, r *http.Request) {
+	var req services.NotifyRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	settings, err := h.service.SetNotifications(r.Context(), req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, settings)
+}
+
+// deleteNotifications handles DELETE /api/v1/notifications
+func (h *Handler) deleteNotifications(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteNotifications(r.Context()); err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,31 @@
+package views
+
+import (
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for saved views
+type Handler struct {
+	service      services.ViewsService
+	maxBodyBytes int64
+}
+
+// NewHandler creates a new saved views handler
+func NewHandler(service services.ViewsService, maxBodyBytes int64) *Handler {
+	return &Handler{
+		service:      service,
+		maxBodyBytes: maxBodyBytes,
+	}
+}
+
+// RegisterRoutes registers the saved view routes
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	viewsRouter := router.PathPrefix("/views").Subrouter()
+
+	viewsRouter.HandleFunc("", h.listViews).Methods("GET", "OPTIONS")
+	viewsRouter.HandleFunc("", h.createView).Methods("POST", "OPTIONS")
+	viewsRouter.HandleFunc("/{name}", h.getView).Methods("GET", "OPTIONS")
+	viewsRouter.HandleFunc("/{name}", h.updateView).Methods("PUT", "OPTIONS")
+	viewsRouter.HandleFunc("/{name}", h.deleteView).Methods("DELETE", "OPTIONS")
+}
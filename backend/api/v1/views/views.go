@@ -0,0 +1,75 @@
+package views
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// listViews handles GET /api/v1/views
+func (h *Handler) listViews(w http.ResponseWriter, r *http.Request) {
+	views, err := h.service.ListViews(r.Context())
+	if err != nil {
+		shared.RespondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, views)
+}
+
+// createView handles POST /api/v1/views
+func (h *Handler) createView(w http.ResponseWriter, r *http.Request) {
+	var req services.ViewRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	view, err := h.service.CreateView(r.Context(), req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusCreated, view)
+}
+
+// getView handles GET /api/v1/views/{name}
+func (h *Handler) getView(w http.ResponseWriter, r *http.Request) {
+	view, err := h.service.GetView(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, view)
+}
+
+// updateView handles PUT /api/v1/views/{name}
+func (h *Handler) updateView(w http.ResponseWriter, r *http.Request) {
+	var req services.ViewRequest
+	if err := shared.DecodeJSON(w, r, h.maxBodyBytes, &req); err != nil {
+		shared.RespondDecodeError(w, err)
+		return
+	}
+
+	view, err := h.service.UpdateView(r.Context(), mux.Vars(r)["name"], req)
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, view)
+}
+
+// deleteView handles DELETE /api/v1/views/{name}
+func (h *Handler) deleteView(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteView(r.Context(), mux.Vars(r)["name"]); err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,27 @@
+package webhooks
+
+import (
+	"github.com/fullstack-assessment/backend/api/middleware"
+	"github.com/fullstack-assessment/backend/services"
+	"github.com/gorilla/mux"
+)
+
+// Handler handles HTTP requests for the deliveries of alert rule webhooks
+type Handler struct {
+	alerts services.AlertsService
+}
+
+// NewHandler creates a new webhooks handler
+func NewHandler(alerts services.AlertsService) *Handler {
+	return &Handler{alerts: alerts}
+}
+
+// RegisterRoutes registers the webhook routes. A webhook is identified by the
+// ID of the alert rule it belongs to, and is reachable by the rule's tenant.
+func (h *Handler) RegisterRoutes(router *mux.Router) {
+	webhooksRouter := router.PathPrefix("/webhooks").Subrouter()
+	webhooksRouter.Use(middleware.ObjectIDParams("id", "deliveryId"))
+
+	webhooksRouter.HandleFunc("/{id}/deliveries", h.listDeliveries).Methods("GET", "OPTIONS")
+	webhooksRouter.HandleFunc("/{id}/deliveries/{deliveryId}/redeliver", h.redeliver).Methods("POST", "OPTIONS")
+}
@@ -0,0 +1,31 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/gorilla/mux"
+)
+
+// listDeliveries handles GET /api/v1/webhooks/{id}/deliveries
+func (h *Handler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := h.alerts.ListDeliveries(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusOK, deliveries)
+}
+
+// redeliver handles POST /api/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver
+func (h *Handler) redeliver(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	delivery, err := h.alerts.Redeliver(r.Context(), vars["id"], vars["deliveryId"])
+	if err != nil {
+		shared.RespondServiceError(w, err)
+		return
+	}
+
+	shared.RespondJSON(w, http.StatusCreated, delivery)
+}
@@ -0,0 +1,446 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidToken is returned for bearer tokens that fail verification
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// clockSkew is how far token timestamps may be off from the local clock
+const clockSkew = time.Minute
+
+// jwksMinRefresh is the least time between fetches of the signing keys, so
+// tokens signed with unknown keys cannot make every request fetch them
+const jwksMinRefresh = time.Minute
+
+// jwksFetchTimeout bounds one fetch of the signing keys, discovery included
+const jwksFetchTimeout = 10 * time.Second
+
+// OIDCConfig holds the settings of an OIDCVerifier
+type OIDCConfig struct {
+	Issuer   string
+	Audience string
+	// JWKSURL is discovered from the issuer's openid-configuration when empty
+	JWKSURL     string
+	CacheTTL    time.Duration
+	TenantClaim string
+	RolesClaim  string
+	AdminRole   string
+}
+
+// OIDCVerifier verifies OIDC bearer tokens signed by an issuer and maps their
+// claims to a Principal. The issuer's signing keys are cached for CacheTTL and
+// fetched again early when a token names a key that is not cached. Keys are
+// fetched in the background, one fetch at a time, so a slow issuer only holds
+// up the tokens signed with keys that are not cached.
+type OIDCVerifier struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu          sync.Mutex
+	jwksURL     string
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refreshing is closed when the fetch in flight ends, and nil without one
+	refreshing chan struct{}
+	// fetchErr is the error of the last fetch
+	fetchErr error
+}
+
+// NewOIDCVerifier creates a verifier for the tokens of config.Issuer
+func NewOIDCVerifier(config OIDCConfig) *OIDCVerifier {
+	return &OIDCVerifier{
+		config:  config,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		jwksURL: config.JWKSURL,
+	}
+}
+
+// LooksLikeToken reports whether a bearer credential is a JWT rather than an API key
+func LooksLikeToken(credential string) bool {
+	return strings.Count(credential, ".") == 2
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks a token's signature, issuer, audience and lifetime, and
+// returns the principal of its subject
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := v.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := v.checkClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, ErrInvalidToken
+	}
+	tenant, _ := claimValue(claims, v.config.TenantClaim).(string)
+
+	var roles []string
+	for _, role := range claimStrings(claimValue(claims, v.config.RolesClaim)) {
+		if role == v.config.AdminRole {
+			role = RoleAdmin
+		}
+		roles = append(roles, role)
+	}
+
+	return &Principal{Subject: subject, Tenant: tenant, Roles: roles}, nil
+}
+
+// checkClaims checks the registered claims of a token at now
+func (v *OIDCVerifier) checkClaims(claims map[string]interface{}, now time.Time) error {
+	if issuer, _ := claims["iss"].(string); issuer != v.config.Issuer {
+		return ErrInvalidToken
+	}
+
+	audienceMatched := false
+	for _, audience := range claimStrings(claims["aud"]) {
+		audienceMatched = audienceMatched || audience == v.config.Audience
+	}
+	if !audienceMatched {
+		return ErrInvalidToken
+	}
+
+	expires, ok := claims["exp"].(float64)
+	if !ok || now.Add(-clockSkew).After(time.Unix(int64(expires), 0)) {
+		return ErrInvalidToken
+	}
+	if notBefore, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(notBefore), 0)) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// signingKey returns the cached key with the given ID, fetching the issuer's
+// keys when the cache expired or does not know the ID. A cached key is returned
+// without waiting for the fetch; an unknown one waits for it, or for ctx.
+func (v *OIDCVerifier) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	key, ok := v.lookupKey(kid)
+	if ok && time.Since(v.fetchedAt) < v.config.CacheTTL {
+		v.mu.Unlock()
+		return key, nil
+	}
+	done := v.refreshing
+	if done == nil && time.Since(v.attemptedAt) >= jwksMinRefresh {
+		done = make(chan struct{})
+		v.refreshing = done
+		v.attemptedAt = time.Now()
+		go v.refresh(done, v.jwksURL)
+	}
+	v.mu.Unlock()
+
+	if ok {
+		// Keep using the cached key while it is refreshed, or the issuer is unreachable
+		return key, nil
+	}
+	if done == nil {
+		return nil, ErrInvalidToken
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if key, ok = v.lookupKey(kid); ok {
+		return key, nil
+	}
+	if v.fetchErr != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", v.fetchErr)
+	}
+	return nil, ErrInvalidToken
+}
+
+// refresh fetches the issuer's keys on its own timeout, independent of the
+// request that needed them, stores them and closes done
+func (v *OIDCVerifier) refresh(done chan struct{}, jwksURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+
+	keys, jwksURL, err := v.fetchKeys(ctx, jwksURL)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	defer close(done)
+	v.refreshing = nil
+	v.fetchErr = err
+	if err != nil {
+		log.Printf("Failed to refresh OIDC signing keys: %v", err)
+		return
+	}
+	v.jwksURL = jwksURL
+	v.keys = keys
+	v.fetchedAt = time.Now()
+}
+
+// lookupKey finds a cached key; tokens without a key ID match the only cached key
+func (v *OIDCVerifier) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys returns the issuer's current keys, and the URL they were fetched
+// from, which is discovered when jwksURL is empty
+func (v *OIDCVerifier) fetchKeys(ctx context.Context, jwksURL string) (map[string]crypto.PublicKey, string, error) {
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, strings.TrimSuffix(v.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return nil, "", fmt.Errorf("discovery failed: %w", err)
+		}
+		if discovery.JWKSURI == "" {
+			return nil, "", errors.New("discovery document has no jwks_uri")
+		}
+		jwksURL = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, jwksURL, &set); err != nil {
+		return nil, "", err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("Skipping OIDC signing key %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, jwksURL, nil
+}
+
+func (v *OIDCVerifier) getJSON(ctx context.Context, url string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// verifySignature checks a JWS signature made with one of the RS* or ES* algorithms
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	var hashID crypto.Hash
+	var h hash.Hash
+	switch alg[2:] {
+	case "256":
+		hashID, h = crypto.SHA256, sha256.New()
+	case "384":
+		hashID, h = crypto.SHA384, sha512.New384()
+	case "512":
+		hashID, h = crypto.SHA512, sha512.New()
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch {
+	case strings.HasPrefix(alg, "RS"):
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key does not match algorithm")
+		}
+		return rsa.VerifyPKCS1v15(rsaKey, hashID, digest, signature)
+	case strings.HasPrefix(alg, "ES"):
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key does not match algorithm")
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("malformed signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+func decodeSegment(segment string, dst interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("malformed key parameter")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// claimValue returns a claim by name, following dots into nested objects
+func claimValue(claims map[string]interface{}, name string) interface{} {
+	if name == "" {
+		return nil
+	}
+	if value, ok := claims[name]; ok {
+		return value
+	}
+	head, rest, ok := strings.Cut(name, ".")
+	if !ok {
+		return nil
+	}
+	nested, _ := claims[head].(map[string]interface{})
+	return claimValue(nested, rest)
+}
+
+// claimStrings reads a claim holding a string array, or a single
+// space-separated string
+func claimStrings(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
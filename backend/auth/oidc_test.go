@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "jobs-dashboard"
+)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+// jwks is the key set publishing the public halves of the test keys as "rsa" and "ec"
+func (k testKeys) jwks() map[string]interface{} {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	return map[string]interface{}{"keys": []map[string]string{
+		{
+			"kty": "RSA", "kid": "rsa", "use": "sig",
+			"n": encode(k.rsa.N.Bytes()),
+			"e": encode(big.NewInt(int64(k.rsa.E)).Bytes()),
+		},
+		{
+			"kty": "EC", "kid": "ec", "use": "sig", "crv": "P-256",
+			"x": encode(k.ec.X.FillBytes(make([]byte, 32))),
+			"y": encode(k.ec.Y.FillBytes(make([]byte, 32))),
+		},
+	}}
+}
+
+func newJWKSServer(t *testing.T, keys testKeys) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(keys.jwks())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestVerifier(jwksURL string) *OIDCVerifier {
+	return NewOIDCVerifier(OIDCConfig{
+		Issuer:      testIssuer,
+		Audience:    testAudience,
+		JWKSURL:     jwksURL,
+		CacheTTL:    time.Hour,
+		TenantClaim: "tenant",
+		RolesClaim:  "roles",
+		AdminRole:   "jobs-admin",
+	})
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":    testIssuer,
+		"aud":    testAudience,
+		"sub":    "user-1",
+		"tenant": "acme",
+		"roles":  []string{"jobs-admin"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func encodeSegment(t *testing.T, value interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// signToken builds a token with the given header and claims, signed by sign
+func signToken(t *testing.T, header, claims map[string]interface{}, sign func(signed string) []byte) string {
+	t.Helper()
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(signed))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey) func(string) []byte {
+	return func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signature
+	}
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey) func(string) []byte {
+	return func(signed string) []byte {
+		digest := sha256.Sum256([]byte(signed))
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+}
+
+func TestOIDCVerifierVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	server := newJWKSServer(t, keys)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&keys.rsa.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signHS256WithPublicKey := func(signed string) []byte {
+		mac := hmac.New(sha256.New, publicDER)
+		mac.Write([]byte(signed))
+		return mac.Sum(nil)
+	}
+	truncated := func(sign func(string) []byte) func(string) []byte {
+		return func(signed string) []byte {
+			signature := sign(signed)
+			return signature[:len(signature)-8]
+		}
+	}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		claims[name] = value
+		return claims
+	}
+
+	rsaHeader := map[string]interface{}{"alg": "RS256", "kid": "rsa"}
+	ecHeader := map[string]interface{}{"alg": "ES256", "kid": "ec"}
+
+	tests := []struct {
+		name    string
+		header  map[string]interface{}
+		claims  map[string]interface{}
+		sign    func(string) []byte
+		wantErr bool
+	}{
+		{name: "valid RS256", header: rsaHeader, claims: validClaims(), sign: signRS256(t, keys.rsa)},
+		{name: "valid ES256", header: ecHeader, claims: validClaims(), sign: signES256(t, keys.ec)},
+		{
+			name:    "alg none",
+			header:  map[string]interface{}{"alg": "none", "kid": "rsa"},
+			claims:  validClaims(),
+			sign:    func(string) []byte { return nil },
+			wantErr: true,
+		},
+		{
+			name:    "HS256 with the public key as secret",
+			header:  map[string]interface{}{"alg": "HS256", "kid": "rsa"},
+			claims:  validClaims(),
+			sign:    signHS256WithPublicKey,
+			wantErr: true,
+		},
+		{
+			name:    "RS256 header on an EC key",
+			header:  map[string]interface{}{"alg": "RS256", "kid": "ec"},
+			claims:  validClaims(),
+			sign:    signES256(t, keys.ec),
+			wantErr: true,
+		},
+		{name: "wrong issuer", header: rsaHeader, claims: withClaim("iss", "https://evil.example.com"), sign: signRS256(t, keys.rsa), wantErr: true},
+		{name: "wrong audience", header: rsaHeader, claims: withClaim("aud", "other-app"), sign: signRS256(t, keys.rsa), wantErr: true},
+		{name: "audience list without ours", header: rsaHeader, claims: withClaim("aud", []string{"a", "b"}), sign: signRS256(t, keys.rsa), wantErr: true},
+		{name: "audience list with ours", header: rsaHeader, claims: withClaim("aud", []string{"a", testAudience}), sign: signRS256(t, keys.rsa)},
+		{name: "expired", header: rsaHeader, claims: withClaim("exp", time.Now().Add(-time.Hour).Unix()), sign: signRS256(t, keys.rsa), wantErr: true},
+		{name: "expired within clock skew", header: rsaHeader, claims: withClaim("exp", time.Now().Add(-clockSkew/2).Unix()), sign: signRS256(t, keys.rsa)},
+		{name: "no expiry", header: rsaHeader, claims: withClaim("exp", nil), sign: signRS256(t, keys.rsa), wantErr: true},
+		{name: "not yet valid", header: rsaHeader, claims: withClaim("nbf", time.Now().Add(time.Hour).Unix()), sign: signRS256(t, keys.rsa), wantErr: true},
+		{name: "no subject", header: rsaHeader, claims: withClaim("sub", ""), sign: signRS256(t, keys.rsa), wantErr: true},
+		{
+			name:    "unknown kid",
+			header:  map[string]interface{}{"alg": "RS256", "kid": "rotated-away"},
+			claims:  validClaims(),
+			sign:    signRS256(t, keys.rsa),
+			wantErr: true,
+		},
+		{name: "truncated RS256 signature", header: rsaHeader, claims: validClaims(), sign: truncated(signRS256(t, keys.rsa)), wantErr: true},
+		{name: "truncated ES256 signature", header: ecHeader, claims: validClaims(), sign: truncated(signES256(t, keys.ec)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := newTestVerifier(server.URL)
+			token := signToken(t, tt.header, tt.claims, tt.sign)
+
+			principal, err := verifier.Verify(context.Background(), token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if principal.Subject != "user-1" || principal.Tenant != "acme" || !principal.HasRole(RoleAdmin) {
+				t.Errorf("Verify() principal = %+v, want user-1 of acme with the admin role", principal)
+			}
+		})
+	}
+}
+
+func TestOIDCVerifierTamperedClaims(t *testing.T) {
+	keys := newTestKeys(t)
+	server := newJWKSServer(t, keys)
+	verifier := newTestVerifier(server.URL)
+
+	token := signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa"}, validClaims(), signRS256(t, keys.rsa))
+	claims := validClaims()
+	claims["sub"] = "someone-else"
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + encodeSegment(t, claims) + "." + parts[2]
+
+	if _, err := verifier.Verify(context.Background(), tampered); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestOIDCVerifierCachedKeyDoesNotWaitForSlowIssuer(t *testing.T) {
+	keys := newTestKeys(t)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			// Every fetch after the first hangs until the test ends
+			<-release
+		}
+		json.NewEncoder(w).Encode(keys.jwks())
+	}))
+	defer server.Close()
+	defer close(release)
+
+	verifier := newTestVerifier(server.URL)
+	token := signToken(t, map[string]interface{}{"alg": "RS256", "kid": "rsa"}, validClaims(), signRS256(t, keys.rsa))
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	// Expire the cache so the next token starts a fetch that hangs
+	verifier.mu.Lock()
+	verifier.fetchedAt = time.Now().Add(-2 * time.Hour)
+	verifier.attemptedAt = time.Now().Add(-2 * jwksMinRefresh)
+	verifier.mu.Unlock()
+
+	verified := make(chan error, 1)
+	go func() {
+		_, err := verifier.Verify(context.Background(), token)
+		verified <- err
+	}()
+	select {
+	case err := <-verified:
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Verify() with a cached key waited for the signing keys to be fetched")
+	}
+
+	// A token with an unknown key waits for the fetch, but only until its context ends
+	unknown := signToken(t, map[string]interface{}{"alg": "RS256", "kid": "new"}, validClaims(), signRS256(t, keys.rsa))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := verifier.Verify(ctx, unknown); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Verify() error = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import "context"
+
+// AnonymousSubject identifies requests made without an authenticated principal
+const AnonymousSubject = "anonymous"
+
+// RoleAdmin is granted to operators allowed to see unredacted job data
+const RoleAdmin = "admin"
+
+// Principal represents the authenticated caller of a request
+type Principal struct {
+	Subject string
+	Tenant  string
+	Roles   []string
+	// Scopes limits what the principal's credential may do; nil means unlimited
+	Scopes []string
+}
+
+// HasRole checks if the principal has the given role
+func (p *Principal) HasRole(role string) bool {
+	if p == nil {
+		return false
+	}
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, or nil if the request is unauthenticated
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// Actor returns the subject of the principal in ctx, or AnonymousSubject
+func Actor(ctx context.Context) string {
+	if principal := PrincipalFromContext(ctx); principal != nil && principal.Subject != "" {
+		return principal.Subject
+	}
+	return AnonymousSubject
+}
+
+// Tenant returns the tenant of the principal in ctx, or an empty string for unauthenticated requests
+func Tenant(ctx context.Context) string {
+	if principal := PrincipalFromContext(ctx); principal != nil {
+		return principal.Tenant
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+// Package buildinfo describes the build of the running backend binary
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+
+	"github.com/fullstack-assessment/backend/metrics"
+)
+
+// Build information, set with
+// -ldflags "-X github.com/fullstack-assessment/backend/buildinfo.Version=... -X ...Commit=... -X ...Date=..."
+var (
+	Version = "dev"
+	Commit  = ""
+	// Date is the build time in RFC 3339
+	Date = ""
+)
+
+var buildInfo = metrics.NewGauge("backend_build_info", "Build of the running backend, always 1.", "version", "commit")
+
+// Info describes a build, as served by GET /version
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build of the running binary. Without a commit set at link
+// time, the revision the Go toolchain stamped into the binary is used.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+	if info.Commit != "" {
+		return info
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// RecordMetric exports the build as the backend_build_info metric
+func RecordMetric() {
+	info := Get()
+	buildInfo.Set(1, info.Version, info.Commit)
+}
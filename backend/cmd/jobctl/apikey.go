@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/repositories"
+	"github.com/fullstack-assessment/backend/services"
+)
+
+// runCreateKey creates an API key directly in the database. Anonymous callers
+// cannot create keys through the API, so the first admin key is created here.
+func runCreateKey(args []string) error {
+	flags := flag.NewFlagSet("create-key", flag.ExitOnError)
+	tenant := flags.String("tenant", "", "tenant the key belongs to")
+	name := flags.String("name", "", "name identifying the key")
+	scopes := flags.String("scopes", "admin", "comma-separated scopes: jobs:read, jobs:write, admin")
+	rateLimit := flags.Int("rate-limit", 0, "requests per minute allowed with the key, 0 for unlimited")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	db, disconnect, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	repo := repositories.NewAPIKeysRepository(db, repositories.Config{})
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	// jobctl runs with direct database access, so it may grant any scope
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Subject: "jobctl", Tenant: *tenant})
+	key, err := services.NewAPIKeysService(repo).CreateAPIKey(ctx, services.CreateAPIKeyRequest{
+		Name:               *name,
+		Scopes:             parseList(*scopes),
+		RateLimitPerMinute: *rateLimit,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("created API key %s for tenant %q with scopes %v\n%s\n", key.Prefix, key.Tenant, key.Scopes, key.Key)
+	return nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// backupFilter selects the documents of a backup
+type backupFilter struct {
+	tenant   string
+	since    time.Time
+	until    time.Time
+	statuses []string
+	types    []string
+}
+
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := flags.String("out", "", "snapshot file to write, - for stdout")
+	groupList := flags.String("groups", "jobs,dlq,audit", "comma-separated groups to back up: jobs, dlq, audit")
+	tenant := flags.String("tenant", "", "only jobs of this tenant")
+	since := flags.String("since", "", "only jobs created, and messages dead-lettered, at or after this date or RFC 3339 time")
+	until := flags.String("until", "", "only jobs created, and messages dead-lettered, before this date or RFC 3339 time")
+	statuses := flags.String("status", "", "only jobs in these comma-separated statuses")
+	types := flags.String("type", "", "only jobs of these comma-separated types")
+	flags.Parse(args)
+
+	if *out == "" {
+		return fmt.Errorf("-out is required")
+	}
+	selected, err := parseGroups(*groupList)
+	if err != nil {
+		return err
+	}
+	filter := backupFilter{tenant: *tenant, statuses: parseList(*statuses), types: parseList(*types)}
+	if filter.since, err = parseTime("since", *since); err != nil {
+		return err
+	}
+	if filter.until, err = parseTime("until", *until); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	db, disconnect, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer disconnect()
+
+	file := os.Stdout
+	if *out != "-" {
+		if file, err = os.Create(*out); err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+
+	w := newSnapshotWriter(file)
+	var names []string
+	for _, name := range groupNames {
+		if selected[name] {
+			names = append(names, name)
+		}
+	}
+	if err := w.writeLine(header{Format: snapshotFormat, CreatedAt: time.Now().UTC(), Groups: names}); err != nil {
+		return err
+	}
+	if err := backup(ctx, db, w, selected, filter); err != nil {
+		return err
+	}
+	if err := w.close(); err != nil {
+		return fmt.Errorf("failed to write the snapshot: %w", err)
+	}
+	if *out != "-" {
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write the snapshot: %w", err)
+		}
+	}
+
+	for _, name := range names {
+		for _, collection := range groups[name] {
+			log.Printf("Backed up %d documents of %s", w.counts[collection], collection)
+		}
+	}
+	return nil
+}
+
+// backup writes the documents of the selected groups matching filter
+func backup(ctx context.Context, db *mongo.Database, w *snapshotWriter, selected map[string]bool, filter backupFilter) error {
+	if selected["jobs"] || selected["audit"] {
+		for _, collection := range []string{"jobs", "jobs_archive"} {
+			if err := backupJobs(ctx, db, w, collection, selected, filter.jobs()); err != nil {
+				return err
+			}
+		}
+	}
+	if selected["dlq"] {
+		if err := backupDocuments(ctx, db, w, "poison_messages", filter.timeRange("first_seen_at")); err != nil {
+			return err
+		}
+		if err := backupDocuments(ctx, db, w, "dead_letters", filter.timeRange("failed_at")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backupJobs writes the jobs of a collection matching filter when the jobs group
+// is selected, and in batches the blobs and comments belonging to them
+func backupJobs(ctx context.Context, db *mongo.Database, w *snapshotWriter, collection string, selected map[string]bool, filter bson.M) error {
+	opts := options.Find()
+	if !selected["jobs"] {
+		opts.SetProjection(bson.M{"_id": 1})
+	}
+	cursor, err := db.Collection(collection).Find(ctx, filter, opts)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var jobIDs, blobIDs bson.A
+	flush := func() error {
+		if selected["jobs"] && len(blobIDs) > 0 {
+			if err := backupDocuments(ctx, db, w, "job_blobs.files", bson.M{"_id": bson.M{"$in": blobIDs}}); err != nil {
+				return err
+			}
+			if err := backupDocuments(ctx, db, w, "job_blobs.chunks", bson.M{"files_id": bson.M{"$in": blobIDs}}); err != nil {
+				return err
+			}
+		}
+		if selected["audit"] && len(jobIDs) > 0 {
+			if err := backupDocuments(ctx, db, w, "job_comments", bson.M{"job_id": bson.M{"$in": jobIDs}}); err != nil {
+				return err
+			}
+		}
+		jobIDs, blobIDs = jobIDs[:0], blobIDs[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var job struct {
+			ID        interface{} `bson:"_id"`
+			ConfigRef *struct {
+				ID primitive.ObjectID `bson:"id"`
+			} `bson:"config_ref"`
+		}
+		if err := bson.Unmarshal(cursor.Current, &job); err != nil {
+			return fmt.Errorf("failed to read %s: %w", collection, err)
+		}
+		if selected["jobs"] {
+			if err := w.write(collection, cursor.Current); err != nil {
+				return err
+			}
+		}
+
+		jobIDs = append(jobIDs, job.ID)
+		if job.ConfigRef != nil {
+			blobIDs = append(blobIDs, job.ConfigRef.ID)
+		}
+		if len(jobIDs) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", collection, err)
+	}
+	return flush()
+}
+
+// backupDocuments writes the documents of a collection matching filter
+func backupDocuments(ctx context.Context, db *mongo.Database, w *snapshotWriter, collection string, filter bson.M) error {
+	cursor, err := db.Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		if err := w.write(collection, cursor.Current); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", collection, err)
+	}
+	return nil
+}
+
+// jobs returns the query selecting the jobs to back up
+func (f backupFilter) jobs() bson.M {
+	query := f.timeRange("created_at")
+	if f.tenant != "" {
+		query["tenant"] = f.tenant
+	}
+	if len(f.statuses) > 0 {
+		query["status"] = bson.M{"$in": f.statuses}
+	}
+	if len(f.types) > 0 {
+		query["job_type"] = bson.M{"$in": f.types}
+	}
+	return query
+}
+
+// timeRange returns a query bounding field by the filter's time range
+func (f backupFilter) timeRange(field string) bson.M {
+	bounds := bson.M{}
+	if !f.since.IsZero() {
+		bounds["$gte"] = f.since
+	}
+	if !f.until.IsZero() {
+		bounds["$lt"] = f.until
+	}
+	if len(bounds) == 0 {
+		return bson.M{}
+	}
+	return bson.M{field: bounds}
+}
+
+// snapshotWriter writes the lines of a compressed snapshot
+type snapshotWriter struct {
+	gz     *gzip.Writer
+	buf    *bufio.Writer
+	counts map[string]int64
+}
+
+func newSnapshotWriter(w io.Writer) *snapshotWriter {
+	gz := gzip.NewWriter(w)
+	return &snapshotWriter{gz: gz, buf: bufio.NewWriter(gz), counts: make(map[string]int64)}
+}
+
+// write writes a document of collection
+func (w *snapshotWriter) write(collection string, document bson.Raw) error {
+	data, err := bson.MarshalExtJSON(document, true, false)
+	if err != nil {
+		return fmt.Errorf("failed to encode a document of %s: %w", collection, err)
+	}
+	if err := w.writeLine(record{Collection: collection, Document: data}); err != nil {
+		return err
+	}
+	w.counts[collection]++
+	return nil
+}
+
+func (w *snapshotWriter) writeLine(value interface{}) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := w.buf.Write(line); err != nil {
+		return fmt.Errorf("failed to write the snapshot: %w", err)
+	}
+	return nil
+}
+
+// close flushes the buffered lines and ends the compressed stream
+func (w *snapshotWriter) close() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
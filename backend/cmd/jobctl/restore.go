@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// restoreCounts tallies the outcome of restoring the documents of a collection
+type restoreCounts struct {
+	read     int64
+	inserted int64
+	replaced int64
+	kept     int64
+}
+
+func runRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	in := flags.String("in", "", "snapshot file to read, - for stdin")
+	groupList := flags.String("groups", "jobs,dlq,audit", "comma-separated groups to restore: jobs, dlq, audit")
+	overwrite := flags.Bool("overwrite", false, "replace documents that already exist instead of keeping them")
+	dryRun := flags.Bool("dry-run", false, "read the snapshot and report what it holds without writing anything")
+	flags.Parse(args)
+
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+	selected, err := parseGroups(*groupList)
+	if err != nil {
+		return err
+	}
+	collections := make(map[string]bool)
+	for name := range selected {
+		for _, collection := range groups[name] {
+			collections[collection] = true
+		}
+	}
+
+	file := os.Stdin
+	if *in != "-" {
+		if file, err = os.Open(*in); err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+
+	ctx := context.Background()
+	var db *mongo.Database
+	if !*dryRun {
+		var disconnect func()
+		if db, disconnect, err = connect(ctx); err != nil {
+			return err
+		}
+		defer disconnect()
+	}
+
+	counts, err := restore(ctx, db, file, collections, *overwrite)
+	for _, name := range groupNames {
+		for _, collection := range groups[name] {
+			c, ok := counts[collection]
+			if !ok {
+				continue
+			}
+			if *dryRun {
+				log.Printf("Would restore %d documents of %s", c.read, collection)
+				continue
+			}
+			log.Printf("Restored %s: %d inserted, %d replaced, %d already present", collection, c.inserted, c.replaced, c.kept)
+		}
+	}
+	return err
+}
+
+// restore writes the documents of the snapshot in the given collections to db,
+// or only counts them when db is nil
+func restore(ctx context.Context, db *mongo.Database, snapshot io.Reader, collections map[string]bool, overwrite bool) (map[string]*restoreCounts, error) {
+	gz, err := gzip.NewReader(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the snapshot: %w", err)
+	}
+	defer gz.Close()
+	reader := bufio.NewReader(gz)
+
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the snapshot header: %w", err)
+	}
+	var head header
+	if err := json.Unmarshal(line, &head); err != nil || head.Format != snapshotFormat {
+		return nil, fmt.Errorf("not a %s snapshot", snapshotFormat)
+	}
+	log.Printf("Restoring a snapshot of %v taken at %s", head.Groups, head.CreatedAt)
+
+	counts := make(map[string]*restoreCounts)
+	pending := make(map[string][]mongo.WriteModel)
+	flush := func(collection string) error {
+		models := pending[collection]
+		if len(models) == 0 {
+			return nil
+		}
+		pending[collection] = models[:0]
+		result, err := db.Collection(collection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", collection, err)
+		}
+		c := counts[collection]
+		c.inserted += result.UpsertedCount
+		if overwrite {
+			c.replaced += result.MatchedCount
+		} else {
+			c.kept += result.MatchedCount
+		}
+		return nil
+	}
+
+	for lineNumber := 2; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return counts, fmt.Errorf("failed to read the snapshot: %w", err)
+		}
+
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return counts, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		if !knownCollection(rec.Collection) {
+			return counts, fmt.Errorf("line %d: unexpected collection %q", lineNumber, rec.Collection)
+		}
+		if !collections[rec.Collection] {
+			continue
+		}
+		if counts[rec.Collection] == nil {
+			counts[rec.Collection] = &restoreCounts{}
+		}
+		counts[rec.Collection].read++
+		if db == nil {
+			continue
+		}
+
+		var document bson.D
+		if err := bson.UnmarshalExtJSON(rec.Document, true, &document); err != nil {
+			return counts, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		model, err := restoreModel(rec.Collection, document, overwrite)
+		if err != nil {
+			return counts, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		pending[rec.Collection] = append(pending[rec.Collection], model)
+		if len(pending[rec.Collection]) == batchSize {
+			if err := flush(rec.Collection); err != nil {
+				return counts, err
+			}
+		}
+	}
+
+	if db != nil {
+		for collection := range pending {
+			if err := flush(collection); err != nil {
+				return counts, err
+			}
+		}
+	}
+	return counts, nil
+}
+
+// restoreModel returns the upsert restoring a document. It matches the document
+// by ID, and for jobs by tenant too, as upserts into a sharded collection need
+// the full shard key. Without overwrite, the document is only written when no
+// document matches.
+func restoreModel(collection string, document bson.D, overwrite bool) (mongo.WriteModel, error) {
+	var filter, fields bson.D
+	hasID := false
+	for _, field := range document {
+		switch {
+		case field.Key == "_id":
+			hasID = true
+			filter = append(filter, field)
+		case collection == "jobs" && field.Key == "tenant":
+			filter = append(filter, field)
+		default:
+			fields = append(fields, field)
+		}
+	}
+	if !hasID {
+		return nil, fmt.Errorf("document of %s has no _id", collection)
+	}
+
+	if overwrite {
+		return mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(document).SetUpsert(true), nil
+	}
+	return mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.D{{Key: "$setOnInsert", Value: fields}}).SetUpsert(true), nil
+}
+
+// knownCollection reports whether a collection belongs to a group
+func knownCollection(collection string) bool {
+	for _, collections := range groups {
+		for _, c := range collections {
+			if c == collection {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,234 @@
+// Command shardjobs prepares the jobs collection of a sharded MongoDB
+// deployment and shards it by repositories.JobsShardKey. It reads the backend's
+// configuration, connects through a mongos and:
+//
+//  1. stores an empty tenant on jobs created without one, in batches,
+//  2. checks that no unique index other than _id would block sharding,
+//  3. creates the index backing the shard key, and
+//  4. enables sharding on the database and shards the collection.
+//
+// Every step is safe to run again, so an interrupted run is resumed by running
+// the command again. With -dry-run it only reports what it would do.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+
+	"github.com/fullstack-assessment/backend/config"
+	"github.com/fullstack-assessment/backend/database"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	databaseName   = "jobprocessor"
+	collectionName = "jobs"
+	// shardKeyIndex names the index backing the shard key
+	shardKeyIndex = "jobs_shard_key"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 1000, "jobs backfilled per batch")
+	dryRun := flag.Bool("dry-run", false, "report what would be done without changing anything")
+	flag.Parse()
+
+	cfg, err := config.Load(os.Getenv(config.FileEnvVar))
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if *batchSize <= 0 {
+		log.Fatalf("-batch must be positive")
+	}
+
+	ctx := context.Background()
+	mongoConfig := database.DefaultMongoConfig(cfg.Mongo.URI)
+	mongoConfig.ConnectTimeout = cfg.Mongo.ConnectTimeout
+	mongoConfig.ServerSelectionTimeout = cfg.Mongo.ServerSelectionTimeout
+	client, err := database.Connect(ctx, mongoConfig)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	if err := run(ctx, client, *batchSize, *dryRun); err != nil {
+		log.Fatalf("Sharding the jobs collection failed: %v", err)
+	}
+}
+
+func run(ctx context.Context, client *mongo.Client, batchSize int, dryRun bool) error {
+	if err := requireMongos(ctx, client); err != nil {
+		return err
+	}
+
+	namespace := databaseName + "." + collectionName
+	sharded, err := shardKeyOf(ctx, client, namespace)
+	if err != nil {
+		return err
+	}
+	if sharded != nil {
+		if !reflect.DeepEqual(sharded, normalize(repositories.JobsShardKey)) {
+			return fmt.Errorf("%s is already sharded by %v, not %v", namespace, sharded, repositories.JobsShardKey)
+		}
+		log.Printf("%s is already sharded by %v", namespace, repositories.JobsShardKey)
+		return nil
+	}
+
+	jobs := client.Database(databaseName).Collection(collectionName)
+	if err := backfillTenants(ctx, jobs, batchSize, dryRun); err != nil {
+		return err
+	}
+	if err := checkUniqueIndexes(ctx, jobs); err != nil {
+		return err
+	}
+	if dryRun {
+		log.Printf("Would create index %s on %v and shard %s", shardKeyIndex, repositories.JobsShardKey, namespace)
+		return nil
+	}
+
+	_, err = jobs.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    repositories.JobsShardKey,
+		Options: options.Index().SetName(shardKeyIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the shard key index: %w", err)
+	}
+
+	admin := client.Database("admin")
+	// Databases are sharding-enabled implicitly from MongoDB 6.0; the command is kept for older servers
+	if err := admin.RunCommand(ctx, bson.D{{Key: "enableSharding", Value: databaseName}}).Err(); err != nil {
+		return fmt.Errorf("failed to enable sharding on %s: %w", databaseName, err)
+	}
+	err = admin.RunCommand(ctx, bson.D{
+		{Key: "shardCollection", Value: namespace},
+		{Key: "key", Value: repositories.JobsShardKey},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to shard %s: %w", namespace, err)
+	}
+	log.Printf("Sharded %s by %v", namespace, repositories.JobsShardKey)
+	return nil
+}
+
+// requireMongos fails unless the client is connected to a mongos router
+func requireMongos(ctx context.Context, client *mongo.Client) error {
+	var hello struct {
+		Msg string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return fmt.Errorf("failed to identify the server: %w", err)
+	}
+	if hello.Msg != "isdbgrid" {
+		return fmt.Errorf("MONGODB_URI must point to a mongos router of a sharded cluster")
+	}
+	return nil
+}
+
+// shardKeyOf returns the shard key of a collection, or nil if it is not sharded
+func shardKeyOf(ctx context.Context, client *mongo.Client, namespace string) (bson.D, error) {
+	var collection struct {
+		Key bson.D `bson:"key"`
+	}
+	err := client.Database("config").Collection("collections").FindOne(ctx, bson.M{"_id": namespace}).Decode(&collection)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the sharding state of %s: %w", namespace, err)
+	}
+	return normalize(collection.Key), nil
+}
+
+// normalize converts the numeric directions of a key to one type so keys read
+// from the server compare equal to keys written in Go
+func normalize(key bson.D) bson.D {
+	normalized := make(bson.D, len(key))
+	for i, field := range key {
+		value := field.Value
+		switch direction := value.(type) {
+		case int32:
+			value = int64(direction)
+		case int:
+			value = int64(direction)
+		case float64:
+			value = int64(direction)
+		}
+		normalized[i] = bson.E{Key: field.Key, Value: value}
+	}
+	return normalized
+}
+
+// backfillTenants stores an empty tenant on jobs created without one, as every
+// job needs the fields of the shard key for writes to be routed by it
+func backfillTenants(ctx context.Context, jobs *mongo.Collection, batchSize int, dryRun bool) error {
+	missing := bson.M{"tenant": bson.M{"$exists": false}}
+	if dryRun {
+		count, err := jobs.CountDocuments(ctx, missing)
+		if err != nil {
+			return fmt.Errorf("failed to count jobs without a tenant: %w", err)
+		}
+		log.Printf("Would store an empty tenant on %d jobs", count)
+		return nil
+	}
+
+	var total int64
+	for {
+		cursor, err := jobs.Find(ctx, missing, options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(int64(batchSize)))
+		if err != nil {
+			return fmt.Errorf("failed to list jobs without a tenant: %w", err)
+		}
+		var batch []struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cursor.All(ctx, &batch); err != nil {
+			return fmt.Errorf("failed to list jobs without a tenant: %w", err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		ids := make(bson.A, len(batch))
+		for i, job := range batch {
+			ids[i] = job.ID
+		}
+		result, err := jobs.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": ids}, "tenant": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"tenant": ""}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store empty tenants: %w", err)
+		}
+		total += result.ModifiedCount
+		log.Printf("Stored an empty tenant on %d jobs so far", total)
+	}
+	return nil
+}
+
+// checkUniqueIndexes fails if the collection has a unique index besides _id, as a
+// sharded collection only keeps unique indexes prefixed by its shard key
+func checkUniqueIndexes(ctx context.Context, jobs *mongo.Collection) error {
+	cursor, err := jobs.Indexes().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+	var indexes []struct {
+		Name   string `bson:"name"`
+		Unique bool   `bson:"unique"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	for _, index := range indexes {
+		if index.Unique && index.Name != "_id_" {
+			return fmt.Errorf("unique index %s blocks sharding; start the current backend once to replace it", index.Name)
+		}
+	}
+	return nil
+}
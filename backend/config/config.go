@@ -0,0 +1,1063 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnvVar names the environment variable pointing at an optional YAML config file
+const FileEnvVar = "CONFIG_FILE"
+
+// redactedValue replaces secret values in the redacted dump
+const redactedValue = "******"
+
+// Config is the complete backend configuration.
+// Values are layered: defaults, then the YAML file, then environment variables.
+type Config struct {
+	Server    ServerConfig    `yaml:"server" json:"server"`
+	Mongo     MongoConfig     `yaml:"mongo" json:"mongo"`
+	Kafka     KafkaConfig     `yaml:"kafka" json:"kafka"`
+	Jobs      JobsConfig      `yaml:"jobs" json:"jobs"`
+	Artifacts ArtifactsConfig `yaml:"artifacts" json:"artifacts"`
+	Alerts    AlertsConfig    `yaml:"alerts" json:"alerts"`
+	Leader    LeaderConfig    `yaml:"leader" json:"leader"`
+	Secrets   SecretsConfig   `yaml:"secrets" json:"secrets"`
+	Hooks     HooksConfig     `yaml:"hooks" json:"hooks"`
+	Retention RetentionConfig `yaml:"retention" json:"retention"`
+	Auth      AuthConfig      `yaml:"auth" json:"auth"`
+	// Notifications are sent for the jobs the backend moves to a terminal status
+	Notifications NotificationsConfig `yaml:"notifications" json:"notifications"`
+}
+
+// ServerConfig holds the HTTP server settings
+type ServerConfig struct {
+	Port                string `yaml:"port" json:"port" env:"PORT"`
+	CORSOrigins         string `yaml:"cors_origins" json:"corsOrigins" env:"CORS_ORIGINS"`
+	MaxRequestBodyBytes int64  `yaml:"max_request_body_bytes" json:"maxRequestBodyBytes" env:"MAX_REQUEST_BODY_BYTES"`
+	// AccessLog writes a JSON line per request to stdout
+	AccessLog bool `yaml:"access_log" json:"accessLog" env:"ACCESS_LOG"`
+	// AccessLogSkipPaths is a comma-separated list of paths left out of the access log
+	AccessLogSkipPaths string `yaml:"access_log_skip_paths" json:"accessLogSkipPaths" env:"ACCESS_LOG_SKIP_PATHS"`
+	// RequestTimeout bounds reads, lists and single-job writes
+	RequestTimeout time.Duration `yaml:"request_timeout" json:"requestTimeout" env:"REQUEST_TIMEOUT"`
+	// BulkRequestTimeout bounds bulk group operations, requeues, uploads and exports
+	BulkRequestTimeout time.Duration `yaml:"bulk_request_timeout" json:"bulkRequestTimeout" env:"BULK_REQUEST_TIMEOUT"`
+	// RouteTimeouts overrides the timeout of routes by "METHOD /route/template"; 0 leaves a route unbounded
+	RouteTimeouts map[string]time.Duration `yaml:"route_timeouts" json:"routeTimeouts" env:"ROUTE_TIMEOUTS"`
+}
+
+// MongoConfig holds the MongoDB connection and query settings
+type MongoConfig struct {
+	URI                     string        `yaml:"uri" json:"uri" env:"MONGODB_URI" secret:"url"`
+	MaxPoolSize             uint64        `yaml:"max_pool_size" json:"maxPoolSize" env:"MONGO_MAX_POOL_SIZE"`
+	MinPoolSize             uint64        `yaml:"min_pool_size" json:"minPoolSize" env:"MONGO_MIN_POOL_SIZE"`
+	MaxConnIdleTime         time.Duration `yaml:"max_conn_idle_time" json:"maxConnIdleTime" env:"MONGO_MAX_CONN_IDLE_TIME"`
+	ConnectTimeout          time.Duration `yaml:"connect_timeout" json:"connectTimeout" env:"MONGO_CONNECT_TIMEOUT"`
+	ServerSelectionTimeout  time.Duration `yaml:"server_selection_timeout" json:"serverSelectionTimeout" env:"MONGO_SERVER_SELECTION_TIMEOUT"`
+	StartupTimeout          time.Duration `yaml:"startup_timeout" json:"startupTimeout" env:"MONGO_STARTUP_TIMEOUT"`
+	QueryTimeout            time.Duration `yaml:"query_timeout" json:"queryTimeout" env:"MONGO_QUERY_TIMEOUT"`
+	ReportingReadPreference string        `yaml:"reporting_read_preference" json:"reportingReadPreference" env:"MONGO_REPORTING_READ_PREFERENCE"`
+	ReadConcern             string        `yaml:"read_concern" json:"readConcern" env:"MONGO_READ_CONCERN"`
+	SlowQueryThreshold      time.Duration `yaml:"slow_query_threshold" json:"slowQueryThreshold" env:"MONGO_SLOW_QUERY_THRESHOLD"`
+	// JobCacheSize is how many jobs are cached for reads by ID; zero disables the cache
+	JobCacheSize int           `yaml:"job_cache_size" json:"jobCacheSize" env:"MONGO_JOB_CACHE_SIZE"`
+	JobCacheTTL  time.Duration `yaml:"job_cache_ttl" json:"jobCacheTTL" env:"MONGO_JOB_CACHE_TTL"`
+}
+
+// KafkaConfig holds the Kafka producer and outbox settings
+type KafkaConfig struct {
+	// Brokers is a comma-separated list of host:port addresses
+	Brokers             string        `yaml:"brokers" json:"brokers" env:"KAFKA_BROKERS"`
+	PublishMaxAttempts  int           `yaml:"publish_max_attempts" json:"publishMaxAttempts" env:"KAFKA_PUBLISH_MAX_ATTEMPTS"`
+	BreakerThreshold    int           `yaml:"breaker_threshold" json:"breakerThreshold" env:"KAFKA_BREAKER_THRESHOLD"`
+	BreakerTimeout      time.Duration `yaml:"breaker_timeout" json:"breakerTimeout" env:"KAFKA_BREAKER_TIMEOUT"`
+	OutboxRelayInterval time.Duration `yaml:"outbox_relay_interval" json:"outboxRelayInterval" env:"OUTBOX_RELAY_INTERVAL"`
+	// CancellationAckTimeout is how long a cancellation may wait for a worker acknowledgement before it is re-sent
+	CancellationAckTimeout time.Duration `yaml:"cancellation_ack_timeout" json:"cancellationAckTimeout" env:"CANCELLATION_ACK_TIMEOUT"`
+	// CancellationMaxAttempts is how many times a cancellation is sent before the job is cancelled without an acknowledgement
+	CancellationMaxAttempts int `yaml:"cancellation_max_attempts" json:"cancellationMaxAttempts" env:"CANCELLATION_MAX_ATTEMPTS"`
+	// CreateTopics allows missing topics to be created at startup with the partitions and replication factor below
+	CreateTopics           bool `yaml:"create_topics" json:"createTopics" env:"KAFKA_CREATE_TOPICS"`
+	TopicPartitions        int  `yaml:"topic_partitions" json:"topicPartitions" env:"KAFKA_TOPIC_PARTITIONS"`
+	TopicReplicationFactor int  `yaml:"topic_replication_factor" json:"topicReplicationFactor" env:"KAFKA_TOPIC_REPLICATION_FACTOR"`
+	// TopicPrefix and GroupPrefix namespace topics and consumer groups so several environments
+	// can share one cluster, e.g. KAFKA_TOPIC_PREFIX=staging; the group prefix defaults to the topic prefix
+	TopicPrefix string `yaml:"topic_prefix" json:"topicPrefix" env:"KAFKA_TOPIC_PREFIX"`
+	GroupPrefix string `yaml:"group_prefix" json:"groupPrefix" env:"KAFKA_GROUP_PREFIX"`
+	// SecondaryBrokers is the cluster publishes fail over to while the circuit to the
+	// brokers is open. It is reached with the same TLS and SASL settings.
+	SecondaryBrokers string `yaml:"secondary_brokers" json:"secondaryBrokers" env:"KAFKA_SECONDARY_BROKERS"`
+	// TLS connects to the brokers over TLS, verifying them against CAFile or the system roots
+	TLS                   bool   `yaml:"tls" json:"tls" env:"KAFKA_TLS"`
+	TLSCAFile             string `yaml:"tls_ca_file" json:"tlsCaFile" env:"KAFKA_TLS_CA_FILE"`
+	TLSCertFile           string `yaml:"tls_cert_file" json:"tlsCertFile" env:"KAFKA_TLS_CERT_FILE"`
+	TLSKeyFile            string `yaml:"tls_key_file" json:"tlsKeyFile" env:"KAFKA_TLS_KEY_FILE"`
+	TLSInsecureSkipVerify bool   `yaml:"tls_insecure_skip_verify" json:"tlsInsecureSkipVerify" env:"KAFKA_TLS_INSECURE_SKIP_VERIFY"`
+	// SASLMechanism is one of plain, scram-sha-256 or scram-sha-512; empty disables SASL
+	SASLMechanism string `yaml:"sasl_mechanism" json:"saslMechanism" env:"KAFKA_SASL_MECHANISM"`
+	SASLUsername  string `yaml:"sasl_username" json:"saslUsername" env:"KAFKA_SASL_USERNAME"`
+	SASLPassword  string `yaml:"sasl_password" json:"saslPassword" env:"KAFKA_SASL_PASSWORD" secret:"true"`
+	// ConsumerStartOffset is where a consumer group without committed offsets starts: first or last
+	ConsumerStartOffset string `yaml:"consumer_start_offset" json:"consumerStartOffset" env:"KAFKA_START_OFFSET"`
+	// RebalanceStrategy assigns partitions to group members: range or roundrobin
+	RebalanceStrategy      string        `yaml:"rebalance_strategy" json:"rebalanceStrategy" env:"KAFKA_REBALANCE_STRATEGY"`
+	ConsumerSessionTimeout time.Duration `yaml:"consumer_session_timeout" json:"consumerSessionTimeout" env:"KAFKA_SESSION_TIMEOUT"`
+	ConsumerMaxWait        time.Duration `yaml:"consumer_max_wait" json:"consumerMaxWait" env:"KAFKA_MAX_WAIT"`
+	// Balancer chooses the partition of each published message: least-bytes, round-robin, hash or sticky
+	Balancer string `yaml:"balancer" json:"balancer" env:"KAFKA_BALANCER"`
+	// TopicBalancers overrides the balancer by topic, without the topic prefix,
+	// e.g. KAFKA_TOPIC_BALANCERS="jobs.export=sticky,jobs.process=round-robin"
+	TopicBalancers map[string]string `yaml:"topic_balancers" json:"topicBalancers,omitempty" env:"KAFKA_TOPIC_BALANCERS"`
+	// Compression is the codec published messages are compressed with: none, gzip, snappy, lz4 or zstd
+	Compression string `yaml:"compression" json:"compression" env:"KAFKA_COMPRESSION"`
+}
+
+// JobsConfig holds the jobs API tunables. These can be changed at runtime by a reload.
+type JobsConfig struct {
+	MaxPageLimit int `yaml:"max_page_limit" json:"maxPageLimit" env:"MAX_PAGE_LIMIT"`
+	// SLOs overrides the registered per-type SLOs, e.g. JOB_SLOS="export=30s,process=10s"
+	SLOs map[string]time.Duration `yaml:"slos" json:"slos,omitempty" env:"JOB_SLOS"`
+	// Dedup sets the duplicate submission policy per job type, e.g. JOB_DEDUP="export=reject:5m,process=dedupe:30s"
+	Dedup map[string]DedupPolicy `yaml:"dedup" json:"dedup,omitempty" env:"JOB_DEDUP"`
+	// Backpressure sets how new jobs are handled while their queue is over capacity
+	Backpressure BackpressureConfig `yaml:"backpressure" json:"backpressure"`
+	// Quota limits how many jobs each tenant creates per day and month
+	Quota QuotaConfig `yaml:"quota" json:"quota"`
+	// RedactedConfigKeys is a comma-separated list of config keys hidden from callers without the admin role
+	RedactedConfigKeys string `yaml:"redacted_config_keys" json:"redactedConfigKeys" env:"JOB_REDACTED_CONFIG_KEYS"`
+	// RetryPolicies overrides the registered retry policy per job type,
+	// e.g. JOB_RETRY_POLICIES="export=max_retries=5;auto_retry=true;initial_delay=30s"
+	RetryPolicies map[string]RetryPolicy `yaml:"retry_policies" json:"retryPolicies,omitempty" env:"JOB_RETRY_POLICIES"`
+	// CanaryPercent routes a percentage of new jobs per type to the workers running
+	// the canary executor version, e.g. JOB_CANARY_PERCENT="export=10"
+	CanaryPercent map[string]int `yaml:"canary_percent" json:"canaryPercent,omitempty" env:"JOB_CANARY_PERCENT"`
+	// MaxInputBytes caps the size of a job's uploaded input file
+	MaxInputBytes int64 `yaml:"max_input_bytes" json:"maxInputBytes" env:"JOB_MAX_INPUT_BYTES"`
+	// ConfigOffloadBytes is the encoded size above which a job's config is stored in GridFS
+	// instead of on the job, keeping job documents far from MongoDB's 16MB limit
+	ConfigOffloadBytes int64 `yaml:"config_offload_bytes" json:"configOffloadBytes" env:"JOB_CONFIG_OFFLOAD_BYTES"`
+	// IDStrategy generates the IDs of new jobs: objectid or uuidv7. Existing jobs
+	// keep their IDs, so it can be changed at any time.
+	IDStrategy string `yaml:"id_strategy" json:"idStrategy" env:"JOB_ID_STRATEGY"`
+	// ListActiveOnly hides terminal jobs from lists requested without a status
+	// filter or active_only parameter
+	ListActiveOnly bool `yaml:"list_active_only" json:"listActiveOnly" env:"JOB_LIST_ACTIVE_ONLY"`
+	// FanInInterval is how often parents waiting for their child jobs are checked for completion
+	FanInInterval time.Duration `yaml:"fan_in_interval" json:"fanInInterval" env:"JOB_FAN_IN_INTERVAL"`
+	// PendingReconcileInterval is how often jobs stuck in pending are looked for
+	PendingReconcileInterval time.Duration `yaml:"pending_reconcile_interval" json:"pendingReconcileInterval" env:"JOB_PENDING_RECONCILE_INTERVAL"`
+	// PendingReconcileThreshold is how long a job stays pending before it is republished.
+	// Keep it above the normal queueing time: a republished job goes to the back of its topic.
+	PendingReconcileThreshold time.Duration `yaml:"pending_reconcile_threshold" json:"pendingReconcileThreshold" env:"JOB_PENDING_RECONCILE_THRESHOLD"`
+	// UsageInterval is how often the daily usage of every tenant is recomputed
+	UsageInterval time.Duration `yaml:"usage_interval" json:"usageInterval" env:"JOB_USAGE_INTERVAL"`
+	// SummarySyncInterval is how often the job summaries catch up on changes made by workers
+	SummarySyncInterval time.Duration `yaml:"summary_sync_interval" json:"summarySyncInterval" env:"JOB_SUMMARY_SYNC_INTERVAL"`
+}
+
+// BackpressureConfig sets when a job queue is over capacity and whether new jobs are
+// rejected or accepted with a warning. Zero thresholds disable the check.
+type BackpressureConfig struct {
+	MaxPending int64  `yaml:"max_pending" json:"maxPending" env:"JOB_BACKPRESSURE_MAX_PENDING"`
+	MaxLag     int64  `yaml:"max_lag" json:"maxLag" env:"JOB_BACKPRESSURE_MAX_LAG"`
+	Mode       string `yaml:"mode" json:"mode" env:"JOB_BACKPRESSURE_MODE"`
+	// TenantModes overrides the mode per tenant, e.g. JOB_BACKPRESSURE_TENANT_MODES="acme=warn,batch=reject"
+	TenantModes map[string]string `yaml:"tenant_modes" json:"tenantModes,omitempty" env:"JOB_BACKPRESSURE_TENANT_MODES"`
+}
+
+// QuotaConfig limits how many jobs each tenant creates per UTC day and month. Zero limits are unlimited.
+type QuotaConfig struct {
+	Daily   int64 `yaml:"daily" json:"daily" env:"JOB_QUOTA_DAILY"`
+	Monthly int64 `yaml:"monthly" json:"monthly" env:"JOB_QUOTA_MONTHLY"`
+	// TenantDaily overrides the daily limit per tenant, e.g. JOB_QUOTA_TENANT_DAILY="acme=5000,trial=50"
+	TenantDaily map[string]int64 `yaml:"tenant_daily" json:"tenantDaily,omitempty" env:"JOB_QUOTA_TENANT_DAILY"`
+	// TenantMonthly overrides the monthly limit per tenant
+	TenantMonthly map[string]int64 `yaml:"tenant_monthly" json:"tenantMonthly,omitempty" env:"JOB_QUOTA_TENANT_MONTHLY"`
+}
+
+// Duplicate submission modes
+const (
+	DedupOff    = "off"
+	DedupReject = "reject"
+	DedupDedupe = "dedupe"
+)
+
+// DedupPolicy controls how jobs with the same name and config submitted within Window are handled.
+// It is written as "mode:window", e.g. "reject:5m".
+type DedupPolicy struct {
+	Mode   string
+	Window time.Duration
+}
+
+// UnmarshalText parses a policy written as "mode:window" or "off"
+func (p *DedupPolicy) UnmarshalText(text []byte) error {
+	mode, window, ok := strings.Cut(string(text), ":")
+	p.Mode = mode
+	p.Window = 0
+	if !ok {
+		if mode != DedupOff {
+			return fmt.Errorf("expected mode:window, got %q", text)
+		}
+		return nil
+	}
+	parsed, err := time.ParseDuration(window)
+	if err != nil {
+		return err
+	}
+	p.Window = parsed
+	return nil
+}
+
+// MarshalText writes the policy in the same form UnmarshalText accepts
+func (p DedupPolicy) MarshalText() ([]byte, error) {
+	if p.Mode == DedupOff {
+		return []byte(p.Mode), nil
+	}
+	return []byte(p.Mode + ":" + p.Window.String()), nil
+}
+
+// RetryPolicy overrides the settings of a job type's retry policy that are set.
+// It is written as semicolon-separated settings, e.g. "max_retries=5;backoff=linear;dlq=never".
+type RetryPolicy struct {
+	MaxRetries   *int
+	AutoRetry    *bool
+	Backoff      string
+	InitialDelay *time.Duration
+	MaxDelay     *time.Duration
+	DLQ          string
+}
+
+// UnmarshalText parses the settings of a retry policy override
+func (p *RetryPolicy) UnmarshalText(text []byte) error {
+	*p = RetryPolicy{}
+	for _, setting := range strings.Split(string(text), ";") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return fmt.Errorf("expected setting=value, got %q", setting)
+		}
+		switch name {
+		case "max_retries":
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("max_retries: %w", err)
+			}
+			p.MaxRetries = &parsed
+		case "auto_retry":
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("auto_retry: %w", err)
+			}
+			p.AutoRetry = &parsed
+		case "backoff":
+			p.Backoff = value
+		case "initial_delay", "max_delay":
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if name == "initial_delay" {
+				p.InitialDelay = &parsed
+			} else {
+				p.MaxDelay = &parsed
+			}
+		case "dlq":
+			p.DLQ = value
+		default:
+			return fmt.Errorf("unknown retry policy setting %q", name)
+		}
+	}
+	return nil
+}
+
+// MarshalText writes the override in the same form UnmarshalText accepts
+func (p RetryPolicy) MarshalText() ([]byte, error) {
+	var settings []string
+	if p.MaxRetries != nil {
+		settings = append(settings, "max_retries="+strconv.Itoa(*p.MaxRetries))
+	}
+	if p.AutoRetry != nil {
+		settings = append(settings, "auto_retry="+strconv.FormatBool(*p.AutoRetry))
+	}
+	if p.Backoff != "" {
+		settings = append(settings, "backoff="+p.Backoff)
+	}
+	if p.InitialDelay != nil {
+		settings = append(settings, "initial_delay="+p.InitialDelay.String())
+	}
+	if p.MaxDelay != nil {
+		settings = append(settings, "max_delay="+p.MaxDelay.String())
+	}
+	if p.DLQ != "" {
+		settings = append(settings, "dlq="+p.DLQ)
+	}
+	return []byte(strings.Join(settings, ";")), nil
+}
+
+// ArtifactsConfig holds the artifact storage settings
+type ArtifactsConfig struct {
+	Storage         string `yaml:"storage" json:"storage" env:"ARTIFACT_STORAGE"`
+	Dir             string `yaml:"dir" json:"dir" env:"ARTIFACT_DIR"`
+	Endpoint        string `yaml:"endpoint" json:"endpoint" env:"S3_ENDPOINT"`
+	Region          string `yaml:"region" json:"region" env:"S3_REGION"`
+	Bucket          string `yaml:"bucket" json:"bucket" env:"S3_BUCKET"`
+	AccessKeyID     string `yaml:"access_key_id" json:"accessKeyId" env:"S3_ACCESS_KEY_ID" secret:"true"`
+	SecretAccessKey string `yaml:"secret_access_key" json:"secretAccessKey" env:"S3_SECRET_ACCESS_KEY" secret:"true"`
+}
+
+// SecretsConfig holds the envelope encryption settings for sensitive job config keys
+type SecretsConfig struct {
+	// EncryptedKeys is a comma-separated list of top-level job config keys encrypted at rest
+	EncryptedKeys string `yaml:"encrypted_keys" json:"encryptedKeys" env:"JOB_ENCRYPTED_CONFIG_KEYS"`
+	// Provider wraps the per-value data keys; "local" uses Key
+	Provider string `yaml:"provider" json:"provider" env:"SECRETS_PROVIDER"`
+	// Key is the base64-encoded 256-bit wrapping key, shared with the workers
+	Key   string `yaml:"key" json:"key" env:"SECRETS_KEY" secret:"true"`
+	KeyID string `yaml:"key_id" json:"keyId" env:"SECRETS_KEY_ID"`
+}
+
+// HooksConfig holds the external lifecycle hooks of the jobs service
+type HooksConfig struct {
+	// PreEnqueueURLs is a comma-separated list of endpoints called, in order, with every new job
+	PreEnqueueURLs string        `yaml:"pre_enqueue_urls" json:"preEnqueueUrls" env:"JOB_HOOK_PRE_ENQUEUE_URLS" secret:"true"`
+	Timeout        time.Duration `yaml:"timeout" json:"timeout" env:"JOB_HOOK_TIMEOUT"`
+	// FailOpen accepts jobs unchanged when a hook endpoint is unreachable or fails
+	FailOpen bool `yaml:"fail_open" json:"failOpen" env:"JOB_HOOK_FAIL_OPEN"`
+}
+
+// NotificationsConfig holds the global notification channels and the SMTP
+// server sending email notifications. Tenants and jobs add their own channels.
+// Workers are configured with the same environment variables.
+type NotificationsConfig struct {
+	// Email is a comma-separated list of addresses notified for every job
+	Email           string `yaml:"email" json:"email" env:"NOTIFY_EMAIL"`
+	SlackWebhookURL string `yaml:"slack_webhook_url" json:"slackWebhookUrl" env:"NOTIFY_SLACK_WEBHOOK_URL" secret:"true"`
+	// On is a comma-separated list of the statuses notified globally; empty means the terminal ones
+	On           string `yaml:"on" json:"on" env:"NOTIFY_ON"`
+	SMTPHost     string `yaml:"smtp_host" json:"smtpHost" env:"SMTP_HOST"`
+	SMTPPort     string `yaml:"smtp_port" json:"smtpPort" env:"SMTP_PORT"`
+	SMTPUsername string `yaml:"smtp_username" json:"smtpUsername" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" json:"smtpPassword" env:"SMTP_PASSWORD" secret:"true"`
+	SMTPFrom     string `yaml:"smtp_from" json:"smtpFrom" env:"SMTP_FROM"`
+}
+
+// RetentionConfig holds the rules removing finished jobs once they are no longer needed
+type RetentionConfig struct {
+	// Rules are keyed by "job_type:status", with * matching any, e.g.
+	// JOB_RETENTION_RULES="export:completed=delete:720h,*:failed=archive:2160h"
+	Rules map[string]RetentionRule `yaml:"rules" json:"rules,omitempty" env:"JOB_RETENTION_RULES"`
+	// RunAt is the time of day, as HH:MM in UTC, the rules are applied
+	RunAt     string `yaml:"run_at" json:"runAt" env:"JOB_RETENTION_RUN_AT"`
+	BatchSize int    `yaml:"batch_size" json:"batchSize" env:"JOB_RETENTION_BATCH_SIZE"`
+	// AuditMaxAge is how long job comments are kept, whatever the rules do with
+	// their jobs; zero keeps them forever
+	AuditMaxAge time.Duration `yaml:"audit_max_age" json:"auditMaxAge" env:"JOB_RETENTION_AUDIT_MAX_AGE"`
+}
+
+// Retention actions
+const (
+	RetentionDelete  = "delete"
+	RetentionArchive = "archive"
+)
+
+// RetentionRule deletes or archives jobs that have not changed for MaxAge.
+// It is written as "action:max_age", e.g. "archive:2160h".
+type RetentionRule struct {
+	Action string
+	MaxAge time.Duration
+}
+
+// UnmarshalText parses a rule written as "action:max_age"
+func (r *RetentionRule) UnmarshalText(text []byte) error {
+	action, maxAge, ok := strings.Cut(string(text), ":")
+	if !ok {
+		return fmt.Errorf("expected action:max_age, got %q", text)
+	}
+	parsed, err := time.ParseDuration(maxAge)
+	if err != nil {
+		return err
+	}
+	r.Action = action
+	r.MaxAge = parsed
+	return nil
+}
+
+// MarshalText writes the rule in the same form UnmarshalText accepts
+func (r RetentionRule) MarshalText() ([]byte, error) {
+	return []byte(r.Action + ":" + r.MaxAge.String()), nil
+}
+
+// SplitRetentionKey returns the job type and status of a retention rule key,
+// empty where the key has *
+func SplitRetentionKey(key string) (jobType, status string, err error) {
+	jobType, status, ok := strings.Cut(key, ":")
+	if !ok {
+		return "", "", fmt.Errorf("expected job_type:status, got %q", key)
+	}
+	if jobType == "*" {
+		jobType = ""
+	}
+	if status == "*" {
+		status = ""
+	}
+	return jobType, status, nil
+}
+
+// RunAtOffset returns RunAt as the time past midnight UTC
+func (c RetentionConfig) RunAtOffset() (time.Duration, error) {
+	runAt, err := time.Parse("15:04", c.RunAt)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(runAt.Hour())*time.Hour + time.Duration(runAt.Minute())*time.Minute, nil
+}
+
+// AlertsConfig holds the alerting engine settings
+type AlertsConfig struct {
+	EvaluationInterval time.Duration `yaml:"evaluation_interval" json:"evaluationInterval" env:"ALERT_EVALUATION_INTERVAL"`
+}
+
+// LeaderConfig holds the lease settings of the background components that run on a single instance
+type LeaderConfig struct {
+	// LeaseTTL is how long a lease outlives its last renewal, bounding how long failover takes
+	LeaseTTL      time.Duration `yaml:"lease_ttl" json:"leaseTtl" env:"LEADER_LEASE_TTL"`
+	RenewInterval time.Duration `yaml:"renew_interval" json:"renewInterval" env:"LEADER_RENEW_INTERVAL"`
+}
+
+// AuthConfig holds the API authentication settings
+type AuthConfig struct {
+	// Required rejects requests without an API key; while it is off, anonymous
+	// requests are allowed, e.g. to create the first admin key
+	Required bool       `yaml:"required" json:"required" env:"AUTH_REQUIRED"`
+	OIDC     OIDCConfig `yaml:"oidc" json:"oidc"`
+}
+
+// OIDCConfig holds the settings for accepting OIDC bearer tokens, e.g. from the
+// dashboard's single sign-on. Tokens are accepted only when Issuer is set.
+type OIDCConfig struct {
+	Issuer   string `yaml:"issuer" json:"issuer" env:"OIDC_ISSUER"`
+	Audience string `yaml:"audience" json:"audience" env:"OIDC_AUDIENCE"`
+	// JWKSURL is discovered from the issuer when empty
+	JWKSURL      string        `yaml:"jwks_url" json:"jwksUrl" env:"OIDC_JWKS_URL"`
+	JWKSCacheTTL time.Duration `yaml:"jwks_cache_ttl" json:"jwksCacheTtl" env:"OIDC_JWKS_CACHE_TTL"`
+	// TenantClaim and RolesClaim name the claims holding the user's tenant and
+	// roles; nested claims are addressed with dots, e.g. realm_access.roles
+	TenantClaim string `yaml:"tenant_claim" json:"tenantClaim" env:"OIDC_TENANT_CLAIM"`
+	RolesClaim  string `yaml:"roles_claim" json:"rolesClaim" env:"OIDC_ROLES_CLAIM"`
+	// AdminRole is the role that grants admin access
+	AdminRole string `yaml:"admin_role" json:"adminRole" env:"OIDC_ADMIN_ROLE"`
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:                "8080",
+			CORSOrigins:         "http://localhost:3000",
+			MaxRequestBodyBytes: 1 << 20,
+			AccessLog:           true,
+			AccessLogSkipPaths:  "/health,/ready,/metrics",
+			RequestTimeout:      10 * time.Second,
+			BulkRequestTimeout:  2 * time.Minute,
+		},
+		Mongo: MongoConfig{
+			URI:                     "mongodb://localhost:27017/jobprocessor",
+			MaxPoolSize:             100,
+			MaxConnIdleTime:         5 * time.Minute,
+			ConnectTimeout:          10 * time.Second,
+			ServerSelectionTimeout:  5 * time.Second,
+			StartupTimeout:          2 * time.Minute,
+			QueryTimeout:            10 * time.Second,
+			ReportingReadPreference: "primary",
+			SlowQueryThreshold:      500 * time.Millisecond,
+			JobCacheTTL:             5 * time.Second,
+		},
+		Kafka: KafkaConfig{
+			Brokers:                 "localhost:9092",
+			PublishMaxAttempts:      3,
+			BreakerThreshold:        5,
+			BreakerTimeout:          30 * time.Second,
+			OutboxRelayInterval:     5 * time.Second,
+			CancellationAckTimeout:  2 * time.Minute,
+			CancellationMaxAttempts: 3,
+			TopicPartitions:         1,
+			TopicReplicationFactor:  1,
+			ConsumerStartOffset:     "first",
+			RebalanceStrategy:       "range",
+			ConsumerSessionTimeout:  30 * time.Second,
+			ConsumerMaxWait:         10 * time.Second,
+			Balancer:                "least-bytes",
+			Compression:             "none",
+		},
+		Jobs: JobsConfig{
+			MaxPageLimit:              100,
+			MaxInputBytes:             100 << 20,
+			ConfigOffloadBytes:        256 << 10,
+			IDStrategy:                "objectid",
+			FanInInterval:             5 * time.Second,
+			PendingReconcileInterval:  time.Minute,
+			PendingReconcileThreshold: 15 * time.Minute,
+			UsageInterval:             5 * time.Minute,
+			SummarySyncInterval:       5 * time.Second,
+			Backpressure: BackpressureConfig{
+				Mode: "off",
+			},
+		},
+		Artifacts: ArtifactsConfig{
+			Storage: "local",
+			Dir:     "/data/artifacts",
+		},
+		Alerts: AlertsConfig{
+			EvaluationInterval: 30 * time.Second,
+		},
+		Leader: LeaderConfig{
+			LeaseTTL:      15 * time.Second,
+			RenewInterval: 5 * time.Second,
+		},
+		Secrets: SecretsConfig{
+			EncryptedKeys: "credentials",
+			Provider:      "local",
+			KeyID:         "local",
+		},
+		Hooks: HooksConfig{
+			Timeout: 5 * time.Second,
+		},
+		Retention: RetentionConfig{
+			RunAt:       "03:00",
+			BatchSize:   500,
+			AuditMaxAge: 365 * 24 * time.Hour,
+		},
+		Notifications: NotificationsConfig{
+			SMTPPort: "587",
+			SMTPFrom: "jobs@localhost",
+		},
+		Auth: AuthConfig{
+			OIDC: OIDCConfig{
+				JWKSCacheTTL: time.Hour,
+				TenantClaim:  "tenant",
+				RolesClaim:   "roles",
+				AdminRole:    "admin",
+			},
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file at path (if any)
+// and the environment, then validates it
+func Load(path string) (*Config, error) {
+	config := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(config).Elem()); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// applyEnv overrides fields tagged with `env` from the environment
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw := os.Getenv(key)
+		if raw == "" {
+			continue
+		}
+		if err := setValue(value, raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %q: %w", key, raw, err)
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// kafkaNamePattern matches the characters Kafka allows in topic names
+var kafkaNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+func setValue(value reflect.Value, raw string) error {
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(raw))
+	}
+	if value.Type() == durationType {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(parsed))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(parsed)
+	case reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetUint(parsed)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", value.Type())
+		}
+		parsed := reflect.MakeMap(value.Type())
+		for _, pair := range strings.Split(raw, ",") {
+			key, item, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok {
+				return fmt.Errorf("expected key=value pairs")
+			}
+			elem := reflect.New(value.Type().Elem()).Elem()
+			if err := setValue(elem, item); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			parsed.SetMapIndex(reflect.ValueOf(key), elem)
+		}
+		value.Set(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// Validate checks the configuration so that bad values fail at startup instead of at first use
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add("server.port must be a valid port number, got %q", c.Server.Port)
+	}
+	for _, origin := range strings.Split(c.Server.CORSOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "*" && !isHTTPURL(origin) {
+			add("server.cors_origins must contain http(s) URLs or *, got %q", origin)
+		}
+	}
+	if c.Server.MaxRequestBodyBytes < 1 {
+		add("server.max_request_body_bytes must be positive")
+	}
+	if c.Server.RequestTimeout <= 0 {
+		add("server.request_timeout must be positive")
+	}
+	if c.Server.BulkRequestTimeout <= 0 {
+		add("server.bulk_request_timeout must be positive")
+	}
+	for route, timeout := range c.Server.RouteTimeouts {
+		if method, template, ok := strings.Cut(route, " "); !ok || method == "" || !strings.HasPrefix(template, "/") {
+			add("server.route_timeouts keys must be \"METHOD /route/template\", got %q", route)
+		} else if timeout < 0 {
+			add("server.route_timeouts must not be negative, got %s for %q", timeout, route)
+		}
+	}
+
+	if u, err := url.Parse(c.Mongo.URI); err != nil || (u.Scheme != "mongodb" && u.Scheme != "mongodb+srv") || u.Host == "" {
+		add("mongo.uri must be a mongodb:// or mongodb+srv:// URL")
+	}
+	if c.Mongo.MaxPoolSize > 0 && c.Mongo.MinPoolSize > c.Mongo.MaxPoolSize {
+		add("mongo.min_pool_size must not exceed mongo.max_pool_size")
+	}
+	if c.Mongo.StartupTimeout <= 0 {
+		add("mongo.startup_timeout must be positive")
+	}
+	if c.Mongo.JobCacheSize < 0 {
+		add("mongo.job_cache_size must not be negative")
+	}
+	if c.Mongo.JobCacheSize > 0 && c.Mongo.JobCacheTTL <= 0 {
+		add("mongo.job_cache_ttl must be positive when the job cache is enabled")
+	}
+
+	if strings.TrimSpace(c.Kafka.Brokers) == "" {
+		add("kafka.brokers is required")
+	}
+	for _, broker := range strings.Split(c.Kafka.Brokers, ",") {
+		if _, _, err := net.SplitHostPort(strings.TrimSpace(broker)); err != nil {
+			add("kafka.brokers must be host:port pairs, got %q", broker)
+		}
+	}
+	if strings.TrimSpace(c.Kafka.SecondaryBrokers) != "" {
+		for _, broker := range strings.Split(c.Kafka.SecondaryBrokers, ",") {
+			if _, _, err := net.SplitHostPort(strings.TrimSpace(broker)); err != nil {
+				add("kafka.secondary_brokers must be host:port pairs, got %q", broker)
+			}
+		}
+	}
+	if c.Kafka.PublishMaxAttempts < 1 {
+		add("kafka.publish_max_attempts must be at least 1")
+	}
+	if c.Kafka.OutboxRelayInterval <= 0 {
+		add("kafka.outbox_relay_interval must be positive")
+	}
+	if c.Kafka.CancellationAckTimeout <= 0 {
+		add("kafka.cancellation_ack_timeout must be positive")
+	}
+	if c.Kafka.CancellationMaxAttempts < 1 {
+		add("kafka.cancellation_max_attempts must be at least 1")
+	}
+	if c.Kafka.TopicPartitions < 1 {
+		add("kafka.topic_partitions must be at least 1")
+	}
+	if c.Kafka.TopicPrefix != "" && !kafkaNamePattern.MatchString(c.Kafka.TopicPrefix) {
+		add("kafka.topic_prefix may only contain letters, digits, '.', '_' and '-', got %q", c.Kafka.TopicPrefix)
+	}
+	if c.Kafka.GroupPrefix != "" && !kafkaNamePattern.MatchString(c.Kafka.GroupPrefix) {
+		add("kafka.group_prefix may only contain letters, digits, '.', '_' and '-', got %q", c.Kafka.GroupPrefix)
+	}
+	if c.Kafka.ConsumerStartOffset != "first" && c.Kafka.ConsumerStartOffset != "last" {
+		add("kafka.consumer_start_offset must be one of first, last, got %q", c.Kafka.ConsumerStartOffset)
+	}
+	if c.Kafka.RebalanceStrategy != "range" && c.Kafka.RebalanceStrategy != "roundrobin" {
+		add("kafka.rebalance_strategy must be one of range, roundrobin, got %q", c.Kafka.RebalanceStrategy)
+	}
+	if !isKafkaBalancer(c.Kafka.Balancer) {
+		add("kafka.balancer must be one of least-bytes, round-robin, hash, sticky, got %q", c.Kafka.Balancer)
+	}
+	for topic, balancer := range c.Kafka.TopicBalancers {
+		if !kafkaNamePattern.MatchString(topic) {
+			add("kafka.topic_balancers has invalid topic name %q", topic)
+		}
+		if !isKafkaBalancer(balancer) {
+			add("kafka.topic_balancers.%s must be one of least-bytes, round-robin, hash, sticky, got %q", topic, balancer)
+		}
+	}
+	switch c.Kafka.Compression {
+	case "none", "gzip", "snappy", "lz4", "zstd":
+	default:
+		add("kafka.compression must be one of none, gzip, snappy, lz4, zstd, got %q", c.Kafka.Compression)
+	}
+	if c.Kafka.ConsumerSessionTimeout <= 0 {
+		add("kafka.consumer_session_timeout must be positive")
+	}
+	if c.Kafka.ConsumerMaxWait <= 0 {
+		add("kafka.consumer_max_wait must be positive")
+	}
+	if c.Kafka.TopicReplicationFactor < 1 {
+		add("kafka.topic_replication_factor must be at least 1")
+	}
+	if (c.Kafka.TLSCertFile == "") != (c.Kafka.TLSKeyFile == "") {
+		add("kafka.tls_cert_file and kafka.tls_key_file must be set together")
+	}
+	if (c.Kafka.TLSCAFile != "" || c.Kafka.TLSCertFile != "" || c.Kafka.TLSInsecureSkipVerify) && !c.Kafka.TLS {
+		add("kafka.tls must be enabled to use the kafka.tls_* settings")
+	}
+	switch strings.ToLower(c.Kafka.SASLMechanism) {
+	case "":
+	case "plain", "scram-sha-256", "scram-sha-512":
+		if c.Kafka.SASLUsername == "" || c.Kafka.SASLPassword == "" {
+			add("kafka.sasl_username and kafka.sasl_password are required with kafka.sasl_mechanism")
+		}
+	default:
+		add("kafka.sasl_mechanism must be plain, scram-sha-256 or scram-sha-512, got %q", c.Kafka.SASLMechanism)
+	}
+
+	if c.Jobs.MaxPageLimit < 1 {
+		add("jobs.max_page_limit must be at least 1")
+	}
+	if c.Jobs.MaxInputBytes < 1 {
+		add("jobs.max_input_bytes must be positive")
+	}
+	if c.Jobs.ConfigOffloadBytes < 1 {
+		add("jobs.config_offload_bytes must be positive")
+	}
+	if !models.JobIDStrategy(c.Jobs.IDStrategy).Valid() {
+		add("jobs.id_strategy must be objectid or uuidv7, got %q", c.Jobs.IDStrategy)
+	}
+	if c.Jobs.FanInInterval <= 0 {
+		add("jobs.fan_in_interval must be positive")
+	}
+	if c.Jobs.PendingReconcileInterval <= 0 {
+		add("jobs.pending_reconcile_interval must be positive")
+	}
+	if c.Jobs.PendingReconcileThreshold <= 0 {
+		add("jobs.pending_reconcile_threshold must be positive")
+	}
+	if c.Jobs.UsageInterval <= 0 {
+		add("jobs.usage_interval must be positive")
+	}
+	if c.Jobs.SummarySyncInterval <= 0 {
+		add("jobs.summary_sync_interval must be positive")
+	}
+	for jobType, slo := range c.Jobs.SLOs {
+		if !models.IsValidJobType(jobType) {
+			add("jobs.slos has unknown job type %q", jobType)
+		}
+		if slo <= 0 {
+			add("jobs.slos.%s must be positive", jobType)
+		}
+	}
+	for jobType, policy := range c.Jobs.Dedup {
+		if !models.IsValidJobType(jobType) {
+			add("jobs.dedup has unknown job type %q", jobType)
+		}
+		switch policy.Mode {
+		case DedupOff:
+		case DedupReject, DedupDedupe:
+			if policy.Window <= 0 {
+				add("jobs.dedup.%s window must be positive", jobType)
+			}
+		default:
+			add("jobs.dedup.%s mode must be one of off, reject, dedupe, got %q", jobType, policy.Mode)
+		}
+	}
+
+	for jobType, policy := range c.Jobs.RetryPolicies {
+		if !models.IsValidJobType(jobType) {
+			add("jobs.retry_policies has unknown job type %q", jobType)
+		}
+		if policy.MaxRetries != nil && (*policy.MaxRetries < 0 || *policy.MaxRetries > models.MaxRetriesLimit) {
+			add("jobs.retry_policies.%s max_retries must be between 0 and %d", jobType, models.MaxRetriesLimit)
+		}
+		switch models.BackoffCurve(policy.Backoff) {
+		case "", models.BackoffFixed, models.BackoffLinear, models.BackoffExponential:
+		default:
+			add("jobs.retry_policies.%s backoff must be one of fixed, linear, exponential, got %q", jobType, policy.Backoff)
+		}
+		if (policy.InitialDelay != nil && *policy.InitialDelay < 0) || (policy.MaxDelay != nil && *policy.MaxDelay < 0) {
+			add("jobs.retry_policies.%s delays must not be negative", jobType)
+		}
+		switch models.DLQMode(policy.DLQ) {
+		case "", models.DLQOnExhausted, models.DLQAlways, models.DLQNever:
+		default:
+			add("jobs.retry_policies.%s dlq must be one of exhausted, always, never, got %q", jobType, policy.DLQ)
+		}
+	}
+
+	for jobType, percent := range c.Jobs.CanaryPercent {
+		if !models.IsValidJobType(jobType) {
+			add("jobs.canary_percent has unknown job type %q", jobType)
+		}
+		if percent < 0 || percent > 100 {
+			add("jobs.canary_percent.%s must be between 0 and 100", jobType)
+		}
+	}
+
+	if c.Jobs.Backpressure.MaxPending < 0 || c.Jobs.Backpressure.MaxLag < 0 {
+		add("jobs.backpressure thresholds must not be negative")
+	}
+	if !isBackpressureMode(c.Jobs.Backpressure.Mode) {
+		add("jobs.backpressure.mode must be one of off, reject, warn, got %q", c.Jobs.Backpressure.Mode)
+	}
+	for tenant, mode := range c.Jobs.Backpressure.TenantModes {
+		if !isBackpressureMode(mode) {
+			add("jobs.backpressure.tenant_modes.%s must be one of off, reject, warn, got %q", tenant, mode)
+		}
+	}
+
+	if c.Jobs.Quota.Daily < 0 || c.Jobs.Quota.Monthly < 0 {
+		add("jobs.quota limits must not be negative")
+	}
+	for tenant, limit := range c.Jobs.Quota.TenantDaily {
+		if limit < 0 {
+			add("jobs.quota.tenant_daily.%s must not be negative", tenant)
+		}
+	}
+	for tenant, limit := range c.Jobs.Quota.TenantMonthly {
+		if limit < 0 {
+			add("jobs.quota.tenant_monthly.%s must not be negative", tenant)
+		}
+	}
+
+	switch c.Artifacts.Storage {
+	case "local":
+		if c.Artifacts.Dir == "" {
+			add("artifacts.dir is required for local storage")
+		}
+	case "s3", "gcs":
+		if c.Artifacts.Bucket == "" {
+			add("artifacts.bucket is required for %s storage", c.Artifacts.Storage)
+		}
+		if c.Artifacts.Endpoint != "" && !isHTTPURL(c.Artifacts.Endpoint) {
+			add("artifacts.endpoint must be an http(s) URL")
+		}
+	default:
+		add("artifacts.storage must be one of local, s3, gcs, got %q", c.Artifacts.Storage)
+	}
+
+	if c.Alerts.EvaluationInterval <= 0 {
+		add("alerts.evaluation_interval must be positive")
+	}
+
+	if c.Leader.RenewInterval <= 0 {
+		add("leader.renew_interval must be positive")
+	}
+	if c.Leader.LeaseTTL <= c.Leader.RenewInterval {
+		add("leader.lease_ttl must be longer than leader.renew_interval")
+	}
+
+	if c.Secrets.Provider != "local" {
+		add("secrets.provider must be local, got %q", c.Secrets.Provider)
+	}
+	if c.Secrets.Key != "" {
+		if key, err := base64.StdEncoding.DecodeString(c.Secrets.Key); err != nil || len(key) != 32 {
+			add("secrets.key must be a base64-encoded 32-byte key")
+		}
+	}
+
+	for _, endpoint := range strings.Split(c.Hooks.PreEnqueueURLs, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" && !isHTTPURL(endpoint) {
+			add("hooks.pre_enqueue_urls must hold http(s) URLs")
+		}
+	}
+	if c.Hooks.Timeout <= 0 {
+		add("hooks.timeout must be positive")
+	}
+
+	for key, rule := range c.Retention.Rules {
+		jobType, status, err := SplitRetentionKey(key)
+		if err != nil {
+			add("retention.rules keys must be job_type:status, got %q", key)
+			continue
+		}
+		if jobType != "" && !models.IsValidJobType(jobType) {
+			add("retention.rules has unknown job type %q", jobType)
+		}
+		switch models.JobStatus(status) {
+		case "", models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelled:
+		default:
+			add("retention.rules.%s status must be one of completed, failed, cancelled or *, got %q", key, status)
+		}
+		if rule.Action != RetentionDelete && rule.Action != RetentionArchive {
+			add("retention.rules.%s action must be one of delete, archive, got %q", key, rule.Action)
+		}
+		if rule.MaxAge <= 0 {
+			add("retention.rules.%s max age must be positive", key)
+		}
+	}
+	if _, err := c.Retention.RunAtOffset(); err != nil {
+		add("retention.run_at must be a time of day such as 03:00, got %q", c.Retention.RunAt)
+	}
+	if c.Retention.BatchSize < 1 {
+		add("retention.batch_size must be positive")
+	}
+	if c.Retention.AuditMaxAge < 0 {
+		add("retention.audit_max_age must not be negative")
+	}
+
+	if c.Auth.OIDC.Issuer != "" {
+		if u, err := url.Parse(c.Auth.OIDC.Issuer); err != nil || u.Scheme == "" || u.Host == "" {
+			add("auth.oidc.issuer must be a URL, got %q", c.Auth.OIDC.Issuer)
+		}
+		if c.Auth.OIDC.Audience == "" {
+			add("auth.oidc.audience is required when auth.oidc.issuer is set")
+		}
+		if c.Auth.OIDC.JWKSCacheTTL <= 0 {
+			add("auth.oidc.jwks_cache_ttl must be positive")
+		}
+		if c.Auth.OIDC.TenantClaim == "" {
+			add("auth.oidc.tenant_claim must not be empty")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func isBackpressureMode(mode string) bool {
+	return mode == "off" || mode == "reject" || mode == "warn"
+}
+
+func isKafkaBalancer(balancer string) bool {
+	return balancer == "least-bytes" || balancer == "round-robin" || balancer == "hash" || balancer == "sticky"
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Redacted returns a copy of the configuration with secrets masked, safe to expose
+func (c *Config) Redacted() *Config {
+	copied := *c
+	redact(reflect.ValueOf(&copied).Elem())
+	return &copied
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			redact(value)
+			continue
+		}
+		if value.Kind() != reflect.String || value.String() == "" {
+			continue
+		}
+
+		switch field.Tag.Get("secret") {
+		case "true":
+			value.SetString(redactedValue)
+		case "url":
+			// Keep the URL readable but hide any embedded password
+			if u, err := url.Parse(value.String()); err == nil {
+				value.SetString(u.Redacted())
+			} else {
+				value.SetString(redactedValue)
+			}
+		}
+	}
+}
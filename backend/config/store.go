@@ -0,0 +1,64 @@
+package config
+
+import (
+	"log"
+	"reflect"
+	"sync"
+)
+
+// Store holds the live configuration and applies reloads.
+// Only tunables take effect on reload; other changes are logged and require a restart.
+type Store struct {
+	path string
+
+	mu        sync.RWMutex
+	current   *Config
+	listeners []func(*Config)
+}
+
+// NewStore loads the configuration from path and the environment
+func NewStore(path string) (*Store, error) {
+	config, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{path: path, current: config}, nil
+}
+
+// Current returns the configuration in effect. Callers must not modify it.
+func (s *Store) Current() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// OnReload registers a callback invoked with the new configuration after each successful reload
+func (s *Store) OnReload(fn func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Reload re-reads the file and environment and applies the tunables.
+// An invalid configuration is rejected and the current one is kept.
+func (s *Store) Reload() error {
+	loaded, err := Load(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	next := *s.current
+	next.Jobs = loaded.Jobs
+	if !reflect.DeepEqual(next, *loaded) {
+		log.Println("Configuration changes outside the reloadable settings are ignored until restart")
+	}
+	s.current = &next
+	listeners := append([]func(*Config){}, s.listeners...)
+	s.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(&next)
+	}
+	return nil
+}
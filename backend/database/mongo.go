@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// MongoConfig holds MongoDB connection and pool settings
+type MongoConfig struct {
+	URI                    string
+	MaxPoolSize            uint64
+	MinPoolSize            uint64
+	MaxConnIdleTime        time.Duration
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	// StartupTimeout bounds the total time spent retrying the initial connection
+	StartupTimeout time.Duration
+	// InitialBackoff is the first delay between connection attempts; it doubles up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultMongoConfig returns the connection settings used when none are configured
+func DefaultMongoConfig(uri string) MongoConfig {
+	return MongoConfig{
+		URI:                    uri,
+		MaxPoolSize:            100,
+		MinPoolSize:            0,
+		MaxConnIdleTime:        5 * time.Minute,
+		ConnectTimeout:         10 * time.Second,
+		ServerSelectionTimeout: 5 * time.Second,
+		StartupTimeout:         2 * time.Minute,
+		InitialBackoff:         500 * time.Millisecond,
+		MaxBackoff:             10 * time.Second,
+	}
+}
+
+// Connect connects to MongoDB, retrying with exponential backoff until the server
+// answers a ping or the startup timeout elapses. This lets the service start before
+// MongoDB is ready instead of crashing on the first failed attempt.
+func Connect(ctx context.Context, config MongoConfig) (*mongo.Client, error) {
+	opts := options.Client().
+		ApplyURI(config.URI).
+		SetMaxPoolSize(config.MaxPoolSize).
+		SetMinPoolSize(config.MinPoolSize).
+		SetMaxConnIdleTime(config.MaxConnIdleTime).
+		SetConnectTimeout(config.ConnectTimeout).
+		SetServerSelectionTimeout(config.ServerSelectionTimeout)
+
+	// The driver connects lazily and reconnects on its own, so the client is created once
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MongoDB configuration: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.StartupTimeout)
+	defer cancel()
+
+	backoff := config.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := Ping(ctx, client, config.ServerSelectionTimeout)
+		if err == nil {
+			return client, nil
+		}
+
+		log.Printf("MongoDB not ready (attempt %d): %v, retrying in %s", attempt, err, backoff)
+		select {
+		case <-ctx.Done():
+			client.Disconnect(context.Background())
+			return nil, fmt.Errorf("MongoDB not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > config.MaxBackoff {
+			backoff = config.MaxBackoff
+		}
+	}
+}
+
+// Ping checks that the primary is reachable within the timeout. The driver
+// re-establishes dropped connections transparently, so a successful ping after
+// an outage means the service has reconnected.
+func Ping(ctx context.Context, client *mongo.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return client.Ping(ctx, readpref.Primary())
+}
@@ -3,12 +3,17 @@ module github.com/fullstack-assessment/backend
 go 1.21
 
 require (
+	github.com/fullstack-assessment/jobid v0.0.0
+	github.com/fullstack-assessment/lifecycle v0.0.0
+	github.com/fullstack-assessment/notify v0.0.0
 	github.com/gorilla/mux v1.8.1
 	github.com/segmentio/kafka-go v0.4.47
 	go.mongodb.org/mongo-driver v1.13.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/klauspost/compress v1.17.4 // indirect
 	github.com/montanaflynn/stats v0.7.1 // indirect
@@ -21,3 +26,9 @@ require (
 	golang.org/x/sync v0.5.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 )
+
+replace github.com/fullstack-assessment/jobid => ../jobid
+
+replace github.com/fullstack-assessment/lifecycle => ../lifecycle
+
+replace github.com/fullstack-assessment/notify => ../notify
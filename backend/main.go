@@ -2,70 +2,401 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/fullstack-assessment/backend/api/middleware"
+	"github.com/fullstack-assessment/backend/api/shared"
+	"github.com/fullstack-assessment/backend/api/v1/admin"
+	"github.com/fullstack-assessment/backend/api/v1/apikeys"
+	"github.com/fullstack-assessment/backend/api/v1/dashboard"
+	"github.com/fullstack-assessment/backend/api/v1/groups"
 	"github.com/fullstack-assessment/backend/api/v1/jobs"
+	"github.com/fullstack-assessment/backend/api/v1/notifications"
+	"github.com/fullstack-assessment/backend/api/v1/views"
+	"github.com/fullstack-assessment/backend/api/v1/webhooks"
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/buildinfo"
+	"github.com/fullstack-assessment/backend/config"
+	"github.com/fullstack-assessment/backend/database"
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/migrations"
+	"github.com/fullstack-assessment/backend/models"
 	"github.com/fullstack-assessment/backend/repositories"
+	"github.com/fullstack-assessment/backend/secrets"
 	"github.com/fullstack-assessment/backend/services"
+	"github.com/fullstack-assessment/backend/storage"
+	"github.com/fullstack-assessment/lifecycle"
+	"github.com/fullstack-assessment/notify"
 	"github.com/gorilla/mux"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func main() {
-	// Get configuration from environment
-	mongoURI := getEnv("MONGODB_URI", "mongodb://localhost:27017/jobprocessor")
-	kafkaBrokers := getEnv("KAFKA_BROKERS", "localhost:9092")
-	port := getEnv("PORT", "8080")
-	corsOrigins := getEnv("CORS_ORIGINS", "http://localhost:3000")
+	// Load configuration from the optional config file and the environment
+	configStore, err := config.NewStore(os.Getenv(config.FileEnvVar))
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	cfg := configStore.Current()
 
-	// Connect to MongoDB
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Connect to MongoDB, retrying until it is available
+	mongoConfig := database.DefaultMongoConfig(cfg.Mongo.URI)
+	mongoConfig.MaxPoolSize = cfg.Mongo.MaxPoolSize
+	mongoConfig.MinPoolSize = cfg.Mongo.MinPoolSize
+	mongoConfig.MaxConnIdleTime = cfg.Mongo.MaxConnIdleTime
+	mongoConfig.ConnectTimeout = cfg.Mongo.ConnectTimeout
+	mongoConfig.ServerSelectionTimeout = cfg.Mongo.ServerSelectionTimeout
+	mongoConfig.StartupTimeout = cfg.Mongo.StartupTimeout
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	client, err := database.Connect(context.Background(), mongoConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 	defer client.Disconnect(context.Background())
-
-	// Ping MongoDB to verify connection
-	if err := client.Ping(ctx, nil); err != nil {
-		log.Fatalf("Failed to ping MongoDB: %v", err)
-	}
 	log.Println("Connected to MongoDB")
 
 	// Initialize database
 	db := client.Database("jobprocessor")
 
 	// Initialize Kafka producer
-	kafkaProducer := services.NewKafkaProducer(kafkaBrokers)
+	services.SetKafkaNames(services.KafkaNames{
+		TopicPrefix: cfg.Kafka.TopicPrefix,
+		GroupPrefix: cfg.Kafka.GroupPrefix,
+	})
+	kafkaConnConfig := services.KafkaConnectionConfig{
+		Brokers:               services.ParseBrokers(cfg.Kafka.Brokers),
+		TLS:                   cfg.Kafka.TLS,
+		TLSCAFile:             cfg.Kafka.TLSCAFile,
+		TLSCertFile:           cfg.Kafka.TLSCertFile,
+		TLSKeyFile:            cfg.Kafka.TLSKeyFile,
+		TLSInsecureSkipVerify: cfg.Kafka.TLSInsecureSkipVerify,
+		SASLMechanism:         cfg.Kafka.SASLMechanism,
+		SASLUsername:          cfg.Kafka.SASLUsername,
+		SASLPassword:          cfg.Kafka.SASLPassword,
+		Reader: services.ReaderSettings{
+			StartOffset:       cfg.Kafka.ConsumerStartOffset,
+			RebalanceStrategy: cfg.Kafka.RebalanceStrategy,
+			SessionTimeout:    cfg.Kafka.ConsumerSessionTimeout,
+			MaxWait:           cfg.Kafka.ConsumerMaxWait,
+		},
+	}
+	kafkaConn, err := services.NewKafkaConnection(kafkaConnConfig)
+	if err != nil {
+		log.Fatalf("Invalid Kafka connection settings: %v", err)
+	}
+	defer kafkaConn.Close()
+
+	producerConfig := services.DefaultProducerConfig(kafkaConn)
+	producerConfig.MaxAttempts = cfg.Kafka.PublishMaxAttempts
+	producerConfig.BreakerThreshold = cfg.Kafka.BreakerThreshold
+	producerConfig.BreakerTimeout = cfg.Kafka.BreakerTimeout
+	producerConfig.Balancer = cfg.Kafka.Balancer
+	producerConfig.TopicBalancers = cfg.Kafka.TopicBalancers
+	producerConfig.Compression = cfg.Kafka.Compression
+
+	// Publishes fail over to the secondary cluster while the circuit to the primary is open
+	var secondaryKafkaConn *services.KafkaConnection
+	if brokers := services.ParseBrokers(cfg.Kafka.SecondaryBrokers); len(brokers) > 0 {
+		kafkaConnConfig.Brokers = brokers
+		secondaryKafkaConn, err = services.NewKafkaConnection(kafkaConnConfig)
+		if err != nil {
+			log.Fatalf("Invalid secondary Kafka connection settings: %v", err)
+		}
+		defer secondaryKafkaConn.Close()
+		producerConfig.Secondary = secondaryKafkaConn
+	}
+
+	kafkaProducer, err := services.NewKafkaProducer(producerConfig)
+	if err != nil {
+		log.Fatalf("Invalid Kafka producer settings: %v", err)
+	}
 	defer kafkaProducer.Close()
 	log.Println("Connected to Kafka")
 
 	// Initialize repositories
-	jobsRepo := repositories.NewJobsRepository(db)
+	repoConfig := repositories.DefaultConfig()
+	repoConfig.QueryTimeout = cfg.Mongo.QueryTimeout
+	repoConfig.ReportingReadPreference = cfg.Mongo.ReportingReadPreference
+	repoConfig.ReadConcern = cfg.Mongo.ReadConcern
+	repoConfig.SlowQueryThreshold = cfg.Mongo.SlowQueryThreshold
+
+	jobsRepo, err := repositories.NewJobsRepository(db, repoConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize jobs repository: %v", err)
+	}
+	if err := jobsRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create jobs indexes: %v", err)
+	}
+	// Dashboards poll the same recent jobs, so reads by ID may be served from a cache
+	var jobsCache *repositories.CachingJobsRepository
+	if cfg.Mongo.JobCacheSize > 0 {
+		jobsCache = repositories.NewCachingJobsRepository(jobsRepo, db, repositories.CacheConfig{
+			Size: cfg.Mongo.JobCacheSize,
+			TTL:  cfg.Mongo.JobCacheTTL,
+		})
+		jobsRepo = jobsCache
+	}
+	jobBlobsRepo := repositories.NewJobBlobsRepository(db, repoConfig)
+	jobSummariesRepo, err := repositories.NewJobSummariesRepository(db, repoConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize job summaries repository: %v", err)
+	}
+	if err := jobSummariesRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create job summary indexes: %v", err)
+	}
+	jobArchiveRepo := repositories.NewJobArchiveRepository(db, repoConfig)
+	jobLogsRepo := repositories.NewJobLogsRepository(db, repoConfig)
+	if err := jobLogsRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create job log indexes: %v", err)
+	}
+	jobCommentsRepo := repositories.NewJobCommentsRepository(db, repoConfig)
+	if err := jobCommentsRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create job comment indexes: %v", err)
+	}
+	tenantNotificationsRepo := repositories.NewTenantNotificationsRepository(db, repoConfig)
+	jobViewsRepo := repositories.NewJobViewsRepository(db, repoConfig)
+	if err := jobViewsRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create saved view indexes: %v", err)
+	}
+	poisonMessagesRepo := repositories.NewPoisonMessagesRepository(db, repoConfig)
+	if err := poisonMessagesRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create poison message indexes: %v", err)
+	}
+	deadLettersRepo := repositories.NewDeadLettersRepository(db, repoConfig)
+	if err := deadLettersRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create dead letter indexes: %v", err)
+	}
+	quotasRepo := repositories.NewQuotasRepository(db, repoConfig)
+	if err := quotasRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create job quota indexes: %v", err)
+	}
+	usageRepo := repositories.NewUsageRepository(db, repoConfig)
+	if err := usageRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create usage indexes: %v", err)
+	}
+	apiKeysRepo := repositories.NewAPIKeysRepository(db, repoConfig)
+	if err := apiKeysRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create API key indexes: %v", err)
+	}
+	jobGroupsRepo := repositories.NewJobGroupsRepository(db, repoConfig)
+	outboxRepo := repositories.NewOutboxRepository(db, repoConfig)
+	alertsRepo := repositories.NewAlertsRepository(db, repoConfig)
+	webhookDeliveriesRepo := repositories.NewWebhookDeliveriesRepository(db, repoConfig)
+	if err := webhookDeliveriesRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create webhook delivery indexes: %v", err)
+	}
+	workersRepo := repositories.NewWorkersRepository(db, repoConfig)
+	leasesRepo := repositories.NewLeasesRepository(db, repoConfig)
+	kafkaFailoverRepo := repositories.NewKafkaFailoverRepository(db, repoConfig)
+
+	// Apply pending schema and data migrations before serving requests
+	if err := migrations.NewRunner(db, leasesRepo, leaseHolder(), migrations.All).Run(context.Background()); err != nil {
+		log.Fatalf("Failed to apply migrations: %v", err)
+	}
+
+	// Initialize artifact storage
+	artifactStore, err := storage.New(storage.Config{
+		Backend:         cfg.Artifacts.Storage,
+		Dir:             cfg.Artifacts.Dir,
+		Endpoint:        cfg.Artifacts.Endpoint,
+		Region:          cfg.Artifacts.Region,
+		Bucket:          cfg.Artifacts.Bucket,
+		AccessKeyID:     cfg.Artifacts.AccessKeyID,
+		SecretAccessKey: cfg.Artifacts.SecretAccessKey,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure artifact storage: %v", err)
+	}
+
+	// Initialize config encryption
+	keyWrapper, err := secrets.New(secrets.Config{
+		Provider: cfg.Secrets.Provider,
+		Key:      cfg.Secrets.Key,
+		KeyID:    cfg.Secrets.KeyID,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure secrets: %v", err)
+	}
+	encryptor := secrets.NewEncryptor(keyWrapper, strings.Split(cfg.Secrets.EncryptedKeys, ","))
 
 	// Initialize services
-	jobsService := services.NewJobsService(jobsRepo, kafkaProducer)
+	maintenance := services.NewMaintenance()
+	queueMonitor := services.NewQueueMonitor(jobsRepo, kafkaProducer, services.DefaultQueueMonitorInterval)
+	completionWatcher := services.NewCompletionWatcher(jobsRepo, services.DefaultCompletionWatcherInterval)
+	jobWatcher := services.NewJobWatcher(jobsRepo, services.DefaultJobWatchInterval)
+
+	// Job events from the services reach metrics and waiting clients through the event bus
+	eventBus := services.NewEventBus()
+	eventBus.Subscribe(services.CountJobEvents)
+	eventBus.Subscribe(completionWatcher.HandleEvent, services.EventJobStatusChanged)
+	eventBus.Subscribe(jobWatcher.HandleEvent, services.EventJobCreated, services.EventJobStatusChanged)
+	summaryProjector := services.NewJobSummaryProjector(jobSummariesRepo, services.DefaultSummaryFlushInterval, cfg.Jobs.SummarySyncInterval)
+	eventBus.Subscribe(summaryProjector.HandleEvent)
+	// Workers notify on the jobs they finish, the backend on those it finishes
+	statusNotifier := services.NewStatusNotifier(notifySettings(cfg), jobsRepo, tenantNotificationsRepo)
+	eventBus.Subscribe(statusNotifier.HandleEvent, services.EventJobStatusChanged)
+
+	// External hooks see every new job before it is stored and queued
+	hooks := services.NewHooks()
+	for _, endpoint := range strings.Split(cfg.Hooks.PreEnqueueURLs, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			hooks.RegisterPreEnqueue(services.NewHTTPHook(endpoint, cfg.Hooks.Timeout, cfg.Hooks.FailOpen))
+		}
+	}
+
+	jobsService := services.NewJobsService(jobsRepo, outboxRepo, quotasRepo, jobBlobsRepo, jobSummariesRepo, jobArchiveRepo, kafkaProducer, artifactStore, maintenance, queueMonitor, completionWatcher, jobWatcher, eventBus, encryptor, hooks, jobsServiceConfig(cfg))
+
+	jobLogsService := services.NewJobLogsService(jobsRepo, jobLogsRepo)
+	jobCommentsService := services.NewJobCommentsService(jobsRepo, jobCommentsRepo)
+	viewsService := services.NewViewsService(jobViewsRepo)
+	tenantNotificationsService := services.NewTenantNotificationsService(tenantNotificationsRepo)
+	groupsService := services.NewGroupsService(jobGroupsRepo, jobsRepo, jobsService)
+	webhookSender := services.NewWebhookSender(webhookDeliveriesRepo)
+	alertsService := services.NewAlertsService(alertsRepo, webhookDeliveriesRepo, webhookSender)
+	workersService := services.NewWorkersService(workersRepo, jobsRepo, queueMonitor)
+	maintenanceService := services.NewMaintenanceService(maintenance, jobsService)
+	kafkaFailoverService := services.NewKafkaFailoverService(kafkaFailoverRepo, workersService)
+	// Cancellations are resolved once they went unacknowledged for every attempt
+	cancellationsService := services.NewCancellationsService(jobsRepo, workersService, eventBus,
+		cfg.Kafka.CancellationAckTimeout*time.Duration(cfg.Kafka.CancellationMaxAttempts))
+	poisonMessagesService := services.NewPoisonMessagesService(poisonMessagesRepo)
+	deadLettersService := services.NewDeadLettersService(deadLettersRepo)
+	usageService := services.NewUsageService(usageRepo)
+	retentionService := services.NewRetentionService(jobsRepo, jobArchiveRepo, jobSummariesRepo, jobLogsRepo, jobCommentsRepo, jobBlobsRepo, retentionRules(cfg), cfg.Retention.AuditMaxAge, cfg.Retention.BatchSize)
+	apiKeysService := services.NewAPIKeysService(apiKeysRepo)
+	dashboardService := services.NewDashboardService(jobsRepo, workersService, kafkaProducer)
+
+	// Dashboard users sign in through single sign-on when an OIDC issuer is configured
+	var oidcVerifier *auth.OIDCVerifier
+	if cfg.Auth.OIDC.Issuer != "" {
+		oidcVerifier = auth.NewOIDCVerifier(auth.OIDCConfig{
+			Issuer:      cfg.Auth.OIDC.Issuer,
+			Audience:    cfg.Auth.OIDC.Audience,
+			JWKSURL:     cfg.Auth.OIDC.JWKSURL,
+			CacheTTL:    cfg.Auth.OIDC.JWKSCacheTTL,
+			TenantClaim: cfg.Auth.OIDC.TenantClaim,
+			RolesClaim:  cfg.Auth.OIDC.RolesClaim,
+			AdminRole:   cfg.Auth.OIDC.AdminRole,
+		})
+	}
+
+	// Apply tunables when the configuration is reloaded
+	configStore.OnReload(func(cfg *config.Config) {
+		jobsService.Reconfigure(jobsServiceConfig(cfg))
+	})
+
+	// Start background components
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	if jobsCache != nil {
+		go jobsCache.Run(backgroundCtx)
+	}
+
+	// The outbox relay, sweepers and alert evaluator run on one instance at a time
+	elector := services.NewLeaderElector(leasesRepo, leaseHolder(), cfg.Leader.LeaseTTL, cfg.Leader.RenewInterval)
+
+	outboxRelay := services.NewOutboxRelay(outboxRepo, kafkaProducer, cfg.Kafka.OutboxRelayInterval, 100)
+	elector.Start(backgroundCtx, services.LeaseOutboxRelay, outboxRelay.Run)
+
+	ackConsumer := services.NewCancellationAckConsumer(kafkaConn, jobsRepo, poisonMessagesRepo, eventBus)
+	go ackConsumer.Run(backgroundCtx)
+
+	cancellationSweeper := services.NewCancellationSweeper(jobsRepo, outboxRepo, kafkaProducer, cancellationsService,
+		cfg.Kafka.CancellationAckTimeout, cfg.Kafka.CancellationMaxAttempts)
+	elector.Start(backgroundCtx, services.LeaseCancellationSweeper, cancellationSweeper.Run)
+
+	fanInSweeper := services.NewFanInSweeper(jobsRepo, cfg.Jobs.FanInInterval, eventBus)
+	elector.Start(backgroundCtx, services.LeaseFanInSweeper, fanInSweeper.Run)
+
+	pendingReconciler := services.NewPendingReconciler(jobsService, cfg.Jobs.PendingReconcileInterval, cfg.Jobs.PendingReconcileThreshold)
+	elector.Start(backgroundCtx, services.LeasePendingReconciler, pendingReconciler.Run)
+
+	// Aggregate the daily usage of every tenant for charge-back
+	usageAggregator := services.NewUsageAggregator(usageRepo, cfg.Jobs.UsageInterval)
+	elector.Start(backgroundCtx, services.LeaseUsageAggregator, usageAggregator.Run)
+
+	// Every instance projects the jobs it changed; one catches up on the changes made by workers
+	go summaryProjector.Run(backgroundCtx)
+	elector.Start(backgroundCtx, services.LeaseJobSummarySync, summaryProjector.RunSync)
+
+	// Remove finished jobs and comments past their retention period every night
+	if len(cfg.Retention.Rules) > 0 || cfg.Retention.AuditMaxAge > 0 {
+		runAt, _ := cfg.Retention.RunAtOffset()
+		elector.Start(backgroundCtx, services.LeaseRetention, services.NewRetentionWorker(retentionService, runAt).Run)
+	}
+
+	go queueMonitor.Run(backgroundCtx)
+	go completionWatcher.Run(backgroundCtx)
+	go jobWatcher.Run(backgroundCtx)
+
+	topicVerifier := services.NewTopicVerifier(kafkaConn, services.RequiredTopics(), services.TopicSettings{
+		Create:            cfg.Kafka.CreateTopics,
+		Partitions:        cfg.Kafka.TopicPartitions,
+		ReplicationFactor: cfg.Kafka.TopicReplicationFactor,
+	})
+	go topicVerifier.Run(backgroundCtx)
+	if secondaryKafkaConn != nil {
+		// The secondary cluster needs the same topics to take over; it does not gate readiness
+		go services.NewTopicVerifier(secondaryKafkaConn, services.RequiredTopics(), services.TopicSettings{
+			Create:            cfg.Kafka.CreateTopics,
+			Partitions:        cfg.Kafka.TopicPartitions,
+			ReplicationFactor: cfg.Kafka.TopicReplicationFactor,
+		}).Run(backgroundCtx)
+	}
+
+	alertEvaluator := services.NewAlertEvaluator(alertsRepo, jobsRepo, kafkaProducer, webhookSender, cfg.Alerts.EvaluationInterval)
+	elector.Start(backgroundCtx, services.LeaseAlertEvaluator, alertEvaluator.Run)
 
 	// Initialize handlers
-	jobsHandler := jobs.NewHandler(jobsService)
+	jobsHandler := jobs.NewHandler(jobsService, jobLogsService, jobCommentsService, viewsService, cfg.Server.MaxRequestBodyBytes)
+	viewsHandler := views.NewHandler(viewsService, cfg.Server.MaxRequestBodyBytes)
+	notificationsHandler := notifications.NewHandler(tenantNotificationsService, cfg.Server.MaxRequestBodyBytes)
+	groupsHandler := groups.NewHandler(groupsService, jobsService, cfg.Server.MaxRequestBodyBytes)
+	apiKeysHandler := apikeys.NewHandler(apiKeysService, cfg.Server.MaxRequestBodyBytes)
+	dashboardHandler := dashboard.NewHandler(dashboardService)
+	webhooksHandler := webhooks.NewHandler(alertsService)
+	adminHandler := admin.NewHandler(configStore, alertsService, workersService, maintenanceService, poisonMessagesService, deadLettersService, jobCommentsService, jobsService, usageService, retentionService, kafkaFailoverService, cancellationsService, cfg.Server.MaxRequestBodyBytes)
 
 	// Setup router
 	router := mux.NewRouter()
 
-	// CORS middleware
-	router.Use(corsMiddleware(corsOrigins))
+	// CORS and compression middleware
+	router.Use(corsMiddleware(cfg.Server.CORSOrigins))
+	router.Use(middleware.Gzip())
 
 	// API routes
 	apiRouter := router.PathPrefix("/api/v1").Subrouter()
+	apiRouter.Use(middleware.Timeout(middleware.TimeoutConfig{
+		Default: cfg.Server.RequestTimeout,
+		Bulk:    cfg.Server.BulkRequestTimeout,
+		Routes:  cfg.Server.RouteTimeouts,
+	}))
+	apiRouter.Use(middleware.Authenticate(apiKeysService, oidcVerifier, cfg.Auth.Required))
+	apiRouter.Use(middleware.FieldCase())
 	jobsHandler.RegisterRoutes(apiRouter)
+	viewsHandler.RegisterRoutes(apiRouter)
+	notificationsHandler.RegisterRoutes(apiRouter)
+	groupsHandler.RegisterRoutes(apiRouter)
+	apiKeysHandler.RegisterRoutes(apiRouter)
+	dashboardHandler.RegisterRoutes(apiRouter)
+	webhooksHandler.RegisterRoutes(apiRouter)
+	adminHandler.RegisterRoutes(apiRouter)
+
+	// Metrics
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	// Build of the running binary
+	buildinfo.RecordMetric()
+	router.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		shared.RespondJSON(w, http.StatusOK, buildinfo.Get())
+	}).Methods("GET")
 
 	// Health check
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -73,45 +404,178 @@ func main() {
 		w.Write([]byte("OK"))
 	}).Methods("GET")
 
+	// Readiness check, failing while MongoDB is unreachable or Kafka topics are missing
+	router.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		if err := database.Ping(r.Context(), client, 2*time.Second); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("MongoDB unavailable: " + err.Error()))
+			return
+		}
+		if err := topicVerifier.Ready(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("READY"))
+	}).Methods("GET")
+
+	// Log and time every request, including those matching no route
+	handler := middleware.AccessLog(router, middleware.AccessLogConfig{
+		Enabled:   cfg.Server.AccessLog,
+		SkipPaths: strings.Split(cfg.Server.AccessLogSkipPaths, ","),
+	})
+
 	// Start server
 	server := &http.Server{
-		Addr:         ":" + port,
-		Handler:      router,
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      handler,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Graceful shutdown
+	// Reload tunables on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
 	go func() {
-		log.Printf("Server starting on port %s", port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed: %v", err)
+		for range reload {
+			if err := configStore.Reload(); err != nil {
+				log.Printf("Configuration reload rejected: %v", err)
+				continue
+			}
+			log.Println("Configuration reloaded")
 		}
 	}()
 
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// The server stops first so requests in flight finish before the leases of
+	// the background components are released
+	group := lifecycle.New()
+	group.Add(
+		lifecycle.Component{
+			Name: "background",
+			// Release the leases so another instance takes the singleton components over at once
+			Stop: func(ctx context.Context) error {
+				stopBackground()
+				elector.Wait()
+				return nil
+			},
+		},
+		lifecycle.Server("http server", server, 30*time.Second, "background"),
+	)
 
-	log.Println("Shutting down server...")
+	log.Printf("Server %s starting on port %s", buildinfo.Version, cfg.Server.Port)
+	if err := group.Run(context.Background(), syscall.SIGINT, syscall.SIGTERM); err != nil {
+		log.Fatalf("Server stopped with errors: %v", err)
+	}
 
-	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	log.Println("Server stopped")
+}
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+// leaseHolder identifies this instance in the leases it holds
+func leaseHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "backend"
 	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
 
-	log.Println("Server stopped")
+// jobsServiceConfig extracts the jobs service tunables from the configuration
+func jobsServiceConfig(cfg *config.Config) services.JobsServiceConfig {
+	dedup := make(map[string]services.DedupPolicy, len(cfg.Jobs.Dedup))
+	for jobType, policy := range cfg.Jobs.Dedup {
+		dedup[jobType] = services.DedupPolicy{
+			Mode:   services.DedupMode(policy.Mode),
+			Window: policy.Window,
+		}
+	}
+
+	tenantModes := make(map[string]services.BackpressureMode, len(cfg.Jobs.Backpressure.TenantModes))
+	for tenant, mode := range cfg.Jobs.Backpressure.TenantModes {
+		tenantModes[tenant] = services.BackpressureMode(mode)
+	}
+
+	retryPolicies := make(map[string]services.RetryPolicyOverride, len(cfg.Jobs.RetryPolicies))
+	for jobType, policy := range cfg.Jobs.RetryPolicies {
+		retryPolicies[jobType] = services.RetryPolicyOverride{
+			MaxRetries:   policy.MaxRetries,
+			AutoRetry:    policy.AutoRetry,
+			Backoff:      models.BackoffCurve(policy.Backoff),
+			InitialDelay: policy.InitialDelay,
+			MaxDelay:     policy.MaxDelay,
+			DLQ:          models.DLQMode(policy.DLQ),
+		}
+	}
+
+	redactedKeys := splitList(cfg.Jobs.RedactedConfigKeys)
+
+	return services.JobsServiceConfig{
+		MaxPageLimit: cfg.Jobs.MaxPageLimit,
+		SLOs:         cfg.Jobs.SLOs,
+		Dedup:        dedup,
+		Backpressure: services.BackpressureConfig{
+			MaxPending:  cfg.Jobs.Backpressure.MaxPending,
+			MaxLag:      cfg.Jobs.Backpressure.MaxLag,
+			Mode:        services.BackpressureMode(cfg.Jobs.Backpressure.Mode),
+			TenantModes: tenantModes,
+		},
+		RedactedConfigKeys: redactedKeys,
+		RetryPolicies:      retryPolicies,
+		CanaryPercent:      cfg.Jobs.CanaryPercent,
+		MaxInputBytes:      cfg.Jobs.MaxInputBytes,
+		ConfigOffloadBytes: cfg.Jobs.ConfigOffloadBytes,
+		ListActiveOnly:     cfg.Jobs.ListActiveOnly,
+		IDStrategy:         models.JobIDStrategy(cfg.Jobs.IDStrategy),
+		Quota: services.QuotaConfig{
+			Daily:         cfg.Jobs.Quota.Daily,
+			Monthly:       cfg.Jobs.Quota.Monthly,
+			TenantDaily:   cfg.Jobs.Quota.TenantDaily,
+			TenantMonthly: cfg.Jobs.Quota.TenantMonthly,
+		},
+	}
+}
+
+// notifySettings extracts the global notification settings from the configuration
+func notifySettings(cfg *config.Config) notify.Settings {
+	return notify.Settings{
+		SMTP: notify.SMTPConfig{
+			Host:     cfg.Notifications.SMTPHost,
+			Port:     cfg.Notifications.SMTPPort,
+			Username: cfg.Notifications.SMTPUsername,
+			Password: cfg.Notifications.SMTPPassword,
+			From:     cfg.Notifications.SMTPFrom,
+		},
+		Email:           splitList(cfg.Notifications.Email),
+		SlackWebhookURL: cfg.Notifications.SlackWebhookURL,
+		On:              splitList(cfg.Notifications.On),
+	}
+}
+
+// splitList splits a comma-separated configuration value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// retentionRules converts the configured retention rules, which Validate has checked
+func retentionRules(cfg *config.Config) []services.RetentionRule {
+	rules := make([]services.RetentionRule, 0, len(cfg.Retention.Rules))
+	for key, rule := range cfg.Retention.Rules {
+		jobType, status, _ := config.SplitRetentionKey(key)
+		rules = append(rules, services.RetentionRule{
+			JobType: models.JobType(jobType),
+			Status:  models.JobStatus(status),
+			Action:  services.RetentionAction(rule.Action),
+			MaxAge:  rule.MaxAge,
+		})
 	}
-	return defaultValue
+	return rules
 }
 
 func corsMiddleware(allowedOrigins string) mux.MiddlewareFunc {
@@ -119,7 +583,8 @@ func corsMiddleware(allowedOrigins string) mux.MiddlewareFunc {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Access-Control-Allow-Origin", allowedOrigins)
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match, If-Match, Accept-Profile, X-API-Key")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag, Retry-After")
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 			if r.Method == "OPTIONS" {
@@ -0,0 +1,315 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// collector is implemented by every metric type registered with a Registry
+type collector interface {
+	name() string
+	write(sb *strings.Builder)
+}
+
+// Registry holds metrics and renders them in the Prometheus text exposition format
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default is the registry used by the package-level constructors
+var Default = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.collectors[c.name()]; exists {
+		panic(fmt.Sprintf("metrics: duplicate registration of %s", c.name()))
+	}
+	r.collectors[c.name()] = c
+}
+
+// Handler returns an HTTP handler serving the registry's metrics
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.Lock()
+		names := make([]string, 0, len(r.collectors))
+		for name := range r.collectors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		collectors := make([]collector, len(names))
+		for i, name := range names {
+			collectors[i] = r.collectors[name]
+		}
+		r.mu.Unlock()
+
+		var sb strings.Builder
+		for _, c := range collectors {
+			c.write(&sb)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(sb.String()))
+	})
+}
+
+// Handler serves the default registry
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// desc holds the metadata shared by all metric types
+type desc struct {
+	metricName string
+	help       string
+	kind       string
+	labels     []string
+}
+
+func (d desc) name() string {
+	return d.metricName
+}
+
+func (d desc) writeHeader(sb *strings.Builder) {
+	fmt.Fprintf(sb, "# HELP %s %s\n", d.metricName, d.help)
+	fmt.Fprintf(sb, "# TYPE %s %s\n", d.metricName, d.kind)
+}
+
+// labelKey joins label values into a map key
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// formatLabels renders label pairs, including any extra pair such as a histogram bucket
+func formatLabels(names, values []string, extra ...string) string {
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return fmt.Sprintf("%g", v)
+}
+
+// series holds one float value per label combination
+type series struct {
+	mu     sync.Mutex
+	values map[string]float64
+	labels map[string][]string
+}
+
+func newSeries() series {
+	return series{values: make(map[string]float64), labels: make(map[string][]string)}
+}
+
+func (s *series) add(labelValues []string, delta float64) {
+	key := labelKey(labelValues)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.labels[key]; !ok {
+		s.labels[key] = append([]string(nil), labelValues...)
+	}
+	s.values[key] += delta
+}
+
+func (s *series) set(labelValues []string, value float64) {
+	key := labelKey(labelValues)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.labels[key]; !ok {
+		s.labels[key] = append([]string(nil), labelValues...)
+	}
+	s.values[key] = value
+}
+
+func (s *series) write(sb *strings.Builder, d desc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 && len(d.labels) == 0 {
+		fmt.Fprintf(sb, "%s 0\n", d.metricName)
+		return
+	}
+	for _, key := range keys {
+		fmt.Fprintf(sb, "%s%s %s\n", d.metricName, formatLabels(d.labels, s.labels[key]), formatValue(s.values[key]))
+	}
+}
+
+// Counter is a monotonically increasing value, optionally partitioned by labels
+type Counter struct {
+	desc
+	series
+}
+
+// NewCounter registers a counter with the default registry
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{metricName: name, help: help, kind: "counter", labels: labels}, series: newSeries()}
+	Default.register(c)
+	return c
+}
+
+// Inc increments the counter for the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta to the counter for the given label values
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	c.series.add(labelValues, delta)
+}
+
+func (c *Counter) write(sb *strings.Builder) {
+	c.writeHeader(sb)
+	c.series.write(sb, c.desc)
+}
+
+// Gauge is a value that can go up and down, optionally partitioned by labels
+type Gauge struct {
+	desc
+	series
+}
+
+// NewGauge registers a gauge with the default registry
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{desc: desc{metricName: name, help: help, kind: "gauge", labels: labels}, series: newSeries()}
+	Default.register(g)
+	return g
+}
+
+// Set sets the gauge for the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.series.set(labelValues, value)
+}
+
+// Add adds delta to the gauge for the given label values
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.series.add(labelValues, delta)
+}
+
+func (g *Gauge) write(sb *strings.Builder) {
+	g.writeHeader(sb)
+	g.series.write(sb, g.desc)
+}
+
+// GaugeFunc is a gauge whose value is computed when metrics are collected
+type GaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+// NewGaugeFunc registers a computed gauge with the default registry
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{metricName: name, help: help, kind: "gauge"}, fn: fn}
+	Default.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(sb *strings.Builder) {
+	g.writeHeader(sb)
+	fmt.Fprintf(sb, "%s %s\n", g.metricName, formatValue(g.fn()))
+}
+
+// DefaultBuckets are the upper bounds of histogram buckets for latencies in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations in cumulative buckets, optionally partitioned by labels
+type Histogram struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	// counts holds the observations per bucket, not cumulated
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds, in
+// increasing order, with the default registry
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{metricName: name, help: help, kind: "histogram", labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	Default.register(h)
+	return h
+}
+
+// Observe records a value for the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := labelKey(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *Histogram) write(sb *strings.Builder) {
+	h.writeHeader(sb)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(sb, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, s.labels, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(sb, "%s_bucket%s %d\n", h.metricName, formatLabels(h.labels, s.labels, "le", formatValue(math.Inf(1))), s.count)
+		fmt.Fprintf(sb, "%s_sum%s %s\n", h.metricName, formatLabels(h.labels, s.labels), formatValue(s.sum))
+		fmt.Fprintf(sb, "%s_count%s %d\n", h.metricName, formatLabels(h.labels, s.labels), s.count)
+	}
+}
@@ -0,0 +1,74 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// All lists the migrations of the backend. New migrations are appended with
+// the next version; applied ones are never changed or removed.
+var All = []Migration{
+	{
+		Version:     1,
+		Description: "expire job locks left behind by stopped workers",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// Workers delete their locks when done; a TTL index removes those of
+			// workers that died holding them
+			_, err := db.Collection("job_locks").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("job_locks_expires").SetExpireAfterSeconds(0),
+			})
+			return err
+		},
+	},
+	{
+		Version:     2,
+		Description: "generate webhook signing secrets for existing alert rules",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			rules := db.Collection("alert_rules")
+			cursor, err := rules.Find(ctx, bson.M{
+				"webhook_url":    bson.M{"$nin": bson.A{nil, ""}},
+				"webhook_secret": bson.M{"$in": bson.A{nil, ""}},
+			}, options.Find().SetProjection(bson.M{"_id": 1}))
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				var rule struct {
+					ID primitive.ObjectID `bson:"_id"`
+				}
+				if err := cursor.Decode(&rule); err != nil {
+					return err
+				}
+				secret, err := models.NewWebhookSecret()
+				if err != nil {
+					return err
+				}
+				if _, err := rules.UpdateOne(ctx, bson.M{"_id": rule.ID}, bson.M{"$set": bson.M{"webhook_secret": secret}}); err != nil {
+					return err
+				}
+			}
+			return cursor.Err()
+		},
+	},
+	{
+		Version:     3,
+		Description: "store an empty tenant on jobs created without one",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			// The tenant leads the shard key of the jobs collection, and writes
+			// match jobs by it, so it must be present on every job
+			_, err := db.Collection("jobs").UpdateMany(ctx,
+				bson.M{"tenant": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"tenant": ""}},
+			)
+			return err
+		},
+	},
+}
@@ -0,0 +1,190 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Lease is the lease held by the backend instance applying migrations, so
+// instances starting together do not apply the same migration twice
+const Lease = "migrations"
+
+const (
+	// leaseTTL is how long the migrations lease lasts without being renewed
+	leaseTTL = time.Minute
+	// leaseRetryInterval is how often an instance waiting for the lease retries
+	leaseRetryInterval = 2 * time.Second
+)
+
+// Migration is a versioned change to the database, such as a backfill, a
+// renamed field or a new collection. Migrations are applied in version order,
+// each at most once. A migration that fails is retried on the next startup,
+// so Up must be safe to run again after a partial run.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// appliedMigration is the record of an applied migration in the migrations collection
+type appliedMigration struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+	DurationMs  int64     `bson:"duration_ms"`
+}
+
+// Runner applies the migrations a database has not had yet
+type Runner struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+	leases     repositories.LeasesRepository
+	holder     string
+	migrations []Migration
+}
+
+// NewRunner creates a runner applying migrations to db. holder identifies this
+// instance in the migrations lease.
+func NewRunner(db *mongo.Database, leases repositories.LeasesRepository, holder string, migrations []Migration) *Runner {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return &Runner{
+		db:         db,
+		collection: db.Collection("migrations"),
+		leases:     leases,
+		holder:     holder,
+		migrations: sorted,
+	}
+}
+
+// Run applies every pending migration. It waits while another instance holds
+// the migrations lease, then applies whatever that instance did not.
+func (r *Runner) Run(ctx context.Context) error {
+	for i, migration := range r.migrations {
+		if migration.Version <= 0 {
+			return fmt.Errorf("migration %q has invalid version %d", migration.Description, migration.Version)
+		}
+		if i > 0 && r.migrations[i-1].Version == migration.Version {
+			return fmt.Errorf("duplicate migration version %d", migration.Version)
+		}
+	}
+
+	pending, err := r.pending(ctx)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	if err := r.acquire(ctx); err != nil {
+		return err
+	}
+	defer r.leases.Release(context.Background(), Lease, r.holder)
+
+	// Keep the lease while migrations run, however long they take
+	renewCtx, stopRenewing := context.WithCancel(ctx)
+	defer stopRenewing()
+	go r.renew(renewCtx)
+
+	// Another instance may have applied some while this one waited for the lease
+	pending, err = r.pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, migration := range pending {
+		if err := r.apply(ctx, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pending returns the migrations not yet recorded as applied, in version order
+func (r *Runner) pending(ctx context.Context) ([]Migration, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	var applied []appliedMigration
+	if err := cursor.All(ctx, &applied); err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+
+	done := make(map[int]bool, len(applied))
+	for _, migration := range applied {
+		done[migration.Version] = true
+	}
+
+	var pending []Migration
+	for _, migration := range r.migrations {
+		if !done[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// apply runs one migration and records it as applied
+func (r *Runner) apply(ctx context.Context, migration Migration) error {
+	log.Printf("Applying migration %d: %s", migration.Version, migration.Description)
+	started := time.Now()
+
+	if err := migration.Up(ctx, r.db); err != nil {
+		return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+	}
+
+	record := appliedMigration{
+		Version:     migration.Version,
+		Description: migration.Description,
+		AppliedAt:   time.Now(),
+		DurationMs:  time.Since(started).Milliseconds(),
+	}
+	if _, err := r.collection.InsertOne(ctx, record); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+	log.Printf("Applied migration %d in %s", migration.Version, time.Since(started).Round(time.Millisecond))
+	return nil
+}
+
+// acquire waits until this instance holds the migrations lease
+func (r *Runner) acquire(ctx context.Context) error {
+	for {
+		acquired, err := r.leases.Acquire(ctx, Lease, r.holder, leaseTTL)
+		if err != nil {
+			return fmt.Errorf("failed to acquire migrations lease: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+
+		log.Printf("Waiting for another instance to finish migrations")
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(leaseRetryInterval):
+		}
+	}
+}
+
+// renew renews the migrations lease until ctx is cancelled
+func (r *Runner) renew(ctx context.Context) {
+	ticker := time.NewTicker(leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.leases.Acquire(ctx, Lease, r.holder, leaseTTL); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to renew migrations lease: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AlertKind identifies the condition an alert rule evaluates
+type AlertKind string
+
+const (
+	// AlertKindFailureRate fires when the share of failed jobs over a window exceeds the threshold
+	AlertKindFailureRate AlertKind = "failure_rate"
+	// AlertKindDLQDepth fires when the dead letter queue holds more messages than the threshold
+	AlertKindDLQDepth AlertKind = "dlq_depth"
+)
+
+// AlertRule is an operator-defined condition evaluated against job activity
+type AlertRule struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name    string             `bson:"name" json:"name"`
+	Kind    AlertKind          `bson:"kind" json:"kind"`
+	JobType JobType            `bson:"job_type,omitempty" json:"jobType,omitempty"`
+	// Tenant owns the rule's webhook deliveries; rules without one are
+	// deployment-wide and their deliveries are only reachable by admins
+	Tenant string `bson:"tenant,omitempty" json:"tenant,omitempty"`
+	// Threshold is a ratio between 0 and 1 for failure_rate and a message count for dlq_depth
+	Threshold float64 `bson:"threshold" json:"threshold"`
+	// WindowSeconds is the evaluation window for failure_rate rules
+	WindowSeconds int64 `bson:"window_seconds,omitempty" json:"windowSeconds,omitempty"`
+	// MinSamples is the number of finished jobs required before a failure_rate rule can fire
+	MinSamples      int64  `bson:"min_samples,omitempty" json:"minSamples,omitempty"`
+	SlackWebhookURL string `bson:"slack_webhook_url,omitempty" json:"slackWebhookUrl,omitempty"`
+	WebhookURL      string `bson:"webhook_url,omitempty" json:"webhookUrl,omitempty"`
+	// WebhookSecret signs the payloads sent to WebhookURL
+	WebhookSecret   string     `bson:"webhook_secret,omitempty" json:"webhookSecret,omitempty"`
+	Enabled         bool       `bson:"enabled" json:"enabled"`
+	Firing          bool       `bson:"firing" json:"firing"`
+	LastValue       float64    `bson:"last_value" json:"lastValue"`
+	LastEvaluatedAt *time.Time `bson:"last_evaluated_at,omitempty" json:"lastEvaluatedAt,omitempty"`
+	LastFiredAt     *time.Time `bson:"last_fired_at,omitempty" json:"lastFiredAt,omitempty"`
+	CreatedAt       time.Time  `bson:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time  `bson:"updated_at" json:"updatedAt"`
+}
+
+// Window returns the evaluation window of the rule
+func (r *AlertRule) Window() time.Duration {
+	return time.Duration(r.WindowSeconds) * time.Second
+}
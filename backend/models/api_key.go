@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API key scopes
+const (
+	// ScopeJobsRead allows reading jobs, views and the other tenant resources
+	ScopeJobsRead = "jobs:read"
+	// ScopeJobsWrite allows creating and changing them
+	ScopeJobsWrite = "jobs:write"
+	// ScopeAdmin allows everything, including the operational endpoints
+	ScopeAdmin = "admin"
+)
+
+// ValidScopes returns the scopes an API key can be granted
+func ValidScopes() []string {
+	return []string{ScopeJobsRead, ScopeJobsWrite, ScopeAdmin}
+}
+
+// APIKey is a credential a tenant's clients authenticate with. Only a hash of
+// the secret is stored; the secret itself is shown once, when the key is created.
+type APIKey struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Tenant string             `bson:"tenant" json:"tenant"`
+	Name   string             `bson:"name" json:"name"`
+	// Prefix is the public start of the key, identifying it in listings and lookups
+	Prefix string   `bson:"prefix" json:"prefix"`
+	Hash   string   `bson:"hash" json:"-"`
+	Scopes []string `bson:"scopes" json:"scopes"`
+	// RateLimitPerMinute caps the requests made with the key; zero is unlimited
+	RateLimitPerMinute int        `bson:"rate_limit_per_minute,omitempty" json:"rateLimitPerMinute,omitempty"`
+	CreatedBy          string     `bson:"created_by" json:"createdBy"`
+	CreatedAt          time.Time  `bson:"created_at" json:"createdAt"`
+	LastUsedAt         *time.Time `bson:"last_used_at,omitempty" json:"lastUsedAt,omitempty"`
+	RevokedAt          *time.Time `bson:"revoked_at,omitempty" json:"revokedAt,omitempty"`
+}
+
+// HasScope reports whether the key grants a scope; the admin scope grants every scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// HashConfig returns a deterministic SHA-256 hash of a job config.
+// The config is normalized first: map keys are sorted, numbers share one
+// representation regardless of how they were decoded, and a nil config
+// hashes the same as an empty one.
+func HashConfig(config map[string]interface{}) (string, error) {
+	normalized, err := normalizeConfig(config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(normalized)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeConfig encodes config as canonical JSON
+func normalizeConfig(config map[string]interface{}) ([]byte, error) {
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+
+	// Round-trip through JSON so BSON integer types and float64 values encode alike
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
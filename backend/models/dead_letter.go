@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeadLetter records a job failure a worker published to the DLQ topic, so the
+// DLQ can be paged through without consuming the topic
+type DeadLetter struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobID        string             `bson:"job_id" json:"jobId"`
+	JobType      JobType            `bson:"job_type" json:"jobType"`
+	Name         string             `bson:"name" json:"name"`
+	Tenant       string             `bson:"tenant,omitempty" json:"tenant,omitempty"`
+	ErrorMessage string             `bson:"error_message" json:"errorMessage"`
+	RetryCount   int                `bson:"retry_count" json:"retryCount"`
+	// WorkerID is the worker that ran the failed attempt
+	WorkerID string    `bson:"worker_id" json:"workerId"`
+	FailedAt time.Time `bson:"failed_at" json:"failedAt"`
+}
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/fullstack-assessment/backend/secrets"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -19,40 +22,253 @@ const (
 type JobStatus string
 
 const (
-	JobStatusPending    JobStatus = "pending"
-	JobStatusProcessing JobStatus = "processing"
-	JobStatusCompleted  JobStatus = "completed"
-	JobStatusFailed     JobStatus = "failed"
-	JobStatusCancelling JobStatus = "cancelling"
-	JobStatusCancelled  JobStatus = "cancelled"
+	JobStatusPending          JobStatus = "pending"
+	JobStatusHeld             JobStatus = "held"           // accepted during maintenance, not yet queued
+	JobStatusAwaitingInput    JobStatus = "awaiting_input" // created with hold_for_input, queued once its input is uploaded
+	JobStatusProcessing       JobStatus = "processing"
+	JobStatusAwaitingChildren JobStatus = "awaiting_children" // executed, completes once all its child jobs are terminal
+	JobStatusCompleted        JobStatus = "completed"
+	JobStatusFailed           JobStatus = "failed"
+	JobStatusCancelling       JobStatus = "cancelling"
+	JobStatusCancelled        JobStatus = "cancelled"
+)
+
+// JobPriority represents the scheduling priority of a job
+type JobPriority string
+
+const (
+	// JobPriorityHigh marks a job republished to its type's priority topic
+	JobPriorityHigh JobPriority = "high"
 )
 
 // Job represents a processing job
 type Job struct {
-	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
-	Name         string                 `bson:"name" json:"name"`
-	JobType      JobType                `bson:"job_type" json:"jobType"`
-	Status       JobStatus              `bson:"status" json:"status"`
-	Config       map[string]interface{} `bson:"config,omitempty" json:"config,omitempty"`
-	ErrorMessage string                 `bson:"error_message,omitempty" json:"errorMessage,omitempty"`
-	RetryCount   int                    `bson:"retry_count" json:"retryCount"`
-	CreatedAt    time.Time              `bson:"created_at" json:"createdAt"`
-	UpdatedAt    time.Time              `bson:"updated_at" json:"updatedAt"`
+	ID            JobID                  `bson:"_id,omitempty" json:"id"`
+	Name          string                 `bson:"name" json:"name"`
+	JobType       JobType                `bson:"job_type" json:"jobType"`
+	Status        JobStatus              `bson:"status" json:"status"`
+	Config        map[string]interface{} `bson:"config,omitempty" json:"config,omitempty"`
+	ConfigRef     *BlobRef               `bson:"config_ref,omitempty" json:"configRef,omitempty"`
+	ConfigHash    string                 `bson:"config_hash,omitempty" json:"configHash,omitempty"`
+	AffinityKey   string                 `bson:"affinity_key,omitempty" json:"affinityKey,omitempty"`
+	Priority      JobPriority            `bson:"priority,omitempty" json:"priority,omitempty"`
+	PrioritizedAt *time.Time             `bson:"prioritized_at,omitempty" json:"prioritizedAt,omitempty"`
+	Canary        bool                   `bson:"canary,omitempty" json:"canary,omitempty"`
+	DispatchID    string                 `bson:"dispatch_id,omitempty" json:"-"`
+	Notify        *NotifyConfig          `bson:"notify,omitempty" json:"notify,omitempty"`
+	ErrorMessage  string                 `bson:"error_message,omitempty" json:"errorMessage,omitempty"`
+	RetryCount    int                    `bson:"retry_count" json:"retryCount"`
+	RetryPolicy   *RetryPolicy           `bson:"retry_policy,omitempty" json:"retryPolicy,omitempty"`
+	RetryAt       *time.Time             `bson:"retry_at,omitempty" json:"retryAt,omitempty"`
+	// ScheduledAt is when a job created to run later is published; it is cleared once it is
+	ScheduledAt        *time.Time   `bson:"scheduled_at,omitempty" json:"scheduledAt,omitempty"`
+	CancelledBy        string       `bson:"cancelled_by,omitempty" json:"cancelledBy,omitempty"`
+	CancellationReason string       `bson:"cancellation_reason,omitempty" json:"cancellationReason,omitempty"`
+	CancelRequestedAt  *time.Time   `bson:"cancel_requested_at,omitempty" json:"cancelRequestedAt,omitempty"`
+	CancelAttempts     int          `bson:"cancel_attempts,omitempty" json:"cancelAttempts,omitempty"`
+	Attempts           []JobAttempt `bson:"attempts,omitempty" json:"attempts,omitempty"`
+	Artifact           *Artifact    `bson:"artifact,omitempty" json:"artifact,omitempty"`
+	// CancellationRevert records why a cancellation was withdrawn, the job
+	// moving back to processing because a worker was still running it
+	CancellationRevert *CancellationRevert `bson:"cancellation_revert,omitempty" json:"cancellationRevert,omitempty"`
+	// Result is the output of the executor, matching the result schema of the job type
+	Result     map[string]interface{} `bson:"result,omitempty" json:"result,omitempty"`
+	Input      *JobInput              `bson:"input,omitempty" json:"input,omitempty"`
+	Checkpoint *JobCheckpoint         `bson:"checkpoint,omitempty" json:"checkpoint,omitempty"`
+	Steps      []JobStep              `bson:"steps,omitempty" json:"steps,omitempty"`
+	ParentID   *JobID                 `bson:"parent_id,omitempty" json:"parentId,omitempty"`
+	Children   *JobChildren           `bson:"children,omitempty" json:"children,omitempty"`
+	GroupID    *primitive.ObjectID    `bson:"group_id,omitempty" json:"groupId,omitempty"`
+	// Tenant is stored even when empty, as it leads the shard key of the collection
+	Tenant      string     `bson:"tenant" json:"tenant,omitempty"`
+	Hold        *JobHold   `bson:"hold,omitempty" json:"hold,omitempty"`
+	SLOMs       int64      `bson:"slo_ms,omitempty" json:"sloMs,omitempty"`
+	SLOBreached bool       `bson:"slo_breached,omitempty" json:"sloBreached,omitempty"`
+	DeadlineAt  *time.Time `bson:"deadline_at,omitempty" json:"deadlineAt,omitempty"`
+	StartedAt   *time.Time `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+	DurationMs  int64      `bson:"duration_ms,omitempty" json:"durationMs,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time  `bson:"updated_at" json:"updatedAt"`
+	// Warning is set on the create response when the job was accepted into an overloaded queue
+	Warning *QueueWarning `bson:"-" json:"warning,omitempty"`
+	// QueuePosition and EstimatedStartAt are computed when a pending job is fetched
+	QueuePosition    *int64     `bson:"-" json:"queuePosition,omitempty"`
+	EstimatedStartAt *time.Time `bson:"-" json:"estimatedStartAt,omitempty"`
+	// Archived is set on jobs fetched from the archive after leaving the jobs collection
+	Archived bool `bson:"-" json:"archived,omitempty"`
+	// Version identifies the job's state for If-Match preconditions and changes
+	// with every update; it matches the job's ETag
+	Version string `bson:"-" json:"version,omitempty"`
+}
+
+// MarshalJSON renders the job with its encrypted config values redacted, so
+// secrets never leave the API regardless of the endpoint serving the job
+func (j Job) MarshalJSON() ([]byte, error) {
+	type job Job
+	redacted := job(j)
+	redacted.Config = secrets.RedactConfig(j.Config)
+	if !j.ID.IsZero() {
+		redacted.Version = ResourceVersion(j.ID.String(), j.UpdatedAt)
+	}
+	return json.Marshal(redacted)
+}
+
+// ResourceVersion identifies the state of a resource by its ID and last
+// modification time, as its ETag does. MongoDB stores times to the
+// millisecond, so the time is truncated and a resource just written has the
+// version it is read back with.
+func ResourceVersion(id string, updatedAt time.Time) string {
+	return fmt.Sprintf("%s-%d", id, updatedAt.Truncate(time.Millisecond).UnixNano())
+}
+
+// NotifyConfig configures notifications sent when a job reaches a terminal state
+type NotifyConfig struct {
+	Email           []string    `bson:"email,omitempty" json:"email,omitempty"`
+	SlackWebhookURL string      `bson:"slack_webhook_url,omitempty" json:"slackWebhookUrl,omitempty"`
+	On              []JobStatus `bson:"on,omitempty" json:"on,omitempty"`
+	Template        string      `bson:"template,omitempty" json:"template,omitempty"`
+}
+
+// BlobRef locates a job value stored in GridFS because it was too large to keep
+// on the job document. A job with a ConfigRef has no config in storage; the
+// service reads it back when the job is fetched.
+type BlobRef struct {
+	ID   primitive.ObjectID `bson:"id" json:"id"`
+	Size int64              `bson:"size" json:"size"`
+}
+
+// JobHold pins a job for debugging: while it is set, the job is not retried
+// automatically, redriven by a bulk requeue or republished by the pending
+// reconciler. Unlike the held status, it does not stop a queued job from running.
+type JobHold struct {
+	By     string    `bson:"by" json:"by"`
+	Reason string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	At     time.Time `bson:"at" json:"at"`
+}
+
+// CancellationRevert explains why a job's cancellation was withdrawn
+type CancellationRevert struct {
+	Reason string    `bson:"reason" json:"reason"`
+	At     time.Time `bson:"at" json:"at"`
+}
+
+// JobInput describes a file uploaded as the input of a job
+type JobInput struct {
+	Filename    string    `bson:"filename" json:"filename"`
+	Storage     string    `bson:"storage" json:"storage"`
+	Key         string    `bson:"key" json:"key"`
+	ContentType string    `bson:"content_type" json:"contentType"`
+	Size        int64     `bson:"size" json:"size"`
+	Checksum    string    `bson:"checksum" json:"checksum"`
+	UploadedAt  time.Time `bson:"uploaded_at" json:"uploadedAt"`
+}
+
+// Artifact describes an output file produced by a job and uploaded to storage
+type Artifact struct {
+	Storage     string    `bson:"storage" json:"storage"`
+	Key         string    `bson:"key" json:"key"`
+	URL         string    `bson:"url" json:"url"`
+	ContentType string    `bson:"content_type" json:"contentType"`
+	Size        int64     `bson:"size" json:"size"`
+	Checksum    string    `bson:"checksum" json:"checksum"`
+	CreatedAt   time.Time `bson:"created_at" json:"createdAt"`
+}
+
+// JobCheckpoint is the progress an executor saved while processing a job.
+// A retried job resumes from it instead of starting over.
+type JobCheckpoint struct {
+	State   map[string]interface{} `bson:"state" json:"state"`
+	Attempt int                    `bson:"attempt" json:"attempt"`
+	SavedAt time.Time              `bson:"saved_at" json:"savedAt"`
+}
+
+// JobStepStatus represents the state of a step of a job's workflow
+type JobStepStatus string
+
+const (
+	JobStepPending   JobStepStatus = "pending"
+	JobStepRunning   JobStepStatus = "running"
+	JobStepCompleted JobStepStatus = "completed"
+	JobStepFailed    JobStepStatus = "failed"
+)
+
+// JobStep records the progress of a step of the workflow defined by an analyze
+// job's config.steps. A retried job resumes at its first step not completed.
+type JobStep struct {
+	Name         string        `bson:"name" json:"name"`
+	Status       JobStepStatus `bson:"status" json:"status"`
+	Attempt      int           `bson:"attempt,omitempty" json:"attempt,omitempty"`
+	StartedAt    *time.Time    `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	FinishedAt   *time.Time    `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+	DurationMs   int64         `bson:"duration_ms,omitempty" json:"durationMs,omitempty"`
+	ErrorMessage string        `bson:"error_message,omitempty" json:"errorMessage,omitempty"`
+}
+
+// JobChildren summarises the child jobs an executor spawned from a job.
+// The counts per terminal status are filled in when the parent completes.
+type JobChildren struct {
+	Total     int `bson:"total" json:"total"`
+	Completed int `bson:"completed" json:"completed"`
+	Failed    int `bson:"failed" json:"failed"`
+	Cancelled int `bson:"cancelled" json:"cancelled"`
+}
+
+// AttemptOutcome represents the result of a single processing attempt
+type AttemptOutcome string
+
+const (
+	AttemptOutcomeRunning   AttemptOutcome = "running"
+	AttemptOutcomeCompleted AttemptOutcome = "completed"
+	AttemptOutcomeFailed    AttemptOutcome = "failed"
+	AttemptOutcomeCancelled AttemptOutcome = "cancelled"
+)
+
+// JobAttempt records a single processing attempt of a job by a worker
+type JobAttempt struct {
+	Number       int            `bson:"number" json:"number"`
+	WorkerID     string         `bson:"worker_id" json:"workerId"`
+	StartedAt    time.Time      `bson:"started_at" json:"startedAt"`
+	FinishedAt   *time.Time     `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+	Outcome      AttemptOutcome `bson:"outcome" json:"outcome"`
+	ErrorMessage string         `bson:"error_message,omitempty" json:"errorMessage,omitempty"`
+	// ExecutorVersion is the executor version of the worker that ran the attempt
+	ExecutorVersion string `bson:"executor_version,omitempty" json:"executorVersion,omitempty"`
+	// WorkerVersion and WorkerCommit identify the build of the worker that ran the attempt
+	WorkerVersion string `bson:"worker_version,omitempty" json:"workerVersion,omitempty"`
+	WorkerCommit  string `bson:"worker_commit,omitempty" json:"workerCommit,omitempty"`
+	// Environment describes where the attempt ran
+	Environment *AttemptEnvironment `bson:"environment,omitempty" json:"environment,omitempty"`
+}
+
+// AttemptEnvironment is the execution environment of the worker that ran an
+// attempt, to tell apart failures that only happen on some workers
+type AttemptEnvironment struct {
+	Host        string `bson:"host" json:"host"`
+	ContainerID string `bson:"container_id,omitempty" json:"containerId,omitempty"`
+	Region      string `bson:"region,omitempty" json:"region,omitempty"`
+	GoOS        string `bson:"go_os" json:"goOs"`
+	GoArch      string `bson:"go_arch" json:"goArch"`
+	GoMaxProcs  int    `bson:"gomaxprocs" json:"gomaxprocs"`
+	// CPULimit and MemoryLimitBytes are the container's resource limits, unset
+	// when unlimited
+	CPULimit         float64 `bson:"cpu_limit,omitempty" json:"cpuLimit,omitempty"`
+	MemoryLimitBytes int64   `bson:"memory_limit_bytes,omitempty" json:"memoryLimitBytes,omitempty"`
 }
 
 // ValidJobTypes returns the list of valid job types
 func ValidJobTypes() []JobType {
-	return []JobType{JobTypeProcess, JobTypeAnalyze, JobTypeExport}
+	types := make([]JobType, 0, len(jobTypeRegistry))
+	for _, spec := range jobTypeRegistry {
+		types = append(types, spec.Type)
+	}
+	return types
 }
 
 // IsValidJobType checks if a job type is valid
 func IsValidJobType(jobType string) bool {
-	for _, valid := range ValidJobTypes() {
-		if string(valid) == jobType {
-			return true
-		}
-	}
-	return false
+	_, ok := LookupJobType(jobType)
+	return ok
 }
 
 // IsTerminalStatus checks if a job status is terminal (cannot be changed)
@@ -60,12 +276,39 @@ func (s JobStatus) IsTerminal() bool {
 	return s == JobStatusCompleted || s == JobStatusFailed || s == JobStatusCancelled
 }
 
+// ActiveJobStatuses returns the statuses of jobs that are not terminal yet
+func ActiveJobStatuses() []JobStatus {
+	return []JobStatus{
+		JobStatusPending, JobStatusHeld, JobStatusAwaitingInput, JobStatusProcessing,
+		JobStatusAwaitingChildren, JobStatusCancelling,
+	}
+}
+
 // CanBeCancelled checks if a job can be cancelled
 func (j *Job) CanBeCancelled() bool {
 	return j.Status == JobStatusPending || j.Status == JobStatusProcessing
 }
 
+// CanBePrioritized checks if a job can be moved ahead of the backlog
+func (j *Job) CanBePrioritized() bool {
+	return j.Status == JobStatusPending
+}
+
+// CanBeHeld checks if a job can be held for debugging; completed jobs have nothing left to hold back
+func (j *Job) CanBeHeld() bool {
+	return j.Status != JobStatusCompleted
+}
+
+// Retry returns the retry policy of the job. Jobs created before retry
+// policies existed follow the default policy.
+func (j *Job) Retry() RetryPolicy {
+	if j.RetryPolicy != nil {
+		return *j.RetryPolicy
+	}
+	return DefaultRetryPolicy()
+}
+
 // CanBeRetried checks if a job can be retried
 func (j *Job) CanBeRetried() bool {
-	return j.Status == JobStatusFailed && j.RetryCount < 3
+	return j.Status == JobStatusFailed && j.RetryCount < j.Retry().MaxRetries
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobComment is an operator note attached to a job
+type JobComment struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	JobID     JobID              `bson:"job_id" json:"jobId"`
+	Author    string             `bson:"author" json:"author"`
+	Text      string             `bson:"text" json:"text"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GroupStatus is the aggregate status of the jobs in a group
+type GroupStatus string
+
+const (
+	GroupStatusEmpty      GroupStatus = "empty"      // no jobs attached yet
+	GroupStatusActive     GroupStatus = "active"     // some jobs are not terminal yet
+	GroupStatusCompleted  GroupStatus = "completed"  // every job completed
+	GroupStatusFailed     GroupStatus = "failed"     // every job is terminal and some did not complete
+	GroupStatusCancelling GroupStatus = "cancelling" // cancelled, some jobs are not terminal yet
+	GroupStatusCancelled  GroupStatus = "cancelled"  // cancelled and every job is terminal
+)
+
+// JobGroup tracks a batch of jobs as one unit, e.g. the jobs of one import
+type JobGroup struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Tenant      string             `bson:"tenant" json:"-"`
+	Name        string             `bson:"name" json:"name"`
+	CreatedBy   string             `bson:"created_by" json:"createdBy"`
+	CancelledBy string             `bson:"cancelled_by,omitempty" json:"cancelledBy,omitempty"`
+	CancelledAt *time.Time         `bson:"cancelled_at,omitempty" json:"cancelledAt,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updatedAt"`
+	// Status, Total, Counts and Progress are computed from the group's jobs when it is fetched
+	Status   GroupStatus         `bson:"-" json:"status"`
+	Total    int64               `bson:"-" json:"total"`
+	Counts   map[JobStatus]int64 `bson:"-" json:"counts"`
+	Progress float64             `bson:"-" json:"progress"`
+}
+
+// Summarize computes the group's aggregate status and progress from its job counts by status
+func (g *JobGroup) Summarize(counts map[JobStatus]int64) {
+	var total, terminal, unsuccessful int64
+	for status, count := range counts {
+		total += count
+		switch status {
+		case JobStatusCompleted:
+			terminal += count
+		case JobStatusFailed, JobStatusCancelled:
+			terminal += count
+			unsuccessful += count
+		}
+	}
+
+	g.Counts = counts
+	g.Total = total
+	g.Progress = 0
+	if total > 0 {
+		g.Progress = float64(terminal) / float64(total)
+	}
+
+	switch {
+	case total == 0:
+		g.Status = GroupStatusEmpty
+	case g.CancelledAt != nil && terminal < total:
+		g.Status = GroupStatusCancelling
+	case g.CancelledAt != nil:
+		g.Status = GroupStatusCancelled
+	case terminal < total:
+		g.Status = GroupStatusActive
+	case unsuccessful > 0:
+		g.Status = GroupStatusFailed
+	default:
+		g.Status = GroupStatusCompleted
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"github.com/fullstack-assessment/jobid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobIDStrategy selects how the IDs of new jobs are generated
+type JobIDStrategy = jobid.Strategy
+
+const (
+	// JobIDObjectID generates MongoDB ObjectIDs, stored as such
+	JobIDObjectID = jobid.ObjectIDs
+	// JobIDUUIDv7 generates time-ordered UUIDv7 strings, which clients can also
+	// generate themselves and which carry over to stores without ObjectIDs
+	JobIDUUIDv7 = jobid.UUIDv7s
+)
+
+// JobID identifies a job: the hex form of an ObjectID or a UUIDv7 string. The
+// workers share its implementation, so both read and store IDs the same way.
+type JobID = jobid.ID
+
+// NewJobID generates the ID of a new job with the given strategy
+func NewJobID(strategy JobIDStrategy) (JobID, error) {
+	return jobid.New(strategy)
+}
+
+// JobIDFromObjectID returns the ID of a job identified by an ObjectID
+func JobIDFromObjectID(id primitive.ObjectID) JobID {
+	return jobid.FromObjectID(id)
+}
+
+// ParseJobID reads a job ID from its string form, accepting ObjectIDs and
+// UUIDv7s in any letter case
+func ParseJobID(value string) (JobID, error) {
+	return jobid.Parse(value)
+}
@@ -0,0 +1,21 @@
+package models
+
+import "testing"
+
+func TestNewJobIDParses(t *testing.T) {
+	for _, strategy := range []JobIDStrategy{JobIDObjectID, JobIDUUIDv7} {
+		t.Run(string(strategy), func(t *testing.T) {
+			id, err := NewJobID(strategy)
+			if err != nil {
+				t.Fatalf("NewJobID() error = %v", err)
+			}
+			parsed, err := ParseJobID(id.String())
+			if err != nil {
+				t.Fatalf("ParseJobID(%q) error = %v", id, err)
+			}
+			if parsed != id {
+				t.Errorf("ParseJobID(%q) = %q", id, parsed)
+			}
+		})
+	}
+}
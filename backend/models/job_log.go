@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// JobLogLine is one line of output captured by a worker during a job attempt
+type JobLogLine struct {
+	JobID     JobID     `bson:"job_id" json:"jobId"`
+	Attempt   int       `bson:"attempt" json:"attempt"`
+	Seq       int       `bson:"seq" json:"seq"`
+	Message   string    `bson:"message" json:"message"`
+	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobSummary holds the fields of a job that dashboard lists show. Summaries are
+// kept in their own collection, denormalized from the jobs, so lists read small
+// documents instead of scanning full jobs. They trail the jobs by a few seconds.
+type JobSummary struct {
+	ID           JobID               `bson:"_id" json:"id"`
+	Name         string              `bson:"name" json:"name"`
+	JobType      JobType             `bson:"job_type" json:"jobType"`
+	Status       JobStatus           `bson:"status" json:"status"`
+	Priority     JobPriority         `bson:"priority,omitempty" json:"priority,omitempty"`
+	Tenant       string              `bson:"tenant,omitempty" json:"tenant,omitempty"`
+	ErrorMessage string              `bson:"error_message,omitempty" json:"errorMessage,omitempty"`
+	RetryCount   int                 `bson:"retry_count" json:"retryCount"`
+	ParentID     *JobID              `bson:"parent_id,omitempty" json:"parentId,omitempty"`
+	GroupID      *primitive.ObjectID `bson:"group_id,omitempty" json:"groupId,omitempty"`
+	Held         bool                `bson:"held,omitempty" json:"held,omitempty"`
+	SLOBreached  bool                `bson:"slo_breached,omitempty" json:"sloBreached,omitempty"`
+	StartedAt    *time.Time          `bson:"started_at,omitempty" json:"startedAt,omitempty"`
+	FinishedAt   *time.Time          `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+	DurationMs   int64               `bson:"duration_ms,omitempty" json:"durationMs,omitempty"`
+	DeadlineAt   *time.Time          `bson:"deadline_at,omitempty" json:"deadlineAt,omitempty"`
+	CreatedAt    time.Time           `bson:"created_at" json:"createdAt"`
+	UpdatedAt    time.Time           `bson:"updated_at" json:"updatedAt"`
+}
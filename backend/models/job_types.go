@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// JobTypeSpec describes a registered job type
+type JobTypeSpec struct {
+	Type        JobType
+	Description string
+	// SLO is the default maximum processing duration before a job is considered in breach
+	SLO time.Duration
+	// Retry is the default retry policy of jobs of the type
+	Retry RetryPolicy
+}
+
+// jobTypeRegistry lists the job types accepted by the API
+var jobTypeRegistry = []JobTypeSpec{
+	{Type: JobTypeProcess, Description: "General processing job", SLO: 10 * time.Second, Retry: DefaultRetryPolicy()},
+	{Type: JobTypeAnalyze, Description: "Data analysis job", SLO: 10 * time.Second, Retry: DefaultRetryPolicy()},
+	{Type: JobTypeExport, Description: "Data export job", SLO: 15 * time.Second, Retry: DefaultRetryPolicy()},
+}
+
+// JobTypeSpecs returns the registered job types
+func JobTypeSpecs() []JobTypeSpec {
+	specs := make([]JobTypeSpec, len(jobTypeRegistry))
+	copy(specs, jobTypeRegistry)
+	return specs
+}
+
+// JobTypeNames returns the names of the registered job types, in registration order
+func JobTypeNames() []string {
+	names := make([]string, len(jobTypeRegistry))
+	for i, spec := range jobTypeRegistry {
+		names[i] = string(spec.Type)
+	}
+	return names
+}
+
+// LookupJobType returns the registered spec for a job type
+func LookupJobType(jobType string) (JobTypeSpec, bool) {
+	for _, spec := range jobTypeRegistry {
+		if string(spec.Type) == jobType {
+			return spec, true
+		}
+	}
+	return JobTypeSpec{}, false
+}
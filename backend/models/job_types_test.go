@@ -0,0 +1,19 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJobTypeNames(t *testing.T) {
+	want := []string{string(JobTypeProcess), string(JobTypeAnalyze), string(JobTypeExport)}
+	names := JobTypeNames()
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("JobTypeNames() = %v, want %v", names, want)
+	}
+	for _, name := range names {
+		if !IsValidJobType(name) {
+			t.Errorf("registered job type %q is not valid", name)
+		}
+	}
+}
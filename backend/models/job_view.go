@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobView is a named filter over the job list, shared by the members of a tenant
+type JobView struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Tenant    string             `bson:"tenant" json:"-"`
+	Name      string             `bson:"name" json:"name"`
+	Filter    ViewFilter         `bson:"filter" json:"filter"`
+	CreatedBy string             `bson:"created_by" json:"createdBy"`
+	CreatedAt time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// ViewFilter is the filter and sort a view applies to the job list
+type ViewFilter struct {
+	Status  []JobStatus `bson:"status,omitempty" json:"status,omitempty"`
+	JobType []JobType   `bson:"job_type,omitempty" json:"jobType,omitempty"`
+	// CreatedWithin is a duration such as "24h", relative to when the view is used
+	CreatedWithin string `bson:"created_within,omitempty" json:"createdWithin,omitempty"`
+	// Sort is a sortable field, prefixed with "-" for descending order
+	Sort string `bson:"sort,omitempty" json:"sort,omitempty"`
+}
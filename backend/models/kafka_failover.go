@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// KafkaRegion names the Kafka cluster the worker fleet consumes jobs from
+type KafkaRegion string
+
+const (
+	// KafkaRegionPrimary is the cluster of the active region
+	KafkaRegionPrimary KafkaRegion = "primary"
+	// KafkaRegionDR is the disaster recovery cluster, holding the topics of the
+	// primary region as mirrored by MirrorMaker
+	KafkaRegionDR KafkaRegion = "dr"
+)
+
+// KafkaFailover is the region the worker fleet is switched to. Workers poll it
+// and move their consumers without being redeployed.
+type KafkaFailover struct {
+	Region    KafkaRegion `bson:"region" json:"region"`
+	Reason    string      `bson:"reason,omitempty" json:"reason,omitempty"`
+	ChangedBy string      `bson:"changed_by,omitempty" json:"changedBy,omitempty"`
+	ChangedAt *time.Time  `bson:"changed_at,omitempty" json:"changedAt,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Lease is held by the backend instance that runs a singleton background
+// component. The holder renews it before it expires; once it has expired
+// another instance may take it over.
+type Lease struct {
+	Name       string    `bson:"_id" json:"name"`
+	Holder     string    `bson:"holder" json:"holder"`
+	AcquiredAt time.Time `bson:"acquired_at" json:"acquiredAt"`
+	RenewedAt  time.Time `bson:"renewed_at" json:"renewedAt"`
+	ExpiresAt  time.Time `bson:"expires_at" json:"expiresAt"`
+}
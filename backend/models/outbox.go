@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OutboxMessage is a Kafka message persisted for later delivery because it
+// could not be published when it was produced
+type OutboxMessage struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Topic       string             `bson:"topic" json:"topic"`
+	Key         string             `bson:"key,omitempty" json:"key,omitempty"`
+	Payload     []byte             `bson:"payload" json:"payload"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	LastError   string             `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"createdAt"`
+	PublishedAt *time.Time         `bson:"published_at,omitempty" json:"publishedAt,omitempty"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PoisonMessage records a Kafka message a consumer could not decode. The message
+// is skipped so the consumer is not stuck on it; this record keeps it for inspection.
+type PoisonMessage struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Topic     string             `bson:"topic" json:"topic"`
+	Partition int                `bson:"partition" json:"partition"`
+	Offset    int64              `bson:"offset" json:"offset"`
+	// Consumer is the consumer group that failed to decode the message
+	Consumer string `bson:"consumer" json:"consumer"`
+	// Source identifies the process that recorded it, a worker ID or "backend"
+	Source  string `bson:"source" json:"source"`
+	Key     string `bson:"key,omitempty" json:"key,omitempty"`
+	Payload string `bson:"payload" json:"payload"`
+	// Truncated is set when the payload was cut to keep the record small
+	Truncated bool   `bson:"truncated,omitempty" json:"truncated,omitempty"`
+	Error     string `bson:"error" json:"error"`
+	// Count is how many times the message was delivered and failed to decode
+	Count       int       `bson:"count" json:"count"`
+	FirstSeenAt time.Time `bson:"first_seen_at" json:"firstSeenAt"`
+	LastSeenAt  time.Time `bson:"last_seen_at" json:"lastSeenAt"`
+}
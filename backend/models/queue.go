@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// QueueDepth describes the backlog of one job type
+type QueueDepth struct {
+	JobType JobType `json:"jobType"`
+	// Pending is the number of jobs waiting to be picked up
+	Pending int64 `json:"pending"`
+	// Lag is the number of job messages workers have not consumed yet
+	Lag int64 `json:"lag"`
+	// ThroughputPerMinute is the recent rate at which jobs of the type finish
+	ThroughputPerMinute float64 `json:"throughputPerMinute"`
+	// EstimatedWaitSeconds is how long a new job is expected to wait before it starts
+	EstimatedWaitSeconds int64     `json:"estimatedWaitSeconds"`
+	CheckedAt            time.Time `json:"checkedAt"`
+}
+
+// QueueWarning is attached to a job accepted while its queue is over capacity
+type QueueWarning struct {
+	Message string     `json:"message"`
+	Queue   QueueDepth `json:"queue"`
+}
+
+// JobTypeStats summarises the processing durations of the jobs of one type that
+// finished within a recent window. Workers refresh it periodically; percentiles
+// are omitted when no job finished in the window.
+type JobTypeStats struct {
+	JobType       JobType   `bson:"_id" json:"jobType"`
+	Finished      int64     `bson:"finished" json:"finished"`
+	WindowMs      int64     `bson:"window_ms" json:"windowMs"`
+	AvgDurationMs float64   `bson:"avg_duration_ms,omitempty" json:"avgDurationMs,omitempty"`
+	MaxDurationMs int64     `bson:"max_duration_ms,omitempty" json:"maxDurationMs,omitempty"`
+	P50DurationMs *float64  `bson:"p50_duration_ms,omitempty" json:"p50DurationMs,omitempty"`
+	P90DurationMs *float64  `bson:"p90_duration_ms,omitempty" json:"p90DurationMs,omitempty"`
+	P95DurationMs *float64  `bson:"p95_duration_ms,omitempty" json:"p95DurationMs,omitempty"`
+	P99DurationMs *float64  `bson:"p99_duration_ms,omitempty" json:"p99DurationMs,omitempty"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updatedAt"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// QuotaPeriod is the period a job creation quota is counted over
+type QuotaPeriod string
+
+const (
+	QuotaPeriodDay   QuotaPeriod = "day"
+	QuotaPeriodMonth QuotaPeriod = "month"
+)
+
+// QuotaWindow identifies one period of a quota, e.g. the day 2024-05-01 in UTC
+type QuotaWindow struct {
+	Period QuotaPeriod
+	// Key names the period, e.g. "2024-05-01" for a day or "2024-05" for a month
+	Key      string
+	ResetsAt time.Time
+}
+
+// QuotaWindowsAt returns the day and month windows containing t, in UTC
+func QuotaWindowsAt(t time.Time) []QuotaWindow {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	month := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return []QuotaWindow{
+		{Period: QuotaPeriodDay, Key: day.Format("2006-01-02"), ResetsAt: day.AddDate(0, 0, 1)},
+		{Period: QuotaPeriodMonth, Key: month.Format("2006-01"), ResetsAt: month.AddDate(0, 1, 0)},
+	}
+}
+
+// QuotaUsage reports the job creation allowance of a tenant in one period.
+// Limit and Remaining are omitted when the period has no limit.
+type QuotaUsage struct {
+	Period    QuotaPeriod `json:"period"`
+	Used      int64       `json:"used"`
+	Limit     *int64      `json:"limit,omitempty"`
+	Remaining *int64      `json:"remaining,omitempty"`
+	ResetsAt  time.Time   `json:"resetsAt"`
+}
+
+// QuotaStatus reports the job creation allowance of a tenant
+type QuotaStatus struct {
+	Tenant  string       `json:"tenant"`
+	Periods []QuotaUsage `json:"periods"`
+}
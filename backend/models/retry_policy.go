@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// BackoffCurve selects how the delay before an automatic retry grows with each retry
+type BackoffCurve string
+
+const (
+	// BackoffFixed waits the initial delay before every retry
+	BackoffFixed BackoffCurve = "fixed"
+	// BackoffLinear waits the initial delay times the retry number
+	BackoffLinear BackoffCurve = "linear"
+	// BackoffExponential doubles the delay with each retry
+	BackoffExponential BackoffCurve = "exponential"
+)
+
+// DLQMode selects which failures of a job are published to the dead letter queue
+type DLQMode string
+
+const (
+	// DLQOnExhausted publishes a failure once no automatic retry follows it
+	DLQOnExhausted DLQMode = "exhausted"
+	// DLQAlways publishes every failed attempt, including those retried automatically
+	DLQAlways DLQMode = "always"
+	// DLQNever publishes no failures of the job
+	DLQNever DLQMode = "never"
+)
+
+// RetryPolicy controls how often a failed job may be retried, how long the worker
+// waits before retrying it automatically and when its failures reach the DLQ.
+// It is resolved when the job is created and stored with it, so the API and the
+// worker apply the same policy.
+type RetryPolicy struct {
+	// MaxRetries bounds the retries of a job, automatic and requested through the API
+	MaxRetries int `bson:"max_retries" json:"maxRetries"`
+	// AutoRetry makes the worker retry failed attempts itself after the backoff delay
+	AutoRetry      bool         `bson:"auto_retry" json:"autoRetry"`
+	Backoff        BackoffCurve `bson:"backoff" json:"backoff"`
+	InitialDelayMs int64        `bson:"initial_delay_ms" json:"initialDelayMs"`
+	MaxDelayMs     int64        `bson:"max_delay_ms" json:"maxDelayMs"`
+	DLQ            DLQMode      `bson:"dlq" json:"dlq"`
+}
+
+// MaxRetriesLimit bounds the retries a policy may allow
+const MaxRetriesLimit = 20
+
+// DefaultRetryPolicy returns the policy of jobs created before retry policies existed:
+// up to three retries requested through the API, with every failure sent to the DLQ
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     3,
+		Backoff:        BackoffExponential,
+		InitialDelayMs: (5 * time.Second).Milliseconds(),
+		MaxDelayMs:     (5 * time.Minute).Milliseconds(),
+		DLQ:            DLQOnExhausted,
+	}
+}
+
+// Delay returns how long to wait before the given retry, counted from 1
+func (p RetryPolicy) Delay(retry int) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	delay := float64(p.InitialDelayMs)
+	switch p.Backoff {
+	case BackoffLinear:
+		delay *= float64(retry)
+	case BackoffExponential:
+		delay *= math.Pow(2, float64(retry-1))
+	}
+	if p.MaxDelayMs > 0 && delay > float64(p.MaxDelayMs) {
+		delay = float64(p.MaxDelayMs)
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// Validate reports the first invalid setting of the policy
+func (p RetryPolicy) Validate() error {
+	if p.MaxRetries < 0 || p.MaxRetries > MaxRetriesLimit {
+		return fmt.Errorf("max_retries must be between 0 and %d", MaxRetriesLimit)
+	}
+	switch p.Backoff {
+	case BackoffFixed, BackoffLinear, BackoffExponential:
+	default:
+		return fmt.Errorf("backoff must be one of fixed, linear, exponential, got %q", p.Backoff)
+	}
+	if p.InitialDelayMs < 0 || p.MaxDelayMs < 0 {
+		return errors.New("backoff delays must not be negative")
+	}
+	if p.MaxDelayMs > 0 && p.MaxDelayMs < p.InitialDelayMs {
+		return errors.New("max_delay must not be shorter than initial_delay")
+	}
+	switch p.DLQ {
+	case DLQOnExhausted, DLQAlways, DLQNever:
+	default:
+		return fmt.Errorf("dlq must be one of exhausted, always, never, got %q", p.DLQ)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// TenantNotifications holds the notifications sent for every job of a tenant,
+// on top of those configured on each job
+type TenantNotifications struct {
+	Tenant       string `bson:"_id" json:"tenant"`
+	NotifyConfig `bson:",inline"`
+	UpdatedBy    string    `bson:"updated_by" json:"updatedBy"`
+	UpdatedAt    time.Time `bson:"updated_at" json:"updatedAt"`
+}
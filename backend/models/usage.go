@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// UsageDayFormat is the layout of the UTC day usage is aggregated by
+const UsageDayFormat = "2006-01-02"
+
+// TenantUsage is the usage of one tenant on one UTC day, aggregated from the
+// jobs that finished that day for charge-back
+type TenantUsage struct {
+	Tenant string `bson:"tenant" json:"tenant"`
+	Day    string `bson:"day" json:"day"`
+	// JobsRun counts the jobs that finished processing, successfully or not
+	JobsRun int64 `bson:"jobs_run" json:"jobsRun"`
+	// ComputeSeconds is the processing time of those jobs
+	ComputeSeconds float64 `bson:"compute_seconds" json:"computeSeconds"`
+	// BytesExported is the size of the artifacts those jobs produced
+	BytesExported int64     `bson:"bytes_exported" json:"bytesExported"`
+	UpdatedAt     time.Time `bson:"updated_at" json:"updatedAt"`
+}
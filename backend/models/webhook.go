@@ -0,0 +1,37 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NewWebhookSecret returns a random secret for signing webhook payloads
+func NewWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// WebhookDelivery records one attempt to deliver a payload to an alert rule's webhook
+type WebhookDelivery struct {
+	ID     primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	RuleID primitive.ObjectID `bson:"rule_id" json:"ruleId"`
+	// Tenant is copied from the rule, so deliveries are scoped like its jobs
+	Tenant string `bson:"tenant,omitempty" json:"tenant,omitempty"`
+	URL    string `bson:"url" json:"url"`
+	// Payload is the JSON body that was sent, kept so it can be redelivered as is
+	Payload string `bson:"payload" json:"payload"`
+	// StatusCode is the endpoint's response code, zero if no response arrived
+	StatusCode int    `bson:"status_code,omitempty" json:"statusCode,omitempty"`
+	LatencyMs  int64  `bson:"latency_ms" json:"latencyMs"`
+	Error      string `bson:"error,omitempty" json:"error,omitempty"`
+	Succeeded  bool   `bson:"succeeded" json:"succeeded"`
+	// RedeliveryOf is the delivery this one manually repeated
+	RedeliveryOf *primitive.ObjectID `bson:"redelivery_of,omitempty" json:"redeliveryOf,omitempty"`
+	DeliveredAt  time.Time           `bson:"delivered_at" json:"deliveredAt"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// WorkerInfo describes a registered worker as reported by its heartbeats
+type WorkerInfo struct {
+	ID            string    `bson:"_id" json:"id"`
+	Hostname      string    `bson:"hostname" json:"hostname"`
+	Version       string    `bson:"version" json:"version"`
+	Commit        string    `bson:"commit,omitempty" json:"commit,omitempty"`
+	BuildDate     string    `bson:"build_date,omitempty" json:"buildDate,omitempty"`
+	Capabilities  []string  `bson:"capabilities" json:"capabilities"`
+	StartedAt     time.Time `bson:"started_at" json:"startedAt"`
+	LastHeartbeat time.Time `bson:"last_heartbeat" json:"lastHeartbeat"`
+	InFlightJobs  []string  `bson:"in_flight_jobs" json:"inFlightJobs"`
+	Draining      bool      `bson:"draining" json:"draining"`
+	// ExecutorVersion is the version of the code that runs jobs; canary workers run the new version
+	ExecutorVersion string `bson:"executor_version,omitempty" json:"executorVersion,omitempty"`
+	Canary          bool   `bson:"canary,omitempty" json:"canary,omitempty"`
+	// KafkaRegion is the region of the worker's Kafka cluster, set by workers
+	// following the failover switch, and KafkaActive whether that region is
+	// active, so the worker consumes jobs
+	KafkaRegion KafkaRegion `bson:"kafka_region,omitempty" json:"kafkaRegion,omitempty"`
+	KafkaActive bool        `bson:"kafka_active,omitempty" json:"kafkaActive,omitempty"`
+}
+
+// DrainStatus reports the work left before workers can be stopped without
+// interrupting jobs. Estimates are -1 when there is no recent throughput or
+// duration to estimate from.
+type DrainStatus struct {
+	Workers []WorkerDrain `json:"workers"`
+	Queues  []QueueDepth  `json:"queues"`
+	// InFlight is the number of jobs the workers are running
+	InFlight int `json:"inFlight"`
+	// Lag is the number of job messages not consumed yet over all job types
+	Lag int64 `json:"lag"`
+	// InFlightEtaSeconds is how long until the running jobs are expected to finish
+	InFlightEtaSeconds int64 `json:"inFlightEtaSeconds"`
+	// EtaSeconds is how long until the queues are expected to be empty and the running jobs finished
+	EtaSeconds int64     `json:"etaSeconds"`
+	CheckedAt  time.Time `json:"checkedAt"`
+}
+
+// WorkerDrain reports the jobs a worker is running
+type WorkerDrain struct {
+	ID            string    `json:"id"`
+	Hostname      string    `json:"hostname"`
+	Draining      bool      `json:"draining"`
+	InFlight      int       `json:"inFlight"`
+	InFlightJobs  []string  `json:"inFlightJobs"`
+	LastHeartbeat time.Time `json:"lastHeartbeat"`
+	// EtaSeconds is how long until the worker's running jobs are expected to finish
+	EtaSeconds int64 `json:"etaSeconds"`
+}
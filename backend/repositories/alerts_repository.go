@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AlertsRepository interface defines the methods for alert rule data access
+type AlertsRepository interface {
+	Create(ctx context.Context, rule *models.AlertRule) error
+	GetByID(ctx context.Context, id string) (*models.AlertRule, error)
+	List(ctx context.Context) ([]models.AlertRule, error)
+	ListEnabled(ctx context.Context) ([]models.AlertRule, error)
+	Update(ctx context.Context, rule *models.AlertRule) error
+	Delete(ctx context.Context, id string) (bool, error)
+	RecordEvaluation(ctx context.Context, id primitive.ObjectID, firing bool, value float64, firedAt *time.Time) error
+}
+
+type alertsRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewAlertsRepository creates a new alert rules repository
+func NewAlertsRepository(db *mongo.Database, config Config) AlertsRepository {
+	return &alertsRepository{
+		collection: db.Collection("alert_rules"),
+		tracker: queryTracker{
+			collection: "alert_rules",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// Create creates a new alert rule
+func (r *alertsRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	ctx, done := r.tracker.start(ctx, "create")
+	defer done()
+
+	rule.ID = primitive.NewObjectID()
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, rule)
+	return translateError(err)
+}
+
+// GetByID retrieves an alert rule by its ID, or returns ErrNotFound if there is
+// none in the tenant scope of ctx
+func (r *alertsRepository) GetByID(ctx context.Context, id string) (*models.AlertRule, error) {
+	ctx, done := r.tracker.start(ctx, "getByID")
+	defer done()
+
+	objectID, err := parseObjectID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var rule models.AlertRule
+	err = r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": objectID})).Decode(&rule)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &rule, nil
+}
+
+// List retrieves all alert rules, oldest first
+func (r *alertsRepository) List(ctx context.Context) ([]models.AlertRule, error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	return r.find(ctx, bson.M{})
+}
+
+// ListEnabled retrieves the alert rules that should be evaluated
+func (r *alertsRepository) ListEnabled(ctx context.Context) ([]models.AlertRule, error) {
+	ctx, done := r.tracker.start(ctx, "listEnabled")
+	defer done()
+
+	return r.find(ctx, bson.M{"enabled": true})
+}
+
+func (r *alertsRepository) find(ctx context.Context, filter bson.M) ([]models.AlertRule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []models.AlertRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Update updates an alert rule
+func (r *alertsRepository) Update(ctx context.Context, rule *models.AlertRule) error {
+	ctx, done := r.tracker.start(ctx, "update")
+	defer done()
+
+	rule.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule)
+	return translateUpdateError(result, err)
+}
+
+// Delete removes an alert rule. It returns false if no rule matched.
+func (r *alertsRepository) Delete(ctx context.Context, id string) (bool, error) {
+	ctx, done := r.tracker.start(ctx, "delete")
+	defer done()
+
+	objectID, err := parseObjectID(id)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+// RecordEvaluation stores the outcome of the latest evaluation of a rule
+func (r *alertsRepository) RecordEvaluation(ctx context.Context, id primitive.ObjectID, firing bool, value float64, firedAt *time.Time) error {
+	ctx, done := r.tracker.start(ctx, "recordEvaluation")
+	defer done()
+
+	set := bson.M{
+		"firing":            firing,
+		"last_value":        value,
+		"last_evaluated_at": time.Now(),
+	}
+	if firedAt != nil {
+		set["last_fired_at"] = *firedAt
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APIKeysRepository interface defines the methods for API key data access
+type APIKeysRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	// GetByPrefix retrieves a key by its prefix, or returns ErrNotFound if there is none
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	List(ctx context.Context, tenant string) ([]models.APIKey, error)
+	// Revoke revokes a key of a tenant. It returns ErrNotFound if no unrevoked key matched.
+	Revoke(ctx context.Context, tenant string, id primitive.ObjectID) (*models.APIKey, error)
+	MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type apiKeysRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewAPIKeysRepository creates a new API keys repository
+func NewAPIKeysRepository(db *mongo.Database, config Config) APIKeysRepository {
+	return &apiKeysRepository{
+		collection: db.Collection("api_keys"),
+		tracker: queryTracker{
+			collection: "api_keys",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+func (r *apiKeysRepository) Create(ctx context.Context, key *models.APIKey) error {
+	ctx, done := r.tracker.start(ctx, "create")
+	defer done()
+
+	key.ID = primitive.NewObjectID()
+	key.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, key)
+	return translateError(err)
+}
+
+func (r *apiKeysRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	ctx, done := r.tracker.start(ctx, "getByPrefix")
+	defer done()
+
+	var key models.APIKey
+	err := r.collection.FindOne(ctx, bson.M{"prefix": prefix}).Decode(&key)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &key, nil
+}
+
+// List retrieves the keys of a tenant, newest first
+func (r *apiKeysRepository) List(ctx context.Context, tenant string) ([]models.APIKey, error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"tenant": tenant}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeysRepository) Revoke(ctx context.Context, tenant string, id primitive.ObjectID) (*models.APIKey, error) {
+	ctx, done := r.tracker.start(ctx, "revoke")
+	defer done()
+
+	var key models.APIKey
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "tenant": tenant, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&key)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &key, nil
+}
+
+func (r *apiKeysRepository) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	ctx, done := r.tracker.start(ctx, "markUsed")
+	defer done()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}})
+	return err
+}
+
+// EnsureIndexes creates the indexes keys are looked up and listed by
+func (r *apiKeysRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "prefix", Value: 1}},
+			Options: options.Index().SetName("api_keys_prefix").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("api_keys_tenant_created"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeadLettersRepository interface defines the methods for reading the DLQ entries workers record
+type DeadLettersRepository interface {
+	List(ctx context.Context, jobType, cursor string, limit int) (*Page[models.DeadLetter], error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type deadLettersRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewDeadLettersRepository creates a new dead letters repository
+func NewDeadLettersRepository(db *mongo.Database, config Config) DeadLettersRepository {
+	return &deadLettersRepository{
+		collection: db.Collection("dead_letters"),
+		tracker: queryTracker{
+			collection: "dead_letters",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// List retrieves a page of DLQ entries, most recent failure first, optionally of one job type
+func (r *deadLettersRepository) List(ctx context.Context, jobType, cursor string, limit int) (*Page[models.DeadLetter], error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	filter := bson.M{}
+	if jobType != "" {
+		filter["job_type"] = jobType
+	}
+
+	return findPage[models.DeadLetter](ctx, r.collection, PageQuery{
+		Filter:    filter,
+		SortField: "failed_at",
+		Cursor:    cursor,
+		Limit:     limit,
+	})
+}
+
+// EnsureIndexes creates the indexes used to list DLQ entries
+func (r *deadLettersRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "failed_at", Value: -1}},
+			Options: options.Index().SetName("dead_letters_failed"),
+		},
+		{
+			Keys:    bson.D{{Key: "job_type", Value: 1}, {Key: "failed_at", Value: -1}},
+			Options: options.Index().SetName("dead_letters_type_failed"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Errors returned by repositories in place of driver errors, so callers can
+// tell the outcomes apart without knowing about MongoDB
+var (
+	// ErrNotFound is returned when no document matches a lookup
+	ErrNotFound = errors.New("document not found")
+	// ErrConflict is returned when a write conflicts with the stored documents:
+	// a conditional update matched no document, or a unique key is already taken
+	ErrConflict = errors.New("document conflicts with stored state")
+	// ErrInvalidID is returned for IDs that are not in the form the document uses,
+	// such as a hex-encoded ObjectID
+	ErrInvalidID = errors.New("invalid ID")
+)
+
+// parseObjectID converts a hex ID to an ObjectID
+func parseObjectID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return objectID, nil
+}
+
+// parseJobID reads a job ID, either an ObjectID or a UUIDv7
+func parseJobID(id string) (models.JobID, error) {
+	jobID, err := models.ParseJobID(id)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return jobID, nil
+}
+
+// translateError converts the driver errors of a read or unconditional write
+// into repository errors. Other errors are returned as they are.
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	}
+	return err
+}
+
+// translateConditionalError converts the driver errors of a conditional update.
+// An update matching no document conflicts with the state it expected.
+func translateConditionalError(err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrConflict
+	}
+	return translateError(err)
+}
+
+// translateUpdateError converts the outcome of an update of a document by ID,
+// returning ErrNotFound if no document has the ID
+func translateUpdateError(result *mongo.UpdateResult, err error) error {
+	if err != nil {
+		return translateError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// IsTimeout reports whether err is the outcome of an operation that ran out of
+// time: an expired context deadline, or a query the server stopped at its
+// maxTimeMS, which the driver derives from the deadline
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err)
+}
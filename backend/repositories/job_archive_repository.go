@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// jobArchiveCollection holds jobs moved out of the jobs collection. Archived jobs
+// keep their ID, so they are looked up like live ones.
+const jobArchiveCollection = "jobs_archive"
+
+// JobArchiveRepository interface defines the methods for archived job data access
+type JobArchiveRepository interface {
+	// Archive stores a job in the archive, replacing an earlier copy. The job's
+	// config must be inline, as offloaded configs are not archived.
+	Archive(ctx context.Context, job *models.Job) error
+	// GetByID retrieves an archived job, or returns ErrNotFound if it is not archived
+	GetByID(ctx context.Context, id string) (*models.Job, error)
+}
+
+type jobArchiveRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewJobArchiveRepository creates a new job archive repository
+func NewJobArchiveRepository(db *mongo.Database, config Config) JobArchiveRepository {
+	return &jobArchiveRepository{
+		collection: db.Collection(jobArchiveCollection),
+		tracker: queryTracker{
+			collection: jobArchiveCollection,
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+func (r *jobArchiveRepository) Archive(ctx context.Context, job *models.Job) error {
+	ctx, done := r.tracker.start(ctx, "archive")
+	defer done()
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job, options.Replace().SetUpsert(true))
+	return translateError(err)
+}
+
+func (r *jobArchiveRepository) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "getByID")
+	defer done()
+
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var job models.Job
+	err = r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": jobID})).Decode(&job)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	job.Archived = true
+	return &job, nil
+}
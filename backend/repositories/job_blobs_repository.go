@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// jobBlobsBucket is the GridFS bucket holding job values too large to keep on
+// the job document. The worker reads configs from the same bucket.
+const jobBlobsBucket = "job_blobs"
+
+// JobBlobsRepository interface defines the methods for storing large job values in GridFS
+type JobBlobsRepository interface {
+	// Put stores a blob under a descriptive name and returns its ID
+	Put(ctx context.Context, name string, data []byte) (primitive.ObjectID, error)
+	// Get reads a blob, or returns ErrNotFound if there is none
+	Get(ctx context.Context, id primitive.ObjectID) ([]byte, error)
+	// Delete removes a blob; deleting a missing blob is not an error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type jobBlobsRepository struct {
+	db      *mongo.Database
+	tracker queryTracker
+}
+
+// NewJobBlobsRepository creates a new job blobs repository
+func NewJobBlobsRepository(db *mongo.Database, config Config) JobBlobsRepository {
+	return &jobBlobsRepository{
+		db: db,
+		tracker: queryTracker{
+			collection: jobBlobsBucket,
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// bucket opens the GridFS bucket bounded by the deadline of ctx. Buckets keep
+// their deadlines as state, so every operation opens its own.
+func (r *jobBlobsRepository) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(r.db, options.GridFSBucket().SetName(jobBlobsBucket))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err := bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+func (r *jobBlobsRepository) Put(ctx context.Context, name string, data []byte) (primitive.ObjectID, error) {
+	ctx, done := r.tracker.start(ctx, "put")
+	defer done()
+
+	bucket, err := r.bucket(ctx)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return bucket.UploadFromStream(name, bytes.NewReader(data))
+}
+
+func (r *jobBlobsRepository) Get(ctx context.Context, id primitive.ObjectID) ([]byte, error) {
+	ctx, done := r.tracker.start(ctx, "get")
+	defer done()
+
+	bucket, err := r.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	_, err = bucket.DownloadToStream(id, &buf)
+	if err == gridfs.ErrFileNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *jobBlobsRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	ctx, done := r.tracker.start(ctx, "delete")
+	defer done()
+
+	bucket, err := r.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	if err := bucket.DeleteContext(ctx, id); err != nil && err != gridfs.ErrFileNotFound {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobCommentsRepository interface defines the methods for job comment data access
+type JobCommentsRepository interface {
+	Create(ctx context.Context, comment *models.JobComment) error
+	ListByJob(ctx context.Context, jobID models.JobID, limit int) ([]models.JobComment, error)
+	// List retrieves a page of the comments of all jobs, newest first, optionally
+	// of one job or author
+	List(ctx context.Context, jobID *models.JobID, author, cursor string, limit int) (*Page[models.JobComment], error)
+	// CountCreatedBefore counts the comments created before a time
+	CountCreatedBefore(ctx context.Context, before time.Time) (int64, error)
+	// DeleteCreatedBefore removes the comments created before a time and
+	// returns how many were removed
+	DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type jobCommentsRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewJobCommentsRepository creates a new job comments repository
+func NewJobCommentsRepository(db *mongo.Database, config Config) JobCommentsRepository {
+	return &jobCommentsRepository{
+		collection: db.Collection("job_comments"),
+		tracker: queryTracker{
+			collection: "job_comments",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// Create inserts a new comment
+func (r *jobCommentsRepository) Create(ctx context.Context, comment *models.JobComment) error {
+	ctx, done := r.tracker.start(ctx, "create")
+	defer done()
+
+	comment.ID = primitive.NewObjectID()
+	comment.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, comment)
+	return translateError(err)
+}
+
+func (r *jobCommentsRepository) CountCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := r.tracker.start(ctx, "countCreatedBefore")
+	defer done()
+
+	return r.collection.CountDocuments(ctx, bson.M{"created_at": bson.M{"$lt": before}})
+}
+
+func (r *jobCommentsRepository) DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := r.tracker.start(ctx, "deleteCreatedBefore")
+	defer done()
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// ListByJob retrieves up to limit comments of a job, oldest first
+func (r *jobCommentsRepository) ListByJob(ctx context.Context, jobID models.JobID, limit int) ([]models.JobComment, error) {
+	ctx, done := r.tracker.start(ctx, "listByJob")
+	defer done()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, bson.M{"job_id": jobID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []models.JobComment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *jobCommentsRepository) List(ctx context.Context, jobID *models.JobID, author, cursor string, limit int) (*Page[models.JobComment], error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	filter := bson.M{}
+	if jobID != nil {
+		filter["job_id"] = *jobID
+	}
+	if author != "" {
+		filter["author"] = author
+	}
+
+	return findPage[models.JobComment](ctx, r.collection, PageQuery{
+		Filter:    filter,
+		SortField: "created_at",
+		Cursor:    cursor,
+		Limit:     limit,
+	})
+}
+
+// EnsureIndexes creates the indexes comment listing and retention rely on
+func (r *jobCommentsRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "job_id", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("job_comments_job_created"),
+		},
+		{
+			// Retention removes the comments past the audit retention period
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("job_comments_created"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobGroupsRepository interface defines the methods for job group data access
+type JobGroupsRepository interface {
+	Create(ctx context.Context, group *models.JobGroup) error
+	GetByID(ctx context.Context, tenant string, id primitive.ObjectID) (*models.JobGroup, error)
+	// MarkCancelled records the cancellation of a group. It returns ErrConflict
+	// if the group does not exist or was already cancelled.
+	MarkCancelled(ctx context.Context, tenant string, id primitive.ObjectID, actor string) (*models.JobGroup, error)
+}
+
+type jobGroupsRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewJobGroupsRepository creates a new job groups repository
+func NewJobGroupsRepository(db *mongo.Database, config Config) JobGroupsRepository {
+	return &jobGroupsRepository{
+		collection: db.Collection("job_groups"),
+		tracker: queryTracker{
+			collection: "job_groups",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+func (r *jobGroupsRepository) Create(ctx context.Context, group *models.JobGroup) error {
+	ctx, done := r.tracker.start(ctx, "create")
+	defer done()
+
+	group.ID = primitive.NewObjectID()
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = group.CreatedAt
+
+	_, err := r.collection.InsertOne(ctx, group)
+	return translateError(err)
+}
+
+// GetByID retrieves a group of a tenant, or returns ErrNotFound if it does not exist
+func (r *jobGroupsRepository) GetByID(ctx context.Context, tenant string, id primitive.ObjectID) (*models.JobGroup, error) {
+	ctx, done := r.tracker.start(ctx, "getByID")
+	defer done()
+
+	var group models.JobGroup
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "tenant": tenant}).Decode(&group)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &group, nil
+}
+
+func (r *jobGroupsRepository) MarkCancelled(ctx context.Context, tenant string, id primitive.ObjectID, actor string) (*models.JobGroup, error) {
+	ctx, done := r.tracker.start(ctx, "markCancelled")
+	defer done()
+
+	now := time.Now()
+	filter := bson.M{"_id": id, "tenant": tenant, "cancelled_at": bson.M{"$exists": false}}
+	update := bson.M{
+		"$set": bson.M{
+			"cancelled_at": now,
+			"cancelled_by": actor,
+			"updated_at":   now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var group models.JobGroup
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&group)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+
+	return &group, nil
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobLogsRepository interface defines the methods for job log data access
+type JobLogsRepository interface {
+	ListAfter(ctx context.Context, jobID models.JobID, attempt, afterSeq, limit int) ([]models.JobLogLine, error)
+	Tail(ctx context.Context, jobID models.JobID, attempt, n int) ([]models.JobLogLine, error)
+	// DeleteByJobs removes the log lines of the given jobs
+	DeleteByJobs(ctx context.Context, jobIDs []models.JobID) error
+	EnsureIndexes(ctx context.Context) error
+}
+
+type jobLogsRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewJobLogsRepository creates a new job logs repository
+func NewJobLogsRepository(db *mongo.Database, config Config) JobLogsRepository {
+	return &jobLogsRepository{
+		collection: db.Collection("job_logs"),
+		tracker: queryTracker{
+			collection: "job_logs",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// ListAfter retrieves up to limit lines of an attempt with a sequence number greater than afterSeq
+func (r *jobLogsRepository) ListAfter(ctx context.Context, jobID models.JobID, attempt, afterSeq, limit int) ([]models.JobLogLine, error) {
+	ctx, done := r.tracker.start(ctx, "listAfter")
+	defer done()
+
+	filter := bson.M{"job_id": jobID, "attempt": attempt, "seq": bson.M{"$gt": afterSeq}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: 1}}).
+		SetLimit(int64(limit))
+
+	return r.find(ctx, filter, opts)
+}
+
+func (r *jobLogsRepository) DeleteByJobs(ctx context.Context, jobIDs []models.JobID) error {
+	ctx, done := r.tracker.start(ctx, "deleteByJobs")
+	defer done()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"job_id": bson.M{"$in": jobIDs}})
+	return err
+}
+
+// Tail retrieves the last n lines of an attempt in order
+func (r *jobLogsRepository) Tail(ctx context.Context, jobID models.JobID, attempt, n int) ([]models.JobLogLine, error) {
+	ctx, done := r.tracker.start(ctx, "tail")
+	defer done()
+
+	filter := bson.M{"job_id": jobID, "attempt": attempt}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: -1}}).
+		SetLimit(int64(n))
+
+	lines, err := r.find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines, nil
+}
+
+func (r *jobLogsRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.JobLogLine, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var lines []models.JobLogLine
+	if err := cursor.All(ctx, &lines); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// EnsureIndexes creates the index log queries rely on
+func (r *jobLogsRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "job_id", Value: 1}, {Key: "attempt", Value: 1}, {Key: "seq", Value: 1}},
+		Options: options.Index().SetName("job_logs_attempt_seq"),
+	})
+	return err
+}
@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// jobSummariesCollection holds the job summaries, projected from the jobs collection
+const jobSummariesCollection = "job_summaries"
+
+// JobSummariesRepository interface defines the methods for the job summaries read model
+type JobSummariesRepository interface {
+	// Refresh projects the given jobs into their summaries
+	Refresh(ctx context.Context, ids []models.JobID) error
+	// RefreshSince projects the jobs updated at or after since into their summaries
+	RefreshSince(ctx context.Context, since time.Time) error
+	// LatestUpdate returns when the most recently updated summarized job was
+	// updated, or the zero time if there are no summaries
+	LatestUpdate(ctx context.Context) (time.Time, error)
+	// Delete removes the summaries of jobs that were removed
+	Delete(ctx context.Context, ids []models.JobID) error
+	List(ctx context.Context, query ListQuery) (*SummaryListResult, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+// SummaryListResult holds a page of job summaries
+type SummaryListResult struct {
+	Summaries []models.JobSummary
+	// Total is nil when the count was not requested
+	Total   *int64
+	HasMore bool
+}
+
+type jobSummariesRepository struct {
+	jobs       *mongo.Collection
+	collection *mongo.Collection
+	// reporting is used for list queries and may read from secondaries
+	reporting *mongo.Collection
+	tracker   queryTracker
+}
+
+// NewJobSummariesRepository creates a new job summaries repository
+func NewJobSummariesRepository(db *mongo.Database, config Config) (JobSummariesRepository, error) {
+	collection, reporting, err := newCollections(db, jobSummariesCollection, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jobSummariesRepository{
+		jobs:       db.Collection("jobs"),
+		collection: collection,
+		reporting:  reporting,
+		tracker: queryTracker{
+			collection: jobSummariesCollection,
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}, nil
+}
+
+// summaryProjection maps the fields of a job to those of its summary
+var summaryProjection = bson.M{
+	"name":          1,
+	"job_type":      1,
+	"status":        1,
+	"priority":      1,
+	"tenant":        1,
+	"error_message": 1,
+	"retry_count":   1,
+	"parent_id":     1,
+	"group_id":      1,
+	"held":          bson.M{"$gt": bson.A{"$hold", nil}},
+	"slo_breached":  1,
+	"started_at":    1,
+	"finished_at":   1,
+	"duration_ms":   1,
+	"deadline_at":   1,
+	"created_at":    1,
+	"updated_at":    1,
+}
+
+// project replaces the summaries of the jobs matching match with their current
+// projection. The projection runs on the server, so no job is read into the backend.
+func (r *jobSummariesRepository) project(ctx context.Context, match bson.M) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$project", Value: summaryProjection}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           jobSummariesCollection,
+			"on":             "_id",
+			"whenMatched":    "replace",
+			"whenNotMatched": "insert",
+		}}},
+	}
+	cursor, err := r.jobs.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+func (r *jobSummariesRepository) Refresh(ctx context.Context, ids []models.JobID) error {
+	ctx, done := r.tracker.start(ctx, "refresh")
+	defer done()
+
+	return r.project(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
+
+func (r *jobSummariesRepository) RefreshSince(ctx context.Context, since time.Time) error {
+	ctx, done := r.tracker.start(ctx, "refreshSince")
+	defer done()
+
+	return r.project(ctx, bson.M{"updated_at": bson.M{"$gte": since}})
+}
+
+func (r *jobSummariesRepository) LatestUpdate(ctx context.Context) (time.Time, error) {
+	ctx, done := r.tracker.start(ctx, "latestUpdate")
+	defer done()
+
+	var summary struct {
+		UpdatedAt time.Time `bson:"updated_at"`
+	}
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetProjection(bson.M{"updated_at": 1})
+	err := r.collection.FindOne(ctx, bson.M{}, opts).Decode(&summary)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return summary.UpdatedAt, nil
+}
+
+func (r *jobSummariesRepository) Delete(ctx context.Context, ids []models.JobID) error {
+	ctx, done := r.tracker.start(ctx, "delete")
+	defer done()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+// List retrieves a page of summaries matching the query, which filters and
+// sorts them like the jobs they summarize
+func (r *jobSummariesRepository) List(ctx context.Context, query ListQuery) (*SummaryListResult, error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	filter := scoped(ctx, query.filter())
+	result := &SummaryListResult{}
+
+	if query.IncludeTotal {
+		total, err := r.reporting.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
+	}
+
+	cursor, err := r.reporting.Find(ctx, filter, query.findOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var summaries []models.JobSummary
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+
+	if len(summaries) > query.Limit {
+		summaries = summaries[:query.Limit]
+		result.HasMore = true
+	}
+	result.Summaries = summaries
+
+	return result, nil
+}
+
+// EnsureIndexes creates the indexes of the default list order and its
+// filters, and of the catch-up on the latest update
+func (r *jobSummariesRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("job_summaries_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("job_summaries_status_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "job_type", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("job_summaries_type_created"),
+		},
+		{
+			Keys:    bson.D{{Key: "updated_at", Value: -1}},
+			Options: options.Index().SetName("job_summaries_updated"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobViewsRepository interface defines the methods for saved view data access
+type JobViewsRepository interface {
+	// Create inserts a view. It returns ErrConflict if the tenant already has a view with that name.
+	Create(ctx context.Context, view *models.JobView) error
+	GetByName(ctx context.Context, tenant, name string) (*models.JobView, error)
+	List(ctx context.Context, tenant string) ([]models.JobView, error)
+	Update(ctx context.Context, view *models.JobView) error
+	Delete(ctx context.Context, tenant, name string) (bool, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type jobViewsRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewJobViewsRepository creates a new saved views repository
+func NewJobViewsRepository(db *mongo.Database, config Config) JobViewsRepository {
+	return &jobViewsRepository{
+		collection: db.Collection("job_views"),
+		tracker: queryTracker{
+			collection: "job_views",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+func (r *jobViewsRepository) Create(ctx context.Context, view *models.JobView) error {
+	ctx, done := r.tracker.start(ctx, "create")
+	defer done()
+
+	view.ID = primitive.NewObjectID()
+	view.CreatedAt = time.Now()
+	view.UpdatedAt = view.CreatedAt
+
+	_, err := r.collection.InsertOne(ctx, view)
+	return translateError(err)
+}
+
+// GetByName retrieves a view of a tenant, or returns ErrNotFound if it does not exist
+func (r *jobViewsRepository) GetByName(ctx context.Context, tenant, name string) (*models.JobView, error) {
+	ctx, done := r.tracker.start(ctx, "getByName")
+	defer done()
+
+	var view models.JobView
+	err := r.collection.FindOne(ctx, bson.M{"tenant": tenant, "name": name}).Decode(&view)
+	if err != nil {
+		return nil, translateError(err)
+	}
+
+	return &view, nil
+}
+
+// List retrieves the views of a tenant ordered by name
+func (r *jobViewsRepository) List(ctx context.Context, tenant string) ([]models.JobView, error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"tenant": tenant}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var views []models.JobView
+	if err := cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+
+	return views, nil
+}
+
+// Update replaces the filter of a view
+func (r *jobViewsRepository) Update(ctx context.Context, view *models.JobView) error {
+	ctx, done := r.tracker.start(ctx, "update")
+	defer done()
+
+	view.UpdatedAt = time.Now()
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": view.ID}, view)
+	return translateUpdateError(result, err)
+}
+
+// Delete removes a view of a tenant. It returns false if no view matched.
+func (r *jobViewsRepository) Delete(ctx context.Context, tenant, name string) (bool, error) {
+	ctx, done := r.tracker.start(ctx, "delete")
+	defer done()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"tenant": tenant, "name": name})
+	if err != nil {
+		return false, err
+	}
+
+	return result.DeletedCount > 0, nil
+}
+
+// EnsureIndexes creates the index that keeps view names unique per tenant
+func (r *jobViewsRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "tenant", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetName("job_views_tenant_name").SetUnique(true),
+	})
+	return err
+}
@@ -0,0 +1,457 @@
+package repositories
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// changeStreamRetryDelay is how long the cache waits before reopening a failed change stream
+const changeStreamRetryDelay = 10 * time.Second
+
+// errChangeStreamUnsupported is the server error code of change streams on a standalone server
+const errChangeStreamUnsupported = 40573
+
+var jobCacheLookups = metrics.NewCounter("job_cache_lookups_total", "Job cache lookups by GetByID, by result.", "result")
+
+// CacheConfig sizes the job read cache
+type CacheConfig struct {
+	// Size is the number of jobs kept; the least recently read are evicted first
+	Size int
+	// TTL bounds how stale a cached job can be when a change it missed was made
+	// by a worker or another backend instance and no change stream is available
+	TTL time.Duration
+}
+
+// CachingJobsRepository serves GetByID from an in-memory LRU cache in front of
+// a jobs repository. Writes through the repository evict the jobs they touch;
+// writes made elsewhere are evicted by Run's change stream, or expire after the TTL.
+type CachingJobsRepository struct {
+	repo       JobsRepository
+	collection *mongo.Collection
+	config     CacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	// evictions counts evictions so a read racing a write does not cache what it read before the write
+	evictions uint64
+}
+
+type cacheEntry struct {
+	id      string
+	job     models.Job
+	expires time.Time
+}
+
+// NewCachingJobsRepository wraps a jobs repository with a read cache. The
+// collection is watched by Run to evict jobs changed by other writers.
+func NewCachingJobsRepository(repo JobsRepository, db *mongo.Database, config CacheConfig) *CachingJobsRepository {
+	return &CachingJobsRepository{
+		repo:       repo,
+		collection: db.Collection("jobs"),
+		config:     config,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// GetByID returns the cached job when fresh, reading it through otherwise.
+// Missing jobs are not cached, and cached jobs of a tenant out of the scope of
+// ctx are not found.
+func (c *CachingJobsRepository) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	key, ok := cacheKey(id)
+	if !ok {
+		return c.repo.GetByID(ctx, id)
+	}
+	if job := c.lookup(key); job != nil {
+		jobCacheLookups.Inc("hit")
+		if !inScope(ctx, job.Tenant) {
+			return nil, ErrNotFound
+		}
+		return job, nil
+	}
+	jobCacheLookups.Inc("miss")
+
+	c.mu.Lock()
+	evictions := c.evictions
+	c.mu.Unlock()
+
+	job, err := c.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, job, evictions)
+	return job, nil
+}
+
+// cacheKey returns the canonical form of a job ID, so the spellings of an ID
+// in any letter case share one entry, and false if id is not a job ID
+func cacheKey(id string) (string, bool) {
+	parsed, err := models.ParseJobID(id)
+	if err != nil {
+		return "", false
+	}
+	return parsed.String(), true
+}
+
+// lookup returns a copy of a fresh cached job, or nil
+func (c *CachingJobsRepository) lookup(id string) *models.Job {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return nil
+	}
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(element)
+		return nil
+	}
+	c.order.MoveToFront(element)
+	job := entry.job
+	return &job
+}
+
+// store caches a job read when the eviction count was evictions, unless a job was evicted since
+func (c *CachingJobsRepository) store(id string, job *models.Job, evictions uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.evictions != evictions {
+		return
+	}
+
+	entry := &cacheEntry{id: id, job: *job, expires: time.Now().Add(c.config.TTL)}
+	if element, ok := c.entries[id]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[id] = c.order.PushFront(entry)
+	for c.order.Len() > c.config.Size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Evict drops a job from the cache
+func (c *CachingJobsRepository) Evict(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evictions++
+	key, ok := cacheKey(id)
+	if !ok {
+		return
+	}
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+func (c *CachingJobsRepository) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).id)
+}
+
+// Run evicts jobs changed by any writer as MongoDB reports the changes, until
+// ctx is cancelled. Change streams need a replica set; on a standalone server
+// the cache relies on its TTL alone.
+func (c *CachingJobsRepository) Run(ctx context.Context) {
+	for {
+		err := c.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		var serverErr mongo.ServerError
+		if errors.As(err, &serverErr) && serverErr.HasErrorCode(errChangeStreamUnsupported) {
+			log.Printf("Change streams are unavailable, cached jobs expire after %s instead", c.config.TTL)
+			return
+		}
+		log.Printf("Job cache change stream failed, retrying in %s: %v", changeStreamRetryDelay, err)
+
+		// Changes may have been missed while the stream was down
+		c.clear()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(changeStreamRetryDelay):
+		}
+	}
+}
+
+func (c *CachingJobsRepository) watch(ctx context.Context) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"operationType": bson.M{"$in": []string{"update", "replace", "delete"}}}}},
+		{{Key: "$project", Value: bson.M{"documentKey": 1}}},
+	}
+	stream, err := c.collection.Watch(ctx, pipeline, options.ChangeStream())
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		// Jobs are keyed by ObjectIDs or UUIDv7 strings, which JobID reads alike
+		var change struct {
+			DocumentKey struct {
+				ID models.JobID `bson:"_id"`
+			} `bson:"documentKey"`
+		}
+		if err := stream.Decode(&change); err != nil {
+			// The changed job is unknown, so none of the cached ones can be trusted
+			log.Printf("Failed to decode job change, clearing the job cache: %v", err)
+			c.clear()
+			continue
+		}
+		c.Evict(change.DocumentKey.ID.String())
+	}
+	return stream.Err()
+}
+
+func (c *CachingJobsRepository) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evictions++
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+// The writes below evict the jobs they change before returning
+
+func (c *CachingJobsRepository) UpdateStatus(ctx context.Context, id string, status models.JobStatus) error {
+	defer c.Evict(id)
+	return c.repo.UpdateStatus(ctx, id, status)
+}
+
+func (c *CachingJobsRepository) UpdateStatusWithRetry(ctx context.Context, id string, status models.JobStatus, retryCount int) error {
+	defer c.Evict(id)
+	return c.repo.UpdateStatusWithRetry(ctx, id, status, retryCount)
+}
+
+func (c *CachingJobsRepository) Update(ctx context.Context, job *models.Job) error {
+	defer c.Evict(job.ID.String())
+	return c.repo.Update(ctx, job)
+}
+
+func (c *CachingJobsRepository) Retry(ctx context.Context, id string, maxRetries int, dispatchID string, versions []time.Time) (*models.Job, error) {
+	defer c.Evict(id)
+	return c.repo.Retry(ctx, id, maxRetries, dispatchID, versions)
+}
+
+func (c *CachingJobsRepository) Prioritize(ctx context.Context, id, dispatchID string, versions []time.Time) (*models.Job, error) {
+	defer c.Evict(id)
+	return c.repo.Prioritize(ctx, id, dispatchID, versions)
+}
+
+func (c *CachingJobsRepository) SetHold(ctx context.Context, id string, hold *models.JobHold, versions []time.Time) (*models.Job, error) {
+	defer c.Evict(id)
+	return c.repo.SetHold(ctx, id, hold, versions)
+}
+
+func (c *CachingJobsRepository) ClearHold(ctx context.Context, id string, versions []time.Time) (*models.Job, error) {
+	defer c.Evict(id)
+	return c.repo.ClearHold(ctx, id, versions)
+}
+
+func (c *CachingJobsRepository) RequestCancellation(ctx context.Context, id, actor, reason string, versions []time.Time) (*models.Job, error) {
+	defer c.Evict(id)
+	return c.repo.RequestCancellation(ctx, id, actor, reason, versions)
+}
+
+func (c *CachingJobsRepository) CompleteCancellation(ctx context.Context, id, note string) (bool, error) {
+	defer c.Evict(id)
+	return c.repo.CompleteCancellation(ctx, id, note)
+}
+
+func (c *CachingJobsRepository) RevertCancellation(ctx context.Context, id models.JobID, reason string) (bool, error) {
+	defer c.Evict(id.String())
+	return c.repo.RevertCancellation(ctx, id, reason)
+}
+
+func (c *CachingJobsRepository) RecordCancellationResend(ctx context.Context, id models.JobID) error {
+	defer c.Evict(id.String())
+	return c.repo.RecordCancellationResend(ctx, id)
+}
+
+func (c *CachingJobsRepository) ReleaseHeld(ctx context.Context) (*models.Job, error) {
+	job, err := c.repo.ReleaseHeld(ctx)
+	if job != nil {
+		c.Evict(job.ID.String())
+	}
+	return job, err
+}
+
+func (c *CachingJobsRepository) RedispatchStalePending(ctx context.Context, before time.Time, dispatchID string) (*models.Job, error) {
+	job, err := c.repo.RedispatchStalePending(ctx, before, dispatchID)
+	if job != nil {
+		c.Evict(job.ID.String())
+	}
+	return job, err
+}
+
+func (c *CachingJobsRepository) AttachInput(ctx context.Context, id string, input *models.JobInput, status models.JobStatus) (*models.Job, error) {
+	defer c.Evict(id)
+	return c.repo.AttachInput(ctx, id, input, status)
+}
+
+func (c *CachingJobsRepository) CompleteFanIn(ctx context.Context, id models.JobID, status models.JobStatus, children models.JobChildren, errorMessage string) (bool, error) {
+	defer c.Evict(id.String())
+	return c.repo.CompleteFanIn(ctx, id, status, children, errorMessage)
+}
+
+func (c *CachingJobsRepository) AssignGroup(ctx context.Context, groupID primitive.ObjectID, tenant string, ids []models.JobID) (int64, error) {
+	defer func() {
+		for _, id := range ids {
+			c.Evict(id.String())
+		}
+	}()
+	return c.repo.AssignGroup(ctx, groupID, tenant, ids)
+}
+
+func (c *CachingJobsRepository) Requeue(ctx context.Context, id models.JobID, statuses []models.JobStatus, dispatchID string) (*models.Job, error) {
+	defer c.Evict(id.String())
+	return c.repo.Requeue(ctx, id, statuses, dispatchID)
+}
+
+func (c *CachingJobsRepository) DeleteForRetention(ctx context.Context, query RetentionQuery, ids []models.JobID) ([]models.JobID, error) {
+	defer func() {
+		for _, id := range ids {
+			c.Evict(id.String())
+		}
+	}()
+	return c.repo.DeleteForRetention(ctx, query, ids)
+}
+
+// The methods below change no job the cache may hold and go straight to the
+// repository. Every method is listed, so a write added to JobsRepository
+// does not bypass the evictions above unnoticed.
+
+func (c *CachingJobsRepository) Create(ctx context.Context, job *models.Job) error {
+	return c.repo.Create(ctx, job)
+}
+
+func (c *CachingJobsRepository) List(ctx context.Context, query ListQuery) (*ListResult, error) {
+	return c.repo.List(ctx, query)
+}
+
+func (c *CachingJobsRepository) Search(ctx context.Context, text string, limit int) ([]SearchHit, error) {
+	return c.repo.Search(ctx, text, limit)
+}
+
+func (c *CachingJobsRepository) CountByStatus(ctx context.Context) (map[models.JobStatus]int64, error) {
+	return c.repo.CountByStatus(ctx)
+}
+
+func (c *CachingJobsRepository) CountPendingByType(ctx context.Context) (map[models.JobType]int64, error) {
+	return c.repo.CountPendingByType(ctx)
+}
+
+func (c *CachingJobsRepository) CountPendingAhead(ctx context.Context, job *models.Job) (int64, error) {
+	return c.repo.CountPendingAhead(ctx, job)
+}
+
+func (c *CachingJobsRepository) DurationStatsByType(ctx context.Context) ([]TypeDurationStats, error) {
+	return c.repo.DurationStatsByType(ctx)
+}
+
+func (c *CachingJobsRepository) CountOutcomesSince(ctx context.Context, jobType models.JobType, since time.Time) (map[models.JobStatus]int64, error) {
+	return c.repo.CountOutcomesSince(ctx, jobType, since)
+}
+
+func (c *CachingJobsRepository) ThroughputSince(ctx context.Context, since time.Time, interval time.Duration) ([]ThroughputBucket, error) {
+	return c.repo.ThroughputSince(ctx, since, interval)
+}
+
+func (c *CachingJobsRepository) FailuresByTypeSince(ctx context.Context, since time.Time, limit int) ([]TypeFailures, error) {
+	return c.repo.FailuresByTypeSince(ctx, since, limit)
+}
+
+func (c *CachingJobsRepository) OldestPending(ctx context.Context) (time.Time, error) {
+	return c.repo.OldestPending(ctx)
+}
+
+func (c *CachingJobsRepository) RecentDurationStats(ctx context.Context) ([]models.JobTypeStats, error) {
+	return c.repo.RecentDurationStats(ctx)
+}
+
+func (c *CachingJobsRepository) ListUnacknowledgedCancellations(ctx context.Context, before time.Time, limit int) ([]models.Job, error) {
+	return c.repo.ListUnacknowledgedCancellations(ctx, before, limit)
+}
+
+func (c *CachingJobsRepository) ListCancellingSince(ctx context.Context, requestedBefore time.Time, limit int) ([]models.Job, error) {
+	return c.repo.ListCancellingSince(ctx, requestedBefore, limit)
+}
+
+func (c *CachingJobsRepository) ListTerminal(ctx context.Context, ids []models.JobID) ([]models.Job, error) {
+	return c.repo.ListTerminal(ctx, ids)
+}
+
+func (c *CachingJobsRepository) ListProcessing(ctx context.Context, ids []models.JobID) ([]models.Job, error) {
+	return c.repo.ListProcessing(ctx, ids)
+}
+
+func (c *CachingJobsRepository) ListUpdatedSince(ctx context.Context, since time.Time, limit int) ([]models.Job, error) {
+	return c.repo.ListUpdatedSince(ctx, since, limit)
+}
+
+func (c *CachingJobsRepository) ListChildren(ctx context.Context, parentID models.JobID, limit int) ([]models.Job, error) {
+	return c.repo.ListChildren(ctx, parentID, limit)
+}
+
+func (c *CachingJobsRepository) CountChildrenByStatus(ctx context.Context, parentID models.JobID) (map[models.JobStatus]int, error) {
+	return c.repo.CountChildrenByStatus(ctx, parentID)
+}
+
+func (c *CachingJobsRepository) ListAwaitingChildren(ctx context.Context, after models.JobID, limit int) ([]models.Job, error) {
+	return c.repo.ListAwaitingChildren(ctx, after, limit)
+}
+
+func (c *CachingJobsRepository) CountByStatusInGroup(ctx context.Context, groupID primitive.ObjectID) (map[models.JobStatus]int64, error) {
+	return c.repo.CountByStatusInGroup(ctx, groupID)
+}
+
+func (c *CachingJobsRepository) ListCancellableInGroup(ctx context.Context, groupID primitive.ObjectID, after models.JobID, limit int) ([]models.Job, error) {
+	return c.repo.ListCancellableInGroup(ctx, groupID, after, limit)
+}
+
+func (c *CachingJobsRepository) CountForRequeue(ctx context.Context, query RequeueQuery) (int64, error) {
+	return c.repo.CountForRequeue(ctx, query)
+}
+
+func (c *CachingJobsRepository) ListForRequeue(ctx context.Context, query RequeueQuery, after models.JobID, limit int) ([]models.Job, error) {
+	return c.repo.ListForRequeue(ctx, query, after, limit)
+}
+
+func (c *CachingJobsRepository) CountForRetention(ctx context.Context, query RetentionQuery) (int64, error) {
+	return c.repo.CountForRetention(ctx, query)
+}
+
+func (c *CachingJobsRepository) ListForRetention(ctx context.Context, query RetentionQuery, after models.JobID, limit int) ([]models.Job, error) {
+	return c.repo.ListForRetention(ctx, query, after, limit)
+}
+
+func (c *CachingJobsRepository) ClaimSubmission(ctx context.Context, key string, jobID models.JobID, window time.Duration) (models.JobID, error) {
+	return c.repo.ClaimSubmission(ctx, key, jobID, window)
+}
+
+func (c *CachingJobsRepository) ReleaseSubmission(ctx context.Context, key string, jobID models.JobID) error {
+	return c.repo.ReleaseSubmission(ctx, key, jobID)
+}
+
+func (c *CachingJobsRepository) ClaimJobID(ctx context.Context, id models.JobID, tenant string) error {
+	return c.repo.ClaimJobID(ctx, id, tenant)
+}
+
+func (c *CachingJobsRepository) EnsureIndexes(ctx context.Context) error {
+	return c.repo.EnsureIndexes(ctx)
+}
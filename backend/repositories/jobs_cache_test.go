@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// countingJobsRepository serves every job it is asked for, counting the reads
+type countingJobsRepository struct {
+	JobsRepository
+	reads int
+}
+
+func (r *countingJobsRepository) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	r.reads++
+	parsed, err := models.ParseJobID(id)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return &models.Job{ID: parsed}, nil
+}
+
+func newTestCache(repo JobsRepository) *CachingJobsRepository {
+	return &CachingJobsRepository{
+		repo:    repo,
+		config:  CacheConfig{Size: 10, TTL: time.Minute},
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func TestCacheKeysNormalizeJobIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		read    string
+		reread  string
+		evicted string
+	}{
+		{name: "ObjectID", read: "65a1b2c3d4e5f60718293a4b", reread: "65A1B2C3D4E5F60718293A4B", evicted: "65a1B2c3d4e5f60718293a4B"},
+		{name: "UUIDv7", read: "01890a5d-ac96-774b-bcce-b302099a8057", reread: "01890A5D-AC96-774B-BCCE-B302099A8057", evicted: "01890a5d-AC96-774b-bcce-B302099A8057"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &countingJobsRepository{}
+			cache := newTestCache(repo)
+			ctx := context.Background()
+
+			if _, err := cache.GetByID(ctx, tt.read); err != nil {
+				t.Fatalf("GetByID(%s) error = %v", tt.read, err)
+			}
+			if _, err := cache.GetByID(ctx, tt.reread); err != nil {
+				t.Fatalf("GetByID(%s) error = %v", tt.reread, err)
+			}
+			if repo.reads != 1 {
+				t.Errorf("reads after two spellings = %d, want one entry shared by both", repo.reads)
+			}
+
+			cache.Evict(tt.evicted)
+			if _, err := cache.GetByID(ctx, tt.read); err != nil {
+				t.Fatalf("GetByID(%s) error = %v", tt.read, err)
+			}
+			if repo.reads != 2 {
+				t.Errorf("reads after evicting %s = %d, want the entry gone", tt.evicted, repo.reads)
+			}
+		})
+	}
+}
+
+func TestCacheSkipsInvalidIDs(t *testing.T) {
+	repo := &countingJobsRepository{}
+	cache := newTestCache(repo)
+
+	if _, err := cache.GetByID(context.Background(), "not-a-job"); !errors.Is(err, ErrInvalidID) {
+		t.Errorf("GetByID() error = %v, want ErrInvalidID", err)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("cache holds %d entries, want none for an invalid ID", len(cache.entries))
+	}
+}
+
+func TestCacheEvictsChangedJobs(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	cached := []string{"65a1b2c3d4e5f60718293a4b", "65a1b2c3d4e5f60718293a4c", "01890a5d-ac96-774b-bcce-b302099a8057"}
+	objectID, _ := primitive.ObjectIDFromHex(cached[0])
+	change := func(token int, id interface{}) bson.D {
+		return bson.D{
+			{Key: "_id", Value: bson.D{{Key: "_data", Value: fmt.Sprint(token)}}},
+			{Key: "documentKey", Value: bson.D{{Key: "_id", Value: id}}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		changes []bson.D
+		want    []string
+	}{
+		{name: "ObjectID", changes: []bson.D{change(1, objectID)}, want: cached[1:]},
+		{name: "UUIDv7", changes: []bson.D{change(1, cached[2])}, want: cached[:2]},
+		{name: "undecodable", changes: []bson.D{change(1, 42)}},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			cache := newTestCache(&countingJobsRepository{})
+			cache.collection = mt.Coll
+			for _, id := range cached {
+				if _, err := cache.GetByID(context.Background(), id); err != nil {
+					mt.Fatalf("GetByID(%s) error = %v", id, err)
+				}
+			}
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "jobprocessor.jobs", mtest.FirstBatch, tt.changes...))
+
+			if err := cache.watch(context.Background()); err != nil {
+				mt.Fatalf("watch() error = %v", err)
+			}
+			var left []string
+			for _, id := range cached {
+				if _, ok := cache.entries[id]; ok {
+					left = append(left, id)
+				}
+			}
+			if !reflect.DeepEqual(left, tt.want) {
+				mt.Errorf("cached after the changes = %v, want %v", left, tt.want)
+			}
+		})
+	}
+}
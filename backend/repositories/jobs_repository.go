@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"time"
 
 	"github.com/fullstack-assessment/backend/models"
@@ -11,89 +13,698 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ListQuery describes a paginated query over jobs
+type ListQuery struct {
+	Page  int
+	Limit int
+	// IncludeTotal controls whether the total number of matching jobs is counted
+	IncludeTotal bool
+	// Statuses and JobTypes restrict the jobs listed when not empty
+	Statuses []models.JobStatus
+	JobTypes []models.JobType
+	// CreatedAfter restricts the jobs listed to those created after it when set
+	CreatedAfter *time.Time
+	// SortField defaults to created_at, newest first
+	SortField     string
+	SortAscending bool
+	// Exclude lists the fields left out of the listed jobs, by their BSON names
+	Exclude []string
+}
+
+// filter returns the MongoDB filter matching the query
+func (q ListQuery) filter() bson.M {
+	filter := bson.M{}
+	if len(q.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": q.Statuses}
+	}
+	if len(q.JobTypes) > 0 {
+		filter["job_type"] = bson.M{"$in": q.JobTypes}
+	}
+	if q.CreatedAfter != nil {
+		filter["created_at"] = bson.M{"$gt": *q.CreatedAfter}
+	}
+	return filter
+}
+
+// findOptions returns the sort and pagination of the query. One extra
+// document is fetched to determine whether another page exists.
+func (q ListQuery) findOptions() *options.FindOptions {
+	sortField, direction := q.SortField, -1
+	if sortField == "" {
+		sortField = "created_at"
+	}
+	if q.SortAscending {
+		direction = 1
+	}
+
+	return options.Find().
+		SetSkip(int64((q.Page - 1) * q.Limit)).
+		SetLimit(int64(q.Limit + 1)).
+		SetSort(bson.D{{Key: sortField, Value: direction}})
+}
+
+// RequeueQuery selects the jobs reset to pending by a bulk requeue
+type RequeueQuery struct {
+	// Statuses is required; JobTypes restricts the jobs when not empty
+	Statuses      []models.JobStatus
+	JobTypes      []models.JobType
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// filter returns the MongoDB filter matching the query
+func (q RequeueQuery) filter() bson.M {
+	// Jobs held for debugging are left as they are
+	filter := bson.M{"status": bson.M{"$in": q.Statuses}, "hold": bson.M{"$exists": false}}
+	if len(q.JobTypes) > 0 {
+		filter["job_type"] = bson.M{"$in": q.JobTypes}
+	}
+	created := bson.M{}
+	if q.CreatedAfter != nil {
+		created["$gte"] = *q.CreatedAfter
+	}
+	if q.CreatedBefore != nil {
+		created["$lt"] = *q.CreatedBefore
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+	return filter
+}
+
+// RetentionMatch selects jobs by type and status; empty values match any
+type RetentionMatch struct {
+	JobType models.JobType
+	Status  models.JobStatus
+}
+
+// RetentionQuery selects the finished jobs removed by a retention rule
+type RetentionQuery struct {
+	// JobType restricts the jobs when set
+	JobType  models.JobType
+	Statuses []models.JobStatus
+	// Except leaves out the jobs governed by more specific rules
+	Except []RetentionMatch
+	// UpdatedBefore is the cutoff; jobs changed since are kept
+	UpdatedBefore time.Time
+}
+
+// filter returns the MongoDB filter matching the query
+func (q RetentionQuery) filter() bson.M {
+	// Jobs held for debugging are kept however old they are
+	filter := bson.M{
+		"status":     bson.M{"$in": q.Statuses},
+		"updated_at": bson.M{"$lt": q.UpdatedBefore},
+		"hold":       bson.M{"$exists": false},
+	}
+	if q.JobType != "" {
+		filter["job_type"] = q.JobType
+	}
+	if len(q.Except) > 0 {
+		except := make(bson.A, 0, len(q.Except))
+		for _, match := range q.Except {
+			condition := bson.M{}
+			if match.JobType != "" {
+				condition["job_type"] = match.JobType
+			}
+			if match.Status != "" {
+				condition["status"] = match.Status
+			}
+			except = append(except, condition)
+		}
+		filter["$nor"] = except
+	}
+	return filter
+}
+
+// ListResult holds a page of jobs
+type ListResult struct {
+	Jobs []models.Job
+	// Total is nil when the count was not requested
+	Total   *int64
+	HasMore bool
+}
+
+// SearchHit is a job matched by a text search along with its relevance score
+type SearchHit struct {
+	models.Job `bson:",inline"`
+	Score      float64 `bson:"score"`
+}
+
+// TypeDurationStats aggregates the processing durations of finished jobs of one type
+type TypeDurationStats struct {
+	JobType       models.JobType `bson:"_id"`
+	Finished      int64          `bson:"finished"`
+	Breached      int64          `bson:"breached"`
+	AvgDurationMs float64        `bson:"avg_duration_ms"`
+	MaxDurationMs int64          `bson:"max_duration_ms"`
+}
+
+// ThroughputBucket counts the jobs created and finished in one time bucket
+type ThroughputBucket struct {
+	Start     time.Time
+	Created   int64
+	Completed int64
+	Failed    int64
+	// DurationPercentiles holds the p50 and p95 processing durations of the jobs finished in the bucket
+	DurationPercentiles []float64
+}
+
+// TypeFailures counts the jobs of one type that finished and failed within a period
+type TypeFailures struct {
+	JobType  models.JobType `bson:"_id" json:"jobType"`
+	Finished int64          `bson:"finished" json:"finished"`
+	Failed   int64          `bson:"failed" json:"failed"`
+}
+
 // JobsRepository interface defines the methods for job data access
 type JobsRepository interface {
 	Create(ctx context.Context, job *models.Job) error
 	GetByID(ctx context.Context, id string) (*models.Job, error)
-	List(ctx context.Context, page, limit int) ([]models.Job, int64, error)
+	List(ctx context.Context, query ListQuery) (*ListResult, error)
+	Search(ctx context.Context, text string, limit int) ([]SearchHit, error)
+	CountByStatus(ctx context.Context) (map[models.JobStatus]int64, error)
+	CountPendingByType(ctx context.Context) (map[models.JobType]int64, error)
+	CountPendingAhead(ctx context.Context, job *models.Job) (int64, error)
+	DurationStatsByType(ctx context.Context) ([]TypeDurationStats, error)
+	CountOutcomesSince(ctx context.Context, jobType models.JobType, since time.Time) (map[models.JobStatus]int64, error)
+	ThroughputSince(ctx context.Context, since time.Time, interval time.Duration) ([]ThroughputBucket, error)
+	// FailuresByTypeSince lists up to limit job types with failures finished since
+	// the given time, those with the most failures first
+	FailuresByTypeSince(ctx context.Context, since time.Time, limit int) ([]TypeFailures, error)
+	// OldestPending returns the creation time of the job pending the longest, or ErrNotFound if none is pending
+	OldestPending(ctx context.Context) (time.Time, error)
+	RecentDurationStats(ctx context.Context) ([]models.JobTypeStats, error)
 	UpdateStatus(ctx context.Context, id string, status models.JobStatus) error
 	UpdateStatusWithRetry(ctx context.Context, id string, status models.JobStatus, retryCount int) error
 	Update(ctx context.Context, job *models.Job) error
+	Prioritize(ctx context.Context, id, dispatchID string, versions []time.Time) (*models.Job, error)
+	Retry(ctx context.Context, id string, maxRetries int, dispatchID string, versions []time.Time) (*models.Job, error)
+	SetHold(ctx context.Context, id string, hold *models.JobHold, versions []time.Time) (*models.Job, error)
+	ClearHold(ctx context.Context, id string, versions []time.Time) (*models.Job, error)
+	RequestCancellation(ctx context.Context, id, actor, reason string, versions []time.Time) (*models.Job, error)
+	CompleteCancellation(ctx context.Context, id, note string) (bool, error)
+	ListUnacknowledgedCancellations(ctx context.Context, before time.Time, limit int) ([]models.Job, error)
+	RecordCancellationResend(ctx context.Context, id models.JobID) error
+	ListCancellingSince(ctx context.Context, requestedBefore time.Time, limit int) ([]models.Job, error)
+	RevertCancellation(ctx context.Context, id models.JobID, reason string) (bool, error)
+	ReleaseHeld(ctx context.Context) (*models.Job, error)
+	RedispatchStalePending(ctx context.Context, before time.Time, dispatchID string) (*models.Job, error)
+	AttachInput(ctx context.Context, id string, input *models.JobInput, status models.JobStatus) (*models.Job, error)
+	ListTerminal(ctx context.Context, ids []models.JobID) ([]models.Job, error)
+	// ListProcessing retrieves the type and start of the jobs among ids that are processing
+	ListProcessing(ctx context.Context, ids []models.JobID) ([]models.Job, error)
+	// ListUpdatedSince retrieves the ID, type and status of up to limit jobs
+	// updated at or after since, least recently updated first
+	ListUpdatedSince(ctx context.Context, since time.Time, limit int) ([]models.Job, error)
+	ListChildren(ctx context.Context, parentID models.JobID, limit int) ([]models.Job, error)
+	CountChildrenByStatus(ctx context.Context, parentID models.JobID) (map[models.JobStatus]int, error)
+	ListAwaitingChildren(ctx context.Context, after models.JobID, limit int) ([]models.Job, error)
+	CompleteFanIn(ctx context.Context, id models.JobID, status models.JobStatus, children models.JobChildren, errorMessage string) (bool, error)
+	AssignGroup(ctx context.Context, groupID primitive.ObjectID, tenant string, ids []models.JobID) (int64, error)
+	CountByStatusInGroup(ctx context.Context, groupID primitive.ObjectID) (map[models.JobStatus]int64, error)
+	ListCancellableInGroup(ctx context.Context, groupID primitive.ObjectID, after models.JobID, limit int) ([]models.Job, error)
+	CountForRequeue(ctx context.Context, query RequeueQuery) (int64, error)
+	ListForRequeue(ctx context.Context, query RequeueQuery, after models.JobID, limit int) ([]models.Job, error)
+	Requeue(ctx context.Context, id models.JobID, statuses []models.JobStatus, dispatchID string) (*models.Job, error)
+	CountForRetention(ctx context.Context, query RetentionQuery) (int64, error)
+	ListForRetention(ctx context.Context, query RetentionQuery, after models.JobID, limit int) ([]models.Job, error)
+	// DeleteForRetention deletes the listed jobs that still match a retention
+	// query and returns the IDs of those it deleted
+	DeleteForRetention(ctx context.Context, query RetentionQuery, ids []models.JobID) ([]models.JobID, error)
+	ClaimSubmission(ctx context.Context, key string, jobID models.JobID, window time.Duration) (models.JobID, error)
+	ReleaseSubmission(ctx context.Context, key string, jobID models.JobID) error
+	ClaimJobID(ctx context.Context, id models.JobID, tenant string) error
+	EnsureIndexes(ctx context.Context) error
 }
 
 type jobsRepository struct {
 	collection *mongo.Collection
+	// submissions records recent submission keys for duplicate detection
+	submissions *mongo.Collection
+	// ids records the tenant holding each client-supplied job ID
+	ids *mongo.Collection
+	// typeStats holds the rolling duration summaries per job type written by workers
+	typeStats *mongo.Collection
+	// reporting is used for list queries and may read from secondaries
+	reporting *mongo.Collection
+	// tenants routes writes to single jobs by their full shard key
+	tenants *tenantCache
+	tracker queryTracker
 }
 
 // NewJobsRepository creates a new jobs repository
-func NewJobsRepository(db *mongo.Database) JobsRepository {
-	return &jobsRepository{
-		collection: db.Collection("jobs"),
+func NewJobsRepository(db *mongo.Database, config Config) (JobsRepository, error) {
+	collection, reporting, err := newCollections(db, "jobs", config)
+	if err != nil {
+		return nil, err
 	}
+
+	return &jobsRepository{
+		collection:  collection,
+		submissions: db.Collection("job_submissions"),
+		ids:         db.Collection("job_ids"),
+		typeStats:   db.Collection("job_type_stats"),
+		reporting:   reporting,
+		tenants:     newTenantCache(tenantCacheSize),
+		tracker: queryTracker{
+			collection: "jobs",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}, nil
 }
 
 // Create creates a new job in the database
 func (r *jobsRepository) Create(ctx context.Context, job *models.Job) error {
-	job.ID = primitive.NewObjectID()
+	ctx, done := r.tracker.start(ctx, "create")
+	defer done()
+
+	if job.ID.IsZero() {
+		job.ID = models.JobIDFromObjectID(primitive.NewObjectID())
+	}
 	job.CreatedAt = time.Now()
 	job.UpdatedAt = time.Now()
 
 	_, err := r.collection.InsertOne(ctx, job)
-	return err
+	if err != nil {
+		return translateError(err)
+	}
+	r.remember(*job)
+	return nil
 }
 
-// GetByID retrieves a job by its ID
+// GetByID retrieves a job by its ID, or returns ErrNotFound if there is none
 func (r *jobsRepository) GetByID(ctx context.Context, id string) (*models.Job, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	ctx, done := r.tracker.start(ctx, "getByID")
+	defer done()
+
+	jobID, err := parseJobID(id)
 	if err != nil {
 		return nil, err
 	}
 
+	// Reads by ID alone go to every shard unless ctx is scoped to a tenant; the
+	// tenant is not known before the job is read
 	var job models.Job
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&job)
+	err = r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": jobID})).Decode(&job)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, nil
-		}
-		return nil, err
+		return nil, translateError(err)
 	}
+	r.remember(job)
 
 	return &job, nil
 }
 
 // List retrieves a paginated list of jobs
-func (r *jobsRepository) List(ctx context.Context, page, limit int) ([]models.Job, int64, error) {
-	skip := (page - 1) * limit
+func (r *jobsRepository) List(ctx context.Context, query ListQuery) (*ListResult, error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	filter := scoped(ctx, query.filter())
+	result := &ListResult{}
 
 	// Get total count
-	total, err := r.collection.CountDocuments(ctx, bson.M{})
-	if err != nil {
-		return nil, 0, err
+	if query.IncludeTotal {
+		total, err := r.reporting.CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		result.Total = &total
 	}
 
-	// Get jobs with pagination, sorted by created_at descending
-	opts := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(limit)).
-		SetSort(bson.D{{Key: "created_at", Value: -1}})
+	// Get jobs with pagination
+	opts := query.findOptions()
+	if len(query.Exclude) > 0 {
+		projection := bson.M{}
+		for _, field := range query.Exclude {
+			projection[field] = 0
+		}
+		opts.SetProjection(projection)
+	}
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.reporting.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var jobs []models.Job
 	if err := cursor.All(ctx, &jobs); err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+
+	if len(jobs) > query.Limit {
+		jobs = jobs[:query.Limit]
+		result.HasMore = true
+	}
+	result.Jobs = jobs
+
+	return result, nil
+}
+
+// Search finds jobs matching the text query, ordered by relevance
+func (r *jobsRepository) Search(ctx context.Context, text string, limit int) ([]SearchHit, error) {
+	ctx, done := r.tracker.start(ctx, "search")
+	defer done()
+
+	filter := bson.M{"$text": bson.M{"$search": text}}
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.reporting.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var hits []SearchHit
+	if err := cursor.All(ctx, &hits); err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
+
+// CountByStatus counts jobs in each status
+func (r *jobsRepository) CountByStatus(ctx context.Context) (map[models.JobStatus]int64, error) {
+	ctx, done := r.tracker.start(ctx, "countByStatus")
+	defer done()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.reporting.Aggregate(ctx, scopedPipeline(ctx, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Status models.JobStatus `bson:"_id"`
+		Count  int64            `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.JobStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// CountPendingByType counts the jobs waiting to be picked up per job type
+func (r *jobsRepository) CountPendingByType(ctx context.Context) (map[models.JobType]int64, error) {
+	ctx, done := r.tracker.start(ctx, "countPendingByType")
+	defer done()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": models.JobStatusPending}}},
+		{{Key: "$group", Value: bson.M{"_id": "$job_type", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.reporting.Aggregate(ctx, scopedPipeline(ctx, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		JobType models.JobType `bson:"_id"`
+		Count   int64          `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.JobType]int64, len(rows))
+	for _, row := range rows {
+		counts[row.JobType] = row.Count
+	}
+	return counts, nil
+}
+
+// CountPendingAhead counts the pending jobs of the same type that will be picked up before job.
+// Prioritized jobs are ahead of all regular ones and ordered by when they were prioritized.
+func (r *jobsRepository) CountPendingAhead(ctx context.Context, job *models.Job) (int64, error) {
+	ctx, done := r.tracker.start(ctx, "countPendingAhead")
+	defer done()
+
+	filter := bson.M{
+		"status":   models.JobStatusPending,
+		"job_type": job.JobType,
+		"_id":      bson.M{"$ne": job.ID},
+	}
+	if job.Priority == models.JobPriorityHigh && job.PrioritizedAt != nil {
+		filter["priority"] = models.JobPriorityHigh
+		filter["prioritized_at"] = bson.M{"$lt": *job.PrioritizedAt}
+	} else {
+		filter["$or"] = bson.A{
+			bson.M{"priority": models.JobPriorityHigh},
+			bson.M{"created_at": bson.M{"$lt": job.CreatedAt}},
+		}
+	}
+
+	// The queue is shared by every tenant, so the jobs ahead are counted whatever their tenant
+	return r.reporting.CountDocuments(ctx, filter)
+}
+
+// DurationStatsByType aggregates processing durations and SLO breaches of finished jobs per type
+func (r *jobsRepository) DurationStatsByType(ctx context.Context) ([]TypeDurationStats, error) {
+	ctx, done := r.tracker.start(ctx, "durationStatsByType")
+	defer done()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"duration_ms": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":             "$job_type",
+			"finished":        bson.M{"$sum": 1},
+			"breached":        bson.M{"$sum": bson.M{"$cond": bson.A{"$slo_breached", 1, 0}}},
+			"avg_duration_ms": bson.M{"$avg": "$duration_ms"},
+			"max_duration_ms": bson.M{"$max": "$duration_ms"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.reporting.Aggregate(ctx, scopedPipeline(ctx, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var stats []TypeDurationStats
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
 	}
+	return stats, nil
+}
+
+// CountOutcomesSince counts jobs that finished since the given time by terminal status.
+// An empty job type counts jobs of every type.
+func (r *jobsRepository) CountOutcomesSince(ctx context.Context, jobType models.JobType, since time.Time) (map[models.JobStatus]int64, error) {
+	ctx, done := r.tracker.start(ctx, "countOutcomesSince")
+	defer done()
+
+	match := bson.M{"finished_at": bson.M{"$gte": since}}
+	if jobType != "" {
+		match["job_type"] = jobType
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.reporting.Aggregate(ctx, scopedPipeline(ctx, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Status models.JobStatus `bson:"_id"`
+		Count  int64            `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.JobStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *jobsRepository) FailuresByTypeSince(ctx context.Context, since time.Time, limit int) ([]TypeFailures, error) {
+	ctx, done := r.tracker.start(ctx, "failuresByTypeSince")
+	defer done()
+
+	failed := bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.JobStatusFailed}}, 1, 0}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"finished_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$job_type",
+			"finished": bson.M{"$sum": 1},
+			"failed":   bson.M{"$sum": failed},
+		}}},
+		{{Key: "$match", Value: bson.M{"failed": bson.M{"$gt": 0}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "failed", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.reporting.Aggregate(ctx, scopedPipeline(ctx, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	failures := []TypeFailures{}
+	if err := cursor.All(ctx, &failures); err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
+
+func (r *jobsRepository) OldestPending(ctx context.Context) (time.Time, error) {
+	ctx, done := r.tracker.start(ctx, "oldestPending")
+	defer done()
+
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetProjection(bson.M{"created_at": 1})
+
+	var job struct {
+		CreatedAt time.Time `bson:"created_at"`
+	}
+	err := r.reporting.FindOne(ctx, scoped(ctx, bson.M{"status": models.JobStatusPending}), opts).Decode(&job)
+	if err != nil {
+		return time.Time{}, translateError(err)
+	}
+	return job.CreatedAt, nil
+}
+
+// ThroughputSince counts the jobs created, completed and failed since the given time in
+// buckets of the interval, aligned to the Unix epoch. Buckets without jobs are omitted.
+func (r *jobsRepository) ThroughputSince(ctx context.Context, since time.Time, interval time.Duration) ([]ThroughputBucket, error) {
+	ctx, done := r.tracker.start(ctx, "throughputSince")
+	defer done()
+
+	bucketOf := func(field string) bson.M {
+		millis := bson.M{"$toLong": field}
+		return bson.M{"$toDate": bson.M{"$subtract": bson.A{millis, bson.M{"$mod": bson.A{millis, interval.Milliseconds()}}}}}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"created_at": bson.M{"$gte": since}},
+			bson.M{"finished_at": bson.M{"$gte": since}},
+		}}}},
+		{{Key: "$facet", Value: bson.M{
+			"created": bson.A{
+				bson.M{"$match": bson.M{"created_at": bson.M{"$gte": since}}},
+				bson.M{"$group": bson.M{"_id": bucketOf("$created_at"), "count": bson.M{"$sum": 1}}},
+			},
+			"finished": bson.A{
+				bson.M{"$match": bson.M{
+					"finished_at": bson.M{"$gte": since},
+					"status":      bson.M{"$in": bson.A{models.JobStatusCompleted, models.JobStatusFailed}},
+				}},
+				bson.M{"$group": bson.M{
+					"_id":       bucketOf("$finished_at"),
+					"completed": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.JobStatusCompleted}}, 1, 0}}},
+					"failed":    bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.JobStatusFailed}}, 1, 0}}},
+					"durations": bson.M{"$percentile": bson.M{
+						"input":  "$duration_ms",
+						"p":      bson.A{0.5, 0.95},
+						"method": "approximate",
+					}},
+				}},
+			},
+		}}},
+	}
+
+	cursor, err := r.reporting.Aggregate(ctx, scopedPipeline(ctx, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var facets []struct {
+		Created []struct {
+			Start time.Time `bson:"_id"`
+			Count int64     `bson:"count"`
+		} `bson:"created"`
+		Finished []struct {
+			Start     time.Time `bson:"_id"`
+			Completed int64     `bson:"completed"`
+			Failed    int64     `bson:"failed"`
+			Durations []float64 `bson:"durations"`
+		} `bson:"finished"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, err
+	}
+	if len(facets) == 0 {
+		return nil, nil
+	}
+
+	buckets := make(map[time.Time]*ThroughputBucket)
+	bucket := func(start time.Time) *ThroughputBucket {
+		if b, ok := buckets[start]; ok {
+			return b
+		}
+		b := &ThroughputBucket{Start: start}
+		buckets[start] = b
+		return b
+	}
+	for _, row := range facets[0].Created {
+		bucket(row.Start).Created = row.Count
+	}
+	for _, row := range facets[0].Finished {
+		b := bucket(row.Start)
+		b.Completed = row.Completed
+		b.Failed = row.Failed
+		b.DurationPercentiles = row.Durations
+	}
+
+	result := make([]ThroughputBucket, 0, len(buckets))
+	for _, b := range buckets {
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result, nil
+}
+
+// RecentDurationStats returns the rolling duration summaries per job type
+func (r *jobsRepository) RecentDurationStats(ctx context.Context) ([]models.JobTypeStats, error) {
+	ctx, done := r.tracker.start(ctx, "recentDurationStats")
+	defer done()
+
+	cursor, err := r.typeStats.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
-	return jobs, total, nil
+	var stats []models.JobTypeStats
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
 }
 
-// UpdateStatus updates the status of a job
+// UpdateStatus updates the status of a job, or returns ErrNotFound if there is none
 func (r *jobsRepository) UpdateStatus(ctx context.Context, id string, status models.JobStatus) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	ctx, done := r.tracker.start(ctx, "updateStatus")
+	defer done()
+
+	jobID, err := parseJobID(id)
 	if err != nil {
 		return err
 	}
@@ -105,13 +716,21 @@ func (r *jobsRepository) UpdateStatus(ctx context.Context, id string, status mod
 		},
 	}
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
-	return err
+	filter, err := r.byID(ctx, jobID)
+	if err != nil {
+		return translateError(err)
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	return translateUpdateError(result, err)
 }
 
-// UpdateStatusWithRetry updates the status and retry count of a job
+// UpdateStatusWithRetry updates the status and retry count of a job, or returns
+// ErrNotFound if there is none
 func (r *jobsRepository) UpdateStatusWithRetry(ctx context.Context, id string, status models.JobStatus, retryCount int) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	ctx, done := r.tracker.start(ctx, "updateStatusWithRetry")
+	defer done()
+
+	jobID, err := parseJobID(id)
 	if err != nil {
 		return err
 	}
@@ -124,14 +743,1045 @@ func (r *jobsRepository) UpdateStatusWithRetry(ctx context.Context, id string, s
 		},
 	}
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
-	return err
+	filter, err := r.byID(ctx, jobID)
+	if err != nil {
+		return translateError(err)
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	return translateUpdateError(result, err)
 }
 
-// Update updates a job in the database
+// Update replaces a job in the database, or returns ErrNotFound if it does not exist
 func (r *jobsRepository) Update(ctx context.Context, job *models.Job) error {
+	ctx, done := r.tracker.start(ctx, "update")
+	defer done()
+
 	job.UpdatedAt = time.Now()
 
-	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": job.ID}, job)
+	result, err := r.collection.ReplaceOne(ctx, scoped(ctx, bson.M{"tenant": job.Tenant, "_id": job.ID}), job)
+	return translateUpdateError(result, err)
+}
+
+// Prioritize marks a pending job as high priority and records the dispatch ID of the
+// message that will run it. It returns ErrConflict if the job is no longer pending, was already prioritized
+// or, when versions are given, is at none of them.
+func (r *jobsRepository) Prioritize(ctx context.Context, id, dispatchID string, versions []time.Time) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "prioritize")
+	defer done()
+
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := r.byID(ctx, jobID)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+	filter["status"] = models.JobStatusPending
+	filter["priority"] = bson.M{"$ne": models.JobPriorityHigh}
+	withVersions(filter, versions)
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"priority":       models.JobPriorityHigh,
+			"prioritized_at": now,
+			"dispatch_id":    dispatchID,
+			"updated_at":     now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.Job
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+
+	return &job, nil
+}
+
+// Retry returns a failed job with fewer than maxRetries retries to pending,
+// counting the retry and recording the dispatch ID of the message that will
+// run it. It returns ErrConflict if no failed job with retries left, and at
+// one of versions when given, matched.
+func (r *jobsRepository) Retry(ctx context.Context, id string, maxRetries int, dispatchID string, versions []time.Time) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "retry")
+	defer done()
+
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := r.byID(ctx, jobID)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+	filter["status"] = models.JobStatusFailed
+	filter["retry_count"] = bson.M{"$lt": maxRetries}
+	withVersions(filter, versions)
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.JobStatusPending,
+			"dispatch_id": dispatchID,
+			"updated_at":  time.Now(),
+		},
+		"$inc": bson.M{"retry_count": 1},
+		"$unset": bson.M{
+			"error_message": "",
+			"retry_at":      "",
+			"started_at":    "",
+			"finished_at":   "",
+			"duration_ms":   "",
+			"slo_breached":  "",
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.Job
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+
+	return &job, nil
+}
+
+// SetHold holds a job that is not completed. It returns ErrConflict if the job was
+// completed, is already held or, when versions are given, is at none of them.
+func (r *jobsRepository) SetHold(ctx context.Context, id string, hold *models.JobHold, versions []time.Time) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "setHold")
+	defer done()
+
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := r.byID(ctx, jobID)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+	filter["status"] = bson.M{"$ne": models.JobStatusCompleted}
+	filter["hold"] = bson.M{"$exists": false}
+	withVersions(filter, versions)
+	update := bson.M{
+		"$set": bson.M{
+			"hold":       hold,
+			"updated_at": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.Job
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+
+	return &job, nil
+}
+
+// ClearHold releases a held job. It returns ErrConflict if the job is not held or,
+// when versions are given, is at none of them.
+func (r *jobsRepository) ClearHold(ctx context.Context, id string, versions []time.Time) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "clearHold")
+	defer done()
+
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := r.byID(ctx, jobID)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+	filter["hold"] = bson.M{"$exists": true}
+	withVersions(filter, versions)
+	update := bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"hold": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.Job
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+
+	return &job, nil
+}
+
+// afterID restricts a job filter to the jobs whose ID sorts after the given one.
+// String IDs sort before ObjectIDs, and range operators only compare values of
+// the same type, so every ObjectID still follows a string ID.
+func afterID(filter bson.M, after models.JobID) {
+	if after.IsZero() {
+		return
+	}
+	if _, ok := after.ObjectID(); ok {
+		filter["_id"] = bson.M{"$gt": after}
+		return
+	}
+	filter["_id"] = bson.M{"$not": bson.M{"$lte": after}}
+}
+
+// withVersions restricts a job filter to the given versions, the job's last update
+// times, so a conditional update misses a job modified since it was read
+func withVersions(filter bson.M, versions []time.Time) {
+	if versions != nil {
+		filter["updated_at"] = bson.M{"$in": versions}
+	}
+}
+
+// RequestCancellation atomically moves a pending or processing job to cancelling,
+// recording the actor and reason. It returns ErrConflict if no job in a cancellable state, and at
+// one of versions when given, matched.
+func (r *jobsRepository) RequestCancellation(ctx context.Context, id, actor, reason string, versions []time.Time) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "requestCancellation")
+	defer done()
+
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := r.byID(ctx, jobID)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+	filter["status"] = bson.M{"$in": []models.JobStatus{models.JobStatusPending, models.JobStatusProcessing}}
+	withVersions(filter, versions)
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":              models.JobStatusCancelling,
+			"cancelled_by":        actor,
+			"cancellation_reason": reason,
+			"cancel_requested_at": now,
+			"cancel_attempts":     1,
+			"updated_at":          now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.Job
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+
+	return &job, nil
+}
+
+// CompleteCancellation moves a cancelling job to cancelled once a worker has acknowledged
+// the cancellation. A non-empty note is recorded as the job's error message. It returns
+// false if the job was not cancelling.
+func (r *jobsRepository) CompleteCancellation(ctx context.Context, id, note string) (bool, error) {
+	ctx, done := r.tracker.start(ctx, "completeCancellation")
+	defer done()
+
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"status":      models.JobStatusCancelled,
+		"finished_at": now,
+		"updated_at":  now,
+	}
+	if note != "" {
+		set["error_message"] = note
+	}
+
+	filter, err := r.byID(ctx, jobID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	filter["status"] = models.JobStatusCancelling
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// ListUnacknowledgedCancellations retrieves cancelling jobs last updated before the given time
+func (r *jobsRepository) ListUnacknowledgedCancellations(ctx context.Context, before time.Time, limit int) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listUnacknowledgedCancellations")
+	defer done()
+
+	filter := bson.M{
+		"status":     models.JobStatusCancelling,
+		"updated_at": bson.M{"$lt": before},
+	}
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "updated_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// RecordCancellationResend counts another delivery of a job's cancellation message
+func (r *jobsRepository) RecordCancellationResend(ctx context.Context, id models.JobID) error {
+	ctx, done := r.tracker.start(ctx, "recordCancellationResend")
+	defer done()
+
+	filter, err := r.byID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	filter["status"] = models.JobStatusCancelling
+	_, err = r.collection.UpdateOne(ctx, filter, bson.M{
+		"$inc": bson.M{"cancel_attempts": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
+	return err
+}
+
+// ListCancellingSince retrieves the jobs whose cancellation was requested before
+// the given time and is still not completed, the longest stuck first
+func (r *jobsRepository) ListCancellingSince(ctx context.Context, requestedBefore time.Time, limit int) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listCancellingSince")
+	defer done()
+
+	filter := bson.M{
+		"status":              models.JobStatusCancelling,
+		"cancel_requested_at": bson.M{"$lt": requestedBefore},
+	}
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "cancel_requested_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// RevertCancellation moves a cancelling job back to processing, dropping its
+// cancellation request and recording on the job why it was withdrawn. It
+// returns false if the job was not cancelling.
+func (r *jobsRepository) RevertCancellation(ctx context.Context, id models.JobID, reason string) (bool, error) {
+	ctx, done := r.tracker.start(ctx, "revertCancellation")
+	defer done()
+
+	filter, err := r.byID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	filter["status"] = models.JobStatusCancelling
+	now := time.Now()
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{
+			"status":              models.JobStatusProcessing,
+			"cancellation_revert": models.CancellationRevert{Reason: reason, At: now},
+			"updated_at":          now,
+		},
+		"$unset": bson.M{
+			"cancelled_by":        "",
+			"cancellation_reason": "",
+			"cancel_requested_at": "",
+			"cancel_attempts":     "",
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// ReleaseHeld moves the oldest held job to pending and returns it, or ErrNotFound if no job is held
+func (r *jobsRepository) ReleaseHeld(ctx context.Context) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "releaseHeld")
+	defer done()
+
+	filter := bson.M{"status": models.JobStatusHeld}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.JobStatusPending,
+			"updated_at": time.Now(),
+		},
+	}
+	return r.claimFirst(ctx, filter, bson.D{{Key: "created_at", Value: 1}}, update)
+}
+
+// RedispatchStalePending gives the pending job least recently updated before the
+// given time a new dispatch ID and returns it, or ErrNotFound if there is none.
+// Only jobs no attempt has started on are picked: their message was lost, or
+// the worker that received it committed it without running the job. The job
+// is claimed in one update that also moves its updated_at, so concurrent
+// reconcilers never pick the same job and it waits a full threshold before
+// being picked again. Jobs waiting for an automatic retry or their scheduled
+// run are left to the worker's schedulers.
+func (r *jobsRepository) RedispatchStalePending(ctx context.Context, before time.Time, dispatchID string) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "redispatchStalePending")
+	defer done()
+
+	filter := bson.M{
+		"status":       models.JobStatusPending,
+		"retry_at":     bson.M{"$exists": false},
+		"scheduled_at": bson.M{"$exists": false},
+		"hold":         bson.M{"$exists": false},
+		"attempts":     bson.M{"$not": bson.M{"$elemMatch": bson.M{"outcome": models.AttemptOutcomeRunning}}},
+		"updated_at":   bson.M{"$lt": before},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"dispatch_id": dispatchID,
+			"updated_at":  time.Now(),
+		},
+	}
+	return r.claimFirst(ctx, filter, bson.D{{Key: "updated_at", Value: 1}}, update)
+}
+
+// AttachInput links an uploaded input file to a job awaiting it and moves the job
+// to status. It returns ErrConflict if the job is not awaiting input.
+func (r *jobsRepository) AttachInput(ctx context.Context, id string, input *models.JobInput, status models.JobStatus) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "attachInput")
+	defer done()
+
+	jobID, err := parseJobID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := r.byID(ctx, jobID)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+	filter["status"] = models.JobStatusAwaitingInput
+	update := bson.M{
+		"$set": bson.M{
+			"input":      input,
+			"status":     status,
+			"updated_at": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.Job
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+
+	return &job, nil
+}
+
+// ListTerminal retrieves the jobs among ids that have reached a terminal status
+func (r *jobsRepository) ListTerminal(ctx context.Context, ids []models.JobID) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listTerminal")
+	defer done()
+
+	filter := bson.M{
+		"_id":    bson.M{"$in": ids},
+		"status": bson.M{"$in": []models.JobStatus{models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelled}},
+	}
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *jobsRepository) ListProcessing(ctx context.Context, ids []models.JobID) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listProcessing")
+	defer done()
+
+	filter := bson.M{
+		"_id":    bson.M{"$in": ids},
+		"status": models.JobStatusProcessing,
+	}
+	opts := options.Find().SetProjection(bson.M{"name": 1, "job_type": 1, "status": 1, "started_at": 1, "created_at": 1, "updated_at": 1})
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+func (r *jobsRepository) ListUpdatedSince(ctx context.Context, since time.Time, limit int) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listUpdatedSince")
+	defer done()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetProjection(bson.M{"job_type": 1, "status": 1, "updated_at": 1})
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"updated_at": bson.M{"$gte": since}}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// ListChildren retrieves the child jobs of a parent in the order they were spawned
+func (r *jobsRepository) ListChildren(ctx context.Context, parentID models.JobID, limit int) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listChildren")
+	defer done()
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "child_index", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"parent_id": parentID}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []models.Job{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// CountChildrenByStatus counts the child jobs of a parent by status
+func (r *jobsRepository) CountChildrenByStatus(ctx context.Context, parentID models.JobID) (map[models.JobStatus]int, error) {
+	ctx, done := r.tracker.start(ctx, "countChildrenByStatus")
+	defer done()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"parent_id": parentID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, scopedPipeline(ctx, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Status models.JobStatus `bson:"_id"`
+		Count  int              `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.JobStatus]int, len(results))
+	for _, result := range results {
+		counts[result.Status] = result.Count
+	}
+	return counts, nil
+}
+
+// ListAwaitingChildren retrieves parent jobs waiting for their children in ID
+// order, starting after the given ID so callers can page through all of them
+func (r *jobsRepository) ListAwaitingChildren(ctx context.Context, after models.JobID, limit int) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listAwaitingChildren")
+	defer done()
+
+	filter := bson.M{"status": models.JobStatusAwaitingChildren}
+	afterID(filter, after)
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// CompleteFanIn moves a parent waiting for its children to its final status
+// with the aggregated child outcomes. It reports false if the parent was no
+// longer waiting, e.g. because it was cancelled meanwhile.
+func (r *jobsRepository) CompleteFanIn(ctx context.Context, id models.JobID, status models.JobStatus, children models.JobChildren, errorMessage string) (bool, error) {
+	ctx, done := r.tracker.start(ctx, "completeFanIn")
+	defer done()
+
+	now := time.Now()
+	set := bson.M{
+		"status":      status,
+		"children":    children,
+		"finished_at": now,
+		"updated_at":  now,
+	}
+	if errorMessage != "" {
+		set["error_message"] = errorMessage
+	}
+
+	filter, err := r.byID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	filter["status"] = models.JobStatusAwaitingChildren
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+// AssignGroup adds the given jobs of tenant, the group's tenant, to a group and
+// returns how many were added. Jobs of other tenants are left out.
+// Jobs already in a group are left where they are.
+func (r *jobsRepository) AssignGroup(ctx context.Context, groupID primitive.ObjectID, tenant string, ids []models.JobID) (int64, error) {
+	ctx, done := r.tracker.start(ctx, "assignGroup")
+	defer done()
+
+	result, err := r.collection.UpdateMany(ctx,
+		scoped(ctx, bson.M{"_id": bson.M{"$in": ids}, "tenant": tenant, "group_id": bson.M{"$exists": false}}),
+		bson.M{"$set": bson.M{"group_id": groupID, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+// CountByStatusInGroup counts the jobs of a group by status
+func (r *jobsRepository) CountByStatusInGroup(ctx context.Context, groupID primitive.ObjectID) (map[models.JobStatus]int64, error) {
+	ctx, done := r.tracker.start(ctx, "countByStatusInGroup")
+	defer done()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"group_id": groupID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, scopedPipeline(ctx, pipeline))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Status models.JobStatus `bson:"_id"`
+		Count  int64            `bson:"count"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.JobStatus]int64, len(results))
+	for _, result := range results {
+		counts[result.Status] = result.Count
+	}
+	return counts, nil
+}
+
+// ListCancellableInGroup retrieves the pending and processing jobs of a group in
+// ID order, starting after the given ID so callers can page through all of them
+func (r *jobsRepository) ListCancellableInGroup(ctx context.Context, groupID primitive.ObjectID, after models.JobID, limit int) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listCancellableInGroup")
+	defer done()
+
+	filter := bson.M{
+		"group_id": groupID,
+		"status":   bson.M{"$in": []models.JobStatus{models.JobStatusPending, models.JobStatusProcessing}},
+	}
+	afterID(filter, after)
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// CountForRequeue counts the jobs matching a requeue query
+func (r *jobsRepository) CountForRequeue(ctx context.Context, query RequeueQuery) (int64, error) {
+	ctx, done := r.tracker.start(ctx, "countForRequeue")
+	defer done()
+
+	return r.collection.CountDocuments(ctx, scoped(ctx, query.filter()))
+}
+
+// ListForRequeue retrieves the jobs matching a requeue query in ID order, after
+// the given ID, so callers can page through them without revisiting the ones
+// already requeued
+func (r *jobsRepository) ListForRequeue(ctx context.Context, query RequeueQuery, after models.JobID, limit int) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listForRequeue")
+	defer done()
+
+	filter := query.filter()
+	afterID(filter, after)
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// CountForRetention counts the jobs matching a retention query
+func (r *jobsRepository) CountForRetention(ctx context.Context, query RetentionQuery) (int64, error) {
+	ctx, done := r.tracker.start(ctx, "countForRetention")
+	defer done()
+
+	return r.collection.CountDocuments(ctx, scoped(ctx, query.filter()))
+}
+
+// ListForRetention retrieves the jobs matching a retention query in ID order,
+// after the given ID
+func (r *jobsRepository) ListForRetention(ctx context.Context, query RetentionQuery, after models.JobID, limit int) ([]models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "listForRetention")
+	defer done()
+
+	filter := query.filter()
+	afterID(filter, after)
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var jobs []models.Job
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// DeleteForRetention deletes the listed jobs still matching a retention query
+func (r *jobsRepository) DeleteForRetention(ctx context.Context, query RetentionQuery, ids []models.JobID) ([]models.JobID, error) {
+	ctx, done := r.tracker.start(ctx, "deleteForRetention")
+	defer done()
+
+	// Jobs retried or held since they were listed no longer match and are kept
+	filter := scoped(ctx, query.filter())
+	filter["_id"] = bson.M{"$in": ids}
+	if _, err := r.collection.DeleteMany(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"_id": bson.M{"$in": ids}}), options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var kept []struct {
+		ID models.JobID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &kept); err != nil {
+		return nil, err
+	}
+	remaining := make(map[models.JobID]bool, len(kept))
+	for _, job := range kept {
+		remaining[job.ID] = true
+	}
+
+	deleted := make([]models.JobID, 0, len(ids))
+	for _, id := range ids {
+		if !remaining[id] {
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, nil
+}
+
+// Requeue resets a job in one of statuses to pending with a new dispatch ID,
+// clearing the outcome of its previous run. It returns ErrConflict if the job's status
+// changed or it was held meanwhile. Attempts, checkpoints and workflow steps
+// are kept.
+func (r *jobsRepository) Requeue(ctx context.Context, id models.JobID, statuses []models.JobStatus, dispatchID string) (*models.Job, error) {
+	ctx, done := r.tracker.start(ctx, "requeue")
+	defer done()
+
+	filter, err := r.byID(ctx, id)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+	filter["status"] = bson.M{"$in": statuses}
+	filter["hold"] = bson.M{"$exists": false}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.JobStatusPending,
+			"dispatch_id": dispatchID,
+			"updated_at":  time.Now(),
+		},
+		"$unset": bson.M{
+			"error_message":       "",
+			"retry_at":            "",
+			"scheduled_at":        "",
+			"cancelled_by":        "",
+			"cancellation_reason": "",
+			"cancel_requested_at": "",
+			"cancel_attempts":     "",
+			"started_at":          "",
+			"finished_at":         "",
+			"duration_ms":         "",
+			"slo_breached":        "",
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.Job
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		return nil, translateConditionalError(err)
+	}
+
+	return &job, nil
+}
+
+// ClaimSubmission records key as submitted by jobID for window, in a single
+// atomic upsert of the claim whose _id, unique, is the key. If the key is
+// already held by a live claim, the job holding it is returned and nothing is
+// recorded; otherwise the returned ID is zero.
+func (r *jobsRepository) ClaimSubmission(ctx context.Context, key string, jobID models.JobID, window time.Duration) (models.JobID, error) {
+	ctx, done := r.tracker.start(ctx, "claimSubmission")
+	defer done()
+
+	now := time.Now()
+	// An absent or expired claim is taken over, a live one is left as it is.
+	// Both fields are computed from the claim as it was before the update.
+	expired := bson.M{"$lte": bson.A{"$expires_at", now}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"job_id":     bson.M{"$cond": bson.A{expired, bson.M{"$literal": jobID}, "$job_id"}},
+			"expires_at": bson.M{"$cond": bson.A{expired, now.Add(window), "$expires_at"}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	var previous struct {
+		JobID     models.JobID `bson:"job_id"`
+		ExpiresAt time.Time    `bson:"expires_at"`
+	}
+	err := r.submissions.FindOneAndUpdate(ctx, bson.M{"_id": key}, update, opts).Decode(&previous)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent submission inserted the claim first and holds it
+		err = r.submissions.FindOne(ctx, bson.M{"_id": key}).Decode(&previous)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", ErrConflict
+		}
+		if err != nil {
+			return "", err
+		}
+		return previous.JobID, nil
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !previous.ExpiresAt.After(now) {
+		return "", nil
+	}
+	return previous.JobID, nil
+}
+
+// ReleaseSubmission drops the claim on key if it is still held by jobID
+func (r *jobsRepository) ReleaseSubmission(ctx context.Context, key string, jobID models.JobID) error {
+	ctx, done := r.tracker.start(ctx, "releaseSubmission")
+	defer done()
+
+	_, err := r.submissions.DeleteOne(ctx, bson.M{"_id": key, "job_id": jobID})
+	return err
+}
+
+// ClaimJobID reserves a client-supplied job ID for tenant, or returns
+// ErrConflict if a job of another tenant holds it. A sharded jobs collection
+// only keeps _id unique per shard, so the IDs are claimed in job_ids, whose
+// _id is the job ID. Jobs created before the claims were recorded are looked
+// up as well; a claim is kept once made, so the ID is never reused.
+func (r *jobsRepository) ClaimJobID(ctx context.Context, id models.JobID, tenant string) error {
+	ctx, done := r.tracker.start(ctx, "claimJobID")
+	defer done()
+
+	var claim struct {
+		Tenant string `bson:"tenant"`
+	}
+	err := r.ids.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$setOnInsert": bson.M{"tenant": tenant, "created_at": time.Now()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&claim)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent claim inserted the ID first
+		err = r.ids.FindOne(ctx, bson.M{"_id": id}).Decode(&claim)
+	}
+	if err != nil {
+		return translateError(err)
+	}
+	if claim.Tenant != tenant {
+		return ErrConflict
+	}
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id, "tenant": bson.M{"$ne": tenant}}, options.Count().SetLimit(1))
+	if err != nil {
+		return translateError(err)
+	}
+	if count > 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// EnsureIndexes creates the indexes the jobs queries rely on
+func (r *jobsRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	if err := r.dropUniqueChildIndex(ctx); err != nil {
+		return err
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Full-text search over the fields operators search by, weighted towards the name
+			Keys: bson.D{{Key: "name", Value: "text"}, {Key: "error_message", Value: "text"}},
+			Options: options.Index().
+				SetName("jobs_text").
+				SetWeights(bson.D{{Key: "name", Value: 10}, {Key: "error_message", Value: 5}}),
+		},
+		{
+			// Queue position lookups count the pending jobs of a type by age
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "job_type", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("jobs_status_type_created"),
+		},
+		{
+			// Lists filtered by status, such as the active jobs, are ordered by age
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("jobs_status_created"),
+		},
+		{
+			// Children are listed and counted by parent, in position order
+			Keys:    bson.D{{Key: "parent_id", Value: 1}, {Key: "child_index", Value: 1}},
+			Options: options.Index().SetName("jobs_parent_child").SetSparse(true),
+		},
+		{
+			// Group progress counts a group's jobs by status; only grouped jobs carry group_id
+			Keys:    bson.D{{Key: "group_id", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("jobs_group_status").SetSparse(true),
+		},
+		{
+			// The pending reconciler looks for pending jobs that have not changed for a while
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}},
+			Options: options.Index().SetName("jobs_status_updated"),
+		},
+		{
+			// Workers poll for automatic retries that are due; only jobs waiting for one carry retry_at
+			Keys:    bson.D{{Key: "retry_at", Value: 1}},
+			Options: options.Index().SetName("jobs_retry_at").SetSparse(true),
+		},
+		{
+			// Workers poll for scheduled runs that are due; only jobs waiting for one carry scheduled_at
+			Keys:    bson.D{{Key: "scheduled_at", Value: 1}},
+			Options: options.Index().SetName("jobs_scheduled_at").SetSparse(true),
+		},
+		{
+			// The job summaries catch up on changes made by workers by when jobs were last updated
+			Keys:    bson.D{{Key: "updated_at", Value: 1}},
+			Options: options.Index().SetName("jobs_updated"),
+		},
+		{
+			// Workers look for unfinished jobs past their deadline; only jobs with a deadline are indexed
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "deadline_at", Value: 1}},
+			Options: options.Index().
+				SetName("jobs_status_deadline").
+				SetPartialFilterExpression(bson.M{"deadline_at": bson.M{"$exists": true}}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Expired submission claims are ignored by ClaimSubmission; the TTL index only keeps the collection small
+	_, err = r.submissions.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("job_submissions_ttl").SetExpireAfterSeconds(0),
+	})
 	return err
 }
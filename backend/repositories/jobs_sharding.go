@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobsShardKey is the shard key of the jobs collection in sharded deployments.
+// Jobs are partitioned by tenant, so the queries of a tenant are routed to the
+// shards holding its jobs, and by hashed ID within a tenant, so a tenant
+// creating millions of jobs is spread over shards instead of appending to the
+// last chunk of its range.
+var JobsShardKey = bson.D{{Key: "tenant", Value: 1}, {Key: "_id", Value: "hashed"}}
+
+// tenantCacheSize is the number of job tenants remembered to route single-job writes
+const tenantCacheSize = 100000
+
+// tenantCache remembers the tenant of jobs by ID. A job never changes tenant,
+// so entries are never stale; the least recently used are evicted once full.
+type tenantCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[models.JobID]*list.Element
+	order   *list.List
+}
+
+type tenantEntry struct {
+	id     models.JobID
+	tenant string
+}
+
+func newTenantCache(size int) *tenantCache {
+	return &tenantCache{
+		size:    size,
+		entries: make(map[models.JobID]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *tenantCache) get(id models.JobID) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*tenantEntry).tenant, true
+}
+
+func (c *tenantCache) put(id models.JobID, tenant string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[id]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[id] = c.order.PushFront(&tenantEntry{id: id, tenant: tenant})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tenantEntry).id)
+	}
+}
+
+// remember records the tenant of jobs read or written through the repository
+func (r *jobsRepository) remember(jobs ...models.Job) {
+	for _, job := range jobs {
+		r.tenants.put(job.ID, job.Tenant)
+	}
+}
+
+// byID returns a filter matching the job with the given ID by its full shard
+// key, so writes to a single job are routed to the one shard holding it. The
+// tenant of a job not seen by this repository yet is read first, unless ctx is
+// scoped to a tenant; the driver's mongo.ErrNoDocuments is returned if there is
+// no such job, or it belongs to a tenant out of scope.
+func (r *jobsRepository) byID(ctx context.Context, id models.JobID) (bson.M, error) {
+	if tenant, ok := r.tenants.get(id); ok {
+		if !inScope(ctx, tenant) {
+			return nil, mongo.ErrNoDocuments
+		}
+		return bson.M{"tenant": tenant, "_id": id}, nil
+	}
+	if tenant, ok := TenantScope(ctx); ok {
+		return bson.M{"tenant": tenant, "_id": id}, nil
+	}
+
+	var owner struct {
+		Tenant string `bson:"tenant"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"tenant": 1})
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}, opts).Decode(&owner); err != nil {
+		return nil, err
+	}
+	r.tenants.put(id, owner.Tenant)
+	return bson.M{"tenant": owner.Tenant, "_id": id}, nil
+}
+
+// claimFirst applies update to the first job matching filter in sort order and
+// returns it after the update, or ErrNotFound if no job matches. A sharded
+// collection only runs findAndModify on a filter with the full shard key, so
+// the job is looked up first and then claimed by its key; a job claimed by
+// someone else in between is skipped for the next one.
+func (r *jobsRepository) claimFirst(ctx context.Context, filter bson.M, sort bson.D, update bson.M) (*models.Job, error) {
+	for {
+		var candidate models.Job
+		opts := options.FindOne().SetSort(sort).SetProjection(bson.M{"tenant": 1})
+		if err := r.collection.FindOne(ctx, scoped(ctx, filter), opts).Decode(&candidate); err != nil {
+			return nil, translateError(err)
+		}
+
+		claim := bson.M{"tenant": candidate.Tenant, "_id": candidate.ID}
+		for field, condition := range filter {
+			claim[field] = condition
+		}
+		var job models.Job
+		err := r.collection.FindOneAndUpdate(ctx, claim, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&job)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			continue
+		}
+		if err != nil {
+			return nil, translateError(err)
+		}
+		r.remember(job)
+		return &job, nil
+	}
+}
+
+// dropUniqueChildIndex drops the unique index that kept children unique by
+// parent and position. A sharded collection only enforces unique indexes
+// prefixed by its shard key, so workers claim the positions in job_children
+// instead, and the index is recreated without the constraint.
+func (r *jobsRepository) dropUniqueChildIndex(ctx context.Context) error {
+	cursor, err := r.collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	var indexes []struct {
+		Name   string `bson:"name"`
+		Unique bool   `bson:"unique"`
+	}
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return err
+	}
+
+	for _, index := range indexes {
+		if index.Name == "jobs_parent_child" && index.Unique {
+			_, err := r.collection.Indexes().DropOne(ctx, index.Name)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// kafkaFailoverID is the ID of the single document holding the failover state.
+// Workers read the same document.
+const kafkaFailoverID = "fleet"
+
+// KafkaFailoverRepository interface defines the methods for the Kafka failover switch
+type KafkaFailoverRepository interface {
+	// Get returns the current state, the primary region if it was never switched
+	Get(ctx context.Context) (*models.KafkaFailover, error)
+	Set(ctx context.Context, failover *models.KafkaFailover) error
+}
+
+type kafkaFailoverRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewKafkaFailoverRepository creates a new Kafka failover repository
+func NewKafkaFailoverRepository(db *mongo.Database, config Config) KafkaFailoverRepository {
+	return &kafkaFailoverRepository{
+		collection: db.Collection("kafka_failover"),
+		tracker: queryTracker{
+			collection: "kafka_failover",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+func (r *kafkaFailoverRepository) Get(ctx context.Context) (*models.KafkaFailover, error) {
+	ctx, done := r.tracker.start(ctx, "get")
+	defer done()
+
+	var failover models.KafkaFailover
+	err := r.collection.FindOne(ctx, bson.M{"_id": kafkaFailoverID}).Decode(&failover)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &models.KafkaFailover{Region: models.KafkaRegionPrimary}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &failover, nil
+}
+
+func (r *kafkaFailoverRepository) Set(ctx context.Context, failover *models.KafkaFailover) error {
+	ctx, done := r.tracker.start(ctx, "set")
+	defer done()
+
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": kafkaFailoverID}, failover, options.Replace().SetUpsert(true))
+	return translateError(err)
+}
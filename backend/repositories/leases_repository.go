@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LeasesRepository interface defines the methods for leader lease data access
+type LeasesRepository interface {
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string) error
+}
+
+type leasesRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewLeasesRepository creates a new leases repository
+func NewLeasesRepository(db *mongo.Database, config Config) LeasesRepository {
+	return &leasesRepository{
+		collection: db.Collection("leases"),
+		tracker: queryTracker{
+			collection: "leases",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// Acquire takes or renews the named lease for holder until ttl from now.
+// It reports false when another holder has an unexpired lease.
+func (r *leasesRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	ctx, done := r.tracker.start(ctx, "acquire")
+	defer done()
+
+	now := time.Now()
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"holder": holder},
+			bson.M{"expires_at": bson.M{"$lte": now}},
+		},
+	}
+	// A pipeline update keeps acquired_at while the same holder renews the lease
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"acquired_at": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$holder", holder}}, "$acquired_at", now}},
+			"holder":      holder,
+			"renewed_at":  now,
+			"expires_at":  now.Add(ttl),
+		}},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// The lease exists and is held by someone else, so the upsert collided with it
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Release expires the named lease if holder still holds it, so another holder can take it at once
+func (r *leasesRepository) Release(ctx context.Context, name, holder string) error {
+	ctx, done := r.tracker.start(ctx, "release")
+	defer done()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": name, "holder": holder},
+		bson.M{"$set": bson.M{"expires_at": time.Now()}},
+	)
+	return err
+}
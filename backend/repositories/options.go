@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Config holds repository-level query settings
+type Config struct {
+	// QueryTimeout bounds every repository operation; zero disables the limit
+	QueryTimeout time.Duration
+	// ReportingReadPreference is used for list and reporting queries, e.g. "secondaryPreferred".
+	// Point reads such as GetByID always go to the primary.
+	ReportingReadPreference string
+	// ReadConcern is the read concern level for all reads, e.g. "majority" or "local"
+	ReadConcern string
+	// SlowQueryThreshold logs operations slower than this duration; zero disables logging
+	SlowQueryThreshold time.Duration
+}
+
+// DefaultConfig returns the repository settings used when none are configured
+func DefaultConfig() Config {
+	return Config{
+		QueryTimeout:            10 * time.Second,
+		ReportingReadPreference: "primary",
+		SlowQueryThreshold:      500 * time.Millisecond,
+	}
+}
+
+// collectionOptions builds the collection options for the given read preference mode
+func (c Config) collectionOptions(mode string) (*options.CollectionOptions, error) {
+	opts := options.Collection()
+
+	if mode != "" {
+		parsed, err := readpref.ModeFromString(mode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid read preference %q: %w", mode, err)
+		}
+		pref, err := readpref.New(parsed)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetReadPreference(pref)
+	}
+
+	switch c.ReadConcern {
+	case "":
+	case "local":
+		opts.SetReadConcern(readconcern.Local())
+	case "majority":
+		opts.SetReadConcern(readconcern.Majority())
+	case "available":
+		opts.SetReadConcern(readconcern.Available())
+	case "linearizable":
+		opts.SetReadConcern(readconcern.Linearizable())
+	default:
+		return nil, fmt.Errorf("invalid read concern %q", c.ReadConcern)
+	}
+
+	return opts, nil
+}
+
+// queryTracker applies per-operation timeouts and logs slow queries
+type queryTracker struct {
+	collection string
+	timeout    time.Duration
+	slow       time.Duration
+}
+
+// start derives the operation context and returns a function that must be
+// called when the operation finishes
+func (t queryTracker) start(ctx context.Context, operation string) (context.Context, func()) {
+	started := time.Now()
+	cancel := func() {}
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+
+	return ctx, func() {
+		cancel()
+		if elapsed := time.Since(started); t.slow > 0 && elapsed >= t.slow {
+			log.Printf("Slow query: %s.%s took %s", t.collection, operation, elapsed)
+		}
+	}
+}
+
+// newCollections returns the primary collection handle and the handle used for reporting reads
+func newCollections(db *mongo.Database, name string, config Config) (*mongo.Collection, *mongo.Collection, error) {
+	primaryOpts, err := config.collectionOptions("primary")
+	if err != nil {
+		return nil, nil, err
+	}
+	reportingOpts, err := config.collectionOptions(config.ReportingReadPreference)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.Collection(name, primaryOpts), db.Collection(name, reportingOpts), nil
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OutboxRepository interface defines the methods for outbox message data access
+type OutboxRepository interface {
+	Enqueue(ctx context.Context, message *models.OutboxMessage) error
+	ListPending(ctx context.Context, limit int) ([]models.OutboxMessage, error)
+	CountPending(ctx context.Context) (int64, error)
+	MarkPublished(ctx context.Context, ids []primitive.ObjectID) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, errorMessage string) error
+}
+
+type outboxRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *mongo.Database, config Config) OutboxRepository {
+	return &outboxRepository{
+		collection: db.Collection("outbox"),
+		tracker: queryTracker{
+			collection: "outbox",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// Enqueue stores a message for later delivery
+func (r *outboxRepository) Enqueue(ctx context.Context, message *models.OutboxMessage) error {
+	ctx, done := r.tracker.start(ctx, "enqueue")
+	defer done()
+
+	message.ID = primitive.NewObjectID()
+	message.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, message)
+	return translateError(err)
+}
+
+// ListPending retrieves the oldest unpublished messages
+func (r *outboxRepository) ListPending(ctx context.Context, limit int) ([]models.OutboxMessage, error) {
+	ctx, done := r.tracker.start(ctx, "listPending")
+	defer done()
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"published_at": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []models.OutboxMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// CountPending counts unpublished messages
+func (r *outboxRepository) CountPending(ctx context.Context) (int64, error) {
+	ctx, done := r.tracker.start(ctx, "countPending")
+	defer done()
+
+	return r.collection.CountDocuments(ctx, bson.M{"published_at": bson.M{"$exists": false}})
+}
+
+// MarkPublished marks messages as delivered
+func (r *outboxRepository) MarkPublished(ctx context.Context, ids []primitive.ObjectID) error {
+	ctx, done := r.tracker.start(ctx, "markPublished")
+	defer done()
+
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		bson.M{"$set": bson.M{"published_at": time.Now()}},
+	)
+	return err
+}
+
+// MarkFailed records a failed delivery attempt
+func (r *outboxRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, errorMessage string) error {
+	ctx, done := r.tracker.start(ctx, "markFailed")
+	defer done()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$inc": bson.M{"attempts": 1},
+			"$set": bson.M{"last_error": errorMessage},
+		},
+	)
+	return err
+}
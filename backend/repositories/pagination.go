@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrInvalidCursor is returned when a page cursor was not issued by findPage
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// PageQuery selects one page of a collection ordered by a single field.
+// Pages are read with a cursor rather than skipped over, so deep pages cost
+// the same as the first and documents inserted meanwhile do not shift them.
+type PageQuery struct {
+	Filter bson.M
+	// SortField orders the documents; ties are broken by _id in the same direction
+	SortField     string
+	SortAscending bool
+	// Cursor is the NextCursor of the previous page, empty for the first page
+	Cursor string
+	Limit  int
+}
+
+// Page holds the documents of one page and the cursor of the next
+type Page[T any] struct {
+	Items []T
+	// NextCursor is empty on the last page
+	NextCursor string
+}
+
+// pageCursor is the position after the last document of a page, encoded into the cursor
+type pageCursor struct {
+	Value bson.RawValue `bson:"v"`
+	ID    bson.RawValue `bson:"id"`
+}
+
+func encodeCursor(value, id bson.RawValue) (string, error) {
+	data, err := bson.Marshal(pageCursor{Value: value, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var position pageCursor
+	if err := bson.Unmarshal(data, &position); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &position, nil
+}
+
+// findPage reads the page of collection selected by query. One extra document
+// is fetched to determine whether another page exists.
+func findPage[T any](ctx context.Context, collection *mongo.Collection, query PageQuery) (*Page[T], error) {
+	direction, after := -1, "$lt"
+	if query.SortAscending {
+		direction, after = 1, "$gt"
+	}
+
+	filter := query.Filter
+	if filter == nil {
+		filter = bson.M{}
+	}
+	if query.Cursor != "" {
+		position, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{
+			filter,
+			bson.M{"$or": bson.A{
+				bson.M{query.SortField: bson.M{after: position.Value}},
+				bson.M{query.SortField: position.Value, "_id": bson.M{after: position.ID}},
+			}},
+		}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: query.SortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(query.Limit + 1))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	page := &Page[T]{Items: []T{}}
+	var last bson.Raw
+	for cursor.Next(ctx) {
+		if len(page.Items) == query.Limit {
+			next, err := encodeCursor(last.Lookup(query.SortField), last.Lookup("_id"))
+			if err != nil {
+				return nil, err
+			}
+			page.NextCursor = next
+			break
+		}
+
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, item)
+		last = append(last[:0], cursor.Current...)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PoisonMessagesRepository interface defines the methods for poison message data access
+type PoisonMessagesRepository interface {
+	Record(ctx context.Context, message *models.PoisonMessage) error
+	List(ctx context.Context, topic, cursor string, limit int) (*Page[models.PoisonMessage], error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type poisonMessagesRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewPoisonMessagesRepository creates a new poison messages repository
+func NewPoisonMessagesRepository(db *mongo.Database, config Config) PoisonMessagesRepository {
+	return &poisonMessagesRepository{
+		collection: db.Collection("poison_messages"),
+		tracker: queryTracker{
+			collection: "poison_messages",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// Record stores a message that failed to decode. A message delivered again to
+// the same consumer group updates its existing record and increments its count.
+func (r *poisonMessagesRepository) Record(ctx context.Context, message *models.PoisonMessage) error {
+	ctx, done := r.tracker.start(ctx, "record")
+	defer done()
+
+	now := time.Now()
+	filter := bson.M{
+		"topic":     message.Topic,
+		"partition": message.Partition,
+		"offset":    message.Offset,
+		"consumer":  message.Consumer,
+	}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"key":           message.Key,
+			"payload":       message.Payload,
+			"truncated":     message.Truncated,
+			"first_seen_at": now,
+		},
+		"$set": bson.M{
+			"source":       message.Source,
+			"error":        message.Error,
+			"last_seen_at": now,
+		},
+		"$inc": bson.M{"count": 1},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// List retrieves a page of poison messages, most recently seen first, optionally of one topic
+func (r *poisonMessagesRepository) List(ctx context.Context, topic, cursor string, limit int) (*Page[models.PoisonMessage], error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	filter := bson.M{}
+	if topic != "" {
+		filter["topic"] = topic
+	}
+
+	return findPage[models.PoisonMessage](ctx, r.collection, PageQuery{
+		Filter:    filter,
+		SortField: "last_seen_at",
+		Cursor:    cursor,
+		Limit:     limit,
+	})
+}
+
+// EnsureIndexes creates the indexes used to record and list poison messages
+func (r *poisonMessagesRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// A message is recorded once per consumer group
+			Keys: bson.D{
+				{Key: "topic", Value: 1},
+				{Key: "partition", Value: 1},
+				{Key: "offset", Value: 1},
+				{Key: "consumer", Value: 1},
+			},
+			Options: options.Index().SetName("poison_messages_message").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "last_seen_at", Value: -1}},
+			Options: options.Index().SetName("poison_messages_last_seen"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// quotaRetention is how long counters are kept after their period ends
+const quotaRetention = 7 * 24 * time.Hour
+
+// QuotasRepository interface defines the methods for job creation quota counters
+type QuotasRepository interface {
+	// Consume counts one job against a tenant's window if fewer than limit were
+	// counted so far. It returns false, leaving the counter unchanged, if the
+	// window is used up. A limit of zero or less counts without limiting.
+	Consume(ctx context.Context, tenant string, window models.QuotaWindow, limit int64) (bool, error)
+	// Refund takes back a job counted against a tenant's window
+	Refund(ctx context.Context, tenant string, window models.QuotaWindow) error
+	// Used returns the number of jobs counted against a tenant's window
+	Used(ctx context.Context, tenant string, window models.QuotaWindow) (int64, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type quotasRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewQuotasRepository creates a new quotas repository
+func NewQuotasRepository(db *mongo.Database, config Config) QuotasRepository {
+	return &quotasRepository{
+		collection: db.Collection("job_quotas"),
+		tracker: queryTracker{
+			collection: "job_quotas",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// quotaCounterID identifies the counter of a tenant's window
+func quotaCounterID(tenant string, window models.QuotaWindow) string {
+	return tenant + "|" + string(window.Period) + "|" + window.Key
+}
+
+func (r *quotasRepository) Consume(ctx context.Context, tenant string, window models.QuotaWindow, limit int64) (bool, error) {
+	ctx, done := r.tracker.start(ctx, "consume")
+	defer done()
+
+	filter := bson.M{"_id": quotaCounterID(tenant, window)}
+	if limit > 0 {
+		filter["count"] = bson.M{"$lt": limit}
+	}
+	update := bson.M{
+		"$inc": bson.M{"count": 1},
+		"$setOnInsert": bson.M{
+			"tenant":     tenant,
+			"period":     window.Period,
+			"key":        window.Key,
+			"expires_at": window.ResetsAt.Add(quotaRetention),
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// The counter exists and is at its limit, so the upsert collided with it
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *quotasRepository) Refund(ctx context.Context, tenant string, window models.QuotaWindow) error {
+	ctx, done := r.tracker.start(ctx, "refund")
+	defer done()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": quotaCounterID(tenant, window), "count": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"count": -1}},
+	)
+	return err
+}
+
+func (r *quotasRepository) Used(ctx context.Context, tenant string, window models.QuotaWindow) (int64, error) {
+	ctx, done := r.tracker.start(ctx, "used")
+	defer done()
+
+	var counter struct {
+		Count int64 `bson:"count"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"_id": quotaCounterID(tenant, window)}).Decode(&counter)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return counter.Count, nil
+}
+
+// EnsureIndexes creates the TTL index that removes counters of past periods
+func (r *quotasRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetName("job_quotas_expires").SetExpireAfterSeconds(0),
+	})
+	return err
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// tenantNotificationsCollection holds the notification settings of each tenant,
+// read by workers when a job finishes
+const tenantNotificationsCollection = "tenant_notifications"
+
+// TenantNotificationsRepository interface defines the methods for tenant notification settings
+type TenantNotificationsRepository interface {
+	// Get retrieves the settings of a tenant, or returns ErrNotFound if it has none
+	Get(ctx context.Context, tenant string) (*models.TenantNotifications, error)
+	// Put stores the settings of a tenant, replacing earlier ones
+	Put(ctx context.Context, settings *models.TenantNotifications) error
+	// Delete removes the settings of a tenant and reports whether it had any
+	Delete(ctx context.Context, tenant string) (bool, error)
+}
+
+type tenantNotificationsRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewTenantNotificationsRepository creates a new tenant notifications repository
+func NewTenantNotificationsRepository(db *mongo.Database, config Config) TenantNotificationsRepository {
+	return &tenantNotificationsRepository{
+		collection: db.Collection(tenantNotificationsCollection),
+		tracker: queryTracker{
+			collection: tenantNotificationsCollection,
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+func (r *tenantNotificationsRepository) Get(ctx context.Context, tenant string) (*models.TenantNotifications, error) {
+	ctx, done := r.tracker.start(ctx, "get")
+	defer done()
+
+	var settings models.TenantNotifications
+	if err := r.collection.FindOne(ctx, bson.M{"_id": tenant}).Decode(&settings); err != nil {
+		return nil, translateError(err)
+	}
+	return &settings, nil
+}
+
+func (r *tenantNotificationsRepository) Put(ctx context.Context, settings *models.TenantNotifications) error {
+	ctx, done := r.tracker.start(ctx, "put")
+	defer done()
+
+	settings.UpdatedAt = time.Now()
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": settings.Tenant}, settings, options.Replace().SetUpsert(true))
+	return translateError(err)
+}
+
+func (r *tenantNotificationsRepository) Delete(ctx context.Context, tenant string) (bool, error) {
+	ctx, done := r.tracker.start(ctx, "delete")
+	defer done()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": tenant})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type tenantScopeKey struct{}
+
+// WithTenantScope returns a copy of ctx restricting the jobs read and written
+// through it to those of tenant, which may be empty for the jobs created
+// without one. Jobs of other tenants are not found. Contexts without a scope,
+// such as those of background components, reach the jobs of every tenant.
+func WithTenantScope(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantScopeKey{}, tenant)
+}
+
+// TenantScope returns the tenant the jobs reached through ctx are restricted
+// to, and false if they are not restricted
+func TenantScope(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantScopeKey{}).(string)
+	return tenant, ok
+}
+
+// scoped restricts a filter over jobs, or documents carrying the tenant of
+// their job, to the tenant scope of ctx. A filter already naming another
+// tenant matches nothing.
+func scoped(ctx context.Context, filter bson.M) bson.M {
+	tenant, ok := TenantScope(ctx)
+	if !ok {
+		return filter
+	}
+	if existing, has := filter["tenant"]; has && existing != tenant {
+		return bson.M{"$and": bson.A{filter, bson.M{"tenant": tenant}}}
+	}
+	filter["tenant"] = tenant
+	return filter
+}
+
+// scopedPipeline starts an aggregation over jobs with a match on the tenant
+// scope of ctx
+func scopedPipeline(ctx context.Context, pipeline mongo.Pipeline) mongo.Pipeline {
+	tenant, ok := TenantScope(ctx)
+	if !ok {
+		return pipeline
+	}
+	match := bson.D{{Key: "$match", Value: bson.M{"tenant": tenant}}}
+	return append(mongo.Pipeline{match}, pipeline...)
+}
+
+// inScope reports whether a job of tenant may be reached through ctx
+func inScope(ctx context.Context, tenant string) bool {
+	scope, ok := TenantScope(ctx)
+	return !ok || scope == tenant
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// tenantClauses returns the tenants a filter requires, following $and
+func tenantClauses(filter bson.M) []interface{} {
+	var tenants []interface{}
+	if tenant, ok := filter["tenant"]; ok {
+		tenants = append(tenants, tenant)
+	}
+	if and, ok := filter["$and"].(bson.A); ok {
+		for _, clause := range and {
+			if clause, ok := clause.(bson.M); ok {
+				tenants = append(tenants, tenantClauses(clause)...)
+			}
+		}
+	}
+	return tenants
+}
+
+func TestScopedFilters(t *testing.T) {
+	after := time.Now()
+	// The filters the jobs repository scopes before querying
+	filters := map[string]func() bson.M{
+		"by ID": func() bson.M { return bson.M{"_id": "abc"} },
+		"empty": func() bson.M { return bson.M{} },
+		"list": func() bson.M {
+			return ListQuery{Statuses: []models.JobStatus{models.JobStatusPending}, CreatedAfter: &after}.filter()
+		},
+		"requeue": func() bson.M { return RequeueQuery{Statuses: []models.JobStatus{models.JobStatusFailed}}.filter() },
+		"retention": func() bson.M {
+			return RetentionQuery{Statuses: []models.JobStatus{models.JobStatusCompleted}, UpdatedBefore: after}.filter()
+		},
+		"updated since":  func() bson.M { return bson.M{"updated_at": bson.M{"$gte": after}} },
+		"children":       func() bson.M { return bson.M{"parent_id": "abc"} },
+		"own tenant":     func() bson.M { return bson.M{"_id": "abc", "tenant": "acme"} },
+		"another tenant": func() bson.M { return bson.M{"_id": "abc", "tenant": "globex"} },
+	}
+
+	for name, build := range filters {
+		t.Run(name+" for a tenant", func(t *testing.T) {
+			filter := scoped(WithTenantScope(context.Background(), "acme"), build())
+			tenants := tenantClauses(filter)
+			if len(tenants) == 0 {
+				t.Fatalf("scoped filter %v has no tenant clause", filter)
+			}
+			for _, tenant := range tenants {
+				if tenant == "acme" {
+					return
+				}
+			}
+			t.Errorf("scoped filter %v does not require the acme tenant", filter)
+		})
+
+		t.Run(name+" for no tenant", func(t *testing.T) {
+			// Callers without a tenant are scoped to the jobs created without one
+			filter := scoped(WithTenantScope(context.Background(), ""), build())
+			if tenants := tenantClauses(filter); len(tenants) == 0 {
+				t.Fatalf("scoped filter %v has no tenant clause", filter)
+			}
+		})
+
+		t.Run(name+" unscoped", func(t *testing.T) {
+			want := build()
+			if got := scoped(context.Background(), build()); !reflect.DeepEqual(got, want) {
+				t.Errorf("scoped() = %v, want the filter unchanged: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestScopedFilterNamingAnotherTenant(t *testing.T) {
+	filter := scoped(WithTenantScope(context.Background(), "acme"), bson.M{"tenant": "globex"})
+	tenants := tenantClauses(filter)
+	if !reflect.DeepEqual(tenants, []interface{}{"globex", "acme"}) {
+		t.Errorf("tenants = %v, want both globex and acme required, so nothing matches", tenants)
+	}
+}
+
+func TestScopedPipeline(t *testing.T) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+
+	scopedToTenant := scopedPipeline(WithTenantScope(context.Background(), "acme"), pipeline)
+	if len(scopedToTenant) != 2 {
+		t.Fatalf("scopedPipeline() has %d stages, want a match before the group", len(scopedToTenant))
+	}
+	want := bson.D{{Key: "$match", Value: bson.M{"tenant": "acme"}}}
+	if !reflect.DeepEqual(scopedToTenant[0], want) {
+		t.Errorf("first stage = %v, want %v", scopedToTenant[0], want)
+	}
+
+	if unscoped := scopedPipeline(context.Background(), pipeline); !reflect.DeepEqual(unscoped, pipeline) {
+		t.Errorf("scopedPipeline() without a scope = %v, want the pipeline unchanged", unscoped)
+	}
+}
+
+func TestInScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		tenant string
+		want   bool
+	}{
+		{name: "own tenant", ctx: WithTenantScope(context.Background(), "acme"), tenant: "acme", want: true},
+		{name: "another tenant", ctx: WithTenantScope(context.Background(), "acme"), tenant: "globex", want: false},
+		{name: "no tenant scope, tenant job", ctx: WithTenantScope(context.Background(), ""), tenant: "acme", want: false},
+		{name: "no tenant scope, untenanted job", ctx: WithTenantScope(context.Background(), ""), tenant: "", want: true},
+		{name: "unscoped", ctx: context.Background(), tenant: "globex", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inScope(tt.ctx, tt.tenant); got != tt.want {
+				t.Errorf("inScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
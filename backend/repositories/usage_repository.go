@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UsageQuery selects usage records; empty fields match every record.
+// From and To are inclusive days in models.UsageDayFormat.
+type UsageQuery struct {
+	Tenant string
+	From   string
+	To     string
+}
+
+// UsageRepository interface defines the methods for tenant usage data access
+type UsageRepository interface {
+	// Aggregate recomputes the usage of every tenant on the UTC days from from to to,
+	// from the jobs that finished on them
+	Aggregate(ctx context.Context, from, to time.Time) error
+	List(ctx context.Context, query UsageQuery) ([]models.TenantUsage, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type usageRepository struct {
+	jobs       *mongo.Collection
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewUsageRepository creates a new usage repository
+func NewUsageRepository(db *mongo.Database, config Config) UsageRepository {
+	return &usageRepository{
+		jobs:       db.Collection("jobs"),
+		collection: db.Collection("usage"),
+		tracker: queryTracker{
+			collection: "usage",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+func (r *usageRepository) Aggregate(ctx context.Context, from, to time.Time) error {
+	ctx, done := r.tracker.start(ctx, "aggregate")
+	defer done()
+
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+
+	// Days are recomputed whole, so aggregating a day again replaces its records
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"finished_at": bson.M{"$gte": start, "$lt": end}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"tenant": bson.M{"$ifNull": bson.A{"$tenant", ""}},
+				"day":    bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$finished_at", "timezone": "UTC"}},
+			},
+			"jobs_run":       bson.M{"$sum": 1},
+			"duration_ms":    bson.M{"$sum": bson.M{"$ifNull": bson.A{"$duration_ms", 0}}},
+			"bytes_exported": bson.M{"$sum": bson.M{"$ifNull": bson.A{"$artifact.size", 0}}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":             bson.M{"$concat": bson.A{"$_id.tenant", "|", "$_id.day"}},
+			"tenant":          "$_id.tenant",
+			"day":             "$_id.day",
+			"jobs_run":        1,
+			"compute_seconds": bson.M{"$divide": bson.A{"$duration_ms", 1000}},
+			"bytes_exported":  1,
+			"updated_at":      "$$NOW",
+		}}},
+		{{Key: "$merge", Value: bson.M{
+			"into":           r.collection.Name(),
+			"on":             "_id",
+			"whenMatched":    "replace",
+			"whenNotMatched": "insert",
+		}}},
+	}
+
+	cursor, err := r.jobs.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.Close(ctx)
+}
+
+// List retrieves usage records by day and tenant
+func (r *usageRepository) List(ctx context.Context, query UsageQuery) ([]models.TenantUsage, error) {
+	ctx, done := r.tracker.start(ctx, "list")
+	defer done()
+
+	filter := bson.M{}
+	if query.Tenant != "" {
+		filter["tenant"] = query.Tenant
+	}
+	days := bson.M{}
+	if query.From != "" {
+		days["$gte"] = query.From
+	}
+	if query.To != "" {
+		days["$lte"] = query.To
+	}
+	if len(days) > 0 {
+		filter["day"] = days
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "day", Value: 1}, {Key: "tenant", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	usage := []models.TenantUsage{}
+	if err := cursor.All(ctx, &usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// EnsureIndexes creates the indexes usage is aggregated and listed by
+func (r *usageRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "day", Value: 1}, {Key: "tenant", Value: 1}},
+		Options: options.Index().SetName("usage_day_tenant"),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Usage is aggregated from the jobs that finished in a range of days
+	_, err = r.jobs.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "finished_at", Value: 1}},
+		Options: options.Index().SetName("jobs_finished_at").SetSparse(true),
+	})
+	return err
+}
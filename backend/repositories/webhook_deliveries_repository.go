@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// webhookDeliveryRetention is how long delivery records are kept
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+// WebhookDeliveriesRepository interface defines the methods for webhook delivery data access
+type WebhookDeliveriesRepository interface {
+	Create(ctx context.Context, delivery *models.WebhookDelivery) error
+	// GetByID retrieves a delivery of a rule, or returns ErrNotFound if there is
+	// none in the tenant scope of ctx
+	GetByID(ctx context.Context, ruleID, id primitive.ObjectID) (*models.WebhookDelivery, error)
+	// ListByRule retrieves the latest deliveries of a rule in the tenant scope of
+	// ctx, newest first
+	ListByRule(ctx context.Context, ruleID primitive.ObjectID, limit int64) ([]models.WebhookDelivery, error)
+	EnsureIndexes(ctx context.Context) error
+}
+
+type webhookDeliveriesRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewWebhookDeliveriesRepository creates a new webhook deliveries repository
+func NewWebhookDeliveriesRepository(db *mongo.Database, config Config) WebhookDeliveriesRepository {
+	return &webhookDeliveriesRepository{
+		collection: db.Collection("webhook_deliveries"),
+		tracker: queryTracker{
+			collection: "webhook_deliveries",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+func (r *webhookDeliveriesRepository) Create(ctx context.Context, delivery *models.WebhookDelivery) error {
+	ctx, done := r.tracker.start(ctx, "create")
+	defer done()
+
+	if delivery.ID.IsZero() {
+		delivery.ID = primitive.NewObjectID()
+	}
+
+	_, err := r.collection.InsertOne(ctx, delivery)
+	return translateError(err)
+}
+
+func (r *webhookDeliveriesRepository) GetByID(ctx context.Context, ruleID, id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	ctx, done := r.tracker.start(ctx, "getByID")
+	defer done()
+
+	var delivery models.WebhookDelivery
+	err := r.collection.FindOne(ctx, scoped(ctx, bson.M{"_id": id, "rule_id": ruleID})).Decode(&delivery)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &delivery, nil
+}
+
+func (r *webhookDeliveriesRepository) ListByRule(ctx context.Context, ruleID primitive.ObjectID, limit int64) ([]models.WebhookDelivery, error) {
+	ctx, done := r.tracker.start(ctx, "listByRule")
+	defer done()
+
+	opts := options.Find().SetSort(bson.D{{Key: "delivered_at", Value: -1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, scoped(ctx, bson.M{"rule_id": ruleID}), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// EnsureIndexes creates the index deliveries are listed by, which also expires
+// them after webhookDeliveryRetention
+func (r *webhookDeliveriesRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, done := r.tracker.start(ctx, "ensureIndexes")
+	defer done()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "rule_id", Value: 1}, {Key: "delivered_at", Value: -1}},
+			Options: options.Index().SetName("webhook_deliveries_rule_delivered"),
+		},
+		{
+			Keys:    bson.D{{Key: "delivered_at", Value: 1}},
+			Options: options.Index().SetName("webhook_deliveries_expires").SetExpireAfterSeconds(int32(webhookDeliveryRetention.Seconds())),
+		},
+	})
+	return err
+}
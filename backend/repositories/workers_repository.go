@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WorkersRepository interface defines the methods for worker registration data access
+type WorkersRepository interface {
+	ListSeenSince(ctx context.Context, since time.Time) ([]models.WorkerInfo, error)
+}
+
+type workersRepository struct {
+	collection *mongo.Collection
+	tracker    queryTracker
+}
+
+// NewWorkersRepository creates a new workers repository
+func NewWorkersRepository(db *mongo.Database, config Config) WorkersRepository {
+	return &workersRepository{
+		collection: db.Collection("workers"),
+		tracker: queryTracker{
+			collection: "workers",
+			timeout:    config.QueryTimeout,
+			slow:       config.SlowQueryThreshold,
+		},
+	}
+}
+
+// ListSeenSince retrieves the workers whose last heartbeat is at or after since
+func (r *workersRepository) ListSeenSince(ctx context.Context, since time.Time) ([]models.WorkerInfo, error) {
+	ctx, done := r.tracker.start(ctx, "listSeenSince")
+	defer done()
+
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"last_heartbeat": bson.M{"$gte": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var workers []models.WorkerInfo
+	if err := cursor.All(ctx, &workers); err != nil {
+		return nil, err
+	}
+
+	return workers, nil
+}
@@ -0,0 +1,238 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RedactedValue replaces encrypted config values in API responses
+const RedactedValue = "[REDACTED]"
+
+// envelopeVersion marks a config value as an encrypted envelope
+const envelopeVersion = "v1"
+
+// Envelope fields. The data key that encrypts the value is itself encrypted
+// by the key wrapper and stored alongside it, so only holders of the wrapping
+// key can recover the value.
+const (
+	fieldVersion    = "_encrypted"
+	fieldKeyID      = "key_id"
+	fieldWrappedKey = "wrapped_key"
+	fieldNonce      = "nonce"
+	fieldCiphertext = "ciphertext"
+)
+
+// ErrEncryptionDisabled is returned when a value must be encrypted but no key is configured
+var ErrEncryptionDisabled = errors.New("config encryption is not configured")
+
+// KeyWrapper encrypts and decrypts the per-value data keys. It is implemented
+// by the local key wrapper and can be implemented by a KMS client.
+type KeyWrapper interface {
+	// KeyID identifies the wrapping key recorded in new envelopes
+	KeyID() string
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Config holds the config encryption settings
+type Config struct {
+	Provider string
+	// Key is the base64-encoded 256-bit wrapping key of the local provider
+	Key   string
+	KeyID string
+}
+
+// New creates the key wrapper selected by the config, or nil when no key is configured
+func New(config Config) (KeyWrapper, error) {
+	switch config.Provider {
+	case "", "local":
+		if config.Key == "" {
+			return nil, nil
+		}
+		key, err := base64.StdEncoding.DecodeString(config.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secrets key: %w", err)
+		}
+		return NewLocalKeyWrapper(config.KeyID, key)
+	default:
+		return nil, fmt.Errorf("unsupported secrets provider %q", config.Provider)
+	}
+}
+
+// LocalKeyWrapper wraps data keys with a key held in process memory
+type LocalKeyWrapper struct {
+	keyID string
+	aead  cipher.AEAD
+}
+
+// NewLocalKeyWrapper creates a key wrapper from a 256-bit key
+func NewLocalKeyWrapper(keyID string, key []byte) (*LocalKeyWrapper, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secrets key must be 32 bytes, got %d", len(key))
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKeyWrapper{keyID: keyID, aead: aead}, nil
+}
+
+func (w *LocalKeyWrapper) KeyID() string {
+	return w.keyID
+}
+
+func (w *LocalKeyWrapper) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return seal(w.aead, dataKey)
+}
+
+func (w *LocalKeyWrapper) Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	if keyID != w.keyID {
+		return nil, fmt.Errorf("value was encrypted with unknown key %q", keyID)
+	}
+	return open(w.aead, wrapped)
+}
+
+// Encryptor encrypts the designated top-level keys of job configs
+type Encryptor struct {
+	keys   KeyWrapper
+	fields map[string]bool
+}
+
+// NewEncryptor creates an encryptor for the given config keys. keys may be nil,
+// in which case configs containing any of those keys are refused.
+func NewEncryptor(keys KeyWrapper, fields []string) *Encryptor {
+	e := &Encryptor{keys: keys, fields: make(map[string]bool)}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			e.fields[field] = true
+		}
+	}
+	return e
+}
+
+// EncryptConfig returns a copy of config with the designated keys replaced by envelopes
+func (e *Encryptor) EncryptConfig(ctx context.Context, config map[string]interface{}) (map[string]interface{}, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	encrypted := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if !e.fields[key] || IsEnvelope(value) {
+			encrypted[key] = value
+			continue
+		}
+		if e.keys == nil {
+			return nil, fmt.Errorf("config.%s: %w", key, ErrEncryptionDisabled)
+		}
+		envelope, err := e.encrypt(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt config.%s: %w", key, err)
+		}
+		encrypted[key] = envelope
+	}
+	return encrypted, nil
+}
+
+func (e *Encryptor) encrypt(ctx context.Context, value interface{}) (map[string]interface{}, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := seal(aead, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := e.keys.Wrap(ctx, dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return map[string]interface{}{
+		fieldVersion:    envelopeVersion,
+		fieldKeyID:      e.keys.KeyID(),
+		fieldWrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		fieldNonce:      base64.StdEncoding.EncodeToString(nonce),
+		fieldCiphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// IsEnvelope reports whether a config value is an encrypted envelope
+func IsEnvelope(value interface{}) bool {
+	fields, ok := asMap(value)
+	return ok && fields[fieldVersion] == envelopeVersion
+}
+
+// RedactConfig returns a copy of config with every encrypted value replaced by RedactedValue
+func RedactConfig(config map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if IsEnvelope(value) {
+			redacted[key] = RedactedValue
+		} else {
+			redacted[key] = value
+		}
+	}
+	return redacted
+}
+
+// asMap returns the fields of a nested config document, however it was decoded
+func asMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case primitive.M:
+		return v, true
+	case primitive.D:
+		return v.Map(), true
+	default:
+		return nil, false
+	}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, returning the nonce followed by the ciphertext
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// dlqTopic returns the Kafka topic failed jobs are published to
+func dlqTopic() string {
+	return topicName("jobs_dlq")
+}
+
+var alertsFiring = metrics.NewGauge("alerts_firing", "Whether an alert rule is currently firing.", "rule")
+
+// AlertEvent is the payload delivered to webhooks when an alert fires or resolves
+type AlertEvent struct {
+	RuleID    string           `json:"rule_id"`
+	RuleName  string           `json:"rule_name"`
+	Kind      models.AlertKind `json:"kind"`
+	JobType   models.JobType   `json:"job_type,omitempty"`
+	State     string           `json:"state"`
+	Value     float64          `json:"value"`
+	Threshold float64          `json:"threshold"`
+	Message   string           `json:"message"`
+	At        time.Time        `json:"at"`
+}
+
+// AlertEvaluator periodically evaluates the enabled alert rules and notifies
+// their channels when a rule starts or stops firing
+type AlertEvaluator struct {
+	rules    repositories.AlertsRepository
+	jobs     repositories.JobsRepository
+	producer *KafkaProducer
+	interval time.Duration
+	client   *http.Client
+	webhooks *WebhookSender
+}
+
+// NewAlertEvaluator creates a new alert evaluator
+func NewAlertEvaluator(rules repositories.AlertsRepository, jobs repositories.JobsRepository, producer *KafkaProducer, webhooks *WebhookSender, interval time.Duration) *AlertEvaluator {
+	return &AlertEvaluator{
+		rules:    rules,
+		jobs:     jobs,
+		producer: producer,
+		interval: interval,
+		client:   newWebhookClient(10 * time.Second),
+		webhooks: webhooks,
+	}
+}
+
+// Run evaluates the rules until ctx is cancelled
+func (e *AlertEvaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.evaluateAll(ctx)
+		}
+	}
+}
+
+// evaluateAll evaluates every enabled rule once
+func (e *AlertEvaluator) evaluateAll(ctx context.Context) {
+	rules, err := e.rules.ListEnabled(ctx)
+	if err != nil {
+		log.Printf("Failed to list alert rules: %v", err)
+		return
+	}
+
+	// The DLQ depth is shared by all dlq_depth rules, so it is read at most once
+	var dlqDepth *int64
+
+	for i := range rules {
+		rule := &rules[i]
+
+		var value float64
+		var firing bool
+		switch rule.Kind {
+		case models.AlertKindFailureRate:
+			value, firing, err = e.evaluateFailureRate(ctx, rule)
+		case models.AlertKindDLQDepth:
+			if dlqDepth == nil {
+				depth, depthErr := e.producer.TopicDepth(ctx, dlqTopic())
+				if depthErr != nil {
+					err = depthErr
+					break
+				}
+				dlqDepth = &depth
+			}
+			value = float64(*dlqDepth)
+			firing = value > rule.Threshold
+		default:
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to evaluate alert rule %s: %v", rule.Name, err)
+			continue
+		}
+
+		e.transition(ctx, rule, firing, value)
+	}
+}
+
+// evaluateFailureRate computes the share of failed jobs finished within the rule's window
+func (e *AlertEvaluator) evaluateFailureRate(ctx context.Context, rule *models.AlertRule) (float64, bool, error) {
+	counts, err := e.jobs.CountOutcomesSince(ctx, rule.JobType, time.Now().Add(-rule.Window()))
+	if err != nil {
+		return 0, false, err
+	}
+
+	failed := counts[models.JobStatusFailed]
+	finished := failed + counts[models.JobStatusCompleted]
+	if finished == 0 {
+		return 0, false, nil
+	}
+
+	rate := float64(failed) / float64(finished)
+	return rate, finished >= rule.MinSamples && rate > rule.Threshold, nil
+}
+
+// transition records the evaluation and notifies when the firing state changes
+func (e *AlertEvaluator) transition(ctx context.Context, rule *models.AlertRule, firing bool, value float64) {
+	firingValue := 0.0
+	if firing {
+		firingValue = 1
+	}
+	alertsFiring.Set(firingValue, rule.Name)
+
+	var firedAt *time.Time
+	changed := firing != rule.Firing
+	if changed && firing {
+		now := time.Now()
+		firedAt = &now
+	}
+
+	if err := e.rules.RecordEvaluation(ctx, rule.ID, firing, value, firedAt); err != nil {
+		log.Printf("Failed to record evaluation of alert rule %s: %v", rule.Name, err)
+		return
+	}
+
+	if !changed {
+		return
+	}
+
+	event := AlertEvent{
+		RuleID:    rule.ID.Hex(),
+		RuleName:  rule.Name,
+		Kind:      rule.Kind,
+		JobType:   rule.JobType,
+		State:     "resolved",
+		Value:     value,
+		Threshold: rule.Threshold,
+		Message:   describeAlert(rule, value),
+		At:        time.Now(),
+	}
+	if firing {
+		event.State = "firing"
+	}
+
+	log.Printf("Alert %s %s: %s", rule.Name, event.State, event.Message)
+	e.notify(ctx, rule, event)
+}
+
+// notify delivers the event to the rule's Slack and webhook channels
+func (e *AlertEvaluator) notify(ctx context.Context, rule *models.AlertRule, event AlertEvent) {
+	if rule.SlackWebhookURL != "" {
+		text := fmt.Sprintf("[%s] %s: %s", event.State, rule.Name, event.Message)
+		if err := e.post(ctx, rule.SlackWebhookURL, map[string]string{"text": text}); err != nil {
+			log.Printf("Failed to send slack alert for rule %s: %v", rule.Name, err)
+		}
+	}
+	if rule.WebhookURL != "" {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Failed to encode webhook alert for rule %s: %v", rule.Name, err)
+			return
+		}
+		delivery, err := e.webhooks.Send(ctx, rule, payload, nil)
+		if err != nil {
+			log.Printf("Failed to record webhook alert for rule %s: %v", rule.Name, err)
+		}
+		if delivery.Error != "" {
+			log.Printf("Failed to send webhook alert for rule %s: %s", rule.Name, delivery.Error)
+		}
+	}
+}
+
+func (e *AlertEvaluator) post(ctx context.Context, url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if err := checkWebhookURL(req.URL); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// describeAlert renders a human readable description of the rule's current value
+func describeAlert(rule *models.AlertRule, value float64) string {
+	switch rule.Kind {
+	case models.AlertKindFailureRate:
+		scope := "all jobs"
+		if rule.JobType != "" {
+			scope = "type=" + string(rule.JobType)
+		}
+		return fmt.Sprintf("%.1f%% failures for %s over %s (threshold %.1f%%)",
+			value*100, scope, rule.Window(), rule.Threshold*100)
+	case models.AlertKindDLQDepth:
+		return fmt.Sprintf("DLQ depth is %.0f (threshold %.0f)", value, rule.Threshold)
+	default:
+		return fmt.Sprintf("value %g (threshold %g)", value, rule.Threshold)
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Alert errors
+var (
+	ErrAlertRuleNotFound       = errors.New("alert rule not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Alert rule defaults
+const (
+	defaultAlertWindowSeconds = 600
+	defaultAlertMinSamples    = 10
+	// webhookDeliveriesLimit is how many of a rule's latest deliveries are listed
+	webhookDeliveriesLimit = 100
+	minWebhookSecretLength = 16
+)
+
+// AlertRuleRequest represents the request to create or replace an alert rule
+type AlertRuleRequest struct {
+	Name            string  `json:"name"`
+	Kind            string  `json:"kind"`
+	JobType         string  `json:"job_type,omitempty"`
+	Threshold       float64 `json:"threshold"`
+	WindowSeconds   int64   `json:"window_seconds,omitempty"`
+	MinSamples      int64   `json:"min_samples,omitempty"`
+	SlackWebhookURL string  `json:"slack_webhook_url,omitempty"`
+	WebhookURL      string  `json:"webhook_url,omitempty"`
+	// WebhookSecret replaces the secret webhook payloads are signed with; one
+	// is generated for rules with a webhook_url that have none
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+	Enabled       *bool  `json:"enabled,omitempty"`
+	// Tenant gives the tenant access to the rule's webhook deliveries
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// AlertsService interface defines the methods for managing alert rules
+type AlertsService interface {
+	CreateRule(ctx context.Context, req AlertRuleRequest) (*models.AlertRule, error)
+	GetRule(ctx context.Context, id string) (*models.AlertRule, error)
+	ListRules(ctx context.Context) ([]models.AlertRule, error)
+	UpdateRule(ctx context.Context, id string, req AlertRuleRequest) (*models.AlertRule, error)
+	DeleteRule(ctx context.Context, id string) error
+	// ListDeliveries retrieves the latest deliveries to a rule's webhook, newest
+	// first. Rules outside the tenant scope of ctx are not found.
+	ListDeliveries(ctx context.Context, id string) ([]models.WebhookDelivery, error)
+	// Redeliver sends the payload of an earlier delivery to the rule's webhook again
+	Redeliver(ctx context.Context, id, deliveryID string) (*models.WebhookDelivery, error)
+}
+
+type alertsService struct {
+	repo       repositories.AlertsRepository
+	deliveries repositories.WebhookDeliveriesRepository
+	sender     *WebhookSender
+}
+
+// NewAlertsService creates a new alerts service
+func NewAlertsService(repo repositories.AlertsRepository, deliveries repositories.WebhookDeliveriesRepository, sender *WebhookSender) AlertsService {
+	return &alertsService{
+		repo:       repo,
+		deliveries: deliveries,
+		sender:     sender,
+	}
+}
+
+// CreateRule validates and stores a new alert rule
+func (s *alertsService) CreateRule(ctx context.Context, req AlertRuleRequest) (*models.AlertRule, error) {
+	rule := &models.AlertRule{}
+	if err := req.apply(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetRule retrieves an alert rule by ID
+func (s *alertsService) GetRule(ctx context.Context, id string) (*models.AlertRule, error) {
+	rule, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) || errors.Is(err, repositories.ErrInvalidID) {
+		return nil, ErrAlertRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// ListRules retrieves all alert rules
+func (s *alertsService) ListRules(ctx context.Context) ([]models.AlertRule, error) {
+	rules, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+
+	if rules == nil {
+		rules = []models.AlertRule{}
+	}
+
+	return rules, nil
+}
+
+// UpdateRule replaces the definition of an alert rule, keeping its evaluation state
+func (s *alertsService) UpdateRule(ctx context.Context, id string, req AlertRuleRequest) (*models.AlertRule, error) {
+	rule, err := s.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.apply(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(ctx, rule); err != nil {
+		return nil, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// DeleteRule removes an alert rule
+func (s *alertsService) DeleteRule(ctx context.Context, id string) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if errors.Is(err, repositories.ErrInvalidID) {
+		return ErrAlertRuleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+
+	if !deleted {
+		return ErrAlertRuleNotFound
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries to a rule's webhook
+func (s *alertsService) ListDeliveries(ctx context.Context, id string) ([]models.WebhookDelivery, error) {
+	rule, err := s.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.deliveries.ListByRule(ctx, rule.ID, webhookDeliveriesLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver sends the payload of an earlier delivery to the rule's current webhook
+// URL, signed with its current secret, and records it as a new delivery
+func (s *alertsService) Redeliver(ctx context.Context, id, deliveryID string) (*models.WebhookDelivery, error) {
+	rule, err := s.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule.WebhookURL == "" {
+		return nil, &ValidationError{Field: "webhook_url", Message: "alert rule has no webhook_url to redeliver to"}
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	original, err := s.deliveries.GetByID(ctx, rule.ID, objectID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return s.sender.Send(ctx, rule, []byte(original.Payload), &original.ID)
+}
+
+// apply validates the request and copies its definition onto the rule
+func (r AlertRuleRequest) apply(rule *models.AlertRule) error {
+	if r.Name == "" {
+		return &ValidationError{Field: "name", Message: "alert rule name is required"}
+	}
+
+	kind := models.AlertKind(r.Kind)
+	switch kind {
+	case models.AlertKindFailureRate:
+		if r.Threshold <= 0 || r.Threshold > 1 {
+			return &ValidationError{Field: "threshold", Message: "failure rate threshold must be between 0 and 1"}
+		}
+		if r.JobType != "" && !models.IsValidJobType(r.JobType) {
+			return &ValidationError{Field: "job_type", Message: fmt.Sprintf("invalid job type '%s'", r.JobType)}
+		}
+		if r.WindowSeconds < 0 || r.MinSamples < 0 {
+			return &ValidationError{Field: "window_seconds", Message: "window and minimum samples must not be negative"}
+		}
+	case models.AlertKindDLQDepth:
+		if r.Threshold < 0 {
+			return &ValidationError{Field: "threshold", Message: "DLQ depth threshold must not be negative"}
+		}
+		if r.JobType != "" {
+			return &ValidationError{Field: "job_type", Message: "job type is not supported for dlq_depth rules"}
+		}
+	default:
+		return &ValidationError{Field: "kind", Message: "kind must be one of: failure_rate, dlq_depth"}
+	}
+
+	if r.SlackWebhookURL == "" && r.WebhookURL == "" {
+		return &ValidationError{Field: "slack_webhook_url", Message: "at least one of slack_webhook_url or webhook_url is required"}
+	}
+	for field, value := range map[string]string{"slack_webhook_url": r.SlackWebhookURL, "webhook_url": r.WebhookURL} {
+		if value == "" {
+			continue
+		}
+		if parsed, err := url.Parse(value); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return &ValidationError{Field: field, Message: "must be an absolute https URL"}
+		}
+	}
+
+	rule.Name = r.Name
+	rule.Kind = kind
+	rule.JobType = models.JobType(r.JobType)
+	rule.Tenant = r.Tenant
+	rule.Threshold = r.Threshold
+	rule.WindowSeconds = 0
+	rule.MinSamples = 0
+	if kind == models.AlertKindFailureRate {
+		rule.WindowSeconds = r.WindowSeconds
+		if rule.WindowSeconds == 0 {
+			rule.WindowSeconds = defaultAlertWindowSeconds
+		}
+		rule.MinSamples = r.MinSamples
+		if rule.MinSamples == 0 {
+			rule.MinSamples = defaultAlertMinSamples
+		}
+	}
+	if r.WebhookSecret != "" && len(r.WebhookSecret) < minWebhookSecretLength {
+		return &ValidationError{Field: "webhook_secret", Message: fmt.Sprintf("must be at least %d characters", minWebhookSecretLength)}
+	}
+
+	rule.SlackWebhookURL = r.SlackWebhookURL
+	rule.WebhookURL = r.WebhookURL
+	if r.WebhookSecret != "" {
+		rule.WebhookSecret = r.WebhookSecret
+	}
+	if rule.WebhookURL != "" && rule.WebhookSecret == "" {
+		secret, err := models.NewWebhookSecret()
+		if err != nil {
+			return fmt.Errorf("failed to generate webhook secret: %w", err)
+		}
+		rule.WebhookSecret = secret
+	}
+	rule.Enabled = r.Enabled == nil || *r.Enabled
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API key errors
+var (
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrInvalidAPIKey  = errors.New("invalid or revoked API key")
+)
+
+// apiKeyTag starts every API key, so leaked keys are easy to recognize
+const apiKeyTag = "jp"
+
+// apiKeyLastUsedInterval is how often the last use of a key is recorded
+const apiKeyLastUsedInterval = time.Minute
+
+// apiKeyGenerateAttempts bounds the keys generated for one creation. Prefixes
+// are short enough to collide now and then, and a colliding key is replaced.
+const apiKeyGenerateAttempts = 5
+
+// maxAPIKeyNameLength bounds the size of an API key's name
+const maxAPIKeyNameLength = 100
+
+// CreateAPIKeyRequest represents the request to create an API key
+type CreateAPIKeyRequest struct {
+	Name               string   `json:"name"`
+	Scopes             []string `json:"scopes"`
+	RateLimitPerMinute int      `json:"rate_limit_per_minute,omitempty"`
+	// Tenant creates the key for another tenant; only admins may set it
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// CreatedAPIKey is a newly created API key along with its secret, which is
+// not stored and cannot be retrieved again
+type CreatedAPIKey struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeysService interface defines the methods for managing API keys.
+// Keys belong to the tenant of the caller.
+type APIKeysService interface {
+	CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) (*models.APIKey, error)
+	// Authenticate returns the unrevoked key matching a secret, or ErrInvalidAPIKey
+	Authenticate(ctx context.Context, secret string) (*models.APIKey, error)
+}
+
+type apiKeysService struct {
+	repo repositories.APIKeysRepository
+}
+
+// NewAPIKeysService creates a new API keys service
+func NewAPIKeysService(repo repositories.APIKeysRepository) APIKeysService {
+	return &apiKeysService{repo: repo}
+}
+
+// CreateAPIKey validates and stores a new key for the caller's tenant. A key
+// cannot be granted scopes the caller's own credential lacks.
+func (s *apiKeysService) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxAPIKeyNameLength {
+		return nil, &ValidationError{Field: "name", Message: fmt.Sprintf("name must be 1-%d characters", maxAPIKeyNameLength)}
+	}
+	if req.RateLimitPerMinute < 0 {
+		return nil, &ValidationError{Field: "rate_limit_per_minute", Message: "rate_limit_per_minute must not be negative"}
+	}
+	scopes, err := validateScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	principal := auth.PrincipalFromContext(ctx)
+	for _, scope := range scopes {
+		if !principalHasScope(principal, scope) {
+			return nil, &ValidationError{Field: "scopes", Message: fmt.Sprintf("cannot grant the %s scope, which your credential lacks", scope)}
+		}
+	}
+
+	tenant := auth.Tenant(ctx)
+	if req.Tenant != "" && req.Tenant != tenant {
+		if !principal.HasRole(auth.RoleAdmin) {
+			return nil, &ValidationError{Field: "tenant", Message: "only admins can create keys for another tenant"}
+		}
+		tenant = req.Tenant
+	}
+
+	for attempt := 1; ; attempt++ {
+		prefix, secret, err := generateAPIKey()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate API key: %w", err)
+		}
+		key := models.APIKey{
+			Tenant:             tenant,
+			Name:               req.Name,
+			Prefix:             prefix,
+			Hash:               hashAPIKey(secret),
+			Scopes:             scopes,
+			RateLimitPerMinute: req.RateLimitPerMinute,
+			CreatedBy:          auth.Actor(ctx),
+		}
+		err = s.repo.Create(ctx, &key)
+		// The prefix is unique; a key colliding with another is generated again
+		if errors.Is(err, repositories.ErrConflict) && attempt < apiKeyGenerateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create API key: %w", err)
+		}
+		return &CreatedAPIKey{APIKey: key, Key: secret}, nil
+	}
+}
+
+// ListAPIKeys retrieves the keys of the caller's tenant, including revoked ones
+func (s *apiKeysService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.repo.List(ctx, auth.Tenant(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key of the caller's tenant; requests made with it are refused from then on
+func (s *apiKeysService) RevokeAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	key, err := s.repo.Revoke(ctx, auth.Tenant(ctx), objectID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return key, nil
+}
+
+func (s *apiKeysService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	prefix, ok := apiKeyPrefix(secret)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	if key.RevokedAt != nil || subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashAPIKey(secret))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err := s.repo.MarkUsed(ctx, key.ID, now); err != nil {
+			log.Printf("Failed to record use of API key %s: %v", key.Prefix, err)
+		}
+	}
+	return key, nil
+}
+
+// validateScopes checks requested scopes and removes duplicates
+func validateScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, &ValidationError{Field: "scopes", Message: "at least one scope is required"}
+	}
+
+	seen := make(map[string]bool, len(requested))
+	var scopes []string
+	for _, scope := range requested {
+		valid := false
+		for _, known := range models.ValidScopes() {
+			valid = valid || scope == known
+		}
+		if !valid {
+			return nil, &ValidationError{Field: "scopes", Message: fmt.Sprintf("unknown scope %q, must be one of %s", scope, strings.Join(models.ValidScopes(), ", "))}
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// principalHasScope reports whether a principal may use a scope. Principals
+// without scopes, such as jobctl's, are unlimited; anonymous callers have none.
+func principalHasScope(principal *auth.Principal, scope string) bool {
+	if principal == nil {
+		return false
+	}
+	if principal.Scopes == nil {
+		return true
+	}
+	for _, granted := range principal.Scopes {
+		if granted == scope || granted == models.ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// generateAPIKey returns a new key, e.g. jp_1a2b3c4d_<secret>, and its prefix jp_1a2b3c4d
+func generateAPIKey() (string, string, error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix := apiKeyTag + "_" + hex.EncodeToString(id)
+	return prefix, prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// apiKeyPrefix returns the prefix of a key in the format generateAPIKey produces
+func apiKeyPrefix(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyTag || len(parts[1]) != 8 || parts[2] == "" {
+		return "", false
+	}
+	return parts[0] + "_" + parts[1], true
+}
+
+// hashAPIKey hashes a key for storage. Keys are random and long, so a fast hash is enough.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeAPIKeysRepository keeps keys in memory by prefix
+type fakeAPIKeysRepository struct {
+	repositories.APIKeysRepository
+	keys    map[string]*models.APIKey
+	lookups []string
+}
+
+func newFakeAPIKeysRepository() *fakeAPIKeysRepository {
+	return &fakeAPIKeysRepository{keys: make(map[string]*models.APIKey)}
+}
+
+func (r *fakeAPIKeysRepository) Create(ctx context.Context, key *models.APIKey) error {
+	if _, ok := r.keys[key.Prefix]; ok {
+		return repositories.ErrConflict
+	}
+	key.ID = primitive.NewObjectID()
+	stored := *key
+	r.keys[key.Prefix] = &stored
+	return nil
+}
+
+func (r *fakeAPIKeysRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	r.lookups = append(r.lookups, prefix)
+	key, ok := r.keys[prefix]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	found := *key
+	return &found, nil
+}
+
+func (r *fakeAPIKeysRepository) MarkUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	return nil
+}
+
+func TestCreateAPIKeyScopes(t *testing.T) {
+	reader := &auth.Principal{Subject: "apikey:jp_reader", Tenant: "acme", Scopes: []string{models.ScopeJobsRead}}
+	writer := &auth.Principal{Subject: "apikey:jp_writer", Tenant: "acme", Scopes: []string{models.ScopeJobsRead, models.ScopeJobsWrite}}
+	admin := &auth.Principal{Subject: "apikey:jp_admin", Tenant: "acme", Scopes: []string{models.ScopeAdmin}, Roles: []string{auth.RoleAdmin}}
+	user := &auth.Principal{Subject: "user-1", Tenant: "acme", Scopes: []string{models.ScopeJobsRead, models.ScopeJobsWrite}}
+
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		req        CreateAPIKeyRequest
+		wantTenant string
+		wantField  string
+	}{
+		{name: "same scopes", principal: writer, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsWrite}}, wantTenant: "acme"},
+		{name: "fewer scopes", principal: writer, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsRead}}, wantTenant: "acme"},
+		{name: "write from read", principal: reader, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsWrite}}, wantField: "scopes"},
+		{name: "admin from write", principal: writer, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeAdmin}}, wantField: "scopes"},
+		{name: "admin from user", principal: user, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeAdmin}}, wantField: "scopes"},
+		{name: "anonymous", principal: nil, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsRead}}, wantField: "scopes"},
+		{name: "admin grants admin", principal: admin, req: CreateAPIKeyRequest{Name: "ops", Scopes: []string{models.ScopeAdmin}}, wantTenant: "acme"},
+		{name: "unknown scope", principal: admin, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{"root"}}, wantField: "scopes"},
+		{name: "own tenant named", principal: writer, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsRead}, Tenant: "acme"}, wantTenant: "acme"},
+		{name: "other tenant from non-admin", principal: writer, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsRead}, Tenant: "globex"}, wantField: "tenant"},
+		{name: "other tenant from user", principal: user, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsRead}, Tenant: "globex"}, wantField: "tenant"},
+		{name: "other tenant from admin", principal: admin, req: CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsRead}, Tenant: "globex"}, wantTenant: "globex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAPIKeysRepository()
+			service := NewAPIKeysService(repo)
+			ctx := auth.WithPrincipal(context.Background(), tt.principal)
+
+			created, err := service.CreateAPIKey(ctx, tt.req)
+			if tt.wantField != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Field != tt.wantField {
+					t.Fatalf("CreateAPIKey() error = %v, want a validation error on %s", err, tt.wantField)
+				}
+				if len(repo.keys) != 0 {
+					t.Error("a refused key was stored")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateAPIKey() error = %v", err)
+			}
+			if created.Tenant != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", created.Tenant, tt.wantTenant)
+			}
+			if stored := repo.keys[created.Prefix]; stored == nil || stored.Hash == created.Key || strings.Contains(stored.Hash, created.Key) {
+				t.Error("the key's secret was stored rather than its hash")
+			}
+		})
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	repo := newFakeAPIKeysRepository()
+	service := NewAPIKeysService(repo)
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "jobctl", Tenant: "acme"})
+
+	created, err := service.CreateAPIKey(ctx, CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.ScopeJobsRead}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	revoked, err := service.CreateAPIKey(ctx, CreateAPIKeyRequest{Name: "old", Scopes: []string{models.ScopeJobsRead}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+	revokedAt := time.Now()
+	repo.keys[revoked.Prefix].RevokedAt = &revokedAt
+
+	// A secret differing in its last character shares the prefix, so it is
+	// found and refused by its hash
+	replacement := "A"
+	if strings.HasSuffix(created.Key, replacement) {
+		replacement = "B"
+	}
+	tampered := created.Key[:len(created.Key)-1] + replacement
+
+	tests := []struct {
+		name       string
+		secret     string
+		wantPrefix string
+		wantLookup bool
+	}{
+		{name: "valid", secret: created.Key, wantPrefix: created.Prefix, wantLookup: true},
+		{name: "wrong secret", secret: tampered, wantLookup: true},
+		{name: "revoked", secret: revoked.Key, wantLookup: true},
+		{name: "unknown prefix", secret: "jp_00000000_secret", wantLookup: true},
+		{name: "malformed", secret: "not-a-key"},
+		{name: "short prefix", secret: "jp_1234_secret"},
+		{name: "no secret", secret: created.Prefix + "_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.lookups = nil
+			key, err := service.Authenticate(context.Background(), tt.secret)
+			if tt.wantPrefix != "" {
+				if err != nil || key.Prefix != tt.wantPrefix {
+					t.Fatalf("Authenticate() = %v, %v, want key %s", key, err, tt.wantPrefix)
+				}
+			} else if !errors.Is(err, ErrInvalidAPIKey) {
+				t.Fatalf("Authenticate() error = %v, want ErrInvalidAPIKey", err)
+			}
+			if (len(repo.lookups) > 0) != tt.wantLookup {
+				t.Errorf("lookups = %v, want a lookup: %v", repo.lookups, tt.wantLookup)
+			}
+		})
+	}
+}
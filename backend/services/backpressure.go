@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// ErrBackpressure is returned when a job is refused because its queue is over capacity
+var ErrBackpressure = errors.New("job queue is over capacity")
+
+// DefaultQueueMonitorInterval is how often the queue monitor measures the backlog
+const DefaultQueueMonitorInterval = 15 * time.Second
+
+// throughputWindow is the window over which the finish rate used for wait estimates is measured
+const throughputWindow = 5 * time.Minute
+
+// minBackpressureRetryAfter bounds the Retry-After advertised when a job is refused
+const minBackpressureRetryAfter = 10 * time.Second
+
+// BackpressureMode selects what happens to new jobs when their queue is over capacity
+type BackpressureMode string
+
+const (
+	// BackpressureOff accepts jobs regardless of the queue depth
+	BackpressureOff BackpressureMode = "off"
+	// BackpressureReject refuses jobs with 429 Too Many Requests
+	BackpressureReject BackpressureMode = "reject"
+	// BackpressureWarn accepts jobs with a warning carrying the expected wait
+	BackpressureWarn BackpressureMode = "warn"
+)
+
+// BackpressureConfig sets when a queue is over capacity and how new jobs are handled
+type BackpressureConfig struct {
+	// MaxPending is the pending job count per type at which a queue is over capacity; zero disables the check
+	MaxPending int64
+	// MaxLag is the consumer lag per type at which a queue is over capacity; zero disables the check
+	MaxLag int64
+	// Mode applies to tenants without an entry in TenantModes
+	Mode        BackpressureMode
+	TenantModes map[string]BackpressureMode
+}
+
+func (c BackpressureConfig) modeFor(tenant string) BackpressureMode {
+	if mode, ok := c.TenantModes[tenant]; ok {
+		return mode
+	}
+	return c.Mode
+}
+
+func (c BackpressureConfig) overloaded(depth models.QueueDepth) bool {
+	return (c.MaxPending > 0 && depth.Pending >= c.MaxPending) || (c.MaxLag > 0 && depth.Lag >= c.MaxLag)
+}
+
+// BackpressureError reports a job refused because its queue is over capacity
+type BackpressureError struct {
+	Queue      models.QueueDepth
+	RetryAfter time.Duration
+}
+
+func (e *BackpressureError) Error() string {
+	return fmt.Sprintf("%s: %d %s jobs pending, consumer lag %d", ErrBackpressure, e.Queue.Pending, e.Queue.JobType, e.Queue.Lag)
+}
+
+func (e *BackpressureError) Unwrap() error {
+	return ErrBackpressure
+}
+
+// QueueMonitor periodically measures the backlog of every job type so job
+// creation can check it without querying MongoDB and Kafka on each request
+type QueueMonitor struct {
+	jobs     repositories.JobsRepository
+	producer *KafkaProducer
+	interval time.Duration
+
+	mu     sync.RWMutex
+	depths map[models.JobType]models.QueueDepth
+}
+
+// NewQueueMonitor creates a new queue monitor
+func NewQueueMonitor(jobs repositories.JobsRepository, producer *KafkaProducer, interval time.Duration) *QueueMonitor {
+	return &QueueMonitor{
+		jobs:     jobs,
+		producer: producer,
+		interval: interval,
+		depths:   make(map[models.JobType]models.QueueDepth),
+	}
+}
+
+// Run measures the queues until ctx is cancelled
+func (m *QueueMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	m.refresh(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.refresh(ctx)
+		}
+	}
+}
+
+// Depth returns the last measured backlog of a job type
+func (m *QueueMonitor) Depth(jobType models.JobType) (models.QueueDepth, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	depth, ok := m.depths[jobType]
+	return depth, ok
+}
+
+// Depths returns the last measured backlog of every job type that was measured
+func (m *QueueMonitor) Depths() []models.QueueDepth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	depths := make([]models.QueueDepth, 0, len(m.depths))
+	for _, jobType := range models.ValidJobTypes() {
+		if depth, ok := m.depths[jobType]; ok {
+			depths = append(depths, depth)
+		}
+	}
+	return depths
+}
+
+// refresh measures the backlog of every job type. A type that cannot be
+// measured keeps its previous measurement.
+func (m *QueueMonitor) refresh(ctx context.Context) {
+	pending, err := m.jobs.CountPendingByType(ctx)
+	if err != nil {
+		log.Printf("Failed to count pending jobs: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, jobType := range models.ValidJobTypes() {
+		outcomes, err := m.jobs.CountOutcomesSince(ctx, jobType, now.Add(-throughputWindow))
+		if err != nil {
+			log.Printf("Failed to measure %s throughput: %v", jobType, err)
+			continue
+		}
+		var finished int64
+		for _, count := range outcomes {
+			finished += count
+		}
+
+		lag, err := m.producer.ConsumerLag(ctx, JobTopic(jobType), JobConsumerGroup(jobType))
+		if err != nil {
+			log.Printf("Failed to read consumer lag for %s jobs: %v", jobType, err)
+			continue
+		}
+
+		depth := models.QueueDepth{
+			JobType:             jobType,
+			Pending:             pending[jobType],
+			Lag:                 lag,
+			ThroughputPerMinute: float64(finished) / throughputWindow.Minutes(),
+			CheckedAt:           now,
+		}
+		depth.EstimatedWaitSeconds = estimateWait(depth.Pending, depth.ThroughputPerMinute)
+
+		m.mu.Lock()
+		m.depths[jobType] = depth
+		m.mu.Unlock()
+	}
+}
+
+// estimateWait returns the seconds needed to work through pending jobs at the
+// given rate, or -1 when nothing has finished recently to estimate from
+func estimateWait(pending int64, perMinute float64) int64 {
+	if pending == 0 {
+		return 0
+	}
+	if perMinute <= 0 {
+		return -1
+	}
+	return int64(math.Ceil(float64(pending) / perMinute * 60))
+}
+
+// checkBackpressure applies the backpressure policy of the caller's tenant to a new job.
+// It returns a *BackpressureError if the job must be refused, or the warning to attach if it is accepted.
+func (s *jobsService) checkBackpressure(tenant string, jobType models.JobType) (*models.QueueWarning, error) {
+	if s.queues == nil {
+		return nil, nil
+	}
+	config := s.currentConfig().Backpressure
+	mode := config.modeFor(tenant)
+	if mode != BackpressureReject && mode != BackpressureWarn {
+		return nil, nil
+	}
+
+	depth, ok := s.queues.Depth(jobType)
+	if !ok || !config.overloaded(depth) {
+		return nil, nil
+	}
+
+	if mode == BackpressureReject {
+		retryAfter := time.Duration(depth.EstimatedWaitSeconds) * time.Second
+		if retryAfter < minBackpressureRetryAfter {
+			retryAfter = minBackpressureRetryAfter
+		}
+		return nil, &BackpressureError{Queue: depth, RetryAfter: retryAfter}
+	}
+
+	message := fmt.Sprintf("the %s queue is over capacity; the job may start late", jobType)
+	if depth.EstimatedWaitSeconds > 0 {
+		message = fmt.Sprintf("the %s queue is over capacity; the job is expected to start in about %s",
+			jobType, time.Duration(depth.EstimatedWaitSeconds)*time.Second)
+	}
+	return &models.QueueWarning{Message: message, Queue: depth}, nil
+}
+
+// estimateStart sets the queue position of a pending job and, when the recent
+// throughput of its type is known, the time it is expected to start.
+// Estimates are best effort; a failure leaves the job without them.
+func (s *jobsService) estimateStart(ctx context.Context, job *models.Job) {
+	ahead, err := s.repo.CountPendingAhead(ctx, job)
+	if err != nil {
+		log.Printf("Failed to compute queue position of job %s: %v", job.ID.String(), err)
+		return
+	}
+	position := ahead + 1
+	job.QueuePosition = &position
+
+	if s.queues == nil {
+		return
+	}
+	depth, ok := s.queues.Depth(job.JobType)
+	if !ok {
+		return
+	}
+	if wait := estimateWait(ahead, depth.ThroughputPerMinute); wait >= 0 {
+		startAt := time.Now().Add(time.Duration(wait) * time.Second)
+		job.EstimatedStartAt = &startAt
+	}
+}
@@ -0,0 +1,15 @@
+package services
+
+import (
+	"math/rand"
+
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// routeToCanary decides whether a new job of the given type is routed to the
+// workers running the canary executor version, as configured per type. The
+// decision is stored with the job so its retries stay on the same version.
+func (s *jobsService) routeToCanary(jobType models.JobType) bool {
+	percent := s.currentConfig().CanaryPercent[string(jobType)]
+	return percent > 0 && rand.Intn(100) < percent
+}
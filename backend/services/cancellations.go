@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"github.com/segmentio/kafka-go"
+)
+
+// cancellationsTopic returns the topic cancellation requests of the two-phase cancellation flow are published to
+func cancellationsTopic() string {
+	return topicName("job_cancellations")
+}
+
+// cancellationAcksTopic returns the topic workers acknowledge cancellations on
+func cancellationAcksTopic() string {
+	return topicName("cancellation_acks")
+}
+
+var cancellationEscalations = metrics.NewCounter("cancellation_escalations_total",
+	"Unacknowledged cancellations re-sent or resolved by the sweeper or an admin, by action.", "action")
+
+// CancellationAckConsumer completes cancellations acknowledged by workers,
+// moving jobs from cancelling to cancelled
+type CancellationAckConsumer struct {
+	conn   *KafkaConnection
+	repo   repositories.JobsRepository
+	poison repositories.PoisonMessagesRepository
+	events *EventBus
+}
+
+// NewCancellationAckConsumer creates a new cancellation acknowledgement consumer
+func NewCancellationAckConsumer(conn *KafkaConnection, repo repositories.JobsRepository, poison repositories.PoisonMessagesRepository, events *EventBus) *CancellationAckConsumer {
+	return &CancellationAckConsumer{
+		conn:   conn,
+		repo:   repo,
+		poison: poison,
+		events: events,
+	}
+}
+
+// cancellationAcksGroup returns the consumer group of the cancellation acknowledgement consumer
+func cancellationAcksGroup() string {
+	return groupName("backend-cancellation-acks")
+}
+
+// Run consumes acknowledgements until ctx is cancelled
+func (c *CancellationAckConsumer) Run(ctx context.Context) {
+	config := c.conn.ReaderConfig(cancellationAcksTopic(), cancellationAcksGroup())
+	config.MinBytes = 1
+	config.MaxBytes = 10e6
+	reader := kafka.NewReader(config)
+	defer reader.Close()
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error reading cancellation ack: %v", err)
+			continue
+		}
+
+		var ack CancellationAckMessage
+		if err := json.Unmarshal(msg.Value, &ack); err != nil {
+			log.Printf("Error unmarshaling cancellation ack: %v", err)
+			recordPoisonMessage(ctx, c.poison, cancellationAcksGroup(), msg, err)
+		} else if completed, err := c.repo.CompleteCancellation(ctx, ack.JobID, ""); err != nil {
+			// Leave the offset uncommitted so the ack is redelivered
+			log.Printf("Failed to complete cancellation of job %s: %v", ack.JobID, err)
+			continue
+		} else if completed {
+			log.Printf("Job %s cancelled (acknowledged by %s)", ack.JobID, ack.WorkerID)
+			if id, err := models.ParseJobID(ack.JobID); err == nil {
+				c.events.StatusChangedByID(ctx, id, models.JobStatusCancelling, models.JobStatusCancelled)
+			}
+		}
+
+		if err := reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to commit cancellation ack offset: %v", err)
+		}
+	}
+}
+
+// CancellationSweeper escalates cancellations that no worker acknowledged in time.
+// The cancellation is re-sent until maxAttempts is reached, after which it is
+// resolved: the job is cancelled without an acknowledgement, or moved back to
+// processing if a live worker is still running it.
+type CancellationSweeper struct {
+	repo          repositories.JobsRepository
+	outbox        repositories.OutboxRepository
+	producer      *KafkaProducer
+	cancellations CancellationsService
+	timeout       time.Duration
+	maxAttempts   int
+}
+
+// NewCancellationSweeper creates a new cancellation sweeper
+func NewCancellationSweeper(repo repositories.JobsRepository, outbox repositories.OutboxRepository, producer *KafkaProducer, cancellations CancellationsService, timeout time.Duration, maxAttempts int) *CancellationSweeper {
+	return &CancellationSweeper{
+		repo:          repo,
+		outbox:        outbox,
+		producer:      producer,
+		cancellations: cancellations,
+		timeout:       timeout,
+		maxAttempts:   maxAttempts,
+	}
+}
+
+// Run sweeps unacknowledged cancellations until ctx is cancelled
+func (s *CancellationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep escalates one batch of overdue cancellations
+func (s *CancellationSweeper) sweep(ctx context.Context) {
+	jobs, err := s.repo.ListUnacknowledgedCancellations(ctx, time.Now().Add(-s.timeout), 100)
+	if err != nil {
+		log.Printf("Failed to list unacknowledged cancellations: %v", err)
+		return
+	}
+
+	// The workers' in-flight jobs are read once per sweep, when a cancellation is resolved
+	var inFlight map[string]string
+	for _, job := range jobs {
+		id := job.ID.String()
+
+		if job.CancelAttempts >= s.maxAttempts {
+			if inFlight == nil {
+				if inFlight, err = s.cancellations.InFlightJobs(ctx); err != nil {
+					log.Printf("Failed to resolve unacknowledged cancellations: %v", err)
+					return
+				}
+			}
+			if _, _, err := s.cancellations.ResolveCancellation(ctx, job, inFlight, false); err != nil {
+				log.Printf("Failed to resolve cancellation of job %s after %d unacknowledged attempts: %v", id, job.CancelAttempts, err)
+			}
+			continue
+		}
+
+		message := CancellationMessage{
+			SchemaVersion: MessageSchemaVersion,
+			JobID:         id,
+			CancelledAt:   time.Now(),
+			CancelledBy:   job.CancelledBy,
+			Reason:        job.CancellationReason,
+			Tenant:        &job.Tenant,
+		}
+		publishWithOutbox(ctx, s.producer, s.outbox, cancellationsTopic(), "", message)
+
+		if err := s.repo.RecordCancellationResend(ctx, job.ID); err != nil {
+			log.Printf("Failed to record cancellation resend for job %s: %v", id, err)
+			continue
+		}
+		cancellationEscalations.Inc("resent")
+		log.Printf("Re-sent unacknowledged cancellation for job %s", id)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when the circuit breaker rejects a call
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState represents the state of a circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitHalfOpen
+	CircuitOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// CircuitBreaker stops calls to a failing dependency after consecutive failures
+// and lets a single trial call through once the open timeout has elapsed
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	state            CircuitState
+	failures         int
+	failureThreshold int
+	openTimeout      time.Duration
+	openedAt         time.Time
+	trialInFlight    bool
+	onStateChange    func(from, to CircuitState)
+}
+
+// NewCircuitBreaker creates a circuit breaker that opens after failureThreshold
+// consecutive failures and half-opens after openTimeout
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+	}
+}
+
+// OnStateChange registers a callback invoked on every state transition
+func (b *CircuitBreaker) OnStateChange(fn func(from, to CircuitState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onStateChange = fn
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen if not
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return ErrCircuitOpen
+		}
+		b.transition(CircuitHalfOpen)
+		b.trialInFlight = true
+		return nil
+	case CircuitHalfOpen:
+		if b.trialInFlight {
+			return ErrCircuitOpen
+		}
+		b.trialInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success records a successful call, closing the circuit
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trialInFlight = false
+	if b.state != CircuitClosed {
+		b.transition(CircuitClosed)
+	}
+}
+
+// Failure records a failed call, opening the circuit once the threshold is reached
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trialInFlight = false
+	if b.state == CircuitHalfOpen || b.failures >= b.failureThreshold {
+		b.openedAt = time.Now()
+		if b.state != CircuitOpen {
+			b.transition(CircuitOpen)
+		}
+	}
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+func (b *CircuitBreaker) transition(to CircuitState) {
+	from := b.state
+	b.state = to
+	if b.onStateChange != nil {
+		b.onStateChange(from, to)
+	}
+}
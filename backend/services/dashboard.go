@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// dashboardWindow is the period the throughput and failures of the dashboard cover
+const dashboardWindow = 24 * time.Hour
+
+// dashboardTopFailing is how many job types the dashboard lists by failures
+const dashboardTopFailing = 5
+
+// Dashboard combines the aggregates the dashboard shows, read in one call
+type Dashboard struct {
+	ByStatus   map[models.JobStatus]int64 `json:"byStatus"`
+	Throughput DashboardThroughput        `json:"throughput"`
+	// TopFailing lists the job types with the most failures in the window, most first
+	TopFailing []repositories.TypeFailures `json:"topFailing"`
+	// DLQDepth is nil when Kafka could not be reached
+	DLQDepth      *int64 `json:"dlqDepth"`
+	ActiveWorkers int    `json:"activeWorkers"`
+	// OldestPendingSeconds is how long the oldest pending job has waited, nil when none is pending
+	OldestPendingSeconds *int64    `json:"oldestPendingSeconds"`
+	GeneratedAt          time.Time `json:"generatedAt"`
+}
+
+// DashboardThroughput counts the jobs finished within the dashboard window
+type DashboardThroughput struct {
+	Window    string  `json:"window"`
+	Completed int64   `json:"completed"`
+	Failed    int64   `json:"failed"`
+	PerHour   float64 `json:"perHour"`
+}
+
+// DashboardService interface defines the methods for the dashboard summary
+type DashboardService interface {
+	GetDashboard(ctx context.Context) (*Dashboard, error)
+}
+
+type dashboardService struct {
+	jobs     repositories.JobsRepository
+	workers  WorkersService
+	producer *KafkaProducer
+}
+
+// NewDashboardService creates a new dashboard service
+func NewDashboardService(jobs repositories.JobsRepository, workers WorkersService, producer *KafkaProducer) DashboardService {
+	return &dashboardService{
+		jobs:     jobs,
+		workers:  workers,
+		producer: producer,
+	}
+}
+
+// GetDashboard runs the dashboard's queries in parallel. It fails if any of them
+// does, except for the DLQ depth, which is left out while Kafka is unavailable.
+func (s *dashboardService) GetDashboard(ctx context.Context) (*Dashboard, error) {
+	now := time.Now()
+	since := now.Add(-dashboardWindow)
+	dashboard := &Dashboard{
+		Throughput:  DashboardThroughput{Window: dashboardWindow.String()},
+		GeneratedAt: now,
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	run := func(name string, query func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := query(); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to read %s: %w", name, err)
+				}
+			}
+		}()
+	}
+
+	run("status counts", func() (err error) {
+		dashboard.ByStatus, err = s.jobs.CountByStatus(ctx)
+		return err
+	})
+	run("throughput", func() error {
+		outcomes, err := s.jobs.CountOutcomesSince(ctx, "", since)
+		if err != nil {
+			return err
+		}
+		dashboard.Throughput.Completed = outcomes[models.JobStatusCompleted]
+		dashboard.Throughput.Failed = outcomes[models.JobStatusFailed]
+		dashboard.Throughput.PerHour = float64(dashboard.Throughput.Completed+dashboard.Throughput.Failed) / dashboardWindow.Hours()
+		return nil
+	})
+	run("failing job types", func() (err error) {
+		dashboard.TopFailing, err = s.jobs.FailuresByTypeSince(ctx, since, dashboardTopFailing)
+		return err
+	})
+	run("DLQ depth", func() error {
+		depth, err := s.producer.TopicDepth(ctx, dlqTopic())
+		if err != nil {
+			log.Printf("Dashboard is missing the DLQ depth: %v", err)
+			return nil
+		}
+		dashboard.DLQDepth = &depth
+		return nil
+	})
+	run("workers", func() error {
+		workers, err := s.workers.ListActiveWorkers(ctx)
+		dashboard.ActiveWorkers = len(workers)
+		return err
+	})
+	run("oldest pending job", func() error {
+		createdAt, err := s.jobs.OldestPending(ctx)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		age := int64(now.Sub(createdAt).Seconds())
+		dashboard.OldestPendingSeconds = &age
+		return nil
+	})
+
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return dashboard, nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// DeadLettersService interface defines the methods for inspecting the job failures published to the DLQ
+type DeadLettersService interface {
+	ListDeadLetters(ctx context.Context, jobType, cursor string, limit int) (*DeadLetterPage, error)
+}
+
+// DeadLetterPage holds one page of DLQ entries. NextCursor is passed back as the
+// cursor to read the following page and is empty on the last page.
+type DeadLetterPage struct {
+	DeadLetters []models.DeadLetter `json:"deadLetters"`
+	NextCursor  string              `json:"nextCursor,omitempty"`
+}
+
+type deadLettersService struct {
+	repo repositories.DeadLettersRepository
+}
+
+// NewDeadLettersService creates a new dead letters service
+func NewDeadLettersService(repo repositories.DeadLettersRepository) DeadLettersService {
+	return &deadLettersService{repo: repo}
+}
+
+// ListDeadLetters retrieves a page of the most recent DLQ entries, optionally of one job type
+func (s *deadLettersService) ListDeadLetters(ctx context.Context, jobType, cursor string, limit int) (*DeadLetterPage, error) {
+	if jobType != "" && !models.IsValidJobType(jobType) {
+		return nil, &ValidationError{Field: "job_type", Message: fmt.Sprintf("invalid job type '%s'", jobType)}
+	}
+	if err := validatePageLimit(limit); err != nil {
+		return nil, err
+	}
+
+	page, err := s.repo.List(ctx, jobType, cursor, limit)
+	if err != nil {
+		return nil, pageError(err, "DLQ entries")
+	}
+
+	return &DeadLetterPage{DeadLetters: page.Items, NextCursor: page.NextCursor}, nil
+}
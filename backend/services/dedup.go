@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// Duplicate submission errors
+var (
+	// ErrDuplicateJob is returned when a job repeats a recent submission
+	ErrDuplicateJob = errors.New("duplicate job submission")
+	// ErrSubmissionInProgress is returned when a job repeats a submission whose
+	// job is still being created
+	ErrSubmissionInProgress = errors.New("a duplicate submission of this job is still being created, retry shortly")
+	// ErrJobIDTaken is returned when a client-supplied job ID is held by a job
+	// the caller cannot see
+	ErrJobIDTaken = errors.New("the job ID is already in use")
+)
+
+// submissionWaitInterval and submissionWaitAttempts bound how long a duplicate
+// submission waits for the job of the original one to be created
+const (
+	submissionWaitInterval = 100 * time.Millisecond
+	submissionWaitAttempts = 20
+)
+
+// DedupMode selects how duplicate submissions of a job type are handled
+type DedupMode string
+
+const (
+	// DedupOff accepts every submission
+	DedupOff DedupMode = "off"
+	// DedupReject refuses a duplicate submission
+	DedupReject DedupMode = "reject"
+	// DedupDedupe returns the job created by the original submission
+	DedupDedupe DedupMode = "dedupe"
+)
+
+// DedupPolicy treats jobs of one type with the same tenant, name and config
+// submitted within Window as duplicates
+type DedupPolicy struct {
+	Mode   DedupMode
+	Window time.Duration
+}
+
+func (p DedupPolicy) enabled() bool {
+	return (p.Mode == DedupReject || p.Mode == DedupDedupe) && p.Window > 0
+}
+
+// DuplicateJobError reports a submission matching a job created within the dedup window,
+// or carrying the client-supplied ID of an existing job
+type DuplicateJobError struct {
+	// Job is the job created by the original submission
+	Job *models.Job
+	// Rejected is true when the policy refuses duplicates rather than returning the original job,
+	// or when the ID is taken by a different job
+	Rejected bool
+	Window   time.Duration
+	// ByID is true when the submission reused the ID of an existing job
+	ByID bool
+}
+
+func (e *DuplicateJobError) Error() string {
+	if e.ByID && e.Rejected {
+		return fmt.Sprintf("job ID %s is already used by a different job", e.Job.ID.String())
+	}
+	if e.ByID {
+		return fmt.Sprintf("job %s was already created with this ID", e.Job.ID.String())
+	}
+	return fmt.Sprintf("job %s with the same name and config was submitted within the last %s", e.Job.ID.String(), e.Window)
+}
+
+func (e *DuplicateJobError) Unwrap() error {
+	return ErrDuplicateJob
+}
+
+// submissionKey identifies a submission by tenant, job type, name and config hash
+func submissionKey(tenant string, jobType models.JobType, name, configHash string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{tenant, string(jobType), name, configHash}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// dedupPolicyFor returns the duplicate submission policy for a job type
+func (s *jobsService) dedupPolicyFor(jobType models.JobType) DedupPolicy {
+	return s.currentConfig().Dedup[string(jobType)]
+}
+
+// claimSubmission records the submission of job under its dedup policy.
+// It returns a *DuplicateJobError when a matching job was submitted within the window,
+// and the key to release if the job cannot be created.
+func (s *jobsService) claimSubmission(ctx context.Context, tenant string, job *models.Job) (string, error) {
+	policy := s.dedupPolicyFor(job.JobType)
+	if !policy.enabled() {
+		return "", nil
+	}
+
+	key := submissionKey(tenant, job.JobType, job.Name, job.ConfigHash)
+	for attempt := 1; ; attempt++ {
+		existingID, err := s.repo.ClaimSubmission(ctx, key, job.ID, policy.Window)
+		if err != nil && !errors.Is(err, repositories.ErrConflict) {
+			return "", fmt.Errorf("failed to check for duplicate submissions: %w", err)
+		}
+		if err == nil && existingID.IsZero() {
+			return key, nil
+		}
+
+		if err == nil {
+			existing, err := s.repo.GetByID(ctx, existingID.String())
+			if err == nil {
+				return "", &DuplicateJobError{
+					Job:      existing,
+					Rejected: policy.Mode == DedupReject,
+					Window:   policy.Window,
+				}
+			}
+			if !errors.Is(err, repositories.ErrNotFound) {
+				return "", fmt.Errorf("failed to load the original submission: %w", err)
+			}
+		}
+
+		// The original job is still being created, or its creation failed and
+		// the claim is being released; either way the claim is tried again once
+		// it settles, so the two submissions never both create a job
+		if attempt == submissionWaitAttempts {
+			log.Printf("Submission claim for job %s has no job yet, refusing duplicate", existingID.String())
+			return "", ErrSubmissionInProgress
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(submissionWaitInterval):
+		}
+	}
+}
+
+// checkCreatedByID returns a *DuplicateJobError if a job with job's client-supplied
+// ID exists. The existing job stands in for a retried submission of the same
+// tenant, type, name and config; any other job holding the ID rejects it. An
+// ID free in the caller's scope is claimed for the job's tenant, as _id is not
+// unique across the shards of the jobs collection.
+func (s *jobsService) checkCreatedByID(ctx context.Context, job *models.Job) error {
+	existing, err := s.repo.GetByID(ctx, job.ID.String())
+	if errors.Is(err, repositories.ErrNotFound) {
+		err := s.repo.ClaimJobID(ctx, job.ID, job.Tenant)
+		if errors.Is(err, repositories.ErrConflict) {
+			return ErrJobIDTaken
+		}
+		if err != nil {
+			return fmt.Errorf("failed to claim the job ID: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check for an existing job: %w", err)
+	}
+
+	same := existing.Tenant == job.Tenant && existing.JobType == job.JobType &&
+		existing.Name == job.Name && existing.ConfigHash == job.ConfigHash
+	return &DuplicateJobError{
+		Job:      existing,
+		Rejected: !same,
+		ByID:     true,
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// JobEventType names a kind of job event
+type JobEventType string
+
+const (
+	// EventJobCreated is published when a job is created
+	EventJobCreated JobEventType = "job.created"
+	// EventJobStatusChanged is published when the backend moves a job to a new status
+	EventJobStatusChanged JobEventType = "job.status_changed"
+	// EventJobFailed is published after the status change of a job that failed
+	EventJobFailed JobEventType = "job.failed"
+)
+
+var jobEvents = metrics.NewCounter("job_events_total", "Job events published by the backend, by type.", "type")
+
+// JobEvent describes a change to a job made by this backend. Transitions
+// made by workers are not published.
+type JobEvent struct {
+	Type    JobEventType
+	JobID   models.JobID
+	JobType models.JobType
+	Status  models.JobStatus
+	// Tenant is the job's tenant, when the publisher has the job
+	Tenant string
+	// PreviousStatus is empty for created jobs
+	PreviousStatus models.JobStatus
+	// Job is the job after the change, when the publisher has it
+	Job *models.Job
+	At  time.Time
+}
+
+// JobEventHandler handles job events. Handlers run on the publisher's goroutine,
+// so slow work such as sending webhooks should be handed off.
+type JobEventHandler func(ctx context.Context, event JobEvent)
+
+// EventBus delivers job events from the service layer to the features reacting
+// to them, such as metrics, notifications and waiting clients, so the services
+// do not depend on each of them
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers map[JobEventType][]JobEventHandler
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[JobEventType][]JobEventHandler)}
+}
+
+// Subscribe registers handler for the given event types, or all of them when none are given
+func (b *EventBus) Subscribe(handler JobEventHandler, types ...JobEventType) {
+	if len(types) == 0 {
+		types = []JobEventType{EventJobCreated, EventJobStatusChanged, EventJobFailed}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, eventType := range types {
+		b.handlers[eventType] = append(b.handlers[eventType], handler)
+	}
+}
+
+// Publish delivers an event to its subscribers. A panicking handler is logged
+// and does not affect the others or the publisher. A nil bus drops the event.
+func (b *EventBus) Publish(ctx context.Context, event JobEvent) {
+	if b == nil {
+		return
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		deliver(ctx, handler, event)
+	}
+}
+
+// CountJobEvents is an event handler counting job events by type
+func CountJobEvents(ctx context.Context, event JobEvent) {
+	jobEvents.Inc(string(event.Type))
+}
+
+func deliver(ctx context.Context, handler JobEventHandler, event JobEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job event handler panicked on %s for job %s: %v", event.Type, event.JobID.String(), r)
+		}
+	}()
+	handler(ctx, event)
+}
+
+// JobCreated publishes the creation of a job
+func (b *EventBus) JobCreated(ctx context.Context, job *models.Job) {
+	b.Publish(ctx, JobEvent{
+		Type:    EventJobCreated,
+		JobID:   job.ID,
+		JobType: job.JobType,
+		Status:  job.Status,
+		Tenant:  job.Tenant,
+		Job:     job,
+		At:      job.CreatedAt,
+	})
+}
+
+// StatusChanged publishes a job's move from previous to its current status,
+// followed by EventJobFailed if it failed
+func (b *EventBus) StatusChanged(ctx context.Context, job *models.Job, previous models.JobStatus) {
+	b.statusChanged(ctx, JobEvent{
+		JobID:          job.ID,
+		JobType:        job.JobType,
+		Status:         job.Status,
+		Tenant:         job.Tenant,
+		PreviousStatus: previous,
+		Job:            job,
+	})
+}
+
+// StatusChangedByID publishes a status change for a publisher that does not hold the updated job
+func (b *EventBus) StatusChangedByID(ctx context.Context, id models.JobID, previous, status models.JobStatus) {
+	b.statusChanged(ctx, JobEvent{
+		JobID:          id,
+		Status:         status,
+		PreviousStatus: previous,
+	})
+}
+
+func (b *EventBus) statusChanged(ctx context.Context, event JobEvent) {
+	event.Type = EventJobStatusChanged
+	b.Publish(ctx, event)
+	if event.Status == models.JobStatusFailed {
+		event.Type = EventJobFailed
+		b.Publish(ctx, event)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// fanInBatchSize is how many waiting parents are loaded per page of a sweep
+const fanInBatchSize = 100
+
+var fanInCompletions = metrics.NewCounter("fan_in_completions_total",
+	"Parent jobs completed once all their child jobs were terminal, by final status.", "status")
+
+// FanInSweeper completes parent jobs whose child jobs have all reached a
+// terminal status. Children finish on workers or through cancellation
+// acknowledgements, so the sweeper checks the parents rather than reacting
+// to each of those paths.
+type FanInSweeper struct {
+	repo     repositories.JobsRepository
+	interval time.Duration
+	events   *EventBus
+}
+
+// NewFanInSweeper creates a new fan-in sweeper
+func NewFanInSweeper(repo repositories.JobsRepository, interval time.Duration, events *EventBus) *FanInSweeper {
+	return &FanInSweeper{
+		repo:     repo,
+		interval: interval,
+		events:   events,
+	}
+}
+
+// Run completes parents whose children are done until ctx is cancelled
+func (s *FanInSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep checks every parent waiting for its children
+func (s *FanInSweeper) sweep(ctx context.Context) {
+	var after models.JobID
+	for {
+		parents, err := s.repo.ListAwaitingChildren(ctx, after, fanInBatchSize)
+		if err != nil {
+			log.Printf("Failed to list jobs awaiting children: %v", err)
+			return
+		}
+
+		for _, parent := range parents {
+			s.settle(ctx, parent)
+		}
+		if len(parents) < fanInBatchSize {
+			return
+		}
+		after = parents[len(parents)-1].ID
+	}
+}
+
+// settle completes a parent if all its children are terminal. The parent
+// completes if every child did and fails otherwise.
+func (s *FanInSweeper) settle(ctx context.Context, parent models.Job) {
+	id := parent.ID.String()
+
+	counts, err := s.repo.CountChildrenByStatus(ctx, parent.ID)
+	if err != nil {
+		log.Printf("Failed to count children of job %s: %v", id, err)
+		return
+	}
+
+	children := models.JobChildren{
+		Completed: counts[models.JobStatusCompleted],
+		Failed:    counts[models.JobStatusFailed],
+		Cancelled: counts[models.JobStatusCancelled],
+	}
+	for _, count := range counts {
+		children.Total += count
+	}
+	if parent.Children != nil && parent.Children.Total > children.Total {
+		// Not every spawned child has been recorded yet
+		return
+	}
+	if children.Completed+children.Failed+children.Cancelled < children.Total {
+		return
+	}
+
+	status := models.JobStatusCompleted
+	errorMessage := ""
+	if unfinished := children.Failed + children.Cancelled; unfinished > 0 {
+		status = models.JobStatusFailed
+		errorMessage = fmt.Sprintf("%d of %d child jobs did not complete", unfinished, children.Total)
+	}
+
+	completed, err := s.repo.CompleteFanIn(ctx, parent.ID, status, children, errorMessage)
+	if err != nil {
+		log.Printf("Failed to complete job %s after its children: %v", id, err)
+		return
+	}
+	if !completed {
+		return
+	}
+	fanInCompletions.Inc(string(status))
+	s.events.StatusChangedByID(ctx, parent.ID, models.JobStatusAwaitingChildren, status)
+	log.Printf("Job %s %s after %d child jobs (%d completed, %d failed, %d cancelled)",
+		id, status, children.Total, children.Completed, children.Failed, children.Cancelled)
+}
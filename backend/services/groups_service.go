@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job group errors
+var (
+	ErrGroupNotFound  = errors.New("group not found")
+	ErrGroupCancelled = errors.New("group is cancelled")
+)
+
+// maxGroupBatch bounds how many jobs are attached or created in one request
+const maxGroupBatch = 1000
+
+// maxGroupNameLength bounds the size of a group name
+const maxGroupNameLength = 200
+
+// groupCancelBatchSize is how many jobs are loaded per page when cancelling a group
+const groupCancelBatchSize = 100
+
+// CreateGroupRequest represents the request to create a job group
+type CreateGroupRequest struct {
+	Name string `json:"name"`
+}
+
+// AttachJobsRequest represents the request to add existing jobs to a group
+type AttachJobsRequest struct {
+	JobIDs []string `json:"job_ids"`
+}
+
+// CreateGroupJobsRequest represents the request to create jobs into a group
+type CreateGroupJobsRequest struct {
+	Jobs []CreateJobRequest `json:"jobs"`
+}
+
+// AttachJobsResult reports how many jobs were added to a group
+type AttachJobsResult struct {
+	Attached int64            `json:"attached"`
+	Group    *models.JobGroup `json:"group"`
+}
+
+// CreateGroupJobsResult reports the outcome of each job of a bulk create, in request order
+type CreateGroupJobsResult struct {
+	Outcomes []GroupJobOutcome
+	Group    *models.JobGroup
+}
+
+// CancelGroupResult reports the outcome of cancelling each job of a group, in the order they were cancelled
+type CancelGroupResult struct {
+	Outcomes []GroupJobOutcome
+	Group    *models.JobGroup
+}
+
+// GroupJobOutcome is the outcome of a bulk operation on one job: the job it
+// created or changed, or the error that prevented it
+type GroupJobOutcome struct {
+	JobID models.JobID
+	Job   *models.Job
+	Err   error
+}
+
+// GroupsService interface defines the methods for managing job groups.
+// Groups belong to the tenant of the caller.
+type GroupsService interface {
+	CreateGroup(ctx context.Context, req CreateGroupRequest) (*models.JobGroup, error)
+	GetGroup(ctx context.Context, id string) (*models.JobGroup, error)
+	AttachJobs(ctx context.Context, id string, req AttachJobsRequest) (*AttachJobsResult, error)
+	CreateJobs(ctx context.Context, id string, req CreateGroupJobsRequest) (*CreateGroupJobsResult, error)
+	CancelGroup(ctx context.Context, id string, req CancelJobRequest) (*CancelGroupResult, error)
+}
+
+type groupsService struct {
+	repo     repositories.JobGroupsRepository
+	jobsRepo repositories.JobsRepository
+	jobs     JobsService
+}
+
+// NewGroupsService creates a new job groups service. Jobs are created and
+// cancelled through the jobs service so they follow the same rules as single jobs.
+func NewGroupsService(repo repositories.JobGroupsRepository, jobsRepo repositories.JobsRepository, jobs JobsService) GroupsService {
+	return &groupsService{
+		repo:     repo,
+		jobsRepo: jobsRepo,
+		jobs:     jobs,
+	}
+}
+
+// CreateGroup validates and stores a new group for the caller's tenant
+func (s *groupsService) CreateGroup(ctx context.Context, req CreateGroupRequest) (*models.JobGroup, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, &ValidationError{Field: "name", Message: "name is required"}
+	}
+	if len(name) > maxGroupNameLength {
+		return nil, &ValidationError{Field: "name", Message: fmt.Sprintf("name must not exceed %d characters", maxGroupNameLength)}
+	}
+
+	group := &models.JobGroup{
+		Tenant:    auth.Tenant(ctx),
+		Name:      name,
+		CreatedBy: auth.Actor(ctx),
+	}
+	if err := s.repo.Create(ctx, group); err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	group.Summarize(map[models.JobStatus]int64{})
+	return group, nil
+}
+
+// GetGroup retrieves a group with its aggregate status and progress
+func (s *groupsService) GetGroup(ctx context.Context, id string) (*models.JobGroup, error) {
+	group, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.summarize(ctx, group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// AttachJobs adds existing jobs to a group. Jobs that do not exist, belong to
+// another tenant than the group or already belong to a group are skipped and
+// not counted as attached.
+func (s *groupsService) AttachJobs(ctx context.Context, id string, req AttachJobsRequest) (*AttachJobsResult, error) {
+	if len(req.JobIDs) == 0 {
+		return nil, &ValidationError{Field: "job_ids", Message: "at least one job ID is required"}
+	}
+	if len(req.JobIDs) > maxGroupBatch {
+		return nil, &ValidationError{Field: "job_ids", Message: fmt.Sprintf("at most %d jobs may be attached at once", maxGroupBatch)}
+	}
+	ids := make([]models.JobID, 0, len(req.JobIDs))
+	for _, jobID := range req.JobIDs {
+		id, err := models.ParseJobID(jobID)
+		if err != nil {
+			return nil, &ValidationError{Field: "job_ids", Message: fmt.Sprintf("invalid job ID %q", jobID)}
+		}
+		ids = append(ids, id)
+	}
+
+	group, err := s.openGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	attached, err := s.jobsRepo.AssignGroup(ctx, group.ID, group.Tenant, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach jobs: %w", err)
+	}
+	if err := s.summarize(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return &AttachJobsResult{Attached: attached, Group: group}, nil
+}
+
+// CreateJobs creates jobs into a group. Each job is validated on its own, so
+// rejected requests are reported in their place and do not prevent the others.
+func (s *groupsService) CreateJobs(ctx context.Context, id string, req CreateGroupJobsRequest) (*CreateGroupJobsResult, error) {
+	if len(req.Jobs) == 0 {
+		return nil, &ValidationError{Field: "jobs", Message: "at least one job is required"}
+	}
+	if len(req.Jobs) > maxGroupBatch {
+		return nil, &ValidationError{Field: "jobs", Message: fmt.Sprintf("at most %d jobs may be created at once", maxGroupBatch)}
+	}
+
+	group, err := s.openGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CreateGroupJobsResult{Outcomes: make([]GroupJobOutcome, 0, len(req.Jobs))}
+	for _, jobReq := range req.Jobs {
+		jobReq.Tenant = group.Tenant
+		jobReq.GroupID = &group.ID
+		job, err := s.jobs.CreateJob(ctx, jobReq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.Outcomes = append(result.Outcomes, GroupJobOutcome{Err: err})
+			continue
+		}
+		result.Outcomes = append(result.Outcomes, GroupJobOutcome{JobID: job.ID, Job: job})
+	}
+
+	if err := s.summarize(ctx, group); err != nil {
+		return nil, err
+	}
+	result.Group = group
+	return result, nil
+}
+
+// CancelGroup marks a group cancelled, so no more jobs can be added to it, and
+// cancels its pending and processing jobs. Jobs in other states are left alone;
+// a job that cannot be cancelled is reported and does not stop the others.
+// Cancelling a cancelled group again cancels the jobs that are still pending or processing.
+func (s *groupsService) CancelGroup(ctx context.Context, id string, req CancelJobRequest) (*CancelGroupResult, error) {
+	if len(req.Reason) > maxCancellationReasonLength {
+		return nil, &ValidationError{
+			Field:   "reason",
+			Message: fmt.Sprintf("reason must not exceed %d characters", maxCancellationReasonLength),
+		}
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+	group, err := s.repo.MarkCancelled(ctx, auth.Tenant(ctx), objectID, req.Actor)
+	if errors.Is(err, repositories.ErrConflict) {
+		// Either the group does not exist or it was cancelled before, in which
+		// case its remaining jobs are cancelled again
+		if group, err = s.findGroup(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel group: %w", err)
+	}
+
+	result := &CancelGroupResult{}
+	var after models.JobID
+	for {
+		jobs, err := s.jobsRepo.ListCancellableInGroup(ctx, objectID, after, groupCancelBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list group jobs: %w", err)
+		}
+		for _, job := range jobs {
+			// A job that finished meanwhile fails with ErrInvalidJobState
+			cancelledJob, err := s.jobs.CancelJob(ctx, job.ID.String(), req)
+			if err != nil && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.Outcomes = append(result.Outcomes, GroupJobOutcome{JobID: job.ID, Job: cancelledJob, Err: err})
+		}
+		if len(jobs) < groupCancelBatchSize {
+			break
+		}
+		after = jobs[len(jobs)-1].ID
+	}
+
+	if err := s.summarize(ctx, group); err != nil {
+		return nil, err
+	}
+	result.Group = group
+	return result, nil
+}
+
+// findGroup retrieves a group of the caller's tenant
+func (s *groupsService) findGroup(ctx context.Context, id string) (*models.JobGroup, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+	group, err := s.repo.GetByID(ctx, auth.Tenant(ctx), objectID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrGroupNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	return group, nil
+}
+
+// openGroup retrieves a group that jobs can still be added to
+func (s *groupsService) openGroup(ctx context.Context, id string) (*models.JobGroup, error) {
+	group, err := s.findGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if group.CancelledAt != nil {
+		return nil, ErrGroupCancelled
+	}
+	return group, nil
+}
+
+// summarize fills in the group's aggregate status and progress
+func (s *groupsService) summarize(ctx context.Context, group *models.JobGroup) error {
+	counts, err := s.jobsRepo.CountByStatusInGroup(ctx, group.ID)
+	if err != nil {
+		return fmt.Errorf("failed to count group jobs: %w", err)
+	}
+	group.Summarize(counts)
+	return nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeGroupsRepository holds groups by ID
+type fakeGroupsRepository struct {
+	repositories.JobGroupsRepository
+	groups map[primitive.ObjectID]*models.JobGroup
+}
+
+func (r *fakeGroupsRepository) GetByID(ctx context.Context, tenant string, id primitive.ObjectID) (*models.JobGroup, error) {
+	group, ok := r.groups[id]
+	if !ok || group.Tenant != tenant {
+		return nil, repositories.ErrNotFound
+	}
+	copied := *group
+	return &copied, nil
+}
+
+func (r *fakeGroupsRepository) MarkCancelled(ctx context.Context, tenant string, id primitive.ObjectID, actor string) (*models.JobGroup, error) {
+	group, ok := r.groups[id]
+	if !ok || group.Tenant != tenant || group.CancelledAt != nil {
+		return nil, repositories.ErrConflict
+	}
+	now := time.Now()
+	group.CancelledAt = &now
+	group.CancelledBy = actor
+	copied := *group
+	return &copied, nil
+}
+
+// fakeGroupJobsRepository lists the cancellable jobs of a group in ID order
+type fakeGroupJobsRepository struct {
+	repositories.JobsRepository
+	cancellable []models.Job
+	pages       int
+}
+
+func (r *fakeGroupJobsRepository) ListCancellableInGroup(ctx context.Context, groupID primitive.ObjectID, after models.JobID, limit int) ([]models.Job, error) {
+	r.pages++
+	var jobs []models.Job
+	for _, job := range r.cancellable {
+		if job.GroupID != nil && *job.GroupID == groupID && job.ID.String() > after.String() && len(jobs) < limit {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *fakeGroupJobsRepository) CountByStatusInGroup(ctx context.Context, groupID primitive.ObjectID) (map[models.JobStatus]int64, error) {
+	return map[models.JobStatus]int64{}, nil
+}
+
+// fakeGroupJobsService creates jobs with a type and cancels any job but those in finished
+type fakeGroupJobsService struct {
+	JobsService
+	created  []CreateJobRequest
+	finished map[models.JobID]bool
+}
+
+func (s *fakeGroupJobsService) CreateJob(ctx context.Context, req CreateJobRequest) (*models.Job, error) {
+	if req.JobType == "" {
+		return nil, &ValidationError{Field: "job_type", Message: "job_type is required"}
+	}
+	s.created = append(s.created, req)
+	return &models.Job{ID: models.JobIDFromObjectID(primitive.NewObjectID()), JobType: models.JobType(req.JobType), GroupID: req.GroupID}, nil
+}
+
+func (s *fakeGroupJobsService) CancelJob(ctx context.Context, id string, req CancelJobRequest) (*models.Job, error) {
+	jobID, _ := models.ParseJobID(id)
+	if s.finished[jobID] {
+		return nil, ErrInvalidJobState
+	}
+	return &models.Job{ID: jobID, Status: models.JobStatusCancelling}, nil
+}
+
+func newTestGroup(tenant string, cancelled bool) *models.JobGroup {
+	group := &models.JobGroup{ID: primitive.NewObjectID(), Tenant: tenant, Name: "nightly"}
+	if cancelled {
+		now := time.Now()
+		group.CancelledAt = &now
+	}
+	return group
+}
+
+func TestCreateGroupJobs(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "user-1", Tenant: "acme"})
+	open := newTestGroup("acme", false)
+	cancelled := newTestGroup("acme", true)
+	foreign := newTestGroup("globex", false)
+
+	tests := []struct {
+		name    string
+		group   string
+		jobs    []CreateJobRequest
+		want    []bool
+		wantErr error
+	}{
+		{
+			name:  "each job on its own",
+			group: open.ID.Hex(),
+			jobs:  []CreateJobRequest{{JobType: "process"}, {}, {JobType: "export"}},
+			want:  []bool{true, false, true},
+		},
+		{name: "cancelled group", group: cancelled.ID.Hex(), jobs: []CreateJobRequest{{JobType: "process"}}, wantErr: ErrGroupCancelled},
+		{name: "another tenant's group", group: foreign.ID.Hex(), jobs: []CreateJobRequest{{JobType: "process"}}, wantErr: ErrGroupNotFound},
+		{name: "invalid group ID", group: "nightly", jobs: []CreateJobRequest{{JobType: "process"}}, wantErr: ErrGroupNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := &fakeGroupsRepository{groups: map[primitive.ObjectID]*models.JobGroup{open.ID: open, cancelled.ID: cancelled, foreign.ID: foreign}}
+			jobs := &fakeGroupJobsService{}
+			service := NewGroupsService(groups, &fakeGroupJobsRepository{}, jobs)
+
+			result, err := service.CreateJobs(ctx, tt.group, CreateGroupJobsRequest{Jobs: tt.jobs})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("CreateJobs() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(jobs.created) != 0 {
+					t.Errorf("created %d jobs, want none", len(jobs.created))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateJobs() error = %v", err)
+			}
+
+			if len(result.Outcomes) != len(tt.want) {
+				t.Fatalf("CreateJobs() returned %d outcomes, want one per job in request order", len(result.Outcomes))
+			}
+			for i, created := range tt.want {
+				outcome := result.Outcomes[i]
+				if created != (outcome.Err == nil) || created != (outcome.Job != nil) {
+					t.Errorf("outcome %d = %+v, want created %v", i, outcome, created)
+				}
+				var validationErr *ValidationError
+				if !created && !errors.As(outcome.Err, &validationErr) {
+					t.Errorf("outcome %d error = %v, want the job's validation error", i, outcome.Err)
+				}
+			}
+			for _, req := range jobs.created {
+				if req.Tenant != open.Tenant || req.GroupID == nil || *req.GroupID != open.ID {
+					t.Errorf("created job tenant %q group %v, want the group's", req.Tenant, req.GroupID)
+				}
+			}
+			if result.Group == nil || result.Group.ID != open.ID {
+				t.Errorf("CreateJobs() group = %+v, want the summarized group", result.Group)
+			}
+		})
+	}
+}
+
+func TestCreateGroupJobsBatchSize(t *testing.T) {
+	service := NewGroupsService(&fakeGroupsRepository{}, &fakeGroupJobsRepository{}, &fakeGroupJobsService{})
+	for _, count := range []int{0, maxGroupBatch + 1} {
+		_, err := service.CreateJobs(context.Background(), primitive.NewObjectID().Hex(), CreateGroupJobsRequest{Jobs: make([]CreateJobRequest, count)})
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "jobs" {
+			t.Errorf("CreateJobs() of %d jobs error = %v, want a validation error on jobs", count, err)
+		}
+	}
+}
+
+func TestCancelGroup(t *testing.T) {
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "user-1", Tenant: "acme"})
+	groupJobs := func(group *models.JobGroup, n int) []models.Job {
+		jobs := make([]models.Job, n)
+		for i := range jobs {
+			jobs[i] = models.Job{ID: models.JobIDFromObjectID(primitive.NewObjectID()), GroupID: &group.ID, Status: models.JobStatusPending}
+		}
+		return jobs
+	}
+
+	tests := []struct {
+		name      string
+		cancelled bool
+		tenant    string
+		jobs      int
+		wantErr   error
+		wantPages int
+	}{
+		{name: "open group", tenant: "acme", jobs: 3, wantPages: 1},
+		{name: "more than a page", tenant: "acme", jobs: groupCancelBatchSize + 1, wantPages: 2},
+		{name: "cancelled again", cancelled: true, tenant: "acme", jobs: 2, wantPages: 1},
+		{name: "another tenant's group", tenant: "globex", jobs: 2, wantErr: ErrGroupNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := newTestGroup(tt.tenant, tt.cancelled)
+			repo := &fakeGroupJobsRepository{cancellable: groupJobs(group, tt.jobs)}
+			// The first job finished after it was listed
+			jobs := &fakeGroupJobsService{finished: map[models.JobID]bool{repo.cancellable[0].ID: true}}
+			groups := &fakeGroupsRepository{groups: map[primitive.ObjectID]*models.JobGroup{group.ID: group}}
+			service := NewGroupsService(groups, repo, jobs)
+
+			result, err := service.CancelGroup(ctx, group.ID.Hex(), CancelJobRequest{Actor: "user-1"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("CancelGroup() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CancelGroup() error = %v", err)
+			}
+
+			if group.CancelledAt == nil || result.Group == nil || result.Group.CancelledAt == nil {
+				t.Errorf("CancelGroup() group = %+v, want it cancelled", result.Group)
+			}
+			if repo.pages != tt.wantPages {
+				t.Errorf("listed %d pages of jobs, want %d", repo.pages, tt.wantPages)
+			}
+			if len(result.Outcomes) != tt.jobs {
+				t.Fatalf("CancelGroup() returned %d outcomes, want one per cancellable job", len(result.Outcomes))
+			}
+			for i, outcome := range result.Outcomes {
+				if outcome.JobID != repo.cancellable[i].ID {
+					t.Errorf("outcome %d is for job %s, want %s", i, outcome.JobID.String(), repo.cancellable[i].ID.String())
+				}
+				finished := i == 0
+				if finished != errors.Is(outcome.Err, ErrInvalidJobState) || finished != (outcome.Job == nil) {
+					t.Errorf("outcome %d = %+v, want finished %v", i, outcome, finished)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/secrets"
+)
+
+// HookPreEnqueue is the lifecycle point before a job is stored and queued. The
+// workers run the hooks of the later points, pre_execute and post_complete.
+const HookPreEnqueue = "pre_enqueue"
+
+var hookCalls = metrics.NewCounter("job_hook_calls_total", "Lifecycle hook calls by point and result.", "point", "result")
+
+// HookRejectedError is returned when a hook rejects a job
+type HookRejectedError struct {
+	Hook   string
+	Reason string
+}
+
+func (e *HookRejectedError) Error() string {
+	return fmt.Sprintf("job rejected by hook %s: %s", e.Hook, e.Reason)
+}
+
+// PreEnqueueHook is called with every new job before it is stored and queued.
+// It may validate the job, rejecting it with a *HookRejectedError, or enrich it
+// by changing its name, config or affinity key. The config holds encrypted
+// values as envelopes. Any other error fails the creation.
+type PreEnqueueHook interface {
+	// Name identifies the hook in errors and logs
+	Name() string
+	PreEnqueue(ctx context.Context, job *models.Job) error
+}
+
+// Hooks holds the lifecycle hooks registered with the jobs service. Hooks run
+// in the order they were registered.
+type Hooks struct {
+	mu         sync.RWMutex
+	preEnqueue []PreEnqueueHook
+}
+
+// NewHooks creates an empty hook registry
+func NewHooks() *Hooks {
+	return &Hooks{}
+}
+
+// RegisterPreEnqueue adds a hook run before jobs are created
+func (h *Hooks) RegisterPreEnqueue(hook PreEnqueueHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.preEnqueue = append(h.preEnqueue, hook)
+}
+
+func (h *Hooks) preEnqueueHooks() []PreEnqueueHook {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.preEnqueue
+}
+
+// runPreEnqueueHooks runs the pre-enqueue hooks on a job, stopping at the first
+// error. Config keys the hooks add are encrypted like submitted ones, and the
+// config hash is updated.
+func (s *jobsService) runPreEnqueueHooks(ctx context.Context, job *models.Job) error {
+	hooks := s.hooks.preEnqueueHooks()
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	for _, hook := range hooks {
+		err := hook.PreEnqueue(ctx, job)
+		switch err.(type) {
+		case nil:
+			hookCalls.Inc(HookPreEnqueue, "accepted")
+		case *HookRejectedError:
+			hookCalls.Inc(HookPreEnqueue, "rejected")
+			return err
+		default:
+			hookCalls.Inc(HookPreEnqueue, "failed")
+			return fmt.Errorf("hook %s failed: %w", hook.Name(), err)
+		}
+	}
+
+	config, err := s.encryptor.EncryptConfig(ctx, job.Config)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt config changed by hooks: %w", err)
+	}
+	configHash, err := models.HashConfig(secrets.RedactConfig(config))
+	if err != nil {
+		return fmt.Errorf("hooks left a config that is not JSON serializable: %w", err)
+	}
+	job.Config = config
+	job.ConfigHash = configHash
+	return nil
+}
+
+// HTTPHook runs a lifecycle hook on an external endpoint. The endpoint receives
+// {"point": ..., "job": ...} as JSON, with the job as the API returns it, and
+// accepts the job with a 2xx response. The response may carry {"config": {...}}
+// with keys to add to or replace in the job's config. A 4xx response rejects
+// the job, with {"reason": "..."} telling the caller why.
+type HTTPHook struct {
+	url    string
+	client *http.Client
+	// failOpen accepts jobs unchanged when the endpoint is unreachable or fails
+	failOpen bool
+}
+
+// NewHTTPHook creates a hook calling endpoint, waiting up to timeout for it
+func NewHTTPHook(endpoint string, timeout time.Duration, failOpen bool) *HTTPHook {
+	return &HTTPHook{
+		url:      endpoint,
+		client:   &http.Client{Timeout: timeout},
+		failOpen: failOpen,
+	}
+}
+
+// Name returns the host of the endpoint, leaving out any credentials in its URL
+func (h *HTTPHook) Name() string {
+	if u, err := url.Parse(h.url); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "http"
+}
+
+type hookRequest struct {
+	Point string      `json:"point"`
+	Job   *models.Job `json:"job"`
+}
+
+type hookResponse struct {
+	Reason string                 `json:"reason"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// PreEnqueue calls the endpoint and applies the config keys it returns
+func (h *HTTPHook) PreEnqueue(ctx context.Context, job *models.Job) error {
+	response, err := h.call(ctx, HookPreEnqueue, job)
+	if err != nil {
+		if _, rejected := err.(*HookRejectedError); rejected || !h.failOpen {
+			return err
+		}
+		log.Printf("Hook %s failed, accepting job %s unchanged: %v", h.Name(), job.ID.String(), err)
+		return nil
+	}
+
+	if len(response.Config) > 0 && job.Config == nil {
+		job.Config = make(map[string]interface{}, len(response.Config))
+	}
+	for key, value := range response.Config {
+		job.Config[key] = value
+	}
+	return nil
+}
+
+func (h *HTTPHook) call(ctx context.Context, point string, job *models.Job) (*hookResponse, error) {
+	body, err := json.Marshal(hookRequest{Point: point, Job: job})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Accepting responses must hold JSON if they have a body; rejections are only read for their reason
+	var response hookResponse
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &response); err != nil && resp.StatusCode < 300 {
+			return nil, fmt.Errorf("invalid hook response: %w", err)
+		}
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return &response, nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		reason := response.Reason
+		if reason == "" {
+			reason = http.StatusText(resp.StatusCode)
+		}
+		return nil, &HookRejectedError{Hook: h.Name(), Reason: reason}
+	default:
+		return nil, fmt.Errorf("hook returned status %d", resp.StatusCode)
+	}
+}
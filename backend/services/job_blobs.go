@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// DefaultConfigOffloadBytes is the encoded size above which a job's config is
+// stored in GridFS when no threshold is configured
+const DefaultConfigOffloadBytes = 256 << 10
+
+// offloadConfig moves a job's config to GridFS when its encoded size exceeds the
+// configured threshold, leaving a reference on the job. Configs are stored as the
+// JSON they are published as, so the worker decodes them like an inline config.
+func (s *jobsService) offloadConfig(ctx context.Context, job *models.Job) error {
+	if len(job.Config) == 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(job.Config)
+	if err != nil {
+		return &ValidationError{Field: "config", Message: "config must be JSON serializable"}
+	}
+	if int64(len(encoded)) <= s.currentConfig().ConfigOffloadBytes {
+		return nil
+	}
+
+	id, err := s.blobs.Put(ctx, fmt.Sprintf("configs/%s.json", job.ID.String()), encoded)
+	if err != nil {
+		return fmt.Errorf("failed to store job config: %w", err)
+	}
+	job.ConfigRef = &models.BlobRef{ID: id, Size: int64(len(encoded))}
+	job.Config = nil
+	return nil
+}
+
+// loadConfig reads back the config of a job that was offloaded to GridFS
+func (s *jobsService) loadConfig(ctx context.Context, job *models.Job) error {
+	return loadConfigBlob(ctx, s.blobs, job)
+}
+
+// loadConfigBlob reads the offloaded config of a job into its config
+func loadConfigBlob(ctx context.Context, blobs repositories.JobBlobsRepository, job *models.Job) error {
+	if job.ConfigRef == nil || job.Config != nil {
+		return nil
+	}
+
+	encoded, err := blobs.Get(ctx, job.ConfigRef.ID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return fmt.Errorf("config %s of job %s is missing", job.ConfigRef.ID.Hex(), job.ID.String())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load job config: %w", err)
+	}
+	if err := json.Unmarshal(encoded, &job.Config); err != nil {
+		return fmt.Errorf("failed to decode job config: %w", err)
+	}
+	return nil
+}
+
+// deleteConfigBlob removes the offloaded config of a job that was not created
+func (s *jobsService) deleteConfigBlob(ctx context.Context, job *models.Job) {
+	if job.ConfigRef == nil {
+		return
+	}
+	if err := s.blobs.Delete(ctx, job.ConfigRef.ID); err != nil {
+		log.Printf("Failed to delete config %s of job %s: %v", job.ConfigRef.ID.Hex(), job.ID.String(), err)
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// ListChildren returns the child jobs spawned from a job in the order they were spawned.
+// Each child carries its own children summary, so the tree can be walked
+// one level at a time.
+func (s *jobsService) ListChildren(ctx context.Context, id string, limit int) ([]models.Job, error) {
+	parent, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit < 1 {
+		limit = DefaultPageLimit
+	}
+	if maxLimit := s.currentConfig().MaxPageLimit; limit > maxLimit {
+		limit = maxLimit
+	}
+
+	children, err := s.repo.ListChildren(ctx, parent.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list child jobs: %w", err)
+	}
+	return children, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// Job comment limits
+const (
+	MaxCommentLength = 4000
+	MaxJobComments   = 500
+)
+
+// CreateCommentRequest represents the request body for commenting on a job
+type CreateCommentRequest struct {
+	Text string `json:"text"`
+}
+
+// JobCommentsService interface defines the methods for job comments
+type JobCommentsService interface {
+	AddComment(ctx context.Context, id string, req CreateCommentRequest) (*models.JobComment, error)
+	ListComments(ctx context.Context, id string) ([]models.JobComment, error)
+	// ListAuditEvents pages through the comments of all jobs, the audit trail of operator actions
+	ListAuditEvents(ctx context.Context, jobID, author, cursor string, limit int) (*AuditEventPage, error)
+}
+
+// AuditEventPage holds one page of the audit trail. NextCursor is passed back as
+// the cursor to read the following page and is empty on the last page.
+type AuditEventPage struct {
+	Events     []models.JobComment `json:"events"`
+	NextCursor string              `json:"nextCursor,omitempty"`
+}
+
+type jobCommentsService struct {
+	jobs     repositories.JobsRepository
+	comments repositories.JobCommentsRepository
+}
+
+// NewJobCommentsService creates a new job comments service
+func NewJobCommentsService(jobs repositories.JobsRepository, comments repositories.JobCommentsRepository) JobCommentsService {
+	return &jobCommentsService{jobs: jobs, comments: comments}
+}
+
+// AddComment attaches a note from the caller to a job
+func (s *jobCommentsService) AddComment(ctx context.Context, id string, req CreateCommentRequest) (*models.JobComment, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, &ValidationError{Field: "text", Message: "comment text is required"}
+	}
+	if len(text) > MaxCommentLength {
+		return nil, &ValidationError{Field: "text", Message: fmt.Sprintf("comment must not exceed %d characters", MaxCommentLength)}
+	}
+
+	job, err := s.jobs.GetByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	comment := &models.JobComment{
+		JobID:  job.ID,
+		Author: auth.Actor(ctx),
+		Text:   text,
+	}
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// ListComments retrieves the comments on a job, oldest first
+func (s *jobCommentsService) ListComments(ctx context.Context, id string) ([]models.JobComment, error) {
+	job, err := s.jobs.GetByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	comments, err := s.comments.ListByJob(ctx, job.ID, MaxJobComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	return comments, nil
+}
+
+// ListAuditEvents retrieves a page of the comments of all jobs, newest first,
+// optionally of one job or author. Comments of deleted jobs are included until
+// the audit retention period removes them.
+func (s *jobCommentsService) ListAuditEvents(ctx context.Context, jobID, author, cursor string, limit int) (*AuditEventPage, error) {
+	var id *models.JobID
+	if jobID != "" {
+		parsed, err := models.ParseJobID(jobID)
+		if err != nil {
+			return nil, &ValidationError{Field: "job_id", Message: "job_id must be an ObjectID or a UUIDv7"}
+		}
+		id = &parsed
+	}
+	if err := validatePageLimit(limit); err != nil {
+		return nil, err
+	}
+
+	page, err := s.comments.List(ctx, id, author, cursor, limit)
+	if err != nil {
+		return nil, pageError(err, "audit events")
+	}
+
+	return &AuditEventPage{Events: page.Items, NextCursor: page.NextCursor}, nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// Hold errors
+var (
+	ErrJobAlreadyHeld = errors.New("job is already held")
+	ErrJobNotHeld     = errors.New("job is not held")
+)
+
+// maxHoldReasonLength bounds the size of a hold reason
+const maxHoldReasonLength = 1000
+
+// HoldJobRequest represents the request to hold a job for debugging
+type HoldJobRequest struct {
+	Reason string `json:"reason,omitempty"`
+	// Actor is the authenticated caller holding the job
+	Actor string `json:"-"`
+	// IfMatch lists the versions the job may be held at; nil holds any version
+	IfMatch []time.Time `json:"-"`
+}
+
+// HoldJob pins a job while it is investigated. A held job still runs if it is
+// queued, but a failure is not retried automatically, and neither bulk requeues
+// nor the pending reconciler republish it until it is released.
+func (s *jobsService) HoldJob(ctx context.Context, id string, req HoldJobRequest) (*models.Job, error) {
+	if len(req.Reason) > maxHoldReasonLength {
+		return nil, &ValidationError{
+			Field:   "reason",
+			Message: fmt.Sprintf("reason must not exceed %d characters", maxHoldReasonLength),
+		}
+	}
+
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(job, req.IfMatch) {
+		return nil, ErrPreconditionFailed
+	}
+	if job.Hold != nil {
+		return nil, ErrJobAlreadyHeld
+	}
+	if !job.CanBeHeld() {
+		return nil, ErrInvalidJobState
+	}
+
+	hold := &models.JobHold{By: req.Actor, Reason: req.Reason, At: time.Now()}
+	job, err = s.repo.SetHold(ctx, id, hold, req.IfMatch)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, staleJobError(req.IfMatch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to hold job: %w", err)
+	}
+
+	log.Printf("Job %s held by %s", id, req.Actor)
+	return job, nil
+}
+
+// ReleaseJob removes the hold of a job. A failed job is not retried on release;
+// it is left for a manual retry or requeue. Pending jobs are picked up by the
+// pending reconciler again if their message was lost while they were held.
+func (s *jobsService) ReleaseJob(ctx context.Context, id string, ifMatch []time.Time) (*models.Job, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(job, ifMatch) {
+		return nil, ErrPreconditionFailed
+	}
+	if job.Hold == nil {
+		return nil, ErrJobNotHeld
+	}
+
+	job, err = s.repo.ClearHold(ctx, id, ifMatch)
+	if errors.Is(err, repositories.ErrConflict) {
+		if ifMatch != nil {
+			return nil, ErrPreconditionFailed
+		}
+		return nil, ErrJobNotHeld
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to release job: %w", err)
+	}
+
+	log.Printf("Job %s released", id)
+	return job, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// DefaultMaxInputBytes caps the size of an uploaded job input when none is configured
+const DefaultMaxInputBytes = 100 << 20
+
+// ErrInputTooLarge is returned when an uploaded input exceeds the configured size limit
+var ErrInputTooLarge = errors.New("input file is too large")
+
+// InputUpload is a file uploaded as the input of a job
+type InputUpload struct {
+	Filename    string
+	ContentType string
+	Content     io.Reader
+}
+
+// AttachInput stores the input file of a job created with hold_for_input and
+// queues the job. The upload is spooled to a temporary file first, so its size
+// and checksum are known before it is stored and nothing is stored if it is too large.
+func (s *jobsService) AttachInput(ctx context.Context, id string, upload InputUpload) (*models.Job, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusAwaitingInput {
+		return nil, ErrInvalidJobState
+	}
+
+	spool, err := os.CreateTemp("", "job-input-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer input: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	maxBytes := s.currentConfig().MaxInputBytes
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(spool, hash), io.LimitReader(upload.Content, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if size > maxBytes {
+		return nil, ErrInputTooLarge
+	}
+	if size == 0 {
+		return nil, &ValidationError{Field: "file", Message: "input file is empty"}
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to buffer input: %w", err)
+	}
+
+	contentType := upload.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	filename := inputFilename(upload.Filename)
+	key := fmt.Sprintf("inputs/%s/%s", id, filename)
+	if err := s.artifacts.Put(ctx, key, spool, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store input: %w", err)
+	}
+
+	input := &models.JobInput{
+		Filename:    filename,
+		Storage:     s.artifacts.Name(),
+		Key:         key,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    "sha256:" + hex.EncodeToString(hash.Sum(nil)),
+		UploadedAt:  time.Now(),
+	}
+
+	// Jobs whose input arrives during maintenance are held like new submissions
+	status := models.JobStatusPending
+	if maintenance := s.maintenance.Status(); maintenance.Enabled && maintenance.Mode == MaintenanceHold {
+		status = models.JobStatusHeld
+	}
+
+	job, err = s.repo.AttachInput(ctx, id, input, status)
+	if errors.Is(err, repositories.ErrConflict) {
+		// Another upload for the job won the race
+		return nil, ErrInvalidJobState
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach input: %w", err)
+	}
+	s.events.StatusChanged(ctx, job, models.JobStatusAwaitingInput)
+
+	if job.Status == models.JobStatusPending {
+		s.publishJob(ctx, job)
+	}
+
+	return job, nil
+}
+
+// inputFilename reduces an uploaded file name to a safe storage key segment
+func inputFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "input"
+	}
+	return name
+}
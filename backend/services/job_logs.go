@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// ErrAttemptNotFound is returned when a job has no attempt with the requested number
+var ErrAttemptNotFound = errors.New("attempt not found")
+
+// Job log query limits
+const (
+	DefaultLogLimit = 1000
+	MaxLogLimit     = 5000
+)
+
+// LogQuery selects the log lines of a job attempt
+type LogQuery struct {
+	// Attempt is the attempt number; zero selects the latest attempt
+	Attempt int
+	// Tail returns only the last Tail lines when positive
+	Tail int
+	// AfterSeq returns only lines after this sequence number, for paging and following
+	AfterSeq int
+	Limit    int
+}
+
+// JobLogs is a page of log lines from one job attempt
+type JobLogs struct {
+	JobID   string              `json:"jobId"`
+	Attempt int                 `json:"attempt"`
+	Lines   []models.JobLogLine `json:"lines"`
+	// NextSeq is the sequence number to pass as AfterSeq to continue reading
+	NextSeq int `json:"nextSeq"`
+	// HasMore is true when more lines can be read right away from NextSeq
+	HasMore bool `json:"hasMore"`
+	// Running is true while the attempt may still produce output
+	Running bool `json:"running"`
+}
+
+// JobLogsService interface defines the methods for retrieving job logs
+type JobLogsService interface {
+	GetLogs(ctx context.Context, id string, query LogQuery) (*JobLogs, error)
+}
+
+type jobLogsService struct {
+	jobs repositories.JobsRepository
+	logs repositories.JobLogsRepository
+}
+
+// NewJobLogsService creates a new job logs service
+func NewJobLogsService(jobs repositories.JobsRepository, logs repositories.JobLogsRepository) JobLogsService {
+	return &jobLogsService{jobs: jobs, logs: logs}
+}
+
+// GetLogs retrieves the captured output of a job attempt
+func (s *jobLogsService) GetLogs(ctx context.Context, id string, query LogQuery) (*JobLogs, error) {
+	if query.Attempt < 0 {
+		return nil, &ValidationError{Field: "attempt", Message: "attempt must be a positive number"}
+	}
+	if query.Tail < 0 || query.Tail > MaxLogLimit {
+		return nil, &ValidationError{Field: "tail", Message: fmt.Sprintf("tail must be between 1 and %d", MaxLogLimit)}
+	}
+	if query.Limit < 1 || query.Limit > MaxLogLimit {
+		query.Limit = DefaultLogLimit
+	}
+
+	job, err := s.jobs.GetByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	result := &JobLogs{
+		JobID:   job.ID.String(),
+		Lines:   []models.JobLogLine{},
+		NextSeq: query.AfterSeq,
+	}
+
+	// A job that has not started yet has no logs, but will once it does
+	if len(job.Attempts) == 0 {
+		result.Running = !job.Status.IsTerminal()
+		return result, nil
+	}
+
+	attempt := job.Attempts[len(job.Attempts)-1]
+	if query.Attempt != 0 {
+		found := false
+		for _, a := range job.Attempts {
+			if a.Number == query.Attempt {
+				attempt, found = a, true
+			}
+		}
+		if !found {
+			return nil, ErrAttemptNotFound
+		}
+	}
+	result.Attempt = attempt.Number
+	result.Running = attempt.Outcome == models.AttemptOutcomeRunning
+
+	var lines []models.JobLogLine
+	if query.Tail > 0 {
+		lines, err = s.logs.Tail(ctx, job.ID, attempt.Number, query.Tail)
+	} else {
+		lines, err = s.logs.ListAfter(ctx, job.ID, attempt.Number, query.AfterSeq, query.Limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job logs: %w", err)
+	}
+
+	if len(lines) > 0 {
+		result.Lines = lines
+		result.NextSeq = lines[len(lines)-1].Seq
+	}
+	result.HasMore = query.Tail == 0 && len(lines) == query.Limit
+
+	return result, nil
+}
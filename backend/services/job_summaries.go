@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// DefaultSummaryFlushInterval is how often the jobs changed by this backend are
+// projected into their summaries
+const DefaultSummaryFlushInterval = 500 * time.Millisecond
+
+// summarySyncOverlap is how far each catch-up reaches back before the previous
+// one started, covering writers whose clocks run behind
+const summarySyncOverlap = 30 * time.Second
+
+var jobSummaryRefreshes = metrics.NewCounter("job_summary_refreshes_total",
+	"Projections of jobs into the job summaries, by trigger and result.", "trigger", "result")
+
+// JobSummaryProjector keeps the job summaries in step with the jobs. Changes
+// this backend makes arrive on the event bus and are projected within a flush
+// interval. Changes made by workers are not published, so the summaries also
+// catch up on all jobs updated since the previous sync.
+type JobSummaryProjector struct {
+	summaries     repositories.JobSummariesRepository
+	flushInterval time.Duration
+	syncInterval  time.Duration
+
+	mu      sync.Mutex
+	pending map[models.JobID]struct{}
+}
+
+// NewJobSummaryProjector creates a new job summary projector
+func NewJobSummaryProjector(summaries repositories.JobSummariesRepository, flushInterval, syncInterval time.Duration) *JobSummaryProjector {
+	return &JobSummaryProjector{
+		summaries:     summaries,
+		flushInterval: flushInterval,
+		syncInterval:  syncInterval,
+		pending:       make(map[models.JobID]struct{}),
+	}
+}
+
+// HandleEvent is an event handler queueing the job of an event for projection
+func (p *JobSummaryProjector) HandleEvent(ctx context.Context, event JobEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending[event.JobID] = struct{}{}
+}
+
+// Run projects the queued jobs until ctx is cancelled. Events are published on
+// every instance, so every instance runs it.
+func (p *JobSummaryProjector) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.flush(ctx)
+		}
+	}
+}
+
+// flush projects the queued jobs, queueing them again if that fails
+func (p *JobSummaryProjector) flush(ctx context.Context) {
+	p.mu.Lock()
+	ids := make([]models.JobID, 0, len(p.pending))
+	for id := range p.pending {
+		ids = append(ids, id)
+	}
+	p.pending = make(map[models.JobID]struct{})
+	p.mu.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	if err := p.summaries.Refresh(ctx, ids); err != nil {
+		jobSummaryRefreshes.Inc("event", "failed")
+		log.Printf("Failed to refresh %d job summaries: %v", len(ids), err)
+		p.mu.Lock()
+		for _, id := range ids {
+			p.pending[id] = struct{}{}
+		}
+		p.mu.Unlock()
+		return
+	}
+	jobSummaryRefreshes.Inc("event", "succeeded")
+}
+
+// RunSync catches the summaries up on the jobs updated since the previous sync
+// until ctx is cancelled. It starts from the newest summary, so the first run
+// against an empty collection builds every summary.
+func (p *JobSummaryProjector) RunSync(ctx context.Context) {
+	since, err := p.summaries.LatestUpdate(ctx)
+	if err != nil {
+		log.Printf("Failed to find the latest job summary, rebuilding all summaries: %v", err)
+	}
+	since = p.sync(ctx, since)
+
+	ticker := time.NewTicker(p.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			since = p.sync(ctx, since)
+		}
+	}
+}
+
+// sync projects the jobs updated since, and returns where the next sync starts
+func (p *JobSummaryProjector) sync(ctx context.Context, since time.Time) time.Time {
+	started := time.Now()
+	from := since
+	if !from.IsZero() {
+		from = from.Add(-summarySyncOverlap)
+	}
+
+	if err := p.summaries.RefreshSince(ctx, from); err != nil {
+		jobSummaryRefreshes.Inc("sync", "failed")
+		if ctx.Err() == nil {
+			log.Printf("Failed to sync job summaries: %v", err)
+		}
+		return since
+	}
+	jobSummaryRefreshes.Inc("sync", "succeeded")
+	return started
+}
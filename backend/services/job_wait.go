@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// DefaultCompletionWatcherInterval is how often the jobs being waited for are checked
+const DefaultCompletionWatcherInterval = time.Second
+
+// Bounds of how long a caller may wait for a job
+const (
+	DefaultWaitTimeout = 30 * time.Second
+	MaxWaitTimeout     = 5 * time.Minute
+)
+
+// CompletionWatcher notifies callers waiting for jobs to reach a terminal status.
+// Jobs finish on workers, so the watcher polls for all waited jobs in one query
+// per interval rather than each caller polling on its own.
+type CompletionWatcher struct {
+	jobs     repositories.JobsRepository
+	interval time.Duration
+
+	mu      sync.Mutex
+	waiters map[models.JobID][]chan models.Job
+}
+
+// NewCompletionWatcher creates a new completion watcher
+func NewCompletionWatcher(jobs repositories.JobsRepository, interval time.Duration) *CompletionWatcher {
+	return &CompletionWatcher{
+		jobs:     jobs,
+		interval: interval,
+		waiters:  make(map[models.JobID][]chan models.Job),
+	}
+}
+
+// Run checks the waited jobs until ctx is cancelled
+func (w *CompletionWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the job once it is terminal, and a
+// function to call when the caller stops waiting
+func (w *CompletionWatcher) Subscribe(id models.JobID) (<-chan models.Job, func()) {
+	ch := make(chan models.Job, 1)
+
+	w.mu.Lock()
+	w.waiters[id] = append(w.waiters[id], ch)
+	w.mu.Unlock()
+
+	return ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		waiters := w.waiters[id]
+		for i, waiter := range waiters {
+			if waiter == ch {
+				waiters = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(waiters) == 0 {
+			delete(w.waiters, id)
+		} else {
+			w.waiters[id] = waiters
+		}
+	}
+}
+
+// check notifies the waiters of the jobs that have become terminal
+func (w *CompletionWatcher) check(ctx context.Context) {
+	w.mu.Lock()
+	ids := make([]models.JobID, 0, len(w.waiters))
+	for id := range w.waiters {
+		ids = append(ids, id)
+	}
+	w.mu.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	jobs, err := w.jobs.ListTerminal(ctx, ids)
+	if err != nil {
+		log.Printf("Failed to check jobs being waited for: %v", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, job := range jobs {
+		for _, waiter := range w.waiters[job.ID] {
+			waiter <- job
+		}
+		delete(w.waiters, job.ID)
+	}
+}
+
+// HandleEvent is an event handler notifying the waiters of a job the backend
+// moved to a terminal status without waiting for the next check
+func (w *CompletionWatcher) HandleEvent(ctx context.Context, event JobEvent) {
+	if event.Type != EventJobStatusChanged || event.Job == nil || !event.Status.IsTerminal() {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, waiter := range w.waiters[event.JobID] {
+		waiter <- *event.Job
+	}
+	delete(w.waiters, event.JobID)
+}
+
+// WaitForJob waits up to timeout for a job to reach a terminal status and returns
+// it, reporting whether it finished. On timeout the job's current state is returned.
+func (s *jobsService) WaitForJob(ctx context.Context, id string, timeout time.Duration) (*models.Job, bool, error) {
+	if timeout <= 0 || timeout > MaxWaitTimeout {
+		return nil, false, &ValidationError{
+			Field:   "timeout",
+			Message: fmt.Sprintf("timeout must be positive and at most %s", MaxWaitTimeout),
+		}
+	}
+
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if job.Status.IsTerminal() {
+		return job, true, nil
+	}
+
+	finished, stop := s.completions.Subscribe(job.ID)
+	defer stop()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case job := <-finished:
+		return &job, true, nil
+	case <-timer.C:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+
+	// The job may have finished since the last check
+	job, err = s.GetJob(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	return job, job.Status.IsTerminal(), nil
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// DefaultJobWatchInterval is how often the jobs updated by workers are checked for watchers
+const DefaultJobWatchInterval = time.Second
+
+const (
+	// jobWatchBatch is how many updated jobs one check reads at most
+	jobWatchBatch = 500
+	// jobWatchBuffer is how many events a watcher may fall behind before it is dropped
+	jobWatchBuffer = 64
+	// jobWatchMemory is how long the status of a job is remembered after it was
+	// last seen, so updates that do not change it are not streamed again
+	jobWatchMemory = 10 * time.Minute
+)
+
+// WatchFilter selects the job events a watcher receives. Empty lists match everything.
+type WatchFilter struct {
+	Status  []models.JobStatus
+	JobType []models.JobType
+
+	// tenant restricts the events to the jobs of one tenant when set. WatchJobs
+	// sets it from the caller's tenant scope, so it cannot be chosen by callers.
+	tenant *string
+}
+
+func (f WatchFilter) validate() error {
+	for _, status := range f.Status {
+		if !isValidJobStatus(status) {
+			return &ValidationError{Field: "status", Message: fmt.Sprintf("invalid status '%s'", status)}
+		}
+	}
+	for _, jobType := range f.JobType {
+		if !models.IsValidJobType(string(jobType)) {
+			return &ValidationError{Field: "job_type", Message: fmt.Sprintf("invalid job type '%s'", jobType)}
+		}
+	}
+	return nil
+}
+
+func (f WatchFilter) matches(event JobEvent) bool {
+	if f.tenant != nil && *f.tenant != event.Tenant {
+		return false
+	}
+	if len(f.Status) > 0 && !slices.Contains(f.Status, event.Status) {
+		return false
+	}
+	return len(f.JobType) == 0 || slices.Contains(f.JobType, event.JobType)
+}
+
+type jobWatch struct {
+	filter WatchFilter
+	events chan JobEvent
+}
+
+type watchedStatus struct {
+	status  models.JobStatus
+	jobType models.JobType
+	tenant  string
+	seen    time.Time
+}
+
+// JobWatcher streams job creations and status changes to watchers, each
+// receiving only the events matching its filter. Changes made by this backend
+// arrive through the event bus; those made by workers are found by polling the
+// recently updated jobs, once per interval for all watchers.
+type JobWatcher struct {
+	jobs     repositories.JobsRepository
+	interval time.Duration
+
+	mu       sync.Mutex
+	watchers map[*jobWatch]struct{}
+	statuses map[models.JobID]watchedStatus
+	since    time.Time
+}
+
+// NewJobWatcher creates a new job watcher
+func NewJobWatcher(jobs repositories.JobsRepository, interval time.Duration) *JobWatcher {
+	return &JobWatcher{
+		jobs:     jobs,
+		interval: interval,
+		watchers: make(map[*jobWatch]struct{}),
+		statuses: make(map[models.JobID]watchedStatus),
+		since:    time.Now(),
+	}
+}
+
+// Run checks the updated jobs until ctx is cancelled
+func (w *JobWatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events matching filter from now on,
+// and a function to call when the caller stops watching. The channel is closed
+// if the caller falls too far behind.
+func (w *JobWatcher) Subscribe(filter WatchFilter) (<-chan JobEvent, func()) {
+	watch := &jobWatch{filter: filter, events: make(chan JobEvent, jobWatchBuffer)}
+
+	w.mu.Lock()
+	w.watchers[watch] = struct{}{}
+	w.mu.Unlock()
+
+	return watch.events, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.drop(watch)
+	}
+}
+
+// HandleEvent is an event handler streaming the changes this backend makes
+// without waiting for the next check
+func (w *JobWatcher) HandleEvent(ctx context.Context, event JobEvent) {
+	if event.Type != EventJobCreated && event.Type != EventJobStatusChanged {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.watchers) == 0 {
+		return
+	}
+
+	// Publishers without the job leave its type and tenant out
+	if event.Job == nil {
+		known, ok := w.statuses[event.JobID]
+		if !ok {
+			// The next check finds the job with its type and tenant
+			return
+		}
+		event.JobType = known.jobType
+		event.Tenant = known.tenant
+	}
+	w.statuses[event.JobID] = watchedStatus{status: event.Status, jobType: event.JobType, tenant: event.Tenant, seen: time.Now()}
+	w.publish(event)
+}
+
+// check streams the status changes of the jobs updated since the previous check
+func (w *JobWatcher) check(ctx context.Context) {
+	now := time.Now()
+
+	w.mu.Lock()
+	if len(w.watchers) == 0 {
+		// Nobody missed the changes made while nobody was watching
+		w.since = now
+		w.statuses = make(map[models.JobID]watchedStatus)
+		w.mu.Unlock()
+		return
+	}
+	since := w.since
+	w.mu.Unlock()
+
+	jobs, err := w.jobs.ListUpdatedSince(ctx, since, jobWatchBatch)
+	if err != nil {
+		log.Printf("Failed to check jobs for watchers: %v", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, job := range jobs {
+		if job.UpdatedAt.After(w.since) {
+			w.since = job.UpdatedAt
+		}
+		known, ok := w.statuses[job.ID]
+		w.statuses[job.ID] = watchedStatus{status: job.Status, jobType: job.JobType, tenant: job.Tenant, seen: now}
+		if ok && known.status == job.Status {
+			continue
+		}
+		w.publish(JobEvent{
+			Type:           EventJobStatusChanged,
+			JobID:          job.ID,
+			JobType:        job.JobType,
+			Status:         job.Status,
+			Tenant:         job.Tenant,
+			PreviousStatus: known.status,
+			At:             job.UpdatedAt,
+		})
+	}
+
+	for id, known := range w.statuses {
+		if now.Sub(known.seen) > jobWatchMemory {
+			delete(w.statuses, id)
+		}
+	}
+}
+
+// publish delivers an event to the matching watchers; w.mu must be held
+func (w *JobWatcher) publish(event JobEvent) {
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+	for watch := range w.watchers {
+		if !watch.filter.matches(event) {
+			continue
+		}
+		select {
+		case watch.events <- event:
+		default:
+			w.drop(watch)
+		}
+	}
+}
+
+// drop removes a watcher and closes its channel; w.mu must be held
+func (w *JobWatcher) drop(watch *jobWatch) {
+	if _, ok := w.watchers[watch]; !ok {
+		return
+	}
+	delete(w.watchers, watch)
+	close(watch.events)
+}
+
+// WatchJobs streams the creations and status changes of the jobs matching
+// filter, restricted to the jobs of the tenant ctx is scoped to
+func (s *jobsService) WatchJobs(ctx context.Context, filter WatchFilter) (<-chan JobEvent, func(), error) {
+	if err := filter.validate(); err != nil {
+		return nil, nil, err
+	}
+	if tenant, ok := repositories.TenantScope(ctx); ok {
+		filter.tenant = &tenant
+	}
+	events, stop := s.watcher.Subscribe(filter)
+	return events, stop, nil
+}
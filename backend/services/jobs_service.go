@@ -2,11 +2,21 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/fullstack-assessment/backend/models"
 	"github.com/fullstack-assessment/backend/repositories"
+	"github.com/fullstack-assessment/backend/secrets"
+	"github.com/fullstack-assessment/backend/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Custom error types for the jobs service
@@ -16,8 +26,20 @@ var (
 	ErrMissingJobName    = errors.New("job name is required")
 	ErrInvalidJobState   = errors.New("job cannot be modified in its current state")
 	ErrMaxRetriesReached = errors.New("maximum retry attempts reached")
+	ErrNoArtifact        = errors.New("job has no artifact")
+	// ErrPreconditionFailed is returned when a job changed since the version the caller read
+	ErrPreconditionFailed = errors.New("job was modified since the given version")
 )
 
+// artifactURLExpiry is how long pre-signed artifact download URLs remain valid
+const artifactURLExpiry = 15 * time.Minute
+
+// maxAffinityKeyLength bounds the size of a job's partition affinity key
+const maxAffinityKeyLength = 256
+
+// maxCancellationReasonLength bounds the size of a cancellation reason
+const maxCancellationReasonLength = 1000
+
 // ValidationError represents a validation error with additional context
 type ValidationError struct {
 	Field   string
@@ -30,39 +52,234 @@ func (e *ValidationError) Error() string {
 
 // CreateJobRequest represents the request to create a new job
 type CreateJobRequest struct {
+	// ID is an optional client-generated ObjectID or UUIDv7. Creating a job again
+	// with the same ID returns the original job instead of a new one.
+	ID      string                 `json:"id,omitempty"`
 	Name    string                 `json:"name"`
 	JobType string                 `json:"job_type"`
 	Config  map[string]interface{} `json:"config,omitempty"`
+	// AffinityKey routes all jobs sharing the key to the same partition so they run serially
+	AffinityKey string `json:"affinity_key,omitempty"`
+	// Notify configures notifications sent when the job reaches a terminal state
+	Notify *NotifyRequest `json:"notify,omitempty"`
+	// Retry overrides the retry policy of the job type for this job
+	Retry *RetryPolicyRequest `json:"retry,omitempty"`
+	// HoldForInput keeps the job out of the queue until an input file is uploaded for it
+	HoldForInput bool `json:"hold_for_input,omitempty"`
+	// DeadlineAt fails the job if it has not completed by then, whether or not it started
+	DeadlineAt *time.Time `json:"deadline_at,omitempty"`
+	// RunAt keeps the job pending until then, when the worker's scheduler publishes it
+	RunAt *time.Time `json:"run_at,omitempty"`
+	// Tenant is the tenant of the authenticated caller, used to scope duplicate detection and quotas
+	// and recorded on the job to meter usage
+	Tenant string `json:"-"`
+	// GroupID is set when the job is created into a group
+	GroupID *primitive.ObjectID `json:"-"`
+}
+
+// CancelJobRequest represents the request to cancel a job
+type CancelJobRequest struct {
+	Reason string `json:"reason,omitempty"`
+	// Actor is the authenticated caller requesting the cancellation
+	Actor string `json:"-"`
+	// IfMatch lists the versions the job may be cancelled at; nil cancels any version
+	IfMatch []time.Time `json:"-"`
 }
 
 // JobFilter represents filters for listing jobs
 type JobFilter struct {
 	Page  int
 	Limit int
+	// IncludeTotal requests the total number of matching jobs
+	IncludeTotal bool
+	// Include lists the large fields, config and checkpoint, that are loaded with
+	// the jobs; they are left out of lists otherwise
+	Include []string
+	// ActiveOnly restricts the jobs listed to those not terminal yet, within
+	// Status when given; nil applies the configured default to lists without a
+	// status filter
+	ActiveOnly *bool
+	// ViewFilter restricts and orders the jobs listed, either directly or from a saved view
+	models.ViewFilter
+}
+
+// ListJobsResult represents a page of jobs along with the pagination actually applied
+type ListJobsResult struct {
+	Jobs    []models.Job
+	Total   *int64
+	HasMore bool
+	Page    int
+	Limit   int
+}
+
+// ListJobSummariesResult represents a page of job summaries along with the pagination actually applied
+type ListJobSummariesResult struct {
+	Summaries []models.JobSummary
+	Total     *int64
+	HasMore   bool
+	Page      int
+	Limit     int
+}
+
+// JobsServiceConfig holds tunable settings for the jobs service
+type JobsServiceConfig struct {
+	// MaxPageLimit caps the number of jobs returned per page
+	MaxPageLimit int
+	// SLOs overrides the registered processing SLO per job type
+	SLOs map[string]time.Duration
+	// Dedup sets the duplicate submission policy per job type; types without one accept duplicates
+	Dedup map[string]DedupPolicy
+	// Backpressure sets how new jobs are handled while their queue is over capacity
+	Backpressure BackpressureConfig
+	// RedactedConfigKeys are the config keys hidden from callers without the admin role
+	RedactedConfigKeys []string
+	// RetryPolicies overrides the registered retry policy per job type
+	RetryPolicies map[string]RetryPolicyOverride
+	// CanaryPercent is the percentage of new jobs per type routed to canary workers
+	CanaryPercent map[string]int
+	// MaxInputBytes caps the size of a job's uploaded input file
+	MaxInputBytes int64
+	// ConfigOffloadBytes is the encoded size above which a job's config is stored in GridFS
+	ConfigOffloadBytes int64
+	// Quota limits how many jobs each tenant creates per day and month
+	Quota QuotaConfig
+	// IDStrategy generates the IDs of jobs created without a client-supplied one
+	IDStrategy models.JobIDStrategy
+	// ListActiveOnly hides terminal jobs from lists without a status filter or ActiveOnly
+	ListActiveOnly bool
 }
 
+// Pagination defaults
+const (
+	DefaultPageLimit    = 10
+	DefaultMaxPageLimit = 100
+)
+
 // JobsService interface defines the methods for job business logic
 type JobsService interface {
 	CreateJob(ctx context.Context, req CreateJobRequest) (*models.Job, error)
 	GetJob(ctx context.Context, id string) (*models.Job, error)
-	ListJobs(ctx context.Context, filter JobFilter) ([]models.Job, int64, error)
-	CancelJob(ctx context.Context, id string) (*models.Job, error)
-	RetryJob(ctx context.Context, id string) (*models.Job, error)
+	GetJobWithQueueInfo(ctx context.Context, id string) (*models.Job, error)
+	// WaitForJob waits up to timeout for a job to finish, reporting whether it did
+	WaitForJob(ctx context.Context, id string, timeout time.Duration) (*models.Job, bool, error)
+	// WatchJobs streams the job events matching filter until the returned stop function is called
+	WatchJobs(ctx context.Context, filter WatchFilter) (<-chan JobEvent, func(), error)
+	GetJobAttempts(ctx context.Context, id string) ([]models.JobAttempt, error)
+	GetArtifactDownload(ctx context.Context, id string) (*ArtifactDownload, error)
+	ListJobs(ctx context.Context, filter JobFilter) (*ListJobsResult, error)
+	// ListJobSummaries lists the summaries of the jobs matching a filter, which
+	// trail the jobs by up to the summary sync interval
+	ListJobSummaries(ctx context.Context, filter JobFilter) (*ListJobSummariesResult, error)
+	GetStats(ctx context.Context) (*JobStats, error)
+	GetTimeseries(ctx context.Context, interval, window time.Duration) (*Timeseries, error)
+	SearchJobs(ctx context.Context, filter SearchFilter) ([]SearchResult, error)
+	ListChildren(ctx context.Context, id string, limit int) ([]models.Job, error)
+	CancelJob(ctx context.Context, id string, req CancelJobRequest) (*models.Job, error)
+	// RetryJob retries a failed job; ifMatch restricts it to the listed versions when not nil
+	RetryJob(ctx context.Context, id string, ifMatch []time.Time) (*models.Job, error)
+	// PrioritizeJob prioritizes a pending job; ifMatch restricts it to the listed versions when not nil
+	PrioritizeJob(ctx context.Context, id string, ifMatch []time.Time) (*models.Job, error)
+	// HoldJob pins a job for debugging, stopping automatic retries, redrives and reconciliation
+	HoldJob(ctx context.Context, id string, req HoldJobRequest) (*models.Job, error)
+	// ReleaseJob removes the hold of a job; ifMatch restricts it to the listed versions when not nil
+	ReleaseJob(ctx context.Context, id string, ifMatch []time.Time) (*models.Job, error)
+	// RedactJob prepares a job for a response to the caller in ctx, hiding the config keys they may not see
+	RedactJob(ctx context.Context, job *models.Job) *models.Job
+	// ReleaseHeldJobs queues the jobs held during maintenance and returns how many were released
+	ReleaseHeldJobs(ctx context.Context) (int, error)
+	// RequeueJobs resets the jobs matching a filter to pending and republishes them in batches
+	RequeueJobs(ctx context.Context, req RequeueRequest, progress func(RequeueProgress)) (RequeueProgress, error)
+	// RepublishStalePending republishes the pending jobs not updated since before and returns how many were republished
+	RepublishStalePending(ctx context.Context, before time.Time) (int, error)
+	// AttachInput stores the input file of a job awaiting it and queues the job
+	AttachInput(ctx context.Context, id string, upload InputUpload) (*models.Job, error)
+	// GetQuotaStatus reports the job creation allowance left to the caller's tenant
+	GetQuotaStatus(ctx context.Context) (*models.QuotaStatus, error)
+	// Reconfigure applies new tunable settings to a running service
+	Reconfigure(config JobsServiceConfig)
 }
 
 type jobsService struct {
-	repo     repositories.JobsRepository
-	producer *KafkaProducer
+	repo        repositories.JobsRepository
+	outbox      repositories.OutboxRepository
+	quotas      repositories.QuotasRepository
+	blobs       repositories.JobBlobsRepository
+	summaries   repositories.JobSummariesRepository
+	archive     repositories.JobArchiveRepository
+	producer    *KafkaProducer
+	artifacts   storage.ArtifactStore
+	maintenance *Maintenance
+	queues      *QueueMonitor
+	completions *CompletionWatcher
+	watcher     *JobWatcher
+	events      *EventBus
+	encryptor   *secrets.Encryptor
+	hooks       *Hooks
+
+	configMu sync.RWMutex
+	config   JobsServiceConfig
 }
 
 // NewJobsService creates a new jobs service
-func NewJobsService(repo repositories.JobsRepository, producer *KafkaProducer) JobsService {
+func NewJobsService(repo repositories.JobsRepository, outbox repositories.OutboxRepository, quotas repositories.QuotasRepository, blobs repositories.JobBlobsRepository, summaries repositories.JobSummariesRepository, archive repositories.JobArchiveRepository, producer *KafkaProducer, artifacts storage.ArtifactStore, maintenance *Maintenance, queues *QueueMonitor, completions *CompletionWatcher, watcher *JobWatcher, events *EventBus, encryptor *secrets.Encryptor, hooks *Hooks, config JobsServiceConfig) JobsService {
 	return &jobsService{
-		repo:     repo,
-		producer: producer,
+		repo:        repo,
+		outbox:      outbox,
+		quotas:      quotas,
+		blobs:       blobs,
+		summaries:   summaries,
+		archive:     archive,
+		producer:    producer,
+		artifacts:   artifacts,
+		maintenance: maintenance,
+		queues:      queues,
+		completions: completions,
+		watcher:     watcher,
+		events:      events,
+		encryptor:   encryptor,
+		hooks:       hooks,
+		config:      config.withDefaults(),
 	}
 }
 
+func (c JobsServiceConfig) withDefaults() JobsServiceConfig {
+	if c.MaxPageLimit < 1 {
+		c.MaxPageLimit = DefaultMaxPageLimit
+	}
+	if c.MaxInputBytes < 1 {
+		c.MaxInputBytes = DefaultMaxInputBytes
+	}
+	if c.ConfigOffloadBytes < 1 {
+		c.ConfigOffloadBytes = DefaultConfigOffloadBytes
+	}
+	if !c.IDStrategy.Valid() {
+		c.IDStrategy = models.JobIDObjectID
+	}
+	return c
+}
+
+// Reconfigure applies new tunable settings to a running service
+func (s *jobsService) Reconfigure(config JobsServiceConfig) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.config = config.withDefaults()
+}
+
+func (s *jobsService) currentConfig() JobsServiceConfig {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// sloFor returns the processing SLO for a job type, preferring configured overrides
+func (s *jobsService) sloFor(jobType models.JobType) time.Duration {
+	if slo, ok := s.currentConfig().SLOs[string(jobType)]; ok {
+		return slo
+	}
+	spec, _ := models.LookupJobType(string(jobType))
+	return spec.SLO
+}
+
 // CreateJob creates a new job and publishes it to Kafka
 func (s *jobsService) CreateJob(ctx context.Context, req CreateJobRequest) (*models.Job, error) {
 	// Validate request
@@ -73,98 +290,641 @@ func (s *jobsService) CreateJob(ctx context.Context, req CreateJobRequest) (*mod
 	if !models.IsValidJobType(req.JobType) {
 		return nil, &ValidationError{
 			Field:   "job_type",
-			Message: fmt.Sprintf("invalid job type '%s', must be one of: process, analyze, export", req.JobType),
+			Message: fmt.Sprintf("invalid job type '%s', must be one of: %s", req.JobType, strings.Join(models.JobTypeNames(), ", ")),
+		}
+	}
+
+	var id models.JobID
+	if req.ID != "" {
+		parsed, err := models.ParseJobID(req.ID)
+		if err != nil {
+			return nil, &ValidationError{Field: "id", Message: "id must be an ObjectID or a UUIDv7"}
+		}
+		id = parsed
+	} else {
+		generated, err := models.NewJobID(s.currentConfig().IDStrategy)
+		if err != nil {
+			return nil, err
 		}
+		id = generated
+	}
+
+	if len(req.AffinityKey) > maxAffinityKeyLength {
+		return nil, &ValidationError{
+			Field:   "affinity_key",
+			Message: fmt.Sprintf("affinity key must not exceed %d characters", maxAffinityKeyLength),
+		}
+	}
+
+	notify, err := req.Notify.toNotifyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	retryPolicy, err := s.resolveRetryPolicy(models.JobType(req.JobType), req.Retry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateWorkflow(models.JobType(req.JobType), req.Config); err != nil {
+		return nil, err
+	}
+
+	if req.DeadlineAt != nil && !req.DeadlineAt.After(time.Now()) {
+		return nil, &ValidationError{Field: "deadline_at", Message: "deadline must be in the future"}
+	}
+	if req.RunAt != nil {
+		if !req.RunAt.After(time.Now()) {
+			return nil, &ValidationError{Field: "run_at", Message: "run_at must be in the future"}
+		}
+		if req.DeadlineAt != nil && !req.RunAt.Before(*req.DeadlineAt) {
+			return nil, &ValidationError{Field: "run_at", Message: "run_at must be before deadline_at"}
+		}
+	}
+
+	// Sensitive config keys are stored encrypted and only decrypted by the worker running the job
+	config, err := s.encryptor.EncryptConfig(ctx, req.Config)
+	if err != nil {
+		if errors.Is(err, secrets.ErrEncryptionDisabled) {
+			return nil, &ValidationError{Field: "config", Message: err.Error()}
+		}
+		return nil, err
+	}
+
+	// The hash is exposed by the API, so it is computed without the encrypted values
+	configHash, err := models.HashConfig(secrets.RedactConfig(config))
+	if err != nil {
+		return nil, &ValidationError{Field: "config", Message: "config must be JSON serializable"}
+	}
+
+	maintenance := s.maintenance.Status()
+	if maintenance.Enabled && maintenance.Mode == MaintenanceReject {
+		return nil, &MaintenanceError{
+			RetryAfter: time.Duration(maintenance.RetryAfterSeconds) * time.Second,
+			Reason:     maintenance.Reason,
+		}
+	}
+
+	warning, err := s.checkBackpressure(req.Tenant, models.JobType(req.JobType))
+	if err != nil {
+		return nil, err
 	}
 
 	// Create the job
 	job := &models.Job{
-		Name:       req.Name,
-		JobType:    models.JobType(req.JobType),
-		Status:     models.JobStatusPending,
-		Config:     req.Config,
-		RetryCount: 0,
+		ID:          id,
+		Name:        req.Name,
+		JobType:     models.JobType(req.JobType),
+		Status:      models.JobStatusPending,
+		Config:      config,
+		ConfigHash:  configHash,
+		AffinityKey: req.AffinityKey,
+		Notify:      notify,
+		RetryCount:  0,
+		RetryPolicy: retryPolicy,
+		SLOMs:       s.sloFor(models.JobType(req.JobType)).Milliseconds(),
+		DeadlineAt:  req.DeadlineAt,
+		ScheduledAt: req.RunAt,
+		Canary:      s.routeToCanary(models.JobType(req.JobType)),
+		GroupID:     req.GroupID,
+		Tenant:      req.Tenant,
+	}
+	if maintenance.Enabled && maintenance.Mode == MaintenanceHold {
+		job.Status = models.JobStatusHeld
+	}
+	// Jobs waiting for their input are queued once it is uploaded, maintenance permitting
+	if req.HoldForInput {
+		job.Status = models.JobStatusAwaitingInput
+	}
+
+	// Hooks may reject the job or enrich it before it is stored and queued
+	if err := s.runPreEnqueueHooks(ctx, job); err != nil {
+		return nil, err
+	}
+
+	// Retried creations with a client-supplied ID return the job they created
+	if req.ID != "" {
+		if err := s.checkCreatedByID(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+
+	submission, err := s.claimSubmission(ctx, req.Tenant, job)
+	if err != nil {
+		return nil, err
+	}
+	releaseSubmission := func() {
+		if submission == "" {
+			return
+		}
+		if err := s.repo.ReleaseSubmission(ctx, submission, job.ID); err != nil {
+			log.Printf("Failed to release submission claim for job %s: %v", job.ID.String(), err)
+		}
+	}
+
+	// Duplicates are not counted, since they do not create a job
+	quota, err := s.consumeQuota(ctx, req.Tenant)
+	if err != nil {
+		releaseSubmission()
+		return nil, err
+	}
+
+	// Configs too large to keep on the job are stored in GridFS
+	config = job.Config
+	if err := s.offloadConfig(ctx, job); err != nil {
+		releaseSubmission()
+		s.refundQuota(ctx, req.Tenant, quota)
+		return nil, err
 	}
 
 	if err := s.repo.Create(ctx, job); err != nil {
+		releaseSubmission()
+		s.refundQuota(ctx, req.Tenant, quota)
+		s.deleteConfigBlob(ctx, job)
+		// A concurrent creation with the same ID got there first
+		if req.ID != "" && errors.Is(err, repositories.ErrConflict) {
+			if err := s.checkCreatedByID(ctx, job); err != nil {
+				return nil, err
+			}
+		}
 		return nil, fmt.Errorf("failed to create job: %w", err)
 	}
 
-	// Publish to Kafka
+	job.Warning = warning
+	s.events.JobCreated(ctx, job)
+	// The caller gets its config back; the message only carries the reference
+	job.Config = config
+
+	// Jobs submitted during maintenance are published when they are released,
+	// and jobs awaiting input when it is uploaded
+	if job.Status == models.JobStatusHeld || job.Status == models.JobStatusAwaitingInput {
+		return job, nil
+	}
+
+	s.publishJob(ctx, job)
+
+	return job, nil
+}
+
+// publishJob publishes a job to its job type's topic, the priority topic for prioritized
+// jobs or the canary topic for jobs routed to canary workers. Jobs scheduled to run
+// later are left to the worker's scheduler, which publishes them once due.
+func (s *jobsService) publishJob(ctx context.Context, job *models.Job) {
+	if job.ScheduledAt != nil {
+		return
+	}
 	message := JobMessage{
-		JobID:     job.ID.Hex(),
-		Name:      job.Name,
-		JobType:   string(job.JobType),
-		Config:    job.Config,
-		CreatedAt: job.CreatedAt,
+		SchemaVersion: MessageSchemaVersion,
+		JobID:         job.ID.String(),
+		Name:          job.Name,
+		JobType:       string(job.JobType),
+		Config:        job.Config,
+		AffinityKey:   job.AffinityKey,
+		DispatchID:    job.DispatchID,
+		CreatedAt:     job.CreatedAt,
+		DeadlineAt:    job.DeadlineAt,
+		Tenant:        &job.Tenant,
+	}
+	if job.Input != nil {
+		message.Input = &JobInputMessage{
+			Storage:     job.Input.Storage,
+			Key:         job.Input.Key,
+			Filename:    job.Input.Filename,
+			ContentType: job.Input.ContentType,
+			Size:        job.Input.Size,
+			Checksum:    job.Input.Checksum,
+		}
+	}
+	if job.ConfigRef != nil {
+		// Offloaded configs are read from GridFS by the worker, keeping messages small
+		message.Config = nil
+		message.ConfigRef = job.ConfigRef
 	}
 
-	if err := s.producer.Publish(ctx, "jobs", message); err != nil {
-		// Log but don't fail - the job is created, worker can pick it up later
-		fmt.Printf("Warning: failed to publish job to Kafka: %v\n", err)
+	topic := JobTopic(job.JobType)
+	if job.Priority == models.JobPriorityHigh {
+		topic = JobPriorityTopic(job.JobType)
+	} else if job.Canary {
+		topic = JobCanaryTopic(job.JobType)
 	}
 
-	return job, nil
+	// Don't fail if Kafka is unavailable - the job is created and the message is
+	// relayed later, or republished by the pending reconciler if it was lost
+	s.publish(ctx, topic, job.AffinityKey, message)
+}
+
+// ReleaseHeldJobs moves the jobs held during maintenance to pending and publishes them
+func (s *jobsService) ReleaseHeldJobs(ctx context.Context) (int, error) {
+	released := 0
+	for {
+		job, err := s.repo.ReleaseHeld(ctx)
+		if errors.Is(err, repositories.ErrNotFound) {
+			return released, nil
+		}
+		if err != nil {
+			return released, fmt.Errorf("failed to release held jobs: %w", err)
+		}
+		s.events.StatusChanged(ctx, job, models.JobStatusHeld)
+		s.publishJob(ctx, job)
+		released++
+	}
 }
 
 // GetJob retrieves a job by ID
 func (s *jobsService) GetJob(ctx context.Context, id string) (*models.Job, error) {
 	job, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrJobNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get job: %w", err)
 	}
 
-	if job == nil {
+	return job, nil
+}
+
+// GetJobWithQueueInfo retrieves a job and, if it is pending, its queue position
+// and estimated start time. Jobs no longer in the jobs collection are looked up
+// in the archive and returned marked as archived.
+func (s *jobsService) GetJobWithQueueInfo(ctx context.Context, id string) (*models.Job, error) {
+	job, err := s.GetJob(ctx, id)
+	if errors.Is(err, ErrJobNotFound) {
+		job, err = s.getArchivedJob(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadConfig(ctx, job); err != nil {
+		return nil, err
+	}
+	if job.Status == models.JobStatusPending {
+		s.estimateStart(ctx, job)
+	}
+
+	return job, nil
+}
+
+// getArchivedJob retrieves a job from the archive
+func (s *jobsService) getArchivedJob(ctx context.Context, id string) (*models.Job, error) {
+	job, err := s.archive.GetByID(ctx, id)
+	if errors.Is(err, repositories.ErrNotFound) {
 		return nil, ErrJobNotFound
 	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived job: %w", err)
+	}
 
 	return job, nil
 }
 
-// ListJobs retrieves a paginated list of jobs
-func (s *jobsService) ListJobs(ctx context.Context, filter JobFilter) ([]models.Job, int64, error) {
+// GetJobAttempts retrieves the processing attempt history of a job
+func (s *jobsService) GetJobAttempts(ctx context.Context, id string) ([]models.JobAttempt, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Attempts == nil {
+		return []models.JobAttempt{}, nil
+	}
+
+	return job.Attempts, nil
+}
+
+// ArtifactDownload describes how to deliver a job artifact to a client: either a
+// redirect to a pre-signed URL or the artifact content itself
+type ArtifactDownload struct {
+	Artifact    *models.Artifact
+	RedirectURL string
+	Content     io.ReadCloser
+}
+
+// GetArtifactDownload resolves the download of a job's output artifact
+func (s *jobsService) GetArtifactDownload(ctx context.Context, id string) (*ArtifactDownload, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Artifact == nil {
+		return nil, ErrNoArtifact
+	}
+
+	redirectURL, err := s.artifacts.PresignGet(job.Artifact.Key, artifactURLExpiry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to presign artifact URL: %w", err)
+	}
+	if redirectURL != "" {
+		return &ArtifactDownload{Artifact: job.Artifact, RedirectURL: redirectURL}, nil
+	}
+
+	content, err := s.artifacts.Open(ctx, job.Artifact.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, ErrNoArtifact
+		}
+		return nil, fmt.Errorf("failed to open artifact: %w", err)
+	}
+
+	return &ArtifactDownload{Artifact: job.Artifact, Content: content}, nil
+}
+
+// paginate applies the default and maximum page limits to a filter and converts
+// it into a list query
+func (s *jobsService) paginate(filter *JobFilter) (repositories.ListQuery, error) {
 	if filter.Page < 1 {
 		filter.Page = 1
 	}
-	if filter.Limit < 1 || filter.Limit > 100 {
-		filter.Limit = 10
+	if filter.Limit < 1 {
+		filter.Limit = DefaultPageLimit
+	}
+	if maxLimit := s.currentConfig().MaxPageLimit; filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+
+	query, err := listQuery(filter.ViewFilter, time.Now())
+	if err != nil {
+		return query, err
+	}
+	activeOnly := s.currentConfig().ListActiveOnly && len(filter.Status) == 0
+	if filter.ActiveOnly != nil {
+		activeOnly = *filter.ActiveOnly
+	}
+	if activeOnly {
+		if query.Statuses, err = activeStatuses(filter.Status); err != nil {
+			return query, err
+		}
+	}
+	query.Page = filter.Page
+	query.Limit = filter.Limit
+	query.IncludeTotal = filter.IncludeTotal
+	return query, nil
+}
+
+// ListJobs retrieves a paginated list of jobs
+func (s *jobsService) ListJobs(ctx context.Context, filter JobFilter) (*ListJobsResult, error) {
+	query, err := s.paginate(&filter)
+	if err != nil {
+		return nil, err
+	}
+	query.Exclude, err = listExclusions(filter.Include)
+	if err != nil {
+		return nil, err
 	}
 
-	jobs, total, err := s.repo.List(ctx, filter.Page, filter.Limit)
+	result, err := s.repo.List(ctx, query)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
 	}
 
-	return jobs, total, nil
+	jobs := result.Jobs
+	if jobs == nil {
+		jobs = []models.Job{}
+	}
+	if slices.Contains(filter.Include, "config") {
+		for i := range jobs {
+			if err := s.loadConfig(ctx, &jobs[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &ListJobsResult{
+		Jobs:    jobs,
+		Total:   result.Total,
+		HasMore: result.HasMore,
+		Page:    filter.Page,
+		Limit:   filter.Limit,
+	}, nil
+}
+
+// ListJobSummaries retrieves a paginated list of job summaries. Summaries are
+// read from the job_summaries read model rather than the jobs, so dashboards can
+// list jobs without scanning their configs and results.
+func (s *jobsService) ListJobSummaries(ctx context.Context, filter JobFilter) (*ListJobSummariesResult, error) {
+	query, err := s.paginate(&filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.summaries.List(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job summaries: %w", err)
+	}
+
+	summaries := result.Summaries
+	if summaries == nil {
+		summaries = []models.JobSummary{}
+	}
+
+	return &ListJobSummariesResult{
+		Summaries: summaries,
+		Total:     result.Total,
+		HasMore:   result.HasMore,
+		Page:      filter.Page,
+		Limit:     filter.Limit,
+	}, nil
+}
+
+// largeJobFields maps the job fields that lists leave out unless included, by
+// JSON name, to their BSON names. They are sized by the caller or the executor
+// and can dwarf the rest of a job.
+var largeJobFields = map[string]string{
+	"config":     "config",
+	"checkpoint": "checkpoint",
+}
+
+// listExclusions returns the BSON names of the large fields not in include
+func listExclusions(include []string) ([]string, error) {
+	included := make(map[string]bool, len(include))
+	for _, field := range include {
+		if _, ok := largeJobFields[field]; !ok {
+			return nil, &ValidationError{Field: "include", Message: fmt.Sprintf("unknown field %q, must be config or checkpoint", field)}
+		}
+		included[field] = true
+	}
+
+	var exclude []string
+	for field, bsonName := range largeJobFields {
+		if !included[field] {
+			exclude = append(exclude, bsonName)
+		}
+	}
+	return exclude, nil
 }
 
 // CancelJob cancels a job and publishes a cancellation message to Kafka
-// NOTE: This is a skeleton - candidate should implement this
-func (s *jobsService) CancelJob(ctx context.Context, id string) (*models.Job, error) {
-	// TODO: Candidate implements this
-	// 1. Get the job by ID
-	// 2. Check if job exists
-	// 3. Check if job can be cancelled (pending or processing status)
-	// 4. Update job status to "cancelling"
-	// 5. Publish cancellation message to Kafka topic "job_cancellations"
-	// 6. Return the updated job
-
-	return nil, errors.New("not implemented")
-}
-
-// RetryJob retries a failed job
-// NOTE: This is a skeleton - candidate should implement this
-func (s *jobsService) RetryJob(ctx context.Context, id string) (*models.Job, error) {
-	// TODO: Candidate implements this
-	// 1. Get the job by ID
-	// 2. Check if job exists
-	// 3. Check if job can be retried (failed status, retry_count < 3)
-	// 4. Increment retry_count
-	// 5. Update job status to "pending"
-	// 6. Re-publish job to Kafka topic "jobs"
-	// 7. Return the updated job
-
-	return nil, errors.New("not implemented")
+func (s *jobsService) CancelJob(ctx context.Context, id string, req CancelJobRequest) (*models.Job, error) {
+	if len(req.Reason) > maxCancellationReasonLength {
+		return nil, &ValidationError{
+			Field:   "reason",
+			Message: fmt.Sprintf("reason must not exceed %d characters", maxCancellationReasonLength),
+		}
+	}
+
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(job, req.IfMatch) {
+		return nil, ErrPreconditionFailed
+	}
+	if !job.CanBeCancelled() {
+		return nil, ErrInvalidJobState
+	}
+	previous := job.Status
+
+	// The update is conditional on the status, and the version if given, so a
+	// concurrent transition is not overwritten
+	job, err = s.repo.RequestCancellation(ctx, id, req.Actor, req.Reason, req.IfMatch)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, staleJobError(req.IfMatch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	s.events.StatusChanged(ctx, job, previous)
+
+	message := CancellationMessage{
+		SchemaVersion: MessageSchemaVersion,
+		JobID:         job.ID.String(),
+		CancelledAt:   job.UpdatedAt,
+		CancelledBy:   job.CancelledBy,
+		Reason:        job.CancellationReason,
+		Tenant:        &job.Tenant,
+	}
+
+	// Don't fail if Kafka is unavailable - the job is marked cancelling and the message is relayed later
+	s.publish(ctx, cancellationsTopic(), "", message)
+
+	return job, nil
+}
+
+// PrioritizeJob moves a pending job ahead of the backlog by republishing it to the
+// priority topic. The job's dispatch ID is replaced so workers skip the original message.
+func (s *jobsService) PrioritizeJob(ctx context.Context, id string, ifMatch []time.Time) (*models.Job, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(job, ifMatch) {
+		return nil, ErrPreconditionFailed
+	}
+	if !job.CanBePrioritized() {
+		return nil, ErrInvalidJobState
+	}
+	// Prioritizing twice would only publish another copy
+	if job.Priority == models.JobPriorityHigh {
+		return job, nil
+	}
+
+	job, err = s.repo.Prioritize(ctx, id, primitive.NewObjectID().Hex(), ifMatch)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, staleJobError(ifMatch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to prioritize job: %w", err)
+	}
+
+	s.publishJob(ctx, job)
+
+	return job, nil
+}
+
+// versionMatches reports whether a job is at one of the versions a caller
+// expects. A job's version is its last update time; nil accepts any version.
+func versionMatches(job *models.Job, ifMatch []time.Time) bool {
+	if ifMatch == nil {
+		return true
+	}
+	for _, version := range ifMatch {
+		if job.UpdatedAt.Equal(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// staleJobError is the error of a conditional update that matched no job. Every
+// transition moves the job to a new version, so with versions given the job
+// changed since the caller read it.
+func staleJobError(ifMatch []time.Time) error {
+	if ifMatch != nil {
+		return ErrPreconditionFailed
+	}
+	return ErrInvalidJobState
+}
+
+// RetryJob returns a failed job to pending and republishes it, counting the
+// retry against the job's retry policy. The job gets a new dispatch ID, so a
+// redelivery of the message that failed cannot run it again.
+func (s *jobsService) RetryJob(ctx context.Context, id string, ifMatch []time.Time) (*models.Job, error) {
+	job, err := s.GetJob(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !versionMatches(job, ifMatch) {
+		return nil, ErrPreconditionFailed
+	}
+	if job.Status != models.JobStatusFailed {
+		return nil, ErrInvalidJobState
+	}
+	if !job.CanBeRetried() {
+		return nil, ErrMaxRetriesReached
+	}
+	previous := job.Status
+
+	// The update is conditional on the status, the retries left and the version
+	// if given, so a concurrent retry or transition is not overwritten
+	job, err = s.repo.Retry(ctx, id, job.Retry().MaxRetries, primitive.NewObjectID().Hex(), ifMatch)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, staleJobError(ifMatch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry job: %w", err)
+	}
+	s.events.StatusChanged(ctx, job, previous)
+
+	s.publishJob(ctx, job)
+
+	return job, nil
+}
+
+// publish publishes a message to Kafka, falling back to the outbox when the
+// broker is unavailable so the message is delivered later by the OutboxRelay
+func (s *jobsService) publish(ctx context.Context, topic, key string, message interface{}) {
+	publishWithOutbox(ctx, s.producer, s.outbox, topic, key, message)
+}
+
+// publishWithOutbox publishes a message to Kafka and enqueues it to the outbox if publishing fails
+func publishWithOutbox(ctx context.Context, producer *KafkaProducer, outbox repositories.OutboxRepository, topic, key string, message interface{}) {
+	err := producer.PublishWithKey(ctx, topic, key, message)
+	if err == nil {
+		return
+	}
+
+	payload, marshalErr := json.Marshal(message)
+	if marshalErr != nil {
+		log.Printf("Failed to marshal message for topic %s: %v", topic, marshalErr)
+		return
+	}
+
+	outboxMessage := &models.OutboxMessage{
+		Topic:     topic,
+		Key:       key,
+		Payload:   payload,
+		LastError: err.Error(),
+	}
+	if err := outbox.Enqueue(ctx, outboxMessage); err != nil {
+		log.Printf("Failed to enqueue message for topic %s to outbox, message lost: %v", topic, err)
+		return
+	}
+
+	log.Printf("Kafka unavailable, queued message for topic %s to outbox: %v", topic, err)
 }
 
 // IsValidationError checks if an error is a validation error
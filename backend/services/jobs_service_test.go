@@ -1,29 +1,390 @@
 package services
 
-// TODO: Candidate writes tests here
-//
-// Test the following scenarios:
-//
-// CreateJob:
-// - Valid input creates a job successfully
-// - Invalid job_type returns an error
-// - Missing required field "name" returns an error
-//
-// GetJob:
-// - Existing job is returned
-// - Non-existent job returns a not-found error
-//
-// CancelJob: (after completing Task 2)
-// - Valid cancellation works
-// - Cancelling a "completed" job returns an error
-// - Cancelling a non-existent job returns an error
-//
-// RetryJob: (after completing Task 2)
-// - Valid retry works
-// - Retrying a non-failed job returns an error
-// - Retrying when max retries reached returns an error
-//
-// Requirements:
-// - Mock JobsRepository and KafkaProducer interfaces
-// - Use table-driven tests where appropriate
-// - Verify the correct Kafka message is published for cancellations
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"github.com/fullstack-assessment/backend/secrets"
+)
+
+// fakeJobsRepository keeps jobs in memory by ID, applying the conditional
+// transitions of the repository to them
+type fakeJobsRepository struct {
+	repositories.JobsRepository
+	jobs map[string]*models.Job
+	// stale are handed out, oldest first, by RedispatchStalePending
+	stale []*models.Job
+	// err fails the next write
+	err error
+}
+
+func newFakeJobsRepository(jobs ...*models.Job) *fakeJobsRepository {
+	repo := &fakeJobsRepository{jobs: make(map[string]*models.Job)}
+	for _, job := range jobs {
+		repo.jobs[job.ID.String()] = job
+	}
+	return repo
+}
+
+func (r *fakeJobsRepository) Create(ctx context.Context, job *models.Job) error {
+	if r.err != nil {
+		return r.err
+	}
+	if _, ok := r.jobs[job.ID.String()]; ok {
+		return repositories.ErrConflict
+	}
+	job.CreatedAt = time.Now()
+	job.UpdatedAt = job.CreatedAt
+	stored := *job
+	r.jobs[job.ID.String()] = &stored
+	return nil
+}
+
+func (r *fakeJobsRepository) ClaimJobID(ctx context.Context, id models.JobID, tenant string) error {
+	return nil
+}
+
+func (r *fakeJobsRepository) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	found := *job
+	return &found, nil
+}
+
+// transition applies update to a job in one of statuses at one of versions,
+// returning ErrConflict like the repository's conditional updates
+func (r *fakeJobsRepository) transition(id string, statuses []models.JobStatus, versions []time.Time, update func(*models.Job)) (*models.Job, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	job, ok := r.jobs[id]
+	if !ok || !versionMatches(job, versions) {
+		return nil, repositories.ErrConflict
+	}
+	matched := false
+	for _, status := range statuses {
+		matched = matched || job.Status == status
+	}
+	if !matched {
+		return nil, repositories.ErrConflict
+	}
+	update(job)
+	job.UpdatedAt = job.UpdatedAt.Add(time.Second)
+	updated := *job
+	return &updated, nil
+}
+
+func (r *fakeJobsRepository) RequestCancellation(ctx context.Context, id, actor, reason string, versions []time.Time) (*models.Job, error) {
+	return r.transition(id, []models.JobStatus{models.JobStatusPending, models.JobStatusProcessing}, versions, func(job *models.Job) {
+		job.Status = models.JobStatusCancelling
+		job.CancelledBy = actor
+		job.CancellationReason = reason
+	})
+}
+
+func (r *fakeJobsRepository) Retry(ctx context.Context, id string, maxRetries int, dispatchID string, versions []time.Time) (*models.Job, error) {
+	return r.transition(id, []models.JobStatus{models.JobStatusFailed}, versions, func(job *models.Job) {
+		job.Status = models.JobStatusPending
+		job.RetryCount++
+		job.DispatchID = dispatchID
+		job.ErrorMessage = ""
+	})
+}
+
+func (r *fakeJobsRepository) RedispatchStalePending(ctx context.Context, before time.Time, dispatchID string) (*models.Job, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.stale) == 0 {
+		return nil, repositories.ErrNotFound
+	}
+	job := *r.stale[0]
+	r.stale = r.stale[1:]
+	job.DispatchID = dispatchID
+	return &job, nil
+}
+
+// fakeOutbox collects the messages a rejected publish leaves for the relay
+type fakeOutbox struct {
+	repositories.OutboxRepository
+	messages []*models.OutboxMessage
+}
+
+func (o *fakeOutbox) Enqueue(ctx context.Context, message *models.OutboxMessage) error {
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+// decode unmarshals the payload of the i-th queued message into v
+func (o *fakeOutbox) decode(t *testing.T, i int, v interface{}) *models.OutboxMessage {
+	t.Helper()
+	if i >= len(o.messages) {
+		t.Fatalf("outbox holds %d messages, want at least %d", len(o.messages), i+1)
+	}
+	if err := json.Unmarshal(o.messages[i].Payload, v); err != nil {
+		t.Fatalf("outbox message %d payload: %v", i, err)
+	}
+	return o.messages[i]
+}
+
+// rejectingProducer returns a producer whose circuit is half-open with its
+// trial publish in flight: it reports itself available, but rejects every
+// publish at once, so the services queue their messages to the outbox
+func rejectingProducer() *KafkaProducer {
+	breaker := NewCircuitBreaker(1, time.Hour)
+	breaker.state = CircuitHalfOpen
+	breaker.trialInFlight = true
+	return &KafkaProducer{breaker: breaker}
+}
+
+// unavailableProducer returns a producer whose circuit to its only cluster is open
+func unavailableProducer() *KafkaProducer {
+	breaker := NewCircuitBreaker(1, time.Hour)
+	breaker.Failure()
+	return &KafkaProducer{breaker: breaker}
+}
+
+func newTestJobsService(repo repositories.JobsRepository, outbox repositories.OutboxRepository, producer *KafkaProducer) *jobsService {
+	return &jobsService{
+		repo:        repo,
+		outbox:      outbox,
+		producer:    producer,
+		maintenance: NewMaintenance(),
+		events:      NewEventBus(),
+		encryptor:   secrets.NewEncryptor(nil, nil),
+		config:      JobsServiceConfig{}.withDefaults(),
+	}
+}
+
+func testJobID(t *testing.T, hex string) models.JobID {
+	t.Helper()
+	id, err := models.ParseJobID(hex)
+	if err != nil {
+		t.Fatalf("ParseJobID(%s) error = %v", hex, err)
+	}
+	return id
+}
+
+func TestCreateJob(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	later := future.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		req         CreateJobRequest
+		wantField   string
+		wantStatus  models.JobStatus
+		wantMessage bool
+	}{
+		{name: "valid", req: CreateJobRequest{Name: "nightly", JobType: "process", Tenant: "acme"}, wantStatus: models.JobStatusPending, wantMessage: true},
+		{name: "client ID", req: CreateJobRequest{ID: "65a1b2c3d4e5f60718293a4b", Name: "nightly", JobType: "export"}, wantStatus: models.JobStatusPending, wantMessage: true},
+		{name: "scheduled", req: CreateJobRequest{Name: "nightly", JobType: "process", RunAt: &future}, wantStatus: models.JobStatusPending},
+		{name: "awaiting input", req: CreateJobRequest{Name: "upload", JobType: "process", HoldForInput: true}, wantStatus: models.JobStatusAwaitingInput},
+		{name: "missing name", req: CreateJobRequest{JobType: "process"}, wantField: "name"},
+		{name: "invalid job type", req: CreateJobRequest{Name: "nightly", JobType: "render"}, wantField: "job_type"},
+		{name: "invalid ID", req: CreateJobRequest{ID: "job-1", Name: "nightly", JobType: "process"}, wantField: "id"},
+		{name: "past deadline", req: CreateJobRequest{Name: "nightly", JobType: "process", DeadlineAt: &past}, wantField: "deadline_at"},
+		{name: "run after deadline", req: CreateJobRequest{Name: "nightly", JobType: "process", RunAt: &later, DeadlineAt: &future}, wantField: "run_at"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeJobsRepository()
+			outbox := &fakeOutbox{}
+			service := newTestJobsService(repo, outbox, rejectingProducer())
+
+			job, err := service.CreateJob(context.Background(), tt.req)
+			if tt.wantField != "" {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) || validationErr.Field != tt.wantField {
+					t.Fatalf("CreateJob() error = %v, want a validation error on %s", err, tt.wantField)
+				}
+				if len(repo.jobs) != 0 || len(outbox.messages) != 0 {
+					t.Errorf("rejected CreateJob() stored %d jobs and queued %d messages", len(repo.jobs), len(outbox.messages))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateJob() error = %v", err)
+			}
+
+			stored, ok := repo.jobs[job.ID.String()]
+			if !ok {
+				t.Fatalf("job %s was not stored", job.ID.String())
+			}
+			if stored.Status != tt.wantStatus || stored.Tenant != tt.req.Tenant || stored.RetryPolicy == nil {
+				t.Errorf("stored job = %+v, want status %s of tenant %q with a retry policy", stored, tt.wantStatus, tt.req.Tenant)
+			}
+			if tt.req.ID != "" && job.ID.String() != tt.req.ID {
+				t.Errorf("job ID = %s, want the client's %s", job.ID.String(), tt.req.ID)
+			}
+
+			if !tt.wantMessage {
+				if len(outbox.messages) != 0 {
+					t.Errorf("CreateJob() queued %d messages, want none until the job is due", len(outbox.messages))
+				}
+				return
+			}
+			var message JobMessage
+			queued := outbox.decode(t, 0, &message)
+			if queued.Topic != JobTopic(models.JobType(tt.req.JobType)) {
+				t.Errorf("message topic = %s, want %s", queued.Topic, JobTopic(models.JobType(tt.req.JobType)))
+			}
+			if message.JobID != job.ID.String() || message.JobType != tt.req.JobType || message.SchemaVersion != MessageSchemaVersion {
+				t.Errorf("message = %+v, want job %s of type %s", message, job.ID.String(), tt.req.JobType)
+			}
+		})
+	}
+}
+
+func TestCreateJobTwiceWithClientID(t *testing.T) {
+	repo := newFakeJobsRepository()
+	service := newTestJobsService(repo, &fakeOutbox{}, rejectingProducer())
+	req := CreateJobRequest{ID: "65a1b2c3d4e5f60718293a4b", Name: "nightly", JobType: "process"}
+
+	if _, err := service.CreateJob(context.Background(), req); err != nil {
+		t.Fatalf("CreateJob() error = %v", err)
+	}
+	_, err := service.CreateJob(context.Background(), req)
+	var duplicate *DuplicateJobError
+	if !errors.As(err, &duplicate) {
+		t.Fatalf("second CreateJob() error = %v, want a DuplicateJobError", err)
+	}
+	if len(repo.jobs) != 1 {
+		t.Errorf("repository holds %d jobs, want the original only", len(repo.jobs))
+	}
+}
+
+func TestGetJob(t *testing.T) {
+	id := testJobID(t, "65a1b2c3d4e5f60718293a4b")
+	service := newTestJobsService(newFakeJobsRepository(&models.Job{ID: id, Status: models.JobStatusPending}), &fakeOutbox{}, rejectingProducer())
+
+	tests := []struct {
+		name    string
+		id      string
+		wantErr error
+	}{
+		{name: "existing", id: id.String()},
+		{name: "missing", id: "65a1b2c3d4e5f60718293a4c", wantErr: ErrJobNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job, err := service.GetJob(context.Background(), tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetJob() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && job.ID != id {
+				t.Errorf("GetJob() = job %s, want %s", job.ID.String(), id.String())
+			}
+		})
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	id := testJobID(t, "65a1b2c3d4e5f60718293a4b")
+	updatedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		status  models.JobStatus
+		id      string
+		ifMatch []time.Time
+		repoErr error
+		wantErr error
+	}{
+		{name: "pending", status: models.JobStatusPending},
+		{name: "processing", status: models.JobStatusProcessing},
+		{name: "matching version", status: models.JobStatusPending, ifMatch: []time.Time{updatedAt}},
+		{name: "completed", status: models.JobStatusCompleted, wantErr: ErrInvalidJobState},
+		{name: "already cancelled", status: models.JobStatusCancelled, wantErr: ErrInvalidJobState},
+		{name: "missing", status: models.JobStatusPending, id: "65a1b2c3d4e5f60718293a4c", wantErr: ErrJobNotFound},
+		{name: "stale version", status: models.JobStatusPending, ifMatch: []time.Time{updatedAt.Add(-time.Minute)}, wantErr: ErrPreconditionFailed},
+		{name: "finished meanwhile", status: models.JobStatusPending, repoErr: repositories.ErrConflict, wantErr: ErrInvalidJobState},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeJobsRepository(&models.Job{ID: id, Status: tt.status, Tenant: "acme", UpdatedAt: updatedAt})
+			repo.err = tt.repoErr
+			outbox := &fakeOutbox{}
+			service := newTestJobsService(repo, outbox, rejectingProducer())
+
+			target := tt.id
+			if target == "" {
+				target = id.String()
+			}
+			job, err := service.CancelJob(context.Background(), target, CancelJobRequest{Reason: "wrong input", Actor: "user-1", IfMatch: tt.ifMatch})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CancelJob() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(outbox.messages) != 0 {
+					t.Errorf("failed CancelJob() queued %d messages", len(outbox.messages))
+				}
+				return
+			}
+
+			if job.Status != models.JobStatusCancelling {
+				t.Errorf("CancelJob() status = %s, want cancelling until a worker acknowledges", job.Status)
+			}
+			var message CancellationMessage
+			queued := outbox.decode(t, 0, &message)
+			if queued.Topic != cancellationsTopic() {
+				t.Errorf("message topic = %s, want %s", queued.Topic, cancellationsTopic())
+			}
+			if message.JobID != id.String() || message.Reason != "wrong input" || message.CancelledBy != "user-1" || message.Tenant == nil || *message.Tenant != "acme" {
+				t.Errorf("cancellation message = %+v, want job %s cancelled by user-1 in acme", message, id.String())
+			}
+		})
+	}
+}
+
+func TestRetryJob(t *testing.T) {
+	id := testJobID(t, "65a1b2c3d4e5f60718293a4b")
+	maxRetries := models.DefaultRetryPolicy().MaxRetries
+
+	tests := []struct {
+		name       string
+		status     models.JobStatus
+		retryCount int
+		wantErr    error
+	}{
+		{name: "failed", status: models.JobStatusFailed},
+		{name: "not failed", status: models.JobStatusCompleted, wantErr: ErrInvalidJobState},
+		{name: "max retries reached", status: models.JobStatusFailed, retryCount: maxRetries, wantErr: ErrMaxRetriesReached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeJobsRepository(&models.Job{ID: id, JobType: models.JobTypeProcess, Status: tt.status, RetryCount: tt.retryCount, DispatchID: "first"})
+			outbox := &fakeOutbox{}
+			service := newTestJobsService(repo, outbox, rejectingProducer())
+
+			job, err := service.RetryJob(context.Background(), id.String(), nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RetryJob() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if job.Status != models.JobStatusPending || job.RetryCount != tt.retryCount+1 {
+				t.Errorf("RetryJob() = status %s, retry count %d, want pending with one more retry", job.Status, job.RetryCount)
+			}
+			var message JobMessage
+			outbox.decode(t, 0, &message)
+			if message.DispatchID == "" || message.DispatchID == "first" || message.DispatchID != job.DispatchID {
+				t.Errorf("retry message dispatch ID = %q, want the job's new %q", message.DispatchID, job.DispatchID)
+			}
+		})
+	}
+}
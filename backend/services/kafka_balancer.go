@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Producer balancer strategies, choosing the partition of each message
+const (
+	// BalancerLeastBytes sends messages to the partition that was sent the fewest bytes
+	BalancerLeastBytes = "least-bytes"
+	// BalancerRoundRobin sends messages to the partitions in turn
+	BalancerRoundRobin = "round-robin"
+	// BalancerHash sends messages with the same key to the same partition, and
+	// messages without a key to the partitions in turn
+	BalancerHash = "hash"
+	// BalancerSticky sends messages to one partition until stickyBatchBytes were
+	// sent to it, then moves to another, so batches fill up
+	BalancerSticky = "sticky"
+)
+
+// stickyBatchBytes is how much a sticky balancer sends to a partition before
+// moving on, the most the writer puts in one batch
+const stickyBatchBytes = 1 << 20
+
+// newKafkaBalancer returns the balancer of a strategy. Whatever the strategy,
+// messages with a key are hashed by it, so jobs sharing an affinity key keep
+// landing on the same partition.
+func newKafkaBalancer(strategy string) (kafka.Balancer, error) {
+	var unkeyed kafka.Balancer
+	switch strategy {
+	case "", BalancerLeastBytes:
+		unkeyed = &kafka.LeastBytes{}
+	case BalancerRoundRobin:
+		unkeyed = &kafka.RoundRobin{}
+	case BalancerHash:
+		return &kafka.Hash{}, nil
+	case BalancerSticky:
+		unkeyed = &stickyBalancer{maxBytes: stickyBatchBytes}
+	default:
+		return nil, fmt.Errorf("unsupported Kafka balancer %q", strategy)
+	}
+	return &keyedBalancer{unkeyed: unkeyed}, nil
+}
+
+// keyedBalancer hashes messages with a key, and balances the others with unkeyed
+type keyedBalancer struct {
+	hash    kafka.Hash
+	unkeyed kafka.Balancer
+}
+
+func (b *keyedBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	if len(msg.Key) > 0 {
+		return b.hash.Balance(msg, partitions...)
+	}
+	return b.unkeyed.Balance(msg, partitions...)
+}
+
+// stickyBalancer sends messages to a random partition until maxBytes were sent
+// to it, then picks another one. Unlike least-bytes, which spreads every batch
+// over all partitions, it keeps batches large when messages are big, and
+// spreads the load evenly over time.
+type stickyBalancer struct {
+	mu        sync.Mutex
+	maxBytes  int
+	partition int
+	sent      int
+	started   bool
+}
+
+func (b *stickyBalancer) Balance(msg kafka.Message, partitions ...int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.started || b.sent >= b.maxBytes || !containsPartition(partitions, b.partition) {
+		b.partition = b.next(partitions)
+		b.sent = 0
+		b.started = true
+	}
+	b.sent += len(msg.Key) + len(msg.Value)
+	return b.partition
+}
+
+// next picks a random partition other than the current one, when there is another
+func (b *stickyBalancer) next(partitions []int) int {
+	if len(partitions) == 1 {
+		return partitions[0]
+	}
+	for {
+		partition := partitions[rand.Intn(len(partitions))]
+		if !b.started || partition != b.partition {
+			return partition
+		}
+	}
+}
+
+func containsPartition(partitions []int, partition int) bool {
+	for _, p := range partitions {
+		if p == partition {
+			return true
+		}
+	}
+	return false
+}
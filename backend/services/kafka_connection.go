@@ -0,0 +1,238 @@
+package services
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// kafkaTimeout bounds dialing brokers and admin requests
+const kafkaTimeout = 10 * time.Second
+
+// SASL mechanisms supported for authenticating to Kafka
+const (
+	SASLPlain       = "plain"
+	SASLScramSHA256 = "scram-sha-256"
+	SASLScramSHA512 = "scram-sha-512"
+)
+
+// Where a consumer group without committed offsets starts reading
+const (
+	StartOffsetFirst = "first"
+	StartOffsetLast  = "last"
+)
+
+// Strategies assigning partitions to the members of a consumer group
+const (
+	RebalanceRange      = "range"
+	RebalanceRoundRobin = "roundrobin"
+)
+
+// ReaderSettings tune the consumer group readers created by a connection
+type ReaderSettings struct {
+	// StartOffset is StartOffsetFirst or StartOffsetLast. It only applies to a
+	// group without committed offsets; "last" skips messages published before it joined.
+	StartOffset       string
+	RebalanceStrategy string
+	// SessionTimeout is how long the group waits for a member's heartbeat before rebalancing
+	SessionTimeout time.Duration
+	// MaxWait is how long a fetch waits for new messages before returning
+	MaxWait time.Duration
+}
+
+// KafkaConnectionConfig holds the brokers and security settings used to reach Kafka
+type KafkaConnectionConfig struct {
+	Brokers []string
+	// TLS enables TLS; the CA file is optional and the system roots are used without it
+	TLS                   bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSInsecureSkipVerify bool
+	// SASLMechanism is empty for no authentication, or one of the SASL constants
+	SASLMechanism string
+	SASLUsername  string
+	SASLPassword  string
+	// Reader tunes the consumer group readers
+	Reader ReaderSettings
+}
+
+// KafkaConnection builds Kafka clients, writers and readers sharing the same
+// brokers and security settings
+type KafkaConnection struct {
+	brokers   []string
+	dialer    *kafka.Dialer
+	transport *kafka.Transport
+	reader    ReaderSettings
+	balancers []kafka.GroupBalancer
+}
+
+// ParseBrokers splits a comma-separated broker list, dropping empty entries
+func ParseBrokers(brokers string) []string {
+	var list []string
+	for _, broker := range strings.Split(brokers, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			list = append(list, broker)
+		}
+	}
+	return list
+}
+
+// NewKafkaConnection creates a Kafka connection, loading any TLS certificates
+// and SASL credentials up front so misconfiguration fails at startup
+func NewKafkaConnection(config KafkaConnectionConfig) (*KafkaConnection, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+
+	tlsConfig, err := kafkaTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := kafkaSASLMechanism(config)
+	if err != nil {
+		return nil, err
+	}
+	balancers, err := kafkaGroupBalancers(config.Reader.RebalanceStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KafkaConnection{
+		brokers: config.Brokers,
+		dialer: &kafka.Dialer{
+			Timeout:       kafkaTimeout,
+			DualStack:     true,
+			TLS:           tlsConfig,
+			SASLMechanism: mechanism,
+		},
+		transport: &kafka.Transport{
+			DialTimeout: kafkaTimeout,
+			TLS:         tlsConfig,
+			SASL:        mechanism,
+		},
+		reader:    config.Reader,
+		balancers: balancers,
+	}, nil
+}
+
+// Brokers returns the configured broker addresses
+func (c *KafkaConnection) Brokers() []string {
+	return c.brokers
+}
+
+// Addr returns the broker addresses for writers and clients, which try each in turn
+func (c *KafkaConnection) Addr() net.Addr {
+	return kafka.TCP(c.brokers...)
+}
+
+// Dialer returns the dialer readers use to connect to the brokers
+func (c *KafkaConnection) Dialer() *kafka.Dialer {
+	return c.dialer
+}
+
+// Transport returns the transport shared by writers and admin clients
+func (c *KafkaConnection) Transport() *kafka.Transport {
+	return c.transport
+}
+
+// ReaderConfig returns the configuration of a consumer group reader of topic
+// with the connection's reader settings. Callers add fetch sizes as needed.
+func (c *KafkaConnection) ReaderConfig(topic, groupID string) kafka.ReaderConfig {
+	config := kafka.ReaderConfig{
+		Brokers:        c.brokers,
+		Dialer:         c.dialer,
+		Topic:          topic,
+		GroupID:        groupID,
+		GroupBalancers: c.balancers,
+		SessionTimeout: c.reader.SessionTimeout,
+		MaxWait:        c.reader.MaxWait,
+		StartOffset:    kafka.FirstOffset,
+	}
+	if c.reader.StartOffset == StartOffsetLast {
+		config.StartOffset = kafka.LastOffset
+	}
+	return config
+}
+
+// kafkaGroupBalancers returns the group balancers of a rebalance strategy; empty uses the client default
+func kafkaGroupBalancers(strategy string) ([]kafka.GroupBalancer, error) {
+	switch strategy {
+	case "":
+		return nil, nil
+	case RebalanceRange:
+		return []kafka.GroupBalancer{kafka.RangeGroupBalancer{}}, nil
+	case RebalanceRoundRobin:
+		return []kafka.GroupBalancer{kafka.RoundRobinGroupBalancer{}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported Kafka rebalance strategy %q", strategy)
+	}
+}
+
+// Client returns a client for admin and metadata requests
+func (c *KafkaConnection) Client() *kafka.Client {
+	return &kafka.Client{
+		Addr:      c.Addr(),
+		Timeout:   kafkaTimeout,
+		Transport: c.transport,
+	}
+}
+
+func kafkaTLSConfig(config KafkaConnectionConfig) (*tls.Config, error) {
+	if !config.TLS {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.TLSInsecureSkipVerify,
+	}
+	if config.TLSCAFile != "" {
+		pem, err := os.ReadFile(config.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Kafka CA file %s", config.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func kafkaSASLMechanism(config KafkaConnectionConfig) (sasl.Mechanism, error) {
+	switch strings.ToLower(config.SASLMechanism) {
+	case "":
+		return nil, nil
+	case SASLPlain:
+		return plain.Mechanism{Username: config.SASLUsername, Password: config.SASLPassword}, nil
+	case SASLScramSHA256:
+		return scram.Mechanism(scram.SHA256, config.SASLUsername, config.SASLPassword)
+	case SASLScramSHA512:
+		return scram.Mechanism(scram.SHA512, config.SASLUsername, config.SASLPassword)
+	default:
+		return nil, fmt.Errorf("unsupported Kafka SASL mechanism %q", config.SASLMechanism)
+	}
+}
+
+// Close releases the idle connections held by the shared transport
+func (c *KafkaConnection) Close() {
+	c.transport.CloseIdleConnections()
+}
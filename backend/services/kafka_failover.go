@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// maxFailoverReasonLength bounds the size of a failover reason
+const maxFailoverReasonLength = 1000
+
+// KafkaFailoverRequest switches the worker fleet to a Kafka region
+type KafkaFailoverRequest struct {
+	Region string `json:"region"`
+	Reason string `json:"reason,omitempty"`
+	// Actor is the authenticated caller switching the region
+	Actor string `json:"-"`
+}
+
+// KafkaFailoverStatus is the region the fleet is switched to, with the workers
+// consuming jobs and on standby counted by their region, so operators can tell
+// when the whole fleet has followed a switch. Workers without a region do not
+// follow the switch and are not counted.
+type KafkaFailoverStatus struct {
+	models.KafkaFailover
+	Active  map[models.KafkaRegion]int `json:"active"`
+	Standby map[models.KafkaRegion]int `json:"standby"`
+}
+
+// KafkaFailoverService interface defines the methods for the Kafka failover switch
+type KafkaFailoverService interface {
+	GetFailover(ctx context.Context) (*KafkaFailoverStatus, error)
+	SetFailover(ctx context.Context, req KafkaFailoverRequest) (*KafkaFailoverStatus, error)
+}
+
+type kafkaFailoverService struct {
+	repo    repositories.KafkaFailoverRepository
+	workers WorkersService
+}
+
+// NewKafkaFailoverService creates a new Kafka failover service
+func NewKafkaFailoverService(repo repositories.KafkaFailoverRepository, workers WorkersService) KafkaFailoverService {
+	return &kafkaFailoverService{repo: repo, workers: workers}
+}
+
+// GetFailover returns the current region and how far the fleet has switched to it
+func (s *kafkaFailoverService) GetFailover(ctx context.Context) (*KafkaFailoverStatus, error) {
+	failover, err := s.repo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Kafka failover state: %w", err)
+	}
+	return s.status(ctx, failover)
+}
+
+// SetFailover switches the worker fleet to a region. Workers pick the change up
+// on their next poll; switching to the current region changes nothing.
+func (s *kafkaFailoverService) SetFailover(ctx context.Context, req KafkaFailoverRequest) (*KafkaFailoverStatus, error) {
+	region := models.KafkaRegion(req.Region)
+	switch region {
+	case models.KafkaRegionPrimary, models.KafkaRegionDR:
+	default:
+		return nil, &ValidationError{Field: "region", Message: "region must be one of primary, dr"}
+	}
+	if len(req.Reason) > maxFailoverReasonLength {
+		return nil, &ValidationError{
+			Field:   "reason",
+			Message: fmt.Sprintf("reason must not exceed %d characters", maxFailoverReasonLength),
+		}
+	}
+
+	current, err := s.repo.Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Kafka failover state: %w", err)
+	}
+	if current.Region == region {
+		return s.status(ctx, current)
+	}
+
+	now := time.Now()
+	failover := &models.KafkaFailover{Region: region, Reason: req.Reason, ChangedBy: req.Actor, ChangedAt: &now}
+	if err := s.repo.Set(ctx, failover); err != nil {
+		return nil, fmt.Errorf("failed to switch the Kafka region: %w", err)
+	}
+	log.Printf("Switched the worker fleet from the %s to the %s Kafka region for %s: %q", current.Region, region, req.Actor, req.Reason)
+	return s.status(ctx, failover)
+}
+
+func (s *kafkaFailoverService) status(ctx context.Context, failover *models.KafkaFailover) (*KafkaFailoverStatus, error) {
+	workers, err := s.workers.ListActiveWorkers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &KafkaFailoverStatus{
+		KafkaFailover: *failover,
+		Active:        make(map[models.KafkaRegion]int),
+		Standby:       make(map[models.KafkaRegion]int),
+	}
+	for _, worker := range workers {
+		switch {
+		case worker.KafkaRegion == "":
+		case worker.KafkaActive:
+			status.Active[worker.KafkaRegion]++
+		default:
+			status.Standby[worker.KafkaRegion]++
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,39 @@
+package services
+
+// KafkaNames namespaces the topics and consumer groups of the backend, so
+// several environments can share one Kafka cluster. Workers must use the same
+// prefixes, through KAFKA_TOPIC_PREFIX and KAFKA_GROUP_PREFIX.
+type KafkaNames struct {
+	// TopicPrefix is prepended to every topic, e.g. "staging" for staging.jobs.export
+	TopicPrefix string
+	// GroupPrefix is prepended to every consumer group; empty means TopicPrefix
+	GroupPrefix string
+}
+
+// kafkaNames is set once at startup, before any component publishes or consumes
+var kafkaNames KafkaNames
+
+// SetKafkaNames sets the prefixes of the backend's topics and consumer groups.
+// It must be called before any topic or group name is used.
+func SetKafkaNames(names KafkaNames) {
+	if names.GroupPrefix == "" {
+		names.GroupPrefix = names.TopicPrefix
+	}
+	kafkaNames = names
+}
+
+// topicName returns the namespaced name of a topic
+func topicName(name string) string {
+	if kafkaNames.TopicPrefix == "" {
+		return name
+	}
+	return kafkaNames.TopicPrefix + "." + name
+}
+
+// groupName returns the namespaced name of a consumer group
+func groupName(name string) string {
+	if kafkaNames.GroupPrefix == "" {
+		return name
+	}
+	return kafkaNames.GroupPrefix + "." + name
+}
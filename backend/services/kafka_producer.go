@@ -3,57 +3,557 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"math/rand"
+	"sync"
 	"time"
 
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
 	"github.com/segmentio/kafka-go"
 )
 
-// KafkaProducer handles publishing messages to Kafka topics
+var (
+	publishAttempts = metrics.NewCounter("kafka_publish_attempts_total",
+		"Kafka write attempts by topic and result.", "topic", "result")
+	circuitStateGauge = metrics.NewGauge("kafka_producer_circuit_state",
+		"Kafka producer circuit breaker state (0 closed, 1 half-open, 2 open).")
+	activeClusterGauge = metrics.NewGauge("kafka_producer_active_cluster",
+		"Kafka cluster the producer publishes to (0 primary, 1 secondary).")
+	failovers = metrics.NewCounter("kafka_producer_failovers_total",
+		"Kafka producer switches between clusters, by the cluster switched to.", "cluster")
+)
+
+// Kafka clusters a producer publishes to
+const (
+	clusterPrimary   = "primary"
+	clusterSecondary = "secondary"
+)
+
+// ProducerConfig holds the Kafka producer settings
+type ProducerConfig struct {
+	Connection *KafkaConnection
+	// MaxAttempts is the number of write attempts per publish, including the first
+	MaxAttempts int
+	// RetryBaseDelay is the initial backoff between attempts; it doubles each retry with jitter
+	RetryBaseDelay time.Duration
+	// BreakerThreshold is the number of consecutive failed publishes that opens the circuit
+	BreakerThreshold int
+	// BreakerTimeout is how long the circuit stays open before a trial publish
+	BreakerTimeout time.Duration
+	// Secondary is the cluster publishes fail over to while the circuit to the
+	// primary is open; nil disables failover
+	Secondary *KafkaConnection
+	// Balancer is the strategy choosing the partition of each message, one of
+	// least-bytes, round-robin, hash or sticky; empty means least-bytes
+	Balancer string
+	// TopicBalancers overrides Balancer by topic, named without the topic prefix
+	TopicBalancers map[string]string
+	// Compression is the codec messages are compressed with: none, gzip,
+	// snappy, lz4 or zstd; empty means none
+	Compression string
+}
+
+// DefaultProducerConfig returns the producer settings used when none are configured
+func DefaultProducerConfig(connection *KafkaConnection) ProducerConfig {
+	return ProducerConfig{
+		Connection:       connection,
+		MaxAttempts:      3,
+		RetryBaseDelay:   100 * time.Millisecond,
+		BreakerThreshold: 5,
+		BreakerTimeout:   30 * time.Second,
+	}
+}
+
+// KafkaProducer handles publishing messages to Kafka topics. With a secondary
+// cluster configured, publishes go there while the circuit to the primary is
+// open, and back to the primary once a trial publish closes it.
 type KafkaProducer struct {
-	writer *kafka.Writer
-	broker string
+	writer    *kafka.Writer
+	conn      *KafkaConnection
+	secondary *KafkaConnection
+	config    ProducerConfig
+	breaker   *CircuitBreaker
+	// compression is the codec of the producer's writers
+	compression kafka.Compression
+
+	// balancers are kept per cluster and topic across publishes, as writers
+	// are not: a balancer created with every writer would start over each
+	// time and send every single-message publish to the same partition
+	balancersMu sync.Mutex
+	balancers   map[balancerKey]kafka.Balancer
 }
 
-// NewKafkaProducer creates a new Kafka producer
-func NewKafkaProducer(broker string) *KafkaProducer {
-	return &KafkaProducer{
-		broker: broker,
+// balancerKey identifies the balancer of a topic on a cluster
+type balancerKey struct {
+	conn  *KafkaConnection
+	topic string
+}
+
+// NewKafkaProducer creates a new Kafka producer. It fails when a balancer
+// strategy or the compression codec is not supported.
+func NewKafkaProducer(config ProducerConfig) (*KafkaProducer, error) {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = 1
+	}
+	if _, err := newKafkaBalancer(config.Balancer); err != nil {
+		return nil, err
 	}
+	topicBalancers := make(map[string]string, len(config.TopicBalancers))
+	for topic, strategy := range config.TopicBalancers {
+		if _, err := newKafkaBalancer(strategy); err != nil {
+			return nil, fmt.Errorf("topic %s: %w", topic, err)
+		}
+		topicBalancers[topicName(topic)] = strategy
+	}
+	config.TopicBalancers = topicBalancers
+	compression, err := kafkaCompression(config.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &KafkaProducer{
+		conn:        config.Connection,
+		secondary:   config.Secondary,
+		config:      config,
+		breaker:     NewCircuitBreaker(config.BreakerThreshold, config.BreakerTimeout),
+		compression: compression,
+		balancers:   make(map[balancerKey]kafka.Balancer),
+	}
+	p.breaker.OnStateChange(func(from, to CircuitState) {
+		log.Printf("Kafka producer circuit breaker %s -> %s", from, to)
+		circuitStateGauge.Set(float64(to))
+		p.circuitChanged(from, to)
+	})
+	return p, nil
+}
+
+// CircuitState returns the state of the producer's circuit breaker
+func (p *KafkaProducer) CircuitState() CircuitState {
+	return p.breaker.State()
+}
+
+// Available reports whether publishes can be expected to succeed: the circuit
+// to the primary is not open, or there is a secondary cluster to fail over to
+func (p *KafkaProducer) Available() bool {
+	return p.secondary != nil || p.breaker.State() != CircuitOpen
+}
+
+// circuitChanged reports the failover to the secondary cluster when the circuit to the
+// primary opens, and back when it closes. A failed trial publish reopens the
+// circuit from half-open, which does not change the cluster.
+func (p *KafkaProducer) circuitChanged(from, to CircuitState) {
+	if p.secondary == nil {
+		return
+	}
+
+	var cluster string
+	switch {
+	case from == CircuitClosed && to == CircuitOpen:
+		cluster = clusterSecondary
+		activeClusterGauge.Set(1)
+	case to == CircuitClosed:
+		cluster = clusterPrimary
+		activeClusterGauge.Set(0)
+	default:
+		return
+	}
+	log.Printf("Kafka producer failover: publishing to the %s cluster", cluster)
+	failovers.Inc(cluster)
 }
 
 // Publish publishes a message to the specified Kafka topic
 func (p *KafkaProducer) Publish(ctx context.Context, topic string, message interface{}) error {
-	// Create a new writer for each publish to support different topics
-	writer := &kafka.Writer{
-		Addr:         kafka.TCP(p.broker),
-		Topic:        topic,
-		Balancer:     &kafka.LeastBytes{},
-		BatchTimeout: 10 * time.Millisecond,
-		RequiredAcks: kafka.RequireOne,
-	}
-	defer writer.Close()
+	return p.PublishWithKey(ctx, topic, "", message)
+}
 
+// PublishWithKey publishes a message to the specified Kafka topic using the given
+// partition key. Messages sharing a key are hashed to the same partition, so they are
+// consumed in order by a single worker. An empty key falls back to least-bytes balancing.
+func (p *KafkaProducer) PublishWithKey(ctx context.Context, topic, key string, message interface{}) error {
 	// Marshal the message to JSON
 	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
 
-	// Write the message
-	err = writer.WriteMessages(ctx, kafka.Message{
+	msg := kafka.Message{
 		Value: data,
-	})
+	}
+	if key != "" {
+		msg.Key = []byte(key)
+	}
 
-	if err != nil {
-		log.Printf("Failed to publish message to topic %s: %v", topic, err)
-		return err
+	errs := p.writeWithRetry(ctx, topic, []kafka.Message{msg})
+	if errs[0] != nil {
+		log.Printf("Failed to publish message to topic %s: %v", topic, errs[0])
+		return errs[0]
 	}
 
 	log.Printf("Published message to topic %s", topic)
 	return nil
 }
 
+// KeyedMessage wraps a message published through PublishBatch with a partition key
+type KeyedMessage struct {
+	Key   string
+	Value interface{}
+}
+
+// BatchPublishError reports which messages of a batch failed to publish.
+// Errors is aligned with the input messages; a nil entry means the message was written.
+type BatchPublishError struct {
+	Errors []error
+}
+
+func (e *BatchPublishError) Error() string {
+	failed := 0
+	for _, err := range e.Errors {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("failed to publish %d of %d messages", failed, len(e.Errors))
+}
+
+// Failed reports whether the message at index i failed to publish
+func (e *BatchPublishError) Failed(i int) bool {
+	return i < len(e.Errors) && e.Errors[i] != nil
+}
+
+// PublishBatch publishes multiple messages to the specified Kafka topic in a single
+// WriteMessages call. Messages may be wrapped in KeyedMessage to set a partition key.
+// If any message fails, a *BatchPublishError attributes the failure to each message.
+func (p *KafkaProducer) PublishBatch(ctx context.Context, topic string, messages []interface{}) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(messages))
+	failed := false
+
+	// Marshal every message up front, remembering where each one lands in the batch
+	batch := make([]kafka.Message, 0, len(messages))
+	batchIndex := make([]int, 0, len(messages))
+	for i, message := range messages {
+		var key string
+		if km, ok := message.(KeyedMessage); ok {
+			key = km.Key
+			message = km.Value
+		}
+
+		data, err := json.Marshal(message)
+		if err != nil {
+			errs[i] = err
+			failed = true
+			continue
+		}
+
+		msg := kafka.Message{Value: data}
+		if key != "" {
+			msg.Key = []byte(key)
+		}
+		batch = append(batch, msg)
+		batchIndex = append(batchIndex, i)
+	}
+
+	if len(batch) > 0 {
+		for j, err := range p.writeWithRetry(ctx, topic, batch) {
+			if err != nil {
+				errs[batchIndex[j]] = err
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		batchErr := &BatchPublishError{Errors: errs}
+		log.Printf("Failed to publish batch to topic %s: %v", topic, batchErr)
+		return batchErr
+	}
+
+	log.Printf("Published %d messages to topic %s", len(messages), topic)
+	return nil
+}
+
+// writeWithRetry writes the messages through the circuit breaker, retrying failed
+// messages with jittered exponential backoff. Attempts the open circuit rejects
+// are written to the secondary cluster, if there is one. It returns one error per message.
+func (p *KafkaProducer) writeWithRetry(ctx context.Context, topic string, msgs []kafka.Message) []error {
+	// A writer is created per publish to support different topics, and per cluster used
+	writers := make(map[*KafkaConnection]*kafka.Writer, 2)
+	defer func() {
+		for _, writer := range writers {
+			writer.Close()
+		}
+	}()
+	writerFor := func(conn *KafkaConnection) *kafka.Writer {
+		if writers[conn] == nil {
+			writers[conn] = p.newWriter(conn, topic)
+		}
+		return writers[conn]
+	}
+
+	errs := make([]error, len(msgs))
+	pending := make([]int, len(msgs))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	delay := p.config.RetryBaseDelay
+	for attempt := 1; len(pending) > 0; attempt++ {
+		conn := p.conn
+		if err := p.breaker.Allow(); err != nil {
+			if p.secondary == nil {
+				for _, i := range pending {
+					errs[i] = err
+				}
+				publishAttempts.Inc(topic, "rejected")
+				return errs
+			}
+			conn = p.secondary
+		}
+
+		batch := make([]kafka.Message, len(pending))
+		for j, i := range pending {
+			batch[j] = msgs[i]
+		}
+
+		err := writerFor(conn).WriteMessages(ctx, batch...)
+		p.recordWrite(conn, topic, err)
+		if err == nil {
+			for _, i := range pending {
+				errs[i] = nil
+			}
+			return errs
+		}
+
+		// Keep only the messages that failed for the next attempt
+		var writeErrs kafka.WriteErrors
+		var stillPending []int
+		if errors.As(err, &writeErrs) && len(writeErrs) == len(pending) {
+			for j, writeErr := range writeErrs {
+				errs[pending[j]] = writeErr
+				if writeErr != nil {
+					stillPending = append(stillPending, pending[j])
+				}
+			}
+		} else {
+			for _, i := range pending {
+				errs[i] = err
+			}
+			stillPending = pending
+		}
+		pending = stillPending
+
+		if attempt >= p.config.MaxAttempts || len(pending) == 0 {
+			break
+		}
+
+		// Jittered exponential backoff before retrying
+		wait := delay
+		if delay > 0 {
+			wait = time.Duration(rand.Int63n(int64(delay))) + delay/2
+		}
+		select {
+		case <-ctx.Done():
+			for _, i := range pending {
+				errs[i] = ctx.Err()
+			}
+			return errs
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+
+	return errs
+}
+
+// recordWrite counts the outcome of a write. Only writes to the primary count
+// toward its circuit; the secondary is written to while the circuit is open.
+func (p *KafkaProducer) recordWrite(conn *KafkaConnection, topic string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	if conn == p.secondary {
+		publishAttempts.Inc(topic, "secondary_"+result)
+		return
+	}
+
+	if err != nil {
+		p.breaker.Failure()
+	} else {
+		p.breaker.Success()
+	}
+	publishAttempts.Inc(topic, result)
+}
+
+// newWriter creates a writer for the topic on a cluster, with the topic's balancer
+func (p *KafkaProducer) newWriter(conn *KafkaConnection, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         conn.Addr(),
+		Transport:    conn.Transport(),
+		Topic:        topic,
+		Balancer:     p.balancer(conn, topic),
+		Compression:  p.compression,
+		BatchTimeout: 10 * time.Millisecond,
+		BatchSize:    1000,
+		RequiredAcks: kafka.RequireOne,
+		// Retries are handled by writeWithRetry so failures reach the circuit breaker
+		MaxAttempts: 1,
+	}
+}
+
+// kafkaCompression returns the compression of a codec name; empty means none
+func kafkaCompression(codec string) (kafka.Compression, error) {
+	switch codec {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported Kafka compression %q", codec)
+	}
+}
+
+// balancer returns the balancer of the topic on a cluster, created on first use
+// with the topic's strategy
+func (p *KafkaProducer) balancer(conn *KafkaConnection, topic string) kafka.Balancer {
+	p.balancersMu.Lock()
+	defer p.balancersMu.Unlock()
+
+	key := balancerKey{conn: conn, topic: topic}
+	if balancer, ok := p.balancers[key]; ok {
+		return balancer
+	}
+	strategy, ok := p.config.TopicBalancers[topic]
+	if !ok {
+		strategy = p.config.Balancer
+	}
+	// Strategies were validated by NewKafkaProducer
+	balancer, _ := newKafkaBalancer(strategy)
+	p.balancers[key] = balancer
+	return balancer
+}
+
+// TopicDepth returns the number of messages retained in a topic across all partitions
+func (p *KafkaProducer) TopicDepth(ctx context.Context, topic string) (int64, error) {
+	client := p.conn.Client()
+
+	ids, err := partitionIDs(ctx, client, topic)
+	if err != nil {
+		return 0, err
+	}
+	requests := make([]kafka.OffsetRequest, 0, 2*len(ids))
+	for _, id := range ids {
+		requests = append(requests, kafka.FirstOffsetOf(id), kafka.LastOffsetOf(id))
+	}
+
+	offsets, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var depth int64
+	for _, partition := range offsets.Topics[topic] {
+		if partition.Error != nil {
+			return 0, partition.Error
+		}
+		depth += partition.LastOffset - partition.FirstOffset
+	}
+
+	return depth, nil
+}
+
+// ConsumerLag returns the number of messages in a topic a consumer group has not committed yet
+func (p *KafkaProducer) ConsumerLag(ctx context.Context, topic, groupID string) (int64, error) {
+	client := p.conn.Client()
+
+	ids, err := partitionIDs(ctx, client, topic)
+	if err != nil {
+		return 0, err
+	}
+	requests := make([]kafka.OffsetRequest, len(ids))
+	for i, id := range ids {
+		requests[i] = kafka.LastOffsetOf(id)
+	}
+
+	committed, err := client.OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: ids},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if committed.Error != nil {
+		return 0, committed.Error
+	}
+	committedOffsets := make(map[int]int64)
+	for _, partition := range committed.Topics[topic] {
+		if partition.Error != nil {
+			return 0, partition.Error
+		}
+		committedOffsets[partition.Partition] = partition.CommittedOffset
+	}
+
+	latest, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var lag int64
+	for _, partition := range latest.Topics[topic] {
+		if partition.Error != nil {
+			return 0, partition.Error
+		}
+		// A group without a committed offset starts at the end of the partition
+		start, ok := committedOffsets[partition.Partition]
+		if !ok || start < 0 {
+			continue
+		}
+		if partition.LastOffset > start {
+			lag += partition.LastOffset - start
+		}
+	}
+
+	return lag, nil
+}
+
+// partitionIDs returns the partitions of a topic
+func partitionIDs(ctx context.Context, client *kafka.Client, topic string) ([]int, error) {
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range metadata.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, t.Error
+		}
+		ids := make([]int, len(t.Partitions))
+		for i, partition := range t.Partitions {
+			ids[i] = partition.ID
+		}
+		return ids, nil
+	}
+	return nil, fmt.Errorf("topic %s not found", topic)
+}
+
 // Close closes the Kafka producer
 func (p *KafkaProducer) Close() error {
 	if p.writer != nil {
@@ -62,25 +562,93 @@ func (p *KafkaProducer) Close() error {
 	return nil
 }
 
+// JobTopic returns the Kafka topic jobs of the given type are published to.
+// Each type has its own topic so workers can subscribe only to the types they handle.
+func JobTopic(jobType models.JobType) string {
+	return topicName("jobs." + string(jobType))
+}
+
+// JobConsumerGroup returns the consumer group workers use for jobs of the given type
+func JobConsumerGroup(jobType models.JobType) string {
+	return groupName("job-worker-" + string(jobType))
+}
+
+// JobPriorityTopic returns the Kafka topic prioritized jobs of the given type are published to.
+// Workers consume it alongside the regular topic, so its jobs don't wait behind the backlog.
+func JobPriorityTopic(jobType models.JobType) string {
+	return JobTopic(jobType) + ".priority"
+}
+
+// JobCanaryTopic returns the Kafka topic of jobs of the given type routed to the
+// workers running the canary executor version. Only canary workers consume it.
+func JobCanaryTopic(jobType models.JobType) string {
+	return JobTopic(jobType) + ".canary"
+}
+
+// MessageSchemaVersion is the version of the layout of the Kafka messages
+// exchanged with workers, carried in their schema_version field. Adding a field
+// keeps it: readers ignore fields they do not know and handle messages without
+// the fields they expect. It is raised only when the meaning of an existing
+// field changes, once every worker can upgrade messages of the older version.
+// Messages published before versioning carry no version and are version 1.
+const MessageSchemaVersion = 1
+
 // JobMessage represents a job message published to Kafka
 type JobMessage struct {
-	JobID     string                 `json:"job_id"`
-	Name      string                 `json:"name"`
-	JobType   string                 `json:"job_type"`
-	Config    map[string]interface{} `json:"config,omitempty"`
-	CreatedAt time.Time              `json:"created_at"`
+	SchemaVersion int                    `json:"schema_version"`
+	JobID         string                 `json:"job_id"`
+	Name          string                 `json:"name"`
+	JobType       string                 `json:"job_type"`
+	Config        map[string]interface{} `json:"config,omitempty"`
+	AffinityKey   string                 `json:"affinity_key,omitempty"`
+	// ConfigRef replaces Config for configs offloaded to GridFS
+	ConfigRef *models.BlobRef `json:"config_ref,omitempty"`
+	// DispatchID identifies the message that may run the job once it has been republished;
+	// older messages for the job are skipped by workers
+	DispatchID string    `json:"dispatch_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	// DeadlineAt is when workers fail the job if it has not completed
+	DeadlineAt *time.Time `json:"deadline_at,omitempty"`
+	// Input locates the uploaded input file of jobs created with hold_for_input
+	Input *JobInputMessage `json:"input,omitempty"`
+	// Tenant lets workers address the job by the shard key of the jobs collection
+	Tenant *string `json:"tenant,omitempty"`
+}
+
+// JobInputMessage locates a job's input file in artifact storage
+type JobInputMessage struct {
+	Storage     string `json:"storage"`
+	Key         string `json:"key"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum"`
 }
 
 // CancellationMessage represents a cancellation message published to Kafka
 type CancellationMessage struct {
-	JobID       string    `json:"job_id"`
-	CancelledAt time.Time `json:"cancelled_at"`
+	SchemaVersion int       `json:"schema_version"`
+	JobID         string    `json:"job_id"`
+	CancelledAt   time.Time `json:"cancelled_at"`
+	CancelledBy   string    `json:"cancelled_by,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	// Tenant lets workers address the job by the shard key of the jobs collection
+	Tenant *string `json:"tenant,omitempty"`
 }
 
 // DLQMessage represents a dead letter queue message
 type DLQMessage struct {
-	JobID        string    `json:"job_id"`
-	FailedAt     time.Time `json:"failed_at"`
-	ErrorMessage string    `json:"error_message"`
-	RetryCount   int       `json:"retry_count"`
+	SchemaVersion int       `json:"schema_version"`
+	JobID         string    `json:"job_id"`
+	FailedAt      time.Time `json:"failed_at"`
+	ErrorMessage  string    `json:"error_message"`
+	RetryCount    int       `json:"retry_count"`
+}
+
+// CancellationAckMessage is published by a worker once it has stopped a cancelled job
+type CancellationAckMessage struct {
+	SchemaVersion int       `json:"schema_version"`
+	JobID         string    `json:"job_id"`
+	WorkerID      string    `json:"worker_id"`
+	AckedAt       time.Time `json:"acked_at"`
 }
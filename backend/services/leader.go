@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// Leases of the background components that must run on a single backend instance
+const (
+	LeaseOutboxRelay         = "outbox-relay"
+	LeaseCancellationSweeper = "cancellation-sweeper"
+	LeaseAlertEvaluator      = "alert-evaluator"
+	LeaseFanInSweeper        = "fan-in-sweeper"
+	LeasePendingReconciler   = "pending-reconciler"
+	LeaseUsageAggregator     = "usage-aggregator"
+	LeaseJobSummarySync      = "job-summary-sync"
+	LeaseRetention           = "retention"
+)
+
+var leaseHeld = metrics.NewGauge("leader_lease_held",
+	"Whether this instance holds the lease of a singleton component (1) or not (0).", "lease")
+
+// LeaderElector runs singleton components on the one backend instance holding
+// their lease in MongoDB. Every instance tries to take each lease on an
+// interval; the holder renews it, and when the holder stops renewing, the
+// lease expires and another instance takes the component over.
+type LeaderElector struct {
+	leases   repositories.LeasesRepository
+	holder   string
+	ttl      time.Duration
+	interval time.Duration
+	wg       sync.WaitGroup
+}
+
+// NewLeaderElector creates a leader elector for holder. Leases are renewed every
+// interval, which must be shorter than ttl.
+func NewLeaderElector(leases repositories.LeasesRepository, holder string, ttl, interval time.Duration) *LeaderElector {
+	return &LeaderElector{
+		leases:   leases,
+		holder:   holder,
+		ttl:      ttl,
+		interval: interval,
+	}
+}
+
+// Start runs component while this instance holds the named lease, until ctx is
+// cancelled. The context passed to component is cancelled when the lease is lost.
+func (e *LeaderElector) Start(ctx context.Context, name string, component func(ctx context.Context)) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		e.run(ctx, name, component)
+	}()
+}
+
+// Wait blocks until every started component has stopped and its lease is released
+func (e *LeaderElector) Wait() {
+	e.wg.Wait()
+}
+
+func (e *LeaderElector) run(ctx context.Context, name string, component func(ctx context.Context)) {
+	var (
+		stop      context.CancelFunc
+		stopped   chan struct{}
+		renewedAt time.Time
+	)
+	leaseHeld.Set(0, name)
+
+	stepDown := func(reason string) {
+		stop()
+		<-stopped
+		stop, stopped = nil, nil
+		leaseHeld.Set(0, name)
+		log.Printf("Stopped %s: %s", name, reason)
+	}
+
+	elect := func() {
+		attempted := time.Now()
+		held, err := e.leases.Acquire(ctx, name, e.holder, e.ttl)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to renew lease %s: %v", name, err)
+			// Keep running until the lease may have expired for the other instances
+			if stop != nil && time.Since(renewedAt) >= e.ttl {
+				stepDown("lease could not be renewed")
+			}
+			return
+		}
+		if !held {
+			if stop != nil {
+				stepDown("lease was taken over by another instance")
+			}
+			return
+		}
+
+		renewedAt = attempted
+		if stop == nil {
+			var componentCtx context.Context
+			componentCtx, stop = context.WithCancel(ctx)
+			stopped = make(chan struct{})
+			go func(done chan struct{}) {
+				defer close(done)
+				component(componentCtx)
+			}(stopped)
+			leaseHeld.Set(1, name)
+			log.Printf("Acquired lease %s as %s, starting component", name, e.holder)
+		}
+	}
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	elect()
+	for {
+		select {
+		case <-ctx.Done():
+			if stop != nil {
+				stepDown("shutting down")
+				// Release with a fresh context so another instance takes over without waiting for expiry
+				releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				if err := e.leases.Release(releaseCtx, name, e.holder); err != nil {
+					log.Printf("Failed to release lease %s: %v", name, err)
+				}
+				cancel()
+			}
+			return
+		case <-ticker.C:
+			elect()
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrMaintenance is returned when job intake is paused for maintenance
+var ErrMaintenance = errors.New("job intake is paused for maintenance")
+
+// defaultMaintenanceRetryAfter is the Retry-After advertised when none is configured
+const defaultMaintenanceRetryAfter = 5 * time.Minute
+
+// maxMaintenanceReasonLength bounds the size of a maintenance reason
+const maxMaintenanceReasonLength = 1000
+
+// MaintenanceMode selects what happens to new jobs during maintenance
+type MaintenanceMode string
+
+const (
+	// MaintenanceReject refuses new jobs with 503 Service Unavailable
+	MaintenanceReject MaintenanceMode = "reject"
+	// MaintenanceHold accepts new jobs in the held status and queues them when maintenance ends
+	MaintenanceHold MaintenanceMode = "hold"
+)
+
+// MaintenanceStatus describes the current maintenance state
+type MaintenanceStatus struct {
+	Enabled           bool            `json:"enabled"`
+	Mode              MaintenanceMode `json:"mode,omitempty"`
+	RetryAfterSeconds int             `json:"retryAfterSeconds,omitempty"`
+	Reason            string          `json:"reason,omitempty"`
+	EnabledBy         string          `json:"enabledBy,omitempty"`
+	Since             *time.Time      `json:"since,omitempty"`
+	// Released is the number of held jobs queued when maintenance was turned off
+	Released int `json:"released,omitempty"`
+}
+
+// MaintenanceRequest turns maintenance mode on or off
+type MaintenanceRequest struct {
+	Enabled           bool   `json:"enabled"`
+	Mode              string `json:"mode,omitempty"`
+	RetryAfterSeconds int    `json:"retry_after_seconds,omitempty"`
+	Reason            string `json:"reason,omitempty"`
+	// Actor is the authenticated caller changing the mode
+	Actor string `json:"-"`
+}
+
+// MaintenanceError reports a job refused because intake is paused
+type MaintenanceError struct {
+	RetryAfter time.Duration
+	Reason     string
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.Reason == "" {
+		return ErrMaintenance.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrMaintenance, e.Reason)
+}
+
+func (e *MaintenanceError) Unwrap() error {
+	return ErrMaintenance
+}
+
+// Maintenance holds the maintenance state shared by the jobs and maintenance services.
+// The state is kept in memory so it works while MongoDB itself is under maintenance.
+type Maintenance struct {
+	mu     sync.RWMutex
+	status MaintenanceStatus
+}
+
+// NewMaintenance creates a maintenance state with intake open
+func NewMaintenance() *Maintenance {
+	return &Maintenance{}
+}
+
+// Status returns the current maintenance state
+func (m *Maintenance) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+func (m *Maintenance) set(status MaintenanceStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = status
+}
+
+// MaintenanceService interface defines the methods for controlling maintenance mode
+type MaintenanceService interface {
+	GetStatus() MaintenanceStatus
+	SetMaintenance(ctx context.Context, req MaintenanceRequest) (MaintenanceStatus, error)
+}
+
+type maintenanceService struct {
+	state *Maintenance
+	jobs  JobsService
+}
+
+// NewMaintenanceService creates a new maintenance service
+func NewMaintenanceService(state *Maintenance, jobs JobsService) MaintenanceService {
+	return &maintenanceService{state: state, jobs: jobs}
+}
+
+// GetStatus returns the current maintenance state
+func (s *maintenanceService) GetStatus() MaintenanceStatus {
+	return s.state.Status()
+}
+
+// SetMaintenance turns maintenance mode on or off. Turning it off queues any held jobs.
+func (s *maintenanceService) SetMaintenance(ctx context.Context, req MaintenanceRequest) (MaintenanceStatus, error) {
+	if !req.Enabled {
+		s.state.set(MaintenanceStatus{})
+
+		released, err := s.jobs.ReleaseHeldJobs(ctx)
+		status := MaintenanceStatus{Released: released}
+		if err != nil {
+			return status, fmt.Errorf("maintenance ended but held jobs were not all released: %w", err)
+		}
+		return status, nil
+	}
+
+	mode := MaintenanceMode(req.Mode)
+	switch mode {
+	case "":
+		mode = MaintenanceReject
+	case MaintenanceReject, MaintenanceHold:
+	default:
+		return MaintenanceStatus{}, &ValidationError{Field: "mode", Message: "mode must be one of reject, hold"}
+	}
+
+	if req.RetryAfterSeconds < 0 {
+		return MaintenanceStatus{}, &ValidationError{Field: "retry_after_seconds", Message: "retry_after_seconds must not be negative"}
+	}
+	retryAfter := req.RetryAfterSeconds
+	if retryAfter == 0 {
+		retryAfter = int(defaultMaintenanceRetryAfter.Seconds())
+	}
+
+	if len(req.Reason) > maxMaintenanceReasonLength {
+		return MaintenanceStatus{}, &ValidationError{
+			Field:   "reason",
+			Message: fmt.Sprintf("reason must not exceed %d characters", maxMaintenanceReasonLength),
+		}
+	}
+
+	since := time.Now()
+	if current := s.state.Status(); current.Enabled {
+		since = *current.Since
+	}
+
+	status := MaintenanceStatus{
+		Enabled:           true,
+		Mode:              mode,
+		RetryAfterSeconds: retryAfter,
+		Reason:            req.Reason,
+		EnabledBy:         req.Actor,
+		Since:             &since,
+	}
+	s.state.set(status)
+	return status, nil
+}
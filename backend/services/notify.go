@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"github.com/fullstack-assessment/notify"
+)
+
+// ErrTenantNotificationsNotFound is returned when a tenant has no notification settings
+var ErrTenantNotificationsNotFound = errors.New("no notifications are configured for the tenant")
+
+// slackWebhookPrefix starts every Slack incoming webhook URL. Notifications are
+// posted to no other address, so they cannot be pointed at internal services.
+const slackWebhookPrefix = notify.SlackWebhookPrefix
+
+// statusNotificationTimeout bounds loading the job and settings of a notification
+const statusNotificationTimeout = 10 * time.Second
+
+// NotifyRequest represents the notification settings supplied when creating a job
+type NotifyRequest struct {
+	Email           []string `json:"email,omitempty"`
+	SlackWebhookURL string   `json:"slack_webhook_url,omitempty"`
+	On              []string `json:"on,omitempty"`
+	Template        string   `json:"template,omitempty"`
+}
+
+// toNotifyConfig validates the request and converts it into the stored notification config
+func (r *NotifyRequest) toNotifyConfig() (*models.NotifyConfig, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	if len(r.Email) == 0 && r.SlackWebhookURL == "" {
+		return nil, &ValidationError{Field: "notify", Message: "at least one of email or slack_webhook_url is required"}
+	}
+
+	for _, address := range r.Email {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return nil, &ValidationError{Field: "notify.email", Message: fmt.Sprintf("invalid email address '%s'", address)}
+		}
+	}
+
+	if r.SlackWebhookURL != "" {
+		parsed, err := url.Parse(r.SlackWebhookURL)
+		if err != nil || !strings.HasPrefix(r.SlackWebhookURL, slackWebhookPrefix) || parsed.Host != "hooks.slack.com" || parsed.User != nil {
+			return nil, &ValidationError{Field: "notify.slack_webhook_url", Message: "must be a Slack incoming webhook URL starting with " + slackWebhookPrefix}
+		}
+	}
+
+	on := make([]models.JobStatus, 0, len(r.On))
+	for _, status := range r.On {
+		jobStatus := models.JobStatus(status)
+		if !jobStatus.IsTerminal() {
+			return nil, &ValidationError{
+				Field:   "notify.on",
+				Message: fmt.Sprintf("invalid status '%s', must be one of: completed, failed, cancelled", status),
+			}
+		}
+		on = append(on, jobStatus)
+	}
+
+	if r.Template != "" {
+		if _, err := template.New("notify").Parse(r.Template); err != nil {
+			return nil, &ValidationError{Field: "notify.template", Message: fmt.Sprintf("invalid template: %v", err)}
+		}
+	}
+
+	return &models.NotifyConfig{
+		Email:           r.Email,
+		SlackWebhookURL: r.SlackWebhookURL,
+		On:              on,
+		Template:        r.Template,
+	}, nil
+}
+
+// TenantNotificationsService interface defines the methods for managing the
+// notifications sent for every job of the caller's tenant
+type TenantNotificationsService interface {
+	GetNotifications(ctx context.Context) (*models.TenantNotifications, error)
+	SetNotifications(ctx context.Context, req NotifyRequest) (*models.TenantNotifications, error)
+	DeleteNotifications(ctx context.Context) error
+}
+
+type tenantNotificationsService struct {
+	repo repositories.TenantNotificationsRepository
+}
+
+// NewTenantNotificationsService creates a new tenant notifications service
+func NewTenantNotificationsService(repo repositories.TenantNotificationsRepository) TenantNotificationsService {
+	return &tenantNotificationsService{repo: repo}
+}
+
+// GetNotifications retrieves the notification settings of the caller's tenant
+func (s *tenantNotificationsService) GetNotifications(ctx context.Context) (*models.TenantNotifications, error) {
+	settings, err := s.repo.Get(ctx, auth.Tenant(ctx))
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrTenantNotificationsNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SetNotifications validates and stores the notification settings of the
+// caller's tenant, which apply to its jobs finishing from then on
+func (s *tenantNotificationsService) SetNotifications(ctx context.Context, req NotifyRequest) (*models.TenantNotifications, error) {
+	notify, err := req.toNotifyConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.TenantNotifications{
+		Tenant:       auth.Tenant(ctx),
+		NotifyConfig: *notify,
+		UpdatedBy:    auth.Actor(ctx),
+	}
+	if err := s.repo.Put(ctx, settings); err != nil {
+		return nil, fmt.Errorf("failed to store notification settings: %w", err)
+	}
+	return settings, nil
+}
+
+// DeleteNotifications stops the notifications of the caller's tenant; those
+// configured on jobs are still sent
+func (s *tenantNotificationsService) DeleteNotifications(ctx context.Context) error {
+	deleted, err := s.repo.Delete(ctx, auth.Tenant(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete notification settings: %w", err)
+	}
+	if !deleted {
+		return ErrTenantNotificationsNotFound
+	}
+	return nil
+}
+
+// StatusNotifier sends the notifications of the jobs this backend moves to a
+// terminal status, such as acknowledged or resolved cancellations and fanned-in
+// parents, using global, per-tenant and per-job settings. Workers notify on the
+// jobs they complete or fail themselves.
+type StatusNotifier struct {
+	notify  *notify.Notifier
+	jobs    repositories.JobsRepository
+	tenants repositories.TenantNotificationsRepository
+}
+
+// NewStatusNotifier creates a status notifier applying settings to every job
+func NewStatusNotifier(settings notify.Settings, jobs repositories.JobsRepository, tenants repositories.TenantNotificationsRepository) *StatusNotifier {
+	return &StatusNotifier{
+		notify:  notify.New(settings),
+		jobs:    jobs,
+		tenants: tenants,
+	}
+}
+
+// HandleEvent is an event handler notifying the channels subscribed to the
+// terminal status a job was moved to. The job and settings are loaded and the
+// notification delivered off the publisher's goroutine; failures are logged.
+func (n *StatusNotifier) HandleEvent(ctx context.Context, event JobEvent) {
+	if event.Type != EventJobStatusChanged || !event.Status.IsTerminal() {
+		return
+	}
+	go n.notifyStatus(event)
+}
+
+func (n *StatusNotifier) notifyStatus(event JobEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), statusNotificationTimeout)
+	defer cancel()
+
+	job := event.Job
+	if job == nil {
+		loaded, err := n.jobs.GetByID(ctx, event.JobID.String())
+		if err != nil {
+			log.Printf("Failed to load job %s for notification: %v", event.JobID.String(), err)
+			return
+		}
+		job = loaded
+	}
+
+	var tenant *notify.Config
+	settings, err := n.tenants.Get(ctx, job.Tenant)
+	switch {
+	case err == nil:
+		tenant = toNotifyConfig(&settings.NotifyConfig)
+	case !errors.Is(err, repositories.ErrNotFound):
+		log.Printf("Failed to load the notification settings of tenant %q: %v", job.Tenant, err)
+	}
+
+	notification, channels, err := n.notify.StatusNotification(notify.Job{
+		ID:           job.ID.String(),
+		Name:         job.Name,
+		JobType:      string(job.JobType),
+		Status:       string(event.Status),
+		ErrorMessage: job.ErrorMessage,
+		RetryCount:   job.RetryCount,
+	}, tenant, toNotifyConfig(job.Notify))
+	if err != nil {
+		log.Printf("Failed to render notification for job %s: %v", job.ID.String(), err)
+		return
+	}
+	if len(channels) == 0 {
+		return
+	}
+	n.notify.Send(job.ID.String(), channels, notification)
+}
+
+// toNotifyConfig converts a stored notification config into the form sent by the notify package
+func toNotifyConfig(config *models.NotifyConfig) *notify.Config {
+	if config == nil {
+		return nil
+	}
+	on := make([]string, 0, len(config.On))
+	for _, status := range config.On {
+		on = append(on, string(status))
+	}
+	return &notify.Config{
+		Email:           config.Email,
+		SlackWebhookURL: config.SlackWebhookURL,
+		On:              on,
+		Template:        config.Template,
+	}
+}
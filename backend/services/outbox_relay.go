@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var outboxDepth = metrics.NewGauge("outbox_pending_messages", "Messages waiting in the outbox for delivery.")
+
+// OutboxRelay periodically delivers outbox messages to Kafka
+type OutboxRelay struct {
+	outbox    repositories.OutboxRepository
+	producer  *KafkaProducer
+	interval  time.Duration
+	batchSize int
+}
+
+// NewOutboxRelay creates a new outbox relay
+func NewOutboxRelay(outbox repositories.OutboxRepository, producer *KafkaProducer, interval time.Duration, batchSize int) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:    outbox,
+		producer:  producer,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run relays outbox messages until ctx is cancelled
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		}
+	}
+}
+
+// relay delivers one batch of pending messages
+func (r *OutboxRelay) relay(ctx context.Context) {
+	if depth, err := r.outbox.CountPending(ctx); err == nil {
+		outboxDepth.Set(float64(depth))
+		if depth == 0 {
+			return
+		}
+	}
+
+	// Leave the outbox alone while the broker is known to be down and there is no secondary
+	if !r.producer.Available() {
+		return
+	}
+
+	messages, err := r.outbox.ListPending(ctx, r.batchSize)
+	if err != nil {
+		log.Printf("Failed to list outbox messages: %v", err)
+		return
+	}
+
+	// Group by topic so each topic is written in a single batch
+	byTopic := make(map[string][]models.OutboxMessage)
+	for _, message := range messages {
+		byTopic[message.Topic] = append(byTopic[message.Topic], message)
+	}
+
+	for topic, topicMessages := range byTopic {
+		batch := make([]interface{}, len(topicMessages))
+		for i, message := range topicMessages {
+			batch[i] = KeyedMessage{Key: message.Key, Value: json.RawMessage(message.Payload)}
+		}
+
+		err := r.producer.PublishBatch(ctx, topic, batch)
+
+		var batchErr *BatchPublishError
+		errors.As(err, &batchErr)
+
+		var published []primitive.ObjectID
+		for i, message := range topicMessages {
+			if err == nil || (batchErr != nil && !batchErr.Failed(i)) {
+				published = append(published, message.ID)
+				continue
+			}
+
+			errorMessage := err.Error()
+			if batchErr != nil {
+				errorMessage = batchErr.Errors[i].Error()
+			}
+			if markErr := r.outbox.MarkFailed(ctx, message.ID, errorMessage); markErr != nil {
+				log.Printf("Failed to record outbox failure for %s: %v", message.ID.Hex(), markErr)
+			}
+		}
+
+		if len(published) > 0 {
+			if err := r.outbox.MarkPublished(ctx, published); err != nil {
+				log.Printf("Failed to mark outbox messages published: %v", err)
+				continue
+			}
+			log.Printf("Relayed %d outbox messages to topic %s", len(published), topic)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// Limits of the cursor-paginated listings: poison messages, the DLQ and the audit trail
+const (
+	DefaultCursorPageLimit = 50
+	MaxCursorPageLimit     = 500
+)
+
+// validatePageLimit checks the limit of a cursor-paginated listing
+func validatePageLimit(limit int) error {
+	if limit < 1 || limit > MaxCursorPageLimit {
+		return &ValidationError{Field: "limit", Message: fmt.Sprintf("limit must be between 1 and %d", MaxCursorPageLimit)}
+	}
+	return nil
+}
+
+// pageError reports a cursor that was not issued by a previous page as a
+// validation error, and wraps other errors of a listing
+func pageError(err error, what string) error {
+	if errors.Is(err, repositories.ErrInvalidCursor) {
+		return &ValidationError{Field: "cursor", Message: "cursor must be the nextCursor of a previous page"}
+	}
+	return fmt.Errorf("failed to list %s: %w", what, err)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxReconcileBatch bounds how many jobs one reconciliation pass republishes;
+// the rest are picked up on the next pass
+const maxReconcileBatch = 500
+
+var pendingRepublished = metrics.NewCounter("pending_jobs_republished_total",
+	"Pending jobs republished because no worker had picked them up.")
+
+// PendingReconciler republishes jobs that have stayed pending, with no attempt
+// started, for longer than a threshold: their message was lost between MongoDB
+// and Kafka, or a worker committed it without running the job. A republished
+// job gets a new dispatch ID, so of a slow backlog's copies only one runs it.
+type PendingReconciler struct {
+	jobs      JobsService
+	interval  time.Duration
+	threshold time.Duration
+}
+
+// NewPendingReconciler creates a new pending reconciler
+func NewPendingReconciler(jobs JobsService, interval, threshold time.Duration) *PendingReconciler {
+	return &PendingReconciler{
+		jobs:      jobs,
+		interval:  interval,
+		threshold: threshold,
+	}
+}
+
+// Run republishes stale pending jobs until ctx is cancelled
+func (r *PendingReconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			republished, err := r.jobs.RepublishStalePending(ctx, time.Now().Add(-r.threshold))
+			if err != nil {
+				log.Printf("Failed to reconcile pending jobs: %v", err)
+			}
+			if republished > 0 {
+				log.Printf("Republished %d jobs pending for over %s", republished, r.threshold)
+			}
+		}
+	}
+}
+
+// RepublishStalePending republishes the pending jobs not updated since before.
+// Each job gets a new dispatch ID first, so only the republished message may run
+// it and a lost message that turns up late is skipped by the workers.
+func (s *jobsService) RepublishStalePending(ctx context.Context, before time.Time) (int, error) {
+	// Republishing while the broker is down would only fill the outbox with copies
+	if !s.producer.Available() {
+		return 0, nil
+	}
+
+	republished := 0
+	for republished < maxReconcileBatch {
+		job, err := s.repo.RedispatchStalePending(ctx, before, primitive.NewObjectID().Hex())
+		if errors.Is(err, repositories.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return republished, fmt.Errorf("failed to redispatch pending job: %w", err)
+		}
+		s.publishJob(ctx, job)
+		pendingRepublished.Inc()
+		republished++
+	}
+	return republished, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// staleJobs returns n pending jobs whose message was lost
+func staleJobs(n int) []*models.Job {
+	jobs := make([]*models.Job, n)
+	for i := range jobs {
+		jobs[i] = &models.Job{
+			ID:         models.JobIDFromObjectID(primitive.NewObjectID()),
+			JobType:    models.JobTypeProcess,
+			Status:     models.JobStatusPending,
+			DispatchID: "lost",
+		}
+	}
+	return jobs
+}
+
+func TestRepublishStalePending(t *testing.T) {
+	tests := []struct {
+		name     string
+		stale    int
+		producer *KafkaProducer
+		repoErr  error
+		want     int
+		wantErr  bool
+	}{
+		{name: "nothing stale", producer: rejectingProducer()},
+		{name: "stale jobs", stale: 3, producer: rejectingProducer(), want: 3},
+		{name: "more than a pass", stale: maxReconcileBatch + 5, producer: rejectingProducer(), want: maxReconcileBatch},
+		{name: "broker down", stale: 3, producer: unavailableProducer()},
+		{name: "repository failure", stale: 3, producer: rejectingProducer(), repoErr: errors.New("connection reset"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeJobsRepository()
+			repo.stale = staleJobs(tt.stale)
+			repo.err = tt.repoErr
+			outbox := &fakeOutbox{}
+			service := newTestJobsService(repo, outbox, tt.producer)
+
+			republished, err := service.RepublishStalePending(context.Background(), time.Now().Add(-10*time.Minute))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RepublishStalePending() error = %v, want error %v", err, tt.wantErr)
+			}
+			if republished != tt.want {
+				t.Errorf("RepublishStalePending() = %d, want %d", republished, tt.want)
+			}
+			if len(outbox.messages) != tt.want {
+				t.Fatalf("queued %d messages, want one per republished job", len(outbox.messages))
+			}
+			if tt.producer.Available() && tt.repoErr == nil && len(repo.stale) != tt.stale-tt.want {
+				t.Errorf("%d stale jobs left, want %d for the next pass", len(repo.stale), tt.stale-tt.want)
+			}
+
+			dispatchIDs := make(map[string]bool)
+			for i := range outbox.messages {
+				var message JobMessage
+				outbox.decode(t, i, &message)
+				if message.DispatchID == "" || message.DispatchID == "lost" || dispatchIDs[message.DispatchID] {
+					t.Errorf("message %d dispatch ID = %q, want a new one per job", i, message.DispatchID)
+				}
+				dispatchIDs[message.DispatchID] = true
+			}
+		})
+	}
+}
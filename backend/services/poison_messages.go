@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"github.com/segmentio/kafka-go"
+)
+
+var poisonMessages = metrics.NewCounter("kafka_poison_messages_total",
+	"Kafka messages the backend could not decode, by topic.", "topic")
+
+// maxPoisonPayloadBytes bounds the part of an undecodable payload that is kept
+const maxPoisonPayloadBytes = 64 << 10
+
+// PoisonMessagesService interface defines the methods for inspecting undecodable Kafka messages
+type PoisonMessagesService interface {
+	ListPoisonMessages(ctx context.Context, topic, cursor string, limit int) (*PoisonMessagePage, error)
+}
+
+// PoisonMessagePage holds one page of poison messages. NextCursor is passed back
+// as the cursor to read the following page and is empty on the last page.
+type PoisonMessagePage struct {
+	Messages   []models.PoisonMessage `json:"messages"`
+	NextCursor string                 `json:"nextCursor,omitempty"`
+}
+
+type poisonMessagesService struct {
+	repo repositories.PoisonMessagesRepository
+}
+
+// NewPoisonMessagesService creates a new poison messages service
+func NewPoisonMessagesService(repo repositories.PoisonMessagesRepository) PoisonMessagesService {
+	return &poisonMessagesService{repo: repo}
+}
+
+// ListPoisonMessages retrieves a page of the most recently seen poison messages, optionally of one topic
+func (s *poisonMessagesService) ListPoisonMessages(ctx context.Context, topic, cursor string, limit int) (*PoisonMessagePage, error) {
+	if err := validatePageLimit(limit); err != nil {
+		return nil, err
+	}
+
+	page, err := s.repo.List(ctx, topic, cursor, limit)
+	if err != nil {
+		return nil, pageError(err, "poison messages")
+	}
+
+	return &PoisonMessagePage{Messages: page.Items, NextCursor: page.NextCursor}, nil
+}
+
+// recordPoisonMessage counts and stores a message a backend consumer could not decode.
+// Failing to store it is logged; the consumer skips the message either way.
+func recordPoisonMessage(ctx context.Context, repo repositories.PoisonMessagesRepository, consumer string, msg kafka.Message, decodeErr error) {
+	poisonMessages.Inc(msg.Topic)
+
+	payload := msg.Value
+	truncated := len(payload) > maxPoisonPayloadBytes
+	if truncated {
+		payload = payload[:maxPoisonPayloadBytes]
+	}
+
+	err := repo.Record(ctx, &models.PoisonMessage{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Consumer:  consumer,
+		Source:    "backend",
+		Key:       string(msg.Key),
+		Payload:   string(payload),
+		Truncated: truncated,
+		Error:     decodeErr.Error(),
+	})
+	if err != nil {
+		log.Printf("Failed to record poison message at %s/%d@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+}
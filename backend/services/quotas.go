@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// ErrQuotaExceeded is returned when a job is refused because its tenant used up a creation quota
+var ErrQuotaExceeded = errors.New("job creation quota exceeded")
+
+var quotaRejections = metrics.NewCounter("job_quota_rejections_total",
+	"Jobs refused because their tenant exceeded a creation quota, by period.", "period")
+
+// QuotaConfig limits how many jobs each tenant creates per UTC day and month.
+// Zero limits are unlimited.
+type QuotaConfig struct {
+	Daily   int64
+	Monthly int64
+	// TenantDaily and TenantMonthly override the limits per tenant
+	TenantDaily   map[string]int64
+	TenantMonthly map[string]int64
+}
+
+// enabled reports whether any quota is configured. Jobs are only counted while one is.
+func (c QuotaConfig) enabled() bool {
+	return c.Daily > 0 || c.Monthly > 0 || len(c.TenantDaily) > 0 || len(c.TenantMonthly) > 0
+}
+
+// limitFor returns the limit of a tenant in a period, or zero if it is unlimited
+func (c QuotaConfig) limitFor(tenant string, period models.QuotaPeriod) int64 {
+	limit, overrides := c.Daily, c.TenantDaily
+	if period == models.QuotaPeriodMonth {
+		limit, overrides = c.Monthly, c.TenantMonthly
+	}
+	if override, ok := overrides[tenant]; ok {
+		return override
+	}
+	return limit
+}
+
+// QuotaExceededError reports a job refused because its tenant used up a creation quota
+type QuotaExceededError struct {
+	Usage      models.QuotaUsage
+	RetryAfter time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: %d of %d jobs per %s used", ErrQuotaExceeded, e.Usage.Used, *e.Usage.Limit, e.Usage.Period)
+}
+
+func (e *QuotaExceededError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// consumeQuota counts a new job against the quotas of its tenant. It returns a
+// *QuotaExceededError if a quota is used up, or the windows the job was counted
+// against, to refund if the job is not created after all.
+func (s *jobsService) consumeQuota(ctx context.Context, tenant string) ([]models.QuotaWindow, error) {
+	config := s.currentConfig().Quota
+	if s.quotas == nil || !config.enabled() {
+		return nil, nil
+	}
+
+	var consumed []models.QuotaWindow
+	for _, window := range models.QuotaWindowsAt(time.Now()) {
+		limit := config.limitFor(tenant, window.Period)
+		ok, err := s.quotas.Consume(ctx, tenant, window, limit)
+		if err != nil {
+			s.refundQuota(ctx, tenant, consumed)
+			return nil, fmt.Errorf("failed to check job quota: %w", err)
+		}
+		if !ok {
+			s.refundQuota(ctx, tenant, consumed)
+			quotaRejections.Inc(string(window.Period))
+			return nil, &QuotaExceededError{
+				Usage:      quotaUsage(window, limit, limit),
+				RetryAfter: time.Until(window.ResetsAt).Round(time.Second),
+			}
+		}
+		consumed = append(consumed, window)
+	}
+	return consumed, nil
+}
+
+// refundQuota takes back a job counted against the given windows of its tenant
+func (s *jobsService) refundQuota(ctx context.Context, tenant string, windows []models.QuotaWindow) {
+	for _, window := range windows {
+		if err := s.quotas.Refund(ctx, tenant, window); err != nil {
+			log.Printf("Failed to refund %s quota of tenant %q: %v", window.Period, tenant, err)
+		}
+	}
+}
+
+// GetQuotaStatus reports the job creation allowance left to the caller's tenant
+func (s *jobsService) GetQuotaStatus(ctx context.Context) (*models.QuotaStatus, error) {
+	tenant := auth.Tenant(ctx)
+	config := s.currentConfig().Quota
+
+	status := &models.QuotaStatus{Tenant: tenant, Periods: []models.QuotaUsage{}}
+	for _, window := range models.QuotaWindowsAt(time.Now()) {
+		var used int64
+		if s.quotas != nil {
+			var err error
+			if used, err = s.quotas.Used(ctx, tenant, window); err != nil {
+				return nil, fmt.Errorf("failed to read job quota: %w", err)
+			}
+		}
+		status.Periods = append(status.Periods, quotaUsage(window, used, config.limitFor(tenant, window.Period)))
+	}
+	return status, nil
+}
+
+// quotaUsage describes the use of a window against its limit, if it has one
+func quotaUsage(window models.QuotaWindow, used, limit int64) models.QuotaUsage {
+	usage := models.QuotaUsage{
+		Period:   window.Period,
+		Used:     used,
+		ResetsAt: window.ResetsAt,
+	}
+	if limit > 0 {
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		usage.Limit = &limit
+		usage.Remaining = &remaining
+	}
+	return usage
+}
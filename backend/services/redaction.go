@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/secrets"
+)
+
+// RedactJob returns a copy of the job with the configured sensitive config keys
+// replaced, unless the caller has the admin role. The job passed in is not modified.
+func (s *jobsService) RedactJob(ctx context.Context, job *models.Job) *models.Job {
+	keys := s.currentConfig().RedactedConfigKeys
+	if job == nil || len(keys) == 0 || len(job.Config) == 0 || auth.PrincipalFromContext(ctx).HasRole(auth.RoleAdmin) {
+		return job
+	}
+
+	redacted := *job
+	redacted.Config = make(map[string]interface{}, len(job.Config))
+	for key, value := range job.Config {
+		redacted.Config[key] = value
+	}
+	for _, key := range keys {
+		if _, ok := redacted.Config[key]; ok {
+			redacted.Config[key] = secrets.RedactedValue
+		}
+	}
+	return &redacted
+}
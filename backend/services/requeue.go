@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Batch sizes of a bulk requeue
+const (
+	defaultRequeueBatchSize = 100
+	maxRequeueBatchSize     = 1000
+)
+
+// requeueableStatuses are the statuses a bulk requeue may reset to pending.
+// Completed jobs are never run again, and jobs in the other statuses are
+// waiting for something that a requeue would bypass.
+var requeueableStatuses = []models.JobStatus{
+	models.JobStatusPending,
+	models.JobStatusProcessing,
+	models.JobStatusFailed,
+	models.JobStatusCancelled,
+}
+
+// RequeueRequest selects the jobs reset to pending and republished by a bulk requeue
+type RequeueRequest struct {
+	Status        []models.JobStatus `json:"status"`
+	JobType       []models.JobType   `json:"job_type,omitempty"`
+	CreatedAfter  *time.Time         `json:"created_after,omitempty"`
+	CreatedBefore *time.Time         `json:"created_before,omitempty"`
+	BatchSize     int                `json:"batch_size,omitempty"`
+	// DryRun counts the matching jobs without requeueing them
+	DryRun bool `json:"dry_run,omitempty"`
+	// Actor is the authenticated caller requeueing the jobs
+	Actor string `json:"-"`
+}
+
+// RequeueProgress reports how far a bulk requeue got
+type RequeueProgress struct {
+	// Matched is the number of jobs matching the filter when the requeue started
+	Matched  int64 `json:"matched"`
+	Requeued int   `json:"requeued"`
+	// Skipped counts the matching jobs whose status changed before they were requeued
+	Skipped int  `json:"skipped"`
+	Batches int  `json:"batches"`
+	Done    bool `json:"done"`
+}
+
+// RequeueJobs resets the jobs matching req to pending and republishes them in
+// batches, calling progress after each batch. Only jobs existing when the
+// requeue starts are considered. Requeued processing jobs get a new dispatch ID,
+// but a worker still running one is not stopped.
+func (s *jobsService) RequeueJobs(ctx context.Context, req RequeueRequest, progress func(RequeueProgress)) (RequeueProgress, error) {
+	query, err := requeueQuery(req)
+	if err != nil {
+		return RequeueProgress{}, err
+	}
+	batchSize := req.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultRequeueBatchSize
+	}
+
+	// Jobs created from now on, including by the requeue itself, are out of scope
+	if now := time.Now(); query.CreatedBefore == nil || query.CreatedBefore.After(now) {
+		query.CreatedBefore = &now
+	}
+
+	var result RequeueProgress
+	result.Matched, err = s.repo.CountForRequeue(ctx, query)
+	if err != nil {
+		return result, fmt.Errorf("failed to count jobs to requeue: %w", err)
+	}
+	if req.DryRun || result.Matched == 0 {
+		result.Done = true
+		return result, nil
+	}
+
+	log.Printf("Requeueing %d jobs for %s", result.Matched, req.Actor)
+
+	var after models.JobID
+	for {
+		jobs, err := s.repo.ListForRequeue(ctx, query, after, batchSize)
+		if err != nil {
+			return result, fmt.Errorf("failed to list jobs to requeue: %w", err)
+		}
+
+		for _, listed := range jobs {
+			job, err := s.repo.Requeue(ctx, listed.ID, query.Statuses, primitive.NewObjectID().Hex())
+			if errors.Is(err, repositories.ErrConflict) {
+				result.Skipped++
+				continue
+			}
+			if err != nil {
+				return result, fmt.Errorf("failed to requeue job %s: %w", listed.ID.String(), err)
+			}
+			if listed.Status != job.Status {
+				s.events.StatusChanged(ctx, job, listed.Status)
+			}
+			s.publishJob(ctx, job)
+			result.Requeued++
+		}
+		if len(jobs) > 0 {
+			result.Batches++
+			after = jobs[len(jobs)-1].ID
+		}
+
+		if len(jobs) < batchSize {
+			result.Done = true
+			log.Printf("Requeued %d jobs for %s, %d skipped", result.Requeued, req.Actor, result.Skipped)
+			return result, nil
+		}
+		if progress != nil {
+			progress(result)
+		}
+	}
+}
+
+// requeueQuery validates a requeue request and returns its repository query
+func requeueQuery(req RequeueRequest) (repositories.RequeueQuery, error) {
+	if len(req.Status) == 0 {
+		return repositories.RequeueQuery{}, &ValidationError{Field: "status", Message: "at least one status is required"}
+	}
+	for _, status := range req.Status {
+		if !isRequeueableStatus(status) {
+			return repositories.RequeueQuery{}, &ValidationError{
+				Field:   "status",
+				Message: fmt.Sprintf("jobs in status %q cannot be requeued, use pending, processing, failed or cancelled", status),
+			}
+		}
+	}
+	for _, jobType := range req.JobType {
+		if !models.IsValidJobType(string(jobType)) {
+			return repositories.RequeueQuery{}, &ValidationError{Field: "job_type", Message: fmt.Sprintf("unknown job type %q", jobType)}
+		}
+	}
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && !req.CreatedAfter.Before(*req.CreatedBefore) {
+		return repositories.RequeueQuery{}, &ValidationError{Field: "created_before", Message: "created_before must be after created_after"}
+	}
+	if req.BatchSize < 0 || req.BatchSize > maxRequeueBatchSize {
+		return repositories.RequeueQuery{}, &ValidationError{
+			Field:   "batch_size",
+			Message: fmt.Sprintf("batch_size must be between 1 and %d", maxRequeueBatchSize),
+		}
+	}
+
+	return repositories.RequeueQuery{
+		Statuses:      req.Status,
+		JobTypes:      req.JobType,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+	}, nil
+}
+
+func isRequeueableStatus(status models.JobStatus) bool {
+	for _, requeueable := range requeueableStatuses {
+		if status == requeueable {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RetentionAction is what happens to a job once its retention period is over
+type RetentionAction string
+
+// Retention actions
+const (
+	// RetentionDelete removes the job for good
+	RetentionDelete RetentionAction = "delete"
+	// RetentionArchive moves the job to the archive, where it can still be fetched by ID
+	RetentionArchive RetentionAction = "archive"
+)
+
+// DefaultRetentionBatchSize is how many jobs are removed at a time
+const DefaultRetentionBatchSize = 500
+
+// retentionStatuses are the statuses of finished jobs, the only ones retention removes
+var retentionStatuses = []models.JobStatus{models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelled}
+
+var retainedJobsRemoved = metrics.NewCounter("job_retention_removed_total", "Jobs removed by retention rules, by action.", "action")
+
+// RetentionRule removes the finished jobs of a type and status that have not
+// changed for MaxAge. An empty JobType or Status matches any. When several rules
+// match a job, the one naming its type wins, then the one naming its status.
+type RetentionRule struct {
+	JobType models.JobType
+	Status  models.JobStatus
+	Action  RetentionAction
+	MaxAge  time.Duration
+}
+
+// precedence orders rules from the most to the least specific
+func (r RetentionRule) precedence() int {
+	switch {
+	case r.JobType != "" && r.Status != "":
+		return 0
+	case r.JobType != "":
+		return 1
+	case r.Status != "":
+		return 2
+	}
+	return 3
+}
+
+// overlaps reports whether a job could match both rules
+func (r RetentionRule) overlaps(other RetentionRule) bool {
+	return (r.JobType == "" || other.JobType == "" || r.JobType == other.JobType) &&
+		(r.Status == "" || other.Status == "" || r.Status == other.Status)
+}
+
+// RetentionRuleReport is what one retention rule removes, or would remove
+type RetentionRuleReport struct {
+	// JobType and Status are * when the rule matches any
+	JobType string          `json:"jobType"`
+	Status  string          `json:"status"`
+	Action  RetentionAction `json:"action"`
+	MaxAge  string          `json:"maxAge"`
+	// Cutoff is the time before which jobs last changed are removed
+	Cutoff time.Time `json:"cutoff"`
+	Jobs   int64     `json:"jobs"`
+}
+
+// RetentionAuditReport is what the audit retention period removes, or would remove
+type RetentionAuditReport struct {
+	MaxAge string `json:"maxAge"`
+	// Cutoff is the time before which comments were created are removed
+	Cutoff   time.Time `json:"cutoff"`
+	Comments int64     `json:"comments"`
+}
+
+// RetentionReport is the outcome of applying the retention rules, or with
+// DryRun, what applying them now would remove. Total counts the jobs; Audit
+// is absent when audit events are kept forever.
+type RetentionReport struct {
+	DryRun bool                  `json:"dryRun"`
+	At     time.Time             `json:"at"`
+	Rules  []RetentionRuleReport `json:"rules"`
+	Total  int64                 `json:"total"`
+	Audit  *RetentionAuditReport `json:"audit,omitempty"`
+}
+
+// RetentionService interface defines the methods for applying retention rules
+type RetentionService interface {
+	// Report counts the jobs each rule would remove now without removing them
+	Report(ctx context.Context) (*RetentionReport, error)
+	// Apply removes the jobs whose retention period is over
+	Apply(ctx context.Context) (*RetentionReport, error)
+}
+
+type retentionService struct {
+	jobs      repositories.JobsRepository
+	archive   repositories.JobArchiveRepository
+	summaries repositories.JobSummariesRepository
+	logs      repositories.JobLogsRepository
+	comments  repositories.JobCommentsRepository
+	blobs     repositories.JobBlobsRepository
+	rules     []RetentionRule
+	// auditMaxAge is how long comments, the audit events of jobs, are kept
+	// whatever happens to their job; zero keeps them forever
+	auditMaxAge time.Duration
+	batchSize   int
+}
+
+// NewRetentionService creates a new retention service applying rules to jobs
+// and auditMaxAge to their comments
+func NewRetentionService(jobs repositories.JobsRepository, archive repositories.JobArchiveRepository, summaries repositories.JobSummariesRepository, logs repositories.JobLogsRepository, comments repositories.JobCommentsRepository, blobs repositories.JobBlobsRepository, rules []RetentionRule, auditMaxAge time.Duration, batchSize int) RetentionService {
+	ordered := append([]RetentionRule(nil), rules...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].precedence() != ordered[j].precedence() {
+			return ordered[i].precedence() < ordered[j].precedence()
+		}
+		if ordered[i].JobType != ordered[j].JobType {
+			return ordered[i].JobType < ordered[j].JobType
+		}
+		return ordered[i].Status < ordered[j].Status
+	})
+	if batchSize < 1 {
+		batchSize = DefaultRetentionBatchSize
+	}
+
+	return &retentionService{
+		jobs:        jobs,
+		archive:     archive,
+		summaries:   summaries,
+		logs:        logs,
+		comments:    comments,
+		blobs:       blobs,
+		rules:       ordered,
+		auditMaxAge: auditMaxAge,
+		batchSize:   batchSize,
+	}
+}
+
+func (s *retentionService) Report(ctx context.Context) (*RetentionReport, error) {
+	return s.apply(ctx, true)
+}
+
+func (s *retentionService) Apply(ctx context.Context) (*RetentionReport, error) {
+	return s.apply(ctx, false)
+}
+
+func (s *retentionService) apply(ctx context.Context, dryRun bool) (*RetentionReport, error) {
+	now := time.Now()
+	report := &RetentionReport{DryRun: dryRun, At: now, Rules: []RetentionRuleReport{}}
+
+	for i, rule := range s.rules {
+		query := s.query(i, now)
+		ruleReport := RetentionRuleReport{
+			JobType: string(rule.JobType),
+			Status:  string(rule.Status),
+			Action:  rule.Action,
+			MaxAge:  rule.MaxAge.String(),
+			Cutoff:  query.UpdatedBefore,
+		}
+		if ruleReport.JobType == "" {
+			ruleReport.JobType = "*"
+		}
+		if ruleReport.Status == "" {
+			ruleReport.Status = "*"
+		}
+
+		var err error
+		if dryRun {
+			ruleReport.Jobs, err = s.jobs.CountForRetention(ctx, query)
+		} else {
+			ruleReport.Jobs, err = s.remove(ctx, rule, query)
+		}
+		report.Total += ruleReport.Jobs
+		report.Rules = append(report.Rules, ruleReport)
+		if err != nil {
+			return report, fmt.Errorf("failed to apply retention rule %s:%s: %w", ruleReport.JobType, ruleReport.Status, err)
+		}
+	}
+
+	if s.auditMaxAge > 0 {
+		audit := &RetentionAuditReport{MaxAge: s.auditMaxAge.String(), Cutoff: now.Add(-s.auditMaxAge)}
+		report.Audit = audit
+
+		var err error
+		if dryRun {
+			audit.Comments, err = s.comments.CountCreatedBefore(ctx, audit.Cutoff)
+		} else {
+			audit.Comments, err = s.comments.DeleteCreatedBefore(ctx, audit.Cutoff)
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to apply audit retention: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// query returns the query of the i-th rule, leaving out the jobs governed by
+// the more specific rules before it
+func (s *retentionService) query(i int, now time.Time) repositories.RetentionQuery {
+	rule := s.rules[i]
+	query := repositories.RetentionQuery{
+		JobType:       rule.JobType,
+		Statuses:      retentionStatuses,
+		UpdatedBefore: now.Add(-rule.MaxAge),
+	}
+	if rule.Status != "" {
+		query.Statuses = []models.JobStatus{rule.Status}
+	}
+	for _, other := range s.rules[:i] {
+		if other.precedence() < rule.precedence() && other.overlaps(rule) {
+			query.Except = append(query.Except, repositories.RetentionMatch{JobType: other.JobType, Status: other.Status})
+		}
+	}
+	return query
+}
+
+// remove archives or deletes the jobs matching a rule's query in batches and
+// returns how many were removed
+func (s *retentionService) remove(ctx context.Context, rule RetentionRule, query repositories.RetentionQuery) (int64, error) {
+	var removed int64
+	var after models.JobID
+	for {
+		jobs, err := s.jobs.ListForRetention(ctx, query, after, s.batchSize)
+		if err != nil {
+			return removed, err
+		}
+		if len(jobs) == 0 {
+			return removed, nil
+		}
+		after = jobs[len(jobs)-1].ID
+
+		ids := make([]models.JobID, 0, len(jobs))
+		configRefs := make(map[models.JobID]primitive.ObjectID)
+		for i := range jobs {
+			job := &jobs[i]
+			if job.ConfigRef != nil {
+				configRefs[job.ID] = job.ConfigRef.ID
+			}
+			if rule.Action == RetentionArchive {
+				if err := s.archiveJob(ctx, job); err != nil {
+					// The job stays until a later run archives it
+					log.Printf("Failed to archive job %s: %v", job.ID.String(), err)
+					continue
+				}
+			}
+			ids = append(ids, job.ID)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		deleted, err := s.jobs.DeleteForRetention(ctx, query, ids)
+		if err != nil {
+			return removed, err
+		}
+		removed += int64(len(deleted))
+		retainedJobsRemoved.Add(float64(len(deleted)), string(rule.Action))
+		s.removeRelated(ctx, deleted, configRefs)
+	}
+}
+
+// archiveJob stores a job in the archive with its config inline, so the
+// config's blob can be deleted with the job
+func (s *retentionService) archiveJob(ctx context.Context, job *models.Job) error {
+	if err := loadConfigBlob(ctx, s.blobs, job); err != nil {
+		return err
+	}
+	archived := *job
+	archived.ConfigRef = nil
+	return s.archive.Archive(ctx, &archived)
+}
+
+// removeRelated deletes the summaries, logs and config blobs of removed jobs.
+// Comments are audit events and stay for the audit retention period. Failures
+// are logged; they leave orphans but no broken jobs.
+func (s *retentionService) removeRelated(ctx context.Context, ids []models.JobID, configRefs map[models.JobID]primitive.ObjectID) {
+	if len(ids) == 0 {
+		return
+	}
+	if err := s.summaries.Delete(ctx, ids); err != nil {
+		log.Printf("Failed to delete summaries of %d removed jobs: %v", len(ids), err)
+	}
+	if err := s.logs.DeleteByJobs(ctx, ids); err != nil {
+		log.Printf("Failed to delete logs of %d removed jobs: %v", len(ids), err)
+	}
+	for _, id := range ids {
+		configID, ok := configRefs[id]
+		if !ok {
+			continue
+		}
+		if err := s.blobs.Delete(ctx, configID); err != nil {
+			log.Printf("Failed to delete config %s of removed job %s: %v", configID.Hex(), id.String(), err)
+		}
+	}
+}
+
+// RetentionWorker applies the retention rules once a day
+type RetentionWorker struct {
+	service RetentionService
+	// runAt is the time of day the rules are applied, as an offset from midnight UTC
+	runAt time.Duration
+}
+
+// NewRetentionWorker creates a retention worker applying the rules daily at runAt past midnight UTC
+func NewRetentionWorker(service RetentionService, runAt time.Duration) *RetentionWorker {
+	return &RetentionWorker{
+		service: service,
+		runAt:   runAt,
+	}
+}
+
+// Run applies the retention rules every night until ctx is cancelled
+func (w *RetentionWorker) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(time.Until(nextDailyRun(time.Now(), w.runAt)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		report, err := w.service.Apply(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to apply retention rules: %v", err)
+			}
+			continue
+		}
+		log.Printf("Retention removed %d jobs", report.Total)
+	}
+}
+
+// nextDailyRun returns the first time after now that is offset past midnight UTC
+func nextDailyRun(now time.Time, offset time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(offset)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
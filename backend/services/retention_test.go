@@ -0,0 +1,332 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeRetentionJobs matches jobs against retention queries the way the
+// repository's filter does
+type fakeRetentionJobs struct {
+	repositories.JobsRepository
+	jobs []models.Job
+}
+
+func (r *fakeRetentionJobs) matches(query repositories.RetentionQuery, job models.Job) bool {
+	if job.Hold != nil || !job.UpdatedAt.Before(query.UpdatedBefore) {
+		return false
+	}
+	if query.JobType != "" && job.JobType != query.JobType {
+		return false
+	}
+	status := false
+	for _, s := range query.Statuses {
+		status = status || job.Status == s
+	}
+	if !status {
+		return false
+	}
+	for _, except := range query.Except {
+		if (except.JobType == "" || except.JobType == job.JobType) && (except.Status == "" || except.Status == job.Status) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *fakeRetentionJobs) CountForRetention(ctx context.Context, query repositories.RetentionQuery) (int64, error) {
+	var count int64
+	for _, job := range r.jobs {
+		if r.matches(query, job) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRetentionJobs) ListForRetention(ctx context.Context, query repositories.RetentionQuery, after models.JobID, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	for _, job := range r.jobs {
+		if job.ID.String() > after.String() && r.matches(query, job) && len(jobs) < limit {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *fakeRetentionJobs) DeleteForRetention(ctx context.Context, query repositories.RetentionQuery, ids []models.JobID) ([]models.JobID, error) {
+	remove := make(map[models.JobID]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	var deleted []models.JobID
+	kept := r.jobs[:0]
+	for _, job := range r.jobs {
+		if remove[job.ID] && r.matches(query, job) {
+			deleted = append(deleted, job.ID)
+			continue
+		}
+		kept = append(kept, job)
+	}
+	r.jobs = kept
+	return deleted, nil
+}
+
+// fakeRetentionArchive archives jobs, failing for those listed in fail
+type fakeRetentionArchive struct {
+	repositories.JobArchiveRepository
+	archived []models.Job
+	fail     map[models.JobID]bool
+}
+
+func (a *fakeRetentionArchive) Archive(ctx context.Context, job *models.Job) error {
+	if a.fail[job.ID] {
+		return errors.New("archive unavailable")
+	}
+	a.archived = append(a.archived, *job)
+	return nil
+}
+
+// fakeRelatedData records what is removed along with the jobs
+type fakeRelatedData struct {
+	summaries []models.JobID
+	logs      []models.JobID
+	blobs     []primitive.ObjectID
+	comments  int64
+}
+
+type fakeRetentionSummaries struct {
+	repositories.JobSummariesRepository
+	*fakeRelatedData
+}
+
+func (r fakeRetentionSummaries) Delete(ctx context.Context, ids []models.JobID) error {
+	r.summaries = append(r.summaries, ids...)
+	return nil
+}
+
+type fakeRetentionLogs struct {
+	repositories.JobLogsRepository
+	*fakeRelatedData
+}
+
+func (r fakeRetentionLogs) DeleteByJobs(ctx context.Context, ids []models.JobID) error {
+	r.logs = append(r.logs, ids...)
+	return nil
+}
+
+type fakeRetentionComments struct {
+	repositories.JobCommentsRepository
+	*fakeRelatedData
+}
+
+func (r fakeRetentionComments) CountCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	return r.comments, nil
+}
+
+func (r fakeRetentionComments) DeleteCreatedBefore(ctx context.Context, before time.Time) (int64, error) {
+	deleted := r.comments
+	r.comments = 0
+	return deleted, nil
+}
+
+type fakeRetentionBlobs struct {
+	repositories.JobBlobsRepository
+	*fakeRelatedData
+}
+
+func (r fakeRetentionBlobs) Get(ctx context.Context, id primitive.ObjectID) ([]byte, error) {
+	return []byte(`{"source":"s3://bucket/input.csv"}`), nil
+}
+
+func (r fakeRetentionBlobs) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.blobs = append(r.blobs, id)
+	return nil
+}
+
+func newTestRetentionService(jobs *fakeRetentionJobs, archive *fakeRetentionArchive, related *fakeRelatedData, rules []RetentionRule, auditMaxAge time.Duration, batchSize int) *retentionService {
+	return NewRetentionService(jobs, archive,
+		fakeRetentionSummaries{fakeRelatedData: related},
+		fakeRetentionLogs{fakeRelatedData: related},
+		fakeRetentionComments{fakeRelatedData: related},
+		fakeRetentionBlobs{fakeRelatedData: related},
+		rules, auditMaxAge, batchSize).(*retentionService)
+}
+
+func TestRetentionRulePrecedence(t *testing.T) {
+	rules := []RetentionRule{
+		{Status: models.JobStatusFailed, Action: RetentionDelete, MaxAge: 7 * 24 * time.Hour},
+		{Action: RetentionDelete, MaxAge: 30 * 24 * time.Hour},
+		{JobType: models.JobTypeExport, Action: RetentionArchive, MaxAge: 24 * time.Hour},
+		{JobType: models.JobTypeExport, Status: models.JobStatusFailed, Action: RetentionArchive, MaxAge: 90 * 24 * time.Hour},
+	}
+	exportFailed := repositories.RetentionMatch{JobType: models.JobTypeExport, Status: models.JobStatusFailed}
+	export := repositories.RetentionMatch{JobType: models.JobTypeExport}
+	failed := repositories.RetentionMatch{Status: models.JobStatusFailed}
+
+	tests := []struct {
+		name         string
+		wantRule     RetentionRule
+		wantStatuses []models.JobStatus
+		wantExcept   []repositories.RetentionMatch
+	}{
+		{name: "type and status", wantRule: rules[3], wantStatuses: []models.JobStatus{models.JobStatusFailed}},
+		{name: "type", wantRule: rules[2], wantStatuses: retentionStatuses, wantExcept: []repositories.RetentionMatch{exportFailed}},
+		{name: "status", wantRule: rules[0], wantStatuses: []models.JobStatus{models.JobStatusFailed}, wantExcept: []repositories.RetentionMatch{exportFailed, export}},
+		{name: "any", wantRule: rules[1], wantStatuses: retentionStatuses, wantExcept: []repositories.RetentionMatch{exportFailed, export, failed}},
+	}
+
+	service := newTestRetentionService(&fakeRetentionJobs{}, &fakeRetentionArchive{}, &fakeRelatedData{}, rules, 0, 0)
+	now := time.Now()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if service.rules[i] != tt.wantRule {
+				t.Fatalf("rule %d = %+v, want %+v", i, service.rules[i], tt.wantRule)
+			}
+			query := service.query(i, now)
+			if !reflect.DeepEqual(query.Statuses, tt.wantStatuses) || !reflect.DeepEqual(query.Except, tt.wantExcept) {
+				t.Errorf("query = statuses %v except %v, want %v except %v", query.Statuses, query.Except, tt.wantStatuses, tt.wantExcept)
+			}
+			if !query.UpdatedBefore.Equal(now.Add(-tt.wantRule.MaxAge)) {
+				t.Errorf("query cutoff = %s, want %s", query.UpdatedBefore, now.Add(-tt.wantRule.MaxAge))
+			}
+		})
+	}
+}
+
+func TestRetentionApply(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-60 * 24 * time.Hour)
+	configRef := &models.BlobRef{ID: primitive.NewObjectID()}
+	job := func(hex string, jobType models.JobType, status models.JobStatus, updatedAt time.Time) models.Job {
+		return models.Job{ID: testJobID(t, hex), JobType: jobType, Status: status, UpdatedAt: updatedAt}
+	}
+
+	oldCompleted := job("65a1b2c3d4e5f60718293a01", models.JobTypeProcess, models.JobStatusCompleted, old)
+	oldFailed := job("65a1b2c3d4e5f60718293a02", models.JobTypeProcess, models.JobStatusFailed, old)
+	oldExport := job("65a1b2c3d4e5f60718293a03", models.JobTypeExport, models.JobStatusCompleted, old)
+	oldExport.ConfigRef = configRef
+	unarchivable := job("65a1b2c3d4e5f60718293a04", models.JobTypeExport, models.JobStatusCancelled, old)
+	recent := job("65a1b2c3d4e5f60718293a05", models.JobTypeProcess, models.JobStatusCompleted, now.Add(-time.Hour))
+	pending := job("65a1b2c3d4e5f60718293a06", models.JobTypeProcess, models.JobStatusPending, old)
+	held := job("65a1b2c3d4e5f60718293a07", models.JobTypeProcess, models.JobStatusCompleted, old)
+	held.Hold = &models.JobHold{By: "user-1", At: old}
+
+	rules := []RetentionRule{
+		{Action: RetentionDelete, MaxAge: 30 * 24 * time.Hour},
+		{JobType: models.JobTypeExport, Action: RetentionArchive, MaxAge: 7 * 24 * time.Hour},
+	}
+
+	tests := []struct {
+		name         string
+		dryRun       bool
+		wantTotal    int64
+		wantRemoved  []models.JobID
+		wantArchived []models.JobID
+	}{
+		{name: "dry run", dryRun: true, wantTotal: 4},
+		{
+			name:         "apply",
+			wantTotal:    3,
+			wantRemoved:  []models.JobID{oldCompleted.ID, oldFailed.ID, oldExport.ID},
+			wantArchived: []models.JobID{oldExport.ID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := &fakeRetentionJobs{jobs: []models.Job{oldCompleted, oldFailed, oldExport, unarchivable, recent, pending, held}}
+			archive := &fakeRetentionArchive{fail: map[models.JobID]bool{unarchivable.ID: true}}
+			related := &fakeRelatedData{comments: 12}
+			service := newTestRetentionService(jobs, archive, related, rules, 90*24*time.Hour, 1)
+
+			var report *RetentionReport
+			var err error
+			if tt.dryRun {
+				report, err = service.Report(context.Background())
+			} else {
+				report, err = service.Apply(context.Background())
+			}
+			if err != nil {
+				t.Fatalf("retention error = %v", err)
+			}
+			if report.Total != tt.wantTotal || report.DryRun != tt.dryRun || len(report.Rules) != 2 {
+				t.Errorf("report = %+v, want %d jobs over both rules", report, tt.wantTotal)
+			}
+			if report.Audit == nil || report.Audit.Comments != 12 {
+				t.Errorf("audit report = %+v, want 12 comments", report.Audit)
+			}
+
+			var remaining []models.JobID
+			for _, job := range jobs.jobs {
+				remaining = append(remaining, job.ID)
+			}
+			if removed := 7 - len(remaining); removed != len(tt.wantRemoved) {
+				t.Errorf("removed %d jobs, want %d", removed, len(tt.wantRemoved))
+			}
+			if !sameJobIDs(related.summaries, tt.wantRemoved) || !sameJobIDs(related.logs, tt.wantRemoved) {
+				t.Errorf("removed summaries %v and logs %v, want those of %v", related.summaries, related.logs, tt.wantRemoved)
+			}
+
+			var archived []models.JobID
+			for _, job := range archive.archived {
+				archived = append(archived, job.ID)
+				if job.ConfigRef != nil || job.Config["source"] != "s3://bucket/input.csv" {
+					t.Errorf("archived job %s config = %v, ref %v, want the config inline", job.ID.String(), job.Config, job.ConfigRef)
+				}
+			}
+			if !sameJobIDs(archived, tt.wantArchived) {
+				t.Errorf("archived %v, want %v", archived, tt.wantArchived)
+			}
+			if wantBlobs := len(tt.wantArchived); len(related.blobs) != wantBlobs {
+				t.Errorf("deleted %d config blobs, want %d", len(related.blobs), wantBlobs)
+			}
+		})
+	}
+}
+
+// sameJobIDs reports whether two lists hold the same IDs in any order
+func sameJobIDs(got, want []models.JobID) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	sorted := func(ids []models.JobID) []string {
+		out := make([]string, len(ids))
+		for i, id := range ids {
+			out[i] = id.String()
+		}
+		sort.Strings(out)
+		return out
+	}
+	return reflect.DeepEqual(sorted(got), sorted(want))
+}
+
+func TestNextDailyRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		now    time.Time
+		offset time.Duration
+		want   time.Time
+	}{
+		{name: "later today", now: time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC), offset: 3 * time.Hour, want: time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)},
+		{name: "passed today", now: time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC), offset: 3 * time.Hour, want: time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)},
+		{name: "exactly now", now: time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC), offset: 3 * time.Hour, want: time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)},
+		{name: "other time zone", now: time.Date(2026, 3, 1, 23, 0, 0, 0, time.FixedZone("UTC-5", -5*3600)), offset: 3 * time.Hour, want: time.Date(2026, 3, 3, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDailyRun(tt.now, tt.offset); !got.Equal(tt.want) {
+				t.Errorf("nextDailyRun() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
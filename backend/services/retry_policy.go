@@ -0,0 +1,106 @@
+package services
+
+import (
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// RetryPolicyOverride replaces the settings of a retry policy that are set.
+// Unset pointers and empty values keep the setting they override.
+type RetryPolicyOverride struct {
+	MaxRetries   *int
+	AutoRetry    *bool
+	Backoff      models.BackoffCurve
+	InitialDelay *time.Duration
+	MaxDelay     *time.Duration
+	DLQ          models.DLQMode
+}
+
+func (o RetryPolicyOverride) apply(policy models.RetryPolicy) models.RetryPolicy {
+	if o.MaxRetries != nil {
+		policy.MaxRetries = *o.MaxRetries
+	}
+	if o.AutoRetry != nil {
+		policy.AutoRetry = *o.AutoRetry
+	}
+	if o.Backoff != "" {
+		policy.Backoff = o.Backoff
+	}
+	if o.InitialDelay != nil {
+		policy.InitialDelayMs = o.InitialDelay.Milliseconds()
+	}
+	if o.MaxDelay != nil {
+		policy.MaxDelayMs = o.MaxDelay.Milliseconds()
+	}
+	if o.DLQ != "" {
+		policy.DLQ = o.DLQ
+	}
+	return policy
+}
+
+// RetryPolicyRequest overrides the retry policy of the job type for a single job
+type RetryPolicyRequest struct {
+	MaxRetries *int  `json:"max_retries,omitempty"`
+	AutoRetry  *bool `json:"auto_retry,omitempty"`
+	// Backoff is one of fixed, linear or exponential
+	Backoff string `json:"backoff,omitempty"`
+	// InitialDelay and MaxDelay are durations such as "10s"
+	InitialDelay string `json:"initial_delay,omitempty"`
+	MaxDelay     string `json:"max_delay,omitempty"`
+	// DLQ is one of exhausted, always or never
+	DLQ string `json:"dlq,omitempty"`
+}
+
+func (r *RetryPolicyRequest) toOverride() (RetryPolicyOverride, error) {
+	if r == nil {
+		return RetryPolicyOverride{}, nil
+	}
+
+	override := RetryPolicyOverride{
+		MaxRetries: r.MaxRetries,
+		AutoRetry:  r.AutoRetry,
+		Backoff:    models.BackoffCurve(r.Backoff),
+		DLQ:        models.DLQMode(r.DLQ),
+	}
+	if r.InitialDelay != "" {
+		delay, err := time.ParseDuration(r.InitialDelay)
+		if err != nil {
+			return override, &ValidationError{Field: "retry.initial_delay", Message: "initial_delay must be a duration such as 10s"}
+		}
+		override.InitialDelay = &delay
+	}
+	if r.MaxDelay != "" {
+		delay, err := time.ParseDuration(r.MaxDelay)
+		if err != nil {
+			return override, &ValidationError{Field: "retry.max_delay", Message: "max_delay must be a duration such as 5m"}
+		}
+		override.MaxDelay = &delay
+	}
+	return override, nil
+}
+
+// retryPolicyFor returns the retry policy of a job type, applying the configured override
+func (s *jobsService) retryPolicyFor(jobType models.JobType) models.RetryPolicy {
+	spec, _ := models.LookupJobType(string(jobType))
+	policy := spec.Retry
+	if override, ok := s.currentConfig().RetryPolicies[string(jobType)]; ok {
+		policy = override.apply(policy)
+	}
+	return policy
+}
+
+// resolveRetryPolicy applies a job's requested overrides to the policy of its type
+func (s *jobsService) resolveRetryPolicy(jobType models.JobType, req *RetryPolicyRequest) (*models.RetryPolicy, error) {
+	override, err := req.toOverride()
+	if err != nil {
+		return nil, err
+	}
+
+	policy := override.apply(s.retryPolicyFor(jobType))
+	if err := policy.Validate(); err != nil {
+		return nil, &ValidationError{Field: "retry", Message: err.Error()}
+	}
+
+	return &policy, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// maxSearchQueryLength bounds the size of a search query
+const maxSearchQueryLength = 256
+
+// Highlight markers wrapped around matched terms
+const (
+	highlightStart = "<em>"
+	highlightEnd   = "</em>"
+)
+
+// SearchFilter represents a full-text search over jobs
+type SearchFilter struct {
+	Query string
+	Limit int
+}
+
+// SearchResult is a job matched by a search with its relevance score and
+// highlighted field values
+type SearchResult struct {
+	Job   models.Job `json:"job"`
+	Score float64    `json:"score"`
+	// Highlights maps field names to their value with matched terms wrapped in <em> tags
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// SearchJobs finds jobs whose name or error message match the query, most relevant first
+func (s *jobsService) SearchJobs(ctx context.Context, filter SearchFilter) ([]SearchResult, error) {
+	query := strings.TrimSpace(filter.Query)
+	if query == "" {
+		return nil, &ValidationError{Field: "q", Message: "search query is required"}
+	}
+	if len(query) > maxSearchQueryLength {
+		return nil, &ValidationError{
+			Field:   "q",
+			Message: fmt.Sprintf("search query must not exceed %d characters", maxSearchQueryLength),
+		}
+	}
+
+	if filter.Limit < 1 {
+		filter.Limit = DefaultPageLimit
+	}
+	if maxLimit := s.currentConfig().MaxPageLimit; filter.Limit > maxLimit {
+		filter.Limit = maxLimit
+	}
+
+	hits, err := s.repo.Search(ctx, query, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search jobs: %w", err)
+	}
+
+	terms := searchTerms(query)
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		highlights := make(map[string]string)
+		if value, ok := highlight(hit.Name, terms); ok {
+			highlights["name"] = value
+		}
+		if value, ok := highlight(hit.ErrorMessage, terms); ok {
+			highlights["errorMessage"] = value
+		}
+		results = append(results, SearchResult{Job: hit.Job, Score: hit.Score, Highlights: highlights})
+	}
+
+	return results, nil
+}
+
+// searchTerms extracts the lowercased words of a query, skipping negated terms
+func searchTerms(query string) []string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		word := strings.ToLower(strings.TrimFunc(field, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}))
+		if word != "" {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// highlight wraps the words of value that start with one of the terms. Prefix
+// matching approximates the stemming Mongo applies, so "fail" marks "failure".
+func highlight(value string, terms []string) (string, bool) {
+	if value == "" || len(terms) == 0 {
+		return "", false
+	}
+
+	var b strings.Builder
+	matched := false
+	start := -1
+	flush := func(end int) {
+		word := value[start:end]
+		lower := strings.ToLower(word)
+		for _, term := range terms {
+			if strings.HasPrefix(lower, term) || (len(lower) >= 3 && strings.HasPrefix(term, lower)) {
+				b.WriteString(highlightStart + word + highlightEnd)
+				matched = true
+				return
+			}
+		}
+		b.WriteString(word)
+	}
+
+	for i, r := range value {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			flush(i)
+			start = -1
+		}
+		if !isWord {
+			b.WriteRune(r)
+		}
+	}
+	if start >= 0 {
+		flush(len(value))
+	}
+
+	return b.String(), matched
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// JobStats summarises the jobs in the system
+type JobStats struct {
+	ByStatus map[models.JobStatus]int64 `json:"byStatus"`
+	SLO      []SLOSummary               `json:"slo"`
+}
+
+// SLOSummary reports SLO compliance of finished jobs of one type
+type SLOSummary struct {
+	JobType       models.JobType `json:"jobType"`
+	SLOMs         int64          `json:"sloMs"`
+	Finished      int64          `json:"finished"`
+	Breached      int64          `json:"breached"`
+	BreachRate    float64        `json:"breachRate"`
+	AvgDurationMs float64        `json:"avgDurationMs"`
+	MaxDurationMs int64          `json:"maxDurationMs"`
+	// Recent holds duration percentiles of the jobs finished within the workers' stats window
+	Recent *models.JobTypeStats `json:"recent,omitempty"`
+}
+
+// GetStats returns job counts by status and an SLO summary per job type
+func (s *jobsService) GetStats(ctx context.Context) (*JobStats, error) {
+	counts, err := s.repo.CountByStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	durations, err := s.repo.DurationStatsByType(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate job durations: %w", err)
+	}
+
+	byType := make(map[models.JobType]SLOSummary, len(durations))
+	for _, d := range durations {
+		summary := SLOSummary{
+			JobType:       d.JobType,
+			Finished:      d.Finished,
+			Breached:      d.Breached,
+			AvgDurationMs: d.AvgDurationMs,
+			MaxDurationMs: d.MaxDurationMs,
+		}
+		if d.Finished > 0 {
+			summary.BreachRate = float64(d.Breached) / float64(d.Finished)
+		}
+		byType[d.JobType] = summary
+	}
+
+	recent, err := s.repo.RecentDurationStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recent job durations: %w", err)
+	}
+	recentByType := make(map[models.JobType]*models.JobTypeStats, len(recent))
+	for i := range recent {
+		recentByType[recent[i].JobType] = &recent[i]
+	}
+
+	// Report every registered type, including those without finished jobs
+	stats := &JobStats{ByStatus: counts, SLO: []SLOSummary{}}
+	for _, jobType := range models.ValidJobTypes() {
+		summary := byType[jobType]
+		summary.JobType = jobType
+		summary.SLOMs = s.sloFor(jobType).Milliseconds()
+		summary.Recent = recentByType[jobType]
+		stats.SLO = append(stats.SLO, summary)
+	}
+
+	return stats, nil
+}
+
+// Timeseries bounds
+const (
+	DefaultTimeseriesInterval = 5 * time.Minute
+	DefaultTimeseriesWindow   = 24 * time.Hour
+	minTimeseriesInterval     = time.Minute
+	maxTimeseriesBuckets      = 1000
+)
+
+// Timeseries holds job throughput in consecutive time buckets, oldest first
+type Timeseries struct {
+	Interval string            `json:"interval"`
+	Window   string            `json:"window"`
+	Buckets  []TimeseriesPoint `json:"buckets"`
+}
+
+// TimeseriesPoint counts the jobs created and finished in one bucket.
+// Duration percentiles are omitted when no job finished in the bucket.
+type TimeseriesPoint struct {
+	Start         time.Time `json:"start"`
+	Created       int64     `json:"created"`
+	Completed     int64     `json:"completed"`
+	Failed        int64     `json:"failed"`
+	ErrorRate     float64   `json:"errorRate"`
+	P50DurationMs *float64  `json:"p50DurationMs,omitempty"`
+	P95DurationMs *float64  `json:"p95DurationMs,omitempty"`
+}
+
+// GetTimeseries returns job throughput over the window in buckets of the interval.
+// Buckets are aligned to the interval and returned for the whole window, including empty ones.
+func (s *jobsService) GetTimeseries(ctx context.Context, interval, window time.Duration) (*Timeseries, error) {
+	if interval < minTimeseriesInterval {
+		return nil, &ValidationError{Field: "interval", Message: fmt.Sprintf("interval must be at least %s", minTimeseriesInterval)}
+	}
+	if window < interval {
+		return nil, &ValidationError{Field: "window", Message: "window must be at least one interval"}
+	}
+	if window/interval > maxTimeseriesBuckets {
+		return nil, &ValidationError{Field: "window", Message: fmt.Sprintf("window must span at most %d intervals", maxTimeseriesBuckets)}
+	}
+
+	// Align to the Unix epoch like the buckets the repository aggregates
+	now := time.Now().UTC()
+	sinceMs := now.Add(-window).UnixMilli()
+	since := time.UnixMilli(sinceMs - sinceMs%interval.Milliseconds()).UTC()
+
+	rows, err := s.repo.ThroughputSince(ctx, since, interval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate job throughput: %w", err)
+	}
+	byStart := make(map[int64]repositories.ThroughputBucket, len(rows))
+	for _, row := range rows {
+		byStart[row.Start.UnixMilli()] = row
+	}
+
+	series := &Timeseries{
+		Interval: interval.String(),
+		Window:   window.String(),
+		Buckets:  []TimeseriesPoint{},
+	}
+	for start := since; !start.After(now); start = start.Add(interval) {
+		point := TimeseriesPoint{Start: start}
+		if row, ok := byStart[start.UnixMilli()]; ok {
+			point.Created = row.Created
+			point.Completed = row.Completed
+			point.Failed = row.Failed
+			if finished := row.Completed + row.Failed; finished > 0 {
+				point.ErrorRate = float64(row.Failed) / float64(finished)
+				if len(row.DurationPercentiles) == 2 {
+					p50, p95 := row.DurationPercentiles[0], row.DurationPercentiles[1]
+					point.P50DurationMs = &p50
+					point.P95DurationMs = &p95
+				}
+			}
+		}
+		series.Buckets = append(series.Buckets, point)
+	}
+
+	return series, nil
+}
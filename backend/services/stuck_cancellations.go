@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// maxStuckCancellations bounds the jobs resolved by one request
+const maxStuckCancellations = 1000
+
+// Resolutions of a stuck cancellation
+const (
+	// CancellationConfirmed moves the job to cancelled: no worker is running it
+	CancellationConfirmed = "cancelled"
+	// CancellationReverted moves the job back to processing: a live worker is
+	// still running it and will complete it
+	CancellationReverted = "reverted"
+)
+
+// ResolveCancellationsRequest selects the stuck cancellations to resolve
+type ResolveCancellationsRequest struct {
+	// OlderThan is how long ago the cancellations were requested, e.g. "30m";
+	// empty uses the sweeper's threshold
+	OlderThan string `json:"older_than,omitempty"`
+	// DryRun reports how each cancellation would be resolved without resolving it
+	DryRun bool `json:"dry_run,omitempty"`
+	// Actor is the authenticated caller resolving the cancellations
+	Actor string `json:"-"`
+}
+
+// ResolvedCancellation reports how the cancellation of a job was resolved
+type ResolvedCancellation struct {
+	JobID      string `json:"jobId"`
+	Resolution string `json:"resolution"`
+	// WorkerID is the worker that ran the job's last attempt
+	WorkerID string `json:"workerId,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// ResolveCancellationsResult reports the resolved cancellations. Skipped counts
+// the jobs whose cancellation completed, or was reverted, while resolving.
+type ResolveCancellationsResult struct {
+	Matched   int                    `json:"matched"`
+	Cancelled int                    `json:"cancelled"`
+	Reverted  int                    `json:"reverted"`
+	Skipped   int                    `json:"skipped"`
+	DryRun    bool                   `json:"dryRun"`
+	Jobs      []ResolvedCancellation `json:"jobs"`
+}
+
+// CancellationsService resolves jobs stuck in cancelling, which leave the state
+// only when a worker acknowledges their cancellation
+type CancellationsService interface {
+	// ResolveStuckCancellations resolves up to 1000 jobs cancelling since before req.OlderThan
+	ResolveStuckCancellations(ctx context.Context, req ResolveCancellationsRequest) (*ResolveCancellationsResult, error)
+	// ResolveCancellation resolves the cancellation of one job, without writing when dryRun is set
+	ResolveCancellation(ctx context.Context, job models.Job, inFlight map[string]string, dryRun bool) (ResolvedCancellation, bool, error)
+	// InFlightJobs returns the worker running each job, as live workers report
+	InFlightJobs(ctx context.Context) (map[string]string, error)
+}
+
+type cancellationsService struct {
+	repo    repositories.JobsRepository
+	workers WorkersService
+	events  *EventBus
+	// stuckAfter is how long a cancellation may wait for its acknowledgement by default
+	stuckAfter time.Duration
+}
+
+// NewCancellationsService creates a new cancellations service
+func NewCancellationsService(repo repositories.JobsRepository, workers WorkersService, events *EventBus, stuckAfter time.Duration) CancellationsService {
+	return &cancellationsService{repo: repo, workers: workers, events: events, stuckAfter: stuckAfter}
+}
+
+// ResolveStuckCancellations resolves the jobs whose cancellation was requested
+// longer ago than req.OlderThan and never acknowledged
+func (s *cancellationsService) ResolveStuckCancellations(ctx context.Context, req ResolveCancellationsRequest) (*ResolveCancellationsResult, error) {
+	olderThan := s.stuckAfter
+	if req.OlderThan != "" {
+		d, err := time.ParseDuration(req.OlderThan)
+		if err != nil || d <= 0 {
+			return nil, &ValidationError{Field: "older_than", Message: "older_than must be a positive duration, e.g. 30m"}
+		}
+		olderThan = d
+	}
+
+	jobs, err := s.repo.ListCancellingSince(ctx, time.Now().Add(-olderThan), maxStuckCancellations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cancelling jobs: %w", err)
+	}
+	inFlight, err := s.InFlightJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResolveCancellationsResult{Matched: len(jobs), DryRun: req.DryRun, Jobs: []ResolvedCancellation{}}
+	for _, job := range jobs {
+		resolved, ok, err := s.ResolveCancellation(ctx, job, inFlight, req.DryRun)
+		if err != nil {
+			return result, err
+		}
+		if !ok {
+			result.Skipped++
+			continue
+		}
+		if resolved.Resolution == CancellationReverted {
+			result.Reverted++
+		} else {
+			result.Cancelled++
+		}
+		result.Jobs = append(result.Jobs, resolved)
+	}
+
+	if !req.DryRun && len(result.Jobs) > 0 {
+		log.Printf("%s resolved %d stuck cancellations: %d cancelled, %d reverted", req.Actor, len(result.Jobs), result.Cancelled, result.Reverted)
+	}
+	return result, nil
+}
+
+// ResolveCancellation decides from the job's attempts and the workers' reports
+// whether the cancellation can still be acknowledged. A job whose last attempt
+// is running on a live worker is moved back to processing, as that worker will
+// complete it; any other job has no worker left to acknowledge and is
+// cancelled. It returns false if the job left cancelling meanwhile.
+func (s *cancellationsService) ResolveCancellation(ctx context.Context, job models.Job, inFlight map[string]string, dryRun bool) (ResolvedCancellation, bool, error) {
+	id := job.ID.String()
+	resolved := ResolvedCancellation{JobID: id, Resolution: CancellationConfirmed, Reason: "no worker was running the job"}
+
+	if n := len(job.Attempts); n > 0 && job.Attempts[n-1].Outcome == models.AttemptOutcomeRunning {
+		resolved.WorkerID = job.Attempts[n-1].WorkerID
+		if worker, ok := inFlight[id]; ok {
+			resolved.WorkerID = worker
+			resolved.Resolution = CancellationReverted
+			resolved.Reason = fmt.Sprintf("worker %s is still running the job", worker)
+		} else {
+			resolved.Reason = fmt.Sprintf("worker %s stopped without acknowledging the cancellation", resolved.WorkerID)
+		}
+	}
+	if dryRun {
+		return resolved, true, nil
+	}
+
+	if resolved.Resolution == CancellationReverted {
+		reverted, err := s.repo.RevertCancellation(ctx, job.ID, resolved.Reason)
+		if err != nil {
+			return resolved, false, fmt.Errorf("failed to revert the cancellation of job %s: %w", id, err)
+		}
+		if !reverted {
+			return resolved, false, nil
+		}
+		s.events.StatusChangedByID(ctx, job.ID, models.JobStatusCancelling, models.JobStatusProcessing)
+		cancellationEscalations.Inc("reverted")
+		log.Printf("Cancellation of job %s reverted: %s", id, resolved.Reason)
+		return resolved, true, nil
+	}
+
+	completed, err := s.repo.CompleteCancellation(ctx, id, "cancellation was not acknowledged: "+resolved.Reason)
+	if err != nil {
+		return resolved, false, fmt.Errorf("failed to cancel job %s: %w", id, err)
+	}
+	if !completed {
+		return resolved, false, nil
+	}
+	s.events.StatusChangedByID(ctx, job.ID, models.JobStatusCancelling, models.JobStatusCancelled)
+	cancellationEscalations.Inc("forced")
+	log.Printf("Job %s cancelled without an acknowledgement: %s", id, resolved.Reason)
+	return resolved, true, nil
+}
+
+// InFlightJobs returns the ID of the live worker running each job
+func (s *cancellationsService) InFlightJobs(ctx context.Context) (map[string]string, error) {
+	workers, err := s.workers.ListActiveWorkers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workers: %w", err)
+	}
+	inFlight := make(map[string]string)
+	for _, worker := range workers {
+		for _, jobID := range worker.InFlightJobs {
+			inFlight[jobID] = worker.ID
+		}
+	}
+	return inFlight, nil
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// ScopeToCaller returns a copy of ctx restricting the jobs the services reach
+// to those of the caller's tenant, so jobs of other tenants are not found.
+// Anonymous callers reach the jobs created without a tenant. Admins operate
+// the whole deployment and reach every tenant's jobs.
+func ScopeToCaller(ctx context.Context) context.Context {
+	principal := auth.PrincipalFromContext(ctx)
+	if principal.HasRole(auth.RoleAdmin) {
+		return ctx
+	}
+	return repositories.WithTenantScope(ctx, auth.Tenant(ctx))
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+func TestScopeToCaller(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		wantScoped bool
+		wantTenant string
+	}{
+		{name: "anonymous", principal: nil, wantScoped: true, wantTenant: ""},
+		{name: "API key", principal: &auth.Principal{Subject: "apikey:jp_1", Tenant: "acme"}, wantScoped: true, wantTenant: "acme"},
+		{name: "dashboard user", principal: &auth.Principal{Subject: "user-1", Tenant: "globex", Roles: []string{"viewer"}}, wantScoped: true, wantTenant: "globex"},
+		{name: "user without tenant", principal: &auth.Principal{Subject: "user-2"}, wantScoped: true, wantTenant: ""},
+		{name: "admin", principal: &auth.Principal{Subject: "ops", Tenant: "acme", Roles: []string{auth.RoleAdmin}}, wantScoped: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ScopeToCaller(auth.WithPrincipal(context.Background(), tt.principal))
+			tenant, scoped := repositories.TenantScope(ctx)
+			if scoped != tt.wantScoped {
+				t.Fatalf("scoped = %v, want %v", scoped, tt.wantScoped)
+			}
+			if scoped && tenant != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", tenant, tt.wantTenant)
+			}
+		})
+	}
+}
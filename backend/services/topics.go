@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/segmentio/kafka-go"
+)
+
+// errTopicsNotVerified is reported until the first topic verification has run
+var errTopicsNotVerified = errors.New("Kafka topics have not been verified yet")
+
+// topicVerifyInterval is how often verification is retried until the topics exist
+const topicVerifyInterval = 15 * time.Second
+
+// TopicSettings controls whether missing topics are created and how
+type TopicSettings struct {
+	// Create allows missing topics to be created instead of only reported
+	Create            bool
+	Partitions        int
+	ReplicationFactor int
+}
+
+// RequiredTopics lists the Kafka topics the backend publishes to or reads from
+func RequiredTopics() []string {
+	topics := []string{cancellationsTopic(), cancellationAcksTopic(), dlqTopic()}
+	for _, jobType := range models.ValidJobTypes() {
+		topics = append(topics, JobTopic(jobType), JobPriorityTopic(jobType), JobCanaryTopic(jobType))
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// TopicVerifier checks at startup that the required topics exist, creating
+// them when allowed, and reports the result for the readiness check
+type TopicVerifier struct {
+	conn     *KafkaConnection
+	topics   []string
+	settings TopicSettings
+
+	mu  sync.RWMutex
+	err error
+}
+
+// NewTopicVerifier creates a new topic verifier
+func NewTopicVerifier(conn *KafkaConnection, topics []string, settings TopicSettings) *TopicVerifier {
+	return &TopicVerifier{
+		conn:     conn,
+		topics:   topics,
+		settings: settings,
+		err:      errTopicsNotVerified,
+	}
+}
+
+// Run verifies the topics, retrying until they all exist or ctx is cancelled
+func (v *TopicVerifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(topicVerifyInterval)
+	defer ticker.Stop()
+
+	for {
+		err := v.verify(ctx)
+		v.mu.Lock()
+		v.err = err
+		v.mu.Unlock()
+
+		if err == nil {
+			log.Printf("Verified Kafka topics: %s", strings.Join(v.topics, ", "))
+			return
+		}
+		log.Printf("Kafka topic verification failed: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ready returns nil once every required topic exists, or the reason it does not
+func (v *TopicVerifier) Ready() error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.err
+}
+
+func (v *TopicVerifier) verify(ctx context.Context) error {
+	client := v.conn.Client()
+
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to list Kafka topics: %w", err)
+	}
+	existing := make(map[string]bool)
+	for _, topic := range metadata.Topics {
+		if topic.Error == nil {
+			existing[topic.Name] = true
+		}
+	}
+
+	var missing []string
+	for _, topic := range v.topics {
+		if !existing[topic] {
+			missing = append(missing, topic)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if !v.settings.Create {
+		return fmt.Errorf("missing Kafka topics %s; create them or enable topic creation", strings.Join(missing, ", "))
+	}
+
+	configs := make([]kafka.TopicConfig, len(missing))
+	for i, topic := range missing {
+		configs[i] = kafka.TopicConfig{
+			Topic:             topic,
+			NumPartitions:     v.settings.Partitions,
+			ReplicationFactor: v.settings.ReplicationFactor,
+		}
+	}
+	created, err := client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: configs})
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka topics %s: %w", strings.Join(missing, ", "), err)
+	}
+	for topic, err := range created.Errors {
+		// Another instance may have created it in the meantime
+		if err != nil && !errors.Is(err, kafka.TopicAlreadyExists) {
+			return fmt.Errorf("failed to create Kafka topic %s: %w", topic, err)
+		}
+	}
+
+	log.Printf("Created Kafka topics: %s", strings.Join(missing, ", "))
+	return nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// maxUsageDays bounds the range of days one usage listing covers
+const maxUsageDays = 366
+
+// UsageService interface defines the methods for reading tenant usage
+type UsageService interface {
+	ListUsage(ctx context.Context, query repositories.UsageQuery) ([]models.TenantUsage, error)
+}
+
+type usageService struct {
+	repo repositories.UsageRepository
+}
+
+// NewUsageService creates a new usage service
+func NewUsageService(repo repositories.UsageRepository) UsageService {
+	return &usageService{repo: repo}
+}
+
+// ListUsage retrieves the daily usage of one or every tenant. Without a range
+// it covers the last 30 days.
+func (s *usageService) ListUsage(ctx context.Context, query repositories.UsageQuery) ([]models.TenantUsage, error) {
+	today := time.Now().UTC()
+	to := today
+	if query.To != "" {
+		parsed, err := time.Parse(models.UsageDayFormat, query.To)
+		if err != nil {
+			return nil, &ValidationError{Field: "to", Message: "to must be a day such as 2024-05-31"}
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -29)
+	if query.From != "" {
+		parsed, err := time.Parse(models.UsageDayFormat, query.From)
+		if err != nil {
+			return nil, &ValidationError{Field: "from", Message: "from must be a day such as 2024-05-01"}
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		return nil, &ValidationError{Field: "from", Message: "from must not be after to"}
+	}
+	if to.Sub(from) >= maxUsageDays*24*time.Hour {
+		return nil, &ValidationError{Field: "from", Message: fmt.Sprintf("the range must not exceed %d days", maxUsageDays)}
+	}
+
+	query.From = from.Format(models.UsageDayFormat)
+	query.To = to.Format(models.UsageDayFormat)
+	return s.repo.List(ctx, query)
+}
+
+// UsageAggregator periodically recomputes the daily usage of every tenant from
+// the jobs that finished. Each pass recomputes today and yesterday, so jobs
+// finishing around midnight are counted once the day is over.
+type UsageAggregator struct {
+	repo     repositories.UsageRepository
+	interval time.Duration
+}
+
+// NewUsageAggregator creates a new usage aggregator
+func NewUsageAggregator(repo repositories.UsageRepository, interval time.Duration) *UsageAggregator {
+	return &UsageAggregator{
+		repo:     repo,
+		interval: interval,
+	}
+}
+
+// Run aggregates usage until ctx is cancelled
+func (a *UsageAggregator) Run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	a.aggregate(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.aggregate(ctx)
+		}
+	}
+}
+
+func (a *UsageAggregator) aggregate(ctx context.Context) {
+	now := time.Now().UTC()
+	if err := a.repo.Aggregate(ctx, now.AddDate(0, 0, -1), now); err != nil && ctx.Err() == nil {
+		log.Printf("Failed to aggregate tenant usage: %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/backend/auth"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// Saved view errors
+var (
+	ErrViewNotFound = errors.New("view not found")
+	ErrViewExists   = errors.New("a view with this name already exists")
+)
+
+// viewNamePattern keeps view names usable as-is in URLs, e.g. failed-exports-today
+var viewNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// sortableFields are the job fields the list can be ordered by
+var sortableFields = map[string]bool{
+	"created_at":  true,
+	"updated_at":  true,
+	"finished_at": true,
+	"duration_ms": true,
+	"name":        true,
+}
+
+// ViewRequest represents the request to create or replace a saved view
+type ViewRequest struct {
+	Name   string            `json:"name"`
+	Filter ViewFilterRequest `json:"filter"`
+}
+
+// ViewFilterRequest is the filter and sort of a saved view
+type ViewFilterRequest struct {
+	Status        []string `json:"status,omitempty"`
+	JobType       []string `json:"job_type,omitempty"`
+	CreatedWithin string   `json:"created_within,omitempty"`
+	Sort          string   `json:"sort,omitempty"`
+}
+
+// ViewsService interface defines the methods for managing saved views.
+// Views belong to the tenant of the caller.
+type ViewsService interface {
+	CreateView(ctx context.Context, req ViewRequest) (*models.JobView, error)
+	GetView(ctx context.Context, name string) (*models.JobView, error)
+	ListViews(ctx context.Context) ([]models.JobView, error)
+	UpdateView(ctx context.Context, name string, req ViewRequest) (*models.JobView, error)
+	DeleteView(ctx context.Context, name string) error
+}
+
+type viewsService struct {
+	repo repositories.JobViewsRepository
+}
+
+// NewViewsService creates a new saved views service
+func NewViewsService(repo repositories.JobViewsRepository) ViewsService {
+	return &viewsService{repo: repo}
+}
+
+// CreateView validates and stores a new view for the caller's tenant
+func (s *viewsService) CreateView(ctx context.Context, req ViewRequest) (*models.JobView, error) {
+	if !viewNamePattern.MatchString(req.Name) {
+		return nil, &ValidationError{Field: "name", Message: "name must be 1-64 lowercase letters, digits, dashes or underscores"}
+	}
+	filter, err := req.Filter.toViewFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	view := &models.JobView{
+		Tenant:    auth.Tenant(ctx),
+		Name:      req.Name,
+		Filter:    filter,
+		CreatedBy: auth.Actor(ctx),
+	}
+	err = s.repo.Create(ctx, view)
+	if errors.Is(err, repositories.ErrConflict) {
+		return nil, ErrViewExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create view: %w", err)
+	}
+
+	return view, nil
+}
+
+// GetView retrieves a view of the caller's tenant by name
+func (s *viewsService) GetView(ctx context.Context, name string) (*models.JobView, error) {
+	view, err := s.repo.GetByName(ctx, auth.Tenant(ctx), name)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrViewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get view: %w", err)
+	}
+
+	return view, nil
+}
+
+// ListViews retrieves the views of the caller's tenant
+func (s *viewsService) ListViews(ctx context.Context) ([]models.JobView, error) {
+	views, err := s.repo.List(ctx, auth.Tenant(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list views: %w", err)
+	}
+
+	if views == nil {
+		views = []models.JobView{}
+	}
+
+	return views, nil
+}
+
+// UpdateView replaces the filter of a view. Views cannot be renamed.
+func (s *viewsService) UpdateView(ctx context.Context, name string, req ViewRequest) (*models.JobView, error) {
+	view, err := s.GetView(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" && req.Name != view.Name {
+		return nil, &ValidationError{Field: "name", Message: "views cannot be renamed"}
+	}
+	filter, err := req.Filter.toViewFilter()
+	if err != nil {
+		return nil, err
+	}
+	view.Filter = filter
+
+	if err := s.repo.Update(ctx, view); err != nil {
+		return nil, fmt.Errorf("failed to update view: %w", err)
+	}
+
+	return view, nil
+}
+
+// DeleteView removes a view of the caller's tenant
+func (s *viewsService) DeleteView(ctx context.Context, name string) error {
+	deleted, err := s.repo.Delete(ctx, auth.Tenant(ctx), name)
+	if err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+
+	if !deleted {
+		return ErrViewNotFound
+	}
+
+	return nil
+}
+
+func (r ViewFilterRequest) toViewFilter() (models.ViewFilter, error) {
+	filter := models.ViewFilter{
+		CreatedWithin: r.CreatedWithin,
+		Sort:          r.Sort,
+	}
+	for _, status := range r.Status {
+		filter.Status = append(filter.Status, models.JobStatus(status))
+	}
+	for _, jobType := range r.JobType {
+		filter.JobType = append(filter.JobType, models.JobType(jobType))
+	}
+
+	if _, err := listQuery(filter, time.Now()); err != nil {
+		return models.ViewFilter{}, err
+	}
+	return filter, nil
+}
+
+// listQuery validates a view filter and converts it into a list query, resolving
+// CreatedWithin relative to now
+func listQuery(filter models.ViewFilter, now time.Time) (repositories.ListQuery, error) {
+	query := repositories.ListQuery{
+		Statuses: filter.Status,
+		JobTypes: filter.JobType,
+	}
+
+	for _, status := range filter.Status {
+		if !isValidJobStatus(status) {
+			return query, &ValidationError{Field: "status", Message: fmt.Sprintf("invalid status '%s'", status)}
+		}
+	}
+	for _, jobType := range filter.JobType {
+		if !models.IsValidJobType(string(jobType)) {
+			return query, &ValidationError{Field: "job_type", Message: fmt.Sprintf("invalid job type '%s'", jobType)}
+		}
+	}
+
+	if filter.CreatedWithin != "" {
+		within, err := time.ParseDuration(filter.CreatedWithin)
+		if err != nil || within <= 0 {
+			return query, &ValidationError{Field: "created_within", Message: "created_within must be a positive duration such as 24h"}
+		}
+		after := now.Add(-within)
+		query.CreatedAfter = &after
+	}
+
+	if filter.Sort != "" {
+		field := strings.TrimPrefix(filter.Sort, "-")
+		if !sortableFields[field] {
+			return query, &ValidationError{Field: "sort", Message: fmt.Sprintf("cannot sort by '%s'", field)}
+		}
+		query.SortField = field
+		query.SortAscending = !strings.HasPrefix(filter.Sort, "-")
+	}
+
+	return query, nil
+}
+
+// activeStatuses returns the statuses listed by an active-only list: the non-terminal
+// ones among statuses, or all of them without a status filter. Listing them by
+// status, rather than excluding terminal ones, lets the query use the status indexes.
+func activeStatuses(statuses []models.JobStatus) ([]models.JobStatus, error) {
+	if len(statuses) == 0 {
+		return models.ActiveJobStatuses(), nil
+	}
+	var active []models.JobStatus
+	for _, status := range statuses {
+		if !status.IsTerminal() {
+			active = append(active, status)
+		}
+	}
+	if len(active) == 0 {
+		return nil, &ValidationError{Field: "active_only", Message: "active_only excludes every status in the status filter"}
+	}
+	return active, nil
+}
+
+func isValidJobStatus(status models.JobStatus) bool {
+	switch status {
+	case models.JobStatusPending, models.JobStatusHeld, models.JobStatusAwaitingInput, models.JobStatusProcessing, models.JobStatusAwaitingChildren,
+		models.JobStatusCompleted, models.JobStatusFailed, models.JobStatusCancelling, models.JobStatusCancelled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// maxWebhookRedirects is how many redirects a webhook request follows
+const maxWebhookRedirects = 3
+
+// errWebhookAddress is returned when a webhook would reach an address outside
+// the public internet
+var errWebhookAddress = errors.New("webhook address is not public")
+
+// newWebhookClient returns a client for the user-supplied URLs of alert rules.
+// It only speaks HTTPS and refuses to connect to private, loopback and
+// link-local addresses, so rules cannot reach services inside the deployment.
+// Addresses are checked when dialing, after DNS resolution, so a host name
+// resolving to an internal address is refused as well.
+func newWebhookClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: refuseInternalAddress,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// A proxy would be dialed instead of the webhook, bypassing the check
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxWebhookRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxWebhookRedirects)
+			}
+			return checkWebhookURL(req.URL)
+		},
+	}
+}
+
+// checkWebhookURL refuses webhook URLs not using HTTPS
+func checkWebhookURL(target *url.URL) error {
+	if target.Scheme != "https" || target.Host == "" {
+		return fmt.Errorf("webhook URL %q must be an absolute https URL", target.Redacted())
+	}
+	return nil
+}
+
+// refuseInternalAddress is a dialer control refusing connections to addresses
+// that are not public
+func refuseInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", errWebhookAddress, host)
+	}
+	return nil
+}
+
+// internalRanges are the ranges not covered by the net.IP predicates that
+// still reach hosts inside the deployment
+var internalRanges = []*net.IPNet{
+	// "This network", which Linux routes to the local host
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	// The carrier-grade NAT range, internal to providers
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+	// The NAT64 prefixes, which translate to any IPv4 address, private ones included
+	{IP: net.ParseIP("64:ff9b::"), Mask: net.CIDRMask(96, 128)},
+	{IP: net.ParseIP("64:ff9b:1::"), Mask: net.CIDRMask(48, 128)},
+}
+
+// isPublicIP reports whether ip is reachable on the public internet
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified() &&
+		!inInternalRange(ip)
+}
+
+// inInternalRange reports whether ip is in one of the internalRanges
+func inInternalRange(ip net.IP) bool {
+	for _, internal := range internalRanges {
+		if internal.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
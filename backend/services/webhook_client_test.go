@@ -0,0 +1,54 @@
+package services
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"100.63.255.255", true},
+		{"100.128.0.1", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"224.0.0.1", false},
+		{"::", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fc00::1", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"64:ff9b:1::a00:1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	// Receivers compute the HMAC-SHA256 of "<timestamp>.<body>" with their secret
+	got := signWebhook("whsec_test", "1700000000", []byte(`{"event":"job.completed"}`))
+	want := "51be9920773f454007b9aaf2ef84578604f287a1ad8b1cf6918458c66aac6bd8"
+	if got != want {
+		t.Errorf("signWebhook() = %s, want %s", got, want)
+	}
+}
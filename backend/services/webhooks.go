@@ -0,0 +1,112 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fullstack-assessment/backend/metrics"
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Headers of signed webhook requests. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the rule's webhook secret, so receivers can
+// reject forged requests and, by checking the timestamp, replayed ones.
+const (
+	webhookSignatureHeader = "X-Webhook-Signature"
+	webhookTimestampHeader = "X-Webhook-Timestamp"
+	webhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+var webhookDeliveries = metrics.NewCounter("webhook_deliveries_total", "Webhook delivery attempts by outcome.", "outcome")
+
+// WebhookSender delivers signed payloads to alert rule webhooks and records every attempt
+type WebhookSender struct {
+	deliveries repositories.WebhookDeliveriesRepository
+	client     *http.Client
+}
+
+// NewWebhookSender creates a new webhook sender
+func NewWebhookSender(deliveries repositories.WebhookDeliveriesRepository) *WebhookSender {
+	return &WebhookSender{
+		deliveries: deliveries,
+		client:     newWebhookClient(10 * time.Second),
+	}
+}
+
+// Send posts a payload to the rule's webhook and records the attempt. A failed
+// delivery is reported in the returned record; the error is only set if the
+// attempt could not be recorded.
+func (s *WebhookSender) Send(ctx context.Context, rule *models.AlertRule, payload []byte, redeliveryOf *primitive.ObjectID) (*models.WebhookDelivery, error) {
+	// The ID is assigned up front so it can be sent in the delivery header
+	delivery := &models.WebhookDelivery{
+		ID:           primitive.NewObjectID(),
+		RuleID:       rule.ID,
+		Tenant:       rule.Tenant,
+		URL:          rule.WebhookURL,
+		Payload:      string(payload),
+		RedeliveryOf: redeliveryOf,
+		DeliveredAt:  time.Now(),
+	}
+
+	statusCode, err := s.post(ctx, rule, delivery.ID, payload)
+	delivery.LatencyMs = time.Since(delivery.DeliveredAt).Milliseconds()
+	delivery.StatusCode = statusCode
+	if err != nil {
+		delivery.Error = err.Error()
+		webhookDeliveries.Inc("failed")
+	} else {
+		delivery.Succeeded = true
+		webhookDeliveries.Inc("succeeded")
+	}
+
+	if err := s.deliveries.Create(ctx, delivery); err != nil {
+		return delivery, fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+func (s *WebhookSender) post(ctx context.Context, rule *models.AlertRule, id primitive.ObjectID, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rule.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	if err := checkWebhookURL(req.URL); err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookDeliveryHeader, id.Hex())
+	if rule.WebhookSecret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookSignatureHeader, "sha256="+signWebhook(rule.WebhookSecret, timestamp, payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook returns the hex HMAC-SHA256 signature of a payload sent at timestamp
+func signWebhook(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fullstack-assessment/backend/models"
+	"github.com/fullstack-assessment/backend/repositories"
+)
+
+// workerHeartbeatTimeout is how long after its last heartbeat a worker is
+// still considered active. Workers send a heartbeat every 10 seconds.
+const workerHeartbeatTimeout = 30 * time.Second
+
+// WorkersService interface defines the methods for fleet visibility
+type WorkersService interface {
+	ListActiveWorkers(ctx context.Context) ([]models.WorkerInfo, error)
+	// DrainStatus reports the jobs running on the active workers and the
+	// backlog left, for deployments waiting to stop workers
+	DrainStatus(ctx context.Context) (*models.DrainStatus, error)
+}
+
+type workersService struct {
+	repo   repositories.WorkersRepository
+	jobs   repositories.JobsRepository
+	queues *QueueMonitor
+}
+
+// NewWorkersService creates a new workers service
+func NewWorkersService(repo repositories.WorkersRepository, jobs repositories.JobsRepository, queues *QueueMonitor) WorkersService {
+	return &workersService{
+		repo:   repo,
+		jobs:   jobs,
+		queues: queues,
+	}
+}
+
+// ListActiveWorkers retrieves the workers that sent a heartbeat recently
+func (s *workersService) ListActiveWorkers(ctx context.Context) ([]models.WorkerInfo, error) {
+	workers, err := s.repo.ListSeenSince(ctx, time.Now().Add(-workerHeartbeatTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workers: %w", err)
+	}
+
+	if workers == nil {
+		workers = []models.WorkerInfo{}
+	}
+
+	return workers, nil
+}
+
+// DrainStatus estimates when each running job finishes from the recent p90
+// duration of its type, falling back to the average, and when each queue is
+// empty from the recent throughput of its type
+func (s *workersService) DrainStatus(ctx context.Context) (*models.DrainStatus, error) {
+	workers, err := s.ListActiveWorkers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	remaining, err := s.remainingRuntimes(ctx, workers, now)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.DrainStatus{
+		Workers:   make([]models.WorkerDrain, 0, len(workers)),
+		Queues:    s.queues.Depths(),
+		CheckedAt: now,
+	}
+	for _, worker := range workers {
+		drain := models.WorkerDrain{
+			ID:            worker.ID,
+			Hostname:      worker.Hostname,
+			Draining:      worker.Draining,
+			InFlight:      len(worker.InFlightJobs),
+			InFlightJobs:  worker.InFlightJobs,
+			LastHeartbeat: worker.LastHeartbeat,
+		}
+		if drain.InFlightJobs == nil {
+			drain.InFlightJobs = []string{}
+		}
+		for _, jobID := range worker.InFlightJobs {
+			eta, ok := remaining[jobID]
+			if !ok {
+				// Jobs no longer processing finished since the heartbeat
+				continue
+			}
+			drain.EtaSeconds = maxEta(drain.EtaSeconds, eta)
+		}
+		status.InFlight += drain.InFlight
+		status.InFlightEtaSeconds = maxEta(status.InFlightEtaSeconds, drain.EtaSeconds)
+		status.Workers = append(status.Workers, drain)
+	}
+
+	status.EtaSeconds = status.InFlightEtaSeconds
+	for _, depth := range status.Queues {
+		status.Lag += depth.Lag
+		status.EtaSeconds = maxEta(status.EtaSeconds, depth.EstimatedWaitSeconds)
+	}
+
+	return status, nil
+}
+
+// remainingRuntimes returns the seconds each in-flight job is expected to run
+// for, keyed by job ID, or -1 when its type has no recent durations. Jobs
+// running past their expected duration are expected to finish any moment.
+func (s *workersService) remainingRuntimes(ctx context.Context, workers []models.WorkerInfo, now time.Time) (map[string]int64, error) {
+	var ids []models.JobID
+	for _, worker := range workers {
+		for _, jobID := range worker.InFlightJobs {
+			if id, err := models.ParseJobID(jobID); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	remaining := make(map[string]int64, len(ids))
+	if len(ids) == 0 {
+		return remaining, nil
+	}
+
+	jobs, err := s.jobs.ListProcessing(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list in-flight jobs: %w", err)
+	}
+
+	expected := make(map[models.JobType]time.Duration)
+	stats, err := s.jobs.RecentDurationStats(ctx)
+	if err != nil {
+		// Without durations every estimate is unknown, which is still worth reporting
+		log.Printf("Failed to read recent job durations: %v", err)
+	}
+	for _, stat := range stats {
+		durationMs := stat.AvgDurationMs
+		if stat.P90DurationMs != nil {
+			durationMs = *stat.P90DurationMs
+		}
+		if durationMs > 0 {
+			expected[stat.JobType] = time.Duration(durationMs * float64(time.Millisecond))
+		}
+	}
+
+	for _, job := range jobs {
+		duration, ok := expected[job.JobType]
+		if !ok {
+			remaining[job.ID.String()] = -1
+			continue
+		}
+		startedAt := job.UpdatedAt
+		if job.StartedAt != nil {
+			startedAt = *job.StartedAt
+		}
+		left := startedAt.Add(duration).Sub(now)
+		if left < 0 {
+			left = 0
+		}
+		remaining[job.ID.String()] = int64(left.Round(time.Second).Seconds())
+	}
+	return remaining, nil
+}
+
+// maxEta returns the later of two estimates, where -1 is unknown and wins
+func maxEta(a, b int64) int64 {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	if b > a {
+		return b
+	}
+	return a
+}
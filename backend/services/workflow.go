@@ -0,0 +1,60 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/fullstack-assessment/backend/models"
+)
+
+// Limits of the workflows defined by config.steps
+const (
+	maxWorkflowSteps          = 50
+	maxWorkflowStepNameLength = 100
+)
+
+// validateWorkflow checks the workflow an analyze job defines in config.steps:
+// a list of steps, each an object with a unique name and an optional config
+// object. The worker runs the steps in order.
+func validateWorkflow(jobType models.JobType, config map[string]interface{}) error {
+	raw, ok := config["steps"]
+	if !ok {
+		return nil
+	}
+	if jobType != models.JobTypeAnalyze {
+		return &ValidationError{Field: "config.steps", Message: "workflows are only supported by analyze jobs"}
+	}
+
+	steps, ok := raw.([]interface{})
+	if !ok || len(steps) == 0 {
+		return &ValidationError{Field: "config.steps", Message: "steps must be a non-empty list"}
+	}
+	if len(steps) > maxWorkflowSteps {
+		return &ValidationError{Field: "config.steps", Message: fmt.Sprintf("a workflow may have at most %d steps", maxWorkflowSteps)}
+	}
+
+	names := make(map[string]bool, len(steps))
+	for i, item := range steps {
+		field := fmt.Sprintf("config.steps[%d]", i)
+		step, ok := item.(map[string]interface{})
+		if !ok {
+			return &ValidationError{Field: field, Message: "step must be an object"}
+		}
+		name, _ := step["name"].(string)
+		if name == "" {
+			return &ValidationError{Field: field + ".name", Message: "step name is required"}
+		}
+		if len(name) > maxWorkflowStepNameLength {
+			return &ValidationError{Field: field + ".name", Message: fmt.Sprintf("step name must not exceed %d characters", maxWorkflowStepNameLength)}
+		}
+		if names[name] {
+			return &ValidationError{Field: field + ".name", Message: fmt.Sprintf("duplicate step name %q", name)}
+		}
+		names[name] = true
+		if stepConfig, ok := step["config"]; ok {
+			if _, ok := stepConfig.(map[string]interface{}); !ok {
+				return &ValidationError{Field: field + ".config", Message: "step config must be an object"}
+			}
+		}
+	}
+	return nil
+}
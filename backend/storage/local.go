@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localStore reads artifacts from a directory shared with the worker
+type localStore struct {
+	dir string
+}
+
+// NewLocalStore creates an artifact store backed by a local directory
+func NewLocalStore(dir string) ArtifactStore {
+	return &localStore{dir: dir}
+}
+
+func (s *localStore) Name() string {
+	return "local"
+}
+
+// PresignGet always returns an empty URL; local artifacts are streamed through the API
+func (s *localStore) PresignGet(key string, expires time.Duration) (string, error) {
+	return "", nil
+}
+
+func (s *localStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, ok := s.path(key)
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// Put writes the content to a temporary file first so readers never see a partial file
+func (s *localStore) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	path, ok := s.path(key)
+	if !ok {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := io.Copy(file, io.LimitReader(content, size)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// path resolves a key inside the artifact directory, guarding against keys escaping it
+func (s *localStore) path(key string) (string, bool) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if rel, err := filepath.Rel(s.dir, path); err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return path, true
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrObjectNotFound is returned when an artifact does not exist in storage
+var ErrObjectNotFound = errors.New("object not found")
+
+// ArtifactStore provides access to job artifacts uploaded by the worker and
+// stores the input files uploaded for jobs
+type ArtifactStore interface {
+	// Name identifies the storage backend
+	Name() string
+	// PresignGet returns a time-limited download URL, or an empty string if the
+	// store cannot issue URLs and content must be served through Open instead
+	PresignGet(key string, expires time.Duration) (string, error)
+	// Open returns a reader for the artifact content
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Put stores size bytes of content under key
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+}
+
+// Config holds the artifact storage settings
+type Config struct {
+	Backend         string
+	Dir             string
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// New creates the artifact store selected by the config
+func New(config Config) (ArtifactStore, error) {
+	switch config.Backend {
+	case "", "local":
+		return NewLocalStore(config.Dir), nil
+	case "s3", "gcs":
+		if config.Bucket == "" {
+			return nil, fmt.Errorf("%s storage requires a bucket", config.Backend)
+		}
+		endpoint := config.Endpoint
+		region := config.Region
+		if config.Backend == "gcs" {
+			// GCS is accessed through its S3-compatible XML API with HMAC keys
+			if endpoint == "" {
+				endpoint = "https://storage.googleapis.com"
+			}
+			if region == "" {
+				region = "auto"
+			}
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return &s3Store{
+			name:            config.Backend,
+			endpoint:        strings.TrimRight(endpoint, "/"),
+			region:          region,
+			bucket:          config.Bucket,
+			accessKeyID:     config.AccessKeyID,
+			secretAccessKey: config.SecretAccessKey,
+			client:          &http.Client{Timeout: 10 * time.Minute},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown artifact storage backend %q", config.Backend)
+	}
+}
@@ -59,6 +59,12 @@ const jobs = [
 // Insert jobs
 db.jobs.insertMany(jobs);
 
+// Full-text search index (also created by the backend on startup)
+db.jobs.createIndex(
+  { name: "text", error_message: "text" },
+  { name: "jobs_text", weights: { name: 10, error_message: 5 } }
+);
+
 print(`Seeded ${jobs.length} jobs into the database.`);
 
 // Show the jobs
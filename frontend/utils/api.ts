@@ -8,6 +8,22 @@ const api = axios.create({
   },
 });
 
+// Access token of the signed-in dashboard user, sent as a bearer token
+let accessToken: string | null = null;
+
+// Set by the single sign-on flow after login and token refresh; null signs out
+export const setAccessToken = (token: string | null) => {
+  accessToken = token;
+};
+
+// Request interceptor attaching the user's access token
+api.interceptors.request.use((config) => {
+  if (accessToken) {
+    config.headers.Authorization = `Bearer ${accessToken}`;
+  }
+  return config;
+});
+
 // Response interceptor for error handling
 api.interceptors.response.use(
   (response) => response,
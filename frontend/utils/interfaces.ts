@@ -4,7 +4,10 @@ export type JobType = 'process' | 'analyze' | 'export';
 // Job statuses
 export type JobStatus =
   | 'pending'
+  | 'held'
+  | 'awaiting_input'
   | 'processing'
+  | 'awaiting_children'
   | 'completed'
   | 'failed'
   | 'cancelling'
@@ -17,10 +20,372 @@ export interface Job {
   jobType: JobType;
   status: JobStatus;
   config?: Record<string, unknown>;
+  // Set when the config was too large to keep on the job and is stored in GridFS
+  configRef?: BlobRef;
+  configHash?: string;
+  affinityKey?: string;
+  priority?: 'high';
+  prioritizedAt?: string;
+  // Set on jobs routed to workers running the canary executor version
+  canary?: boolean;
+  notify?: NotifyConfig;
   errorMessage?: string;
   retryCount: number;
+  retryPolicy?: RetryPolicy;
+  // Set while the job waits for an automatic retry
+  retryAt?: string;
+  // Set while the job waits for the run it was scheduled for
+  scheduledAt?: string;
+  cancelledBy?: string;
+  cancellationReason?: string;
+  cancelRequestedAt?: string;
+  cancelAttempts?: number;
+  // Set when a cancellation was withdrawn because a worker was still running the job
+  cancellationRevert?: CancellationRevert;
+  attempts?: JobAttempt[];
+  artifact?: Artifact;
+  // Executor output, shaped by the job type's result schema
+  result?: Record<string, unknown>;
+  // Uploaded input of jobs created with hold_for_input
+  input?: JobInput;
+  // Last progress saved by the executor; a retried job resumes from it
+  checkpoint?: JobCheckpoint;
+  // Progress of the workflow an analyze job defines in config.steps
+  steps?: JobStep[];
+  // Set on child jobs spawned by another job's executor
+  parentId?: string;
+  children?: JobChildren;
+  groupId?: string;
+  tenant?: string;
+  // Set while the job is held for debugging, see JobHold
+  hold?: JobHold;
+  sloMs?: number;
+  sloBreached?: boolean;
+  // The job is failed if it has not completed by then
+  deadlineAt?: string;
+  startedAt?: string;
+  finishedAt?: string;
+  durationMs?: number;
   createdAt: string;
   updatedAt: string;
+  // Set on the create response when the job was accepted into an overloaded queue
+  warning?: QueueWarning;
+  // Computed for pending jobs fetched individually
+  queuePosition?: number;
+  estimatedStartAt?: string;
+  // Set on jobs fetched from the archive after leaving the jobs collection
+  archived?: boolean;
+  // Sent as If-Match on cancel and prioritize to fail with 412 if the job changed since
+  version?: string;
+}
+
+// Input file uploaded for a job
+export interface JobInput {
+  filename: string;
+  storage: string;
+  key: string;
+  contentType: string;
+  size: number;
+  checksum: string;
+  uploadedAt: string;
+}
+
+// Locates a job value stored in GridFS
+export interface BlobRef {
+  id: string;
+  size: number;
+}
+
+// A debugging hold: no automatic retries, requeues or republishing until released
+export interface JobHold {
+  by: string;
+  reason?: string;
+  at: string;
+}
+
+// Why a job's cancellation was withdrawn
+export interface CancellationRevert {
+  reason: string;
+  at: string;
+}
+
+// Executor progress saved on a job
+export interface JobCheckpoint {
+  state: Record<string, unknown>;
+  attempt: number;
+  savedAt: string;
+}
+
+export type JobStepStatus = 'pending' | 'running' | 'completed' | 'failed';
+
+// A step of an analyze job's workflow; retries resume at the first step not completed
+export interface JobStep {
+  name: string;
+  status: JobStepStatus;
+  attempt?: number;
+  startedAt?: string;
+  finishedAt?: string;
+  durationMs?: number;
+  errorMessage?: string;
+}
+
+// Child jobs spawned from a job, counted by terminal status once the parent completes
+export interface JobChildren {
+  total: number;
+  completed: number;
+  failed: number;
+  cancelled: number;
+}
+
+// Response of GET /api/v1/jobs/{id}/children
+export interface JobChildrenResponse {
+  parentId: string;
+  children: Job[];
+}
+
+// Aggregate status of the jobs in a group
+export type GroupStatus =
+  | 'empty'
+  | 'active'
+  | 'completed'
+  | 'failed'
+  | 'cancelling'
+  | 'cancelled';
+
+// Batch of jobs tracked and cancelled as one unit
+export interface JobGroup {
+  id: string;
+  name: string;
+  createdBy: string;
+  cancelledBy?: string;
+  cancelledAt?: string;
+  createdAt: string;
+  updatedAt: string;
+  status: GroupStatus;
+  total: number;
+  counts: Partial<Record<JobStatus, number>>;
+  // Share of the group's jobs in a terminal status, from 0 to 1
+  progress: number;
+}
+
+// Outcome of one element of a bulk request, with the status it would have had on its own
+export interface BatchItem<T> {
+  index: number;
+  status: number;
+  id?: string;
+  // Set on failed elements; field names the invalid field of a validation error
+  code?: string;
+  error?: string;
+  field?: string;
+  data?: T;
+}
+
+// Multi-status payload of bulk requests, answered with 207 when any element failed
+export interface BatchResult<T> {
+  succeeded: number;
+  failed: number;
+  items: BatchItem<T>[];
+}
+
+// Response of POST /api/v1/groups/{id}/jobs/bulk and POST /api/v1/groups/{id}/cancel
+export interface GroupBatchResponse extends BatchResult<Job> {
+  group: JobGroup;
+}
+
+// Backlog of one job type
+export interface QueueDepth {
+  jobType: JobType;
+  pending: number;
+  lag: number;
+  throughputPerMinute: number;
+  estimatedWaitSeconds: number;
+  checkedAt: string;
+}
+
+// Jobs a worker is running; etaSeconds is -1 when it cannot be estimated
+export interface WorkerDrain {
+  id: string;
+  hostname: string;
+  draining: boolean;
+  inFlight: number;
+  inFlightJobs: string[];
+  lastHeartbeat: string;
+  etaSeconds: number;
+}
+
+// Work left before workers can be stopped without interrupting jobs
+export interface DrainStatus {
+  workers: WorkerDrain[];
+  queues: QueueDepth[];
+  inFlight: number;
+  lag: number;
+  inFlightEtaSeconds: number;
+  etaSeconds: number;
+  checkedAt: string;
+}
+
+// Warning attached to a job accepted while its queue is over capacity
+export interface QueueWarning {
+  message: string;
+  queue: QueueDepth;
+}
+
+// How often a failed job may be retried and when its failures reach the DLQ
+export interface RetryPolicy {
+  maxRetries: number;
+  autoRetry: boolean;
+  backoff: 'fixed' | 'linear' | 'exponential';
+  initialDelayMs: number;
+  maxDelayMs: number;
+  dlq: 'exhausted' | 'always' | 'never';
+}
+
+// Terminal-state notification settings
+export interface NotifyConfig {
+  email?: string[];
+  slackWebhookUrl?: string;
+  on?: JobStatus[];
+  template?: string;
+}
+
+// Output file produced by an export job
+export interface Artifact {
+  storage: string;
+  key: string;
+  url: string;
+  contentType: string;
+  size: number;
+  checksum: string;
+  createdAt: string;
+}
+
+// Job attempt outcomes
+export type AttemptOutcome = 'running' | 'completed' | 'failed' | 'cancelled';
+
+// A single processing attempt of a job
+export interface JobAttempt {
+  number: number;
+  workerId: string;
+  startedAt: string;
+  finishedAt?: string;
+  outcome: AttemptOutcome;
+  errorMessage?: string;
+  executorVersion?: string;
+  // Build of the worker that ran the attempt
+  workerVersion?: string;
+  workerCommit?: string;
+  environment?: AttemptEnvironment;
+}
+
+// Execution environment of the worker that ran an attempt
+export interface AttemptEnvironment {
+  host: string;
+  containerId?: string;
+  region?: string;
+  goOs: string;
+  goArch: string;
+  gomaxprocs: number;
+  // Container resource limits, unset when unlimited
+  cpuLimit?: number;
+  memoryLimitBytes?: number;
+}
+
+// A line of output captured during a job attempt
+export interface JobLogLine {
+  jobId: string;
+  attempt: number;
+  seq: number;
+  message: string;
+  timestamp: string;
+}
+
+// Job logs response; pass nextSeq as `after` to continue reading
+export interface JobLogs {
+  jobId: string;
+  attempt: number;
+  lines: JobLogLine[];
+  nextSeq: number;
+  hasMore: boolean;
+  running: boolean;
+}
+
+// A job event streamed by GET /api/v1/jobs/events; previousStatus is omitted
+// for created jobs and when the backend did not see the job before
+export interface JobWatchEvent {
+  jobId: string;
+  jobType: JobType;
+  status: JobStatus;
+  previousStatus?: JobStatus;
+  at: string;
+}
+
+// An operator note attached to a job
+export interface JobComment {
+  id: string;
+  jobId: string;
+  author: string;
+  text: string;
+  createdAt: string;
+}
+
+// Filter and sort a saved view applies to the job list
+export interface ViewFilter {
+  status?: JobStatus[];
+  jobType?: JobType[];
+  createdWithin?: string;
+  // A sortable field, prefixed with "-" for descending order
+  sort?: string;
+}
+
+// A named job list filter shared within a tenant; pass its name as `view` when listing jobs
+export interface JobView {
+  id: string;
+  name: string;
+  filter: ViewFilter;
+  createdBy: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+// A Kafka message a consumer could not decode and skipped
+export interface PoisonMessage {
+  id: string;
+  topic: string;
+  partition: number;
+  offset: number;
+  consumer: string;
+  source: string;
+  key?: string;
+  payload: string;
+  truncated?: boolean;
+  error: string;
+  count: number;
+  firstSeenAt: string;
+  lastSeenAt: string;
+}
+
+// A page of poison messages; pass nextCursor as the cursor to read the next page
+export interface PoisonMessagePage {
+  messages: PoisonMessage[];
+  nextCursor?: string;
+}
+
+// What one retention rule removes, or would remove; jobType and status are * when the rule matches any
+export interface RetentionRuleReport {
+  jobType: JobType | '*';
+  status: JobStatus | '*';
+  action: 'delete' | 'archive';
+  maxAge: string;
+  cutoff: string;
+  jobs: number;
+}
+
+// Outcome of applying the retention rules, or with dryRun, what applying them now would remove
+export interface RetentionReport {
+  dryRun: boolean;
+  at: string;
+  rules: RetentionRuleReport[];
+  total: number;
 }
 
 // API response wrapper
@@ -32,19 +397,168 @@ export interface ApiResponse<T> {
 
 // Create job request
 export interface CreateJobRequest {
+  // Client-generated ObjectID or UUIDv7; retrying with the same ID returns the original job
+  id?: string;
   name: string;
   job_type: string;
   config?: Record<string, unknown>;
+  affinity_key?: string;
+  // Keep the job out of the queue until its input is uploaded
+  hold_for_input?: boolean;
+  // RFC 3339 time after which an unfinished job is failed
+  deadline_at?: string;
+  // RFC 3339 time to keep the job pending until
+  run_at?: string;
+  notify?: {
+    email?: string[];
+    slack_webhook_url?: string;
+    on?: JobStatus[];
+    template?: string;
+  };
+  retry?: {
+    max_retries?: number;
+    auto_retry?: boolean;
+    backoff?: RetryPolicy['backoff'];
+    initial_delay?: string;
+    max_delay?: string;
+    dlq?: RetryPolicy['dlq'];
+  };
+}
+
+// Cancel job request
+export interface CancelJobRequest {
+  reason?: string;
 }
 
 // List jobs response
 export interface JobsResponse {
   jobs: Job[];
-  total: number;
+  total?: number;
+  hasMore: boolean;
   page: number;
   limit: number;
 }
 
+// Fields of a job shown in dashboard lists, read from a projection that
+// trails the jobs by a few seconds
+export interface JobSummary {
+  id: string;
+  name: string;
+  jobType: JobType;
+  status: JobStatus;
+  priority?: 'high';
+  tenant?: string;
+  errorMessage?: string;
+  retryCount: number;
+  parentId?: string;
+  groupId?: string;
+  held?: boolean;
+  sloBreached?: boolean;
+  startedAt?: string;
+  finishedAt?: string;
+  durationMs?: number;
+  deadlineAt?: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+// Job summaries list response
+export interface JobSummariesResponse {
+  jobs: JobSummary[];
+  total?: number;
+  hasMore: boolean;
+  page: number;
+  limit: number;
+}
+
+// Search jobs response; highlighted values wrap matched terms in <em> tags
+export interface SearchJobsResponse {
+  results: {
+    job: Job;
+    score: number;
+    highlights?: {
+      name?: string;
+      errorMessage?: string;
+    };
+  }[];
+  query: string;
+}
+
+// SLO compliance of finished jobs of one type
+export interface SLOSummary {
+  jobType: JobType;
+  sloMs: number;
+  finished: number;
+  breached: number;
+  breachRate: number;
+  avgDurationMs: number;
+  maxDurationMs: number;
+  // Durations of the jobs finished within the workers' rolling stats window
+  recent?: JobTypeStats;
+}
+
+// Rolling duration summary of one job type; percentiles are omitted when no job finished in the window
+export interface JobTypeStats {
+  jobType: JobType;
+  finished: number;
+  windowMs: number;
+  avgDurationMs?: number;
+  maxDurationMs?: number;
+  p50DurationMs?: number;
+  p90DurationMs?: number;
+  p95DurationMs?: number;
+  p99DurationMs?: number;
+  updatedAt: string;
+}
+
+// Job stats response
+export interface JobStats {
+  byStatus: Partial<Record<JobStatus, number>>;
+  slo: SLOSummary[];
+}
+
+// Failures of one job type within the dashboard window
+export interface TypeFailures {
+  jobType: JobType;
+  finished: number;
+  failed: number;
+}
+
+// Dashboard summary response; dlqDepth is null while Kafka is unreachable and
+// oldestPendingSeconds is null when no job is pending
+export interface Dashboard {
+  byStatus: Partial<Record<JobStatus, number>>;
+  throughput: {
+    window: string;
+    completed: number;
+    failed: number;
+    perHour: number;
+  };
+  topFailing: TypeFailures[];
+  dlqDepth: number | null;
+  activeWorkers: number;
+  oldestPendingSeconds: number | null;
+  generatedAt: string;
+}
+
+// Job throughput in one time bucket; durations are omitted when no job finished in it
+export interface TimeseriesPoint {
+  start: string;
+  created: number;
+  completed: number;
+  failed: number;
+  errorRate: number;
+  p50DurationMs?: number;
+  p95DurationMs?: number;
+}
+
+// Job throughput timeseries response, oldest bucket first
+export interface JobTimeseries {
+  interval: string;
+  window: string;
+  buckets: TimeseriesPoint[];
+}
+
 // Helper to check if a job can be cancelled
 export function canBeCancelled(job: Job): boolean {
   return job.status === 'pending' || job.status === 'processing';
@@ -52,7 +566,7 @@ export function canBeCancelled(job: Job): boolean {
 
 // Helper to check if a job can be retried
 export function canBeRetried(job: Job): boolean {
-  return job.status === 'failed' && job.retryCount < 3;
+  return job.status === 'failed' && job.retryCount < (job.retryPolicy?.maxRetries ?? 3);
 }
 
 // Helper to check if a job is in a terminal state
@@ -1,5 +1,5 @@
 import api from './api';
-import { Job, JobsResponse, ApiResponse } from './interfaces';
+import { Job, JobsResponse, JobSummariesResponse, ApiResponse } from './interfaces';
 
 /**
  * Fetch a paginated list of jobs
@@ -21,6 +21,26 @@ export async function fetchJobs(
   return response.data.data!;
 }
 
+/**
+ * Fetch a paginated list of job summaries, the fields dashboard lists show
+ * @param page - Page number (1-indexed)
+ * @param limit - Number of items per page (default: 10, max: 100)
+ */
+export async function fetchJobSummaries(
+  page: number = 1,
+  limit: number = 10
+): Promise<JobSummariesResponse> {
+  const response = await api.get<ApiResponse<JobSummariesResponse>>('/api/v1/jobs/summaries', {
+    params: { page, limit },
+  });
+
+  if (response.data.status === 'error') {
+    throw new Error(response.data.error || 'Failed to fetch job summaries');
+  }
+
+  return response.data.data!;
+}
+
 /**
  * Fetch a single job by ID
  * @param id - Job ID
@@ -38,5 +58,6 @@ export async function fetchJob(id: string): Promise<Job> {
 // Query keys for TanStack Query
 export const queryKeys = {
   jobs: (page: number, limit: number) => ['jobs', { page, limit }] as const,
+  jobSummaries: (page: number, limit: number) => ['jobSummaries', { page, limit }] as const,
   job: (id: string) => ['job', id] as const,
 };
@@ -0,0 +1,5 @@
+module github.com/fullstack-assessment/jobid
+
+go 1.21
+
+require go.mongodb.org/mongo-driver v1.13.1
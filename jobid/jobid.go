@@ -0,0 +1,179 @@
+// Package jobid generates, parses and stores job IDs the same way in the
+// backend and the workers: the hex form of an ObjectID or a UUIDv7 string.
+package jobid
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Strategy selects how the IDs of new jobs are generated
+type Strategy string
+
+const (
+	// ObjectIDs generates MongoDB ObjectIDs, stored as such
+	ObjectIDs Strategy = "objectid"
+	// UUIDv7s generates time-ordered UUIDv7 strings, which clients can also
+	// generate themselves and which carry over to stores without ObjectIDs
+	UUIDv7s Strategy = "uuidv7"
+)
+
+// Valid reports whether the strategy is known
+func (s Strategy) Valid() bool {
+	return s == ObjectIDs || s == UUIDv7s
+}
+
+// ID identifies a job: the hex form of an ObjectID or a UUIDv7 string. Both
+// kinds can be stored side by side, so the strategy can change while jobs
+// created under the other one are still around.
+type ID string
+
+// New generates the ID of a new job with the given strategy. It fails only
+// when no random bits can be read for a UUIDv7.
+func New(strategy Strategy) (ID, error) {
+	if strategy == UUIDv7s {
+		return newUUIDv7(time.Now())
+	}
+	return FromObjectID(primitive.NewObjectID()), nil
+}
+
+// FromObjectID returns the ID of a job identified by an ObjectID
+func FromObjectID(id primitive.ObjectID) ID {
+	return ID(id.Hex())
+}
+
+// Parse reads a job ID from its string form, accepting ObjectIDs and UUIDv7s
+// in any letter case
+func Parse(value string) (ID, error) {
+	id := ID(strings.ToLower(value))
+	if id.isObjectID() || id.isUUIDv7() {
+		return id, nil
+	}
+	return "", fmt.Errorf("%q is neither an ObjectID nor a UUIDv7", value)
+}
+
+// String returns the ID as it appears in URLs, messages and logs
+func (id ID) String() string {
+	return string(id)
+}
+
+// IsZero reports whether the ID is unset
+func (id ID) IsZero() bool {
+	return id == ""
+}
+
+// ObjectID returns the ObjectID of an ID generated by the objectid strategy
+func (id ID) ObjectID() (primitive.ObjectID, bool) {
+	if !id.isObjectID() {
+		return primitive.NilObjectID, false
+	}
+	objectID, err := primitive.ObjectIDFromHex(string(id))
+	return objectID, err == nil
+}
+
+// MarshalBSONValue stores ObjectID job IDs as ObjectIDs and the others as
+// strings, so filters on _id and job_id match whichever form a job has
+func (id ID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if objectID, ok := id.ObjectID(); ok {
+		return bson.MarshalValue(objectID)
+	}
+	return bson.MarshalValue(string(id))
+}
+
+// UnmarshalBSONValue reads a job ID stored as an ObjectID or a string
+func (id *ID) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.ObjectID:
+		*id = FromObjectID(raw.ObjectID())
+	case bsontype.String:
+		*id = ID(raw.StringValue())
+	case bsontype.Null, bsontype.Undefined:
+		*id = ""
+	default:
+		return fmt.Errorf("cannot decode %s into a job ID", t)
+	}
+	return nil
+}
+
+func (id ID) isObjectID() bool {
+	return len(id) == 24 && isLowerHex(string(id))
+}
+
+// isUUIDv7 reports whether the ID is a canonical lowercase UUID of version 7
+// and the RFC 9562 variant
+func (id ID) isUUIDv7() bool {
+	s := string(id)
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return false
+	}
+	if !isLowerHex(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]) {
+		return false
+	}
+	return s[14] == '7' && strings.ContainsRune("89ab", rune(s[19]))
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// uuidv7Clock keeps the UUIDv7s generated in one process ordered: rand_a
+// holds a counter, started at a random value each millisecond and incremented
+// for every ID generated in that millisecond, as in RFC 9562 method 1
+var uuidv7Clock struct {
+	sync.Mutex
+	millis  int64
+	counter uint16
+}
+
+// maxUUIDv7Counter is the largest value of the 12 bit rand_a counter
+const maxUUIDv7Counter = 0xfff
+
+// newUUIDv7 generates a UUIDv7: 48 bits of Unix milliseconds, a 12 bit counter
+// and 62 random bits, so IDs sort by creation time like ObjectIDs
+func newUUIDv7(now time.Time) (ID, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", fmt.Errorf("failed to generate job ID: %w", err)
+	}
+
+	uuidv7Clock.Lock()
+	millis := now.UnixMilli()
+	if millis > uuidv7Clock.millis {
+		// Starting below half the range leaves room for the IDs that follow
+		uuidv7Clock.millis = millis
+		uuidv7Clock.counter = binary.BigEndian.Uint16(b[6:8]) & (maxUUIDv7Counter >> 1)
+	} else if uuidv7Clock.counter < maxUUIDv7Counter {
+		// The same millisecond, or the clock went back
+		uuidv7Clock.counter++
+	} else {
+		// The counter is exhausted, so borrow the next millisecond
+		uuidv7Clock.millis++
+		uuidv7Clock.counter = 0
+	}
+	millis, counter := uuidv7Clock.millis, uuidv7Clock.counter
+	uuidv7Clock.Unlock()
+
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(millis))
+	copy(b[:6], timestamp[2:])
+	binary.BigEndian.PutUint16(b[6:8], 0x7000|counter)
+	b[8] = 0x80 | b[8]&0x3f
+
+	h := hex.EncodeToString(b[:])
+	return ID(h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]), nil
+}
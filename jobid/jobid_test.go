@@ -0,0 +1,164 @@
+package jobid
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// uuidBytes decodes the 16 bytes of a UUID string
+func uuidBytes(t *testing.T, id ID) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(string(id), "-", ""))
+	if err != nil || len(b) != 16 {
+		t.Fatalf("%q is not a UUID: %v", id, err)
+	}
+	return b
+}
+
+func TestNewUUIDv7(t *testing.T) {
+	resetUUIDv7Clock(t)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	id, err := newUUIDv7(now)
+	if err != nil {
+		t.Fatalf("newUUIDv7() error = %v", err)
+	}
+	b := uuidBytes(t, id)
+
+	if version := b[6] >> 4; version != 7 {
+		t.Errorf("version = %d, want 7", version)
+	}
+	if variant := b[8] >> 6; variant != 0b10 {
+		t.Errorf("variant bits = %02b, want 10", variant)
+	}
+	var millis int64
+	for _, c := range b[:6] {
+		millis = millis<<8 | int64(c)
+	}
+	if millis < now.UnixMilli() {
+		t.Errorf("timestamp = %d, want at least %d", millis, now.UnixMilli())
+	}
+	if !id.isUUIDv7() {
+		t.Errorf("%q is not recognized as a UUIDv7", id)
+	}
+}
+
+// resetUUIDv7Clock forgets the IDs generated so far once the test ends, so
+// the times a test generates at do not carry over to the next
+func resetUUIDv7Clock(t *testing.T) {
+	t.Cleanup(func() {
+		uuidv7Clock.Lock()
+		uuidv7Clock.millis, uuidv7Clock.counter = 0, 0
+		uuidv7Clock.Unlock()
+	})
+}
+
+func TestNewUUIDv7Ordering(t *testing.T) {
+	resetUUIDv7Clock(t)
+	// Far in the future, so no other test has generated IDs at a later time
+	now := time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var previous ID
+	for i := 0; i < 3*maxUUIDv7Counter; i++ {
+		// All in the same millisecond, overflowing the counter
+		id, err := newUUIDv7(now)
+		if err != nil {
+			t.Fatalf("newUUIDv7() error = %v", err)
+		}
+		if id <= previous {
+			t.Fatalf("ID %d in the same millisecond %s sorts before %s", i, id, previous)
+		}
+		previous = id
+	}
+
+	later, err := newUUIDv7(now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("newUUIDv7() error = %v", err)
+	}
+	if later <= previous {
+		t.Errorf("ID of a later millisecond %s sorts before %s", later, previous)
+	}
+
+	// A clock going back does not reorder the IDs
+	earlier, err := newUUIDv7(now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("newUUIDv7() error = %v", err)
+	}
+	if earlier <= later {
+		t.Errorf("ID after the clock went back %s sorts before %s", earlier, later)
+	}
+}
+
+func TestParse(t *testing.T) {
+	generated, err := New(UUIDv7s)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	objectID, err := New(ObjectIDs)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    ID
+		wantErr bool
+	}{
+		{name: "generated UUIDv7", value: generated.String(), want: generated},
+		{name: "generated ObjectID", value: objectID.String(), want: objectID},
+		{name: "upper case UUIDv7", value: "018F3A5C-8B2E-7D41-9C3A-1B2C3D4E5F60", want: "018f3a5c-8b2e-7d41-9c3a-1b2c3d4e5f60"},
+		{name: "upper case ObjectID", value: "65A1B2C3D4E5F60718293A4B", want: "65a1b2c3d4e5f60718293a4b"},
+		{name: "UUIDv4", value: "018f3a5c-8b2e-4d41-9c3a-1b2c3d4e5f60", wantErr: true},
+		{name: "wrong variant", value: "018f3a5c-8b2e-7d41-cc3a-1b2c3d4e5f60", wantErr: true},
+		{name: "no hyphens", value: "018f3a5c8b2e7d419c3a1b2c3d4e5f60", wantErr: true},
+		{name: "not hex", value: "018f3a5c-8b2e-7d41-9c3a-1b2c3d4e5fzz", wantErr: true},
+		{name: "short ObjectID", value: "65a1b2c3d4e5f60718293a4", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) = %q, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Parse(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIDBSONRoundTrip(t *testing.T) {
+	for _, strategy := range []Strategy{ObjectIDs, UUIDv7s} {
+		t.Run(string(strategy), func(t *testing.T) {
+			id, err := New(strategy)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			raw, err := bson.Marshal(bson.M{"_id": id})
+			if err != nil {
+				t.Fatalf("bson.Marshal() error = %v", err)
+			}
+			var decoded struct {
+				ID ID `bson:"_id"`
+			}
+			if err := bson.Unmarshal(raw, &decoded); err != nil {
+				t.Fatalf("bson.Unmarshal() error = %v", err)
+			}
+			if decoded.ID != id {
+				t.Errorf("round trip = %q, want %q", decoded.ID, id)
+			}
+			if _, isObjectID := id.ObjectID(); isObjectID != (strategy == ObjectIDs) {
+				t.Errorf("ObjectID() ok = %v for the %s strategy", isObjectID, strategy)
+			}
+		})
+	}
+}
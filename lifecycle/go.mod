@@ -0,0 +1,3 @@
+module github.com/fullstack-assessment/lifecycle
+
+go 1.21
@@ -0,0 +1,265 @@
+// Package lifecycle starts and stops the components of a service in order:
+// servers, consumers, producers and background loops. Components start after
+// the components they depend on and stop before them, each within its own
+// timeout, so a stuck component cannot hold up the shutdown of the others.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds the start and stop of a component that does not set its own
+const DefaultTimeout = 30 * time.Second
+
+// Component is a part of a service managed by a Group. Every function is optional.
+type Component struct {
+	Name string
+	// DependsOn names the components started before this one and stopped after it
+	DependsOn []string
+	// Start prepares the component and returns once it is ready
+	Start func(ctx context.Context) error
+	// Run runs the component until ctx is cancelled. A component whose Run
+	// returns on its own stops the group.
+	Run func(ctx context.Context) error
+	// Stop releases the component after its Run returned
+	Stop func(ctx context.Context) error
+	// StartTimeout bounds Start, DefaultTimeout if zero
+	StartTimeout time.Duration
+	// StopTimeout bounds waiting for Run to return and Stop together, DefaultTimeout if zero
+	StopTimeout time.Duration
+}
+
+// Loop returns a component running a background loop until it is stopped
+func Loop(name string, run func(ctx context.Context), dependsOn ...string) Component {
+	return Component{
+		Name:      name,
+		DependsOn: dependsOn,
+		Run: func(ctx context.Context) error {
+			run(ctx)
+			return nil
+		},
+	}
+}
+
+// Server returns a component serving HTTP until it is stopped, then shutting
+// down gracefully within timeout
+func Server(name string, server *http.Server, timeout time.Duration, dependsOn ...string) Component {
+	return Component{
+		Name:        name,
+		DependsOn:   dependsOn,
+		StopTimeout: timeout,
+		Run: func(ctx context.Context) error {
+			failed := make(chan error, 1)
+			go func() {
+				log.Printf("Serving %s on %s", name, server.Addr)
+				failed <- server.ListenAndServe()
+			}()
+			select {
+			case err := <-failed:
+				return err
+			case <-ctx.Done():
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), timeoutOr(timeout))
+			defer cancel()
+			return server.Shutdown(shutdownCtx)
+		},
+	}
+}
+
+// Group starts and stops a set of components
+type Group struct {
+	components []Component
+	started    []*running
+
+	done     chan struct{}
+	doneOnce sync.Once
+	// cause is the error of the component whose Run returned on its own
+	cause error
+}
+
+type running struct {
+	Component
+	cancel   context.CancelFunc
+	finished chan struct{}
+	// err is what Run returned, set once finished is closed
+	err error
+}
+
+// New creates an empty group
+func New() *Group {
+	return &Group{done: make(chan struct{})}
+}
+
+// Add adds components to the group. Components without dependencies between
+// them start in the order they were added.
+func (g *Group) Add(components ...Component) {
+	g.components = append(g.components, components...)
+}
+
+// Start starts the components in dependency order. If one fails to start, the
+// ones already started are stopped and its error is returned.
+func (g *Group) Start(ctx context.Context) error {
+	ordered, err := g.order()
+	if err != nil {
+		return err
+	}
+
+	for _, component := range ordered {
+		if component.Start != nil {
+			startCtx, cancel := context.WithTimeout(ctx, timeoutOr(component.StartTimeout))
+			err := component.Start(startCtx)
+			cancel()
+			if err != nil {
+				err = fmt.Errorf("failed to start %s: %w", component.Name, err)
+				return errors.Join(err, g.Stop())
+			}
+		}
+
+		runCtx, cancel := context.WithCancel(context.Background())
+		r := &running{Component: component, cancel: cancel, finished: make(chan struct{})}
+		g.started = append(g.started, r)
+		if component.Run == nil {
+			close(r.finished)
+			continue
+		}
+		go func() {
+			defer close(r.finished)
+			r.err = r.Run(runCtx)
+			if runCtx.Err() == nil {
+				g.finish(r.Name, r.err)
+			}
+		}()
+	}
+	return nil
+}
+
+// Done is closed once a component's Run returns without being stopped
+func (g *Group) Done() <-chan struct{} {
+	return g.done
+}
+
+func (g *Group) finish(name string, err error) {
+	g.doneOnce.Do(func() {
+		if err != nil {
+			g.cause = fmt.Errorf("%s failed: %w", name, err)
+		} else {
+			log.Printf("%s finished", name)
+		}
+		close(g.done)
+	})
+}
+
+// Stop stops the started components in reverse dependency order. A component
+// that does not stop within its timeout is reported and left behind.
+func (g *Group) Stop() error {
+	var errs []error
+	for i := len(g.started) - 1; i >= 0; i-- {
+		r := g.started[i]
+		timeout := timeoutOr(r.StopTimeout)
+		stopCtx, cancel := context.WithTimeout(context.Background(), timeout)
+
+		r.cancel()
+		select {
+		case <-r.finished:
+			if r.err != nil && !errors.Is(r.err, context.Canceled) {
+				errs = append(errs, fmt.Errorf("%s failed while stopping: %w", r.Name, r.err))
+			}
+			if r.Stop != nil {
+				if err := r.Stop(stopCtx); err != nil {
+					errs = append(errs, fmt.Errorf("failed to stop %s: %w", r.Name, err))
+				}
+			}
+		case <-stopCtx.Done():
+			errs = append(errs, fmt.Errorf("%s did not stop within %s", r.Name, timeout))
+		}
+		cancel()
+	}
+	g.started = nil
+	return errors.Join(errs...)
+}
+
+// Run starts the group and stops it once one of signals arrives, ctx is done
+// or a component's Run returns on its own. It returns the error of that
+// component and those of stopping the group.
+func (g *Group) Run(ctx context.Context, signals ...os.Signal) error {
+	if err := g.Start(ctx); err != nil {
+		return err
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, signals...)
+	defer signal.Stop(quit)
+
+	var cause error
+	select {
+	case sig := <-quit:
+		log.Printf("Received %s, shutting down", sig)
+	case <-ctx.Done():
+	case <-g.done:
+		cause = g.cause
+	}
+	return errors.Join(cause, g.Stop())
+}
+
+// order sorts the components so each comes after the components it depends on
+func (g *Group) order() ([]Component, error) {
+	byName := make(map[string]Component, len(g.components))
+	for _, component := range g.components {
+		if _, ok := byName[component.Name]; ok {
+			return nil, fmt.Errorf("component %s is added twice", component.Name)
+		}
+		byName[component.Name] = component
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(g.components))
+	ordered := make([]Component, 0, len(g.components))
+	var visit func(component Component) error
+	visit = func(component Component) error {
+		switch state[component.Name] {
+		case visiting:
+			return fmt.Errorf("components depend on each other through %s", component.Name)
+		case visited:
+			return nil
+		}
+		state[component.Name] = visiting
+		for _, name := range component.DependsOn {
+			dependency, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("%s depends on unknown component %s", component.Name, name)
+			}
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		state[component.Name] = visited
+		ordered = append(ordered, component)
+		return nil
+	}
+
+	for _, component := range g.components {
+		if err := visit(component); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+func timeoutOr(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return DefaultTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,3 @@
+module github.com/fullstack-assessment/notify
+
+go 1.21
@@ -0,0 +1,340 @@
+// Package notify renders job notifications and delivers them over Slack and
+// email, the same way for the backend and the workers, which each notify on
+// the jobs they move to a terminal status.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultTemplate is used when neither a job nor its tenant define a template
+const DefaultTemplate = `Job "{{.Name}}" ({{.ID}}, {{.JobType}}) {{.Status}}{{if .ErrorMessage}}: {{.ErrorMessage}}{{end}}`
+
+// SlackWebhookPrefix starts every Slack incoming webhook URL; notifications are
+// posted to no other address, so they cannot reach internal services
+const SlackWebhookPrefix = "https://hooks.slack.com/"
+
+// sendTimeout bounds the delivery of one notification over all its channels
+const sendTimeout = 30 * time.Second
+
+// Notification is a rendered message ready to be delivered over a channel
+type Notification struct {
+	Subject string
+	Body    string
+}
+
+// Channel delivers notifications to a single destination
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, notification Notification) error
+}
+
+// slackChannel posts notifications to a Slack incoming webhook
+type slackChannel struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func (c *slackChannel) Name() string {
+	return "slack"
+}
+
+func (c *slackChannel) Send(ctx context.Context, notification Notification) error {
+	if !strings.HasPrefix(c.webhookURL, SlackWebhookPrefix) {
+		return fmt.Errorf("refusing to post to %q, which is not a Slack webhook", c.webhookURL)
+	}
+	payload, err := json.Marshal(map[string]string{"text": notification.Body})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SMTPConfig holds the settings for sending email notifications
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// emailChannel sends notifications by email over SMTP
+type emailChannel struct {
+	config SMTPConfig
+	to     []string
+}
+
+func (c *emailChannel) Name() string {
+	return "email"
+}
+
+func (c *emailChannel) Send(ctx context.Context, notification Notification) error {
+	if c.config.Host == "" {
+		return fmt.Errorf("SMTP is not configured")
+	}
+
+	var auth smtp.Auth
+	if c.config.Username != "" {
+		auth = smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)
+	}
+
+	// Addresses are rewritten from their parsed form, and the subject, which
+	// holds the job's name, is encoded, so no value can add a header line
+	to := make([]string, 0, len(c.to))
+	recipients := make([]string, 0, len(c.to))
+	for _, address := range c.to {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", address, err)
+		}
+		to = append(to, parsed.String())
+		recipients = append(recipients, parsed.Address)
+	}
+	from, err := mail.ParseAddress(c.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", c.config.From, err)
+	}
+
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		from.String(), strings.Join(to, ", "), encodeHeader(notification.Subject), notification.Body)
+
+	if err := c.sendMail(ctx, auth, from.Address, recipients, []byte(message)); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("sending email: %w", ctx.Err())
+		}
+		return err
+	}
+	return nil
+}
+
+// sendMail sends a message as smtp.SendMail does, but gives up once ctx ends:
+// the connection is dialed with the context and closed when it is cancelled,
+// and its deadline is the context's, so a stalled server cannot hold a send
+func (c *emailChannel) sendMail(ctx context.Context, auth smtp.Auth, from string, recipients []string, message []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.config.Host, c.config.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.config.Host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("SMTP server %s does not support authentication", c.config.Host)
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(message); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// encodeHeader makes a value safe to use as a header: line breaks are dropped
+// and anything but printable ASCII is encoded as an RFC 2047 word
+func encodeHeader(value string) string {
+	value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+	return mime.QEncoding.Encode("utf-8", value)
+}
+
+// Settings holds the global notification defaults applied to every job
+type Settings struct {
+	SMTP            SMTPConfig
+	Email           []string
+	SlackWebhookURL string
+	On              []string
+}
+
+// Config holds the notifications a tenant sends for all its jobs, or a job for itself
+type Config struct {
+	Email           []string
+	SlackWebhookURL string
+	On              []string
+	Template        string
+}
+
+// Job holds the job fields available to notification templates
+type Job struct {
+	ID           string
+	Name         string
+	JobType      string
+	Status       string
+	ErrorMessage string
+	RetryCount   int
+}
+
+// Notifier builds the channels of notifications from the global settings and
+// the configs of tenants and jobs, and delivers them
+type Notifier struct {
+	settings Settings
+	client   *http.Client
+}
+
+// New creates a notifier applying settings to every job
+func New(settings Settings) *Notifier {
+	return &Notifier{
+		settings: settings,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GlobalChannels builds the channels configured for all jobs
+func (n *Notifier) GlobalChannels() []Channel {
+	var channels []Channel
+	if n.settings.SlackWebhookURL != "" {
+		channels = append(channels, &slackChannel{webhookURL: n.settings.SlackWebhookURL, client: n.client})
+	}
+	if len(n.settings.Email) > 0 {
+		channels = append(channels, &emailChannel{config: n.settings.SMTP, to: n.settings.Email})
+	}
+	return channels
+}
+
+// StatusNotification renders the notification of a job reaching its status
+// and returns it with the channels subscribed to that status, globally and by
+// configs, which are the tenant's followed by the job's; nil configs are
+// skipped. The template of the last config defining one is used. No channels
+// are returned when nobody is subscribed.
+func (n *Notifier) StatusNotification(job Job, configs ...*Config) (Notification, []Channel, error) {
+	var channels []Channel
+	if Subscribed(n.settings.On, job.Status) {
+		channels = append(channels, n.GlobalChannels()...)
+	}
+	tmplText := DefaultTemplate
+	for _, config := range configs {
+		if config == nil {
+			continue
+		}
+		if config.Template != "" {
+			tmplText = config.Template
+		}
+		if !Subscribed(config.On, job.Status) {
+			continue
+		}
+		if config.SlackWebhookURL != "" {
+			channels = append(channels, &slackChannel{webhookURL: config.SlackWebhookURL, client: n.client})
+		}
+		if len(config.Email) > 0 {
+			channels = append(channels, &emailChannel{config: n.settings.SMTP, to: config.Email})
+		}
+	}
+	if len(channels) == 0 {
+		return Notification{}, nil, nil
+	}
+
+	body, err := Render(tmplText, job)
+	if err != nil {
+		return Notification{}, nil, err
+	}
+	return Notification{
+		Subject: fmt.Sprintf("Job %s %s", job.Name, job.Status),
+		Body:    body,
+	}, channels, nil
+}
+
+// Send delivers a notification about a job over each channel, logging
+// failures. It blocks until every channel was tried, so callers not waiting
+// for the delivery run it on its own goroutine.
+func (n *Notifier) Send(jobID string, channels []Channel, notification Notification) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	for _, channel := range channels {
+		if err := channel.Send(ctx, notification); err != nil {
+			log.Printf("Failed to send %s notification for job %s: %v", channel.Name(), jobID, err)
+			continue
+		}
+		log.Printf("Sent %s notification for job %s", channel.Name(), jobID)
+	}
+}
+
+// Subscribed reports whether a status is in the subscription list; an empty
+// list means all terminal statuses
+func Subscribed(on []string, status string) bool {
+	if len(on) == 0 {
+		return status == "completed" || status == "failed" || status == "cancelled"
+	}
+	for _, s := range on {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Render executes a notification template with the fields of job
+func Render(tmplText string, job Job) (string, error) {
+	tmpl, err := template.New("notification").Parse(tmplText)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, job); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Attempt outcomes
+const (
+	AttemptRunning   = "running"
+	AttemptCompleted = "completed"
+	AttemptFailed    = "failed"
+	AttemptCancelled = "cancelled"
+)
+
+// errJobCancelled is returned by startAttempt when the job was cancelled before it started
+var errJobCancelled = errors.New("job was cancelled before processing started")
+
+// errJobSuperseded is returned by startAttempt when the job was republished and
+// the message being processed is no longer the one allowed to run it
+var errJobSuperseded = errors.New("job was republished by a newer message")
+
+// errJobAlreadyProcessed is returned by startAttempt when the message's status
+// change was already applied, typically because the offset commit was lost, or
+// the job has since moved past pending and processing
+var errJobAlreadyProcessed = errors.New("job message was already processed")
+
+// startAttempt marks a job as processing and appends a new running attempt
+// record to its history. It returns the attempt number, errJobCancelled if the
+// job is cancelling or cancelled, errJobSuperseded if the job's dispatch ID
+// does not match the message's, or errJobAlreadyProcessed if the message's
+// offset is in the job's processed-offset ledger or the job is neither pending
+// nor processing. The job is matched by its
+// tenant as well, the full shard key a sharded collection needs to find and modify it.
+func startAttempt(ctx context.Context, collection *mongo.Collection, jobID JobID, tenant, dispatchID, offset, workerID string) (int, error) {
+	now := time.Now()
+
+	filter := jobFilter(tenant, jobID)
+	// Only a queued job, or one a crashed attempt left processing, may start;
+	// a message redelivered after the job finished must not rerun it
+	filter["status"] = bson.M{"$in": []string{StatusPending, StatusProcessing}}
+	// Messages published before the job was republished carry no or an older dispatch ID
+	filter["dispatch_id"] = bson.M{"$exists": false}
+	if offset != "" {
+		filter["processed_offsets"] = bson.M{"$ne": offset}
+	}
+	if dispatchID != "" {
+		filter["dispatch_id"] = dispatchID
+	}
+
+	// Status and attempt are written in one update, numbering the attempt from
+	// the attempts already recorded, so a redelivery or a crash cannot leave a
+	// processing job without its attempt or two attempts with one number
+	attempts := bson.M{"$ifNull": bson.A{"$attempts", bson.A{}}}
+	build := currentBuild()
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status":     StatusProcessing,
+			"started_at": now,
+			"updated_at": now,
+			"attempts": bson.M{"$concatArrays": bson.A{attempts, bson.A{bson.M{
+				"number":           bson.M{"$add": bson.A{bson.M{"$size": attempts}, 1}},
+				"worker_id":        bson.M{"$literal": workerID},
+				"executor_version": bson.M{"$literal": executorVersion},
+				"worker_version":   bson.M{"$literal": build.Version},
+				"worker_commit":    bson.M{"$literal": build.Commit},
+				"environment":      bson.M{"$literal": environment.attemptFields()},
+				"started_at":       now,
+				"outcome":          AttemptRunning,
+			}}}},
+		}}},
+	}
+
+	var after struct {
+		Attempts []struct {
+			Number int `bson:"number"`
+		} `bson:"attempts"`
+	}
+	err := collection.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().
+			SetReturnDocument(options.After).
+			SetProjection(bson.M{"attempts": bson.M{"$slice": -1}}),
+	).Decode(&after)
+	if err == mongo.ErrNoDocuments {
+		return 0, skipReason(ctx, collection, jobID, tenant, offset)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(after.Attempts) == 0 {
+		return 0, errors.New("job has no attempt after starting one")
+	}
+	return after.Attempts[0].Number, nil
+}
+
+// skipReason explains why startAttempt matched no job
+func skipReason(ctx context.Context, collection *mongo.Collection, jobID JobID, tenant, offset string) error {
+	var job struct {
+		Status           string   `bson:"status"`
+		ProcessedOffsets []string `bson:"processed_offsets"`
+	}
+	err := collection.FindOne(ctx, jobFilter(tenant, jobID),
+		options.FindOne().SetProjection(bson.M{"status": 1, "processed_offsets": 1}),
+	).Decode(&job)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	if offset != "" {
+		for _, processed := range job.ProcessedOffsets {
+			if processed == offset {
+				return errJobAlreadyProcessed
+			}
+		}
+	}
+	if err == mongo.ErrNoDocuments || job.Status == StatusCancelling || job.Status == StatusCancelled {
+		return errJobCancelled
+	}
+	if job.Status != StatusPending && job.Status != StatusProcessing {
+		return errJobAlreadyProcessed
+	}
+	return errJobSuperseded
+}
+
+// finishAttempt records the outcome of an attempt started with startAttempt
+func finishAttempt(ctx context.Context, collection *mongo.Collection, jobID JobID, tenant string, number int, outcome, errorMessage string) error {
+	set := bson.M{
+		"attempts.$.finished_at": time.Now(),
+		"attempts.$.outcome":     outcome,
+	}
+	if errorMessage != "" {
+		set["attempts.$.error_message"] = errorMessage
+	}
+
+	filter := jobFilter(tenant, jobID)
+	filter["attempts.number"] = number
+	_, err := collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	return err
+}
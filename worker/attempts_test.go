@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestStartAttemptSkipReason(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	jobID, err := parseJobID("65a1b2c3d4e5f60718293a4b")
+	if err != nil {
+		t.Fatalf("parseJobID() error = %v", err)
+	}
+	noMatch := mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil})
+	found := func(job bson.D) bson.D {
+		return mtest.CreateCursorResponse(0, "jobprocessor.jobs", mtest.FirstBatch, job)
+	}
+	missing := mtest.CreateCursorResponse(0, "jobprocessor.jobs", mtest.FirstBatch)
+
+	tests := []struct {
+		name string
+		job  bson.D
+		want error
+	}{
+		{name: "missing", want: errJobCancelled},
+		{name: "cancelling", job: bson.D{{Key: "status", Value: StatusCancelling}}, want: errJobCancelled},
+		{name: "cancelled", job: bson.D{{Key: "status", Value: StatusCancelled}}, want: errJobCancelled},
+		{name: "offset applied", job: bson.D{{Key: "status", Value: StatusProcessing}, {Key: "processed_offsets", Value: bson.A{"jobs.process/0/42"}}}, want: errJobAlreadyProcessed},
+		{name: "completed", job: bson.D{{Key: "status", Value: StatusCompleted}}, want: errJobAlreadyProcessed},
+		{name: "failed", job: bson.D{{Key: "status", Value: StatusFailed}}, want: errJobAlreadyProcessed},
+		{name: "awaiting children", job: bson.D{{Key: "status", Value: StatusAwaitingChildren}}, want: errJobAlreadyProcessed},
+		{name: "republished", job: bson.D{{Key: "status", Value: StatusPending}}, want: errJobSuperseded},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			lookup := missing
+			if tt.job != nil {
+				lookup = found(tt.job)
+			}
+			mt.AddMockResponses(noMatch, lookup)
+
+			_, err := startAttempt(context.Background(), mt.Coll, jobID, "", "dispatch-1", "jobs.process/0/42", "worker-1")
+			if err != tt.want {
+				mt.Fatalf("startAttempt() error = %v, want %v", err, tt.want)
+			}
+
+			started := mt.GetStartedEvent()
+			if started == nil || started.CommandName != "findAndModify" {
+				mt.Fatalf("started command = %v, want findAndModify", started)
+			}
+			statuses, err := started.Command.Lookup("query", "status", "$in").Array().Values()
+			if err != nil || len(statuses) != 2 || statuses[0].StringValue() != StatusPending || statuses[1].StringValue() != StatusProcessing {
+				mt.Errorf("startAttempt() status filter = %v, want pending or processing", statuses)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// jobBlobsBucket is the GridFS bucket the backend stores job configs in when
+// they are too large to keep on the job
+const jobBlobsBucket = "job_blobs"
+
+// BlobRef locates a job value stored in GridFS
+type BlobRef struct {
+	ID   primitive.ObjectID `json:"id" bson:"id"`
+	Size int64              `json:"size" bson:"size"`
+}
+
+// loadConfig fills in the config of a job message that carries a reference to
+// an offloaded config instead. The config is stored as JSON, so it decodes the
+// same as a config sent inline.
+func (w *Worker) loadConfig(ctx context.Context, jobMsg *JobMessage) error {
+	ref := jobMsg.ConfigRef
+	if ref == nil || jobMsg.Config != nil {
+		return nil
+	}
+
+	bucket, err := gridfs.NewBucket(w.collection.Database(), options.GridFSBucket().SetName(jobBlobsBucket))
+	if err != nil {
+		return fmt.Errorf("Failed to open job config storage: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		bucket.SetReadDeadline(deadline)
+	}
+
+	var buf bytes.Buffer
+	if _, err := bucket.DownloadToStream(ref.ID, &buf); err != nil {
+		return fmt.Errorf("Failed to load job config %s: %v", ref.ID.Hex(), err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &jobMsg.Config); err != nil {
+		return fmt.Errorf("Failed to decode job config %s: %v", ref.ID.Hex(), err)
+	}
+	return nil
+}
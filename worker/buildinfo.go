@@ -0,0 +1,53 @@
+package main
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, set with -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+var (
+	version = "dev"
+	commit  = ""
+	// buildDate is the build time in RFC 3339
+	buildDate = ""
+)
+
+// buildInfo describes the worker build, as served by GET /version
+type buildInfo struct {
+	Version         string `json:"version"`
+	Commit          string `json:"commit,omitempty"`
+	Date            string `json:"date,omitempty"`
+	GoVersion       string `json:"goVersion"`
+	ExecutorVersion string `json:"executorVersion"`
+	Canary          bool   `json:"canary"`
+}
+
+// currentBuild returns the build of the running worker. Without a commit set
+// at link time, the revision the Go toolchain stamped into the binary is used.
+func currentBuild() buildInfo {
+	info := buildInfo{
+		Version:         version,
+		Commit:          commit,
+		Date:            buildDate,
+		GoVersion:       runtime.Version(),
+		ExecutorVersion: executorVersion,
+		Canary:          canaryWorker,
+	}
+	if info.Commit != "" {
+		return info
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.Commit = setting.Value
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// checkpointStep is how long the simulated executor works between checkpoints
+const checkpointStep = time.Second
+
+// Checkpoint is the progress an executor saved on a job. A retried or
+// resumed job is handed its last checkpoint and continues from there.
+type Checkpoint struct {
+	State   bson.M    `bson:"state"`
+	Attempt int       `bson:"attempt"`
+	SavedAt time.Time `bson:"saved_at"`
+}
+
+// SaveCheckpoint persists an executor's progress on a job. It only applies
+// while the job is processing, so a cancelled job keeps its last checkpoint.
+func (w *Worker) SaveCheckpoint(ctx context.Context, jobMsg JobMessage, attempt int, state bson.M) error {
+	id, err := parseJobID(jobMsg.JobID)
+	if err != nil {
+		return fmt.Errorf("invalid job ID %q: %w", jobMsg.JobID, err)
+	}
+
+	filter := jobFilter(jobMsg.tenant(), id)
+	filter["status"] = StatusProcessing
+	now := time.Now()
+	_, err = w.collection.UpdateOne(ctx,
+		filter,
+		bson.M{"$set": bson.M{
+			"checkpoint": Checkpoint{State: state, Attempt: attempt, SavedAt: now},
+			"updated_at": now,
+		}},
+	)
+	return err
+}
+
+// loadCheckpoint returns the job's last checkpoint, or nil if it has none
+func loadCheckpoint(ctx context.Context, collection *mongo.Collection, jobID JobID, tenant string) (*Checkpoint, error) {
+	var job struct {
+		Checkpoint *Checkpoint `bson:"checkpoint"`
+	}
+	err := collection.FindOne(ctx, jobFilter(tenant, jobID)).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+	return job.Checkpoint, nil
+}
+
+// checkpointInt reads an integer from checkpoint state, which decodes as int32 or int64
+func checkpointInt(state bson.M, key string) int {
+	switch value := state[key].(type) {
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	case int:
+		return value
+	}
+	return 0
+}
+
+// simulateProcessing stands in for the executor's work. It runs in steps and
+// checkpoints after each one, so a retried job skips the steps already done.
+func (w *Worker) simulateProcessing(ctx context.Context, jobMsg JobMessage, jobID JobID, attempt int, logf func(format string, args ...interface{})) {
+	// Simulate processing time (2-5 seconds)
+	steps := 2 + rand.Intn(4)
+	step := 0
+
+	checkpoint, err := loadCheckpoint(ctx, w.collection, jobID, jobMsg.tenant())
+	if err != nil {
+		logf("Failed to load checkpoint, starting over: %v", err)
+	}
+	if checkpoint != nil {
+		if total := checkpointInt(checkpoint.State, "steps"); total > 0 {
+			steps = total
+			step = checkpointInt(checkpoint.State, "step")
+			logf("Resuming from checkpoint of attempt %d at step %d of %d", checkpoint.Attempt, step, steps)
+		}
+	}
+
+	remaining := time.Duration(steps-step) * checkpointStep
+	logf("Processing %s job %q (estimated %s)", jobMsg.JobType, jobMsg.Name, remaining)
+	for step < steps {
+		// Each step stands for a call to the service the job type depends on
+		if err := w.limits.Acquire(ctx, jobMsg.JobType); err != nil {
+			return
+		}
+		time.Sleep(checkpointStep)
+		step++
+		if err := w.SaveCheckpoint(ctx, jobMsg, attempt, bson.M{"step": step, "steps": steps}); err != nil {
+			logf("Failed to save checkpoint at step %d: %v", step, err)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fullstack-assessment/jobid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxChildren bounds how many child jobs one job may spawn
+const maxChildren = 100
+
+// childSpec describes a child job an executor spawns
+type childSpec struct {
+	Name    string
+	JobType string
+	Config  map[string]interface{}
+}
+
+// childSpecs reads the child jobs requested by a job's "children" config, a
+// list of objects with a name, a jobType and an optional config
+func childSpecs(jobMsg JobMessage) ([]childSpec, error) {
+	raw, ok := jobMsg.Config["children"]
+	if !ok {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("children must be a list")
+	}
+	if len(items) > maxChildren {
+		return nil, fmt.Errorf("at most %d children may be spawned, got %d", maxChildren, len(items))
+	}
+
+	specs := make([]childSpec, 0, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("child %d must be an object", i)
+		}
+		spec := childSpec{Name: fmt.Sprintf("%s #%d", jobMsg.Name, i+1)}
+		if name, ok := fields["name"].(string); ok && name != "" {
+			spec.Name = name
+		}
+		spec.JobType, _ = fields["jobType"].(string)
+		if !isKnownJobType(spec.JobType) {
+			return nil, fmt.Errorf("child %d has unknown job type %q", i, spec.JobType)
+		}
+		if config, ok := fields["config"].(map[string]interface{}); ok {
+			spec.Config = config
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// isKnownJobType reports whether jobType is one of allJobTypes
+func isKnownJobType(jobType string) bool {
+	for _, known := range allJobTypes {
+		if jobType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// SpawnChildren creates child jobs linked to their parent by parent_id and
+// queues them. Children are keyed by their position, so spawning again after
+// a redelivery or retry does not duplicate them; only newly created children
+// are published. The backend completes the parent once all are terminal.
+func (w *Worker) SpawnChildren(ctx context.Context, parent JobMessage, specs []childSpec) error {
+	parentID, err := parseJobID(parent.JobID)
+	if err != nil {
+		return fmt.Errorf("invalid parent job ID %q: %w", parent.JobID, err)
+	}
+
+	// Children belong to the parent's tenant, so their usage is charged to it
+	tenant := parent.tenant()
+
+	for i, spec := range specs {
+		childID, err := w.claimChild(ctx, parentID, tenant, i)
+		if err != nil {
+			return fmt.Errorf("failed to claim child %d: %w", i, err)
+		}
+
+		now := time.Now()
+		child := bson.M{
+			"name":        spec.Name,
+			"job_type":    spec.JobType,
+			"config":      spec.Config,
+			"status":      StatusPending,
+			"retry_count": 0,
+			"parent_id":   parentID,
+			"child_index": i,
+			"created_at":  now,
+			"updated_at":  now,
+		}
+		// The filter holds the full shard key of the jobs collection, as upserts on a sharded collection require
+		result, err := w.collection.UpdateOne(ctx,
+			jobFilter(tenant, childID),
+			bson.M{"$setOnInsert": child},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create child %d: %w", i, err)
+		}
+		if result.UpsertedID == nil {
+			continue
+		}
+
+		message := JobMessage{
+			SchemaVersion: messageSchemaVersion,
+			JobID:         childID.String(),
+			Name:          spec.Name,
+			JobType:       spec.JobType,
+			Config:        spec.Config,
+			CreatedAt:     now,
+			Tenant:        &tenant,
+		}
+		if err := w.kafka.publishJob(ctx, jobTopic(spec.JobType), message); err != nil {
+			return fmt.Errorf("failed to publish child %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// claimChild returns the ID of the child at a position of a parent, choosing it
+// the first time. The jobs collection cannot keep children unique by parent
+// and position once it is sharded, so the positions are claimed here instead.
+func (w *Worker) claimChild(ctx context.Context, parentID JobID, tenant string, index int) (JobID, error) {
+	// Children spawned before positions were claimed keep their ID
+	var existing struct {
+		ID JobID `bson:"_id"`
+	}
+	err := w.collection.FindOne(ctx,
+		bson.M{"tenant": tenant, "parent_id": parentID, "child_index": index},
+		options.FindOne().SetProjection(bson.M{"_id": 1}),
+	).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		if existing.ID, err = jobid.New(w.idStrategy); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	var claim struct {
+		JobID JobID `bson:"job_id"`
+	}
+	err = w.children.FindOneAndUpdate(ctx,
+		bson.M{"_id": fmt.Sprintf("%s:%d", parentID, index)},
+		bson.M{"$setOnInsert": bson.M{"job_id": existing.ID, "created_at": time.Now()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&claim)
+	return claim.JobID, err
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fullstack-assessment/jobid"
+	"github.com/fullstack-assessment/lifecycle"
+)
+
+// Config is the complete worker configuration, read from environment variables
+// over the defaults and validated once at startup, so a bad value stops the
+// worker instead of being replaced by its default
+type Config struct {
+	MetricsPort string `env:"METRICS_PORT"`
+	// WorkerID identifies the worker in attempt records, the host name by default
+	WorkerID string `env:"WORKER_ID"`
+	// JobTypes is a comma-separated list of the job types handled; empty handles all
+	JobTypes        string `env:"WORKER_JOB_TYPES"`
+	ExecutorVersion string `env:"EXECUTOR_VERSION"`
+	Canary          bool   `env:"EXECUTOR_CANARY"`
+	// Region is recorded on attempts, the Kafka region by default
+	Region string `env:"WORKER_REGION"`
+	// ContainerID may be set where cgroups do not reveal it
+	ContainerID     string        `env:"CONTAINER_ID"`
+	ShutdownTimeout time.Duration `env:"WORKER_SHUTDOWN_TIMEOUT"`
+	// JobIDStrategy generates the IDs of spawned children, as the backend's does for new jobs
+	JobIDStrategy  string        `env:"JOB_ID_STRATEGY"`
+	JobLockTTL     time.Duration `env:"JOB_LOCK_TTL"`
+	JobLogMaxBytes int           `env:"JOB_LOG_MAX_BYTES"`
+	// FetchBuffer is how many messages per jobs topic are fetched ahead of processing
+	FetchBuffer int `env:"WORKER_FETCH_BUFFER"`
+	// RetryMaxPerMinute caps the automatic retries published per minute across workers; zero is no cap
+	RetryMaxPerMinute int `env:"RETRY_MAX_PER_MINUTE"`
+	// RateLimits are per-type downstream calls per second, e.g. "analyze=5,export=0.5:3"
+	RateLimits string `env:"WORKER_RATE_LIMITS"`
+
+	Kafka         KafkaSettings
+	Topics        TopicSettings
+	Mongo         MongoConfig
+	Storage       StorageConfig
+	Notifications NotificationSettings
+	Dispatch      DispatchSettings
+	Hooks         HookSettings
+	Secrets       SecretsSettings
+	Replay        ReplaySettings
+	// Tunables are applied again when the configuration is reloaded
+	Tunables Tunables
+}
+
+// KafkaSettings holds the Kafka connection and consumer settings
+type KafkaSettings struct {
+	// Brokers is a comma-separated list of host:port addresses
+	Brokers string `env:"KAFKA_BROKERS"`
+	// SecondaryBrokers is the cluster the backend fails over to
+	SecondaryBrokers string `env:"KAFKA_SECONDARY_BROKERS"`
+	TopicPrefix      string `env:"KAFKA_TOPIC_PREFIX"`
+	// GroupPrefix defaults to the topic prefix
+	GroupPrefix       string `env:"KAFKA_GROUP_PREFIX"`
+	MirrorTopicPrefix string `env:"KAFKA_MIRROR_TOPIC_PREFIX"`
+	// Region is the worker's side of the failover switch: primary, dr or empty
+	Region                string `env:"KAFKA_REGION"`
+	TLS                   bool   `env:"KAFKA_TLS"`
+	TLSInsecureSkipVerify bool   `env:"KAFKA_TLS_INSECURE_SKIP_VERIFY"`
+	TLSCAFile             string `env:"KAFKA_TLS_CA_FILE"`
+	TLSCertFile           string `env:"KAFKA_TLS_CERT_FILE"`
+	TLSKeyFile            string `env:"KAFKA_TLS_KEY_FILE"`
+	SASLMechanism         string `env:"KAFKA_SASL_MECHANISM"`
+	SASLUsername          string `env:"KAFKA_SASL_USERNAME"`
+	SASLPassword          string `env:"KAFKA_SASL_PASSWORD"`
+	// Compression is the codec of the messages the worker writes: none, gzip, snappy, lz4 or zstd
+	Compression    string        `env:"KAFKA_COMPRESSION"`
+	SessionTimeout time.Duration `env:"KAFKA_SESSION_TIMEOUT"`
+	MaxWait        time.Duration `env:"KAFKA_MAX_WAIT"`
+	// StartOffset is first or last; with last, a new consumer group skips jobs published before it joined
+	StartOffset       string `env:"KAFKA_START_OFFSET"`
+	RebalanceStrategy string `env:"KAFKA_REBALANCE_STRATEGY"`
+}
+
+// NotificationSettings holds the global notification channels, as the backend reads them
+type NotificationSettings struct {
+	SMTPHost        string `env:"SMTP_HOST"`
+	SMTPPort        string `env:"SMTP_PORT"`
+	SMTPUsername    string `env:"SMTP_USERNAME"`
+	SMTPPassword    string `env:"SMTP_PASSWORD"`
+	SMTPFrom        string `env:"SMTP_FROM"`
+	Email           string `env:"NOTIFY_EMAIL"`
+	SlackWebhookURL string `env:"NOTIFY_SLACK_WEBHOOK_URL"`
+	On              string `env:"NOTIFY_ON"`
+}
+
+// DispatchSettings selects whether a jobs topic's partitions are processed in parallel
+type DispatchSettings struct {
+	Mode string `env:"WORKER_DISPATCH_MODE"`
+	// TopicModes overrides the mode per topic, e.g. "jobs.export=partition,jobs.analyze.priority=serial"
+	TopicModes string `env:"WORKER_DISPATCH_MODES"`
+}
+
+// HookSettings holds the HTTP lifecycle hooks
+type HookSettings struct {
+	Timeout          time.Duration `env:"WORKER_HOOK_TIMEOUT"`
+	FailOpen         bool          `env:"WORKER_HOOK_FAIL_OPEN"`
+	PreExecuteURLs   string        `env:"WORKER_HOOK_PRE_EXECUTE_URLS"`
+	PostCompleteURLs string        `env:"WORKER_HOOK_POST_COMPLETE_URLS"`
+}
+
+// SecretsSettings holds the key unwrapping the data keys of encrypted config values
+type SecretsSettings struct {
+	KeyID string `env:"SECRETS_KEY_ID"`
+	// Key is the base64-encoded 32-byte wrapping key shared with the backend
+	Key string `env:"SECRETS_KEY"`
+}
+
+// ReplaySettings selects the job messages re-processed in replay mode
+type ReplaySettings struct {
+	Enabled bool `env:"REPLAY_MODE"`
+	// RunID defaults to the time the replay started
+	RunID  string `env:"REPLAY_RUN_ID"`
+	Topics string `env:"REPLAY_TOPICS"`
+	// From is an RFC 3339 time
+	From             string `env:"REPLAY_FROM"`
+	ShadowCollection string `env:"SHADOW_COLLECTION"`
+}
+
+// Tunables can be changed at runtime: the worker reloads them on SIGHUP
+type Tunables struct {
+	// Concurrency caps the jobs processed at once across all topics; zero
+	// leaves it to the dispatch modes
+	Concurrency          int           `env:"WORKER_CONCURRENCY"`
+	RetryPollInterval    time.Duration `env:"RETRY_POLL_INTERVAL"`
+	DeadlinePollInterval time.Duration `env:"DEADLINE_POLL_INTERVAL"`
+	TypeStatsInterval    time.Duration `env:"JOB_TYPE_STATS_INTERVAL"`
+	TypeStatsWindow      time.Duration `env:"JOB_TYPE_STATS_WINDOW"`
+}
+
+// defaultConfig returns the configuration used when nothing is overridden
+func defaultConfig() *Config {
+	hostname, _ := os.Hostname()
+	return &Config{
+		MetricsPort:     "9090",
+		WorkerID:        hostname,
+		ExecutorVersion: version,
+		ShutdownTimeout: lifecycle.DefaultTimeout,
+		JobIDStrategy:   string(jobid.ObjectIDs),
+		JobLockTTL:      defaultJobLockTTL,
+		JobLogMaxBytes:  defaultJobLogMaxBytes,
+		FetchBuffer:     defaultFetchBuffer,
+		Kafka: KafkaSettings{
+			Brokers:           "localhost:9092",
+			Compression:       "none",
+			SessionTimeout:    30 * time.Second,
+			MaxWait:           10 * time.Second,
+			StartOffset:       "first",
+			RebalanceStrategy: "range",
+		},
+		Topics: TopicSettings{
+			Partitions:        1,
+			ReplicationFactor: 1,
+		},
+		Mongo: MongoConfig{
+			URI:                    "mongodb://localhost:27017/jobprocessor",
+			MaxPoolSize:            20,
+			MaxConnIdleTime:        5 * time.Minute,
+			ConnectTimeout:         10 * time.Second,
+			ServerSelectionTimeout: 5 * time.Second,
+			StartupTimeout:         2 * time.Minute,
+			InitialBackoff:         500 * time.Millisecond,
+			MaxBackoff:             10 * time.Second,
+		},
+		Storage: StorageConfig{
+			Backend: "local",
+			Dir:     "/data/artifacts",
+		},
+		Notifications: NotificationSettings{
+			SMTPPort: "587",
+			SMTPFrom: "jobs@localhost",
+		},
+		Dispatch: DispatchSettings{
+			Mode: dispatchSerial,
+		},
+		Hooks: HookSettings{
+			Timeout: defaultHookTimeout,
+		},
+		Secrets: SecretsSettings{
+			KeyID: "local",
+		},
+		Replay: ReplaySettings{
+			ShadowCollection: defaultShadowCollection,
+		},
+		Tunables: Tunables{
+			RetryPollInterval:    defaultRetryPollInterval,
+			DeadlinePollInterval: defaultDeadlinePollInterval,
+			TypeStatsInterval:    defaultTypeStatsInterval,
+			TypeStatsWindow:      defaultTypeStatsWindow,
+		},
+	}
+}
+
+// loadConfig builds the configuration from the defaults and the environment, then validates it
+func loadConfig() (*Config, error) {
+	config := defaultConfig()
+	if err := applyEnv(reflect.ValueOf(config).Elem()); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// applyEnv overrides fields tagged with `env` from the environment
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := field.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw := os.Getenv(key)
+		if raw == "" {
+			continue
+		}
+		if err := setValue(value, raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %q: %w", key, raw, err)
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(value reflect.Value, raw string) error {
+	if value.Type() == durationType {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(parsed))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(parsed)
+	case reflect.Int:
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(parsed))
+	case reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetUint(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// Validate checks the configuration so that bad values fail at startup instead of at first use
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.MetricsPort); err != nil || port < 1 || port > 65535 {
+		add("METRICS_PORT must be a valid port number, got %q", c.MetricsPort)
+	}
+	if c.WorkerID == "" {
+		add("WORKER_ID is required where the host name is unknown")
+	}
+	if _, err := parseJobTypes(c.JobTypes); err != nil {
+		add("WORKER_JOB_TYPES: %v", err)
+	}
+	if c.ShutdownTimeout <= 0 {
+		add("WORKER_SHUTDOWN_TIMEOUT must be positive")
+	}
+	if !jobid.Strategy(c.JobIDStrategy).Valid() {
+		add("JOB_ID_STRATEGY must be %s or %s, got %q", jobid.ObjectIDs, jobid.UUIDv7s, c.JobIDStrategy)
+	}
+	if c.JobLockTTL <= 0 {
+		add("JOB_LOCK_TTL must be positive")
+	}
+	if c.JobLogMaxBytes < 1 {
+		add("JOB_LOG_MAX_BYTES must be positive")
+	}
+	if c.FetchBuffer < 0 {
+		add("WORKER_FETCH_BUFFER must not be negative")
+	}
+	if c.RetryMaxPerMinute < 0 {
+		add("RETRY_MAX_PER_MINUTE must not be negative")
+	}
+
+	if len(splitList(c.Kafka.Brokers)) == 0 {
+		add("KAFKA_BROKERS must list at least one broker")
+	}
+	if c.Topics.Partitions < 1 || c.Topics.ReplicationFactor < 1 {
+		add("KAFKA_TOPIC_PARTITIONS and KAFKA_TOPIC_REPLICATION_FACTOR must be positive")
+	}
+
+	if c.Mongo.URI == "" {
+		add("MONGODB_URI is required")
+	}
+	if c.Mongo.MinPoolSize > c.Mongo.MaxPoolSize && c.Mongo.MaxPoolSize != 0 {
+		add("MONGO_MIN_POOL_SIZE must not exceed MONGO_MAX_POOL_SIZE")
+	}
+	if c.Mongo.ConnectTimeout <= 0 || c.Mongo.ServerSelectionTimeout <= 0 || c.Mongo.StartupTimeout <= 0 {
+		add("MONGO_CONNECT_TIMEOUT, MONGO_SERVER_SELECTION_TIMEOUT and MONGO_STARTUP_TIMEOUT must be positive")
+	}
+
+	if c.Notifications.SlackWebhookURL != "" && !isHTTPURL(c.Notifications.SlackWebhookURL) {
+		add("NOTIFY_SLACK_WEBHOOK_URL must be an http(s) URL")
+	}
+	if c.Replay.From != "" {
+		if _, err := time.Parse(time.RFC3339, c.Replay.From); err != nil {
+			add("REPLAY_FROM must be an RFC 3339 time: %v", err)
+		}
+	}
+	if c.Replay.ShadowCollection == "" {
+		add("SHADOW_COLLECTION must not be empty")
+	}
+
+	if c.Tunables.Concurrency < 0 {
+		add("WORKER_CONCURRENCY must not be negative")
+	}
+	if c.Tunables.RetryPollInterval <= 0 || c.Tunables.DeadlinePollInterval <= 0 {
+		add("RETRY_POLL_INTERVAL and DEADLINE_POLL_INTERVAL must be positive")
+	}
+	if c.Tunables.TypeStatsInterval <= 0 || c.Tunables.TypeStatsWindow <= 0 {
+		add("JOB_TYPE_STATS_INTERVAL and JOB_TYPE_STATS_WINDOW must be positive")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// jobTypeList returns the validated job types the worker handles
+func (c *Config) jobTypeList() []string {
+	jobTypes, _ := parseJobTypes(c.JobTypes)
+	return jobTypes
+}
+
+// configStore holds the live configuration and applies reloads.
+// Only the tunables take effect on reload; other changes are logged and require a restart.
+type configStore struct {
+	mu        sync.RWMutex
+	current   *Config
+	listeners []func(*Config)
+}
+
+// newConfigStore loads the configuration from the environment
+func newConfigStore() (*configStore, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &configStore{current: config}, nil
+}
+
+// Current returns the configuration in effect. Callers must not modify it.
+func (s *configStore) Current() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// OnReload registers a callback invoked with the new configuration after each successful reload
+func (s *configStore) OnReload(fn func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Reload re-reads the environment and applies the tunables.
+// An invalid configuration is rejected and the current one is kept.
+func (s *configStore) Reload() error {
+	loaded, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	next := *s.current
+	next.Tunables = loaded.Tunables
+	if !reflect.DeepEqual(next, *loaded) {
+		log.Println("Configuration changes outside the reloadable settings are ignored until restart")
+	}
+	s.current = &next
+	listeners := append([]func(*Config){}, s.listeners...)
+	s.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(&next)
+	}
+	return nil
+}
+
+// tunables returns the tunables in effect
+func (s *configStore) tunables() Tunables {
+	return s.Current().Tunables
+}
+
+// reloadableTicker ticks at an interval read from the live configuration, so a
+// reload changes it from the next tick
+type reloadableTicker struct {
+	ticker   *time.Ticker
+	current  time.Duration
+	interval func() time.Duration
+}
+
+func newReloadableTicker(interval func() time.Duration) *reloadableTicker {
+	current := interval()
+	return &reloadableTicker{ticker: time.NewTicker(current), current: current, interval: interval}
+}
+
+// C delivers the ticks
+func (t *reloadableTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+// refresh picks up a reloaded interval; call it after each tick
+func (t *reloadableTicker) refresh() {
+	if interval := t.interval(); interval != t.current {
+		t.ticker.Reset(interval)
+		t.current = interval
+	}
+}
+
+// Stop stops the ticker
+func (t *reloadableTicker) Stop() {
+	t.ticker.Stop()
+}
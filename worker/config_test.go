@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "overrides", env: map[string]string{"WORKER_CONCURRENCY": "4", "RETRY_POLL_INTERVAL": "1s", "MONGO_MAX_POOL_SIZE": "50"}},
+		{name: "not a number", env: map[string]string{"WORKER_FETCH_BUFFER": "ten"}, wantErr: true},
+		{name: "negative pool size", env: map[string]string{"MONGO_MAX_POOL_SIZE": "-1"}, wantErr: true},
+		{name: "not a duration", env: map[string]string{"JOB_LOCK_TTL": "30"}, wantErr: true},
+		{name: "not a bool", env: map[string]string{"KAFKA_TLS": "yes please"}, wantErr: true},
+		{name: "zero poll interval", env: map[string]string{"DEADLINE_POLL_INTERVAL": "0s"}, wantErr: true},
+		{name: "unknown job type", env: map[string]string{"WORKER_JOB_TYPES": "process,render"}, wantErr: true},
+		{name: "unknown ID strategy", env: map[string]string{"JOB_ID_STRATEGY": "uuidv4"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			config, err := loadConfig()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("loadConfig() succeeded, want the invalid value rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if tt.env["WORKER_CONCURRENCY"] != "" && (config.Tunables.Concurrency != 4 || config.Tunables.RetryPollInterval != time.Second || config.Mongo.MaxPoolSize != 50) {
+				t.Errorf("loadConfig() = %+v, want the overrides", config)
+			}
+		})
+	}
+}
+
+func TestConfigStoreReload(t *testing.T) {
+	t.Setenv("WORKER_CONCURRENCY", "2")
+	t.Setenv("WORKER_FETCH_BUFFER", "5")
+	store, err := newConfigStore()
+	if err != nil {
+		t.Fatalf("newConfigStore() error = %v", err)
+	}
+	var reloaded *Config
+	store.OnReload(func(config *Config) { reloaded = config })
+
+	t.Setenv("WORKER_CONCURRENCY", "8")
+	t.Setenv("WORKER_FETCH_BUFFER", "20")
+	if err := store.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if current := store.Current(); current.Tunables.Concurrency != 8 || current.FetchBuffer != 5 {
+		t.Errorf("after reload concurrency = %d, fetch buffer = %d, want the tunable changed only", current.Tunables.Concurrency, current.FetchBuffer)
+	}
+	if reloaded != store.Current() {
+		t.Error("reload listener was not called with the new configuration")
+	}
+
+	t.Setenv("WORKER_CONCURRENCY", "-1")
+	if err := store.Reload(); err == nil {
+		t.Error("Reload() accepted a negative concurrency")
+	}
+	if store.Current().Tunables.Concurrency != 8 {
+		t.Error("a rejected reload changed the configuration")
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// recordDeadLetter stores a failure published to the DLQ in the dead_letters
+// collection, where operators can page through it with the admin API instead of
+// reading the topic. Failing to store it is logged; the DLQ message is sent either way.
+func (w *Worker) recordDeadLetter(ctx context.Context, jobMsg JobMessage, dlqMsg DLQMessage) {
+	_, err := w.deadLetters.InsertOne(ctx, bson.M{
+		"job_id":        dlqMsg.JobID,
+		"job_type":      jobMsg.JobType,
+		"name":          jobMsg.Name,
+		"tenant":        jobMsg.tenant(),
+		"error_message": dlqMsg.ErrorMessage,
+		"retry_count":   dlqMsg.RetryCount,
+		"worker_id":     workerID,
+		"failed_at":     dlqMsg.FailedAt,
+	})
+	if err != nil {
+		log.Printf("Failed to record DLQ entry of job %s: %v", dlqMsg.JobID, err)
+	}
+}
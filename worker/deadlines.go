@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultDeadlinePollInterval is how often jobs past their deadline are looked for
+const defaultDeadlinePollInterval = 5 * time.Second
+
+// deadlineBatchSize bounds how many jobs past their deadline are failed per poll
+const deadlineBatchSize = 100
+
+// deadlineStatuses are the statuses a job is failed from once its deadline has
+// passed. Jobs being cancelled are left to finish cancelling.
+var deadlineStatuses = []string{StatusPending, StatusHeld, StatusAwaitingInput, StatusProcessing, StatusAwaitingChildren}
+
+// pastDeadline reports whether a job's deadline has passed
+func pastDeadline(deadlineAt *time.Time) bool {
+	return deadlineAt != nil && !time.Now().Before(*deadlineAt)
+}
+
+// deadlineExceeded returns the error message of a job failed at its deadline
+func deadlineExceeded(deadlineAt time.Time) string {
+	return fmt.Sprintf("Deadline exceeded at %s", deadlineAt.UTC().Format(time.RFC3339))
+}
+
+// failPastDeadline fails a job that has not finished by its deadline and sends
+// its notifications. It reports false if the job finished meanwhile or another
+// worker failed it first.
+func (w *Worker) failPastDeadline(ctx context.Context, jobMsg JobMessage) bool {
+	jobID, err := parseJobID(jobMsg.JobID)
+	if err != nil || jobMsg.DeadlineAt == nil {
+		return false
+	}
+
+	now := time.Now()
+	errorMessage := deadlineExceeded(*jobMsg.DeadlineAt)
+	filter := jobFilter(jobMsg.tenant(), jobID)
+	filter["status"] = bson.M{"$in": deadlineStatuses}
+	filter["deadline_at"] = bson.M{"$lte": now}
+	result, err := w.collection.UpdateOne(ctx,
+		filter,
+		bson.M{
+			"$set": bson.M{
+				"status":        StatusFailed,
+				"error_message": errorMessage,
+				"finished_at":   now,
+				"updated_at":    now,
+			},
+			// A job waiting for an automatic retry or its scheduled run is not published
+			"$unset": bson.M{dueRetry: "", dueScheduled: ""},
+		},
+	)
+	if err != nil {
+		log.Printf("Failed to fail job %s past its deadline: %v", jobMsg.JobID, err)
+		return false
+	}
+	if result.ModifiedCount == 0 {
+		return false
+	}
+
+	log.Printf("Job %s failed: %s", jobMsg.JobID, errorMessage)
+	metrics.recordOutcome("deadline_exceeded")
+	w.notifier.NotifyTerminal(ctx, jobID, jobMsg.tenant())
+	w.hooks.runPostComplete(jobMsg, JobOutcome{Status: StatusFailed, ErrorMessage: errorMessage})
+	return true
+}
+
+// runDeadlines fails the unfinished jobs of the given types whose deadline has
+// passed until ctx is cancelled. Every worker polls; a job is claimed by the
+// conditional update failing it, so it is failed and notified once.
+func (w *Worker) runDeadlines(ctx context.Context, jobTypes []string) {
+	ticker := newReloadableTicker(func() time.Duration { return w.tunables().DeadlinePollInterval })
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			ticker.refresh()
+			w.failDue(ctx, jobTypes)
+		}
+	}
+}
+
+// failDue fails one batch of jobs past their deadline
+func (w *Worker) failDue(ctx context.Context, jobTypes []string) {
+	cursor, err := w.collection.Find(ctx,
+		bson.M{
+			"status":      bson.M{"$in": deadlineStatuses},
+			"job_type":    bson.M{"$in": jobTypes},
+			"deadline_at": bson.M{"$lte": time.Now()},
+		},
+		options.Find().
+			SetProjection(bson.M{"tenant": 1, "name": 1, "job_type": 1, "created_at": 1, "deadline_at": 1}).
+			SetLimit(deadlineBatchSize),
+	)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to list jobs past their deadline: %v", err)
+		}
+		return
+	}
+
+	var jobs []struct {
+		ID         JobID      `bson:"_id"`
+		Tenant     string     `bson:"tenant"`
+		Name       string     `bson:"name"`
+		JobType    string     `bson:"job_type"`
+		CreatedAt  time.Time  `bson:"created_at"`
+		DeadlineAt *time.Time `bson:"deadline_at"`
+	}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		log.Printf("Failed to decode jobs past their deadline: %v", err)
+		return
+	}
+
+	for _, job := range jobs {
+		tenant := job.Tenant
+		w.failPastDeadline(ctx, JobMessage{
+			JobID:      job.ID.String(),
+			Tenant:     &tenant,
+			Name:       job.Name,
+			JobType:    job.JobType,
+			CreatedAt:  job.CreatedAt,
+			DeadlineAt: job.DeadlineAt,
+		})
+	}
+}
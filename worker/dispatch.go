@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Dispatch modes of a jobs consumer
+const (
+	// dispatchSerial processes the messages of all partitions one at a time
+	dispatchSerial = "serial"
+	// dispatchPartition processes each partition's messages in order, and
+	// different partitions in parallel
+	dispatchPartition = "partition"
+)
+
+// defaultFetchBuffer is how many messages per jobs topic are fetched ahead of processing
+const defaultFetchBuffer = 10
+
+// partitionQueueSize is how many fetched messages wait per partition in partition mode
+const partitionQueueSize = 1
+
+// dispatchSettings selects the dispatch mode of each jobs topic
+type dispatchSettings struct {
+	mode string
+	// topicModes overrides the mode per topic, keyed by the topic's base name, e.g. jobs.export
+	topicModes map[string]string
+}
+
+// loadDispatchSettings parses WORKER_DISPATCH_MODE and the per-topic overrides in
+// WORKER_DISPATCH_MODES, e.g. "jobs.export=partition,jobs.analyze.priority=serial"
+func loadDispatchSettings(config DispatchSettings) (dispatchSettings, error) {
+	settings := dispatchSettings{
+		mode:       strings.ToLower(config.Mode),
+		topicModes: make(map[string]string),
+	}
+	if !isDispatchMode(settings.mode) {
+		return settings, fmt.Errorf("unsupported WORKER_DISPATCH_MODE %q", settings.mode)
+	}
+
+	for _, pair := range splitList(config.TopicModes) {
+		topic, mode, ok := strings.Cut(pair, "=")
+		topic, mode = strings.TrimSpace(topic), strings.ToLower(strings.TrimSpace(mode))
+		if !ok || topic == "" {
+			return settings, fmt.Errorf("WORKER_DISPATCH_MODES expects topic=mode pairs, got %q", pair)
+		}
+		if !isDispatchMode(mode) {
+			return settings, fmt.Errorf("unsupported dispatch mode %q for topic %s", mode, topic)
+		}
+		settings.topicModes[topic] = mode
+	}
+	return settings, nil
+}
+
+func isDispatchMode(mode string) bool {
+	return mode == dispatchSerial || mode == dispatchPartition
+}
+
+// modeFor returns the dispatch mode of a namespaced topic
+func (s dispatchSettings) modeFor(topic string) string {
+	base := topic
+	if topicPrefix != "" {
+		base = strings.TrimPrefix(topic, topicPrefix+".")
+	}
+	if mode, ok := s.topicModes[base]; ok {
+		return mode
+	}
+	return s.mode
+}
+
+// partitionDispatcher hands fetched messages to one goroutine per partition, so
+// each partition is processed in order while partitions run in parallel
+type partitionDispatcher struct {
+	handle func(msg kafka.Message)
+
+	wg     sync.WaitGroup
+	queues map[int]chan kafka.Message
+}
+
+func newPartitionDispatcher(handle func(msg kafka.Message)) *partitionDispatcher {
+	return &partitionDispatcher{
+		handle: handle,
+		queues: make(map[int]chan kafka.Message),
+	}
+}
+
+// dispatch queues a message for its partition's goroutine, starting it for a
+// newly seen partition. It blocks while the partition's queue is full, or until
+// ctx is cancelled.
+func (d *partitionDispatcher) dispatch(ctx context.Context, msg kafka.Message) {
+	queue, ok := d.queues[msg.Partition]
+	if !ok {
+		queue = make(chan kafka.Message, partitionQueueSize)
+		d.queues[msg.Partition] = queue
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for msg := range queue {
+				d.handle(msg)
+			}
+		}()
+		log.Printf("Dispatching partition %d of %s to its own goroutine", msg.Partition, msg.Topic)
+	}
+
+	select {
+	case queue <- msg:
+	case <-ctx.Done():
+	}
+}
+
+// close stops accepting messages and waits for the queued ones to be processed
+func (d *partitionDispatcher) close() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
+
+// jobSlots caps the jobs a worker processes at once across its consumers. The
+// limit is a tunable, so it can be resized while jobs run; a nil jobSlots or a
+// limit of zero does not cap them.
+type jobSlots struct {
+	mu      sync.Mutex
+	limit   int
+	running int
+	// freed is closed and replaced when a slot is released or the limit changes
+	freed chan struct{}
+}
+
+func newJobSlots(limit int) *jobSlots {
+	return &jobSlots{limit: limit, freed: make(chan struct{})}
+}
+
+// acquire waits for a free slot, returning false if ctx is cancelled first
+func (s *jobSlots) acquire(ctx context.Context) bool {
+	if s == nil {
+		return true
+	}
+	for {
+		s.mu.Lock()
+		if s.limit <= 0 || s.running < s.limit {
+			s.running++
+			s.mu.Unlock()
+			return true
+		}
+		freed := s.freed
+		s.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// release frees a slot taken by acquire
+func (s *jobSlots) release() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	s.wake()
+}
+
+// resize changes the limit; jobs already running over a lowered limit finish
+func (s *jobSlots) resize(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if limit != s.limit {
+		log.Printf("Worker concurrency changed from %d to %d", s.limit, limit)
+	}
+	s.limit = limit
+	s.wake()
+}
+
+// wake lets the waiting acquirers check for a slot again; s.mu must be held
+func (s *jobSlots) wake() {
+	close(s.freed)
+	s.freed = make(chan struct{})
+}
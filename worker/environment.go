@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// executionEnvironment describes where the worker runs, recorded with every
+// attempt so failures specific to some workers can be told apart
+type executionEnvironment struct {
+	Host        string
+	ContainerID string
+	Region      string
+	// CPULimit is the number of CPUs the container may use, zero when unlimited
+	CPULimit float64
+	// MemoryLimitBytes is the container's memory limit, zero when unlimited
+	MemoryLimitBytes int64
+}
+
+// environment is detected once at startup
+var environment executionEnvironment
+
+// containerIDPattern matches the 64-character ID of a container in a cgroup path
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// detectEnvironment reads the host, container and resource limits of the
+// worker. The region is WORKER_REGION, or the worker's Kafka region without it;
+// the container ID may be set with CONTAINER_ID where cgroups do not reveal it.
+func detectEnvironment(config *Config) executionEnvironment {
+	env := executionEnvironment{
+		ContainerID: config.ContainerID,
+		Region:      config.Region,
+	}
+	if env.ContainerID == "" {
+		env.ContainerID = containerID()
+	}
+	if env.Region == "" {
+		env.Region = config.Kafka.Region
+	}
+	env.Host, _ = os.Hostname()
+	env.CPULimit = cpuLimit()
+	env.MemoryLimitBytes = memoryLimit()
+	return env
+}
+
+// attemptFields returns the environment as recorded on an attempt
+func (e executionEnvironment) attemptFields() bson.M {
+	fields := bson.M{
+		"host":       e.Host,
+		"go_os":      runtime.GOOS,
+		"go_arch":    runtime.GOARCH,
+		"gomaxprocs": runtime.GOMAXPROCS(0),
+	}
+	if e.ContainerID != "" {
+		fields["container_id"] = e.ContainerID
+	}
+	if e.Region != "" {
+		fields["region"] = e.Region
+	}
+	if e.CPULimit > 0 {
+		fields["cpu_limit"] = e.CPULimit
+	}
+	if e.MemoryLimitBytes > 0 {
+		fields["memory_limit_bytes"] = e.MemoryLimitBytes
+	}
+	return fields
+}
+
+// containerID finds the ID of the container the worker runs in from its
+// cgroups, or from its mounts under cgroup v2, where the cgroup path is often
+// just "/"
+func containerID() string {
+	for _, path := range []string{"/proc/self/cgroup", "/proc/self/mountinfo"} {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if path == "/proc/self/mountinfo" && !strings.Contains(line, "/containers/") {
+				continue
+			}
+			if id := containerIDPattern.FindString(line); id != "" {
+				file.Close()
+				return id
+			}
+		}
+		file.Close()
+	}
+	return ""
+}
+
+// cpuLimit reads the CPU quota of the worker's cgroup, under cgroup v2 or v1
+func cpuLimit() float64 {
+	if data, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] != "max" {
+			return quotaCPUs(fields[0], fields[1])
+		}
+		return 0
+	}
+	quota, err := os.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_quota_us")
+	if err != nil {
+		return 0
+	}
+	period, err := os.ReadFile("/sys/fs/cgroup/cpu/cpu.cfs_period_us")
+	if err != nil {
+		return 0
+	}
+	return quotaCPUs(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+// quotaCPUs converts a CFS quota and period to a number of CPUs
+func quotaCPUs(quota, period string) float64 {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0
+	}
+	return q / p
+}
+
+// memoryLimit reads the memory limit of the worker's cgroup, under cgroup v2
+// or v1. Cgroup v1 reports no limit as a huge number, which is ignored.
+func memoryLimit() int64 {
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		limit, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil || limit <= 0 || limit >= 1<<62 {
+			return 0
+		}
+		return limit
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// exportRowCount is the number of rows in a simulated export
+const exportRowCount = 100
+
+// buildExport renders the simulated output of an export job in the configured format
+func buildExport(jobMsg JobMessage) (data []byte, contentType, extension string, err error) {
+	format, _ := jobMsg.Config["format"].(string)
+
+	rows := make([]map[string]string, exportRowCount)
+	for i := range rows {
+		rows[i] = map[string]string{
+			"id":    strconv.Itoa(i + 1),
+			"value": strconv.Itoa(rand.Intn(10000)),
+		}
+	}
+
+	switch format {
+	case "csv":
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		if includeHeaders, ok := jobMsg.Config["includeHeaders"].(bool); !ok || includeHeaders {
+			writer.Write([]string{"id", "value"})
+		}
+		for _, row := range rows {
+			writer.Write([]string{row["id"], row["value"]})
+		}
+		writer.Flush()
+		return buf.Bytes(), "text/csv", "csv", writer.Error()
+	case "", "json":
+		data, err := json.Marshal(rows)
+		return data, "application/json", "json", err
+	default:
+		return nil, "", "", fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// exportResult returns the result of an export job, describing its artifact
+func exportResult(jobMsg JobMessage) map[string]interface{} {
+	format, _ := jobMsg.Config["format"].(string)
+	if format == "" {
+		format = "json"
+	}
+	return map[string]interface{}{"rows": exportRowCount, "format": format}
+}
+
+// uploadExportArtifact builds the export output, uploads it to storage and returns
+// the artifact document to store on the job
+func uploadExportArtifact(ctx context.Context, storage ArtifactStorage, jobMsg JobMessage, attempt int) (bson.M, error) {
+	data, contentType, extension, err := buildExport(jobMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("exports/%s/attempt-%d.%s", jobMsg.JobID, attempt, extension)
+	url, err := storage.Put(ctx, key, data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(data)
+	return bson.M{
+		"storage":      storage.Name(),
+		"key":          key,
+		"url":          url,
+		"content_type": contentType,
+		"size":         len(data),
+		"checksum":     "sha256:" + hex.EncodeToString(checksum[:]),
+		"created_at":   time.Now(),
+	}, nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Kafka regions, matching the backend's failover switch
+const (
+	regionPrimary = "primary"
+	regionDR      = "dr"
+)
+
+// kafkaFailoverID is the ID of the document the backend stores the switch in
+const kafkaFailoverID = "fleet"
+
+// failoverPollInterval is how often the failover switch is read
+const failoverPollInterval = 10 * time.Second
+
+// mirrorTopicPrefix is the prefix MirrorMaker gives the topics it mirrors into
+// this worker's cluster from the other region, e.g. primary.jobs.export. It is
+// read from KAFKA_MIRROR_TOPIC_PREFIX; mirrored topics are not consumed without it.
+var mirrorTopicPrefix string
+
+// mirroredTopic returns the name of the mirror of topic in this worker's cluster
+func mirroredTopic(topic string) string {
+	return mirrorTopicPrefix + "." + topic
+}
+
+// isMirroredTopic reports whether a message was consumed from a mirrored topic
+func isMirroredTopic(topic string) bool {
+	return mirrorTopicPrefix != "" && strings.HasPrefix(topic, mirrorTopicPrefix+".")
+}
+
+// failoverSwitch follows the region the backend's failover switch points the
+// fleet at. A worker consumes jobs only while its own region, KAFKA_REGION, is
+// the active one, so the fleet of the other region stands by without being
+// redeployed. A nil switch, for workers without KAFKA_REGION, is always active.
+type failoverSwitch struct {
+	collection *mongo.Collection
+	region     string
+
+	mu      sync.Mutex
+	active  string
+	changed chan struct{}
+}
+
+// loadFailoverSwitch follows the switch for the worker's region, KAFKA_REGION,
+// returning nil when it is not set
+func loadFailoverSwitch(collection *mongo.Collection, region string) (*failoverSwitch, error) {
+	switch region {
+	case "":
+		return nil, nil
+	case regionPrimary, regionDR:
+	default:
+		return nil, fmt.Errorf("KAFKA_REGION must be one of %s, %s, got %q", regionPrimary, regionDR, region)
+	}
+	return &failoverSwitch{collection: collection, region: region, changed: make(chan struct{})}, nil
+}
+
+// Start reads the switch once, so consumers start in the right state
+func (s *failoverSwitch) Start(ctx context.Context) error {
+	s.poll(ctx)
+	return nil
+}
+
+// Run reads the switch until ctx is cancelled
+func (s *failoverSwitch) Run(ctx context.Context) {
+	ticker := time.NewTicker(failoverPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.poll(ctx)
+		}
+	}
+}
+
+func (s *failoverSwitch) poll(ctx context.Context) {
+	var failover struct {
+		Region string `bson:"region"`
+	}
+	err := s.collection.FindOne(ctx, bson.M{"_id": kafkaFailoverID}, options.FindOne().SetProjection(bson.M{"region": 1})).Decode(&failover)
+	if err == mongo.ErrNoDocuments {
+		failover.Region = regionPrimary
+	} else if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Failed to read the Kafka failover switch: %v", err)
+		}
+		// Until the switch is read once, the primary region is assumed active
+		s.mu.Lock()
+		unknown := s.active == ""
+		s.mu.Unlock()
+		if !unknown {
+			return
+		}
+		failover.Region = regionPrimary
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if failover.Region == s.active {
+		return
+	}
+	if s.active != "" {
+		log.Printf("Kafka failover switched from the %s to the %s region", s.active, failover.Region)
+	}
+	s.active = failover.Region
+	close(s.changed)
+	s.changed = make(chan struct{})
+	if s.active == s.region {
+		log.Printf("Region %s is active, consuming jobs", s.region)
+	} else {
+		log.Printf("Region %s is on standby, not consuming jobs", s.region)
+	}
+}
+
+// state reports whether the worker's region is active, and a channel closed
+// when the switch changes
+func (s *failoverSwitch) state() (bool, <-chan struct{}) {
+	if s == nil {
+		return true, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active == s.region, s.changed
+}
+
+// whileActive runs consume while the worker's region is active, with a context
+// cancelled when the region is switched away, and runs it again when the region
+// is switched back, until ctx is cancelled
+func (s *failoverSwitch) whileActive(ctx context.Context, consume func(ctx context.Context)) {
+	for ctx.Err() == nil {
+		active, changed := s.state()
+		if !active {
+			select {
+			case <-ctx.Done():
+			case <-changed:
+			}
+			continue
+		}
+		if changed == nil {
+			consume(ctx)
+			return
+		}
+
+		activeCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-changed:
+				cancel()
+			case <-activeCtx.Done():
+			}
+		}()
+		consume(activeCtx)
+		cancel()
+	}
+}
+
+// heartbeatFields returns the region fields reported in the worker's heartbeat
+func (s *failoverSwitch) heartbeatFields() bson.M {
+	if s == nil {
+		return nil
+	}
+	active, _ := s.state()
+	return bson.M{"kafka_region": s.region, "kafka_active": active}
+}
+
+// startedElsewhere reports whether the job of a message consumed from a mirrored
+// topic was already started from the original topic, in the other region, so
+// it is not run twice. Mirrored messages carry their own offsets, which the
+// processed-offset ledger cannot match, so the job is checked by ID: only a
+// job still pending is run, and startAttempt skips it if it was republished.
+func (w *Worker) startedElsewhere(ctx context.Context, jobMsg JobMessage) bool {
+	jobID, err := parseJobID(jobMsg.JobID)
+	if err != nil {
+		return false
+	}
+	tenant, err := w.jobTenant(ctx, jobID, jobMsg.Tenant)
+	if err != nil {
+		return false
+	}
+	var job struct {
+		Status string `bson:"status"`
+	}
+	opts := options.FindOne().SetProjection(bson.M{"status": 1})
+	if err := w.collection.FindOne(ctx, jobFilter(tenant, jobID), opts).Decode(&job); err != nil {
+		// Missing jobs and errors are left to processing, which handles both
+		return false
+	}
+	return job.Status != StatusPending
+}
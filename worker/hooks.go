@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Lifecycle points at which the worker runs hooks. New jobs are checked by the
+// backend's pre_enqueue hooks before they are queued.
+const (
+	HookPreExecute   = "pre_execute"
+	HookPostComplete = "post_complete"
+)
+
+// defaultHookTimeout bounds a call of an HTTP hook when WORKER_HOOK_TIMEOUT is not set
+const defaultHookTimeout = 5 * time.Second
+
+// HookRejectedError is returned when a pre-execute hook rejects a job attempt
+type HookRejectedError struct {
+	Hook   string
+	Reason string
+}
+
+func (e *HookRejectedError) Error() string {
+	return fmt.Sprintf("Job rejected by hook %s: %s", e.Hook, e.Reason)
+}
+
+// PreExecuteHook is called before every attempt of a job. It may change the
+// config the attempt runs with, whose encrypted values are still envelopes. An
+// error fails the attempt like a failed execution, so the job's retry policy
+// decides whether it is tried again.
+type PreExecuteHook interface {
+	Name() string
+	PreExecute(ctx context.Context, jobMsg *JobMessage) error
+}
+
+// JobOutcome is the terminal state a job reached
+type JobOutcome struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// PostCompleteHook is called after a job completed or failed for good. Errors
+// are logged and never affect the job.
+type PostCompleteHook interface {
+	Name() string
+	PostComplete(ctx context.Context, jobMsg JobMessage, outcome JobOutcome) error
+}
+
+// Hooks holds the lifecycle hooks registered with the worker. Hooks run in the
+// order they were registered.
+type Hooks struct {
+	mu           sync.RWMutex
+	preExecute   []PreExecuteHook
+	postComplete []PostCompleteHook
+}
+
+// NewHooks creates an empty hook registry
+func NewHooks() *Hooks {
+	return &Hooks{}
+}
+
+// RegisterPreExecute adds a hook run before job attempts
+func (h *Hooks) RegisterPreExecute(hook PreExecuteHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.preExecute = append(h.preExecute, hook)
+}
+
+// RegisterPostComplete adds a hook run after jobs reach a terminal state
+func (h *Hooks) RegisterPostComplete(hook PostCompleteHook) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.postComplete = append(h.postComplete, hook)
+}
+
+// runPreExecute runs the pre-execute hooks on a job message, stopping at the
+// first error. A nil registry runs none.
+func (h *Hooks) runPreExecute(ctx context.Context, jobMsg *JobMessage) error {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	hooks := h.preExecute
+	h.mu.RUnlock()
+
+	for _, hook := range hooks {
+		err := hook.PreExecute(ctx, jobMsg)
+		switch err.(type) {
+		case nil:
+			metrics.recordHookCall(HookPreExecute, "accepted")
+		case *HookRejectedError:
+			metrics.recordHookCall(HookPreExecute, "rejected")
+			return err
+		default:
+			metrics.recordHookCall(HookPreExecute, "failed")
+			return fmt.Errorf("Hook %s failed: %v", hook.Name(), err)
+		}
+	}
+	return nil
+}
+
+// runPostComplete asynchronously runs the post-complete hooks of a job that
+// reached a terminal state, logging failures
+func (h *Hooks) runPostComplete(jobMsg JobMessage, outcome JobOutcome) {
+	if h == nil {
+		return
+	}
+	h.mu.RLock()
+	hooks := h.postComplete
+	h.mu.RUnlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, hook := range hooks {
+			if err := hook.PostComplete(ctx, jobMsg, outcome); err != nil {
+				metrics.recordHookCall(HookPostComplete, "failed")
+				log.Printf("Post-complete hook %s failed for job %s: %v", hook.Name(), jobMsg.JobID, err)
+				continue
+			}
+			metrics.recordHookCall(HookPostComplete, "accepted")
+		}
+	}()
+}
+
+// loadHooks registers the configured HTTP hooks
+func loadHooks(settings HookSettings) (*Hooks, error) {
+	hooks := NewHooks()
+	timeout := settings.Timeout
+	if timeout <= 0 {
+		return nil, fmt.Errorf("WORKER_HOOK_TIMEOUT must be positive")
+	}
+	failOpen := settings.FailOpen
+
+	for _, endpoint := range splitList(settings.PreExecuteURLs) {
+		if !isHTTPURL(endpoint) {
+			return nil, fmt.Errorf("WORKER_HOOK_PRE_EXECUTE_URLS must hold http(s) URLs")
+		}
+		hooks.RegisterPreExecute(newHTTPHook(endpoint, timeout, failOpen))
+	}
+	for _, endpoint := range splitList(settings.PostCompleteURLs) {
+		if !isHTTPURL(endpoint) {
+			return nil, fmt.Errorf("WORKER_HOOK_POST_COMPLETE_URLS must hold http(s) URLs")
+		}
+		hooks.RegisterPostComplete(newHTTPHook(endpoint, timeout, failOpen))
+	}
+	return hooks, nil
+}
+
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// httpHook runs lifecycle hooks on an external endpoint. The endpoint receives
+// {"point": ..., "job": ..., "outcome": ...} as JSON, with the job as it was
+// published, and accepts with a 2xx response. Before execution the response may
+// carry {"config": {...}} with keys to add to or replace in the job's config,
+// and a 4xx response rejects the attempt, with {"reason": "..."} saying why.
+type httpHook struct {
+	url    string
+	client *http.Client
+	// failOpen runs attempts unchanged when the endpoint is unreachable or fails
+	failOpen bool
+}
+
+func newHTTPHook(endpoint string, timeout time.Duration, failOpen bool) *httpHook {
+	return &httpHook{
+		url:      endpoint,
+		client:   &http.Client{Timeout: timeout},
+		failOpen: failOpen,
+	}
+}
+
+// Name returns the host of the endpoint, leaving out any credentials in its URL
+func (h *httpHook) Name() string {
+	if u, err := url.Parse(h.url); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "http"
+}
+
+type hookRequest struct {
+	Point   string      `json:"point"`
+	Job     JobMessage  `json:"job"`
+	Outcome *JobOutcome `json:"outcome,omitempty"`
+}
+
+type hookResponse struct {
+	Reason string                 `json:"reason"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// PreExecute calls the endpoint and applies the config keys it returns
+func (h *httpHook) PreExecute(ctx context.Context, jobMsg *JobMessage) error {
+	response, err := h.call(ctx, hookRequest{Point: HookPreExecute, Job: *jobMsg})
+	if err != nil {
+		if _, rejected := err.(*HookRejectedError); rejected || !h.failOpen {
+			return err
+		}
+		log.Printf("Hook %s failed, running job %s unchanged: %v", h.Name(), jobMsg.JobID, err)
+		return nil
+	}
+
+	if len(response.Config) > 0 && jobMsg.Config == nil {
+		jobMsg.Config = make(map[string]interface{}, len(response.Config))
+	}
+	for key, value := range response.Config {
+		jobMsg.Config[key] = value
+	}
+	return nil
+}
+
+// PostComplete notifies the endpoint of the job's outcome
+func (h *httpHook) PostComplete(ctx context.Context, jobMsg JobMessage, outcome JobOutcome) error {
+	_, err := h.call(ctx, hookRequest{Point: HookPostComplete, Job: jobMsg, Outcome: &outcome})
+	return err
+}
+
+func (h *httpHook) call(ctx context.Context, request hookRequest) (*hookResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Accepting responses must hold JSON if they have a body; rejections are only read for their reason
+	var response hookResponse
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, &response); err != nil && resp.StatusCode < 300 {
+			return nil, fmt.Errorf("Invalid hook response: %v", err)
+		}
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return &response, nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		reason := response.Reason
+		if reason == "" {
+			reason = http.StatusText(resp.StatusCode)
+		}
+		return nil, &HookRejectedError{Hook: h.Name(), Reason: reason}
+	default:
+		return nil, fmt.Errorf("Hook returned status %d", resp.StatusCode)
+	}
+}
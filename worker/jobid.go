@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/fullstack-assessment/jobid"
+)
+
+// JobID is a job's ID as the backend stores it: an ObjectID for jobs created
+// under the objectid ID strategy, a UUIDv7 string under uuidv7. Messages carry
+// either form as a string. The backend shares its implementation.
+type JobID = jobid.ID
+
+// parseJobID reads the ID of a job from a message
+func parseJobID(value string) (JobID, error) {
+	return jobid.Parse(value)
+}
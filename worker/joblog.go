@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultJobLogMaxBytes caps the log output captured per attempt
+const defaultJobLogMaxBytes = 64 * 1024
+
+// jobLogger captures the log output of one job attempt in the job_logs collection.
+// Lines are written as they are logged so they can be followed while the job runs.
+// Output beyond maxBytes is dropped after a truncation notice.
+type jobLogger struct {
+	collection *mongo.Collection
+	jobID      JobID
+	attempt    int
+	maxBytes   int
+
+	seq       int
+	written   int
+	truncated bool
+}
+
+// newJobLogger creates a logger for an attempt of a job
+func (w *Worker) newJobLogger(jobID JobID, attempt int) *jobLogger {
+	return &jobLogger{
+		collection: w.logs,
+		jobID:      jobID,
+		attempt:    attempt,
+		maxBytes:   w.logMaxBytes,
+	}
+}
+
+// Printf logs a line to the worker output and captures it for the attempt
+func (l *jobLogger) Printf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Printf("[job %s attempt %d] %s", l.jobID.String(), l.attempt, message)
+
+	if l.truncated {
+		return
+	}
+	if l.written+len(message) > l.maxBytes {
+		l.truncated = true
+		message = fmt.Sprintf("log truncated after %d bytes", l.written)
+	}
+	l.written += len(message)
+	l.seq++
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := l.collection.InsertOne(ctx, bson.M{
+		"job_id":    l.jobID,
+		"attempt":   l.attempt,
+		"seq":       l.seq,
+		"message":   message,
+		"timestamp": time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to store log line for job %s: %v", l.jobID.String(), err)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// kafkaTimeout bounds dialing brokers and admin requests
+const kafkaTimeout = 10 * time.Second
+
+// Base names of the topics shared by every job type, namespaced by topicName
+const (
+	cancellationsTopic    = "job_cancellations"
+	cancellationAcksTopic = "cancellation_acks"
+	dlqTopic              = "jobs_dlq"
+	// legacyJobsTopic carries jobs published before per-type topics existed
+	legacyJobsTopic = "jobs"
+)
+
+// topicPrefix and groupPrefix namespace the worker's topics and consumer groups,
+// so several environments can share one Kafka cluster. They are read from
+// KAFKA_TOPIC_PREFIX and KAFKA_GROUP_PREFIX, which default to the topic prefix,
+// and must match the backend's.
+var (
+	topicPrefix string
+	groupPrefix string
+)
+
+// topicName returns the namespaced name of a topic, e.g. staging.jobs.export
+func topicName(name string) string {
+	if topicPrefix == "" {
+		return name
+	}
+	return topicPrefix + "." + name
+}
+
+// groupName returns the namespaced name of a consumer group, e.g. staging.job-worker-export
+func groupName(name string) string {
+	if groupPrefix == "" {
+		return name
+	}
+	return groupPrefix + "." + name
+}
+
+// kafkaConnection holds the brokers and the TLS and SASL settings shared by
+// every reader, writer and admin client of the worker
+type kafkaConnection struct {
+	// secondary marks the cluster the backend fails over to
+	secondary bool
+	brokers   []string
+	dialer    *kafka.Dialer
+	transport *kafka.Transport
+	reader    readerSettings
+	// compression is the codec of the messages the worker writes
+	compression kafka.Compression
+}
+
+// readerSettings tune the consumer group readers, matching the backend's
+type readerSettings struct {
+	// startOffset applies only to a group without committed offsets
+	startOffset    int64
+	balancers      []kafka.GroupBalancer
+	sessionTimeout time.Duration
+	maxWait        time.Duration
+}
+
+// loadKafkaConnection creates the connection to the brokers of KAFKA_BROKERS
+func loadKafkaConnection(settings KafkaSettings) (*kafkaConnection, error) {
+	brokers := splitList(settings.Brokers)
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no Kafka brokers configured")
+	}
+	return newKafkaConnection(brokers, settings)
+}
+
+// loadSecondaryKafkaConnection creates the connection to the cluster the backend
+// fails over to, KAFKA_SECONDARY_BROKERS. It is reached with the same TLS and
+// SASL settings as the primary, and is nil when not configured.
+func loadSecondaryKafkaConnection(settings KafkaSettings) (*kafkaConnection, error) {
+	brokers := splitList(settings.SecondaryBrokers)
+	if len(brokers) == 0 {
+		return nil, nil
+	}
+	conn, err := newKafkaConnection(brokers, settings)
+	if err != nil {
+		return nil, err
+	}
+	conn.secondary = true
+	return conn, nil
+}
+
+// newKafkaConnection creates a connection to brokers with the configured TLS, SASL and reader settings
+func newKafkaConnection(brokers []string, settings KafkaSettings) (*kafkaConnection, error) {
+	tlsConfig, err := loadKafkaTLS(settings)
+	if err != nil {
+		return nil, err
+	}
+	mechanism, err := loadKafkaSASL(settings)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := loadReaderSettings(settings)
+	if err != nil {
+		return nil, err
+	}
+	compression, err := loadKafkaCompression(settings.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafkaConnection{
+		brokers: brokers,
+		dialer: &kafka.Dialer{
+			Timeout:       kafkaTimeout,
+			DualStack:     true,
+			TLS:           tlsConfig,
+			SASLMechanism: mechanism,
+		},
+		transport: &kafka.Transport{
+			DialTimeout: kafkaTimeout,
+			TLS:         tlsConfig,
+			SASL:        mechanism,
+		},
+		reader:      reader,
+		compression: compression,
+	}, nil
+}
+
+// metricsTopic returns the topic label of a reader's metrics, which tells the
+// readers of the secondary cluster apart from those of the primary
+func (c *kafkaConnection) metricsTopic(topic string) string {
+	if c.secondary {
+		return "secondary/" + topic
+	}
+	return topic
+}
+
+// readerConfig returns the configuration of a consumer group reader of topic
+// with the connection's reader settings
+func (c *kafkaConnection) readerConfig(topic, groupID string) kafka.ReaderConfig {
+	return kafka.ReaderConfig{
+		Brokers:        c.brokers,
+		Dialer:         c.dialer,
+		Topic:          topic,
+		GroupID:        groupID,
+		MinBytes:       10e3,
+		MaxBytes:       10e6,
+		StartOffset:    c.reader.startOffset,
+		GroupBalancers: c.reader.balancers,
+		SessionTimeout: c.reader.sessionTimeout,
+		MaxWait:        c.reader.maxWait,
+	}
+}
+
+// writer creates a writer for the topic that balances messages by least bytes
+// and compresses them with the connection's codec
+func (c *kafkaConnection) writer(topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(c.brokers...),
+		Transport:    c.transport,
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		BatchTimeout: 10 * time.Millisecond,
+		Compression:  c.compression,
+	}
+}
+
+// publishJob publishes a job message to topic
+func (c *kafkaConnection) publishJob(ctx context.Context, topic string, message JobMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	writer := c.writer(topic)
+	defer writer.Close()
+
+	msg := kafka.Message{Value: data}
+	if message.AffinityKey != "" {
+		// Keep jobs sharing an affinity key on the same partition, as the backend does
+		writer.Balancer = &kafka.Hash{}
+		msg.Key = []byte(message.AffinityKey)
+	}
+	return writer.WriteMessages(ctx, msg)
+}
+
+// client creates a client for admin and metadata requests
+func (c *kafkaConnection) client() *kafka.Client {
+	return &kafka.Client{
+		Addr:      kafka.TCP(c.brokers...),
+		Timeout:   kafkaTimeout,
+		Transport: c.transport,
+	}
+}
+
+func loadKafkaTLS(settings KafkaSettings) (*tls.Config, error) {
+	if !settings.TLS {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: settings.TLSInsecureSkipVerify,
+	}
+	if caFile := settings.TLSCAFile; caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Kafka CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Kafka CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	certFile, keyFile := settings.TLSCertFile, settings.TLSKeyFile
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func loadKafkaSASL(settings KafkaSettings) (sasl.Mechanism, error) {
+	mechanism := strings.ToLower(settings.SASLMechanism)
+	if mechanism == "" {
+		return nil, nil
+	}
+
+	username, password := settings.SASLUsername, settings.SASLPassword
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD are required with KAFKA_SASL_MECHANISM")
+	}
+
+	switch mechanism {
+	case "plain":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unsupported KAFKA_SASL_MECHANISM %q", mechanism)
+	}
+}
+
+// loadKafkaCompression returns the codec the worker compresses the messages it
+// writes with, KAFKA_COMPRESSION: none, gzip, snappy, lz4 or zstd
+func loadKafkaCompression(name string) (kafka.Compression, error) {
+	switch name := strings.ToLower(name); name {
+	case "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported KAFKA_COMPRESSION %q", name)
+	}
+}
+
+// checkCompressionCodecs round-trips a message through every codec the backend
+// may compress with, so a worker that could not decode them fails at startup
+// instead of stalling its consumers on the first compressed batch
+func checkCompressionCodecs() error {
+	sample := bytes.Repeat([]byte(`{"job_id":"check","config":{}}`), 64)
+	for _, compression := range []kafka.Compression{kafka.Gzip, kafka.Snappy, kafka.Lz4, kafka.Zstd} {
+		codec := compression.Codec()
+		if codec == nil {
+			return fmt.Errorf("no %s codec available", compression)
+		}
+		var compressed bytes.Buffer
+		w := codec.NewWriter(&compressed)
+		if _, err := w.Write(sample); err != nil {
+			return fmt.Errorf("%s codec: %w", compression, err)
+		}
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("%s codec: %w", compression, err)
+		}
+		r := codec.NewReader(&compressed)
+		decoded, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s codec: %w", compression, err)
+		}
+		if !bytes.Equal(decoded, sample) {
+			return fmt.Errorf("%s codec does not round-trip messages", compression)
+		}
+	}
+	return nil
+}
+
+// loadReaderSettings returns the consumer settings. KAFKA_START_OFFSET is first or
+// last; with last, a new consumer group skips jobs published before it joined.
+func loadReaderSettings(config KafkaSettings) (readerSettings, error) {
+	settings := readerSettings{
+		sessionTimeout: config.SessionTimeout,
+		maxWait:        config.MaxWait,
+	}
+	if settings.sessionTimeout <= 0 || settings.maxWait <= 0 {
+		return settings, fmt.Errorf("KAFKA_SESSION_TIMEOUT and KAFKA_MAX_WAIT must be positive")
+	}
+
+	switch offset := strings.ToLower(config.StartOffset); offset {
+	case "first":
+		settings.startOffset = kafka.FirstOffset
+	case "last":
+		settings.startOffset = kafka.LastOffset
+	default:
+		return settings, fmt.Errorf("unsupported KAFKA_START_OFFSET %q", offset)
+	}
+
+	switch strategy := strings.ToLower(config.RebalanceStrategy); strategy {
+	case "range":
+		settings.balancers = []kafka.GroupBalancer{kafka.RangeGroupBalancer{}}
+	case "roundrobin":
+		settings.balancers = []kafka.GroupBalancer{kafka.RoundRobinGroupBalancer{}}
+	default:
+		return settings, fmt.Errorf("unsupported KAFKA_REBALANCE_STRATEGY %q", strategy)
+	}
+	return settings, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// maxProcessedOffsets bounds the processed-offset ledger kept on each job.
+// A redelivered message is older than the messages applied after it, so only
+// the most recent entries are needed to recognise it.
+const maxProcessedOffsets = 20
+
+// messageOffset identifies a Kafka message by topic, partition and offset
+func messageOffset(msg kafka.Message) string {
+	return fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+}
+
+// withProcessedOffset adds the message's offset to the job's ledger as part of a
+// status update, so the mutation and its record are applied atomically. Messages
+// redelivered after a restart or rebalance are then skipped by startAttempt.
+func withProcessedOffset(update bson.M, offset string) bson.M {
+	if offset == "" {
+		return update
+	}
+	update["$push"] = bson.M{
+		"processed_offsets": bson.M{
+			"$each":  bson.A{offset},
+			"$slice": -maxProcessedOffsets,
+		},
+	}
+	return update
+}
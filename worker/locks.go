@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultJobLockTTL is how long a job lock stays valid without being renewed
+const defaultJobLockTTL = 30 * time.Second
+
+// errJobLocked is returned when another worker, or another attempt of this one,
+// holds the lock of a job
+var errJobLocked = errors.New("job is locked by another attempt")
+
+// JobLocks grants workers exclusive ownership of a job while they process it.
+// A lock is a document keyed by job ID in the job_locks collection; it expires
+// unless its holder renews it, so a crashed worker's jobs are freed after the TTL.
+// Each acquisition is identified by a random token, so a worker handling the
+// same job twice, such as a redelivered message, cannot take or release the
+// lock of the other attempt.
+type JobLocks struct {
+	collection *mongo.Collection
+	owner      string
+	ttl        time.Duration
+}
+
+// NewJobLocks creates the job locks held by the given worker
+func NewJobLocks(collection *mongo.Collection, owner string, ttl time.Duration) *JobLocks {
+	if ttl <= 0 {
+		ttl = defaultJobLockTTL
+	}
+	return &JobLocks{
+		collection: collection,
+		owner:      owner,
+		ttl:        ttl,
+	}
+}
+
+// JobLock is a lock held on one job
+type JobLock struct {
+	locks *JobLocks
+	jobID string
+	token string
+	stop  context.CancelFunc
+	done  chan struct{}
+}
+
+// Acquire takes the lock of a job, returning errJobLocked if it is held,
+// whether by another worker or by this one for another message. The returned
+// context is cancelled when the lock is lost, so the caller stops working on a
+// job another worker has taken over. The lock is renewed in the background
+// until Release is called.
+func (l *JobLocks) Acquire(ctx context.Context, jobID string) (*JobLock, context.Context, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	// Only an expired lock matches, so the upsert of a held lock fails on the
+	// duplicate _id, whichever worker holds it
+	filter := bson.M{
+		"_id":        jobID,
+		"expires_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"owner":       l.owner,
+			"token":       token,
+			"acquired_at": now,
+			"expires_at":  now.Add(l.ttl),
+		},
+	}
+	_, err = l.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, nil, errJobLocked
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lockCtx, stop := context.WithCancel(ctx)
+	lock := &JobLock{
+		locks: l,
+		jobID: jobID,
+		token: token,
+		stop:  stop,
+		done:  make(chan struct{}),
+	}
+	go lock.renew(lockCtx)
+	return lock, lockCtx, nil
+}
+
+// renew extends the lock every third of its TTL until it is released, cancelling
+// the lock context if the lock was taken over by another worker
+func (j *JobLock) renew(ctx context.Context) {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.locks.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := j.locks.collection.UpdateOne(ctx,
+				bson.M{"_id": j.jobID, "token": j.token},
+				bson.M{"$set": bson.M{"expires_at": time.Now().Add(j.locks.ttl)}},
+			)
+			if err != nil {
+				// A failed renewal is retried; the lock only lapses after the TTL
+				if ctx.Err() == nil {
+					log.Printf("Failed to renew lock of job %s: %v", j.jobID, err)
+				}
+				continue
+			}
+			if result.MatchedCount == 0 {
+				log.Printf("Lock of job %s was taken over, stopping", j.jobID)
+				metrics.recordLockLost()
+				j.stop()
+				return
+			}
+		}
+	}
+}
+
+// Release stops renewing the lock and deletes it so the job can be picked up again
+func (j *JobLock) Release() {
+	j.stop()
+	<-j.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := j.locks.collection.DeleteOne(ctx, bson.M{"_id": j.jobID, "token": j.token}); err != nil {
+		log.Printf("Failed to release lock of job %s: %v", j.jobID, err)
+	}
+}
+
+// newLockToken returns a random token identifying one acquisition of a lock
+func newLockToken() (string, error) {
+	var token [16]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(token[:]), nil
+}
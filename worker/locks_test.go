@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// lockFilter returns the filter of the next update or delete sent to the job_locks collection
+func lockFilter(mt *mtest.T, command string) bson.Raw {
+	mt.Helper()
+	started := mt.GetStartedEvent()
+	if started == nil || started.CommandName != command {
+		mt.Fatalf("started command = %v, want %s", started, command)
+	}
+	values, err := started.Command.Lookup(command + "s").Array().Values()
+	if err != nil || len(values) != 1 {
+		mt.Fatalf("%s statements = %v, %v, want one", command, values, err)
+	}
+	return values[0].Document().Lookup("q").Document()
+}
+
+func TestJobLocks(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("held lock", func(mt *mtest.T) {
+		locks := NewJobLocks(mt.Coll, "worker-1", time.Minute)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 0}))
+		held, _, err := locks.Acquire(context.Background(), "job-1")
+		if err != nil {
+			mt.Fatalf("Acquire() error = %v", err)
+		}
+		defer func() {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+			held.Release()
+		}()
+
+		filter := lockFilter(mt, "update")
+		if _, err := filter.LookupErr("expires_at"); err != nil {
+			mt.Errorf("Acquire() filter %v matches locks that have not expired", filter)
+		}
+
+		// The upsert of a held lock collides with it on _id
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Index:   0,
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: jobprocessor.job_locks index: _id_",
+		}))
+		if _, _, err := locks.Acquire(context.Background(), "job-1"); !errors.Is(err, errJobLocked) {
+			mt.Fatalf("second Acquire() error = %v, want errJobLocked", err)
+		}
+	})
+
+	mt.Run("stale release", func(mt *mtest.T) {
+		locks := NewJobLocks(mt.Coll, "worker-1", time.Minute)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		stale, _, err := locks.Acquire(context.Background(), "job-1")
+		if err != nil {
+			mt.Fatalf("Acquire() error = %v", err)
+		}
+		// The lock expired and another attempt took it over
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		current, _, err := locks.Acquire(context.Background(), "job-1")
+		if err != nil {
+			mt.Fatalf("Acquire() error = %v", err)
+		}
+		if stale.token == current.token {
+			mt.Fatal("two acquisitions of a lock share a token")
+		}
+		mt.ClearEvents()
+
+		// Nothing matches the stale token, so the current lock is kept
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+		stale.Release()
+		filter := lockFilter(mt, "delete")
+		if id := filter.Lookup("_id").StringValue(); id != "job-1" {
+			mt.Errorf("Release() deleted the lock of %q, want job-1", id)
+		}
+		if token, ok := filter.Lookup("token").StringValueOK(); !ok || token != stale.token {
+			mt.Errorf("Release() filter %v does not require the stale token, so it deletes the current lock", filter)
+		}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		current.Release()
+	})
+}
@@ -3,264 +3,1066 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"math/rand"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/fullstack-assessment/jobid"
+	"github.com/fullstack-assessment/lifecycle"
+	"github.com/fullstack-assessment/notify"
 	"github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // JobMessage represents a job message from Kafka
 type JobMessage struct {
-	JobID     string                 `json:"job_id"`
-	Name      string                 `json:"name"`
-	JobType   string                 `json:"job_type"`
-	Config    map[string]interface{} `json:"config,omitempty"`
-	CreatedAt time.Time              `json:"created_at"`
+	// SchemaVersion is the version of the message's layout, see messageSchemaVersion
+	SchemaVersion int                    `json:"schema_version"`
+	JobID         string                 `json:"job_id"`
+	Name          string                 `json:"name"`
+	JobType       string                 `json:"job_type"`
+	Config        map[string]interface{} `json:"config,omitempty"`
+	AffinityKey   string                 `json:"affinity_key,omitempty"`
+	DispatchID    string                 `json:"dispatch_id,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	// DeadlineAt is when the job is failed if it has not completed
+	DeadlineAt *time.Time `json:"deadline_at,omitempty"`
+	// ConfigRef replaces Config for configs the backend offloaded to GridFS
+	ConfigRef *BlobRef `json:"config_ref,omitempty"`
+	// Input locates the uploaded input file of jobs created with hold_for_input
+	Input *JobInput `json:"input,omitempty"`
+	// Tenant addresses the job by the shard key of the jobs collection; it is
+	// read from the job for messages published without it
+	Tenant *string `json:"tenant,omitempty"`
+}
+
+// JobInput locates a job's uploaded input file in artifact storage
+type JobInput struct {
+	Storage     string `json:"storage" bson:"storage"`
+	Key         string `json:"key" bson:"key"`
+	Filename    string `json:"filename" bson:"filename"`
+	ContentType string `json:"content_type" bson:"content_type"`
+	Size        int64  `json:"size" bson:"size"`
+	Checksum    string `json:"checksum" bson:"checksum"`
 }
 
 // CancellationMessage represents a cancellation message from Kafka
 type CancellationMessage struct {
-	JobID       string    `json:"job_id"`
-	CancelledAt time.Time `json:"cancelled_at"`
+	SchemaVersion int       `json:"schema_version"`
+	JobID         string    `json:"job_id"`
+	CancelledAt   time.Time `json:"cancelled_at"`
+	CancelledBy   string    `json:"cancelled_by,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	// Tenant of the job, absent on messages published before it was added
+	Tenant *string `json:"tenant,omitempty"`
+}
+
+// CancellationAckMessage is published once the worker has stopped a cancelled job
+type CancellationAckMessage struct {
+	SchemaVersion int       `json:"schema_version"`
+	JobID         string    `json:"job_id"`
+	WorkerID      string    `json:"worker_id"`
+	AckedAt       time.Time `json:"acked_at"`
 }
 
 // DLQMessage represents a dead letter queue message
 type DLQMessage struct {
-	JobID        string    `json:"job_id"`
-	FailedAt     time.Time `json:"failed_at"`
-	ErrorMessage string    `json:"error_message"`
-	RetryCount   int       `json:"retry_count"`
+	SchemaVersion int       `json:"schema_version"`
+	JobID         string    `json:"job_id"`
+	FailedAt      time.Time `json:"failed_at"`
+	ErrorMessage  string    `json:"error_message"`
+	RetryCount    int       `json:"retry_count"`
+}
+
+// workerID identifies this worker in attempt records
+var workerID string
+
+// Worker holds the dependencies shared by the job and cancellation consumers
+type Worker struct {
+	kafka      *kafkaConnection
+	collection *mongo.Collection
+	dlqWriter  *kafka.Writer
+	ackWriter  *kafka.Writer
+	notifier   *Notifier
+	storage    ArtifactStorage
+	registry   *Registration
+	// logs stores the output captured per job attempt
+	logs        *mongo.Collection
+	logMaxBytes int
+	secrets     *configDecryptor
+	// poison stores messages that could not be decoded
+	poison *mongo.Collection
+	// deadLetters stores the failures published to the DLQ
+	deadLetters *mongo.Collection
+	// children records the ID of the child spawned at each position of a parent
+	children *mongo.Collection
+	// idStrategy generates the IDs of spawned children, as the backend's does for new jobs
+	idStrategy jobid.Strategy
+	// locks keeps other workers from processing a job this worker is processing
+	locks *JobLocks
+	// dispatch selects whether a jobs topic's partitions are processed in parallel
+	dispatch dispatchSettings
+	// fetchBuffer is how many messages per jobs topic are fetched ahead of processing
+	fetchBuffer int
+	// hooks run before job attempts and after jobs reach a terminal state
+	hooks *Hooks
+	// limits throttles the calls executors make to downstream services
+	limits *RateLimits
+	// retries publishes automatic retries once their backoff has passed
+	retries Scheduler
+	// failover stops consuming jobs while the worker's Kafka region is on standby
+	failover *failoverSwitch
+	// settings holds the live configuration, whose tunables change on reload
+	settings *configStore
+	// slots caps the jobs processed at once, WORKER_CONCURRENCY
+	slots *jobSlots
+}
+
+// tunables returns the reloadable settings in effect
+func (w *Worker) tunables() Tunables {
+	return w.settings.tunables()
+}
+
+// Job types
+const (
+	JobTypeProcess = "process"
+	JobTypeAnalyze = "analyze"
+	JobTypeExport  = "export"
+)
+
+// allJobTypes lists every job type, handled by default
+var allJobTypes = []string{JobTypeProcess, JobTypeAnalyze, JobTypeExport}
+
+// jobTopic returns the Kafka topic jobs of the given type are published to
+func jobTopic(jobType string) string {
+	return topicName("jobs." + jobType)
+}
+
+// jobPriorityTopic returns the Kafka topic prioritized jobs of the given type are published to
+func jobPriorityTopic(jobType string) string {
+	return jobTopic(jobType) + ".priority"
+}
+
+// jobCanaryTopic returns the Kafka topic of jobs of the given type routed to canary workers
+func jobCanaryTopic(jobType string) string {
+	return jobTopic(jobType) + ".canary"
+}
+
+// parseJobTypes parses a comma-separated list of job types; an empty list means all types
+func parseJobTypes(value string) ([]string, error) {
+	types := splitList(value)
+	if len(types) == 0 {
+		return allJobTypes, nil
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, jobType := range types {
+		valid := false
+		for _, known := range allJobTypes {
+			if jobType == known {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown job type %q", jobType)
+		}
+		if !seen[jobType] {
+			seen[jobType] = true
+			result = append(result, jobType)
+		}
+	}
+	return result, nil
 }
 
 // Job statuses
 const (
 	StatusPending    = "pending"
+	StatusHeld       = "held"
 	StatusProcessing = "processing"
 	StatusCompleted  = "completed"
 	StatusFailed     = "failed"
 	StatusCancelling = "cancelling"
 	StatusCancelled  = "cancelled"
+	// StatusAwaitingChildren is set on an executed job until its child jobs are terminal
+	StatusAwaitingChildren = "awaiting_children"
+	// StatusAwaitingInput is set on a job created to wait for its input file
+	StatusAwaitingInput = "awaiting_input"
 )
 
 func main() {
-	// Get configuration from environment
-	mongoURI := getEnv("MONGODB_URI", "mongodb://localhost:27017/jobprocessor")
-	kafkaBrokers := getEnv("KAFKA_BROKERS", "localhost:9092")
+	// Load and validate the configuration, stopping on any bad value
+	settings, err := newConfigStore()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	cfg := settings.Current()
+
+	kafkaConn, err := loadKafkaConnection(cfg.Kafka)
+	if err != nil {
+		log.Fatalf("Invalid Kafka connection settings: %v", err)
+	}
+	if err := checkCompressionCodecs(); err != nil {
+		log.Fatalf("Cannot decode compressed Kafka messages: %v", err)
+	}
+	secondaryKafkaConn, err := loadSecondaryKafkaConnection(cfg.Kafka)
+	if err != nil {
+		log.Fatalf("Invalid secondary Kafka connection settings: %v", err)
+	}
+	workerID = cfg.WorkerID
+	executorVersion = cfg.ExecutorVersion
+	canaryWorker = cfg.Canary
+	topicPrefix = cfg.Kafka.TopicPrefix
+	groupPrefix = cfg.Kafka.GroupPrefix
+	if groupPrefix == "" {
+		groupPrefix = topicPrefix
+	}
+	mirrorTopicPrefix = cfg.Kafka.MirrorTopicPrefix
+	environment = detectEnvironment(cfg)
 
-	// Connect to MongoDB
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	jobTypes := cfg.jobTypeList()
+	log.Printf("Worker %s handles job types: %s", version, strings.Join(jobTypes, ", "))
+	if canaryWorker {
+		log.Printf("Worker runs canary executor version %s", executorVersion)
+	}
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	// Connect to MongoDB, retrying until it is available
+	client, err := connectMongo(context.Background(), cfg.Mongo)
 	if err != nil {
 		log.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
 	defer client.Disconnect(context.Background())
-
-	// Ping MongoDB
-	if err := client.Ping(ctx, nil); err != nil {
-		log.Fatalf("Failed to ping MongoDB: %v", err)
-	}
 	log.Println("Worker connected to MongoDB")
 
 	collection := client.Database("jobprocessor").Collection("jobs")
 
+	// Create artifact storage for job outputs
+	storage, err := NewArtifactStorage(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to configure artifact storage: %v", err)
+	}
+
+	// Create notifier for terminal state notifications
+	notifier := NewNotifier(notify.Settings{
+		SMTP: notify.SMTPConfig{
+			Host:     cfg.Notifications.SMTPHost,
+			Port:     cfg.Notifications.SMTPPort,
+			Username: cfg.Notifications.SMTPUsername,
+			Password: cfg.Notifications.SMTPPassword,
+			From:     cfg.Notifications.SMTPFrom,
+		},
+		Email:           splitList(cfg.Notifications.Email),
+		SlackWebhookURL: cfg.Notifications.SlackWebhookURL,
+		On:              splitList(cfg.Notifications.On),
+	}, collection)
+
 	// Create Kafka producer for DLQ
-	dlqWriter := &kafka.Writer{
-		Addr:         kafka.TCP(kafkaBrokers),
-		Topic:        "jobs_dlq",
-		Balancer:     &kafka.LeastBytes{},
-		BatchTimeout: 10 * time.Millisecond,
+	dlqWriter := kafkaConn.writer(topicName(dlqTopic))
+
+	// Create Kafka producer for cancellation acknowledgements
+	ackWriter := kafkaConn.writer(topicName(cancellationAcksTopic))
+
+	secrets, err := loadConfigDecryptor(cfg.Secrets)
+	if err != nil {
+		log.Fatalf("Invalid secrets configuration: %v", err)
 	}
-	defer dlqWriter.Close()
 
-	// Create context with cancellation
-	ctx, cancel = context.WithCancel(context.Background())
-	defer cancel()
+	dispatch, err := loadDispatchSettings(cfg.Dispatch)
+	if err != nil {
+		log.Fatalf("Invalid dispatch settings: %v", err)
+	}
+	hooks, err := loadHooks(cfg.Hooks)
+	if err != nil {
+		log.Fatalf("Invalid hook settings: %v", err)
+	}
+	limits, err := loadRateLimits(cfg.RateLimits)
+	if err != nil {
+		log.Fatalf("Invalid rate limits: %v", err)
+	}
+	if described := limits.describe(); described != "" {
+		log.Printf("Downstream calls are rate limited: %s", described)
+	}
+	retryPollInterval := func() time.Duration { return settings.tunables().RetryPollInterval }
+	retryBudget := newRetryBudget(client.Database("jobprocessor").Collection("retry_budget"), cfg.RetryMaxPerMinute)
 
-	// Create wait group for consumers
-	var wg sync.WaitGroup
+	failover, err := loadFailoverSwitch(client.Database("jobprocessor").Collection("kafka_failover"), cfg.Kafka.Region)
+	if err != nil {
+		log.Fatalf("Invalid Kafka failover settings: %v", err)
+	}
+
+	// Register this worker so it shows up in the fleet listing
+	registry := NewRegistration(client.Database("jobprocessor").Collection("workers"), workerID, jobTypes, failover)
+
+	worker := &Worker{
+		kafka:       kafkaConn,
+		collection:  collection,
+		dlqWriter:   dlqWriter,
+		ackWriter:   ackWriter,
+		notifier:    notifier,
+		storage:     storage,
+		registry:    registry,
+		logs:        client.Database("jobprocessor").Collection("job_logs"),
+		logMaxBytes: cfg.JobLogMaxBytes,
+		secrets:     secrets,
+		poison:      client.Database("jobprocessor").Collection("poison_messages"),
+		deadLetters: client.Database("jobprocessor").Collection("dead_letters"),
+		children:    client.Database("jobprocessor").Collection("job_children"),
+		idStrategy:  jobid.Strategy(cfg.JobIDStrategy),
+		dispatch:    dispatch,
+		fetchBuffer: cfg.FetchBuffer,
+		hooks:       hooks,
+		limits:      limits,
+		retries:     newRetryScheduler(collection, kafkaConn, jobTypes, retryBudget, retryPollInterval),
+		failover:    failover,
+		locks:       NewJobLocks(client.Database("jobprocessor").Collection("job_locks"), workerID, cfg.JobLockTTL),
+		settings:    settings,
+		slots:       newJobSlots(cfg.Tunables.Concurrency),
+	}
 
-	// Start jobs consumer
-	wg.Add(1)
+	// Apply tunables when the configuration is reloaded; the pollers read their
+	// intervals from the store on each tick
+	settings.OnReload(func(cfg *Config) {
+		worker.slots.resize(cfg.Tunables.Concurrency)
+	})
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
 	go func() {
-		defer wg.Done()
-		consumeJobs(ctx, kafkaBrokers, collection, dlqWriter)
+		for range reload {
+			if err := settings.Reload(); err != nil {
+				log.Printf("Configuration reload rejected: %v", err)
+				continue
+			}
+			log.Println("Configuration reloaded")
+		}
 	}()
 
-	// Start cancellations consumer
-	wg.Add(1)
+	// A drain stops fetching new messages while in-flight jobs finish; the
+	// consumers then return, which stops the worker
+	drainCtx, stopFetching := context.WithCancel(context.Background())
+	defer stopFetching()
+
+	var drainOnce sync.Once
+	requestDrain := func() {
+		drainOnce.Do(func() {
+			log.Println("Drain requested, no longer fetching new messages")
+			metrics.setDraining()
+			stopFetching()
+		})
+	}
+	drainSignal := make(chan os.Signal, 1)
+	signal.Notify(drainSignal, syscall.SIGUSR1)
 	go func() {
-		defer wg.Done()
-		consumeCancellations(ctx, kafkaBrokers, collection)
+		for range drainSignal {
+			requestDrain()
+		}
 	}()
 
+	// Verify the topics this worker needs; readiness fails until they exist
+	topics := newTopicVerifier(kafkaConn, requiredTopics(jobTypes, canaryWorker), cfg.Topics)
+
+	ready := func(ctx context.Context) error {
+		if err := pingMongo(ctx, client, 2*time.Second); err != nil {
+			return fmt.Errorf("MongoDB unavailable: %w", err)
+		}
+		return topics.Ready()
+	}
+
+	// Components stop in reverse order of their dependencies: the consumers
+	// finish their jobs before the producers they publish with are flushed and
+	// the worker is deregistered, and /ready answers until the end
+	group := lifecycle.New()
+	group.Add(
+		lifecycle.Component{
+			Name: "producers",
+			// Closing flushes the DLQ messages and acknowledgements still buffered
+			Stop: func(ctx context.Context) error {
+				return errors.Join(dlqWriter.Close(), ackWriter.Close())
+			},
+		},
+		lifecycle.Loop("topic verifier", topics.Run),
+		lifecycle.Server("metrics server", newMetricsServer(":"+cfg.MetricsPort, requestDrain, ready), 5*time.Second),
+	)
+	shutdownTimeout := cfg.ShutdownTimeout
+
+	// Replay mode re-processes historical job messages into the shadow results
+	// collection instead of consuming live jobs
+	replay, err := loadReplayConfig(cfg.Replay, jobTypes)
+	if err != nil {
+		log.Fatalf("Invalid replay settings: %v", err)
+	}
+	if replay.Enabled {
+		shadow := client.Database("jobprocessor").Collection(cfg.Replay.ShadowCollection)
+		group.Add(lifecycle.Component{
+			Name:      "replay",
+			DependsOn: []string{"producers", "metrics server"},
+			Run: func(ctx context.Context) error {
+				worker.replay(ctx, replay, shadow)
+				return nil
+			},
+			StopTimeout: shutdownTimeout,
+		})
+		if err := group.Run(context.Background(), syscall.SIGINT, syscall.SIGTERM); err != nil {
+			log.Printf("Replay stopped with errors: %v", err)
+			return
+		}
+		log.Println("Replay stopped")
+		return
+	}
+
+	// The backend publishes to the secondary cluster while the primary is failing,
+	// so its topics are consumed too; they are idle outside a failover, and jobs
+	// published there during one are drained after the backend fails back
+	conns := []*kafkaConnection{kafkaConn}
+	if secondaryKafkaConn != nil {
+		log.Printf("Consuming from the secondary Kafka cluster %s as well", strings.Join(secondaryKafkaConn.brokers, ","))
+		conns = append(conns, secondaryKafkaConn)
+	}
+
+	consumerDeps := []string{"producers", "metrics server", "registration"}
+	if failover != nil {
+		log.Printf("Worker follows the Kafka failover switch for region %s", failover.region)
+		group.Add(lifecycle.Component{
+			Name:  "failover switch",
+			Start: failover.Start,
+			Run: func(ctx context.Context) error {
+				failover.Run(ctx)
+				return nil
+			},
+		})
+		consumerDeps = append(consumerDeps, "failover switch")
+	}
+	if mirrorTopicPrefix != "" {
+		log.Printf("Consuming the topics mirrored with prefix %s as well", mirrorTopicPrefix)
+	}
+
+	group.Add(
+		lifecycle.Component{
+			Name: "registration",
+			Run: func(ctx context.Context) error {
+				registry.Run(ctx)
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				registry.Deregister()
+				return nil
+			},
+		},
+		lifecycle.Loop("type stats writer", newTypeStatsWriter(client.Database("jobprocessor"), jobTypes, settings.tunables).Run),
+		lifecycle.Loop("retry scheduler", worker.retries.Run),
+		lifecycle.Loop("run scheduler", newRunScheduler(collection, kafkaConn, jobTypes, retryPollInterval).Run),
+		lifecycle.Loop("deadline checker", func(ctx context.Context) {
+			worker.runDeadlines(ctx, jobTypes)
+		}),
+		lifecycle.Component{
+			Name:      "consumers",
+			DependsOn: consumerDeps,
+			Run: func(ctx context.Context) error {
+				// Processing uses ctx, fetching also stops on a drain
+				fetchCtx, stop := context.WithCancel(ctx)
+				defer stop()
+				stopAfterDrain := context.AfterFunc(drainCtx, stop)
+				defer stopAfterDrain()
+
+				worker.runConsumers(ctx, fetchCtx, conns, jobTypes)
+				return nil
+			},
+			StopTimeout: shutdownTimeout,
+		},
+	)
+
 	log.Println("Worker started, waiting for messages...")
+	if err := group.Run(context.Background(), syscall.SIGINT, syscall.SIGTERM); err != nil {
+		log.Printf("Worker stopped with errors: %v", err)
+		return
+	}
+	log.Println("Worker stopped")
+}
 
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+// runConsumers consumes the topics this worker handles on each cluster, while
+// its Kafka region is active, until the consumers return
+func (w *Worker) runConsumers(ctx, fetchCtx context.Context, conns []*kafkaConnection, jobTypes []string) {
+	var wg sync.WaitGroup
+	consume := func(run func(fetchCtx context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.failover.whileActive(fetchCtx, run)
+		}()
+	}
 
-	log.Println("Shutting down worker...")
-	cancel()
+	for _, conn := range conns {
+		conn := conn
+		for _, sub := range jobSubscriptions(jobTypes) {
+			sub := sub
+			consume(func(fetchCtx context.Context) {
+				w.consumeJobs(ctx, fetchCtx, conn, sub.topic, sub.group)
+			})
+			// The backlog of the other region is consumed from its mirrors in this
+			// worker's cluster, in the same groups so offsets translated by
+			// MirrorMaker are picked up
+			if conn == w.kafka && mirrorTopicPrefix != "" {
+				consume(func(fetchCtx context.Context) {
+					w.consumeJobs(ctx, fetchCtx, conn, mirroredTopic(sub.topic), sub.group)
+				})
+			}
+		}
+
+		// Start cancellations consumer
+		consume(func(fetchCtx context.Context) {
+			w.consumeCancellations(ctx, fetchCtx, conn, topicName(cancellationsTopic))
+		})
+		if conn == w.kafka && mirrorTopicPrefix != "" {
+			consume(func(fetchCtx context.Context) {
+				w.consumeCancellations(ctx, fetchCtx, conn, mirroredTopic(topicName(cancellationsTopic)))
+			})
+		}
+	}
 	wg.Wait()
-	log.Println("Worker stopped")
 }
 
-func consumeJobs(ctx context.Context, brokers string, collection *mongo.Collection, dlqWriter *kafka.Writer) {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     []string{brokers},
-		Topic:       "jobs",
-		GroupID:     "job-worker",
-		MinBytes:    10e3,
-		MaxBytes:    10e6,
-		StartOffset: kafka.LastOffset,
-	})
+// subscription is a jobs topic and the consumer group consuming it
+type subscription struct {
+	topic string
+	group string
+}
+
+// jobSubscriptions lists the jobs topics a worker handling jobTypes consumes
+func jobSubscriptions(jobTypes []string) []subscription {
+	var subs []subscription
+	if canaryWorker {
+		// Canary workers only consume the jobs routed to the canary executor version
+		for _, jobType := range jobTypes {
+			subs = append(subs, subscription{jobCanaryTopic(jobType), groupName("job-worker-" + jobType + "-canary")})
+		}
+		return subs
+	}
+
+	// Start a jobs consumer for each job type this worker handles. Prioritized jobs
+	// have their own consumer so they are not stuck behind the regular backlog.
+	for _, jobType := range jobTypes {
+		subs = append(subs,
+			subscription{jobTopic(jobType), groupName("job-worker-" + jobType)},
+			subscription{jobPriorityTopic(jobType), groupName("job-worker-" + jobType + "-priority")},
+		)
+	}
+
+	// Jobs published before per-type topics existed are drained by workers handling every type
+	if len(jobTypes) == len(allJobTypes) {
+		subs = append(subs, subscription{topicName(legacyJobsTopic), groupName("job-worker")})
+	}
+	return subs
+}
+
+func (w *Worker) consumeJobs(ctx, fetchCtx context.Context, conn *kafkaConnection, topic, groupID string) {
+	reader := kafka.NewReader(conn.readerConfig(topic, groupID))
 	defer reader.Close()
+	metrics.registerReader(conn.metricsTopic(topic), reader)
+
+	handle := func(msg kafka.Message) {
+		// After a hard stop, queued messages are left for redelivery
+		if ctx.Err() != nil || !w.slots.acquire(ctx) {
+			return
+		}
+		defer w.slots.release()
+		w.handleJobMessage(ctx, reader, groupID, msg)
+	}
+
+	// In partition mode the processing loop only hands messages over; stopping
+	// it waits for the messages already handed over to be processed
+	var dispatcher *partitionDispatcher
+	if w.dispatch.modeFor(topic) == dispatchPartition {
+		dispatcher = newPartitionDispatcher(handle)
+		defer dispatcher.close()
+	}
+
+	// Messages are fetched ahead into a bounded buffer, so a slow fetch does not
+	// stall processing and slow processing holds back fetching once it is full
+	buffer := make(chan kafka.Message, w.fetchBuffer)
+	metrics.registerFetchBuffer(conn.metricsTopic(topic), buffer)
+	go fetchMessages(fetchCtx, reader, buffer)
+
+	for msg := range buffer {
+		// Buffered messages are left uncommitted on a drain, for another worker to pick up
+		if fetchCtx.Err() != nil {
+			return
+		}
+		if dispatcher != nil {
+			dispatcher.dispatch(ctx, msg)
+			continue
+		}
+		handle(msg)
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// fetchMessages fetches messages into buffer until ctx is cancelled, then closes it
+func fetchMessages(ctx context.Context, reader *kafka.Reader, buffer chan<- kafka.Message) {
+	defer close(buffer)
 
 	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error reading message: %v", err)
+			continue
+		}
+
 		select {
+		case buffer <- msg:
 		case <-ctx.Done():
 			return
-		default:
-			msg, err := reader.ReadMessage(ctx)
-			if err != nil {
-				if ctx.Err() != nil {
-					return
-				}
-				log.Printf("Error reading message: %v", err)
-				continue
-			}
+		}
+	}
+}
 
-			var jobMsg JobMessage
-			if err := json.Unmarshal(msg.Value, &jobMsg); err != nil {
-				log.Printf("Error unmarshaling job message: %v", err)
-				continue
-			}
+// processOutcome is how handling a job message ended
+type processOutcome int
 
-			log.Printf("Processing job: %s (%s)", jobMsg.JobID, jobMsg.Name)
-			processJob(ctx, collection, dlqWriter, jobMsg)
+const (
+	// jobHandled means the message was applied, or is not to be applied
+	jobHandled processOutcome = iota
+	// jobNotStarted means a transient failure kept the job from starting
+	jobNotStarted
+)
+
+// Jobs kept from starting by a transient failure are tried maxStartAttempts
+// times, waiting startRetryDelay before the first retry and doubling it after
+const (
+	maxStartAttempts = 4
+	startRetryDelay  = time.Second
+)
+
+// handleJobMessage processes a fetched job message and commits it. Messages of
+// one partition are handled one at a time, so offsets are committed in order.
+// A job a transient failure kept from starting is tried again with backoff
+// first; if it still cannot start, it is left pending with no attempt, which
+// the backend's reconciler republishes.
+func (w *Worker) handleJobMessage(ctx context.Context, reader *kafka.Reader, groupID string, msg kafka.Message) {
+	var jobMsg JobMessage
+	if err := decodeMessage(messageKindJob, msg.Value, &jobMsg); err != nil {
+		log.Printf("Error unmarshaling job message: %v", err)
+		w.recordPoisonMessage(ctx, groupID, msg, err)
+		commitMessage(ctx, reader, msg)
+		return
+	}
+
+	if isMirroredTopic(msg.Topic) && w.startedElsewhere(ctx, jobMsg) {
+		log.Printf("Job %s was already started in the other region, skipping mirrored message", jobMsg.JobID)
+		metrics.recordMirrorDuplicate()
+		commitMessage(ctx, reader, msg)
+		return
+	}
+
+	log.Printf("Processing job: %s (%s)", jobMsg.JobID, jobMsg.Name)
+	metrics.jobStarted()
+	w.registry.jobStarted(jobMsg.JobID)
+	outcome := w.processJob(ctx, jobMsg, messageOffset(msg))
+	// The message is handled again in place rather than left uncommitted: the
+	// commit of any later message on the partition would commit it as well
+	delay := startRetryDelay
+	for retry := 1; outcome == jobNotStarted && retry < maxStartAttempts; retry++ {
+		log.Printf("Job %s could not be started, retrying in %s", jobMsg.JobID, delay)
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if ctx.Err() != nil {
+			break
 		}
+		outcome = w.processJob(ctx, jobMsg, messageOffset(msg))
+		delay *= 2
+	}
+	w.registry.jobFinished(jobMsg.JobID)
+	metrics.jobFinished()
+	if outcome == jobNotStarted && ctx.Err() == nil {
+		log.Printf("Job %s could not be started after %d attempts, leaving it pending for the backend's reconciler", jobMsg.JobID, maxStartAttempts)
+	}
+
+	// Only commit once processing is done so a hard stop redelivers the job
+	if ctx.Err() != nil {
+		return
 	}
+	commitMessage(ctx, reader, msg)
 }
 
-func processJob(ctx context.Context, collection *mongo.Collection, dlqWriter *kafka.Writer, jobMsg JobMessage) {
-	objectID, err := primitive.ObjectIDFromHex(jobMsg.JobID)
+// commitMessage commits the offset of a handled message
+func commitMessage(ctx context.Context, reader *kafka.Reader, msg kafka.Message) {
+	if err := reader.CommitMessages(ctx, msg); err != nil {
+		log.Printf("Failed to commit offset %d on topic %s: %v", msg.Offset, msg.Topic, err)
+	}
+}
+
+// processJob runs the job of a message. It returns jobNotStarted when a
+// transient failure, such as MongoDB being unreachable, kept the job from
+// starting, so handling the message again may start it; any other outcome is final.
+func (w *Worker) processJob(ctx context.Context, jobMsg JobMessage, offset string) processOutcome {
+	jobID, err := parseJobID(jobMsg.JobID)
 	if err != nil {
 		log.Printf("Invalid job ID: %s", jobMsg.JobID)
-		return
+		return jobHandled
 	}
 
-	// Update status to processing
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
-		"$set": bson.M{
-			"status":     StatusProcessing,
-			"updated_at": time.Now(),
-		},
-	})
+	// Only one worker processes a job at a time, even when a rebalance or a requeue
+	// delivers it twice. The skipped message is not lost: the holder finishes the
+	// job, or if it never starts it, the job stays pending with no attempt and the
+	// backend's reconciler republishes it.
+	lock, ctx, err := w.locks.Acquire(ctx, jobMsg.JobID)
+	if err == errJobLocked {
+		log.Printf("Job %s is being processed by another attempt, skipping", jobMsg.JobID)
+		metrics.recordLockConflict()
+		return jobHandled
+	}
+	if err != nil {
+		log.Printf("Failed to lock job %s: %v", jobMsg.JobID, err)
+		return jobNotStarted
+	}
+	defer lock.Release()
+
+	// Every read and write below addresses the job by tenant and _id
+	tenant, err := w.jobTenant(ctx, jobID, jobMsg.Tenant)
+	if err != nil {
+		log.Printf("Failed to read the tenant of job %s: %v", jobMsg.JobID, err)
+		return jobNotStarted
+	}
+	jobMsg.Tenant = &tenant
+
+	// A job past its deadline is failed instead of run
+	if pastDeadline(jobMsg.DeadlineAt) {
+		w.failPastDeadline(ctx, jobMsg)
+		return jobHandled
+	}
+
+	// Update status to processing and record the attempt
+	attempt, err := startAttempt(ctx, w.collection, jobID, tenant, jobMsg.DispatchID, offset, workerID)
+	if err == errJobCancelled {
+		log.Printf("Job %s was cancelled before processing, skipping", jobMsg.JobID)
+		w.acknowledgeCancellation(ctx, jobID)
+		return jobHandled
+	}
+	if err == errJobSuperseded {
+		log.Printf("Job %s was republished, skipping superseded message", jobMsg.JobID)
+		return jobHandled
+	}
+	if err == errJobAlreadyProcessed {
+		log.Printf("Job %s message %s was already applied, skipping redelivery", jobMsg.JobID, offset)
+		return jobHandled
+	}
 	if err != nil {
 		log.Printf("Failed to update job status to processing: %v", err)
-		return
+		return jobNotStarted
 	}
 
 	log.Printf("Job %s status updated to processing", jobMsg.JobID)
+	jobLog := w.newJobLogger(jobID, attempt)
+	jobLog.Printf("Attempt %d started on worker %s", attempt, workerID)
 
-	// Simulate processing time (2-5 seconds)
-	processingTime := time.Duration(2+rand.Intn(4)) * time.Second
-	time.Sleep(processingTime)
+	// An offloaded config is loaded once per attempt; failing to load it fails the attempt
+	processErr := w.loadConfig(ctx, &jobMsg)
+	if processErr == nil {
+		// Hooks may reject the attempt or adjust the config it runs with
+		processErr = w.hooks.runPreExecute(ctx, &jobMsg)
+	}
+	if processErr != nil {
+		jobLog.Printf("%v", processErr)
+	} else {
+		// A panic while processing fails the job below, once its retry policy is loaded
+		processErr = runIsolated(jobMsg, func() error {
+			// Analyze jobs defining a workflow run its steps instead
+			steps, err := workflowSteps(jobMsg)
+			if err != nil {
+				jobLog.Printf("%v", err)
+				return err
+			}
+			if len(steps) > 0 {
+				return w.runWorkflow(ctx, jobMsg, jobID, attempt, steps, jobLog.Printf)
+			}
+			w.simulateProcessing(ctx, jobMsg, jobID, attempt, jobLog.Printf)
+			return nil
+		})
+	}
 
 	// Check if job was cancelled during processing
 	var job bson.M
-	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&job)
+	err = w.collection.FindOne(ctx, jobFilter(tenant, jobID)).Decode(&job)
 	if err != nil {
 		log.Printf("Failed to check job status: %v", err)
-		return
+		return jobHandled
 	}
 
 	if job["status"] == StatusCancelling || job["status"] == StatusCancelled {
-		log.Printf("Job %s was cancelled, skipping completion", jobMsg.JobID)
-		return
+		jobLog.Printf("Job was cancelled, skipping completion")
+		if err := finishAttempt(ctx, w.collection, jobID, tenant, attempt, AttemptCancelled, ""); err != nil {
+			log.Printf("Failed to record attempt for job %s: %v", jobMsg.JobID, err)
+		}
+		metrics.recordOutcome(StatusCancelled)
+		if job["status"] == StatusCancelling {
+			w.acknowledgeCancellation(ctx, jobID)
+		}
+		return jobHandled
+	}
+
+	// A job that reached its deadline while processing is failed, not completed or retried
+	if job["status"] == StatusFailed || pastDeadline(jobMsg.DeadlineAt) {
+		w.failPastDeadline(ctx, jobMsg)
+		jobLog.Printf("Job did not complete by its deadline")
+		if err := finishAttempt(ctx, w.collection, jobID, tenant, attempt, AttemptFailed, "Deadline exceeded"); err != nil {
+			log.Printf("Failed to record attempt for job %s: %v", jobMsg.JobID, err)
+		}
+		return jobHandled
+	}
+
+	retryCount := 0
+	if rc, ok := job["retry_count"].(int32); ok {
+		retryCount = int(rc)
+	}
+
+	policy := jobRetryPolicy(job)
+	// A job held for debugging keeps its failure for inspection instead of retrying
+	if job["hold"] != nil && policy.AutoRetry {
+		jobLog.Printf("Job is held, automatic retries are disabled")
+		policy.AutoRetry = false
+	}
+	timing := w.finishTiming(ctx, jobID, jobMsg, job)
+
+	var output executorOutput
+	err = processErr
+	if err == nil {
+		output, err = w.execute(ctx, jobMsg, attempt, w.storage, jobLog.Printf)
+	}
+	if panicErr, ok := err.(*executorPanic); ok {
+		jobLog.Printf("%s", panicErr.report())
+	}
+	if err != nil {
+		w.failJob(ctx, jobID, jobMsg, attempt, retryCount, policy, offset, err, timing)
+		return jobHandled
+	}
+
+	// Jobs that fan out wait for their children instead of completing
+	specs, err := childSpecs(jobMsg)
+	if err == nil && len(specs) > 0 {
+		jobLog.Printf("Spawning %d child jobs", len(specs))
+		err = w.SpawnChildren(ctx, jobMsg, specs)
+	}
+	if err != nil {
+		jobLog.Printf("Failed to spawn child jobs: %v", err)
+		w.failJob(ctx, jobID, jobMsg, attempt, retryCount, policy, offset, fmt.Errorf("Failed to spawn child jobs: %v", err), timing)
+		return jobHandled
+	}
+
+	completion := bson.M{
+		"status":     StatusCompleted,
+		"updated_at": time.Now(),
+	}
+	if len(specs) > 0 {
+		completion["status"] = StatusAwaitingChildren
+		completion["children"] = bson.M{"total": len(specs), "completed": 0, "failed": 0, "cancelled": 0}
+	}
+	for field, value := range timing {
+		completion[field] = value
+	}
+	if output.Artifact != nil {
+		completion["artifact"] = output.Artifact
+	}
+	if output.Result != nil {
+		completion["result"] = output.Result
 	}
 
+	// Update status to completed; the checkpoint is only needed to resume unfinished work
+	_, err = w.collection.UpdateOne(ctx, jobFilter(tenant, jobID), withProcessedOffset(bson.M{
+		"$set":   completion,
+		"$unset": bson.M{"checkpoint": ""},
+	}, offset))
+	if err != nil {
+		log.Printf("Failed to update job status to completed: %v", err)
+		return jobHandled
+	}
+	if err := finishAttempt(ctx, w.collection, jobID, tenant, attempt, AttemptCompleted, ""); err != nil {
+		log.Printf("Failed to record attempt for job %s: %v", jobMsg.JobID, err)
+	}
+
+	if len(specs) > 0 {
+		jobLog.Printf("Job executed, waiting for %d child jobs", len(specs))
+		metrics.recordOutcome(StatusAwaitingChildren)
+		return jobHandled
+	}
+	jobLog.Printf("Job completed successfully")
+	metrics.recordOutcome(StatusCompleted)
+	w.notifier.NotifyTerminal(ctx, jobID, tenant)
+	w.hooks.runPostComplete(jobMsg, JobOutcome{Status: StatusCompleted})
+	return jobHandled
+}
+
+// executorOutput is what an executor produces: a result, and an artifact
+// uploaded to storage for export jobs
+type executorOutput struct {
+	Result   map[string]interface{}
+	Artifact bson.M
+}
+
+// execute runs the executor of a job and returns its output once the result
+// matches the job type's result schema. It only touches artifact storage, so
+// replays can run it without affecting the job. A panicking executor returns an
+// *executorPanic error, and a malformed result a *resultSchemaError.
+func (w *Worker) execute(ctx context.Context, jobMsg JobMessage, attempt int, storage ArtifactStorage, logf func(format string, args ...interface{})) (output executorOutput, err error) {
+	err = runIsolated(jobMsg, func() error {
+		output, err = w.runExecutor(ctx, jobMsg, attempt, storage, logf)
+		return err
+	})
+	if err != nil {
+		return output, err
+	}
+	if err := validateResult(jobMsg.JobType, output.Result); err != nil {
+		logf("%v", err)
+		return executorOutput{}, err
+	}
+	return output, nil
+}
+
+// runExecutor runs the executor of a job's type
+func (w *Worker) runExecutor(ctx context.Context, jobMsg JobMessage, attempt int, storage ArtifactStorage, logf func(format string, args ...interface{})) (executorOutput, error) {
 	// Simulate random failures (20% chance)
 	if rand.Float32() < 0.2 {
-		errorMessage := "Simulated processing failure"
-		retryCount := 0
-		if rc, ok := job["retry_count"].(int32); ok {
-			retryCount = int(rc)
+		logf("Processing failed: simulated processing failure")
+		return executorOutput{}, errors.New("Simulated processing failure")
+	}
+
+	// Encrypted config values are only decrypted here, for the execution itself
+	config, err := w.secrets.decryptConfig(jobMsg.Config)
+	if err != nil {
+		logf("Failed to decrypt job config: %v", err)
+		return executorOutput{}, fmt.Errorf("Failed to decrypt job config: %v", err)
+	}
+	execMsg := jobMsg
+	execMsg.Config = config
+
+	if input := jobMsg.Input; input != nil {
+		logf("Reading input %s (%d bytes) from %s", input.Filename, input.Size, input.Storage)
+	}
+
+	switch jobMsg.JobType {
+	case JobTypeProcess:
+		return executorOutput{Result: map[string]interface{}{"records_processed": 1 + rand.Intn(1000)}}, nil
+	case JobTypeAnalyze:
+		rows := 1 + rand.Intn(10000)
+		mean := rand.Float64() * 100
+		return executorOutput{Result: map[string]interface{}{
+			"rows_analyzed": rows,
+			"mean":          mean,
+			"summary":       fmt.Sprintf("Analyzed %d rows with a mean of %.2f", rows, mean),
+		}}, nil
+	}
+
+	// Export jobs produce an artifact that is uploaded to storage
+	logf("Uploading export artifact")
+	artifact, err := uploadExportArtifact(ctx, storage, execMsg, attempt)
+	if err != nil {
+		logf("Artifact upload failed: %v", err)
+		return executorOutput{}, fmt.Errorf("Failed to upload export artifact: %v", err)
+	}
+	logf("Uploaded artifact %s (%d bytes)", artifact["key"], artifact["size"])
+	return executorOutput{Result: exportResult(execMsg), Artifact: artifact}, nil
+}
+
+// failJob records a failed attempt. Depending on the job's retry policy the job is
+// scheduled for an automatic retry or marked as failed, and published to the DLQ.
+// A job whose executor panicked is failed without retries and always published to
+// the DLQ, with the stack trace of the panic recorded on the attempt. A job whose
+// result violates its type's schema is failed without retries as well.
+func (w *Worker) failJob(ctx context.Context, jobID JobID, jobMsg JobMessage, attempt, retryCount int, policy retryPolicy, offset string, cause error, timing bson.M) {
+	errorMessage, attemptError := cause.Error(), cause.Error()
+	panicErr, panicked := cause.(*executorPanic)
+	if panicked {
+		attemptError = panicErr.report()
+	}
+	// A retry would return a result of the same malformed shape
+	_, malformed := cause.(*resultSchemaError)
+
+	retrying := policy.shouldRetry(retryCount) && !panicked && !malformed
+	retryAt := time.Now().Add(policy.delay(retryCount + 1))
+
+	if retrying {
+		if err := w.scheduleRetry(ctx, jobID, jobMsg.tenant(), retryCount, offset, errorMessage, retryAt, timing); err != nil {
+			log.Printf("Failed to schedule retry of job %s: %v", jobMsg.JobID, err)
+			return
+		}
+	} else {
+		failure := bson.M{
+			"status":        StatusFailed,
+			"error_message": errorMessage,
+			"updated_at":    time.Now(),
+		}
+		for field, value := range timing {
+			failure[field] = value
 		}
 
 		// Update status to failed
-		_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
-			"$set": bson.M{
-				"status":        StatusFailed,
-				"error_message": errorMessage,
-				"updated_at":    time.Now(),
-			},
-		})
+		_, err := w.collection.UpdateOne(ctx, jobFilter(jobMsg.tenant(), jobID), withProcessedOffset(bson.M{
+			"$set": failure,
+		}, offset))
 		if err != nil {
 			log.Printf("Failed to update job status to failed: %v", err)
 			return
 		}
+	}
+	if err := finishAttempt(ctx, w.collection, jobID, jobMsg.tenant(), attempt, AttemptFailed, attemptError); err != nil {
+		log.Printf("Failed to record attempt for job %s: %v", jobMsg.JobID, err)
+	}
 
-		// Publish to DLQ
+	// Publish to DLQ
+	if policy.sendsToDLQ(retrying) || panicked {
 		dlqMsg := DLQMessage{
-			JobID:        jobMsg.JobID,
-			FailedAt:     time.Now(),
-			ErrorMessage: errorMessage,
-			RetryCount:   retryCount,
+			SchemaVersion: messageSchemaVersion,
+			JobID:         jobMsg.JobID,
+			FailedAt:      time.Now(),
+			ErrorMessage:  errorMessage,
+			RetryCount:    retryCount,
 		}
 		dlqData, _ := json.Marshal(dlqMsg)
-		dlqWriter.WriteMessages(ctx, kafka.Message{Value: dlqData})
-
-		log.Printf("Job %s failed and published to DLQ", jobMsg.JobID)
-		return
+		w.dlqWriter.WriteMessages(ctx, kafka.Message{Value: dlqData})
+		w.recordDeadLetter(ctx, jobMsg, dlqMsg)
+		log.Printf("Job %s failure published to DLQ", jobMsg.JobID)
 	}
 
-	// Update status to completed
-	_, err = collection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{
-		"$set": bson.M{
-			"status":     StatusCompleted,
-			"updated_at": time.Now(),
-		},
-	})
-	if err != nil {
-		log.Printf("Failed to update job status to completed: %v", err)
+	if retrying {
+		log.Printf("Job %s failed, retry %d of %d scheduled for %s", jobMsg.JobID, retryCount+1, policy.MaxRetries, retryAt.Format(time.RFC3339))
+		metrics.recordOutcome("retried")
 		return
 	}
 
-	log.Printf("Job %s completed successfully", jobMsg.JobID)
+	log.Printf("Job %s failed", jobMsg.JobID)
+	metrics.recordOutcome(StatusFailed)
+	w.notifier.NotifyTerminal(ctx, jobID, jobMsg.tenant())
+	w.hooks.runPostComplete(jobMsg, JobOutcome{Status: StatusFailed, ErrorMessage: errorMessage})
 }
 
-func consumeCancellations(ctx context.Context, brokers string, collection *mongo.Collection) {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:     []string{brokers},
-		Topic:       "job_cancellations",
-		GroupID:     "job-worker-cancellations",
-		MinBytes:    10e3,
-		MaxBytes:    10e6,
-		StartOffset: kafka.LastOffset,
-	})
+// cancellationsGroup is the consumer group of the cancellations consumer
+const cancellationsGroup = "job-worker-cancellations"
+
+func (w *Worker) consumeCancellations(ctx, fetchCtx context.Context, conn *kafkaConnection, topic string) {
+	reader := kafka.NewReader(conn.readerConfig(topic, groupName(cancellationsGroup)))
 	defer reader.Close()
+	metrics.registerReader(conn.metricsTopic(topic), reader)
 
 	for {
 		select {
-		case <-ctx.Done():
+		case <-fetchCtx.Done():
 			return
 		default:
-			msg, err := reader.ReadMessage(ctx)
+			msg, err := reader.FetchMessage(fetchCtx)
 			if err != nil {
-				if ctx.Err() != nil {
+				if fetchCtx.Err() != nil {
 					return
 				}
 				log.Printf("Error reading cancellation message: %v", err)
@@ -268,52 +1070,73 @@ func consumeCancellations(ctx context.Context, brokers string, collection *mongo
 			}
 
 			var cancelMsg CancellationMessage
-			if err := json.Unmarshal(msg.Value, &cancelMsg); err != nil {
+			if err := decodeMessage(messageKindCancellation, msg.Value, &cancelMsg); err != nil {
 				log.Printf("Error unmarshaling cancellation message: %v", err)
+				w.recordPoisonMessage(ctx, groupName(cancellationsGroup), msg, err)
+				commitMessage(ctx, reader, msg)
 				continue
 			}
 
-			log.Printf("Processing cancellation for job: %s", cancelMsg.JobID)
-			processCancellation(ctx, collection, cancelMsg)
+			log.Printf("Processing cancellation for job: %s (by %s, reason: %q)", cancelMsg.JobID, cancelMsg.CancelledBy, cancelMsg.Reason)
+			w.processCancellation(ctx, cancelMsg)
+			commitMessage(ctx, reader, msg)
 		}
 	}
 }
 
-func processCancellation(ctx context.Context, collection *mongo.Collection, cancelMsg CancellationMessage) {
-	objectID, err := primitive.ObjectIDFromHex(cancelMsg.JobID)
+// processCancellation acknowledges a cancellation once no worker is processing the
+// job. Jobs in flight are acknowledged by the worker processing them when it stops.
+func (w *Worker) processCancellation(ctx context.Context, cancelMsg CancellationMessage) {
+	jobID, err := parseJobID(cancelMsg.JobID)
 	if err != nil {
 		log.Printf("Invalid job ID for cancellation: %s", cancelMsg.JobID)
 		return
 	}
 
-	// Update status to cancelled
-	result, err := collection.UpdateOne(ctx,
-		bson.M{
-			"_id":    objectID,
-			"status": bson.M{"$in": []string{StatusPending, StatusProcessing, StatusCancelling}},
-		},
-		bson.M{
-			"$set": bson.M{
-				"status":     StatusCancelled,
-				"updated_at": time.Now(),
-			},
-		},
-	)
+	tenant, err := w.jobTenant(ctx, jobID, cancelMsg.Tenant)
 	if err != nil {
-		log.Printf("Failed to cancel job: %v", err)
+		log.Printf("Failed to read the tenant of job %s: %v", cancelMsg.JobID, err)
 		return
 	}
 
-	if result.ModifiedCount > 0 {
-		log.Printf("Job %s cancelled successfully", cancelMsg.JobID)
-	} else {
-		log.Printf("Job %s could not be cancelled (may have already completed)", cancelMsg.JobID)
+	var job struct {
+		Status   string `bson:"status"`
+		Attempts []struct {
+			Outcome string `bson:"outcome"`
+		} `bson:"attempts"`
+	}
+	if err := w.collection.FindOne(ctx, jobFilter(tenant, jobID)).Decode(&job); err != nil {
+		log.Printf("Failed to load job %s for cancellation: %v", cancelMsg.JobID, err)
+		return
+	}
+
+	if job.Status != StatusCancelling {
+		log.Printf("Job %s could not be cancelled (status %s)", cancelMsg.JobID, job.Status)
+		return
+	}
+
+	if n := len(job.Attempts); n > 0 && job.Attempts[n-1].Outcome == AttemptRunning {
+		log.Printf("Job %s is being processed, cancellation will be acknowledged when it stops", cancelMsg.JobID)
+		return
 	}
+
+	w.acknowledgeCancellation(ctx, jobID)
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// acknowledgeCancellation publishes the acknowledgement that moves a cancelling job to cancelled
+func (w *Worker) acknowledgeCancellation(ctx context.Context, jobID JobID) {
+	ack := CancellationAckMessage{
+		SchemaVersion: messageSchemaVersion,
+		JobID:         jobID.String(),
+		WorkerID:      workerID,
+		AckedAt:       time.Now(),
+	}
+	data, _ := json.Marshal(ack)
+	if err := w.ackWriter.WriteMessages(ctx, kafka.Message{Value: data}); err != nil {
+		// The backend re-sends unacknowledged cancellations, so this is retried later
+		log.Printf("Failed to acknowledge cancellation of job %s: %v", ack.JobID, err)
+		return
 	}
-	return defaultValue
+
+	log.Printf("Acknowledged cancellation of job %s", ack.JobID)
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestProcessJobNotStarted(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	// Not a retryable error, so the driver answers each command with one response
+	timedOut := mtest.CreateCommandErrorResponse(mtest.CommandError{
+		Code:    50,
+		Name:    "MaxTimeMSExpired",
+		Message: "operation exceeded time limit",
+	})
+	acquired := mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1})
+	released := mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1})
+	locked := mtest.CreateWriteErrorsResponse(mtest.WriteError{
+		Code:    11000,
+		Message: "E11000 duplicate key error collection: jobprocessor.job_locks index: _id_",
+	})
+	tenant := "acme"
+
+	tests := []struct {
+		name      string
+		tenant    *string
+		responses []bson.D
+		want      processOutcome
+	}{
+		{name: "lock unavailable", tenant: &tenant, responses: []bson.D{timedOut}, want: jobNotStarted},
+		{name: "locked by another attempt", tenant: &tenant, responses: []bson.D{locked}, want: jobHandled},
+		{name: "tenant unreadable", responses: []bson.D{acquired, timedOut, released}, want: jobNotStarted},
+		{name: "attempt not started", tenant: &tenant, responses: []bson.D{acquired, timedOut, released}, want: jobNotStarted},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			w := &Worker{collection: mt.Coll, locks: NewJobLocks(mt.Coll, "worker-1", time.Minute)}
+			mt.AddMockResponses(tt.responses...)
+
+			jobMsg := JobMessage{JobID: "65a1b2c3d4e5f60718293a4b", Name: "nightly", JobType: JobTypeProcess, Tenant: tt.tenant}
+			if got := w.processJob(context.Background(), jobMsg, "jobs-process:0:42"); got != tt.want {
+				mt.Errorf("processJob() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	mt.Run("invalid job ID", func(mt *mtest.T) {
+		w := &Worker{collection: mt.Coll, locks: NewJobLocks(mt.Coll, "worker-1", time.Minute)}
+		if got := w.processJob(context.Background(), JobMessage{JobID: "not-an-id"}, ""); got != jobHandled {
+			mt.Errorf("processJob() = %v, want the message handled", got)
+		}
+	})
+}
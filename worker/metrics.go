@@ -0,0 +1,519 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// rateWindow is the window over which the processing rate is averaged
+const rateWindow = 60 * time.Second
+
+// durationBuckets are the upper bounds in seconds of the processing duration histogram
+var durationBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// durationHistogram counts processing durations of one job type into durationBuckets
+type durationHistogram struct {
+	// counts[i] is the number of durations at most durationBuckets[i]; the last entry counts all
+	counts []int64
+	sum    float64
+}
+
+func newDurationHistogram() *durationHistogram {
+	return &durationHistogram{counts: make([]int64, len(durationBuckets)+1)}
+}
+
+func (h *durationHistogram) observe(seconds float64) {
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.counts[len(durationBuckets)]++
+	h.sum += seconds
+}
+
+// hookCall labels the calls of lifecycle hooks
+type hookCall struct {
+	point  string
+	result string
+}
+
+// workerMetrics holds the autoscaling signals exposed by the worker
+type workerMetrics struct {
+	inFlight int64
+	draining int32
+
+	mu        sync.Mutex
+	outcomes  map[string]int64
+	breaches  map[string]int64
+	durations map[string]*durationHistogram
+	poison    map[string]int64
+	locks     map[string]int64
+	panics    map[string]int64
+	hooks     map[hookCall]int64
+	// throttled is the time executors waited on rate limits, in seconds by job type
+	throttled map[string]float64
+	// deferredRetries is how many due retries waited for the retry budget at the last poll
+	deferredRetries int64
+	// mirrorDuplicates counts mirrored job messages skipped as already started in the primary region
+	mirrorDuplicates int64
+	// schemas counts the messages decoded by kind and schema version
+	schemas   map[messageSchema]int64
+	finished  []time.Time
+	readers   map[string]*kafka.Reader
+	buffers   map[string]chan kafka.Message
+	startedAt time.Time
+}
+
+var metrics = newWorkerMetrics()
+
+func newWorkerMetrics() *workerMetrics {
+	return &workerMetrics{
+		outcomes:  make(map[string]int64),
+		breaches:  make(map[string]int64),
+		durations: make(map[string]*durationHistogram),
+		poison:    make(map[string]int64),
+		locks:     make(map[string]int64),
+		panics:    make(map[string]int64),
+		hooks:     make(map[hookCall]int64),
+		schemas:   make(map[messageSchema]int64),
+		throttled: make(map[string]float64),
+		readers:   make(map[string]*kafka.Reader),
+		buffers:   make(map[string]chan kafka.Message),
+		startedAt: time.Now(),
+	}
+}
+
+// registerReader tracks a Kafka reader so its consumer lag can be reported
+func (m *workerMetrics) registerReader(topic string, reader *kafka.Reader) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readers[topic] = reader
+}
+
+// registerFetchBuffer tracks the fetch buffer of a topic so its depth can be reported
+func (m *workerMetrics) registerFetchBuffer(topic string, buffer chan kafka.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.buffers[topic] = buffer
+}
+
+// jobStarted marks a job as in flight
+func (m *workerMetrics) jobStarted() {
+	atomic.AddInt64(&m.inFlight, 1)
+}
+
+// jobFinished marks an in-flight job as done
+func (m *workerMetrics) jobFinished() {
+	atomic.AddInt64(&m.inFlight, -1)
+}
+
+// recordOutcome counts a job that reached the given outcome
+func (m *workerMetrics) recordOutcome(outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.outcomes[outcome]++
+	m.finished = append(m.finished, time.Now())
+	m.pruneLocked()
+}
+
+// recordSLOBreach counts a job of the given type that exceeded its SLO
+func (m *workerMetrics) recordSLOBreach(jobType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breaches[jobType]++
+}
+
+// recordDuration adds the processing duration of a finished job to its type's histogram
+func (m *workerMetrics) recordDuration(jobType string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	histogram, ok := m.durations[jobType]
+	if !ok {
+		histogram = newDurationHistogram()
+		m.durations[jobType] = histogram
+	}
+	histogram.observe(duration.Seconds())
+}
+
+// recordPoisonMessage counts a message of the topic that could not be decoded
+func (m *workerMetrics) recordPoisonMessage(topic string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.poison[topic]++
+}
+
+// recordPanic counts an executor of the given job type that panicked
+func (m *workerMetrics) recordPanic(jobType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.panics[jobType]++
+}
+
+// recordRateLimitWait adds the time an executor of the given job type waited on its rate limit
+func (m *workerMetrics) recordRateLimitWait(jobType string, wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.throttled[jobType] += wait.Seconds()
+}
+
+// setDeferredRetries records how many due retries wait for the retry budget to renew
+func (m *workerMetrics) setDeferredRetries(count int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deferredRetries = count
+}
+
+// recordLockConflict counts a job skipped because another worker held its lock
+func (m *workerMetrics) recordLockConflict() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.locks["conflict"]++
+}
+
+// recordMirrorDuplicate counts a mirrored job message skipped because the job
+// was already started from the primary region's topics
+func (m *workerMetrics) recordMirrorDuplicate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mirrorDuplicates++
+}
+
+// recordMessageSchema counts a message decoded with its kind and schema version
+func (m *workerMetrics) recordMessageSchema(schema messageSchema) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schemas[schema]++
+}
+
+// recordLockLost counts a job abandoned because another worker took over its lock
+func (m *workerMetrics) recordLockLost() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.locks["lost"]++
+}
+
+// recordHookCall counts a call of a lifecycle hook at point by its result
+func (m *workerMetrics) recordHookCall(point, result string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks[hookCall{point: point, result: result}]++
+}
+
+// processingRate returns the number of jobs finished per second over the rate window
+func (m *workerMetrics) processingRate() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pruneLocked()
+	window := rateWindow
+	if uptime := time.Since(m.startedAt); uptime < window {
+		window = uptime
+	}
+	if window <= 0 {
+		return 0
+	}
+	return float64(len(m.finished)) / window.Seconds()
+}
+
+func (m *workerMetrics) pruneLocked() {
+	cutoff := time.Now().Add(-rateWindow)
+	i := 0
+	for i < len(m.finished) && m.finished[i].Before(cutoff) {
+		i++
+	}
+	m.finished = m.finished[i:]
+}
+
+func (m *workerMetrics) setDraining() {
+	atomic.StoreInt32(&m.draining, 1)
+}
+
+func (m *workerMetrics) isDraining() bool {
+	return atomic.LoadInt32(&m.draining) == 1
+}
+
+// writePrometheus writes the metrics in the Prometheus text exposition format
+func (m *workerMetrics) writePrometheus(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	// Outcomes of canary and stable workers are compared by joining on this series
+	fmt.Fprintln(w, "# HELP worker_executor_info Executor version of the worker and whether it is a canary.")
+	fmt.Fprintln(w, "# TYPE worker_executor_info gauge")
+	fmt.Fprintf(w, "worker_executor_info{executor_version=%q,canary=\"%t\"} 1\n", executorVersion, canaryWorker)
+
+	fmt.Fprintln(w, "# HELP worker_jobs_in_flight Number of jobs currently being processed.")
+	fmt.Fprintln(w, "# TYPE worker_jobs_in_flight gauge")
+	fmt.Fprintf(w, "worker_jobs_in_flight %d\n", atomic.LoadInt64(&m.inFlight))
+
+	fmt.Fprintln(w, "# HELP worker_processing_rate Jobs finished per second over the last minute.")
+	fmt.Fprintln(w, "# TYPE worker_processing_rate gauge")
+	fmt.Fprintf(w, "worker_processing_rate %g\n", m.processingRate())
+
+	draining := 0
+	if m.isDraining() {
+		draining = 1
+	}
+	fmt.Fprintln(w, "# HELP worker_draining Whether the worker is draining and no longer fetching jobs.")
+	fmt.Fprintln(w, "# TYPE worker_draining gauge")
+	fmt.Fprintf(w, "worker_draining %d\n", draining)
+
+	m.mu.Lock()
+	outcomes := make([]string, 0, len(m.outcomes))
+	for outcome := range m.outcomes {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+	counts := make([]int64, len(outcomes))
+	for i, outcome := range outcomes {
+		counts[i] = m.outcomes[outcome]
+	}
+	jobTypes := make([]string, 0, len(m.breaches))
+	for jobType := range m.breaches {
+		jobTypes = append(jobTypes, jobType)
+	}
+	sort.Strings(jobTypes)
+	breaches := make([]int64, len(jobTypes))
+	for i, jobType := range jobTypes {
+		breaches[i] = m.breaches[jobType]
+	}
+	durationTypes := make([]string, 0, len(m.durations))
+	for jobType := range m.durations {
+		durationTypes = append(durationTypes, jobType)
+	}
+	sort.Strings(durationTypes)
+	durations := make([]durationHistogram, len(durationTypes))
+	for i, jobType := range durationTypes {
+		histogram := m.durations[jobType]
+		durations[i] = durationHistogram{counts: append([]int64(nil), histogram.counts...), sum: histogram.sum}
+	}
+	poisonTopics := make([]string, 0, len(m.poison))
+	for topic := range m.poison {
+		poisonTopics = append(poisonTopics, topic)
+	}
+	sort.Strings(poisonTopics)
+	poison := make([]int64, len(poisonTopics))
+	for i, topic := range poisonTopics {
+		poison[i] = m.poison[topic]
+	}
+	panicTypes := make([]string, 0, len(m.panics))
+	for jobType := range m.panics {
+		panicTypes = append(panicTypes, jobType)
+	}
+	sort.Strings(panicTypes)
+	panics := make([]int64, len(panicTypes))
+	for i, jobType := range panicTypes {
+		panics[i] = m.panics[jobType]
+	}
+	throttledTypes := make([]string, 0, len(m.throttled))
+	for jobType := range m.throttled {
+		throttledTypes = append(throttledTypes, jobType)
+	}
+	sort.Strings(throttledTypes)
+	throttled := make([]float64, len(throttledTypes))
+	for i, jobType := range throttledTypes {
+		throttled[i] = m.throttled[jobType]
+	}
+	deferredRetries := m.deferredRetries
+	mirrorDuplicates := m.mirrorDuplicates
+	schemas := make([]messageSchema, 0, len(m.schemas))
+	for schema := range m.schemas {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		if schemas[i].kind != schemas[j].kind {
+			return schemas[i].kind < schemas[j].kind
+		}
+		return schemas[i].version < schemas[j].version
+	})
+	schemaCounts := make([]int64, len(schemas))
+	for i, schema := range schemas {
+		schemaCounts[i] = m.schemas[schema]
+	}
+	lockEvents := make([]string, 0, len(m.locks))
+	for event := range m.locks {
+		lockEvents = append(lockEvents, event)
+	}
+	sort.Strings(lockEvents)
+	locks := make([]int64, len(lockEvents))
+	for i, event := range lockEvents {
+		locks[i] = m.locks[event]
+	}
+	hookCalls := make([]hookCall, 0, len(m.hooks))
+	for call := range m.hooks {
+		hookCalls = append(hookCalls, call)
+	}
+	sort.Slice(hookCalls, func(i, j int) bool {
+		if hookCalls[i].point != hookCalls[j].point {
+			return hookCalls[i].point < hookCalls[j].point
+		}
+		return hookCalls[i].result < hookCalls[j].result
+	})
+	hooks := make([]int64, len(hookCalls))
+	for i, call := range hookCalls {
+		hooks[i] = m.hooks[call]
+	}
+	topics := make([]string, 0, len(m.readers))
+	for topic := range m.readers {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	readers := make([]*kafka.Reader, len(topics))
+	for i, topic := range topics {
+		readers[i] = m.readers[topic]
+	}
+	bufferTopics := make([]string, 0, len(m.buffers))
+	for topic := range m.buffers {
+		bufferTopics = append(bufferTopics, topic)
+	}
+	sort.Strings(bufferTopics)
+	buffers := make([]chan kafka.Message, len(bufferTopics))
+	for i, topic := range bufferTopics {
+		buffers[i] = m.buffers[topic]
+	}
+	m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP worker_jobs_processed_total Jobs processed by outcome.")
+	fmt.Fprintln(w, "# TYPE worker_jobs_processed_total counter")
+	for i, outcome := range outcomes {
+		fmt.Fprintf(w, "worker_jobs_processed_total{outcome=%q} %d\n", outcome, counts[i])
+	}
+
+	fmt.Fprintln(w, "# HELP worker_slo_breaches_total Jobs that exceeded their processing SLO by job type.")
+	fmt.Fprintln(w, "# TYPE worker_slo_breaches_total counter")
+	for i, jobType := range jobTypes {
+		fmt.Fprintf(w, "worker_slo_breaches_total{job_type=%q} %d\n", jobType, breaches[i])
+	}
+
+	fmt.Fprintln(w, "# HELP worker_job_duration_seconds Processing duration of finished jobs by job type.")
+	fmt.Fprintln(w, "# TYPE worker_job_duration_seconds histogram")
+	for i, jobType := range durationTypes {
+		histogram := durations[i]
+		for j, bound := range durationBuckets {
+			fmt.Fprintf(w, "worker_job_duration_seconds_bucket{job_type=%q,le=\"%g\"} %d\n", jobType, bound, histogram.counts[j])
+		}
+		total := histogram.counts[len(durationBuckets)]
+		fmt.Fprintf(w, "worker_job_duration_seconds_bucket{job_type=%q,le=\"+Inf\"} %d\n", jobType, total)
+		fmt.Fprintf(w, "worker_job_duration_seconds_sum{job_type=%q} %g\n", jobType, histogram.sum)
+		fmt.Fprintf(w, "worker_job_duration_seconds_count{job_type=%q} %d\n", jobType, total)
+	}
+
+	fmt.Fprintln(w, "# HELP worker_poison_messages_total Messages that could not be decoded by topic.")
+	fmt.Fprintln(w, "# TYPE worker_poison_messages_total counter")
+	for i, topic := range poisonTopics {
+		fmt.Fprintf(w, "worker_poison_messages_total{topic=%q} %d\n", topic, poison[i])
+	}
+
+	fmt.Fprintln(w, "# HELP worker_executor_panics_total Executors that panicked by job type.")
+	fmt.Fprintln(w, "# TYPE worker_executor_panics_total counter")
+	for i, jobType := range panicTypes {
+		fmt.Fprintf(w, "worker_executor_panics_total{job_type=%q} %d\n", jobType, panics[i])
+	}
+
+	fmt.Fprintln(w, "# HELP worker_rate_limit_wait_seconds_total Time executors waited on downstream rate limits by job type.")
+	fmt.Fprintln(w, "# TYPE worker_rate_limit_wait_seconds_total counter")
+	for i, jobType := range throttledTypes {
+		fmt.Fprintf(w, "worker_rate_limit_wait_seconds_total{job_type=%q} %g\n", jobType, throttled[i])
+	}
+
+	fmt.Fprintln(w, "# HELP worker_retries_deferred Due automatic retries waiting for the global retry budget to renew.")
+	fmt.Fprintln(w, "# TYPE worker_retries_deferred gauge")
+	fmt.Fprintf(w, "worker_retries_deferred %d\n", deferredRetries)
+
+	fmt.Fprintln(w, "# HELP worker_job_lock_events_total Jobs skipped or abandoned over job locks by event.")
+	fmt.Fprintln(w, "# TYPE worker_job_lock_events_total counter")
+	for i, event := range lockEvents {
+		fmt.Fprintf(w, "worker_job_lock_events_total{event=%q} %d\n", event, locks[i])
+	}
+
+	fmt.Fprintln(w, "# HELP worker_mirror_duplicates_total Mirrored job messages skipped because the job was already started in the primary region.")
+	fmt.Fprintln(w, "# TYPE worker_mirror_duplicates_total counter")
+	fmt.Fprintf(w, "worker_mirror_duplicates_total %d\n", mirrorDuplicates)
+
+	fmt.Fprintln(w, "# HELP worker_messages_decoded_total Kafka messages decoded by kind and schema version.")
+	fmt.Fprintln(w, "# TYPE worker_messages_decoded_total counter")
+	for i, schema := range schemas {
+		fmt.Fprintf(w, "worker_messages_decoded_total{kind=%q,schema_version=\"%d\"} %d\n", schema.kind, schema.version, schemaCounts[i])
+	}
+
+	fmt.Fprintln(w, "# HELP worker_job_hook_calls_total Lifecycle hook calls by point and result.")
+	fmt.Fprintln(w, "# TYPE worker_job_hook_calls_total counter")
+	for i, call := range hookCalls {
+		fmt.Fprintf(w, "worker_job_hook_calls_total{point=%q,result=%q} %d\n", call.point, call.result, hooks[i])
+	}
+
+	fmt.Fprintln(w, "# HELP worker_fetch_buffer_depth Messages fetched and waiting to be processed per topic.")
+	fmt.Fprintln(w, "# TYPE worker_fetch_buffer_depth gauge")
+	for i, topic := range bufferTopics {
+		fmt.Fprintf(w, "worker_fetch_buffer_depth{topic=%q} %d\n", topic, len(buffers[i]))
+	}
+
+	fmt.Fprintln(w, "# HELP worker_fetch_buffer_capacity Messages that can be fetched ahead of processing per topic.")
+	fmt.Fprintln(w, "# TYPE worker_fetch_buffer_capacity gauge")
+	for i, topic := range bufferTopics {
+		fmt.Fprintf(w, "worker_fetch_buffer_capacity{topic=%q} %d\n", topic, cap(buffers[i]))
+	}
+
+	fmt.Fprintln(w, "# HELP worker_consumer_lag Messages behind the latest offset per topic.")
+	fmt.Fprintln(w, "# TYPE worker_consumer_lag gauge")
+	for i, topic := range topics {
+		// Reader.Lag is always -1 for consumer group readers; the stats carry the
+		// lag observed on the latest fetch whichever partitions are assigned
+		fmt.Fprintf(w, "worker_consumer_lag{topic=%q} %d\n", topic, readers[i].Stats().Lag)
+	}
+}
+
+// newMetricsServer creates the server of /metrics, /version, the /ready check and the /drain trigger on the given address
+func newMetricsServer(addr string, drain func(), ready func(ctx context.Context) error) *http.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		metrics.writePrometheus(w)
+	})
+
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(currentBuild())
+	})
+
+	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+		if metrics.isDraining() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("draining"))
+			return
+		}
+		if err := ready(r.Context()); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.Write([]byte("READY"))
+	})
+
+	mux.HandleFunc("/drain", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		drain()
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("draining"))
+	})
+
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// MongoConfig holds MongoDB connection and pool settings
+type MongoConfig struct {
+	URI                    string        `env:"MONGODB_URI"`
+	MaxPoolSize            uint64        `env:"MONGO_MAX_POOL_SIZE"`
+	MinPoolSize            uint64        `env:"MONGO_MIN_POOL_SIZE"`
+	MaxConnIdleTime        time.Duration `env:"MONGO_MAX_CONN_IDLE_TIME"`
+	ConnectTimeout         time.Duration `env:"MONGO_CONNECT_TIMEOUT"`
+	ServerSelectionTimeout time.Duration `env:"MONGO_SERVER_SELECTION_TIMEOUT"`
+	// StartupTimeout bounds the total time spent retrying the initial connection
+	StartupTimeout time.Duration `env:"MONGO_STARTUP_TIMEOUT"`
+	// InitialBackoff is the first delay between connection attempts; it doubles up to MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// connectMongo connects to MongoDB, retrying with exponential backoff until the
+// server answers a ping or the startup timeout elapses
+func connectMongo(ctx context.Context, config MongoConfig) (*mongo.Client, error) {
+	opts := options.Client().
+		ApplyURI(config.URI).
+		SetMaxPoolSize(config.MaxPoolSize).
+		SetMinPoolSize(config.MinPoolSize).
+		SetMaxConnIdleTime(config.MaxConnIdleTime).
+		SetConnectTimeout(config.ConnectTimeout).
+		SetServerSelectionTimeout(config.ServerSelectionTimeout)
+
+	// The driver connects lazily and reconnects on its own, so the client is created once
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MongoDB configuration: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.StartupTimeout)
+	defer cancel()
+
+	backoff := config.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := pingMongo(ctx, client, config.ServerSelectionTimeout)
+		if err == nil {
+			return client, nil
+		}
+
+		log.Printf("MongoDB not ready (attempt %d): %v, retrying in %s", attempt, err, backoff)
+		select {
+		case <-ctx.Done():
+			client.Disconnect(context.Background())
+			return nil, fmt.Errorf("MongoDB not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > config.MaxBackoff {
+			backoff = config.MaxBackoff
+		}
+	}
+}
+
+// pingMongo checks that the primary is reachable within the timeout
+func pingMongo(ctx context.Context, client *mongo.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return client.Ping(ctx, readpref.Primary())
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fullstack-assessment/notify"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// tenantNotificationsCollection holds the notification settings of each tenant
+const tenantNotificationsCollection = "tenant_notifications"
+
+// jobNotifyConfig mirrors the per-job notify block stored on the job document,
+// and the settings stored for each tenant
+type jobNotifyConfig struct {
+	Email           []string `bson:"email"`
+	SlackWebhookURL string   `bson:"slack_webhook_url"`
+	On              []string `bson:"on"`
+	Template        string   `bson:"template"`
+}
+
+func (c *jobNotifyConfig) toConfig() *notify.Config {
+	if c == nil {
+		return nil
+	}
+	return &notify.Config{
+		Email:           c.Email,
+		SlackWebhookURL: c.SlackWebhookURL,
+		On:              c.On,
+		Template:        c.Template,
+	}
+}
+
+// notificationJob holds the job fields available to notification templates
+type notificationJob struct {
+	ID           JobID            `bson:"_id"`
+	Name         string           `bson:"name"`
+	JobType      string           `bson:"job_type"`
+	Status       string           `bson:"status"`
+	Tenant       string           `bson:"tenant"`
+	ErrorMessage string           `bson:"error_message"`
+	RetryCount   int              `bson:"retry_count"`
+	Notify       *jobNotifyConfig `bson:"notify"`
+}
+
+// Notifier sends the notifications of the jobs this worker completes or fails,
+// using global, per-tenant and per-job settings, and of SLO breaches. Jobs the
+// backend moves to a terminal status, such as acknowledged cancellations, are
+// notified by the backend.
+type Notifier struct {
+	notify     *notify.Notifier
+	collection *mongo.Collection
+	// tenants holds the settings each tenant applies to all its jobs
+	tenants *mongo.Collection
+}
+
+// NewNotifier creates a new notifier
+func NewNotifier(settings notify.Settings, collection *mongo.Collection) *Notifier {
+	return &Notifier{
+		notify:     notify.New(settings),
+		collection: collection,
+		tenants:    collection.Database().Collection(tenantNotificationsCollection),
+	}
+}
+
+// NotifyTerminal loads the job and asynchronously notifies all channels subscribed
+// to its current status. Delivery failures are logged and never affect processing.
+func (n *Notifier) NotifyTerminal(ctx context.Context, jobID JobID, tenant string) {
+	var job notificationJob
+	if err := n.collection.FindOne(ctx, jobFilter(tenant, jobID)).Decode(&job); err != nil {
+		log.Printf("Failed to load job %s for notification: %v", jobID.String(), err)
+		return
+	}
+
+	notification, channels, err := n.notify.StatusNotification(notify.Job{
+		ID:           job.ID.String(),
+		Name:         job.Name,
+		JobType:      job.JobType,
+		Status:       job.Status,
+		ErrorMessage: job.ErrorMessage,
+		RetryCount:   job.RetryCount,
+	}, n.tenantSettings(ctx, job.Tenant).toConfig(), job.Notify.toConfig())
+	if err != nil {
+		log.Printf("Failed to render notification for job %s: %v", jobID.String(), err)
+		return
+	}
+	if len(channels) == 0 {
+		return
+	}
+
+	go n.notify.Send(jobID.String(), channels, notification)
+}
+
+// NotifySLOBreach asynchronously notifies the globally configured channels that a
+// job exceeded its processing SLO
+func (n *Notifier) NotifySLOBreach(ctx context.Context, jobID JobID, name, jobType string, duration, slo time.Duration) {
+	channels := n.notify.GlobalChannels()
+	if len(channels) == 0 {
+		return
+	}
+
+	notification := notify.Notification{
+		Subject: fmt.Sprintf("Job %s breached its SLO", name),
+		Body: fmt.Sprintf("Job %q (%s, %s) took %s, exceeding its SLO of %s",
+			name, jobID.String(), jobType, duration.Round(time.Millisecond), slo),
+	}
+
+	go n.notify.Send(jobID.String(), channels, notification)
+}
+
+// tenantSettings loads the notification settings of a tenant, or nil if it has none
+func (n *Notifier) tenantSettings(ctx context.Context, tenant string) *jobNotifyConfig {
+	var settings jobNotifyConfig
+	err := n.tenants.FindOne(ctx, bson.M{"_id": tenant}).Decode(&settings)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		log.Printf("Failed to load the notification settings of tenant %q: %v", tenant, err)
+		return nil
+	}
+	return &settings
+}
+
+// splitList splits a comma-separated environment value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
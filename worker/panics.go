@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// executorPanic is the error of an executor that panicked. The job is failed
+// with it instead of the panic taking down the worker and its other jobs.
+type executorPanic struct {
+	value interface{}
+	stack []byte
+}
+
+func (e *executorPanic) Error() string {
+	return fmt.Sprintf("Executor panicked: %v", e.value)
+}
+
+// report returns the error with the stack trace of the panic, recorded as the attempt error
+func (e *executorPanic) report() string {
+	return fmt.Sprintf("%s\n\n%s", e.Error(), e.stack)
+}
+
+// runIsolated runs an executor step of a job, turning a panic into an *executorPanic error
+func runIsolated(jobMsg JobMessage, step func() error) (err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			panicErr := &executorPanic{value: value, stack: debug.Stack()}
+			log.Printf("Executor of job %s panicked: %s", jobMsg.JobID, panicErr.report())
+			metrics.recordPanic(jobMsg.JobType)
+			err = panicErr
+		}
+	}()
+	return step()
+}
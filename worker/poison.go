@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxPoisonPayloadBytes bounds the part of an undecodable payload that is kept
+const maxPoisonPayloadBytes = 64 << 10
+
+// recordPoisonMessage counts a message that could not be decoded and stores its
+// raw payload and error in the poison_messages collection, where operators can
+// inspect it through the admin API. The consumer skips the message either way.
+func (w *Worker) recordPoisonMessage(ctx context.Context, groupID string, msg kafka.Message, decodeErr error) {
+	metrics.recordPoisonMessage(msg.Topic)
+
+	payload := msg.Value
+	truncated := len(payload) > maxPoisonPayloadBytes
+	if truncated {
+		payload = payload[:maxPoisonPayloadBytes]
+	}
+
+	// Redeliveries of the same message to the group update the existing record
+	now := time.Now()
+	_, err := w.poison.UpdateOne(ctx,
+		bson.M{
+			"topic":     msg.Topic,
+			"partition": msg.Partition,
+			"offset":    msg.Offset,
+			"consumer":  groupID,
+		},
+		bson.M{
+			"$setOnInsert": bson.M{
+				"key":           string(msg.Key),
+				"payload":       string(payload),
+				"truncated":     truncated,
+				"first_seen_at": now,
+			},
+			"$set": bson.M{
+				"source":       workerID,
+				"error":        decodeErr.Error(),
+				"last_seen_at": now,
+			},
+			"$inc": bson.M{"count": 1},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Failed to record poison message at %s/%d@%d: %v", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+}
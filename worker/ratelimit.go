@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenBucket allows rate calls per second on average, and bursts of up to
+// burst calls after a quiet period
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// reserve takes a token and returns how long to wait before it may be used.
+// Tokens are taken in the order callers arrive, so waiting callers are served fairly.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token that was not used
+func (b *tokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// RateLimits throttles the calls executors make to downstream services, per job
+// type, so a burst of jobs of one type does not overwhelm the services it depends on.
+// The limits apply per worker; divide the downstream limit by the number of
+// workers handling the type.
+type RateLimits struct {
+	buckets map[string]*tokenBucket
+}
+
+// loadRateLimits parses WORKER_RATE_LIMITS, per-type calls per second with an
+// optional burst, e.g. "analyze=5,export=0.5:3". The burst defaults to the rate
+// rounded up. Job types without a limit are not throttled.
+func loadRateLimits(value string) (*RateLimits, error) {
+	limits := &RateLimits{buckets: make(map[string]*tokenBucket)}
+	for _, pair := range splitList(value) {
+		jobType, limit, ok := strings.Cut(pair, "=")
+		jobType, limit = strings.TrimSpace(jobType), strings.TrimSpace(limit)
+		if !ok || jobType == "" {
+			return nil, fmt.Errorf("WORKER_RATE_LIMITS expects job_type=rate[:burst] pairs, got %q", pair)
+		}
+		if _, err := parseJobTypes(jobType); err != nil {
+			return nil, err
+		}
+
+		rateValue, burstValue, hasBurst := strings.Cut(limit, ":")
+		rate, err := strconv.ParseFloat(rateValue, 64)
+		if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("rate limit of %s must be a positive number of calls per second, got %q", jobType, rateValue)
+		}
+		burst := int(math.Ceil(rate))
+		if hasBurst {
+			burst, err = strconv.Atoi(burstValue)
+			if err != nil || burst < 1 {
+				return nil, fmt.Errorf("rate limit burst of %s must be a positive integer, got %q", jobType, burstValue)
+			}
+		}
+		limits.buckets[jobType] = newTokenBucket(rate, burst)
+	}
+	return limits, nil
+}
+
+// Acquire waits until a job of the given type may call its downstream service.
+// It returns ctx's error if ctx is done first.
+func (l *RateLimits) Acquire(ctx context.Context, jobType string) error {
+	bucket, ok := l.buckets[jobType]
+	if !ok {
+		return nil
+	}
+
+	wait := bucket.reserve(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	metrics.recordRateLimitWait(jobType, wait)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		bucket.cancel()
+		return ctx.Err()
+	}
+}
+
+// describe lists the configured limits for the startup log
+func (l *RateLimits) describe() string {
+	var limits []string
+	for _, jobType := range allJobTypes {
+		if bucket, ok := l.buckets[jobType]; ok {
+			limits = append(limits, fmt.Sprintf("%s=%g/s (burst %g)", jobType, bucket.rate, bucket.burst))
+		}
+	}
+	return strings.Join(limits, ", ")
+}
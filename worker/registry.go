@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// executorVersion is the version of the code that runs jobs, recorded with every
+// attempt. It defaults to the build version and is set with EXECUTOR_VERSION.
+var executorVersion = version
+
+// canaryWorker is set on workers running a canary executor version. They consume
+// only the canary topics, which receive the share of jobs routed to the canary.
+var canaryWorker bool
+
+// heartbeatInterval is how often the worker refreshes its registration
+const heartbeatInterval = 10 * time.Second
+
+// Registration records this worker in the workers collection and keeps its
+// heartbeat and in-flight jobs up to date for fleet visibility
+type Registration struct {
+	collection *mongo.Collection
+	id         string
+	startedAt  time.Time
+	// capabilities lists the job types this worker processes
+	capabilities []string
+	// failover reports the worker's Kafka region, if it follows the failover switch
+	failover *failoverSwitch
+
+	mu       sync.Mutex
+	inFlight map[string]struct{}
+}
+
+// NewRegistration creates the registration for this worker
+func NewRegistration(collection *mongo.Collection, id string, capabilities []string, failover *failoverSwitch) *Registration {
+	return &Registration{
+		collection:   collection,
+		id:           id,
+		startedAt:    time.Now(),
+		capabilities: capabilities,
+		failover:     failover,
+		inFlight:     make(map[string]struct{}),
+	}
+}
+
+// Run registers the worker and sends heartbeats until ctx is cancelled
+func (r *Registration) Run(ctx context.Context) {
+	r.heartbeat(ctx)
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.heartbeat(ctx)
+		}
+	}
+}
+
+// jobStarted adds a job to the worker's in-flight list
+func (r *Registration) jobStarted(jobID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight[jobID] = struct{}{}
+}
+
+// jobFinished removes a job from the worker's in-flight list
+func (r *Registration) jobFinished(jobID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.inFlight, jobID)
+}
+
+func (r *Registration) inFlightJobs() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jobs := make([]string, 0, len(r.inFlight))
+	for jobID := range r.inFlight {
+		jobs = append(jobs, jobID)
+	}
+	sort.Strings(jobs)
+	return jobs
+}
+
+// heartbeat upserts the worker document with its current state
+func (r *Registration) heartbeat(ctx context.Context) {
+	hostname, _ := os.Hostname()
+	build := currentBuild()
+
+	fields := bson.M{
+		"hostname":         hostname,
+		"version":          build.Version,
+		"commit":           build.Commit,
+		"build_date":       build.Date,
+		"executor_version": executorVersion,
+		"canary":           canaryWorker,
+		"capabilities":     r.capabilities,
+		"started_at":       r.startedAt,
+		"last_heartbeat":   time.Now(),
+		"in_flight_jobs":   r.inFlightJobs(),
+		"draining":         metrics.isDraining(),
+	}
+	for key, value := range r.failover.heartbeatFields() {
+		fields[key] = value
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": r.id}, bson.M{"$set": fields}, options.Update().SetUpsert(true))
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Failed to send worker heartbeat: %v", err)
+	}
+}
+
+// Deregister removes the worker from the fleet on shutdown
+func (r *Registration) Deregister() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": r.id}); err != nil {
+		log.Printf("Failed to deregister worker: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultShadowCollection stores the results of replayed jobs
+const defaultShadowCollection = "shadow_results"
+
+// replayConfig selects the job messages a worker in replay mode re-processes.
+// In replay mode the worker runs the executor on historical job messages and
+// writes the outcome to the shadow results collection, next to the outcome the
+// job really had, so a new executor version can be validated against past
+// traffic. Job statuses, attempts, logs and the DLQ are left untouched.
+type replayConfig struct {
+	Enabled bool
+	// RunID names the replay; it is the consumer group suffix, so a restarted
+	// replay with the same ID resumes where it stopped
+	RunID string
+	// Topics defaults to the job topics of the types the worker handles and
+	// may name a snapshot topic instead
+	Topics []string
+	// From skips messages published before it; zero replays from the earliest offset
+	From time.Time
+}
+
+func loadReplayConfig(settings ReplaySettings, jobTypes []string) (replayConfig, error) {
+	config := replayConfig{
+		Enabled: settings.Enabled,
+		RunID:   settings.RunID,
+		Topics:  splitList(settings.Topics),
+	}
+	if config.RunID == "" {
+		config.RunID = time.Now().UTC().Format("20060102T150405")
+	}
+	if len(config.Topics) == 0 {
+		for _, jobType := range jobTypes {
+			config.Topics = append(config.Topics, jobTopic(jobType), jobPriorityTopic(jobType))
+		}
+	}
+	if from := settings.From; from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return config, fmt.Errorf("REPLAY_FROM must be an RFC 3339 time: %w", err)
+		}
+		config.From = parsed
+	}
+	return config, nil
+}
+
+// shadowStorage keeps the artifacts of replayed jobs apart from the real ones
+type shadowStorage struct {
+	ArtifactStorage
+	prefix string
+}
+
+func (s shadowStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	return s.ArtifactStorage.Put(ctx, s.prefix+key, data, contentType)
+}
+
+// replay re-processes the configured topics into the shadow results collection until ctx is cancelled
+func (w *Worker) replay(ctx context.Context, config replayConfig, shadow *mongo.Collection) {
+	log.Printf("Replay %s started on topics %v with executor version %s", config.RunID, config.Topics, executorVersion)
+
+	var wg sync.WaitGroup
+	for _, topic := range config.Topics {
+		wg.Add(1)
+		go func(topic string) {
+			defer wg.Done()
+			w.consumeReplay(ctx, config, shadow, topic)
+		}(topic)
+	}
+	wg.Wait()
+}
+
+func (w *Worker) consumeReplay(ctx context.Context, config replayConfig, shadow *mongo.Collection, topic string) {
+	readerConfig := w.kafka.readerConfig(topic, groupName("job-worker-replay-"+config.RunID))
+	// A replay reads the topic from the start whatever KAFKA_START_OFFSET says
+	readerConfig.StartOffset = kafka.FirstOffset
+	reader := kafka.NewReader(readerConfig)
+	defer reader.Close()
+
+	storage := shadowStorage{ArtifactStorage: w.storage, prefix: "shadow/" + config.RunID + "/"}
+
+	for {
+		msg, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error reading replay message: %v", err)
+			continue
+		}
+
+		var jobMsg JobMessage
+		if msg.Time.Before(config.From) {
+			// Published before the replay window
+		} else if err := decodeMessage(messageKindJob, msg.Value, &jobMsg); err != nil {
+			log.Printf("Skipping undecodable replay message at %s: %v", messageOffset(msg), err)
+		} else if err := w.replayJob(ctx, config, shadow, storage, msg, jobMsg); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// Leave the offset uncommitted so the message is replayed again
+			log.Printf("Failed to record shadow result of job %s: %v", jobMsg.JobID, err)
+			continue
+		}
+
+		commitMessage(ctx, reader, msg)
+	}
+}
+
+// replayJob runs the executor on a job message and records the outcome with the
+// outcome the job really had. The job itself is only read.
+func (w *Worker) replayJob(ctx context.Context, config replayConfig, shadow *mongo.Collection, storage ArtifactStorage, msg kafka.Message, jobMsg JobMessage) error {
+	logf := func(format string, args ...interface{}) {
+		log.Printf("[replay %s job %s] %s", config.RunID, jobMsg.JobID, fmt.Sprintf(format, args...))
+	}
+
+	started := time.Now()
+
+	// Simulate processing time (2-5 seconds), as the real execution does
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Duration(2+rand.Intn(4)) * time.Second):
+	}
+
+	status, errorMessage := StatusCompleted, ""
+	var output executorOutput
+	execErr := w.loadConfig(ctx, &jobMsg)
+	if execErr == nil {
+		output, execErr = w.execute(ctx, jobMsg, 0, storage, logf)
+	}
+	if execErr != nil {
+		status, errorMessage = StatusFailed, execErr.Error()
+	}
+
+	result := bson.M{
+		"run_id":           config.RunID,
+		"executor_version": executorVersion,
+		"worker_version":   version,
+		"worker_id":        workerID,
+		"job_id":           jobMsg.JobID,
+		"job_type":         jobMsg.JobType,
+		"dispatch_id":      jobMsg.DispatchID,
+		"status":           status,
+		"error_message":    errorMessage,
+		"duration_ms":      time.Since(started).Milliseconds(),
+		"artifact":         output.Artifact,
+		"result":           output.Result,
+		"replayed_at":      time.Now(),
+	}
+
+	// Record the real outcome alongside for comparison
+	if jobID, err := parseJobID(jobMsg.JobID); err == nil {
+		var original struct {
+			Status       string `bson:"status"`
+			ErrorMessage string `bson:"error_message"`
+			DurationMs   int64  `bson:"duration_ms"`
+		}
+		filter := bson.M{"_id": jobID}
+		if jobMsg.Tenant != nil {
+			filter = jobFilter(*jobMsg.Tenant, jobID)
+		}
+		err := w.collection.FindOne(ctx, filter,
+			options.FindOne().SetProjection(bson.M{"status": 1, "error_message": 1, "duration_ms": 1}),
+		).Decode(&original)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+		if err == nil {
+			result["original"] = bson.M{
+				"status":        original.Status,
+				"error_message": original.ErrorMessage,
+				"duration_ms":   original.DurationMs,
+			}
+			result["matches"] = original.Status == status
+		}
+	}
+
+	// A message replayed again after a restart replaces its earlier result
+	_, err := shadow.UpdateOne(ctx,
+		bson.M{
+			"run_id":    config.RunID,
+			"topic":     msg.Topic,
+			"partition": msg.Partition,
+			"offset":    msg.Offset,
+		},
+		bson.M{"$set": result},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	logf("Replayed as %s with executor version %s", status, executorVersion)
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Types of result fields, named as in JSON Schema
+const (
+	fieldString  = "string"
+	fieldNumber  = "number"
+	fieldInteger = "integer"
+	fieldBoolean = "boolean"
+	fieldObject  = "object"
+	fieldArray   = "array"
+)
+
+// resultSchema declares the shape of the result a job type's executor returns,
+// so consumers of results can rely on it. Fields maps each field to its type;
+// fields not listed are rejected.
+type resultSchema struct {
+	Fields   map[string]string
+	Required []string
+}
+
+// resultSchemas are the result schemas of the job types. A job type without
+// one may return any result.
+var resultSchemas = map[string]resultSchema{
+	JobTypeProcess: {
+		Fields:   map[string]string{"records_processed": fieldInteger, "warnings": fieldArray},
+		Required: []string{"records_processed"},
+	},
+	JobTypeAnalyze: {
+		Fields:   map[string]string{"rows_analyzed": fieldInteger, "mean": fieldNumber, "summary": fieldString},
+		Required: []string{"rows_analyzed", "mean"},
+	},
+	JobTypeExport: {
+		Fields:   map[string]string{"rows": fieldInteger, "format": fieldString},
+		Required: []string{"rows", "format"},
+	},
+}
+
+// resultSchemaError reports an executor result that does not match its job type's schema
+type resultSchemaError struct {
+	jobType    string
+	violations []string
+}
+
+func (e *resultSchemaError) Error() string {
+	return fmt.Sprintf("Result violates the %s result schema: %s", e.jobType, strings.Join(e.violations, "; "))
+}
+
+// validateResult checks the result of a job against its type's schema
+func validateResult(jobType string, result map[string]interface{}) error {
+	schema, ok := resultSchemas[jobType]
+	if !ok {
+		return nil
+	}
+
+	var violations []string
+	for _, field := range schema.Required {
+		if _, ok := result[field]; !ok {
+			violations = append(violations, fmt.Sprintf("%s is required", field))
+		}
+	}
+
+	fields := make([]string, 0, len(result))
+	for field := range result {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		expected, ok := schema.Fields[field]
+		if !ok {
+			violations = append(violations, fmt.Sprintf("%s is not a declared field", field))
+			continue
+		}
+		if actual := fieldType(result[field]); !matchesFieldType(actual, expected) {
+			violations = append(violations, fmt.Sprintf("%s must be %s, got %s", field, article(expected), article(actual)))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &resultSchemaError{jobType: jobType, violations: violations}
+	}
+	return nil
+}
+
+// fieldType returns the JSON Schema type of a result value
+func fieldType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fieldString
+	case bool:
+		return fieldBoolean
+	case int, int32, int64:
+		return fieldInteger
+	case float64:
+		if v == math.Trunc(v) && !math.IsInf(v, 0) {
+			return fieldInteger
+		}
+		return fieldNumber
+	case map[string]interface{}:
+		return fieldObject
+	case []interface{}, []string:
+		return fieldArray
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// matchesFieldType reports whether a value of type actual is valid for a field
+// of type expected; integers are numbers too
+func matchesFieldType(actual, expected string) bool {
+	return actual == expected || (expected == fieldNumber && actual == fieldInteger)
+}
+
+func article(fieldType string) string {
+	switch fieldType {
+	case fieldInteger, fieldObject, fieldArray:
+		return "an " + fieldType
+	case "null":
+		return fieldType
+	}
+	return "a " + fieldType
+}
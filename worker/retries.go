@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Backoff curves and DLQ modes of a retry policy
+const (
+	backoffFixed       = "fixed"
+	backoffLinear      = "linear"
+	backoffExponential = "exponential"
+
+	dlqOnExhausted = "exhausted"
+	dlqAlways      = "always"
+	dlqNever       = "never"
+)
+
+// defaultRetryPollInterval is how often due automatic retries are looked for
+const defaultRetryPollInterval = 5 * time.Second
+
+// retryPolicy is the retry policy the backend stores with each job when it is created
+type retryPolicy struct {
+	MaxRetries     int    `bson:"max_retries"`
+	AutoRetry      bool   `bson:"auto_retry"`
+	Backoff        string `bson:"backoff"`
+	InitialDelayMs int64  `bson:"initial_delay_ms"`
+	MaxDelayMs     int64  `bson:"max_delay_ms"`
+	DLQ            string `bson:"dlq"`
+}
+
+// defaultRetryPolicy applies to jobs created before retry policies existed:
+// no automatic retries, and every failure goes to the DLQ
+var defaultRetryPolicy = retryPolicy{
+	MaxRetries: 3,
+	Backoff:    backoffExponential,
+	DLQ:        dlqOnExhausted,
+}
+
+// jobRetryPolicy returns the retry policy stored with a job
+func jobRetryPolicy(job bson.M) retryPolicy {
+	raw, ok := job["retry_policy"]
+	if !ok {
+		return defaultRetryPolicy
+	}
+	data, err := bson.Marshal(raw)
+	if err != nil {
+		return defaultRetryPolicy
+	}
+	var policy retryPolicy
+	if err := bson.Unmarshal(data, &policy); err != nil {
+		return defaultRetryPolicy
+	}
+	return policy
+}
+
+// shouldRetry reports whether a failed job is retried automatically
+func (p retryPolicy) shouldRetry(retryCount int) bool {
+	return p.AutoRetry && retryCount < p.MaxRetries
+}
+
+// delay returns how long to wait before the given retry, counted from 1
+func (p retryPolicy) delay(retry int) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+
+	delay := float64(p.InitialDelayMs)
+	switch p.Backoff {
+	case backoffLinear:
+		delay *= float64(retry)
+	case backoffExponential:
+		delay *= math.Pow(2, float64(retry-1))
+	}
+	if p.MaxDelayMs > 0 && delay > float64(p.MaxDelayMs) {
+		delay = float64(p.MaxDelayMs)
+	}
+	return time.Duration(delay) * time.Millisecond
+}
+
+// sendsToDLQ reports whether a failure is published to the DLQ
+func (p retryPolicy) sendsToDLQ(retrying bool) bool {
+	switch p.DLQ {
+	case dlqNever:
+		return false
+	case dlqAlways:
+		return true
+	default:
+		return !retrying
+	}
+}
+
+// scheduleRetry returns a failed job to pending with a new dispatch ID and has
+// the retry scheduler publish it again at retryAt, in a single update so the
+// job is never pending without being due. The failed message's offset is
+// recorded with it, like every other status change.
+func (w *Worker) scheduleRetry(ctx context.Context, jobID JobID, tenant string, retryCount int, offset, errorMessage string, retryAt time.Time, timing bson.M) error {
+	fields := bson.M{
+		"status":        StatusPending,
+		"error_message": errorMessage,
+		"retry_count":   retryCount + 1,
+		"dispatch_id":   primitive.NewObjectID().Hex(),
+		"updated_at":    time.Now(),
+	}
+	for field, value := range timing {
+		fields[field] = value
+	}
+	for field, value := range w.retries.DueFields(retryAt) {
+		fields[field] = value
+	}
+
+	_, err := w.collection.UpdateOne(ctx, jobFilter(tenant, jobID), withProcessedOffset(bson.M{"$set": fields}, offset))
+	return err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// retryBudgetID is the _id of the document counting the retries of the current minute
+const retryBudgetID = "automatic_retries"
+
+// retryBudget caps the automatic retries published per minute across all
+// workers, so a dependency recovering from an outage is not hit by every job
+// that failed during it at once. Retries over the cap stay in the delayed-retry
+// queue, the pending jobs with a due retry_at, and are published in order as
+// the budget renews. The count of the current minute is shared through one
+// document in the retry_budget collection.
+type retryBudget struct {
+	collection *mongo.Collection
+	perMinute  int
+}
+
+// newRetryBudget creates a budget of perMinute retries; zero or less means no cap
+func newRetryBudget(collection *mongo.Collection, perMinute int) *retryBudget {
+	return &retryBudget{collection: collection, perMinute: perMinute}
+}
+
+// take claims one retry from the budget of the current minute, reporting
+// false when it is spent
+func (b *retryBudget) take(ctx context.Context, now time.Time) (bool, error) {
+	if b.perMinute <= 0 {
+		return true, nil
+	}
+
+	window := now.UTC().Truncate(time.Minute)
+	// Only a document of an earlier minute or with budget left matches, so the
+	// upsert of a spent budget fails on the duplicate _id
+	filter := bson.M{
+		"_id": retryBudgetID,
+		"$or": bson.A{
+			bson.M{"window": bson.M{"$ne": window}},
+			bson.M{"count": bson.M{"$lt": b.perMinute}},
+		},
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"count": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$window", window}},
+				bson.M{"$add": bson.A{"$count", 1}},
+				1,
+			}},
+			"window": window,
+		}}},
+	}
+	_, err := b.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// refund returns a retry taken at now that was not published
+func (b *retryBudget) refund(ctx context.Context, now time.Time) error {
+	if b.perMinute <= 0 {
+		return nil
+	}
+
+	filter := bson.M{
+		"_id":    retryBudgetID,
+		"window": now.UTC().Truncate(time.Minute),
+		"count":  bson.M{"$gt": 0},
+	}
+	_, err := b.collection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"count": -1}})
+	return err
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Fields holding the time a pending job is due to be published
+const (
+	// dueRetry is set by workers when they schedule an automatic retry
+	dueRetry = "retry_at"
+	// dueScheduled is set by the backend for jobs created to run later
+	dueScheduled = "scheduled_at"
+)
+
+// Scheduler publishes pending jobs to their topic once they are due, for retry
+// backoff and jobs scheduled to run later, since Kafka has no delayed delivery
+type Scheduler interface {
+	// Schedule has a pending job published at due, for callers not writing the job otherwise
+	Schedule(ctx context.Context, jobID JobID, tenant string, due time.Time) error
+	// DueFields returns the fields to $set on a job to have it published at
+	// due, for callers returning it to pending in the same update
+	DueFields(due time.Time) bson.M
+	// Run publishes jobs as they come due until ctx is cancelled
+	Run(ctx context.Context)
+}
+
+// pollingScheduler is a Scheduler keeping the due time in a field of the job
+// and polling for due jobs, rather than tiered delay topics, so scheduled jobs
+// survive worker restarts, can be held or cancelled while they wait, and need
+// no extra topics. Every worker polls, and a job is claimed by clearing its
+// due time. A sharded jobs collection only finds and modifies a job by its full
+// shard key, so due jobs are looked up first and then claimed by their key.
+type pollingScheduler struct {
+	collection *mongo.Collection
+	kafka      *kafkaConnection
+	jobTypes   []string
+	// interval is the reloadable time between polls
+	interval func() time.Duration
+	// field holds the due time
+	field string
+	// what names the publications in logs
+	what string
+	// budget caps the publications per minute, if not nil
+	budget *retryBudget
+}
+
+// newRetryScheduler creates the scheduler of automatic retries
+func newRetryScheduler(collection *mongo.Collection, kafkaConn *kafkaConnection, jobTypes []string, budget *retryBudget, interval func() time.Duration) *pollingScheduler {
+	return &pollingScheduler{
+		collection: collection,
+		kafka:      kafkaConn,
+		jobTypes:   jobTypes,
+		interval:   interval,
+		field:      dueRetry,
+		what:       "automatic retry",
+		budget:     budget,
+	}
+}
+
+// newRunScheduler creates the scheduler of jobs created to run later
+func newRunScheduler(collection *mongo.Collection, kafkaConn *kafkaConnection, jobTypes []string, interval func() time.Duration) *pollingScheduler {
+	return &pollingScheduler{
+		collection: collection,
+		kafka:      kafkaConn,
+		jobTypes:   jobTypes,
+		interval:   interval,
+		field:      dueScheduled,
+		what:       "scheduled run",
+	}
+}
+
+// Schedule records when the job is due
+func (s *pollingScheduler) Schedule(ctx context.Context, jobID JobID, tenant string, due time.Time) error {
+	filter := jobFilter(tenant, jobID)
+	filter["status"] = StatusPending
+	_, err := s.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{s.field: due}})
+	return err
+}
+
+// DueFields returns the due time as the field the scheduler polls
+func (s *pollingScheduler) DueFields(due time.Time) bson.M {
+	return bson.M{s.field: due}
+}
+
+// Run publishes due jobs until ctx is cancelled
+func (s *pollingScheduler) Run(ctx context.Context) {
+	ticker := newReloadableTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			ticker.refresh()
+			s.publishDue(ctx)
+		}
+	}
+}
+
+func (s *pollingScheduler) publishDue(ctx context.Context) {
+	for {
+		var job struct {
+			ID          JobID                  `bson:"_id"`
+			Tenant      string                 `bson:"tenant"`
+			Name        string                 `bson:"name"`
+			JobType     string                 `bson:"job_type"`
+			Config      map[string]interface{} `bson:"config"`
+			ConfigRef   *BlobRef               `bson:"config_ref"`
+			AffinityKey string                 `bson:"affinity_key"`
+			Priority    string                 `bson:"priority"`
+			Canary      bool                   `bson:"canary"`
+			DispatchID  string                 `bson:"dispatch_id"`
+			CreatedAt   time.Time              `bson:"created_at"`
+			DeadlineAt  *time.Time             `bson:"deadline_at"`
+			Input       *JobInput              `bson:"input"`
+		}
+		now := time.Now()
+		due := bson.M{
+			"status":   StatusPending,
+			"job_type": bson.M{"$in": s.jobTypes},
+			s.field:    bson.M{"$lte": now},
+			// Held jobs keep their due time and are published once released
+			"hold": bson.M{"$exists": false},
+		}
+		var candidate struct {
+			ID     JobID  `bson:"_id"`
+			Tenant string `bson:"tenant"`
+		}
+		err := s.collection.FindOne(ctx, due,
+			options.FindOne().SetSort(bson.M{s.field: 1}).SetProjection(bson.M{"tenant": 1}),
+		).Decode(&candidate)
+		if err == mongo.ErrNoDocuments {
+			if s.budget != nil {
+				metrics.setDeferredRetries(0)
+			}
+			return
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to look for jobs due for %s: %v", s.what, err)
+			}
+			return
+		}
+
+		// The budget is taken before the job is claimed, so a spent budget
+		// leaves the job untouched in its place in the queue
+		if s.budget != nil {
+			allowed, err := s.budget.take(ctx, now)
+			if err != nil {
+				log.Printf("Failed to take from the retry budget: %v", err)
+				return
+			}
+			if !allowed {
+				deferred, err := s.collection.CountDocuments(ctx, due)
+				if err == nil {
+					metrics.setDeferredRetries(deferred)
+					log.Printf("Retry budget of %d per minute is spent, deferring %d due retries", s.budget.perMinute, deferred)
+				}
+				return
+			}
+		}
+
+		claim := jobFilter(candidate.Tenant, candidate.ID)
+		for field, condition := range due {
+			claim[field] = condition
+		}
+		err = s.collection.FindOneAndUpdate(ctx,
+			claim,
+			bson.M{
+				"$unset": bson.M{s.field: ""},
+				"$set":   bson.M{"updated_at": now},
+			},
+		).Decode(&job)
+		if err != nil {
+			// The job was not claimed, so neither was the budget taken for it
+			s.refund(ctx, now)
+		}
+		if err == mongo.ErrNoDocuments {
+			// Another worker claimed it first
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to claim jobs due for %s: %v", s.what, err)
+			}
+			return
+		}
+
+		message := JobMessage{
+			SchemaVersion: messageSchemaVersion,
+			JobID:         job.ID.String(),
+			Name:          job.Name,
+			JobType:       job.JobType,
+			Config:        job.Config,
+			ConfigRef:     job.ConfigRef,
+			AffinityKey:   job.AffinityKey,
+			DispatchID:    job.DispatchID,
+			CreatedAt:     job.CreatedAt,
+			DeadlineAt:    job.DeadlineAt,
+			Input:         job.Input,
+			Tenant:        &job.Tenant,
+		}
+		topic := jobTopic(job.JobType)
+		if job.Priority == "high" {
+			topic = jobPriorityTopic(job.JobType)
+		} else if job.Canary {
+			topic = jobCanaryTopic(job.JobType)
+		}
+
+		if err := s.kafka.publishJob(ctx, topic, message); err != nil {
+			// Put the job back so it is published on a later poll
+			log.Printf("Failed to publish %s of job %s: %v", s.what, message.JobID, err)
+			s.refund(ctx, now)
+			s.putBack(ctx, job.ID, job.Tenant, now)
+			return
+		}
+		log.Printf("Published %s of job %s to %s", s.what, message.JobID, topic)
+	}
+}
+
+// putBackAttempts and putBackDelay bound how often a claimed job that was not
+// published is put back before it is left to the operators
+const (
+	putBackAttempts = 3
+	putBackDelay    = time.Second
+)
+
+// putBack makes a claimed job that could not be published due again. A job
+// that cannot be put back stays pending without a due time, which nothing
+// publishes, so the failure is retried and then logged for an operator.
+func (s *pollingScheduler) putBack(ctx context.Context, jobID JobID, tenant string, due time.Time) {
+	var err error
+	for attempt := 1; attempt <= putBackAttempts; attempt++ {
+		if err = s.Schedule(ctx, jobID, tenant, due); err == nil {
+			return
+		}
+		log.Printf("Failed to put %s of job %s back, attempt %d of %d: %v", s.what, jobID, attempt, putBackAttempts, err)
+		if attempt < putBackAttempts {
+			select {
+			case <-ctx.Done():
+				// A shutting-down worker still tries once more below
+				ctx = context.WithoutCancel(ctx)
+			case <-time.After(putBackDelay):
+			}
+		}
+	}
+	log.Printf("Gave up putting %s of job %s back; it stays pending until requeued: %v", s.what, jobID, err)
+}
+
+// refund returns the budget taken at now for a job that was not published
+func (s *pollingScheduler) refund(ctx context.Context, now time.Time) {
+	if s.budget == nil {
+		return
+	}
+	if err := s.budget.refund(ctx, now); err != nil {
+		log.Printf("Failed to refund the retry budget: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+)
+
+// messageSchemaVersion is the version of the layout of the Kafka messages the
+// worker reads and writes, carried in their schema_version field. Fields added
+// to a message do not change it: workers ignore fields they do not know, and
+// handle messages without a field they expect as they do today. It is raised
+// only when the meaning of an existing field changes, with an upgrade
+// registered in messageUpgrades to bring older messages to the new layout.
+// Messages published before versioning carry no version and are version 1.
+const messageSchemaVersion = 1
+
+// Kinds of versioned messages
+const (
+	messageKindJob          = "job"
+	messageKindCancellation = "cancellation"
+)
+
+// messageUpgrade rewrites a decoded message of one version into the layout of
+// the next one
+type messageUpgrade func(message map[string]interface{})
+
+// messageUpgrades holds, by kind and by the version they upgrade from, the
+// steps that bring older messages to messageSchemaVersion. Deploy workers with
+// an upgrade before the backend publishes the new version.
+var messageUpgrades = map[string]map[int]messageUpgrade{}
+
+// messageSchema labels the messages of a kind and schema version
+type messageSchema struct {
+	kind    string
+	version int
+}
+
+// newerSchemasLogged remembers the newer schemas already logged
+var newerSchemasLogged sync.Map
+
+// decodeMessage decodes a message of kind into v, whatever its schema version.
+// Older messages are upgraded to the current layout; newer ones are decoded as
+// far as the current layout goes, as their new fields are unknown here.
+func decodeMessage(kind string, data []byte, v interface{}) error {
+	var envelope struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	version := envelope.SchemaVersion
+	if version == 0 {
+		version = 1
+	}
+	schema := messageSchema{kind: kind, version: version}
+	metrics.recordMessageSchema(schema)
+
+	if version > messageSchemaVersion {
+		if _, logged := newerSchemasLogged.LoadOrStore(schema, true); !logged {
+			log.Printf("Decoding %s messages of schema version %d with version %d; upgrade the worker to read their new fields", kind, version, messageSchemaVersion)
+		}
+	}
+	if version >= messageSchemaVersion || len(messageUpgrades[kind]) == 0 {
+		return json.Unmarshal(data, v)
+	}
+
+	var message map[string]interface{}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return err
+	}
+	for ; version < messageSchemaVersion; version++ {
+		if upgrade := messageUpgrades[kind][version]; upgrade != nil {
+			upgrade(message)
+		}
+	}
+	message["schema_version"] = messageSchemaVersion
+	upgraded, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(upgraded, v)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// envelopeVersion marks a job config value encrypted by the backend
+const envelopeVersion = "v1"
+
+// errNoSecretsKey is returned when a job has encrypted config but no key is configured
+var errNoSecretsKey = errors.New("job config is encrypted but SECRETS_KEY is not set")
+
+// configDecryptor decrypts the config values the backend encrypted at rest.
+// Each value carries its own data key, wrapped with the shared SECRETS_KEY.
+type configDecryptor struct {
+	keyID string
+	// wrapper is nil when no key is configured
+	wrapper cipher.AEAD
+}
+
+// loadConfigDecryptor decodes the configured wrapping key
+func loadConfigDecryptor(settings SecretsSettings) (*configDecryptor, error) {
+	d := &configDecryptor{keyID: settings.KeyID}
+
+	encoded := settings.Key
+	if encoded == "" {
+		return d, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SECRETS_KEY: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("SECRETS_KEY must be 32 bytes, got %d", len(key))
+	}
+	if d.wrapper, err = newAEAD(key); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// decryptConfig returns a copy of config with encrypted values replaced by their
+// plaintext. The result must only be used for execution and never logged or stored.
+func (d *configDecryptor) decryptConfig(config map[string]interface{}) (map[string]interface{}, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	decrypted := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		envelope, ok := value.(map[string]interface{})
+		if !ok || envelope["_encrypted"] != envelopeVersion {
+			decrypted[key] = value
+			continue
+		}
+		plaintext, err := d.decrypt(envelope)
+		if err != nil {
+			return nil, fmt.Errorf("config.%s: %w", key, err)
+		}
+		decrypted[key] = plaintext
+	}
+	return decrypted, nil
+}
+
+func (d *configDecryptor) decrypt(envelope map[string]interface{}) (interface{}, error) {
+	if d.wrapper == nil {
+		return nil, errNoSecretsKey
+	}
+	if keyID, _ := envelope["key_id"].(string); keyID != d.keyID {
+		return nil, fmt.Errorf("value was encrypted with unknown key %q", keyID)
+	}
+
+	wrapped, err := decodeField(envelope, "wrapped_key")
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := decodeField(envelope, "nonce")
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := decodeField(envelope, "ciphertext")
+	if err != nil {
+		return nil, err
+	}
+
+	// The wrapped data key is the wrapping nonce followed by the sealed key
+	size := d.wrapper.NonceSize()
+	if len(wrapped) < size {
+		return nil, errors.New("wrapped key is too short")
+	}
+	dataKey, err := d.wrapper.Open(nil, wrapped[:size], wrapped[size:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(plaintext, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeField(envelope map[string]interface{}, field string) ([]byte, error) {
+	encoded, _ := envelope[field].(string)
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("envelope has an invalid %s", field)
+	}
+	return data, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
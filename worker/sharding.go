@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// jobTenant returns the tenant of a job, which leads the shard key of the jobs
+// collection. Messages carry it; those published before they did are looked up
+// by _id, the only lookup without the tenant.
+func (w *Worker) jobTenant(ctx context.Context, jobID JobID, tenant *string) (string, error) {
+	if tenant != nil {
+		return *tenant, nil
+	}
+
+	var owner struct {
+		Tenant string `bson:"tenant"`
+	}
+	err := w.collection.FindOne(ctx, bson.M{"_id": jobID}, options.FindOne().SetProjection(bson.M{"tenant": 1})).Decode(&owner)
+	return owner.Tenant, err
+}
+
+// jobFilter matches a job by the full shard key of the jobs collection. A
+// sharded collection routes the query to the job's shard with it, and only
+// keeps _id unique per shard, so jobs of different tenants may share an _id.
+func jobFilter(tenant string, jobID JobID) bson.M {
+	return bson.M{"tenant": tenant, "_id": jobID}
+}
+
+// tenant returns the tenant of the message's job. processJob resolves it for
+// messages published without one before the job is read or written.
+func (m JobMessage) tenant() string {
+	if m.Tenant == nil {
+		return ""
+	}
+	return *m.Tenant
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// finishTiming returns the fields recording when a job finished and how long it
+// ran, records the duration in the metrics and reports a breach if it exceeded the job's SLO
+func (w *Worker) finishTiming(ctx context.Context, jobID JobID, jobMsg JobMessage, job bson.M) bson.M {
+	now := time.Now()
+	fields := bson.M{"finished_at": now}
+
+	startedAt, ok := job["started_at"].(primitive.DateTime)
+	if !ok {
+		return fields
+	}
+	duration := now.Sub(startedAt.Time())
+	fields["duration_ms"] = duration.Milliseconds()
+	metrics.recordDuration(jobMsg.JobType, duration)
+
+	slo := time.Duration(toInt64(job["slo_ms"])) * time.Millisecond
+	if slo > 0 && duration > slo {
+		fields["slo_breached"] = true
+		log.Printf("Job %s breached its SLO: took %s, SLO %s", jobMsg.JobID, duration.Round(time.Millisecond), slo)
+		metrics.recordSLOBreach(jobMsg.JobType)
+		w.notifier.NotifySLOBreach(ctx, jobID, jobMsg.Name, jobMsg.JobType, duration, slo)
+	}
+
+	return fields
+}
+
+// toInt64 converts a numeric BSON value to int64
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	default:
+		return 0
+	}
+}
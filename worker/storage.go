@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ArtifactStorage stores job output artifacts
+type ArtifactStorage interface {
+	// Name identifies the storage backend recorded on the job
+	Name() string
+	// Put uploads data under key and returns the artifact's canonical URL
+	Put(ctx context.Context, key string, data []byte, contentType string) (string, error)
+}
+
+// StorageConfig holds the artifact storage settings
+type StorageConfig struct {
+	Backend         string `env:"ARTIFACT_STORAGE"`
+	Dir             string `env:"ARTIFACT_DIR"`
+	Endpoint        string `env:"S3_ENDPOINT"`
+	Region          string `env:"S3_REGION"`
+	Bucket          string `env:"S3_BUCKET"`
+	AccessKeyID     string `env:"S3_ACCESS_KEY_ID"`
+	SecretAccessKey string `env:"S3_SECRET_ACCESS_KEY"`
+}
+
+// NewArtifactStorage creates the artifact storage selected by the config
+func NewArtifactStorage(config StorageConfig) (ArtifactStorage, error) {
+	switch config.Backend {
+	case "", "local":
+		return &localStorage{dir: config.Dir}, nil
+	case "s3", "gcs":
+		if config.Bucket == "" {
+			return nil, fmt.Errorf("%s storage requires a bucket", config.Backend)
+		}
+		endpoint := config.Endpoint
+		region := config.Region
+		if config.Backend == "gcs" {
+			// GCS is accessed through its S3-compatible XML API with HMAC keys
+			if endpoint == "" {
+				endpoint = "https://storage.googleapis.com"
+			}
+			if region == "" {
+				region = "auto"
+			}
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+		}
+		return &s3Storage{
+			name:            config.Backend,
+			endpoint:        strings.TrimRight(endpoint, "/"),
+			region:          region,
+			bucket:          config.Bucket,
+			accessKeyID:     config.AccessKeyID,
+			secretAccessKey: config.SecretAccessKey,
+			client:          &http.Client{Timeout: 60 * time.Second},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown artifact storage backend %q", config.Backend)
+	}
+}
+
+// localStorage writes artifacts to a directory shared with the backend
+type localStorage struct {
+	dir string
+}
+
+func (s *localStorage) Name() string {
+	return "local"
+}
+
+func (s *localStorage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return "file://" + path, nil
+}
+
+// s3Storage uploads artifacts to S3 or an S3-compatible service using SigV4 signing
+type s3Storage struct {
+	name            string
+	endpoint        string
+	region          string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+	client          *http.Client
+}
+
+func (s *s3Storage) Name() string {
+	return s.name
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	objectURL := s.endpoint + "/" + s.bucket + "/" + uriEncodePath(key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return fmt.Sprintf("%s://%s/%s", s.name, s.bucket, key), nil
+}
+
+// sign adds AWS Signature Version 4 headers to the request
+func (s *s3Storage) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"content-type":         req.Header.Get("Content-Type"),
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	signature := hex.EncodeToString(hmacSHA256(s.signingKey(date), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+}
+
+func (s *s3Storage) signingKey(date string) []byte {
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// uriEncodePath encodes each path segment as required by SigV4
+func uriEncodePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func uriEncode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
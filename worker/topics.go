@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// errTopicsNotVerified is reported until the first topic verification has run
+var errTopicsNotVerified = errors.New("Kafka topics have not been verified yet")
+
+// topicVerifyInterval is how often verification is retried until the topics exist
+const topicVerifyInterval = 15 * time.Second
+
+// TopicSettings controls whether missing topics are created and how
+type TopicSettings struct {
+	Create            bool `env:"KAFKA_CREATE_TOPICS"`
+	Partitions        int  `env:"KAFKA_TOPIC_PARTITIONS"`
+	ReplicationFactor int  `env:"KAFKA_TOPIC_REPLICATION_FACTOR"`
+}
+
+// requiredTopics lists the topics a worker handling the given job types consumes or publishes to
+func requiredTopics(jobTypes []string, canary bool) []string {
+	topics := []string{topicName(cancellationsTopic), topicName(cancellationAcksTopic), topicName(dlqTopic)}
+	for _, jobType := range jobTypes {
+		if canary {
+			topics = append(topics, jobCanaryTopic(jobType))
+			continue
+		}
+		topics = append(topics, jobTopic(jobType), jobPriorityTopic(jobType))
+	}
+	if len(jobTypes) == len(allJobTypes) && !canary {
+		topics = append(topics, topicName(legacyJobsTopic))
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// topicVerifier checks that the required topics exist, creating them when
+// allowed, and reports the result for the readiness check
+type topicVerifier struct {
+	conn     *kafkaConnection
+	topics   []string
+	settings TopicSettings
+
+	mu  sync.RWMutex
+	err error
+}
+
+func newTopicVerifier(conn *kafkaConnection, topics []string, settings TopicSettings) *topicVerifier {
+	return &topicVerifier{
+		conn:     conn,
+		topics:   topics,
+		settings: settings,
+		err:      errTopicsNotVerified,
+	}
+}
+
+// Run verifies the topics, retrying until they all exist or ctx is cancelled
+func (v *topicVerifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(topicVerifyInterval)
+	defer ticker.Stop()
+
+	for {
+		err := v.verify(ctx)
+		v.mu.Lock()
+		v.err = err
+		v.mu.Unlock()
+
+		if err == nil {
+			log.Printf("Verified Kafka topics: %s", strings.Join(v.topics, ", "))
+			return
+		}
+		log.Printf("Kafka topic verification failed: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ready returns nil once every required topic exists, or the reason it does not
+func (v *topicVerifier) Ready() error {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.err
+}
+
+func (v *topicVerifier) verify(ctx context.Context) error {
+	client := v.conn.client()
+
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to list Kafka topics: %w", err)
+	}
+	existing := make(map[string]bool)
+	for _, topic := range metadata.Topics {
+		if topic.Error == nil {
+			existing[topic.Name] = true
+		}
+	}
+
+	var missing []string
+	for _, topic := range v.topics {
+		if !existing[topic] {
+			missing = append(missing, topic)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if !v.settings.Create {
+		return fmt.Errorf("missing Kafka topics %s; create them or set KAFKA_CREATE_TOPICS=true", strings.Join(missing, ", "))
+	}
+
+	configs := make([]kafka.TopicConfig, len(missing))
+	for i, topic := range missing {
+		configs[i] = kafka.TopicConfig{
+			Topic:             topic,
+			NumPartitions:     v.settings.Partitions,
+			ReplicationFactor: v.settings.ReplicationFactor,
+		}
+	}
+	created, err := client.CreateTopics(ctx, &kafka.CreateTopicsRequest{Topics: configs})
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka topics %s: %w", strings.Join(missing, ", "), err)
+	}
+	for topic, err := range created.Errors {
+		// Another worker or the backend may have created it in the meantime
+		if err != nil && !errors.Is(err, kafka.TopicAlreadyExists) {
+			return fmt.Errorf("failed to create Kafka topic %s: %w", topic, err)
+		}
+	}
+
+	log.Printf("Created Kafka topics: %s", strings.Join(missing, ", "))
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Defaults for the rolling duration summaries written to job_type_stats
+const (
+	defaultTypeStatsInterval = time.Minute
+	defaultTypeStatsWindow   = time.Hour
+)
+
+// typeStatsWriter periodically summarises the processing durations of recently
+// finished jobs per type into the job_type_stats collection, so the stats
+// endpoints read a handful of documents instead of scanning the jobs.
+// Every worker writes the summaries of the types it handles; the result is the
+// same whichever worker writes it.
+type typeStatsWriter struct {
+	jobs     *mongo.Collection
+	stats    *mongo.Collection
+	jobTypes []string
+	// tunables holds the reloadable interval and window
+	tunables func() Tunables
+}
+
+func newTypeStatsWriter(db *mongo.Database, jobTypes []string, tunables func() Tunables) *typeStatsWriter {
+	return &typeStatsWriter{
+		jobs:     db.Collection("jobs"),
+		stats:    db.Collection("job_type_stats"),
+		jobTypes: jobTypes,
+		tunables: tunables,
+	}
+}
+
+// Run refreshes the summaries until ctx is cancelled
+func (s *typeStatsWriter) Run(ctx context.Context) {
+	ticker := newReloadableTicker(func() time.Duration { return s.tunables().TypeStatsInterval })
+	defer ticker.Stop()
+
+	for {
+		if err := s.refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to update job type stats: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			ticker.refresh()
+		}
+	}
+}
+
+func (s *typeStatsWriter) refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	window := s.tunables().TypeStatsWindow
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"job_type":    bson.M{"$in": s.jobTypes},
+			"finished_at": bson.M{"$gte": now.Add(-window)},
+			"duration_ms": bson.M{"$exists": true},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$job_type",
+			"finished": bson.M{"$sum": 1},
+			"avg":      bson.M{"$avg": "$duration_ms"},
+			"max":      bson.M{"$max": "$duration_ms"},
+			"percentiles": bson.M{"$percentile": bson.M{
+				"input":  "$duration_ms",
+				"p":      bson.A{0.5, 0.9, 0.95, 0.99},
+				"method": "approximate",
+			}},
+		}}},
+	}
+
+	cursor, err := s.jobs.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	var rows []struct {
+		JobType     string    `bson:"_id"`
+		Finished    int64     `bson:"finished"`
+		Avg         float64   `bson:"avg"`
+		Max         int64     `bson:"max"`
+		Percentiles []float64 `bson:"percentiles"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return err
+	}
+
+	summaries := make(map[string]bson.M, len(s.jobTypes))
+	for _, jobType := range s.jobTypes {
+		// Types without recent jobs are written too, so stale percentiles don't linger
+		summaries[jobType] = bson.M{"finished": int64(0)}
+	}
+	for _, row := range rows {
+		summary := bson.M{
+			"finished":        row.Finished,
+			"avg_duration_ms": row.Avg,
+			"max_duration_ms": row.Max,
+		}
+		if len(row.Percentiles) == 4 {
+			summary["p50_duration_ms"] = row.Percentiles[0]
+			summary["p90_duration_ms"] = row.Percentiles[1]
+			summary["p95_duration_ms"] = row.Percentiles[2]
+			summary["p99_duration_ms"] = row.Percentiles[3]
+		}
+		summaries[row.JobType] = summary
+	}
+
+	for jobType, summary := range summaries {
+		summary["window_ms"] = window.Milliseconds()
+		summary["updated_at"] = now
+		_, err := s.stats.ReplaceOne(ctx, bson.M{"_id": jobType}, summary, options.Replace().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
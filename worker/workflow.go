@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Workflow step states recorded on the job
+const (
+	StepPending   = "pending"
+	StepRunning   = "running"
+	StepCompleted = "completed"
+	StepFailed    = "failed"
+)
+
+// workflowStepFailureRate is the chance of a simulated workflow step failing
+const workflowStepFailureRate = 0.05
+
+// workflowStep is a step of the workflow an analyze job defines in config.steps
+type workflowStep struct {
+	Name   string
+	Config map[string]interface{}
+}
+
+// stepRecord is the progress of a workflow step recorded on the job
+type stepRecord struct {
+	Name    string `bson:"name"`
+	Status  string `bson:"status"`
+	Attempt int    `bson:"attempt,omitempty"`
+}
+
+// workflowSteps reads the workflow of an analyze job, a list of objects with a
+// name and an optional config. It returns nil if the job defines none.
+func workflowSteps(jobMsg JobMessage) ([]workflowStep, error) {
+	if jobMsg.JobType != JobTypeAnalyze {
+		return nil, nil
+	}
+	raw, ok := jobMsg.Config["steps"]
+	if !ok {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Workflow steps must be a list")
+	}
+
+	steps := make([]workflowStep, 0, len(items))
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Workflow step %d must be an object", i)
+		}
+		step := workflowStep{}
+		step.Name, _ = fields["name"].(string)
+		if step.Name == "" {
+			return nil, fmt.Errorf("Workflow step %d has no name", i)
+		}
+		if config, ok := fields["config"].(map[string]interface{}); ok {
+			step.Config = config
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// config returns the config a step runs with: the job's config without the
+// workflow, overridden by the step's own config
+func (s workflowStep) config(jobMsg JobMessage) map[string]interface{} {
+	config := make(map[string]interface{}, len(jobMsg.Config)+len(s.Config))
+	for key, value := range jobMsg.Config {
+		if key != "steps" {
+			config[key] = value
+		}
+	}
+	for key, value := range s.Config {
+		config[key] = value
+	}
+	return config
+}
+
+// runWorkflow runs the steps of a job's workflow in order, recording the status
+// and duration of each on the job. Steps completed by an earlier attempt are
+// skipped, so a retried job resumes at the step that failed.
+func (w *Worker) runWorkflow(ctx context.Context, jobMsg JobMessage, jobID JobID, attempt int, steps []workflowStep, logf func(format string, args ...interface{})) error {
+	tenant := jobMsg.tenant()
+	records, err := w.recordWorkflow(ctx, jobID, tenant, steps)
+	if err != nil {
+		logf("Failed to record workflow steps: %v", err)
+		return fmt.Errorf("Failed to record workflow steps: %v", err)
+	}
+
+	logf("Running workflow of %d steps", len(steps))
+	for i, step := range steps {
+		if records[i].Status == StepCompleted {
+			logf("Skipping step %q, completed by attempt %d", step.Name, records[i].Attempt)
+			continue
+		}
+
+		logf("Running step %q (%d of %d)", step.Name, i+1, len(steps))
+		startedAt := time.Now()
+		if err := w.startStep(ctx, jobID, tenant, i, attempt, startedAt); err != nil {
+			logf("Failed to record start of step %q: %v", step.Name, err)
+		}
+
+		stepErr := w.limits.Acquire(ctx, jobMsg.JobType)
+		if stepErr == nil {
+			stepErr = runWorkflowStep(ctx, step.config(jobMsg))
+		}
+		if err := w.finishStep(ctx, jobID, tenant, i, startedAt, stepErr); err != nil {
+			logf("Failed to record outcome of step %q: %v", step.Name, err)
+		}
+		if stepErr != nil {
+			logf("Step %q failed: %v", step.Name, stepErr)
+			return fmt.Errorf("Step %q failed: %v", step.Name, stepErr)
+		}
+		logf("Step %q completed in %s", step.Name, time.Since(startedAt).Round(time.Millisecond))
+	}
+	return nil
+}
+
+// runWorkflowStep stands in for the executor of a workflow step, which runs
+// with the step's config
+func runWorkflowStep(ctx context.Context, config map[string]interface{}) error {
+	time.Sleep(checkpointStep)
+	if rand.Float32() < workflowStepFailureRate {
+		return fmt.Errorf("Simulated step failure")
+	}
+	return nil
+}
+
+// recordWorkflow returns the step records of a job, first recording its steps
+// as pending unless an earlier attempt recorded the same steps
+func (w *Worker) recordWorkflow(ctx context.Context, jobID JobID, tenant string, steps []workflowStep) ([]stepRecord, error) {
+	var job struct {
+		Steps []stepRecord `bson:"steps"`
+	}
+	err := w.collection.FindOne(ctx, jobFilter(tenant, jobID), options.FindOne().SetProjection(bson.M{"steps": 1})).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+	if sameSteps(job.Steps, steps) {
+		return job.Steps, nil
+	}
+
+	records := make([]stepRecord, len(steps))
+	for i, step := range steps {
+		records[i] = stepRecord{Name: step.Name, Status: StepPending}
+	}
+	_, err = w.collection.UpdateOne(ctx,
+		processingJob(tenant, jobID),
+		bson.M{"$set": bson.M{"steps": records, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// sameSteps reports whether the recorded steps are those of the workflow
+func sameSteps(records []stepRecord, steps []workflowStep) bool {
+	if len(records) != len(steps) {
+		return false
+	}
+	for i, step := range steps {
+		if records[i].Name != step.Name {
+			return false
+		}
+	}
+	return true
+}
+
+// startStep records a step as running in the given attempt
+func (w *Worker) startStep(ctx context.Context, jobID JobID, tenant string, index, attempt int, startedAt time.Time) error {
+	prefix := fmt.Sprintf("steps.%d.", index)
+	_, err := w.collection.UpdateOne(ctx,
+		processingJob(tenant, jobID),
+		bson.M{
+			"$set": bson.M{
+				prefix + "status":     StepRunning,
+				prefix + "attempt":    attempt,
+				prefix + "started_at": startedAt,
+				"updated_at":          time.Now(),
+			},
+			"$unset": bson.M{
+				prefix + "finished_at":   "",
+				prefix + "duration_ms":   "",
+				prefix + "error_message": "",
+			},
+		},
+	)
+	return err
+}
+
+// finishStep records the outcome of a step that started at startedAt
+func (w *Worker) finishStep(ctx context.Context, jobID JobID, tenant string, index int, startedAt time.Time, stepErr error) error {
+	prefix := fmt.Sprintf("steps.%d.", index)
+	now := time.Now()
+	set := bson.M{
+		prefix + "status":      StepCompleted,
+		prefix + "finished_at": now,
+		prefix + "duration_ms": now.Sub(startedAt).Milliseconds(),
+		"updated_at":           now,
+	}
+	if stepErr != nil {
+		set[prefix+"status"] = StepFailed
+		set[prefix+"error_message"] = stepErr.Error()
+	}
+	_, err := w.collection.UpdateOne(ctx, processingJob(tenant, jobID), bson.M{"$set": set})
+	return err
+}
+
+// processingJob matches a job while it is processing, so steps are not
+// recorded on a job that was cancelled or failed meanwhile
+func processingJob(tenant string, jobID JobID) bson.M {
+	filter := jobFilter(tenant, jobID)
+	filter["status"] = StatusProcessing
+	return filter
+}